@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/avgvstvs96/differential/internal/app"
+	"github.com/avgvstvs96/differential/internal/churn"
+	"github.com/spf13/cobra"
+)
+
+var churnCmd = &cobra.Command{
+	Use:   "churn [range]",
+	Short: "Show a heatmap of how often files changed over a revision range",
+	Long: `Aggregates the diffs of every commit in a revision range (HEAD by default)
+and renders a table of the files or directories with the most changed lines,
+with their churn over time shown as a heatmap.
+
+  differential churn main~100..main
+  differential churn --by dir --depth 2 v1.0..HEAD`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeRevisionArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := buildConfig(cmd)
+		if err != nil {
+			return err
+		}
+
+		revRange := "HEAD"
+		if len(args) == 1 {
+			revRange = args[0]
+		}
+
+		by, _ := cmd.Flags().GetString("by")
+		if by != "file" && by != "dir" {
+			return fmt.Errorf("invalid --by %q (want file or dir)", by)
+		}
+		churnOpts := churn.Options{ByDir: by == "dir"}
+		churnOpts.Depth, _ = cmd.Flags().GetInt("depth")
+		churnOpts.Buckets, _ = cmd.Flags().GetInt("buckets")
+		churnOpts.Top, _ = cmd.Flags().GetInt("top")
+
+		return app.RunChurn(revRange, churnOpts, cfg)
+	},
+}
+
+func init() {
+	churnCmd.Flags().String("by", "file", "Group churn by file or dir")
+	churnCmd.Flags().Int("depth", 1, "Directory levels to group by with --by dir")
+	churnCmd.Flags().Int("buckets", 12, "Number of time columns in the heatmap")
+	churnCmd.Flags().Int("top", 20, "Show only the N most changed entries (0 for all)")
+	rootCmd.AddCommand(churnCmd)
+}
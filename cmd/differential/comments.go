@@ -0,0 +1,30 @@
+package main
+
+import (
+	"github.com/avgvstvs96/differential/internal/app"
+	"github.com/spf13/cobra"
+)
+
+var commentsCmd = &cobra.Command{
+	Use:   "comments",
+	Short: "Export the review comments written in the viewer",
+	Long: `Prints the comments written on lines of the diff in the viewer (key c) for
+the current repository and commit, as markdown or as the body of a GitHub
+create review request.
+
+  differential comments > review.md
+  differential comments --format github --commit-id "$(git rev-parse origin/feature)" |
+    gh api repos/owner/repo/pulls/123/reviews --input -`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("format")
+		commitID, _ := cmd.Flags().GetString("commit-id")
+		return app.RunComments(format, commitID)
+	},
+}
+
+func init() {
+	commentsCmd.Flags().String("format", app.CommentFormatMarkdown, "Output format: markdown or github")
+	commentsCmd.Flags().String("commit-id", "", "Commit the GitHub review comments on, the pull request's head (default HEAD)")
+	rootCmd.AddCommand(commentsCmd)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"github.com/avgvstvs96/differential/internal/git"
+	"github.com/avgvstvs96/differential/internal/themes"
+	"github.com/spf13/cobra"
+)
+
+// completeThemes completes --theme with the names of the available themes
+func completeThemes(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if err := themes.Initialize(); err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	return themes.ListThemes(), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeLanguages completes the languages --language takes
+func completeLanguages(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return themes.Languages(), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeRevisions completes revisions and ranges from the repository's
+// refs. The diff command also takes paths, so they are completed when no
+// ref matches.
+func completeRevisions(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	refs, err := git.CurrentBackend().Refs()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+	matches := git.CompleteRevision(toComplete, append([]string{"HEAD"}, refs...))
+	if len(matches) == 0 {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+	return matches, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeStashes completes stash entries for stash diff
+func completeStashes(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) >= 2 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	stashes, err := git.StashList()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	refs := make([]string, len(stashes))
+	for i, stash := range stashes {
+		refs[i] = stash.Ref + "\t" + stash.Subject
+	}
+	return refs, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeRevisionArgs completes up to n revisions
+func completeRevisionArgs(n int) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) >= n {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		matches, _ := completeRevisions(cmd, args, toComplete)
+		return matches, cobra.ShellCompDirectiveNoFileComp
+	}
+}
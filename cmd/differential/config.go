@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/avgvstvs96/differential/internal/config"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Show and change settings in the config file",
+	Long: `Reads and writes the config file, ~/.config/differential/config.toml unless
+--config names another one. Settings are named section.name, as listed by
+config list.
+
+  differential config init
+  differential config set ui.theme nord
+  differential config get ui.theme`,
+}
+
+var configGetCmd = &cobra.Command{
+	Use:               "get <setting>",
+	Short:             "Print the value of a setting",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeSettings,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := buildConfig(cmd)
+		if err != nil {
+			return err
+		}
+		value, err := cfg.Get(args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), value)
+		return nil
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <setting> <value>",
+	Short: "Change a setting in the config file",
+	Long: `Changes a setting in the config file, creating the file if needed. The rest of
+the file, comments included, is left as it is. Lists are given separated by
+commas.
+
+  differential config set ui.theme nord
+  differential config set filters.exclude '*.lock,vendor/**'`,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeSettings,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := configFilePath()
+		if err != nil {
+			return err
+		}
+		return config.SetInFile(path, args[0], args[1])
+	},
+}
+
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Print every setting with its value",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := buildConfig(cmd)
+		if err != nil {
+			return err
+		}
+		for _, key := range config.Keys() {
+			value, err := cfg.Get(key)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "%s = %s\n", key, value)
+		}
+		return nil
+	},
+}
+
+var configInitCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Write a config file holding the defaults, with comments",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := configFilePath()
+		if err != nil {
+			return err
+		}
+		force, _ := cmd.Flags().GetBool("force")
+		if err := config.WriteDefault(path, force); err != nil {
+			return err
+		}
+		fmt.Fprintln(cmd.ErrOrStderr(), "Wrote", path)
+		return nil
+	},
+}
+
+// configFilePath returns the config file in use, or where it goes when
+// there is none yet
+func configFilePath() (string, error) {
+	if cfgFile != "" {
+		return cfgFile, nil
+	}
+	if used := viper.ConfigFileUsed(); used != "" {
+		return used, nil
+	}
+	if path := config.NewConfig().ConfigPath(); path != "" {
+		return path, nil
+	}
+	return "", fmt.Errorf("no home directory for the config file; pass --config")
+}
+
+// completeSettings completes the setting names of the config file
+func completeSettings(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var keys []string
+	for _, key := range config.Keys() {
+		if strings.HasPrefix(key, toComplete) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	configInitCmd.Flags().Bool("force", false, "Overwrite the config file if it exists")
+
+	configCmd.AddCommand(configGetCmd, configSetCmd, configListCmd, configInitCmd)
+	rootCmd.AddCommand(configCmd)
+}
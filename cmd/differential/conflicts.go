@@ -0,0 +1,30 @@
+package main
+
+import (
+	"github.com/avgvstvs96/differential/internal/app"
+	"github.com/spf13/cobra"
+)
+
+var conflictsCmd = &cobra.Command{
+	Use:   "conflicts <ours> <theirs>",
+	Short: "Preview the conflicts a merge would produce",
+	Long: `Performs an in-memory merge of two revisions (git merge-tree) and renders
+the files and hunks that would conflict, without touching the worktree.
+Conflicts with a resolution recorded by git rerere show that resolution, and
+during a merge in progress it can be applied to the worktree.
+
+  differential conflicts main feature/login`,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeRevisionArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := buildConfig(cmd)
+		if err != nil {
+			return err
+		}
+		return app.RunConflicts(args[0], args[1], cfg)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(conflictsCmd)
+}
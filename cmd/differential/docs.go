@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/cobra/doc"
+)
+
+var docsCmd = &cobra.Command{
+	Use:    "docs",
+	Short:  "Generate documentation",
+	Hidden: true,
+}
+
+var docsManCmd = &cobra.Command{
+	Use:   "man [dir]",
+	Short: "Generate man pages for differential and its subcommands",
+	Long: `Writes a man page for each command to dir (man by default), e.g.
+differential.1 and differential-stash-diff.1.
+
+  differential docs man /usr/local/share/man/man1`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := "man"
+		if len(args) == 1 {
+			dir = args[0]
+		}
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", dir, err)
+		}
+
+		rootCmd.DisableAutoGenTag = true
+		header := &doc.GenManHeader{Title: "DIFFERENTIAL", Section: "1", Source: "differential " + version}
+		if err := doc.GenManTree(rootCmd, header, dir); err != nil {
+			return fmt.Errorf("failed to generate man pages: %w", err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	docsCmd.AddCommand(docsManCmd)
+	rootCmd.AddCommand(docsCmd)
+}
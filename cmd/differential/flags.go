@@ -0,0 +1,309 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/avgvstvs96/differential/internal/app"
+	"github.com/avgvstvs96/differential/internal/config"
+	"github.com/avgvstvs96/differential/internal/diff"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// options holds the values of the root command's flags. Flags with a config
+// file setting only override it when they are set explicitly.
+type options struct {
+	// Display
+	theme        string
+	sideBySide   bool
+	lineNumbers  bool
+	dimContext   bool
+	invisibles   bool
+	plainColumns bool
+	accessible   bool
+	style        string
+	hunkStats    bool
+	whitespace   string
+	ignored      string
+	intraline    string
+	colorMoved   bool
+	semantic     bool
+	tokens       string
+	language     string
+	encoding     string
+	listThemes   bool
+	fresh        bool
+
+	// Git
+	context       int
+	ignoreCRAtEOL bool
+	untracked     bool
+	gitArgs       []string
+	include       []string
+	exclude       []string
+	ignoreLines   []string
+	searchChange  string
+	searchRegex   bool
+	lineRanges    []string
+	lintCommits   bool
+
+	// Input
+	snippet       bool
+	fromClipboard bool
+	delimiter     string
+	session       string
+
+	// Output
+	pipeMode      bool
+	pagerMode     bool
+	noPager       bool
+	output        string
+	force         bool
+	print         bool
+	stat          bool
+	matches       bool
+	script        string
+	format        string
+	maxFiles      int
+	maxLines      int
+	timeout       time.Duration
+	deterministic bool
+	ci            bool
+	saveSession   string
+}
+
+var opts options
+
+// Flag groups, in the order help lists them
+const (
+	groupDisplay = "Display"
+	groupGit     = "Git"
+	groupInput   = "Input"
+	groupOutput  = "Output"
+)
+
+var flagGroups = []string{groupDisplay, groupGit, groupInput, groupOutput}
+
+// flagGroupAnnotation marks the help group of a flag
+const flagGroupAnnotation = "differential_flag_group"
+
+// defineFlags adds the root command's flags, bound to opts
+func defineFlags(cmd *cobra.Command) {
+	persistent, local := cmd.PersistentFlags(), cmd.Flags()
+
+	persistent.StringVar(&cfgFile, "config", "", "config file (default is $HOME/.config/differential/config.toml)")
+
+	persistent.StringVarP(&opts.theme, "theme", "t", "dracula", "Color theme to use")
+	persistent.BoolVarP(&opts.sideBySide, "side-by-side", "s", false, "Show diff in side-by-side view")
+	persistent.BoolVarP(&opts.lineNumbers, "line-numbers", "n", true, "Show line numbers")
+	persistent.BoolVar(&opts.dimContext, "dim-context", false, "Dim unchanged context lines so changes stand out")
+	persistent.BoolVar(&opts.invisibles, "show-invisibles", false, "Mark tabs, spaces and trailing whitespace, and show control, zero-width and bidi characters")
+	persistent.BoolVar(&opts.plainColumns, "plain-columns", false, "Separate side-by-side columns with ASCII markers and no background padding, for copying as plain text")
+	persistent.StringVar(&opts.style, "style", "background", "Show changed lines on the diff backgrounds, in the diff colors, or with colored markers only: background, foreground or minimal")
+	persistent.BoolVar(&opts.accessible, "accessibility", false, "Mark changes with underlines and bars as well as color")
+	persistent.BoolVar(&opts.hunkStats, "hunk-stats", false, "Count added, removed and modified lines in hunk headers")
+	persistent.StringVar(&opts.whitespace, "whitespace-changes", "show", "Show changes that only touch whitespace as usual, with a badge, or hide them: show, badge or hide")
+	persistent.StringVar(&opts.ignored, "ignored-changes", "dim", "Dim or hide the changes matching --ignore-matching-lines: dim or hide")
+	persistent.StringVar(&opts.intraline, "intraline", "character", "Mark changes within lines by character, word, or token of the file's language")
+	persistent.BoolVar(&opts.colorMoved, "color-moved", false, "Color blocks removed in one place and added in another as moved")
+	persistent.BoolVar(&opts.fresh, "fresh", false, "Ignore the UI state saved by previous sessions")
+	persistent.StringVar(&opts.language, "language", "", "Highlight every file as this language, e.g. go or html, when file names don't tell it")
+	persistent.StringVar(&opts.encoding, "encoding", "", "Read every file in this encoding, e.g. latin-1 or utf-16le, instead of detecting it")
+	local.BoolVar(&opts.semantic, "semantic", false, "Diff JSON/YAML/TOML files by structure instead of lines")
+	local.StringVar(&opts.tokens, "tokens", "", "Highlight with semantic tokens from a JSON file instead of chroma, e.g. from an editor")
+	local.BoolVar(&opts.listThemes, "list-themes", false, "List available themes")
+	setFlagGroup(groupDisplay, persistent, "theme", "side-by-side", "line-numbers", "dim-context", "show-invisibles", "plain-columns", "style", "accessibility", "hunk-stats", "whitespace-changes", "ignored-changes", "intraline", "color-moved", "language", "encoding", "fresh")
+	setFlagGroup(groupDisplay, local, "semantic", "tokens", "list-themes")
+
+	persistent.IntVarP(&opts.context, "context", "c", 3, "Number of context lines to show")
+	persistent.BoolVar(&opts.ignoreCRAtEOL, "ignore-cr-at-eol", false, "Ignore line ending changes between LF and CRLF")
+	persistent.StringSliceVar(&opts.include, "include", nil, "Only show files matching these globs (repeatable)")
+	persistent.StringSliceVar(&opts.exclude, "exclude", nil, "Skip files matching these globs, e.g. '*.pb.go' (repeatable)")
+	persistent.StringArrayVarP(&opts.ignoreLines, "ignore-matching-lines", "I", nil, "Ignore changes whose removed and added lines all match this regular expression, like diff -I (repeatable)")
+	persistent.BoolVar(&opts.lintCommits, "lint-commits", false, "Lint commit messages in git log/show output")
+	local.BoolVar(&opts.untracked, "untracked", false, "Show untracked files that aren't ignored as added, with the unstaged changes")
+	local.StringArrayVar(&opts.gitArgs, "git-arg", nil, "Pass an option to git diff, e.g. --git-arg=--find-copies-harder (repeatable)")
+	local.StringVar(&opts.searchChange, "search-change", "", "Only show changes adding or removing this string, highlighting it (git -S)")
+	local.BoolVar(&opts.searchRegex, "search-regex", false, "Treat --search-change as a regular expression (git -G)")
+	local.StringArrayVarP(&opts.lineRanges, "line-range", "L", nil, "Show the history of a function or line range, e.g. -L :main:cmd/main.go or -L 10,20:README.md (repeatable)")
+	setFlagGroup(groupGit, persistent, "context", "ignore-cr-at-eol", "include", "exclude", "ignore-matching-lines", "lint-commits")
+	setFlagGroup(groupGit, local, "untracked", "git-arg", "search-change", "search-regex", "line-range")
+
+	local.BoolVar(&opts.snippet, "snippet", false, "Diff two text blocks from stdin separated by a delimiter line")
+	local.BoolVar(&opts.fromClipboard, "from-clipboard", false, "Read the snippet blocks from the clipboard (implies --snippet)")
+	local.StringVar(&opts.delimiter, "delimiter", app.DefaultSnippetDelimiter, "Line separating the two snippet blocks")
+	local.StringVar(&opts.session, "session", "", "Resume the review saved in a session file by --save-session, instead of running git")
+	setFlagGroup(groupInput, local, "snippet", "from-clipboard", "delimiter", "session")
+
+	persistent.BoolVarP(&opts.pipeMode, "pipe-mode", "p", false, "Force pipe mode (non-interactive)")
+	persistent.BoolVar(&opts.deterministic, "deterministic", false, "Render the same output whatever the terminal and environment, for snapshot tests")
+	persistent.BoolVar(&opts.ci, "ci", false, "Render for CI logs: deterministic, 120 columns wide and followed by a summary line (default true when CI=true)")
+	local.BoolVar(&opts.noPager, "no-pager", false, "Disable pager for output")
+	local.BoolVar(&opts.pagerMode, "pager-mode", false, `Render git's output as it arrives, for use as git's core.pager; output that isn't a diff passes through`)
+	local.StringVarP(&opts.output, "output", "o", "", "Write the diff to a file instead of the terminal; .html, .ansi or plain text by extension")
+	local.BoolVar(&opts.force, "force", false, "Overwrite the --output file if it exists")
+	local.BoolVar(&opts.print, "print", false, "Render for printing: dark text on no background, independent of the theme")
+	local.BoolVar(&opts.stat, "stat", false, "Print per-file addition and deletion counts instead of the diff")
+	local.BoolVar(&opts.matches, "matches", false, "Print which removed line each added line replaced, with their similarity, as JSON instead of the diff")
+	local.StringVar(&opts.script, "script", "", `Take viewer actions without a terminal and print what each did, e.g. "next-hunk;stage;next-file;quit"`)
+	local.StringVar(&opts.format, "format", app.StatFormatText, "Output format for --stat: text, json or csv")
+	local.IntVar(&opts.maxFiles, "max-files", 0, "Render at most this many files, noting the rest in a footer (0 for no limit)")
+	local.IntVar(&opts.maxLines, "max-lines", 0, "Render at most this many diff lines, noting the rest in a footer (0 for no limit)")
+	local.StringVar(&opts.saveSession, "save-session", "", "Save the diff and the review state (collapsed files, comments, approvals) to a file on quit, e.g. review.dsp")
+	local.DurationVar(&opts.timeout, "timeout", 0, "Stop rendering after this long, e.g. 5s, keeping the files done so far (0 for no limit)")
+	setFlagGroup(groupOutput, persistent, "pipe-mode", "deterministic", "ci")
+	setFlagGroup(groupOutput, local, "no-pager", "pager-mode", "output", "force", "print", "stat", "matches", "script", "format", "max-files", "max-lines", "timeout", "save-session")
+
+	cobra.AddTemplateFunc("groupedFlagUsages", groupedFlagUsages)
+	cmd.SetUsageTemplate(strings.Replace(cmd.UsageTemplate(),
+		"\n\nFlags:\n{{.LocalFlags.FlagUsages | trimTrailingWhitespaces}}",
+		"{{groupedFlagUsages .LocalFlags}}", 1))
+}
+
+// setFlagGroup puts flags in a help group
+func setFlagGroup(group string, flags *pflag.FlagSet, names ...string) {
+	for _, name := range names {
+		flags.SetAnnotation(name, flagGroupAnnotation, []string{group})
+	}
+}
+
+// groupedFlagUsages lists flags under a heading for each group, with the
+// flags outside of groups (such as --help) last
+func groupedFlagUsages(flags *pflag.FlagSet) string {
+	var sb strings.Builder
+	for _, group := range append(flagGroups, "") {
+		set := pflag.NewFlagSet(group, pflag.ContinueOnError)
+		flags.VisitAll(func(flag *pflag.Flag) {
+			if name := flag.Annotations[flagGroupAnnotation]; len(name) > 0 && name[0] == group || len(name) == 0 && group == "" {
+				set.AddFlag(flag)
+			}
+		})
+		if !set.HasAvailableFlags() {
+			continue
+		}
+		title := "Flags"
+		if group != "" {
+			title = group + " Flags"
+		}
+		sb.WriteString("\n\n" + title + ":\n" + strings.TrimRight(set.FlagUsages(), " \n"))
+	}
+	return sb.String()
+}
+
+// apply sets the configuration from the flags the user set on cmd
+func (o *options) apply(cmd *cobra.Command, cfg *config.Config) error {
+	flags := cmd.Flags()
+	if flags.Changed("theme") {
+		cfg.UI.Theme = o.theme
+	}
+	if o.sideBySide {
+		cfg.UI.DefaultView = "side-by-side"
+	}
+	if flags.Changed("line-numbers") {
+		cfg.UI.LineNumbers = o.lineNumbers
+	}
+	if flags.Changed("dim-context") {
+		cfg.UI.DimContext = o.dimContext
+	}
+	if flags.Changed("show-invisibles") {
+		cfg.UI.ShowInvisibles = o.invisibles
+	}
+	if flags.Changed("plain-columns") {
+		cfg.UI.PlainColumns = o.plainColumns
+	}
+	if flags.Changed("style") {
+		cfg.UI.Style = o.style
+	}
+	if flags.Changed("accessibility") {
+		cfg.UI.Accessibility = o.accessible
+	}
+	if flags.Changed("hunk-stats") {
+		cfg.UI.HunkStats = o.hunkStats
+	}
+	if flags.Changed("whitespace-changes") {
+		cfg.UI.WhitespaceChanges = o.whitespace
+	}
+	if flags.Changed("ignored-changes") {
+		cfg.UI.IgnoredChanges = o.ignored
+	}
+	if flags.Changed("color-moved") {
+		cfg.UI.ColorMoved = o.colorMoved
+	}
+	if flags.Changed("intraline") {
+		cfg.UI.IntralineGranularity = o.intraline
+	}
+	if o.semantic {
+		cfg.UI.SemanticDiff = true
+	}
+	cfg.TokensFile = o.tokens
+	if o.language != "" {
+		cfg.Language = o.language
+	}
+	if o.encoding != "" {
+		name, err := diff.EncodingName(o.encoding)
+		if err != nil {
+			return fmt.Errorf("invalid --encoding: %w", err)
+		}
+		cfg.Encoding = name
+	}
+
+	if flags.Changed("context") {
+		cfg.Git.DefaultContext = o.context
+	}
+	if flags.Changed("ignore-cr-at-eol") {
+		cfg.Git.IgnoreCRAtEOL = o.ignoreCRAtEOL
+	}
+	if flags.Changed("untracked") {
+		cfg.Git.Untracked = o.untracked
+	}
+	cfg.Git.ExtraArgs = append(cfg.Git.ExtraArgs, o.gitArgs...)
+	cfg.Filters.Include = append(cfg.Filters.Include, o.include...)
+	cfg.Filters.Exclude = append(cfg.Filters.Exclude, o.exclude...)
+	for _, pattern := range o.ignoreLines {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid --ignore-matching-lines pattern: %w", err)
+		}
+	}
+	cfg.Filters.IgnoreMatchingLines = append(cfg.Filters.IgnoreMatchingLines, o.ignoreLines...)
+
+	if o.lintCommits {
+		cfg.Lint.Commits = true
+	}
+	cfg.Search.Pattern, cfg.Search.Regex = o.searchChange, o.searchRegex
+	if cfg.Search.Regex {
+		if _, err := regexp.Compile(cfg.Search.Pattern); err != nil {
+			return fmt.Errorf("invalid --search-change pattern: %w", err)
+		}
+	}
+
+	if o.output != "" {
+		cfg.Output.Path, cfg.Output.Force = o.output, o.force
+	}
+	cfg.Output.Print = o.print
+	if o.maxFiles < 0 || o.maxLines < 0 || o.timeout < 0 {
+		return fmt.Errorf("--max-files, --max-lines and --timeout must not be negative")
+	}
+	cfg.Limits = config.LimitsConfig{MaxFiles: o.maxFiles, MaxLines: o.maxLines, Timeout: o.timeout}
+	cfg.Deterministic = o.deterministic
+
+	// A resumed session is saved back to its file unless told otherwise
+	cfg.SessionFile = o.saveSession
+	if cfg.SessionFile == "" {
+		cfg.SessionFile = o.session
+	}
+
+	// CI services set CI=true; --ci=false turns CI mode off there
+	cfg.CI = o.ci
+	if !flags.Changed("ci") {
+		cfg.CI = os.Getenv("CI") == "true"
+	}
+	if cfg.CI {
+		cfg.Deterministic = true
+		cfg.Output.Plain = os.Getenv("NO_COLOR") != ""
+	}
+	return nil
+}
@@ -1,14 +1,23 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
+	"path/filepath"
 
 	"github.com/avgvstvs96/differential/internal/app"
 	"github.com/avgvstvs96/differential/internal/config"
 	"github.com/avgvstvs96/differential/internal/diff"
+	"github.com/avgvstvs96/differential/internal/git"
+	"github.com/avgvstvs96/differential/internal/state"
 	"github.com/avgvstvs96/differential/internal/themes"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/muesli/termenv"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -16,10 +25,17 @@ import (
 var (
 	version = "0.1.0"
 	cfgFile string
+
+	// configErr holds why the config file couldn't be read
+	configErr error
+
+	// configFiles are the config files read, the user's then the
+	// repository's
+	configFiles []string
 )
 
 var rootCmd = &cobra.Command{
-	Use:   "differential [file1] [file2]",
+	Use:   "differential [file1] [file2] [file3]",
 	Short: "A beautiful TUI for file diffing with syntax highlighting",
 	Long: `Differential is a terminal UI for viewing diffs with syntax highlighting,
 character-level changes, and interactive navigation.
@@ -27,26 +43,32 @@ character-level changes, and interactive navigation.
 It can be used as a drop-in replacement for git diff:
   git diff | differential
   differential file1.go file2.go
-  differential HEAD~3 HEAD`,
-	RunE: runDiff,
+  differential HEAD~3 HEAD
+
+Passing three files renders a three-way diff of ours and theirs against base:
+  differential base.go ours.go theirs.go`,
+	Args:              cobra.ArbitraryArgs,
+	ValidArgsFunction: completeRevisions,
+	RunE:              runDiff,
 }
 
 func init() {
 	cobra.OnInitialize(initConfig)
 
-	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.config/differential/config.toml)")
-	rootCmd.Flags().StringP("theme", "t", "dracula", "Color theme to use")
-	rootCmd.Flags().BoolP("side-by-side", "s", false, "Show diff in side-by-side view")
-	rootCmd.Flags().BoolP("line-numbers", "n", true, "Show line numbers")
-	rootCmd.Flags().IntP("context", "c", 3, "Number of context lines to show")
-	rootCmd.Flags().BoolP("list-themes", "", false, "List available themes")
-	rootCmd.Flags().BoolP("no-pager", "", false, "Disable pager for output")
-	rootCmd.Flags().BoolP("pipe-mode", "p", false, "Force pipe mode (non-interactive)")
+	defineFlags(rootCmd)
+	rootCmd.RegisterFlagCompletionFunc("theme", completeThemes)
+	rootCmd.RegisterFlagCompletionFunc("language", completeLanguages)
 
+	viper.BindPFlags(rootCmd.PersistentFlags())
 	viper.BindPFlags(rootCmd.Flags())
 }
 
 func initConfig() {
+	// Deterministic output only depends on a config file given explicitly
+	if opts.deterministic && cfgFile == "" {
+		return
+	}
+
 	if cfgFile != "" {
 		viper.SetConfigFile(cfgFile)
 	} else {
@@ -61,27 +83,133 @@ func initConfig() {
 	viper.AutomaticEnv()
 	viper.SetEnvPrefix("DIFFERENTIAL")
 
-	if err := viper.ReadInConfig(); err == nil {
-		fmt.Println("Using config file:", viper.ConfigFileUsed())
+	// A missing config file is fine, but a broken one is reported by
+	// buildConfig rather than ignored
+	if err := viper.ReadInConfig(); err != nil {
+		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
+			configErr = fmt.Errorf("failed to read config file: %w", err)
+		}
+	} else {
+		configFiles = append(configFiles, viper.ConfigFileUsed())
+	}
+
+	// Settings shared by the repository override the user's
+	if root, err := git.Root(); err == nil && !opts.deterministic && configErr == nil {
+		configErr = mergeRepoConfig(filepath.Join(root, config.RepoFile))
 	}
 }
 
-func runDiff(cmd *cobra.Command, args []string) error {
+// mergeRepoConfig merges the settings of a repository's config file over
+// the user's, warning about the ones a repository can't set
+func mergeRepoConfig(path string) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	settings, ignored, err := config.RepoSettings(data)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	for _, key := range ignored {
+		fmt.Fprintf(os.Stderr, "warning: %s: ignoring %s, which only the user config can set\n", path, key)
+	}
+	configFiles = append(configFiles, path)
+	return viper.MergeConfigMap(settings)
+}
+
+// buildConfig creates the configuration from the defaults, the config file
+// and finally the CLI flags that were set explicitly
+func buildConfig(cmd *cobra.Command) (*config.Config, error) {
+	if configErr != nil {
+		return nil, configErr
+	}
 	cfg := config.NewConfig()
 
+	// Apply the config file on top of the defaults
+	if err := viper.Unmarshal(cfg, func(dc *mapstructure.DecoderConfig) {
+		dc.TagName = "toml"
+	}); err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// viper splits keys at dots and lowercases them, which globs and theme
+	// keys don't survive, so the languages and theme overrides tables are
+	// read from the files
+	for _, path := range configFiles {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		languages, err := config.ParseLanguages(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %s: %w", path, err)
+		}
+		for pattern, language := range languages {
+			if cfg.Languages == nil {
+				cfg.Languages = make(map[string]string)
+			}
+			cfg.Languages[pattern] = language
+		}
+		overrides, err := config.ParseThemeOverrides(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %s: %w", path, err)
+		}
+		for key, color := range overrides {
+			if cfg.ThemeOverrides == nil {
+				cfg.ThemeOverrides = make(map[string]string)
+			}
+			cfg.ThemeOverrides[key] = color
+		}
+	}
+
 	// Apply CLI flags
-	if theme, _ := cmd.Flags().GetString("theme"); theme != "" {
-		cfg.UI.Theme = theme
+	if err := opts.apply(cmd, cfg); err != nil {
+		return nil, err
+	}
+	if cfg.Deterministic {
+		// Always use truecolor on a dark background, whatever the terminal,
+		// without asking the terminal for its colors
+		themes.SetDark(true)
+		lipgloss.SetColorProfile(termenv.TrueColor)
+	}
+
+	if err := git.SetBackend(cfg.Git.Backend); err != nil {
+		return nil, fmt.Errorf("invalid git config: %w", err)
 	}
-	if sideBySide, _ := cmd.Flags().GetBool("side-by-side"); sideBySide {
-		cfg.UI.DefaultView = "side-by-side"
+	// The viewer picks the tint from the terminal's background when it
+	// starts, see runModel
+	alpha := cfg.UI.DiffBackgroundAlpha
+	if alpha == 0 {
+		alpha = themes.DefaultDiffBackgroundAlpha
 	}
-	if lineNumbers, _ := cmd.Flags().GetBool("line-numbers"); !lineNumbers {
-		cfg.UI.LineNumbers = false
+	if err := themes.Default().SetDiffBackgroundAlpha(alpha); err != nil {
+		return nil, fmt.Errorf("invalid ui config: %w", err)
+	}
+	if err := themes.Default().SetOverrides(cfg.ThemeOverrides); err != nil {
+		return nil, fmt.Errorf("invalid theme config: %w", err)
+	}
+
+	return cfg, nil
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	cfg, err := buildConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	// Arguments after "--" go to git diff as they are
+	if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+		cfg.Git.Passthrough = args[dash:]
+		args = args[:dash]
 	}
 
 	// List themes mode
-	if listThemes, _ := cmd.Flags().GetBool("list-themes"); listThemes {
+	if opts.listThemes {
 		// Initialize themes first to get the actual list
 		if err := themes.Initialize(); err != nil {
 			return fmt.Errorf("failed to initialize themes: %w", err)
@@ -99,8 +227,9 @@ func runDiff(cmd *cobra.Command, args []string) error {
 		
 		themeList := themes.ListThemes()
 		for _, themeName := range themeList {
-			// Set the theme
-			if err := themes.SetTheme(themeName); err != nil {
+			// Resolve the theme without activating it
+			theme, err := themes.Default().Resolve(themeName)
+			if err != nil {
 				continue
 			}
 			
@@ -113,32 +242,89 @@ func runDiff(cmd *cobra.Command, args []string) error {
 				continue
 			}
 			
-			opts := diff.RenderOptions{
+			renderOpts := diff.RenderOptions{
 				Width:           80,
 				ShowLineNumbers: true,
 				ViewMode:        diff.ViewUnified,
+				Theme:           theme,
 			}
 			
-			output := diff.RenderUnifiedDiff(result, opts)
+			output := diff.RenderUnifiedDiff(result, renderOpts)
 			fmt.Print(output)
 		}
 		fmt.Println()
 		return nil
 	}
 
+	// Pager mode - render git's output as git pages it through us
+	if opts.pagerMode {
+		if stat, _ := os.Stdin.Stat(); stat.Mode()&os.ModeCharDevice != 0 {
+			return fmt.Errorf("--pager-mode reads git's output from stdin; set it as core.pager")
+		}
+		return app.RunPagerMode(cmd.Context(), os.Stdin, opts.noPager, cfg)
+	}
+
+	// Saved session - resume the review as it was left
+	if opts.session != "" {
+		restoreState(cmd, cfg)
+		return app.RunSession(cmd.Context(), opts.session, cfg)
+	}
+
+	// Snippet mode
+	if opts.snippet || opts.fromClipboard {
+		return app.RunSnippet(cmd.Context(), os.Stdin, opts.fromClipboard, opts.pipeMode, opts.delimiter, cfg)
+	}
+
+	// Patch files - show each patch of the series below its commit message
+	if patches, ok := app.PatchFiles(append(args[:len(args):len(args)], cfg.Git.Passthrough...)); ok {
+		pipeMode := opts.pipeMode || cfg.Output.Path != "" || cfg.CI
+		if !pipeMode {
+			restoreState(cmd, cfg)
+		}
+		return app.RunPatches(cmd.Context(), patches, pipeMode, cfg)
+	}
+
+	// Three files - render a three-way diff against the base
+	if app.IsThreeWay(args) {
+		return app.RunThreeWay(args[0], args[1], args[2], cfg)
+	}
+
+	// Structured files - diff keys and values instead of lines
+	if cfg.UI.SemanticDiff && len(args) == 2 {
+		if handled, err := app.RunSemanticDiff(args[0], args[1], cfg); handled || err != nil {
+			return err
+		}
+	}
+
+	// Line ranges - show the history of a function or lines
+	if len(opts.lineRanges) > 0 {
+		return app.RunLineLog(cmd.Context(), opts.lineRanges, args, cfg)
+	}
+
+	// Scripted viewer - take the script's actions instead of key presses
+	if opts.script != "" {
+		return app.RunScript(cmd.Context(), args, opts.script, cfg)
+	}
+
 	// Determine mode
 	isPipeMode := false
 	var input io.Reader
 
-	// Check if stdin has data
+	// Check if stdin has data. CI jobs often run with stdin that isn't a
+	// terminal but holds nothing, so there arguments take precedence.
 	stat, _ := os.Stdin.Stat()
-	if (stat.Mode() & os.ModeCharDevice) == 0 {
+	if (stat.Mode()&os.ModeCharDevice) == 0 && !(cfg.CI && len(args) > 0) {
 		isPipeMode = true
 		input = os.Stdin
 	}
 
-	// Force pipe mode flag
-	if forceMode, _ := cmd.Flags().GetBool("pipe-mode"); forceMode {
+	if input != nil && (cmd.Flags().Changed("git-arg") || len(cfg.Git.Passthrough) > 0) {
+		fmt.Fprintln(os.Stderr, "warning: git diff arguments are ignored when reading a diff from stdin")
+	}
+
+	// Force pipe mode flag; output files and CI logs are always rendered in
+	// pipe mode
+	if opts.pipeMode || cfg.Output.Path != "" || cfg.CI {
 		isPipeMode = true
 		// If no stdin input but files provided, we'll generate diff in RunPipeMode
 		if input == nil && len(args) > 0 {
@@ -146,18 +332,93 @@ func runDiff(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// Stats only - print counts and exit
+	if opts.stat {
+		return app.RunStat(cmd.Context(), input, cfg, args, opts.format)
+	}
+	if opts.matches {
+		return app.RunMatches(cmd.Context(), input, cfg, args)
+	}
+
 	if isPipeMode {
 		// Pipe mode - render diff and exit
-		return app.RunPipeMode(input, cfg, args)
+		return app.RunPipeMode(cmd.Context(), input, cfg, args)
 	}
 
 	// TUI mode
-	return app.RunTUIMode(args, cfg)
+	restoreState(cmd, cfg)
+	return app.RunTUIMode(cmd.Context(), args, cfg)
+}
+
+// restoreState applies the UI state saved by the last TUI session, unless
+// --fresh is set. Saved state only fills in settings left at their defaults:
+// flags given explicitly and settings from the config files take precedence.
+func restoreState(cmd *cobra.Command, cfg *config.Config) {
+	if opts.fresh {
+		return
+	}
+
+	cfg.StateFile = state.DefaultPath()
+	saved, err := state.Load(cfg.StateFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ignoring saved state: %v\n", err)
+		return
+	}
+
+	// configured reports whether the flag, if any, or the config files set
+	// key
+	configured := func(flag, key string) bool {
+		return (flag != "" && cmd.Flags().Changed(flag)) || viper.IsSet(key)
+	}
+	if saved.Theme != "" && !configured("theme", "ui.theme") {
+		cfg.UI.Theme = saved.Theme
+	}
+	if saved.ViewMode != "" && !configured("side-by-side", "ui.default_view") {
+		cfg.UI.DefaultView = saved.ViewMode
+	}
+	if saved.LineNumbers != nil && !configured("line-numbers", "ui.line_numbers") {
+		cfg.UI.LineNumbers = *saved.LineNumbers
+	}
+	if saved.DimContext != nil && !configured("dim-context", "ui.dim_context") {
+		cfg.UI.DimContext = *saved.DimContext
+	}
+	if saved.WrapLines != nil && !configured("", "ui.wrap_lines") {
+		cfg.UI.WrapLines = *saved.WrapLines
+	}
+	if saved.Gutter != "" && !configured("", "gutter.mode") {
+		cfg.Gutter.Mode = saved.Gutter
+	}
 }
 
 func main() {
-	if err := rootCmd.Execute(); err != nil {
+	// Ctrl+C cancels git, parsing and rendering instead of killing the
+	// process halfway through writing the terminal
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	quietOnCancel(rootCmd)
+	err := rootCmd.ExecuteContext(ctx)
+	stop()
+	if errors.Is(err, context.Canceled) {
+		os.Exit(130)
+	}
+	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
-}
\ No newline at end of file
+}
+
+// quietOnCancel keeps cobra from printing the error and usage of cmd and its
+// subcommands when they are interrupted; main exits with 130 instead
+func quietOnCancel(cmd *cobra.Command) {
+	if run := cmd.RunE; run != nil {
+		cmd.RunE = func(cmd *cobra.Command, args []string) error {
+			err := run(cmd, args)
+			if errors.Is(err, context.Canceled) {
+				cmd.SilenceErrors, cmd.SilenceUsage = true, true
+			}
+			return err
+		}
+	}
+	for _, sub := range cmd.Commands() {
+		quietOnCancel(sub)
+	}
+}
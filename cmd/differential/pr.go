@@ -0,0 +1,37 @@
+package main
+
+import (
+	"github.com/avgvstvs96/differential/internal/app"
+	"github.com/spf13/cobra"
+)
+
+var prCmd = &cobra.Command{
+	Use:   "pr <url|number>",
+	Short: "Review a GitHub pull request or GitLab merge request",
+	Long: `Fetches the diff of a pull request through the GitHub or GitLab API and opens
+it in the viewer, below its title and branches. A bare number refers to a
+pull request of the repository of the origin remote.
+
+Private repositories need a token in GITHUB_TOKEN (or GH_TOKEN) for GitHub
+and GITLAB_TOKEN for GitLab.
+
+  differential pr https://github.com/owner/repo/pull/123
+  differential pr https://gitlab.com/group/project/-/merge_requests/45
+  differential pr 123`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := buildConfig(cmd)
+		if err != nil {
+			return err
+		}
+		pipeMode := opts.pipeMode || cfg.CI
+		if !pipeMode {
+			restoreState(cmd, cfg)
+		}
+		return app.RunPR(cmd.Context(), args[0], pipeMode, cfg)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(prCmd)
+}
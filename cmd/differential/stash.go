@@ -0,0 +1,52 @@
+package main
+
+import (
+	"github.com/avgvstvs96/differential/internal/app"
+	"github.com/spf13/cobra"
+)
+
+var stashCmd = &cobra.Command{
+	Use:   "stash",
+	Short: "List stash entries to view, apply, pop or drop",
+	Long: `Lists the stash entries. Enter shows the changes an entry records, as
+git stash show -p prints them, and esc returns to the list. a, p and d
+apply, pop and drop the selected entry after asking to confirm.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := buildConfig(cmd)
+		if err != nil {
+			return err
+		}
+		restoreState(cmd, cfg)
+		return app.RunStashes(cmd.Context(), cfg)
+	},
+}
+
+var stashDiffCmd = &cobra.Command{
+	Use:   "diff [stash] [stash]",
+	Short: "Diff two stash entries, or a stash entry against the worktree",
+	Long: `Compares two stash entries, or a single stash entry against the current
+worktree. Entries may be given as stash@{n} or as a bare index. Without
+arguments, a picker lists the stash entries to choose from.
+
+  differential stash diff 0        # stash@{0} vs worktree
+  differential stash diff 2 0      # stash@{2} vs stash@{0}
+  differential stash diff          # pick from the stash list`,
+	Args:              cobra.MaximumNArgs(2),
+	ValidArgsFunction: completeStashes,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := buildConfig(cmd)
+		if err != nil {
+			return err
+		}
+		if !opts.pipeMode {
+			restoreState(cmd, cfg)
+		}
+		return app.RunStashDiff(cmd.Context(), args, opts.pipeMode, cfg)
+	},
+}
+
+func init() {
+	stashCmd.AddCommand(stashDiffCmd)
+	rootCmd.AddCommand(stashCmd)
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/avgvstvs96/differential/internal/themes"
+	"github.com/spf13/cobra"
+)
+
+var themesCmd = &cobra.Command{
+	Use:   "themes",
+	Short: "Check, export and import themes",
+	Long: `Works with theme files, the JSON files --theme also takes in place of a
+theme name, and converts themes to and from the formats of other tools.
+
+  differential themes validate mytheme.json
+  differential --theme mytheme.json
+  differential themes export --format delta nord >> ~/.gitconfig
+  differential themes import gruvbox-dark-hard.yaml > gruvbox-dark-hard.json`,
+}
+
+var themesValidateCmd = &cobra.Command{
+	Use:   "validate <file>",
+	Short: "Report the missing, unknown and invalid colors of a theme file",
+	Long: `Checks a theme file. Colors that don't resolve and keys that aren't colors
+of a theme, such as misspelled ones, are errors. Colors the file leaves
+out are listed as taken from the theme it extends, or the ` + themes.BaseTheme + ` theme.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := themes.Initialize(); err != nil {
+			return fmt.Errorf("failed to initialize themes: %w", err)
+		}
+		theme, err := themes.ReadJSON(args[0])
+		if err != nil {
+			return err
+		}
+
+		report := themes.Default().Check(theme)
+		out := cmd.OutOrStdout()
+		for _, problem := range report.Errors {
+			fmt.Fprintln(out, "invalid color:", problem)
+		}
+		for _, key := range report.Unknown {
+			fmt.Fprintln(out, "unknown key:", key)
+		}
+		if len(report.Missing) > 0 && report.Base != "" {
+			fmt.Fprintf(out, "from %s: %s\n", report.Base, strings.Join(report.Missing, ", "))
+		}
+		if !report.OK() {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("%s has %d invalid color(s) and %d unknown key(s)", args[0], len(report.Errors), len(report.Unknown))
+		}
+		fmt.Fprintf(out, "%s: ok\n", args[0])
+		return nil
+	},
+}
+
+var themesExportCmd = &cobra.Command{
+	Use:   "export [theme]",
+	Short: "Print a theme as a Chroma style, base16 scheme or delta config",
+	Long: `Prints the colors of a theme, or of the configured one, for other tools:
+a Chroma style in XML, a base16 scheme in YAML or a delta feature to add to
+a git config file. The theme overrides of the config file apply.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeThemes,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("format")
+		cfg, err := buildConfig(cmd)
+		if err != nil {
+			return err
+		}
+		name := cfg.UI.Theme
+		if len(args) > 0 {
+			name = args[0]
+		}
+		if err := themes.Default().Set(name); err != nil {
+			return err
+		}
+
+		output, err := themes.Export(themes.GetCurrentTheme(), strings.TrimSuffix(filepath.Base(name), ".json"), format)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(cmd.OutOrStdout(), output)
+		return nil
+	},
+}
+
+var themesImportCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Convert a base16 scheme to a theme file",
+	Long: `Prints a base16 scheme in YAML as a theme file, which --theme takes. Both
+the current format and the older one without a palette section are read.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		data, err := os.ReadFile(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to read base16 scheme: %w", err)
+		}
+		theme, err := themes.ParseBase16(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", args[0], err)
+		}
+		if theme.Name == "" {
+			theme.Name = strings.TrimSuffix(filepath.Base(args[0]), filepath.Ext(args[0]))
+		}
+
+		output, err := json.MarshalIndent(theme, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), string(output))
+		return nil
+	},
+}
+
+func init() {
+	themesExportCmd.Flags().String("format", "chroma", "Output format: "+strings.Join(themes.ExportFormats, ", "))
+	themesCmd.AddCommand(themesValidateCmd, themesExportCmd, themesImportCmd)
+	rootCmd.AddCommand(themesCmd)
+}
@@ -0,0 +1,125 @@
+// Package differential renders diffs with differential's styling for use
+// in other programs, such as chat bots and editor plugins that show only
+// the part of a diff relevant to them.
+package differential
+
+import (
+	"context"
+
+	"github.com/avgvstvs96/differential/internal/diff"
+	"github.com/avgvstvs96/differential/internal/themes"
+)
+
+type (
+	// File is one file of a parsed diff
+	File = diff.DiffResult
+	// Hunk is one hunk of a file
+	Hunk = diff.Hunk
+	// Options controls how diffs are rendered
+	Options = diff.RenderOptions
+	// ThemeColors are the colors of a theme, for Options.Theme
+	ThemeColors = themes.ThemeColors
+	// LineMatch is a removed line paired with the added line replacing it
+	LineMatch = diff.LineMatch
+	// FileMatches holds the line matches of one file
+	FileMatches = diff.FileMatches
+	// FileStat summarizes the changes to one file
+	FileStat = diff.FileStat
+)
+
+// ParseOptions controls how ParseWith parses a diff
+type ParseOptions struct {
+	// OnFile, if set, is called with each file as soon as it is parsed,
+	// along with its stats, e.g. to compute the test-to-code ratio of a
+	// change. It must not modify file.
+	OnFile func(file *File, stat FileStat)
+}
+
+// View modes for Options.ViewMode
+const (
+	Unified    = diff.ViewUnified
+	SideBySide = diff.ViewSideBySide
+)
+
+// Parse parses git diff output into its files
+func Parse(text string) ([]*File, error) {
+	return diff.ParseMultiFileDiff(text)
+}
+
+// ParseWith is Parse with options
+func ParseWith(text string, opts ParseOptions) ([]*File, error) {
+	return diff.ParseMultiFileDiffHook(context.Background(), text, opts.OnFile)
+}
+
+// Theme returns the colors of a built-in theme by name
+func Theme(name string) (*ThemeColors, error) {
+	return themes.Default().Resolve(name)
+}
+
+// Themes lists the names of the built-in themes
+func Themes() []string {
+	return themes.Default().List()
+}
+
+// Renderer renders files with one set of options. The styles and syntax
+// highlighters it builds are reused, and each file's output is cached, so
+// keep one around to render several files or snippets. Call Reset after
+// modifying a rendered file. A Renderer is not safe for concurrent use.
+type Renderer struct {
+	renderer *diff.Renderer
+}
+
+// NewRenderer creates a renderer for opts
+func NewRenderer(opts Options) *Renderer {
+	return &Renderer{renderer: diff.NewRenderer(opts)}
+}
+
+// RenderFile renders every hunk of file
+func (r *Renderer) RenderFile(file *File) string {
+	return r.renderer.Render(file)
+}
+
+// RenderFiles renders several files, with a header above each file when
+// there is more than one
+func (r *Renderer) RenderFiles(files []*File) string {
+	return r.renderer.RenderFiles(files)
+}
+
+// RenderHunk renders only the hunk of file at index, counting from zero
+func (r *Renderer) RenderHunk(file *File, index int) (string, error) {
+	return r.renderer.RenderHunk(file, index)
+}
+
+// RenderLines renders only lines start to end of the new version of file,
+// inclusive, along with the lines removed between them
+func (r *Renderer) RenderLines(file *File, start, end int) (string, error) {
+	return r.renderer.RenderLines(file, start, end)
+}
+
+// Reset drops the cached output of the files rendered so far
+func (r *Renderer) Reset() {
+	r.renderer.Reset()
+}
+
+// RenderFile renders every hunk of file
+func RenderFile(file *File, opts Options) string {
+	return NewRenderer(opts).RenderFile(file)
+}
+
+// RenderHunk renders only the hunk of file at index, counting from zero
+func RenderHunk(file *File, index int, opts Options) (string, error) {
+	return NewRenderer(opts).RenderHunk(file, index)
+}
+
+// RenderLines renders only lines start to end of the new version of file,
+// inclusive, along with the lines removed between them
+func RenderLines(file *File, start, end int, opts Options) (string, error) {
+	return NewRenderer(opts).RenderLines(file, start, end)
+}
+
+// Matches returns how the removed lines of each file were paired with the
+// added lines replacing them, as the side-by-side view pairs them, with how
+// similar the lines are
+func Matches(files []*File) []FileMatches {
+	return diff.MatchReport(files)
+}
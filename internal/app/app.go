@@ -1,16 +1,25 @@
 package app
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
+	"regexp"
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/avgvstvs96/differential/internal/commit"
 	"github.com/avgvstvs96/differential/internal/config"
 	"github.com/avgvstvs96/differential/internal/diff"
+	"github.com/avgvstvs96/differential/internal/fuzzy"
+	"github.com/avgvstvs96/differential/internal/git"
+	"github.com/avgvstvs96/differential/internal/preview"
+	"github.com/avgvstvs96/differential/internal/review"
+	"github.com/avgvstvs96/differential/internal/state"
 	"github.com/avgvstvs96/differential/internal/themes"
 )
 
@@ -22,75 +31,242 @@ const (
 	ModeDiff
 	ModeSearch
 	ModeHelp
+	ModePatterns
+	ModeComment  // Writing a comment on a line
+	ModeComments // Listing the comments
+	ModeSummary  // Showing the statistics of the diff
+	ModeStashes  // Listing the stash entries
 )
 
 // Model represents the main application state
 type Model struct {
 	// Application state
 	mode         Mode
+	ctx          context.Context // Done on interrupt; bounds the git runs of reloads
 	config       *config.Config
 	windowWidth  int
 	windowHeight int
 	ready        bool
+	resizes      int // Window resizes so far, to lay out only the last of a burst
 	err          error
+	actionErr    error // Failure of the last key's action, shown in the status bar until the next key
 
 	// Current diff
-	diffResult   *diff.DiffResult
-	diffText     string
-	filename     string
-	viewMode     diff.ViewMode
+	files    []*diff.DiffResult
+	diffText string
+	filename string
+	viewMode diff.ViewMode
+	header   string // Rendered above the files, e.g. commit lint findings
+	renderer *rendererCache
 
 	// Navigation
 	scrollOffset int
+	cursor       int // Output row of the current line, which the viewport follows
 	selectedHunk int
-	selectedLine int
+
+	// Staging
+	stageable bool             // Whether the diff is the unstaged changes, so lines can be staged
+	visual    bool             // Whether lines are being selected
+	anchor    int              // Output row where the selection started
+	undo      []stageOperation // Staging steps to take back, most recent last
+	redo      []stageOperation // Undone steps to make again, most recent last
+
+	// Hunks marked as staged where the diff can't be staged
+	staged map[state.StagedHunk]bool
+
+	// Mass-change review
+	patterns      []diff.ChangePattern // Distinct changes, listed in ModePatterns
+	patternCursor int
+	approved      map[string]bool // Keys of the approved patterns
+
+	// Patch series, shown a patch at a time
+	series     []seriesPatch
+	patchIndex int
+
+	// Review comments
+	comments      []review.Comment // In file and line order
+	commentsFile  string           // Where comments are saved; empty outside a repository
+	commentRoot   string           // Repository and commit the comments belong to
+	commentCommit string
+	commentCursor int
+	commentTarget review.Comment // Line of the comment being written
+	input         string         // Body of the comment being written
+	inputFrom     Mode           // Mode to return to when the comment is done
+
+	// Statistics panel
+	summary       diff.Summary
+	summaryScroll int
+
+	// Repository browser
+	browsePaths   []string // Tracked files, listed when the finder first opens
+	browseQuery   string
+	browseMatches []fuzzy.Match // Files matching the query, best first
+	browseCursor  int
+	browseFrom    *shownDiff // The diff shown before browsing, which esc returns to
+
+	// Stash list
+	stashes      []git.Stash // Nil unless the viewer was opened on the stash list
+	stashCursor  int
+	stashConfirm string // Action awaiting confirmation: "apply", "pop" or "drop"
+	stashStatus  string // Outcome of the last action
+
+	// File tree pane
+	showTree   bool
+	treeFocus  bool    // Whether keys move the tree's cursor rather than the diff's
+	treeCursor int     // Row of the tree selected while it has the focus
+	treeRatio  float64 // Share of the window the tree takes
+	dragging   bool    // Whether the tree's divider is being dragged
 
 	// UI state
 	showLineNumbers bool
+	dimContext      bool
+	showInvisibles  bool // Whether whitespace and hidden characters are marked
+	fullFile        bool // Whether files are shown whole with a change gutter
+	untracked       bool // Whether untracked files are shown with the unstaged changes
+	foldDuplicates  bool
+	gutter          diff.Gutter
+	hunkContext     diff.HunkContextMode
+	whitespace      diff.WhitespaceMode
+	ignored         diff.IgnoredMode
+	intraline       diff.IntralineGranularity
+	style           diff.RenderStyle
 	contextLines    int
+	search          *regexp.Regexp // Matches highlighted in changed lines
+	links           diff.LinkFunc  // Links to the files on their forge, nil when there are none
+	tokens          diff.SemanticTokens
+	languages       diff.Languages
+
+	// Context lines of the files regenerated with + and -
+	fileContext map[*diff.DiffResult]int
+
+	// Set while the diff loads in the background, see loadingModel
+	loading *loader
+	loadErr error
+}
+
+// RunPipeMode runs the application in pipe mode (non-interactive),
+// stopping with ctx's error when ctx is done
+func RunPipeMode(ctx context.Context, input io.Reader, cfg *config.Config, args []string) error {
+	return runPipeMode(ctx, input, cfg, args, "")
 }
 
-// RunPipeMode runs the application in pipe mode (non-interactive)
-func RunPipeMode(input io.Reader, cfg *config.Config, args []string) error {
+// runPipeMode renders the diff in pipe mode below header
+func runPipeMode(ctx context.Context, input io.Reader, cfg *config.Config, args []string, header string) error {
 	// Initialize themes
 	if err := themes.Initialize(); err != nil {
 		return fmt.Errorf("failed to initialize themes: %w", err)
 	}
 
 	// Set theme
-	if err := themes.SetTheme(cfg.UI.Theme); err != nil {
+	if err := themes.Default().Set(cfg.UI.Theme); err != nil {
 		return fmt.Errorf("failed to set theme: %w", err)
 	}
 
-	var diffText string
-	var err error
+	if cfg.Output.Path != "" {
+		useOutputColors(cfg.Output.Path)
+	}
 
-	// Get diff text from input or generate from files
-	if input != nil {
-		// Read from stdin
-		data, err := io.ReadAll(input)
+	// The timeout covers running git as well as rendering; only a diff
+	// that is partly rendered can be shown truncated
+	if cfg.Limits.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Limits.Timeout)
+		defer cancel()
+	}
+
+	diffText, err := readDiffInput(ctx, input, cfg, args)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("timed out after %s: %w", cfg.Limits.Timeout, err)
+	}
+	if err != nil {
+		return err
+	}
+
+	opts, err := pipeRenderOptions(cfg)
+	if err != nil {
+		return err
+	}
+	if input == nil {
+		opts.Links = hyperlinks(ctx, args, cfg)
+	}
+
+	// git show and git log -p output shows each commit above its diff
+	series, err := parseSeries(ctx, "", commit.SplitCommits(diffText), cfg)
+	if err != nil {
+		return fmt.Errorf("failed to format diff: %w", err)
+	}
+	var files []*diff.DiffResult
+	var rendered string
+	limits := diff.Limits{Files: cfg.Limits.MaxFiles, Lines: cfg.Limits.MaxLines}
+	if len(series) > 1 {
+		rendered, files, err = renderSeries(ctx, series, opts, limits)
 		if err != nil {
-			return fmt.Errorf("failed to read input: %w", err)
+			return err
 		}
-		diffText = string(data)
-	} else if len(args) == 2 {
-		// Generate diff from two files
-		diffText, err = runDiff(args[0], args[1])
-		if err != nil {
-			return fmt.Errorf("failed to diff files: %w", err)
+	} else {
+		if len(series) == 1 {
+			header += renderPatchHeader(series[0], 0, 1, opts.Width)
+			files = series[0].files
+			opts.FileHeaders = true
+		} else if files, err = parseFiles(ctx, diffText, cfg); err != nil {
+			return fmt.Errorf("failed to format diff: %w", err)
 		}
-	} else if len(args) > 0 {
-		// Pass args to git diff
-		diffText, err = runGitDiff(args)
+		rendered, err = diff.NewRenderer(opts).RenderFilesLimited(ctx, files, limits)
 		if err != nil {
-			return fmt.Errorf("failed to run git diff: %w", err)
+			return err
 		}
-	} else {
-		return fmt.Errorf("no diff input provided")
+	}
+	output := header + renderCommitLint(diffText, cfg, opts.Width) + rendered
+	if n := diff.IgnoredChanges(files); n > 0 {
+		output += ignoredNote(n, opts.IgnoredChanges) + "\n"
+	}
+	if cfg.CI {
+		output += ciSummary(files) + "\n"
+	}
+	return emitOutput(output, opts, cfg)
+}
+
+// pipeRenderOptions returns the options pipe mode renders diffs with
+func pipeRenderOptions(cfg *config.Config) (diff.RenderOptions, error) {
+	gutter, err := gutterOptions(cfg)
+	if err != nil {
+		return diff.RenderOptions{}, err
+	}
+	hunkContext, err := hunkContextMode(cfg)
+	if err != nil {
+		return diff.RenderOptions{}, err
+	}
+	whitespace, err := whitespaceMode(cfg)
+	if err != nil {
+		return diff.RenderOptions{}, err
+	}
+	intraline, err := intralineGranularity(cfg)
+	if err != nil {
+		return diff.RenderOptions{}, err
+	}
+	style, err := renderStyle(cfg)
+	if err != nil {
+		return diff.RenderOptions{}, err
+	}
+	ignored, err := ignoredMode(cfg)
+	if err != nil {
+		return diff.RenderOptions{}, err
+	}
+	search, err := searchRegexp(cfg)
+	if err != nil {
+		return diff.RenderOptions{}, err
+	}
+	tokens, err := semanticTokens(cfg)
+	if err != nil {
+		return diff.RenderOptions{}, err
+	}
+	languages, err := languageOptions(cfg)
+	if err != nil {
+		return diff.RenderOptions{}, err
 	}
 
 	// Determine terminal width
-	width := getTerminalWidth()
+	width := outputWidth(cfg)
 
 	// Create render options
 	opts := diff.RenderOptions{
@@ -98,20 +274,135 @@ func RunPipeMode(input io.Reader, cfg *config.Config, args []string) error {
 		ShowLineNumbers: cfg.UI.LineNumbers,
 		ContextLines:    cfg.Git.DefaultContext,
 		TabWidth:        cfg.UI.TabWidth,
+		Gutter:          gutter,
+		HunkContext:     hunkContext,
+		HunkStats:       cfg.UI.HunkStats,
+		Whitespace:      whitespace,
+		IgnoredChanges:  ignored,
+		Intraline:       intraline,
+		Style:           style,
+		FoldContext:     cfg.UI.FoldContext,
+		BinaryBytes:     cfg.UI.BinaryDiffBytes,
+		Icons:           cfg.UI.Icons,
+		DimContext:      cfg.UI.DimContext,
+		ShowInvisibles:  cfg.UI.ShowInvisibles,
+		PlainColumns:    cfg.UI.PlainColumns,
+		Accessibility:   cfg.UI.Accessibility,
+		Languages:       languages,
+		Highlight:       search,
+		Tokens:          tokens,
+		Theme:           outputTheme(cfg),
+		LoadBlob:        loadBlob,
+		LoadBlobPrefix:  loadBlobPrefix,
+		SubmoduleLog:    git.SubmoduleLog,
 	}
 
 	// Format based on view mode
-	var output string
 	if cfg.UI.DefaultView == "side-by-side" {
 		opts.ViewMode = diff.ViewSideBySide
-		output, err = diff.FormatSideBySideDiff("", diffText, opts)
 	} else {
 		opts.ViewMode = diff.ViewUnified
-		output, err = diff.FormatUnifiedDiff("", diffText, opts)
+	}
+	if cfg.Deterministic {
+		opts.ImageProtocol = preview.ProtocolHalfBlock
 	}
 
-	if err != nil {
-		return fmt.Errorf("failed to format diff: %w", err)
+	return opts, nil
+}
+
+// emitOutput writes rendered output to the output file, or shows it
+func emitOutput(output string, opts diff.RenderOptions, cfg *config.Config) error {
+	if cfg.Output.Plain {
+		output = diff.ParseANSI(output).Plain()
+	}
+	if cfg.Output.Path != "" {
+		return writeOutput(cfg.Output.Path, output, opts.Theme, cfg.Output.Force)
+	}
+	return displayOutput(output, cfg)
+}
+
+// ignoredNote returns the line below a diff counting the changes
+// --ignore-matching-lines dimmed or hid
+func ignoredNote(count int, mode diff.IgnoredMode) string {
+	changes := "changes"
+	if count == 1 {
+		changes = "change"
+	}
+	verb := "dimmed"
+	if mode == diff.IgnoredHide {
+		verb = "hidden"
+	}
+	note := fmt.Sprintf("⊘ %d %s matching ignore_matching_lines %s", count, changes, verb)
+	return lipgloss.NewStyle().Foreground(themes.GetCurrentTheme().TextMuted).Render(note)
+}
+
+// ciSummary returns the line ending CI output, counting the changes as
+// key=value pairs for scripts to parse. Filtered files aren't counted.
+func ciSummary(files []*diff.DiffResult) string {
+	stats := diff.Stats(files)
+	additions, deletions, binary := 0, 0, 0
+	for _, stat := range stats {
+		additions += stat.Additions
+		deletions += stat.Deletions
+		if stat.Binary {
+			binary++
+		}
+	}
+	return fmt.Sprintf("differential: files=%d additions=%d deletions=%d binary=%d", len(stats), additions, deletions, binary)
+}
+
+// readDiffInput reads the diff to show from stdin, or generates it from the
+// file, blob or revision arguments
+func readDiffInput(ctx context.Context, input io.Reader, cfg *config.Config, args []string) (string, error) {
+	var diffText string
+	var err error
+
+	// Get diff text from input or generate from files
+	if input != nil {
+		// Read from stdin
+		data, err := io.ReadAll(input)
+		if err != nil {
+			return "", fmt.Errorf("failed to read input: %w", err)
+		}
+		diffText = string(data)
+		if text, colored := diff.StripDiffColors(diffText); colored {
+			fmt.Fprintln(os.Stderr, "warning: ignoring the colors of the input diff (use git diff --no-color)")
+			diffText = text
+		}
+	} else if isBlobPair(args) {
+		// Compare a file at a revision against another revision or a worktree file
+		diffText, err = runBlobDiff(args[0], args[1], cfg.Git.DefaultContext)
+		if err != nil {
+			return "", fmt.Errorf("failed to diff files: %w", err)
+		}
+	} else if isPathPair(args) {
+		// Generate diff from two files
+		diffText, err = runPathDiff(ctx, cfg, args[0], args[1])
+		if err != nil {
+			return "", fmt.Errorf("failed to diff files: %w", err)
+		}
+	} else if len(args) > 0 || len(cfg.Git.Passthrough) > 0 {
+		// Pass args to git diff
+		if err := git.ValidateRevisionArgs(args); err != nil {
+			return "", err
+		}
+		diffText, err = runGitDiff(ctx, cfg, args)
+		if err != nil {
+			return "", fmt.Errorf("failed to run git diff: %w", err)
+		}
+	} else {
+		return "", fmt.Errorf("no diff input provided")
+	}
+
+	return diffText, nil
+}
+
+// displayOutput prints rendered output, paging it when it doesn't fit the
+// terminal unless output has to be deterministic
+func displayOutput(output string, cfg *config.Config) error {
+	if cfg.Deterministic {
+		fmt.Print(output)
+		return nil
 	}
 
 	// Determine if we should use a pager
@@ -133,85 +424,281 @@ func RunPipeMode(input io.Reader, cfg *config.Config, args []string) error {
 	return nil
 }
 
-// RunTUIMode runs the application in TUI mode (interactive)
-func RunTUIMode(args []string, cfg *config.Config) error {
+// RunTUIMode runs the application in TUI mode (interactive), quitting when
+// ctx is done
+func RunTUIMode(ctx context.Context, args []string, cfg *config.Config) error {
 	// Initialize themes
 	if err := themes.Initialize(); err != nil {
 		return fmt.Errorf("failed to initialize themes: %w", err)
 	}
 
 	// Set theme
-	if err := themes.SetTheme(cfg.UI.Theme); err != nil {
+	if err := themes.Default().Set(cfg.UI.Theme); err != nil {
 		return fmt.Errorf("failed to set theme: %w", err)
 	}
 
-	// Create initial model
-	m := Model{
-		mode:            ModeDiff,
-		config:          cfg,
-		showLineNumbers: cfg.UI.LineNumbers,
-		contextLines:    cfg.Git.DefaultContext,
-		viewMode:        diff.ViewUnified,
-	}
+	m := loadingModel(Model{ctx: ctx, config: cfg}, func(step func(string)) (Model, error) {
+		step("Running git diff")
+		diffText, filename, stageable, err := tuiInput(ctx, args, cfg)
+		if err != nil {
+			return Model{}, err
+		}
+		step("Parsing")
+		m, err := newModel(ctx, diffText, filename, "", stageable, cfg)
+		if err != nil {
+			return Model{}, err
+		}
+		m.links = hyperlinks(ctx, args, cfg)
+		return m, nil
+	})
+	return runModel(ctx, m)
+}
 
+// tuiInput returns the diff the viewer shows for args, the name of the file
+// compared when there is one, and whether the diff holds the unstaged
+// changes, which can be staged
+func tuiInput(ctx context.Context, args []string, cfg *config.Config) (diffText, filename string, stageable bool, err error) {
 	// Handle different input modes
 	if len(args) == 0 {
 		// No args - try to run git diff in current directory
-		diffText, err := runGitDiff([]string{})
+		text, err := worktreeDiff(ctx, cfg, cfg.Git.Untracked)
+		if err != nil {
+			return "", "", false, err
+		}
+		diffText = text
+	} else if isBlobPair(args) {
+		// A file at a revision against another revision or a worktree file
+		text, err := runBlobDiff(args[0], args[1], cfg.Git.DefaultContext)
 		if err != nil {
-			return fmt.Errorf("failed to get git diff: %w", err)
+			return "", "", false, fmt.Errorf("failed to diff files: %w", err)
 		}
-		m.diffText = diffText
-	} else if len(args) == 2 {
+		diffText = text
+		filename = args[1]
+	} else if isPathPair(args) {
 		// Two files - compare them
-		diffText, err := runDiff(args[0], args[1])
+		text, err := runPathDiff(ctx, cfg, args[0], args[1])
 		if err != nil {
-			return fmt.Errorf("failed to diff files: %w", err)
+			return "", "", false, fmt.Errorf("failed to diff files: %w", err)
 		}
-		m.diffText = diffText
-		m.filename = args[1]
+		diffText = text
+		filename = args[1]
 	} else {
 		// Pass args to git diff
-		diffText, err := runGitDiff(args)
+		if err := git.ValidateRevisionArgs(args); err != nil {
+			return "", "", false, err
+		}
+		text, err := runGitDiff(ctx, cfg, args)
 		if err != nil {
-			return fmt.Errorf("failed to run git diff: %w", err)
+			return "", "", false, fmt.Errorf("failed to run git diff: %w", err)
 		}
-		m.diffText = diffText
+		diffText = text
 	}
 
-	// Parse diff
-	result, err := diff.ParseUnifiedDiff(m.diffText)
-	if err != nil {
-		return fmt.Errorf("failed to parse diff: %w", err)
+	// Only the changes git diff shows without revisions are unstaged
+	stageable = len(args) == 0 && len(cfg.Git.Passthrough) == 0
+	return diffText, filename, stageable, nil
+}
+
+// startTUI parses diffText and runs the interactive viewer on it, showing
+// header above the files. stageable tells whether diffText holds the
+// unstaged changes of the worktree, which can then be staged from the
+// viewer.
+func startTUI(ctx context.Context, diffText, filename, header string, stageable bool, cfg *config.Config) error {
+	m := loadingModel(Model{ctx: ctx, config: cfg}, func(step func(string)) (Model, error) {
+		step("Parsing")
+		return newModel(ctx, diffText, filename, header, stageable, cfg)
+	})
+	return runModel(ctx, m)
+}
+
+// runModel runs the interactive viewer until it quits, then saves its UI
+// state and the review session
+func runModel(ctx context.Context, m Model) error {
+	// Only the viewer, which has the terminal to itself, asks it for its
+	// colors. Deterministic output doesn't depend on them.
+	if !m.config.Deterministic {
+		if err := themes.Default().UseTerminalColors(m.config.UI.DiffBackgroundAlpha == 0); err != nil {
+			return err
+		}
 	}
-	m.diffResult = result
 
 	// Start TUI
-	p := tea.NewProgram(m, tea.WithAltScreen())
-	if _, err := p.Run(); err != nil {
+	p := tea.NewProgram(m, tea.WithAltScreen(), tea.WithContext(ctx))
+	final, err := p.Run()
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
 		return fmt.Errorf("error running program: %w", err)
 	}
 
+	if final, ok := final.(Model); ok {
+		if final.loadErr != nil {
+			return final.loadErr
+		}
+		if final.loading != nil && !final.loading.loaded {
+			// Quit before there was anything to save
+			return nil
+		}
+		final.saveState()
+		final.saveSession()
+	}
 	return nil
 }
 
-// Init initializes the model
+// NewModel returns the interactive viewer on diffText, for driving it
+// without a terminal as the end-to-end tests do. Themes must be initialized
+// first.
+func NewModel(ctx context.Context, diffText string, cfg *config.Config) (Model, error) {
+	return newModel(ctx, diffText, "", "", false, cfg)
+}
+
+// LoadModel returns the interactive viewer on the diff load returns, which
+// it runs in the background with a spinner showing, as RunTUIMode does
+func LoadModel(ctx context.Context, load func() (string, error), cfg *config.Config) Model {
+	return loadingModel(Model{ctx: ctx, config: cfg}, func(step func(string)) (Model, error) {
+		step("Running git diff")
+		diffText, err := load()
+		if err != nil {
+			return Model{}, err
+		}
+		step("Parsing")
+		return newModel(ctx, diffText, "", "", false, cfg)
+	})
+}
+
+// newModel parses diffText and sets up the viewer's initial state, as
+// startTUI describes its arguments
+func newModel(ctx context.Context, diffText, filename, header string, stageable bool, cfg *config.Config) (Model, error) {
+	m := Model{
+		mode:            ModeDiff,
+		ctx:             ctx,
+		config:          cfg,
+		showLineNumbers: cfg.UI.LineNumbers,
+		dimContext:      cfg.UI.DimContext,
+		showInvisibles:  cfg.UI.ShowInvisibles,
+		foldDuplicates:  cfg.UI.FoldDuplicates,
+		contextLines:    cfg.Git.DefaultContext,
+		viewMode:        diff.ViewUnified,
+		diffText:        diffText,
+		filename:        filename,
+		renderer:        &rendererCache{},
+		stageable:       stageable,
+		showTree:        cfg.UI.FileTree,
+		untracked:       cfg.Git.Untracked,
+		treeRatio:       min(max(cfg.UI.FileTreeRatio, minTreeRatio), maxTreeRatio),
+	}
+	if cfg.UI.DefaultView == "side-by-side" {
+		m.viewMode = diff.ViewSideBySide
+	}
+
+	gutter, err := gutterOptions(cfg)
+	if err != nil {
+		return Model{}, err
+	}
+	m.gutter = gutter
+
+	hunkContext, err := hunkContextMode(cfg)
+	if err != nil {
+		return Model{}, err
+	}
+	m.hunkContext = hunkContext
+
+	whitespace, err := whitespaceMode(cfg)
+	if err != nil {
+		return Model{}, err
+	}
+	m.whitespace = whitespace
+
+	ignored, err := ignoredMode(cfg)
+	if err != nil {
+		return Model{}, err
+	}
+	m.ignored = ignored
+
+	intraline, err := intralineGranularity(cfg)
+	if err != nil {
+		return Model{}, err
+	}
+	m.intraline = intraline
+
+	style, err := renderStyle(cfg)
+	if err != nil {
+		return Model{}, err
+	}
+	m.style = style
+
+	search, err := searchRegexp(cfg)
+	if err != nil {
+		return Model{}, err
+	}
+	m.search = search
+
+	tokens, err := semanticTokens(cfg)
+	if err != nil {
+		return Model{}, err
+	}
+	m.tokens = tokens
+
+	languages, err := languageOptions(cfg)
+	if err != nil {
+		return Model{}, err
+	}
+	m.languages = languages
+
+	// Parse diff
+	files, err := parseFiles(ctx, m.diffText, cfg)
+	if err != nil {
+		return Model{}, fmt.Errorf("failed to parse diff: %w", err)
+	}
+	m.files = files
+	m.header = header + renderCommitLint(diffText, cfg, getTerminalWidth())
+	if m.collapseGenerated(files) {
+		m.refoldHunks()
+	}
+	m.restoreCollapsed()
+	m.loadComments()
+	return m, nil
+}
+
+// Init initializes the model, starting the background load of a viewer
+// made by loadingModel, or taking the mouse for resizing the file tree
+// when it's shown
 func (m Model) Init() tea.Cmd {
+	if m.loading != nil {
+		return m.initLoading()
+	}
+	if m.showTree {
+		return tea.EnableMouseCellMotion
+	}
 	return nil
 }
 
 // Update handles messages and updates the model
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if m.loading != nil {
+		return m.updateLoading(msg)
+	}
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
-		m.windowWidth = msg.Width
-		m.windowHeight = msg.Height
-		m.ready = true
+		return m.handleResize(msg)
+
+	case resizeMsg:
+		if msg.seq == m.resizes {
+			m.resize(msg.width, msg.height)
+		}
 		return m, nil
 
 	case tea.KeyMsg:
 		return m.handleKeyPress(msg)
 
+	case tea.MouseMsg:
+		return m.handleMouse(msg)
+
+	case actionErrMsg:
+		m.actionErr = msg.err
+		return m, nil
+
 	case error:
 		m.err = msg
 		return m, nil
@@ -222,6 +709,9 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 // View renders the UI
 func (m Model) View() string {
+	if m.loading != nil {
+		return m.renderLoading()
+	}
 	if !m.ready {
 		return "Initializing..."
 	}
@@ -232,102 +722,632 @@ func (m Model) View() string {
 			Render(fmt.Sprintf("Error: %v", m.err))
 	}
 
-	if m.diffResult == nil || len(m.diffResult.Hunks) == 0 {
+	if m.mode == ModeBrowse {
+		return m.renderBrowse()
+	}
+	if m.mode == ModeStashes {
+		return m.renderStashes()
+	}
+	// A patch without changes, like a cover letter, still shows its message
+	if len(m.files) == 0 && len(m.series) == 0 {
 		return "No changes to display"
 	}
+	if m.mode == ModePatterns {
+		return m.renderPatterns()
+	}
+	if m.mode == ModeComments {
+		return m.renderComments()
+	}
+	if m.mode == ModeSummary {
+		return m.renderSummary()
+	}
 
-	// Render diff
-	opts := diff.RenderOptions{
-		Width:           m.windowWidth,
+	lines := m.rows()
+	visibleLines := m.visibleRows()
+	m.clampScroll()
+
+	end := m.scrollOffset + visibleLines
+	if end > len(lines) {
+		end = len(lines)
+	}
+
+	// Highlight the visual selection and the cursor line
+	if m.visual {
+		first, last := m.selectedRows()
+		for row := max(first, m.scrollOffset); row <= last && row < end; row++ {
+			lines[row] = m.renderSelectedLine(lines[row])
+		}
+	}
+	if m.cursor >= m.scrollOffset && m.cursor < end {
+		lines[m.cursor] = m.renderCursorLine(lines[m.cursor])
+	}
+
+	shown := lines[m.scrollOffset:end]
+	if m.treeWidth() > 0 {
+		// The tree fills the height of the window
+		for len(shown) < visibleLines {
+			shown = append(shown, "")
+		}
+		shown = m.joinTree(shown)
+	}
+	visible := strings.Join(shown, "\n")
+
+	// Add status bar, or the comment being written
+	statusBar := m.renderStatusBar()
+	if m.mode == ModeComment {
+		statusBar = m.renderCommentInput()
+	}
+
+	return visible + "\n" + statusBar
+}
+
+// renderOptions returns the options the diff view is rendered with
+func (m Model) renderOptions() diff.RenderOptions {
+	return diff.RenderOptions{
+		Width:           m.diffWidth(),
 		ViewMode:        m.viewMode,
 		ShowLineNumbers: m.showLineNumbers,
 		ContextLines:    m.contextLines,
 		TabWidth:        m.config.UI.TabWidth,
+		Gutter:          m.gutter,
+		HunkContext:     m.hunkContext,
+		Whitespace:      m.whitespace,
+		IgnoredChanges:  m.ignored,
+		Intraline:       m.intraline,
+		Style:           m.style,
+		HunkStats:       m.config.UI.HunkStats,
+		FoldContext:     m.config.UI.FoldContext,
+		BinaryBytes:     m.config.UI.BinaryDiffBytes,
+		Icons:           m.config.UI.Icons,
+		DimContext:      m.dimContext,
+		ShowInvisibles:  m.showInvisibles,
+		PlainColumns:    m.config.UI.PlainColumns,
+		Accessibility:   m.config.UI.Accessibility,
+		FullFile:        m.fullFile,
+		FileHeaders:     len(m.series) > 0,
+		Languages:       m.languages,
+		Highlight:       m.search,
+		Links:           m.links,
+		Tokens:          m.tokens,
+		LoadBlob:        loadBlob,
+		LoadBlobPrefix:  loadBlobPrefix,
+		SubmoduleLog:    git.SubmoduleLog,
+		// Graphics protocols don't survive the alt screen redraws
+		ImageProtocol: preview.ProtocolHalfBlock,
 	}
+}
 
-	var output string
-	if m.viewMode == diff.ViewSideBySide {
-		output = diff.RenderSideBySideDiff(m.diffResult, opts)
+// rendererCache keeps the renderer between frames so files are only
+// rendered again when the layout changes
+type rendererCache struct {
+	renderer *diff.Renderer
+}
+
+// get returns a renderer for opts, reusing the previous one if the options
+// affecting the output are unchanged
+func (c *rendererCache) get(opts diff.RenderOptions) *diff.Renderer {
+	if c == nil {
+		return diff.NewRenderer(opts)
+	}
+	if c.renderer != nil {
+		prev := c.renderer.Options()
+		if prev.Width == opts.Width && prev.ViewMode == opts.ViewMode &&
+			prev.ShowLineNumbers == opts.ShowLineNumbers && prev.ContextLines == opts.ContextLines &&
+			prev.TabWidth == opts.TabWidth && prev.Gutter == opts.Gutter &&
+			prev.DimContext == opts.DimContext && prev.FullFile == opts.FullFile &&
+			prev.ShowInvisibles == opts.ShowInvisibles && prev.Intraline == opts.Intraline {
+			return c.renderer
+		}
+	}
+	c.renderer = diff.NewRenderer(opts)
+	return c.renderer
+}
+
+// rows returns the lines of the rendered output. The renderer caches the
+// files, so this is cheap to call for every key press.
+func (m Model) rows() []string {
+	output := m.header + m.renderer.get(m.renderOptions()).RenderFiles(m.files)
+	return strings.Split(strings.TrimSuffix(output, "\n"), "\n")
+}
+
+// rowCount returns how many rows rows returns, without putting them
+// together
+func (m Model) rowCount() int {
+	count := strings.Count(m.header, "\n") + m.renderer.get(m.renderOptions()).RowCount(m.files)
+	return max(count, 1)
+}
+
+// visibleRows returns how many rows of output fit above the status bar
+func (m Model) visibleRows() int {
+	return max(m.windowHeight-2, 1)
+}
+
+// moveCursor moves the cursor by delta rows within the output, scrolling
+// the viewport to keep it visible
+func (m *Model) moveCursor(delta int) {
+	m.cursor = min(max(m.cursor+delta, 0), m.rowCount()-1)
+	m.followCursor()
+}
+
+// page scrolls the viewport a page down, or up, moving the cursor along so
+// it stays at the same place on screen
+func (m *Model) page(up bool) {
+	page := m.visibleRows()
+	if up {
+		m.scrollOffset = max(m.scrollOffset-page, 0)
+		m.moveCursor(-page)
 	} else {
-		output = diff.RenderUnifiedDiff(m.diffResult, opts)
+		m.scrollOffset += page
+		m.clampScroll()
+		m.moveCursor(page)
+	}
+}
+
+// followCursor scrolls the viewport as little as possible to show the cursor
+func (m *Model) followCursor() {
+	if m.cursor < m.scrollOffset {
+		m.scrollOffset = m.cursor
 	}
+	if visible := m.visibleRows(); m.cursor >= m.scrollOffset+visible {
+		m.scrollOffset = m.cursor - visible + 1
+	}
+	m.clampScroll()
+}
+
+// clampScroll keeps the viewport from scrolling past the last row, so the
+// end of the output fills the window rather than leaving rows blank
+func (m *Model) clampScroll() {
+	m.scrollOffset = max(min(m.scrollOffset, m.rowCount()-m.visibleRows()), 0)
+}
 
-	// Apply scrolling
-	lines := strings.Split(output, "\n")
-	visibleLines := m.windowHeight - 2 // Leave room for status bar
+// cursorLine returns the file and line numbers under the cursor
+func (m Model) cursorLine() (file *diff.DiffResult, oldLine, newLine int, ok bool) {
+	row := m.cursor - strings.Count(m.header, "\n")
+	return m.renderer.get(m.renderOptions()).LineAt(m.files, row)
+}
 
-	if m.scrollOffset >= len(lines) {
-		m.scrollOffset = len(lines) - 1
+// renderCursorLine highlights the row under the cursor
+func (m Model) renderCursorLine(row string) string {
+	theme := themes.GetCurrentTheme()
+	style := lipgloss.NewStyle().Background(theme.Cursor).Foreground(theme.Text)
+	if width := m.diffWidth(); width > 0 {
+		style = style.Width(width)
 	}
-	if m.scrollOffset < 0 {
-		m.scrollOffset = 0
+	return style.Render(diff.ParseANSI(row).Plain())
+}
+
+// collapseFileAtCursor toggles the collapsed state of the file under the
+// cursor and moves the cursor to its header
+func (m *Model) collapseFileAtCursor() {
+	i, row, ok := m.fileAtCursor()
+	if !ok {
+		return
 	}
+	file := m.files[i]
+	if file.SkipReason == "" {
+		file.Collapsed = !file.Collapsed
+		m.cursor = row
+		m.followCursor()
+	}
+}
 
-	end := m.scrollOffset + visibleLines
-	if end > len(lines) {
-		end = len(lines)
+// fileAtCursor returns the index of the file under the cursor and the row
+// its header starts at
+func (m Model) fileAtCursor() (index, row int, ok bool) {
+	_, offsets := m.renderer.get(m.renderOptions()).RenderFilesWithOffsets(m.files)
+	headerLines := strings.Count(m.header, "\n")
+	for i := len(offsets) - 1; i >= 0; i-- {
+		if offsets[i]+headerLines <= m.cursor {
+			return i, offsets[i] + headerLines, true
+		}
+	}
+	return 0, 0, false
+}
+
+// unfoldHunkAtCursor shows the folded context of the hunk under the
+// cursor, or folds it again
+func (m *Model) unfoldHunkAtCursor() {
+	file, oldLine, newLine, ok := m.cursorLine()
+	if !ok {
+		return
+	}
+	for i := range file.Hunks {
+		if hunkContains(file.Hunks[i], oldLine, newLine) {
+			m.keepPosition(func() {
+				file.Hunks[i].Unfolded = !file.Hunks[i].Unfolded
+				m.renderer.get(m.renderOptions()).Reset()
+			})
+			return
+		}
 	}
+}
 
-	visible := strings.Join(lines[m.scrollOffset:end], "\n")
+// hunkRows returns the output rows of the first line of each hunk shown,
+// per file. Collapsed and skipped files and folded hunks have none.
+func (m Model) hunkRows() [][]int {
+	headerLines := strings.Count(m.header, "\n")
+	fileRows := m.renderer.get(m.renderOptions()).HunkRows(m.files)
+	rows := make([][]int, len(fileRows))
+	for i := range fileRows {
+		for _, row := range fileRows[i] {
+			rows[i] = append(rows[i], row+headerLines)
+		}
+	}
+	return rows
+}
 
-	// Add status bar
-	statusBar := m.renderStatusBar()
+// jumpTo moves the cursor to the first of rows below it, or the last above
+// it when backward, reporting whether there was one
+func (m *Model) jumpTo(rows []int, backward bool) bool {
+	target := -1
+	for _, row := range rows {
+		if backward && row < m.cursor {
+			target = row
+		}
+		if !backward && row > m.cursor {
+			target = row
+			break
+		}
+	}
+	if target < 0 {
+		return false
+	}
+	m.cursor = target
+	m.followCursor()
+	return true
+}
 
-	return visible + "\n" + statusBar
+// jumpHunk moves the cursor to the first line of the next or previous hunk
+func (m *Model) jumpHunk(backward bool) bool {
+	var rows []int
+	for _, fileRows := range m.hunkRows() {
+		rows = append(rows, fileRows...)
+	}
+	return m.jumpTo(rows, backward)
+}
+
+// jumpFile moves the cursor to the next or previous file: to its first
+// line, or to its header when it shows none. Backward, the cursor goes to
+// the start of its own file first.
+func (m *Model) jumpFile(backward bool) bool {
+	_, offsets := m.renderer.get(m.renderOptions()).RenderFilesWithOffsets(m.files)
+	headerLines := strings.Count(m.header, "\n")
+	hunkRows := m.hunkRows()
+	start := func(i int) int {
+		if len(hunkRows[i]) > 0 {
+			return hunkRows[i][0]
+		}
+		return offsets[i] + headerLines
+	}
+
+	// The file the cursor is in, -1 above the first
+	current := -1
+	for i, offset := range offsets {
+		if offset+headerLines <= m.cursor {
+			current = i
+		}
+	}
+	target := current + 1
+	if backward {
+		target = current
+		if current < 0 || start(current) >= m.cursor {
+			target = current - 1
+		}
+	}
+	if target < 0 || target >= len(offsets) {
+		return false
+	}
+	m.cursor = start(target)
+	m.followCursor()
+	return true
+}
+
+// replaceDiff shows another diff in the viewer, such as a file found in
+// the repository or a stash entry. Staging needs the unstaged changes, so
+// the new diff can't be staged.
+func (m *Model) replaceDiff(diffText, filename string, files []*diff.DiffResult) {
+	m.mode = ModeDiff
+	m.files, m.diffText, m.filename = files, diffText, filename
+	if m.collapseGenerated(files) {
+		m.refoldHunks()
+	}
+	m.header, m.series, m.links = "", nil, nil
+	m.stageable = false
+	m.renderer = &rendererCache{}
+	m.cursor, m.scrollOffset, m.visual = 0, 0, false
+}
+
+// shownDiff is a diff the viewer showed and the position in it, kept to
+// return to once another diff replaced it
+type shownDiff struct {
+	diffText, filename   string
+	files                []*diff.DiffResult
+	header               string
+	series               []seriesPatch
+	links                diff.LinkFunc
+	stageable            bool
+	renderer             *rendererCache
+	cursor, scrollOffset int
+}
+
+// currentDiff returns the diff the viewer shows, for restoreDiff
+func (m *Model) currentDiff() *shownDiff {
+	return &shownDiff{
+		diffText:     m.diffText,
+		filename:     m.filename,
+		files:        m.files,
+		header:       m.header,
+		series:       m.series,
+		links:        m.links,
+		stageable:    m.stageable,
+		renderer:     m.renderer,
+		cursor:       m.cursor,
+		scrollOffset: m.scrollOffset,
+	}
+}
+
+// restoreDiff shows a diff replaced by replaceDiff again, where it was left
+func (m *Model) restoreDiff(shown *shownDiff) {
+	m.mode = ModeDiff
+	m.diffText, m.filename, m.files = shown.diffText, shown.filename, shown.files
+	m.header, m.series, m.links = shown.header, shown.series, shown.links
+	m.stageable = shown.stageable
+	m.renderer = shown.renderer
+	m.cursor, m.scrollOffset, m.visual = shown.cursor, shown.scrollOffset, false
+}
+
+// toggleViewMode switches between the unified and side-by-side views,
+// keeping the cursor on its line
+func (m *Model) toggleViewMode() {
+	m.keepPosition(func() {
+		if m.viewMode == diff.ViewUnified {
+			m.viewMode = diff.ViewSideBySide
+		} else {
+			m.viewMode = diff.ViewUnified
+		}
+	})
+}
+
+// toggleFullFile switches between showing files whole and as hunks,
+// keeping the cursor on its line when the other view shows it
+func (m *Model) toggleFullFile() {
+	m.keepPosition(func() { m.fullFile = !m.fullFile })
+}
+
+// toggleDuplicates folds the hunks repeated across files, or expands them
+func (m *Model) toggleDuplicates() {
+	m.keepPosition(func() {
+		m.foldDuplicates = !m.foldDuplicates
+		if m.foldDuplicates {
+			diff.FoldDuplicateHunks(m.files)
+		} else {
+			diff.UnfoldDuplicateHunks(m.files)
+		}
+		diff.MarkApproved(m.files, m.approved)
+		m.renderer.get(m.renderOptions()).Reset()
+	})
 }
 
 // handleKeyPress handles keyboard input
 func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.actionErr = nil
+	switch m.mode {
+	case ModePatterns:
+		return m.handlePatternKey(msg)
+	case ModeComment:
+		return m.handleCommentInput(msg)
+	case ModeComments:
+		return m.handleCommentsKey(msg)
+	case ModeBrowse:
+		return m.handleBrowseKey(msg)
+	case ModeSummary:
+		return m.handleSummaryKey(msg)
+	case ModeStashes:
+		return m.handleStashKey(msg)
+	}
+
+	if m.treeFocus && m.handleTreeKey(msg) {
+		return m, nil
+	}
+
 	switch msg.String() {
 	case "q", "ctrl+c":
 		return m, tea.Quit
 
 	case "j", "down":
-		m.scrollOffset++
+		m.moveCursor(1)
 		return m, nil
 
 	case "k", "up":
-		if m.scrollOffset > 0 {
-			m.scrollOffset--
-		}
+		m.moveCursor(-1)
 		return m, nil
 
 	case "ctrl+f", "pgdown":
-		m.scrollOffset += m.windowHeight - 2
+		// Page down, keeping the cursor at the same place on screen
+		m.page(false)
 		return m, nil
 
 	case "ctrl+b", "pgup":
-		m.scrollOffset -= m.windowHeight - 2
-		if m.scrollOffset < 0 {
-			m.scrollOffset = 0
-		}
+		m.page(true)
 		return m, nil
 
 	case "g", "home":
-		m.scrollOffset = 0
+		m.cursor, m.scrollOffset = 0, 0
 		return m, nil
 
 	case "G", "end":
-		// Scroll to bottom
-		totalLines := countLines(m.View())
-		m.scrollOffset = totalLines - m.windowHeight + 2
-		if m.scrollOffset < 0 {
-			m.scrollOffset = 0
-		}
+		// Move to the last line, with the end of the output filling the
+		// window
+		m.moveCursor(m.rowCount())
 		return m, nil
 
-	case "tab":
-		// Toggle view mode
-		if m.viewMode == diff.ViewUnified {
-			m.viewMode = diff.ViewSideBySide
-		} else {
-			m.viewMode = diff.ViewUnified
+	case "tab", "v":
+		// Move the focus between the file tree and the diff
+		if msg.String() == "tab" && m.treeWidth() > 0 {
+			m.treeFocus = !m.treeFocus
+			m.treeCursor = m.treeHighlight()
+			return m, nil
 		}
+		m.toggleViewMode()
 		return m, nil
 
 	case "n":
 		// Toggle line numbers
-		m.showLineNumbers = !m.showLineNumbers
+		m.keepPosition(func() { m.showLineNumbers = !m.showLineNumbers })
+		return m, nil
+
+	case "d":
+		// Toggle dimmed context lines
+		m.keepPosition(func() { m.dimContext = !m.dimContext })
+		return m, nil
+
+	case "i":
+		// Mark whitespace and show hidden characters
+		m.keepPosition(func() { m.showInvisibles = !m.showInvisibles })
+		return m, nil
+
+	case "f":
+		// Show files whole with a change gutter, or as hunks
+		m.toggleFullFile()
+		return m, nil
+
+	case "F":
+		// Fold or expand changes repeated across files
+		m.toggleDuplicates()
+		return m, nil
+
+	case "P":
+		// Review the distinct changes, approving them a pattern at a time
+		m.openPatterns()
+		return m, nil
+
+	case "c":
+		// Comment on the cursor's line, or edit its comment
+		if target, ok := m.cursorComment(); ok {
+			m.startComment(target, ModeDiff)
+		}
+		return m, nil
+
+	case "S":
+		// Show the statistics of the diff
+		m.openSummary()
+		return m, nil
+
+	case "C":
+		// List the comments
+		m.mode = ModeComments
+		return m, nil
+
+	case "ctrl+o":
+		// Find a file of the repository to show
+		m.openBrowse()
+		return m, nil
+
+	case "t":
+		// Show or hide the file tree, which narrows the diff
+		var cmd tea.Cmd
+		m.keepPosition(func() { cmd = m.toggleTree() })
+		return m, cmd
+
+	case "z":
+		// Collapse or expand the file under the cursor
+		m.collapseFileAtCursor()
+		return m, nil
+
+	case "+", "-":
+		// Show more or less context around the changes of the file under
+		// the cursor
+		delta := contextStep
+		if msg.String() == "-" {
+			delta = -contextStep
+		}
+		if err := m.changeFileContext(delta); err != nil {
+			m.actionErr = err
+		}
+		return m, nil
+
+	case "o":
+		// Show or fold the long context runs of the hunk under the cursor
+		m.unfoldHunkAtCursor()
+		return m, nil
+
+	case "}", "{":
+		// Jump to the next or previous hunk
+		m.jumpHunk(msg.String() == "{")
+		return m, nil
+
+	case "]", "[":
+		// Jump to the next or previous file
+		m.jumpFile(msg.String() == "[")
+		return m, nil
+
+	case ">":
+		// Show the next patch of a series
+		m.showPatch(m.patchIndex + 1)
+		return m, nil
+
+	case "<":
+		// Show the previous patch of a series
+		m.showPatch(m.patchIndex - 1)
+		return m, nil
+
+	case "e":
+		// Open the cursor's line in the editor
+		return m, m.openEditor()
+
+	case "V":
+		// Start or cancel selecting lines from the cursor
+		m.visual, m.anchor = !m.visual, m.cursor
+		return m, nil
+
+	case "esc":
+		// Back to the diff shown before browsing files, or to the stash
+		// list the diff was opened from
+		switch {
+		case m.visual:
+		case m.browseFrom != nil:
+			m.restoreDiff(m.browseFrom)
+			m.browseFrom = nil
+		case m.stashes != nil:
+			m.mode = ModeStashes
+		}
+		m.visual = false
+		return m, nil
+
+	case "s":
+		// Stage the selected lines, or the hunk under the cursor
+		if err := m.stageSelection(); err != nil {
+			m.actionErr = err
+		}
+		return m, nil
+
+	case "u":
+		// Undo the last staging step
+		if err := m.undoStage(); err != nil {
+			m.actionErr = err
+		}
+		return m, nil
+
+	case "ctrl+r":
+		// Redo the last undone staging step
+		if err := m.redoStage(); err != nil {
+			m.actionErr = err
+		}
+		return m, nil
+
+	case "U":
+		// Show or hide the untracked files among the unstaged changes
+		if m.stageable {
+			m.untracked = !m.untracked
+			if err := m.reloadDiff(); err != nil {
+				m.actionErr = err
+			}
+		}
+		return m, nil
+
+	case "L":
+		// Cycle gutter modes
+		m.keepPosition(func() { m.gutter.Mode = m.gutter.Mode.Next() })
 		return m, nil
 
 	case "?":
@@ -351,14 +1371,31 @@ func (m Model) renderStatusBar() string {
 	// Build status text
 	var parts []string
 
+	// The last action's failure comes first, so it isn't cut off
+	if m.actionErr != nil {
+		parts = append(parts, fmt.Sprintf("Error: %v", m.actionErr))
+	}
+
 	// File info
-	if m.diffResult.NewFile != "" {
-		parts = append(parts, m.diffResult.NewFile)
+	if len(m.files) == 1 {
+		parts = append(parts, m.files[0].DisplayName())
+	} else {
+		parts = append(parts, fmt.Sprintf("%d files", len(m.files)))
+	}
+	if len(m.series) > 1 {
+		parts = append(parts, fmt.Sprintf("Patch %d/%d", m.patchIndex+1, len(m.series)))
 	}
 
 	// Stats
-	additions, deletions := m.diffResult.CountChanges()
+	additions, deletions := 0, 0
+	for _, file := range m.files {
+		added, deleted := file.CountChanges()
+		additions += added
+		deletions += deleted
+	}
 	parts = append(parts, fmt.Sprintf("+%d -%d", additions, deletions))
+	size := diff.ReviewSizeOf(diff.Stats(m.files))
+	parts = append(parts, fmt.Sprintf("Size %s (%s)", size, size.Estimate()))
 
 	// View mode
 	viewMode := "Unified"
@@ -366,10 +1403,48 @@ func (m Model) renderStatusBar() string {
 		viewMode = "Side-by-Side"
 	}
 	parts = append(parts, viewMode)
+	if m.fullFile {
+		parts = append(parts, "Full file")
+	}
+	if m.dimContext {
+		parts = append(parts, "Dim")
+	}
+	if m.showInvisibles {
+		parts = append(parts, "Invisibles")
+	}
+	if m.visual {
+		first, last := m.selectedRows()
+		parts = append(parts, fmt.Sprintf("Visual (%d rows)", last-first+1))
+	}
+	if approved := m.approvedHunks(); approved > 0 {
+		parts = append(parts, fmt.Sprintf("%d approved", approved))
+	}
+	if staged := m.stagedHunks(); staged > 0 {
+		parts = append(parts, fmt.Sprintf("%d staged", staged))
+	}
+	if ignored := diff.IgnoredChanges(m.files); ignored > 0 {
+		parts = append(parts, fmt.Sprintf("%d ignored", ignored))
+	}
+
+	if target, ok := m.cursorComment(); ok && target.Body != "" {
+		parts = append(parts, "💬 "+diff.TruncateString(target.Body, 40))
+	}
+
+	// Cursor position
+	if _, oldLine, newLine, ok := m.cursorLine(); ok {
+		var position []string
+		if oldLine > 0 {
+			position = append(position, fmt.Sprintf("-%d", oldLine))
+		}
+		if newLine > 0 {
+			position = append(position, fmt.Sprintf("+%d", newLine))
+		}
+		parts = append(parts, "Line "+strings.Join(position, " "))
+	}
 
 	// Line numbers
 	if m.showLineNumbers {
-		parts = append(parts, "Lines: ON")
+		parts = append(parts, "Lines: "+m.gutter.Mode.String())
 	} else {
 		parts = append(parts, "Lines: OFF")
 	}
@@ -377,12 +1452,35 @@ func (m Model) renderStatusBar() string {
 	// Controls hint
 	parts = append(parts, "? for help")
 
+	// A narrow window cuts the bar short rather than wrapping it
 	status := strings.Join(parts, " │ ")
+	if m.windowWidth > 0 {
+		status = diff.TruncateString(status, m.windowWidth)
+	}
 	return style.Render(status)
 }
 
 // Helper functions
 
+// deterministicWidth is the width output is rendered at with
+// --deterministic, the same as when the terminal's width is unknown
+const deterministicWidth = 80
+
+// ciWidth is the width output is rendered at with --ci, which suits the
+// log viewers of CI services
+const ciWidth = 120
+
+// outputWidth returns the width to render non-interactive output at
+func outputWidth(cfg *config.Config) int {
+	if cfg.CI {
+		return ciWidth
+	}
+	if cfg.Deterministic {
+		return deterministicWidth
+	}
+	return getTerminalWidth()
+}
+
 func getTerminalWidth() int {
 	cmd := exec.Command("tput", "cols")
 	output, err := cmd.Output()
@@ -441,24 +1539,61 @@ func showWithPager(content string) error {
 	return nil
 }
 
-func runGitDiff(args []string) (string, error) {
-	cmdArgs := append([]string{"diff", "--no-color", "--no-ext-diff"}, args...)
-	cmd := exec.Command("git", cmdArgs...)
-	output, err := cmd.Output()
-	if err != nil {
-		// Check if it's just an empty diff
-		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
-			return "", nil
+// runGitDiff runs git diff with args between the configured extra options
+// and the passthrough arguments
+func runGitDiff(ctx context.Context, cfg *config.Config, args []string) (string, error) {
+	var cmdArgs []string
+	cmdArgs = append(cmdArgs, cfg.Git.ExtraArgs...)
+	if cfg.Git.IgnoreCRAtEOL {
+		cmdArgs = append(cmdArgs, "--ignore-cr-at-eol")
+	}
+	if cfg.Search.Pattern != "" {
+		// Let git skip files without matching changes; hunks are filtered
+		// after parsing
+		if cfg.Search.Regex {
+			cmdArgs = append(cmdArgs, "-G"+cfg.Search.Pattern)
+		} else {
+			cmdArgs = append(cmdArgs, "-S"+cfg.Search.Pattern)
 		}
+	}
+	cmdArgs = append(cmdArgs, args...)
+	cmdArgs = append(cmdArgs, cfg.Git.Passthrough...)
+	return git.DiffContext(ctx, cmdArgs...)
+}
+
+// worktreeDiff returns the unstaged changes, followed by the untracked
+// files as added files when untracked is set
+func worktreeDiff(ctx context.Context, cfg *config.Config, untracked bool) (string, error) {
+	text, err := runGitDiff(ctx, cfg, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to run git diff: %w", err)
+	}
+	if !untracked {
+		return text, nil
+	}
+	added, err := git.UntrackedDiff(ctx)
+	if err != nil {
 		return "", err
 	}
-	return string(output), nil
+	return text + added, nil
+}
+
+// runPathDiff diffs two files or directories, through git when there are
+// git diff options to honor
+func runPathDiff(ctx context.Context, cfg *config.Config, path1, path2 string) (string, error) {
+	if len(cfg.Git.ExtraArgs) > 0 || len(cfg.Git.Passthrough) > 0 || cfg.Git.IgnoreCRAtEOL {
+		return runGitDiff(ctx, cfg, []string{"--no-index", path1, path2})
+	}
+	return runDiff(ctx, path1, path2)
 }
 
-func runDiff(file1, file2 string) (string, error) {
-	cmd := exec.Command("diff", "-u", file1, file2)
+func runDiff(ctx context.Context, file1, file2 string) (string, error) {
+	cmd := exec.CommandContext(ctx, "diff", "-u", file1, file2)
 	output, err := cmd.Output()
 	if err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
 		// diff returns exit code 1 when files differ, which is normal
 		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
 			return string(output), nil
@@ -468,6 +1603,242 @@ func runDiff(file1, file2 string) (string, error) {
 	return string(output), nil
 }
 
-func countLines(s string) int {
-	return strings.Count(s, "\n") + 1
-}
\ No newline at end of file
+// parseFiles parses a possibly multi-file diff and applies the configured
+// include/exclude filters
+func parseFiles(ctx context.Context, diffText string, cfg *config.Config) ([]*diff.DiffResult, error) {
+	files, err := diff.ParseMultiFileDiffContext(ctx, diffText)
+	if err != nil {
+		return nil, err
+	}
+	filter := diff.FileFilter{
+		Include: cfg.Filters.Include,
+		Exclude: cfg.Filters.Exclude,
+	}
+	filter.Apply(files)
+	// Decoding UTF-16 files reads them again, so only the files --max-files
+	// leaves are decoded
+	decoded := files
+	if n := cfg.Limits.MaxFiles; n > 0 && len(decoded) > n {
+		decoded = decoded[:n]
+	}
+	diff.DecodeFiles(decoded, cfg.Encoding, loadBlob, cfg.Git.DefaultContext)
+	patterns, err := ignorePatterns(cfg)
+	if err != nil {
+		return nil, err
+	}
+	ignored, err := ignoredMode(cfg)
+	if err != nil {
+		return nil, err
+	}
+	diff.IgnoreMatchingLines(files, patterns, ignored == diff.IgnoredHide)
+	generatedFiles(ctx, files, cfg).Apply(files)
+	if cfg.UI.ColorMoved {
+		diff.DetectMovedLines(files)
+	}
+	if cfg.UI.FoldDuplicates {
+		diff.FoldDuplicateHunks(files)
+	}
+
+	if cfg.Search.Pattern != "" {
+		re, err := searchRegexp(cfg)
+		if err != nil {
+			return nil, err
+		}
+		files = diff.FilterChanges(files, re)
+	}
+
+	return files, nil
+}
+
+// searchRegexp compiles the --search-change pattern, matching it literally
+// unless --search-regex is set. It returns nil when not searching.
+func searchRegexp(cfg *config.Config) (*regexp.Regexp, error) {
+	if cfg.Search.Pattern == "" {
+		return nil, nil
+	}
+	if !cfg.Search.Regex {
+		return regexp.MustCompile(regexp.QuoteMeta(cfg.Search.Pattern)), nil
+	}
+	re, err := regexp.Compile(cfg.Search.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid search pattern: %w", err)
+	}
+	return re, nil
+}
+
+// semanticTokens loads the --tokens file, returning nil without one
+func semanticTokens(cfg *config.Config) (diff.SemanticTokens, error) {
+	if cfg.TokensFile == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(cfg.TokensFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tokens: %w", err)
+	}
+	return diff.ParseSemanticTokens(data)
+}
+
+// gutterOptions builds the line-number gutter layout from the config
+func gutterOptions(cfg *config.Config) (diff.Gutter, error) {
+	mode, err := diff.ParseGutterMode(cfg.Gutter.Mode)
+	if err != nil {
+		return diff.Gutter{}, fmt.Errorf("invalid gutter config: %w", err)
+	}
+	return diff.Gutter{
+		Mode:      mode,
+		Width:     cfg.Gutter.Width,
+		Separator: cfg.Gutter.Separator,
+	}, nil
+}
+
+// hunkContextMode parses the function context mode for hunk headers from
+// the config
+func hunkContextMode(cfg *config.Config) (diff.HunkContextMode, error) {
+	mode, err := diff.ParseHunkContextMode(cfg.UI.HunkContext)
+	if err != nil {
+		return diff.HunkContextGit, fmt.Errorf("invalid ui config: %w", err)
+	}
+	return mode, nil
+}
+
+// whitespaceMode parses how whitespace-only changes are shown from the
+// config
+func whitespaceMode(cfg *config.Config) (diff.WhitespaceMode, error) {
+	mode, err := diff.ParseWhitespaceMode(cfg.UI.WhitespaceChanges)
+	if err != nil {
+		return diff.WhitespaceShow, fmt.Errorf("invalid ui config: %w", err)
+	}
+	return mode, nil
+}
+
+// ignoredMode parses how changes matching ignore_matching_lines are shown
+// from the config
+func ignoredMode(cfg *config.Config) (diff.IgnoredMode, error) {
+	mode, err := diff.ParseIgnoredMode(cfg.UI.IgnoredChanges)
+	if err != nil {
+		return diff.IgnoredDim, fmt.Errorf("invalid ui config: %w", err)
+	}
+	return mode, nil
+}
+
+// ignorePatterns compiles the ignore_matching_lines patterns
+func ignorePatterns(cfg *config.Config) ([]*regexp.Regexp, error) {
+	var patterns []*regexp.Regexp
+	for _, pattern := range cfg.Filters.IgnoreMatchingLines {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ignore_matching_lines pattern: %w", err)
+		}
+		patterns = append(patterns, re)
+	}
+	return patterns, nil
+}
+
+// collapseGenerated collapses the generated files of a multi-file diff
+// shown in the viewer, unless the config keeps them expanded, reporting
+// whether it collapsed any. Output outside the viewer shows them whole.
+func (m Model) collapseGenerated(files []*diff.DiffResult) bool {
+	return m.config.UI.CollapseGenerated && diff.CollapseGenerated(files)
+}
+
+// generatedFiles recognizes generated files by the filters.generated globs
+// and, in a git repository, by the linguist-generated attribute
+func generatedFiles(ctx context.Context, files []*diff.DiffResult, cfg *config.Config) diff.GeneratedFiles {
+	generated := diff.GeneratedFiles{Patterns: cfg.Filters.Generated}
+	if cfg.Deterministic || len(files) == 0 {
+		// Deterministic output doesn't depend on the repository's attributes
+		return generated
+	}
+	paths := make([]string, len(files))
+	for i, file := range files {
+		paths[i] = file.DisplayName()
+	}
+	// Outside a repository no file is marked
+	generated.Attributes, _ = git.LinguistGenerated(ctx, paths)
+	return generated
+}
+
+// intralineGranularity parses the units changes within lines are marked
+// in from the config
+func intralineGranularity(cfg *config.Config) (diff.IntralineGranularity, error) {
+	granularity, err := diff.ParseIntralineGranularity(cfg.UI.IntralineGranularity)
+	if err != nil {
+		return diff.IntralineCharacter, fmt.Errorf("invalid ui config: %w", err)
+	}
+	return granularity, nil
+}
+
+// renderStyle parses how changed lines are colored from the config
+func renderStyle(cfg *config.Config) (diff.RenderStyle, error) {
+	style, err := diff.ParseRenderStyle(cfg.UI.Style)
+	if err != nil {
+		return diff.StyleBackground, fmt.Errorf("invalid ui config: %w", err)
+	}
+	return style, nil
+}
+
+// languageOptions checks the lexers --language and the languages config
+// name, returning them for the renderer
+func languageOptions(cfg *config.Config) (diff.Languages, error) {
+	if cfg.Language != "" && themes.LexerName(cfg.Language) == "" {
+		return diff.Languages{}, fmt.Errorf("invalid --language: unknown language %q", cfg.Language)
+	}
+	for pattern, language := range cfg.Languages {
+		if themes.LexerName(language) == "" {
+			return diff.Languages{}, fmt.Errorf("invalid languages config: unknown language %q for %q", language, pattern)
+		}
+	}
+	return diff.Languages{Override: cfg.Language, ByGlob: cfg.Languages}, nil
+}
+
+// isPathPair reports whether args name two paths to compare directly rather
+// than revisions for git diff. Paths win when an argument is both a path and
+// a ref; a "--" separator always means git diff.
+func isPathPair(args []string) bool {
+	if len(args) != 2 {
+		return false
+	}
+	for _, arg := range args {
+		if arg == "--" {
+			return false
+		}
+		if _, err := os.Stat(arg); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// loadBlob reads a file version for binary previews by the git object
+// named in the diff header. The file on disk is read for a side without
+// one, and in place of an object git diff named for the worktree without
+// storing it, when the file still has those contents.
+func loadBlob(path, id string) ([]byte, error) {
+	if strings.Trim(id, "0") == "" {
+		return os.ReadFile(path)
+	}
+	data, err := git.ReadBlob(id)
+	if err == nil {
+		return data, nil
+	}
+	if worktree, readErr := os.ReadFile(path); readErr == nil && git.HasBlobID(worktree, id) {
+		return worktree, nil
+	}
+	return nil, err
+}
+
+// loadBlobPrefix reads the first n bytes of a file version for binary hex
+// dumps, and its size. Versions that aren't objects in the repository are
+// read whole as loadBlob reads them.
+func loadBlobPrefix(path, id string, n int) ([]byte, int64, error) {
+	if strings.Trim(id, "0") != "" {
+		if data, size, err := git.ReadBlobPrefix(id, n); err == nil {
+			return data, size, nil
+		}
+	}
+	data, err := loadBlob(path, id)
+	if err != nil {
+		return nil, 0, err
+	}
+	return data[:min(len(data), n)], int64(len(data)), nil
+}
@@ -0,0 +1,60 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/avgvstvs96/differential/internal/diff"
+	"github.com/avgvstvs96/differential/internal/git"
+)
+
+// isBlobPair reports whether args compare a file at a revision against
+// another revision's version or a worktree file, in either order
+func isBlobPair(args []string) bool {
+	if len(args) != 2 {
+		return false
+	}
+	blobs := 0
+	for _, arg := range args {
+		if git.IsBlobSpec(arg) {
+			blobs++
+		} else if _, err := os.Stat(arg); err != nil {
+			return false
+		}
+	}
+	return blobs > 0
+}
+
+// runBlobDiff diffs two sides that may each be a blob spec or a worktree
+// path, reading both in memory so no temp files are needed
+func runBlobDiff(oldArg, newArg string, contextLines int) (string, error) {
+	oldData, err := readSide(oldArg)
+	if err != nil {
+		return "", err
+	}
+	newData, err := readSide(newArg)
+	if err != nil {
+		return "", err
+	}
+
+	if bytes.IndexByte(oldData, 0) >= 0 || bytes.IndexByte(newData, 0) >= 0 {
+		if bytes.Equal(oldData, newData) {
+			return "", nil
+		}
+		return fmt.Sprintf("Binary files %s and %s differ\n", oldArg, newArg), nil
+	}
+	return diff.UnifiedDiff(oldArg, newArg, string(oldData), string(newData), contextLines), nil
+}
+
+// readSide reads one side of a comparison from git or from disk
+func readSide(arg string) ([]byte, error) {
+	if git.IsBlobSpec(arg) {
+		return git.ReadBlob(arg)
+	}
+	data, err := os.ReadFile(arg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", arg, err)
+	}
+	return data, nil
+}
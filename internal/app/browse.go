@@ -0,0 +1,168 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/avgvstvs96/differential/internal/config"
+	"github.com/avgvstvs96/differential/internal/diff"
+	"github.com/avgvstvs96/differential/internal/fuzzy"
+	"github.com/avgvstvs96/differential/internal/git"
+	"github.com/avgvstvs96/differential/internal/themes"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// openBrowse opens the finder over the repository's tracked files, which
+// are listed the first time it opens
+func (m *Model) openBrowse() {
+	if m.browsePaths == nil {
+		paths, err := git.TrackedFiles(m.ctx)
+		if err != nil {
+			m.err = err
+			return
+		}
+		m.browsePaths = paths
+	}
+	m.mode = ModeBrowse
+	m.filterBrowse()
+}
+
+// filterBrowse ranks the tracked files by the query, moving the cursor to
+// the best match
+func (m *Model) filterBrowse() {
+	m.browseMatches = fuzzy.Find(m.browseQuery, m.browsePaths)
+	m.browseCursor = 0
+}
+
+// showBrowsed shows the diff against HEAD of the file under the finder's
+// cursor, or the whole file when it is unchanged
+func (m *Model) showBrowsed() {
+	if m.browseCursor >= len(m.browseMatches) {
+		return
+	}
+	path := m.browseMatches[m.browseCursor].Str
+	diffText, files, err := browseFile(m.ctx, path, m.config)
+	if err != nil {
+		m.err = err
+		return
+	}
+
+	// Files browsed one after another all return to the diff before them
+	if m.browseFrom == nil {
+		m.browseFrom = m.currentDiff()
+	}
+	m.replaceDiff(diffText, path, files)
+}
+
+// browseFile returns the diff against HEAD of the file at path and its
+// files, or a view of the whole file when it has no changes
+func browseFile(ctx context.Context, path string, cfg *config.Config) (string, []*diff.DiffResult, error) {
+	// Before the first commit there is no HEAD to compare with
+	if git.IsRef("HEAD") {
+		diffText, err := runGitDiff(ctx, cfg, []string{"HEAD", "--", path})
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to run git diff: %w", err)
+		}
+		files, err := parseFiles(ctx, diffText, cfg)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to parse diff: %w", err)
+		}
+		if len(files) > 0 {
+			return diffText, files, nil
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return "", []*diff.DiffResult{diff.FileView(path, string(data))}, nil
+}
+
+// handleBrowseKey handles key presses in the finder, where letters type
+// the query
+func (m Model) handleBrowseKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		return m, tea.Quit
+	case tea.KeyEsc, tea.KeyCtrlO:
+		m.mode = ModeDiff
+	case tea.KeyEnter:
+		m.showBrowsed()
+	case tea.KeyDown, tea.KeyCtrlN:
+		m.browseCursor = min(m.browseCursor+1, max(len(m.browseMatches)-1, 0))
+	case tea.KeyUp, tea.KeyCtrlP:
+		m.browseCursor = max(m.browseCursor-1, 0)
+	case tea.KeyBackspace:
+		if runes := []rune(m.browseQuery); len(runes) > 0 {
+			m.browseQuery = string(runes[:len(runes)-1])
+			m.filterBrowse()
+		}
+	case tea.KeyCtrlU:
+		m.browseQuery = ""
+		m.filterBrowse()
+	case tea.KeySpace:
+		m.browseQuery += " "
+		m.filterBrowse()
+	case tea.KeyRunes:
+		m.browseQuery += string(msg.Runes)
+		m.filterBrowse()
+	}
+	return m, nil
+}
+
+// renderBrowse renders the finder: the query, then the matching files with
+// their matched characters marked
+func (m Model) renderBrowse() string {
+	theme := themes.GetCurrentTheme()
+	titleStyle := lipgloss.NewStyle().Foreground(theme.Text).Bold(true)
+	cursorStyle := lipgloss.NewStyle().Background(theme.Selection).Foreground(theme.Text)
+	mutedStyle := lipgloss.NewStyle().Foreground(theme.TextMuted)
+	matchStyle := lipgloss.NewStyle().Foreground(theme.SyntaxFunction).Bold(true)
+
+	var sb strings.Builder
+	sb.WriteString(titleStyle.Render("Find file › " + m.browseQuery + "█"))
+	sb.WriteString(mutedStyle.Render(fmt.Sprintf(" · %d/%d", len(m.browseMatches), len(m.browsePaths))))
+	sb.WriteString("\n\n")
+
+	// Keep the cursor in view below the title
+	visible := max(m.visibleRows()-2, 1)
+	start := max(m.browseCursor-visible+1, 0)
+	end := min(start+visible, len(m.browseMatches))
+	for i := start; i < end; i++ {
+		match := m.browseMatches[i]
+		if i == m.browseCursor {
+			sb.WriteString(cursorStyle.Width(m.windowWidth).Render(diff.TruncateString(match.Str, m.windowWidth)))
+		} else {
+			sb.WriteString(diff.TruncateString(markMatches(match, matchStyle), m.windowWidth))
+		}
+		sb.WriteString("\n")
+	}
+	for i := end - start; i < visible; i++ {
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString(mutedStyle.Render("type to filter • ↑/↓: move • enter: show • esc: back • ctrl+c: quit"))
+	return sb.String()
+}
+
+// markMatches renders the candidate of match with its matched characters
+// in style
+func markMatches(match fuzzy.Match, style lipgloss.Style) string {
+	matched := make(map[int]bool, len(match.Positions))
+	for _, pos := range match.Positions {
+		matched[pos] = true
+	}
+	var sb strings.Builder
+	for i, r := range []rune(match.Str) {
+		if matched[i] {
+			sb.WriteString(style.Render(string(r)))
+		} else {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,107 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/avgvstvs96/differential/internal/churn"
+	"github.com/avgvstvs96/differential/internal/config"
+	"github.com/avgvstvs96/differential/internal/diff"
+	"github.com/avgvstvs96/differential/internal/git"
+	"github.com/avgvstvs96/differential/internal/themes"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// RunChurn renders a heatmap of the lines changed per file or directory over
+// a revision range
+func RunChurn(revRange string, opts churn.Options, cfg *config.Config) error {
+	// Initialize themes
+	if err := themes.Initialize(); err != nil {
+		return fmt.Errorf("failed to initialize themes: %w", err)
+	}
+
+	// Set theme
+	if err := themes.Default().Set(cfg.UI.Theme); err != nil {
+		return fmt.Errorf("failed to set theme: %w", err)
+	}
+
+	if err := git.ValidateRefs(revRange); err != nil {
+		return err
+	}
+
+	stats, err := git.LogNumStat(revRange)
+	if err != nil {
+		return err
+	}
+
+	// Apply path filters
+	filter := diff.FileFilter{
+		Include: cfg.Filters.Include,
+		Exclude: cfg.Filters.Exclude,
+	}
+	kept := stats[:0]
+	for _, stat := range stats {
+		if filter.Allows(stat.Path) {
+			kept = append(kept, stat)
+		}
+	}
+	stats = kept
+	if len(stats) == 0 {
+		fmt.Printf("No changes in %s\n", revRange)
+		return nil
+	}
+
+	return displayOutput(renderChurn(revRange, churn.Aggregate(stats, opts)), cfg)
+}
+
+// renderChurn renders a churn table as one row per file, with a heat cell
+// for each time bucket followed by the totals
+func renderChurn(revRange string, table *churn.Table) string {
+	theme := themes.GetCurrentTheme()
+	titleStyle := lipgloss.NewStyle().Foreground(theme.Text).Bold(true)
+	mutedStyle := lipgloss.NewStyle().Foreground(theme.TextMuted)
+	addedStyle := lipgloss.NewStyle().Foreground(theme.DiffAdded)
+	removedStyle := lipgloss.NewStyle().Foreground(theme.DiffRemoved)
+
+	nameWidth := 0
+	for _, row := range table.Rows {
+		nameWidth = max(nameWidth, lipgloss.Width(row.Name))
+	}
+
+	var sb strings.Builder
+	sb.WriteString(titleStyle.Render("Churn " + revRange))
+	sb.WriteString(mutedStyle.Render(fmt.Sprintf("  %s → %s",
+		table.Start.Format("2006-01-02"), table.End.Format("2006-01-02"))))
+	sb.WriteString("\n\n")
+
+	for _, row := range table.Rows {
+		sb.WriteString(row.Name)
+		sb.WriteString(strings.Repeat(" ", nameWidth-lipgloss.Width(row.Name)+2))
+		for _, value := range row.Buckets {
+			sb.WriteString(heatCell(theme, value, table.Max))
+		}
+		sb.WriteString("  ")
+		sb.WriteString(addedStyle.Render(fmt.Sprintf("+%d", row.Additions)))
+		sb.WriteString(" ")
+		sb.WriteString(removedStyle.Render(fmt.Sprintf("-%d", row.Deletions)))
+		sb.WriteString(mutedStyle.Render(fmt.Sprintf("  %d commit(s)", row.Commits)))
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
+// heatCell renders one bucket, shading from the panel background towards
+// the removed color as value approaches maxValue
+func heatCell(theme *themes.ThemeColors, value, maxValue int) string {
+	if value == 0 || maxValue == 0 {
+		return lipgloss.NewStyle().Background(theme.BackgroundPanel).Render("  ")
+	}
+	// Keep a visible step between empty and the smallest non-zero value
+	ratio := 0.2 + 0.8*float64(value)/float64(maxValue)
+	color, err := themes.Mix(theme.DiffRemoved, theme.BackgroundPanel, ratio)
+	if err != nil {
+		color = theme.DiffRemoved
+	}
+	return lipgloss.NewStyle().Background(color).Render("  ")
+}
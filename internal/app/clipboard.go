@@ -0,0 +1,40 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+)
+
+// clipboardCommands lists the paste commands to try, in order, per platform
+var clipboardCommands = map[string][][]string{
+	"darwin":  {{"pbpaste"}},
+	"windows": {{"powershell.exe", "-NoProfile", "-Command", "Get-Clipboard -Raw"}},
+	"linux": {
+		{"wl-paste", "--no-newline"},
+		{"xclip", "-selection", "clipboard", "-o"},
+		{"xsel", "--clipboard", "--output"},
+	},
+}
+
+// readClipboard returns the system clipboard contents using the first
+// available paste command
+func readClipboard() (string, error) {
+	commands, ok := clipboardCommands[runtime.GOOS]
+	if !ok {
+		commands = clipboardCommands["linux"]
+	}
+
+	for _, command := range commands {
+		if _, err := exec.LookPath(command[0]); err != nil {
+			continue
+		}
+		output, err := exec.Command(command[0], command[1:]...).Output()
+		if err != nil {
+			return "", fmt.Errorf("failed to read clipboard with %s: %w", command[0], err)
+		}
+		return string(output), nil
+	}
+	return "", errors.New("no clipboard tool found (install wl-clipboard, xclip or xsel)")
+}
@@ -0,0 +1,321 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/avgvstvs96/differential/internal/diff"
+	"github.com/avgvstvs96/differential/internal/git"
+	"github.com/avgvstvs96/differential/internal/review"
+	"github.com/avgvstvs96/differential/internal/themes"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// commentKey returns the repository and commit comments are stored under:
+// the top-level directory and HEAD, which is empty before the first commit
+func commentKey() (root, commit string) {
+	root = repoRoot()
+	if root == "" {
+		return "", ""
+	}
+	commit, _ = git.ResolveRef("HEAD")
+	return root, commit
+}
+
+// loadComments loads the comments saved for the repository and commit.
+// Outside a repository comments only last for the session.
+func (m *Model) loadComments() {
+	m.commentRoot, m.commentCommit = commentKey()
+	if m.commentRoot == "" {
+		return
+	}
+	m.commentsFile = review.DefaultPath()
+	store, err := review.Load(m.commentsFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ignoring saved comments: %v\n", err)
+		return
+	}
+	m.comments = store.Comments(m.commentRoot, m.commentCommit)
+}
+
+// saveComments writes the comments to the store, keeping those of other
+// repositories and commits
+func (m *Model) saveComments() {
+	if m.commentsFile == "" {
+		return
+	}
+	store, err := review.Load(m.commentsFile)
+	if err != nil {
+		// Replace an unreadable store rather than losing the new comments
+		store = &review.Store{}
+	}
+	store.SetComments(m.commentRoot, m.commentCommit, m.comments)
+	if err := store.Save(m.commentsFile); err != nil {
+		m.err = err
+	}
+}
+
+// cursorComment returns the line under the cursor as the target of a
+// comment, with the body of its comment if it has one
+func (m Model) cursorComment() (review.Comment, bool) {
+	file, oldLine, newLine, ok := m.cursorLine()
+	if !ok {
+		return review.Comment{}, false
+	}
+	target := review.Comment{Path: file.DisplayName(), Line: newLine, Side: review.SideNew}
+	if newLine == 0 {
+		target.Line, target.Side = oldLine, review.SideOld
+	}
+	target.Text = lineText(file, oldLine, newLine)
+	if i := review.Find(m.comments, target.Path, target.Side, target.Line); i >= 0 {
+		target.Body = m.comments[i].Body
+	}
+	return target, true
+}
+
+// lineText returns the content of the new line newLine of file, or of the
+// old line oldLine when newLine is 0
+func lineText(file *diff.DiffResult, oldLine, newLine int) string {
+	for _, hunk := range file.Hunks {
+		for _, line := range hunk.Lines {
+			if (newLine > 0 && line.NewLineNo == newLine) || (newLine == 0 && line.OldLineNo == oldLine) {
+				return line.Content
+			}
+		}
+	}
+	return ""
+}
+
+// startComment starts writing a comment on target from mode, to which
+// the view returns when the comment is done
+func (m *Model) startComment(target review.Comment, from Mode) {
+	m.commentTarget = target
+	m.input = target.Body
+	m.inputFrom = from
+	m.mode = ModeComment
+}
+
+// finishComment saves the comment being written, or removes the comment
+// when its body was cleared
+func (m *Model) finishComment() {
+	m.mode = m.inputFrom
+	target := m.commentTarget
+	target.Body = strings.TrimSpace(m.input)
+	m.input = ""
+
+	i := review.Find(m.comments, target.Path, target.Side, target.Line)
+	switch {
+	case target.Body == "" && i < 0:
+		return
+	case target.Body == "":
+		m.comments = append(m.comments[:i:i], m.comments[i+1:]...)
+	case i >= 0:
+		m.comments[i].Body = target.Body
+	default:
+		m.comments = append(m.comments, target)
+		review.Sort(m.comments)
+	}
+	m.commentCursor = min(m.commentCursor, max(len(m.comments)-1, 0))
+	m.saveComments()
+}
+
+// handleCommentInput handles key presses while a comment is written
+func (m Model) handleCommentInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		return m, tea.Quit
+	case tea.KeyEnter:
+		m.finishComment()
+	case tea.KeyEsc:
+		m.mode = m.inputFrom
+		m.input = ""
+	case tea.KeyBackspace:
+		if runes := []rune(m.input); len(runes) > 0 {
+			m.input = string(runes[:len(runes)-1])
+		}
+	case tea.KeyCtrlU:
+		m.input = ""
+	case tea.KeySpace:
+		m.input += " "
+	case tea.KeyRunes:
+		m.input += string(msg.Runes)
+	}
+	return m, nil
+}
+
+// renderCommentInput renders the comment being written in place of the
+// status bar
+func (m Model) renderCommentInput() string {
+	theme := themes.GetCurrentTheme()
+	style := lipgloss.NewStyle().
+		Background(theme.BackgroundPanel).
+		Foreground(theme.Text).
+		Width(m.windowWidth)
+	prompt := fmt.Sprintf("Comment on %s › ", commentLocation(m.commentTarget))
+
+	// Keep the end of a long comment in view
+	text := []rune(m.input + "█")
+	if room := m.windowWidth - lipgloss.Width(prompt); room > 0 && len(text) > room {
+		text = text[len(text)-room:]
+	}
+	return style.Render(prompt + string(text))
+}
+
+// commentLocation names the line of a comment, e.g. main.go:+12 for a
+// line of the new file and main.go:-3 for a removed line
+func commentLocation(c review.Comment) string {
+	sign := "+"
+	if c.Side == review.SideOld {
+		sign = "-"
+	}
+	return fmt.Sprintf("%s:%s%d", c.Path, sign, c.Line)
+}
+
+// showComment returns to the diff with the cursor on the line of the
+// comment under the panel's cursor
+func (m *Model) showComment() {
+	m.mode = ModeDiff
+	if m.commentCursor >= len(m.comments) {
+		return
+	}
+	c := m.comments[m.commentCursor]
+	oldLine, newLine := 0, c.Line
+	if c.Side == review.SideOld {
+		oldLine, newLine = c.Line, 0
+	}
+	for _, file := range m.files {
+		if file.DisplayName() != c.Path {
+			continue
+		}
+		if row, ok := m.renderer.get(m.renderOptions()).RowOf(m.files, file, oldLine, newLine); ok {
+			m.cursor = row + strings.Count(m.header, "\n")
+			m.followCursor()
+		}
+		return
+	}
+}
+
+// handleCommentsKey handles key presses in the comment panel
+func (m Model) handleCommentsKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q", "ctrl+c":
+		return m, tea.Quit
+
+	case "j", "down":
+		m.commentCursor = min(m.commentCursor+1, max(len(m.comments)-1, 0))
+
+	case "k", "up":
+		m.commentCursor = max(m.commentCursor-1, 0)
+
+	case "enter":
+		m.showComment()
+
+	case "c", "e":
+		if m.commentCursor < len(m.comments) {
+			m.startComment(m.comments[m.commentCursor], ModeComments)
+		}
+
+	case "d", "x":
+		if m.commentCursor < len(m.comments) {
+			m.comments = append(m.comments[:m.commentCursor:m.commentCursor], m.comments[m.commentCursor+1:]...)
+			m.commentCursor = min(m.commentCursor, max(len(m.comments)-1, 0))
+			m.saveComments()
+		}
+
+	case "esc", "C":
+		m.mode = ModeDiff
+	}
+	return m, nil
+}
+
+// renderComments renders the comment panel, in file and line order
+func (m Model) renderComments() string {
+	theme := themes.GetCurrentTheme()
+	titleStyle := lipgloss.NewStyle().Foreground(theme.Text).Bold(true)
+	cursorStyle := lipgloss.NewStyle().Background(theme.Selection).Foreground(theme.Text)
+	mutedStyle := lipgloss.NewStyle().Foreground(theme.TextMuted)
+
+	var sb strings.Builder
+	title := "1 comment"
+	if len(m.comments) != 1 {
+		title = fmt.Sprintf("%d comments", len(m.comments))
+	}
+	sb.WriteString(titleStyle.Render(title))
+	switch {
+	case m.commentsFile == "" && m.config.SessionFile != "":
+		sb.WriteString(mutedStyle.Render(" · saved in " + m.config.SessionFile))
+	case m.commentsFile == "":
+		sb.WriteString(mutedStyle.Render(" · not saved outside a repository"))
+	}
+	sb.WriteString("\n\n")
+
+	// Keep the cursor in view below the title
+	visible := max(m.visibleRows()-2, 1)
+	start := max(m.commentCursor-visible+1, 0)
+	end := min(start+visible, len(m.comments))
+	for i := start; i < end; i++ {
+		c := m.comments[i]
+		location := commentLocation(c)
+		if i == m.commentCursor {
+			line := location + "  " + c.Body
+			sb.WriteString(cursorStyle.Width(m.windowWidth).Render(diff.TruncateString(line, m.windowWidth)))
+		} else {
+			line := mutedStyle.Render(location) + "  " + c.Body
+			sb.WriteString(diff.TruncateString(line, m.windowWidth))
+		}
+		sb.WriteString("\n")
+	}
+	for i := end - start; i < visible; i++ {
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString(mutedStyle.Render("enter: show in diff • c: edit • d: delete • esc: back • q: quit"))
+	return sb.String()
+}
+
+// Formats accepted by RunComments
+const (
+	CommentFormatMarkdown = "markdown"
+	CommentFormatGitHub   = "github"
+)
+
+// RunComments prints the comments saved for the current repository and
+// commit as a markdown review, or as a GitHub review payload commenting on
+// commitID (HEAD when empty)
+func RunComments(format, commitID string) error {
+	root, commit := commentKey()
+	if root == "" {
+		return fmt.Errorf("comments are only saved inside a git repository")
+	}
+	store, err := review.Load(review.DefaultPath())
+	if err != nil {
+		return err
+	}
+	comments := store.Comments(root, commit)
+
+	switch format {
+	case "", CommentFormatMarkdown:
+		if len(comments) == 0 {
+			fmt.Fprintln(os.Stderr, "No comments")
+			return nil
+		}
+		title := "Review"
+		if commit != "" {
+			title = "Review of " + commit[:min(len(commit), 7)]
+		}
+		fmt.Print(review.Markdown(title, comments))
+		return nil
+	case CommentFormatGitHub:
+		if commitID == "" {
+			commitID = commit
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(review.NewGitHubReview(commitID, comments))
+	default:
+		return fmt.Errorf("unknown comment format %q (want markdown or github)", format)
+	}
+}
@@ -0,0 +1,69 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/avgvstvs96/differential/internal/commit"
+	"github.com/avgvstvs96/differential/internal/config"
+	"github.com/avgvstvs96/differential/internal/themes"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// renderCommitLint renders a metadata panel with the lint findings for every
+// commit in git log/show output. It returns an empty string when linting is
+// off or the diff has no commit headers.
+func renderCommitLint(diffText string, cfg *config.Config, width int) string {
+	if !cfg.Lint.Commits {
+		return ""
+	}
+	commits := commit.Parse(diffText)
+	if len(commits) == 0 {
+		return ""
+	}
+
+	theme := themes.GetCurrentTheme()
+	titleStyle := lipgloss.NewStyle().Foreground(theme.Text).Bold(true)
+	mutedStyle := lipgloss.NewStyle().Foreground(theme.TextMuted)
+	warnStyle := lipgloss.NewStyle().Foreground(theme.DiffRemoved)
+	okStyle := lipgloss.NewStyle().Foreground(theme.DiffAdded)
+	panelStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(theme.Border).
+		Padding(0, 1)
+	if width > 4 {
+		panelStyle = panelStyle.Width(width - 2)
+	}
+
+	opts := commit.LintOptions{
+		SubjectLength:  cfg.Lint.SubjectLength,
+		BodyWidth:      cfg.Lint.BodyWidth,
+		RequireSignoff: cfg.Lint.RequireSignoff,
+	}
+
+	var sb strings.Builder
+	for _, c := range commits {
+		var lines []string
+		lines = append(lines, titleStyle.Render(c.ShortHash()+" "+c.Subject()))
+		if c.Author != "" {
+			lines = append(lines, mutedStyle.Render(fmt.Sprintf("%s <%s>", c.Author, c.AuthorEmail)))
+		}
+
+		findings := commit.Lint(c, opts)
+		if len(findings) == 0 {
+			lines = append(lines, okStyle.Render("✓ message looks good"))
+		}
+		for _, f := range findings {
+			location := ""
+			if f.Line > 0 {
+				location = fmt.Sprintf("line %d: ", f.Line)
+			}
+			lines = append(lines, warnStyle.Render(fmt.Sprintf("⚠ %s%s", location, f.Message))+
+				mutedStyle.Render(" ["+f.Rule+"]"))
+		}
+
+		sb.WriteString(panelStyle.Render(strings.Join(lines, "\n")))
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,186 @@
+package app
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/avgvstvs96/differential/internal/config"
+	"github.com/avgvstvs96/differential/internal/diff"
+	"github.com/avgvstvs96/differential/internal/git"
+	"github.com/avgvstvs96/differential/internal/themes"
+)
+
+// conflictMarker is the start marker git writes into conflicted files
+const conflictMarker = "<<<<<<<"
+
+// RunConflicts previews the conflicts a merge of theirs into ours would produce
+func RunConflicts(ours, theirs string, cfg *config.Config) error {
+	// Initialize themes
+	if err := themes.Initialize(); err != nil {
+		return fmt.Errorf("failed to initialize themes: %w", err)
+	}
+
+	// Set theme
+	if err := themes.Default().Set(cfg.UI.Theme); err != nil {
+		return fmt.Errorf("failed to set theme: %w", err)
+	}
+
+	if err := git.ValidateRefs(ours, theirs); err != nil {
+		return err
+	}
+	languages, err := languageOptions(cfg)
+	if err != nil {
+		return err
+	}
+
+	preview, err := git.MergeTree(ours, theirs)
+	if err != nil {
+		return err
+	}
+
+	theme := themes.GetCurrentTheme()
+	titleStyle := lipgloss.NewStyle().Foreground(theme.Text).Bold(true)
+	fileStyle := lipgloss.NewStyle().Foreground(theme.DiffRemoved).Bold(true)
+
+	if !preview.HasConflicts() {
+		fmt.Println(titleStyle.Render(fmt.Sprintf("%s and %s merge cleanly", ours, theirs)))
+		return nil
+	}
+
+	opts := diff.RenderOptions{
+		Width:           outputWidth(cfg),
+		ShowLineNumbers: cfg.UI.LineNumbers,
+		ContextLines:    cfg.Git.DefaultContext,
+		TabWidth:        cfg.UI.TabWidth,
+		ViewMode:        diff.ViewUnified,
+		Languages:       languages,
+	}
+
+	var sb strings.Builder
+	sb.WriteString(titleStyle.Render(fmt.Sprintf("Merging %s into %s would conflict in %d file(s)",
+		theirs, ours, len(preview.Conflicts))))
+	sb.WriteString("\n\n")
+
+	hintStyle := lipgloss.NewStyle().Foreground(theme.DiffAdded).Bold(true)
+	resolutions := make(map[string]*git.Resolution)
+
+	for _, path := range preview.Conflicts {
+		diffText, err := preview.ConflictDiff(path, cfg.Git.DefaultContext)
+		if err != nil {
+			return fmt.Errorf("failed to diff %s: %w", path, err)
+		}
+
+		result, err := diff.ParseUnifiedDiff(diffText)
+		if err != nil {
+			return fmt.Errorf("failed to parse diff for %s: %w", path, err)
+		}
+
+		// Only keep the hunks that carry conflict markers
+		result.Hunks = conflictHunks(result.Hunks)
+
+		sb.WriteString(fileStyle.Render(fmt.Sprintf("✗ %s (%d conflicting hunk(s))", path, len(result.Hunks))))
+		sb.WriteString("\n")
+		sb.WriteString(diff.RenderUnifiedDiff(result, opts))
+
+		// Show what a recorded rerere resolution would do to the conflict
+		resolution, diffText, err := recordedResolution(preview, path, cfg.Git.DefaultContext)
+		if err != nil {
+			return err
+		}
+		if resolution == nil {
+			continue
+		}
+		resolutions[path] = resolution
+
+		resolved, err := diff.ParseUnifiedDiff(diffText)
+		if err != nil {
+			return fmt.Errorf("failed to parse resolution for %s: %w", path, err)
+		}
+		sb.WriteString(hintStyle.Render(fmt.Sprintf("↺ %s has a recorded resolution (rerere %s)", path, resolution.ID[:8])))
+		sb.WriteString("\n")
+		sb.WriteString(diff.RenderUnifiedDiff(resolved, opts))
+	}
+
+	if err := displayOutput(sb.String(), cfg); err != nil {
+		return err
+	}
+
+	return offerResolutions(preview, resolutions)
+}
+
+// recordedResolution looks up the rerere resolution for a conflicted path and
+// returns it with the diff from the conflicted to the resolved file
+func recordedResolution(preview *git.MergePreview, path string, contextLines int) (*git.Resolution, string, error) {
+	content, err := preview.ConflictedContent(path)
+	if err != nil {
+		return nil, "", err
+	}
+	resolution, err := git.RecordedResolution(content)
+	if err != nil || resolution == nil {
+		return nil, "", err
+	}
+	diffText := diff.UnifiedDiff("a/"+path, "b/"+path, string(content), string(resolution.Postimage), contextLines)
+	return resolution, diffText, nil
+}
+
+// offerResolutions asks whether to apply each recorded resolution to the
+// worktree. Resolutions only apply to files that are conflicted in a merge in
+// progress with the same conflict.
+func offerResolutions(preview *git.MergePreview, resolutions map[string]*git.Resolution) error {
+	if len(resolutions) == 0 {
+		return nil
+	}
+	if !git.MergeInProgress() {
+		fmt.Printf("Start the merge (git merge %s) to apply recorded resolutions\n", preview.Theirs)
+		return nil
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	for _, path := range preview.Conflicts {
+		resolution, ok := resolutions[path]
+		if !ok {
+			continue
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		if id, _ := git.RerereID(content); id != resolution.ID {
+			continue
+		}
+
+		fmt.Printf("Apply recorded resolution to %s? [y/N] ", path)
+		answer, _ := reader.ReadString('\n')
+		if !strings.EqualFold(strings.TrimSpace(answer), "y") {
+			continue
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, resolution.Postimage, info.Mode().Perm()); err != nil {
+			return fmt.Errorf("failed to apply resolution to %s: %w", path, err)
+		}
+		fmt.Printf("Resolved %s (review it, then git add %s)\n", path, path)
+	}
+	return nil
+}
+
+// conflictHunks returns the hunks that contain a conflict start marker
+func conflictHunks(hunks []diff.Hunk) []diff.Hunk {
+	var conflicting []diff.Hunk
+	for _, hunk := range hunks {
+		for _, line := range hunk.Lines {
+			if line.Kind == diff.LineAdded && strings.HasPrefix(line.Content, conflictMarker) {
+				conflicting = append(conflicting, hunk)
+				break
+			}
+		}
+	}
+	return conflicting
+}
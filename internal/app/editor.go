@@ -0,0 +1,89 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// openEditor returns a command that opens the file under the cursor in the
+// editor, at the cursor's line or, for removed lines and headers, the first
+// new-file line below it
+func (m Model) openEditor() tea.Cmd {
+	row := m.cursor - strings.Count(m.header, "\n")
+	file, line, ok := m.renderer.get(m.renderOptions()).NewLineAt(m.files, row)
+	if !ok {
+		return nil
+	}
+
+	cmd, err := editorCommand(m.config.Editor.Command, editorPath(file.NewFile), line)
+	if err != nil {
+		return func() tea.Msg { return actionErrMsg{err} }
+	}
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		if err != nil {
+			return actionErrMsg{fmt.Errorf("failed to run editor: %w", err)}
+		}
+		return nil
+	})
+}
+
+// actionErrMsg carries the failure of an action run in the background, like
+// the editor, to the status bar
+type actionErrMsg struct {
+	err error
+}
+
+// editorCommand builds the command opening path at line from a template
+// using {file} and {line}. Without a template $VISUAL or $EDITOR is run
+// with +line, which vi, nano and emacs understand.
+func editorCommand(template, path string, line int) (*exec.Cmd, error) {
+	if template == "" {
+		editor := os.Getenv("VISUAL")
+		if editor == "" {
+			editor = os.Getenv("EDITOR")
+		}
+		if editor == "" {
+			editor = "vi"
+		}
+		template = editor + " +{line} {file}"
+	}
+
+	fields := strings.Fields(template)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty editor command")
+	}
+	replacer := strings.NewReplacer("{file}", path, "{line}", strconv.Itoa(line))
+	for i, field := range fields {
+		fields[i] = replacer.Replace(field)
+	}
+	if !strings.Contains(template, "{file}") {
+		fields = append(fields, path)
+	}
+	return exec.Command(fields[0], fields[1:]...), nil
+}
+
+// editorPath resolves a path from a diff, which git gives relative to the
+// repository root, against the working directory
+func editorPath(name string) string {
+	if filepath.IsAbs(name) {
+		return name
+	}
+	if root := repoRoot(); root != "" {
+		if path := filepath.Join(root, name); fileExists(path) {
+			return path
+		}
+	}
+	return name
+}
+
+// fileExists reports whether path names an existing file
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,71 @@
+package app
+
+import "github.com/avgvstvs96/differential/internal/diff"
+
+// contextStep is how many lines of context + and - add or take away
+const contextStep = 3
+
+// changeFileContext regenerates the hunks of the file under the cursor with
+// delta more lines of context, starting from default_context, and keeps
+// the cursor on its line
+func (m *Model) changeFileContext(delta int) error {
+	i, _, ok := m.fileAtCursor()
+	if !ok {
+		return nil
+	}
+	file := m.files[i]
+	current, ok := m.fileContext[file]
+	if !ok {
+		current = m.contextLines
+	}
+	lines := max(current+delta, 0)
+	if lines == current {
+		return nil
+	}
+
+	var err error
+	m.keepPosition(func() {
+		if err = diff.RegenerateContext(file, lines, loadBlob); err != nil {
+			return
+		}
+		if m.fileContext == nil {
+			m.fileContext = make(map[*diff.DiffResult]int)
+		}
+		m.fileContext[file] = lines
+		m.refoldHunks()
+		m.renderer.get(m.renderOptions()).Reset()
+	})
+	return err
+}
+
+// keepFileContext regenerates the files of a reloaded diff that were shown
+// with more or less context before, by name, dropping those that no
+// longer can be
+func (m *Model) keepFileContext(files []*diff.DiffResult) {
+	if len(m.fileContext) == 0 {
+		return
+	}
+	byName := make(map[string]int)
+	for _, file := range m.files {
+		if lines, ok := m.fileContext[file]; ok {
+			byName[file.DisplayName()] = lines
+		}
+	}
+	m.fileContext = make(map[*diff.DiffResult]int)
+	for _, file := range files {
+		lines, ok := byName[file.DisplayName()]
+		if ok && diff.RegenerateContext(file, lines, loadBlob) == nil {
+			m.fileContext[file] = lines
+		}
+	}
+}
+
+// refoldHunks marks the hunks repeated across files and those of approved
+// changes again, after hunks were rebuilt
+func (m *Model) refoldHunks() {
+	if m.foldDuplicates {
+		diff.UnfoldDuplicateHunks(m.files)
+		diff.FoldDuplicateHunks(m.files)
+	}
+	diff.MarkApproved(m.files, m.approved)
+}
@@ -0,0 +1,186 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/avgvstvs96/differential/internal/commit"
+	"github.com/avgvstvs96/differential/internal/config"
+	"github.com/avgvstvs96/differential/internal/diff"
+	"github.com/avgvstvs96/differential/internal/git"
+	"github.com/avgvstvs96/differential/internal/themes"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// RunLineLog renders the history of functions or line ranges given as git
+// log -L ranges, newest commit first, with the traced function's header
+// pinned above each commit's diff
+func RunLineLog(ctx context.Context, ranges, revs []string, cfg *config.Config) error {
+	// Initialize themes
+	if err := themes.Initialize(); err != nil {
+		return fmt.Errorf("failed to initialize themes: %w", err)
+	}
+
+	// Set theme
+	if err := themes.Default().Set(cfg.UI.Theme); err != nil {
+		return fmt.Errorf("failed to set theme: %w", err)
+	}
+
+	if err := git.ValidateRevisionArgs(revs); err != nil {
+		return err
+	}
+	logText, err := git.LineLog(ranges, append(revs, cfg.Git.Passthrough...)...)
+	if err != nil {
+		return fmt.Errorf("failed to run git log: %w", err)
+	}
+	if strings.TrimSpace(logText) == "" {
+		fmt.Printf("No history for %s\n", strings.Join(ranges, ", "))
+		return nil
+	}
+
+	gutter, err := gutterOptions(cfg)
+	if err != nil {
+		return err
+	}
+	hunkContext, err := hunkContextMode(cfg)
+	if err != nil {
+		return err
+	}
+	whitespace, err := whitespaceMode(cfg)
+	if err != nil {
+		return err
+	}
+	intraline, err := intralineGranularity(cfg)
+	if err != nil {
+		return err
+	}
+	style, err := renderStyle(cfg)
+	if err != nil {
+		return err
+	}
+	ignored, err := ignoredMode(cfg)
+	if err != nil {
+		return err
+	}
+	languages, err := languageOptions(cfg)
+	if err != nil {
+		return err
+	}
+	opts := diff.RenderOptions{
+		Width:           outputWidth(cfg),
+		ShowLineNumbers: cfg.UI.LineNumbers,
+		ContextLines:    cfg.Git.DefaultContext,
+		TabWidth:        cfg.UI.TabWidth,
+		Gutter:          gutter,
+		HunkContext:     hunkContext,
+		Whitespace:      whitespace,
+		IgnoredChanges:  ignored,
+		Intraline:       intraline,
+		Style:           style,
+		HunkStats:       cfg.UI.HunkStats,
+		FoldContext:     cfg.UI.FoldContext,
+		BinaryBytes:     cfg.UI.BinaryDiffBytes,
+		DimContext:      cfg.UI.DimContext,
+		ShowInvisibles:  cfg.UI.ShowInvisibles,
+		PlainColumns:    cfg.UI.PlainColumns,
+		Accessibility:   cfg.UI.Accessibility,
+		Languages:       languages,
+		LoadBlob:        loadBlob,
+		LoadBlobPrefix:  loadBlobPrefix,
+	}
+	if cfg.UI.DefaultView == "side-by-side" {
+		opts.ViewMode = diff.ViewSideBySide
+	}
+
+	output, err := renderLineLog(ctx, logText, opts, cfg)
+	if err != nil {
+		return err
+	}
+	return displayOutput(output, cfg)
+}
+
+// renderLineLog renders git log -L output as one section per commit: the
+// commit's subject, the header of each traced range and its diff
+func renderLineLog(ctx context.Context, logText string, opts diff.RenderOptions, cfg *config.Config) (string, error) {
+	theme := themes.GetCurrentTheme()
+	titleStyle := lipgloss.NewStyle().Foreground(theme.Text).Bold(true)
+	mutedStyle := lipgloss.NewStyle().Foreground(theme.TextMuted)
+	pinStyle := lipgloss.NewStyle().
+		Foreground(theme.SyntaxFunction).
+		Background(theme.BackgroundPanel).
+		Bold(true)
+	if opts.Width > 0 {
+		pinStyle = pinStyle.Width(opts.Width)
+	}
+
+	type section struct {
+		commit *commit.Commit
+		files  []*diff.DiffResult
+	}
+	var sections []section
+	for _, chunk := range commit.Split(logText) {
+		commits := commit.Parse(chunk)
+		files, err := parseFiles(ctx, chunk, cfg)
+		if err != nil {
+			return "", fmt.Errorf("failed to parse git log output: %w", err)
+		}
+		if len(commits) == 1 {
+			sections = append(sections, section{commit: commits[0], files: files})
+		}
+	}
+
+	// Later commits describe the range in its current form, so the newest
+	// version of each range names the function for all of them
+	headers := make(map[string]string)
+	for _, s := range sections {
+		for _, file := range s.files {
+			if _, ok := headers[file.NewFile]; !ok {
+				headers[file.NewFile] = rangeHeader(file)
+			}
+		}
+	}
+
+	renderer := diff.NewRenderer(opts)
+	var sb strings.Builder
+	for _, s := range sections {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		sb.WriteString(titleStyle.Render(s.commit.ShortHash() + " " + s.commit.Subject()))
+		if s.commit.Author != "" {
+			sb.WriteString(mutedStyle.Render("  " + s.commit.Author))
+		}
+		sb.WriteString("\n")
+		for _, file := range s.files {
+			if header := headers[file.NewFile]; header != "" {
+				pin := fmt.Sprintf("%s  ƒ %s", file.DisplayName(), header)
+				if opts.Width > 0 {
+					pin = diff.TruncateString(pin, opts.Width)
+				}
+				sb.WriteString(pinStyle.Render(pin))
+				sb.WriteString("\n")
+			}
+			rendered, err := renderer.RenderFilesContext(ctx, []*diff.DiffResult{file})
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString(rendered)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String(), nil
+}
+
+// rangeHeader returns the first line of a traced range, which for a
+// :funcname range is the function's declaration
+func rangeHeader(file *diff.DiffResult) string {
+	for _, hunk := range file.Hunks {
+		for _, line := range hunk.Lines {
+			if line.Kind != diff.LineRemoved {
+				return strings.TrimSpace(line.Content)
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,40 @@
+package app
+
+import (
+	"context"
+	"os"
+
+	"github.com/avgvstvs96/differential/internal/config"
+	"github.com/avgvstvs96/differential/internal/diff"
+	"github.com/avgvstvs96/differential/internal/forge"
+	"github.com/avgvstvs96/differential/internal/git"
+)
+
+// hyperlinks returns links from file headers and line numbers to the files
+// on GitHub or GitLab, for diffs of args between two commits of a
+// repository whose origin remote is hosted there. It returns nil when
+// ui.hyperlinks is off, for output files and pipes, which would keep the
+// escape sequences, and for other diffs.
+func hyperlinks(ctx context.Context, args []string, cfg *config.Config) diff.LinkFunc {
+	if !cfg.UI.Hyperlinks || cfg.Output.Path != "" || !isTerminal(os.Stdout) {
+		return nil
+	}
+	from, to, ok := git.ResolveRange(args)
+	if !ok {
+		return nil
+	}
+	remote, err := git.RunContext(ctx, "remote", "get-url", "origin")
+	if err != nil {
+		return nil
+	}
+	repo, err := forge.ParseRemote(remote)
+	if err != nil {
+		return nil
+	}
+	return func(path string, line int, old bool) string {
+		if old {
+			return repo.BlobURL(from, path, line)
+		}
+		return repo.BlobURL(to, path, line)
+	}
+}
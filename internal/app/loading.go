@@ -0,0 +1,155 @@
+package app
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/avgvstvs96/differential/internal/themes"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// spinnerFrames are drawn in turn while the diff loads
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// spinnerInterval is how long each spinner frame is shown
+const spinnerInterval = 100 * time.Millisecond
+
+// loader is the state of the viewer while its diff is made, parsed and
+// first rendered in the background. The viewer shows a spinner naming the
+// step under way and only takes q and ctrl+c until it's done.
+type loader struct {
+	load    func(step func(string)) (Model, error) // Makes the viewer
+	step    *loadStep
+	started time.Time
+	frame   int
+	loaded  bool // Whether load returned
+	// rendering is set once the first render started, which needs the
+	// window's size
+	rendering bool
+}
+
+// loadStep is the name of the step the background load is at, shared
+// between it and the viewer
+type loadStep struct {
+	mu   sync.Mutex
+	name string
+}
+
+func (s *loadStep) set(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.name = name
+}
+
+func (s *loadStep) get() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.name
+}
+
+// spinnerMsg advances the spinner
+type spinnerMsg struct{}
+
+// loadedMsg carries the viewer load made, or the error it failed with
+type loadedMsg struct {
+	model Model
+	err   error
+}
+
+// renderedMsg tells the first render of the loaded diff is done
+type renderedMsg struct{}
+
+// loadingModel returns a viewer that runs load in the background and then
+// becomes the viewer load returns. load names each step it starts with
+// step. An error from load quits the viewer, and runModel returns it.
+func loadingModel(m Model, load func(step func(string)) (Model, error)) Model {
+	m.loading = &loader{load: load, step: &loadStep{name: "Loading"}, started: time.Now()}
+	return m
+}
+
+// initLoading starts the spinner and the background load
+func (m Model) initLoading() tea.Cmd {
+	l := m.loading
+	return tea.Batch(spinnerTick(), func() tea.Msg {
+		model, err := l.load(l.step.set)
+		return loadedMsg{model, err}
+	})
+}
+
+func spinnerTick() tea.Cmd {
+	return tea.Tick(spinnerInterval, func(time.Time) tea.Msg { return spinnerMsg{} })
+}
+
+// updateLoading handles messages while the diff loads
+func (m Model) updateLoading(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		// The layout waits for the loaded viewer
+		m.windowWidth, m.windowHeight = msg.Width, msg.Height
+		m.ready = true
+		if m.loading.loaded && !m.loading.rendering {
+			return m, m.renderFirst()
+		}
+		return m, nil
+
+	case tea.KeyMsg:
+		if key := msg.String(); key == "ctrl+c" || key == m.config.Keybindings.Quit {
+			return m, tea.Quit
+		}
+		return m, nil
+
+	case spinnerMsg:
+		m.loading.frame++
+		return m, spinnerTick()
+
+	case loadedMsg:
+		if msg.err != nil {
+			m.loadErr = msg.err
+			return m, tea.Quit
+		}
+		loaded := msg.model
+		init := loaded.Init()
+		loaded.windowWidth, loaded.windowHeight, loaded.ready = m.windowWidth, m.windowHeight, m.ready
+		loaded.loading = m.loading
+		loaded.loading.loaded = true
+		loaded.loading.step.set("Rendering")
+		if !loaded.ready {
+			return loaded, init
+		}
+		return loaded, tea.Batch(init, loaded.renderFirst())
+
+	case renderedMsg:
+		m.loading = nil
+		m.followCursor()
+		return m, nil
+	}
+	return m, nil
+}
+
+// renderFirst renders the files in the background, so the renderer has
+// them cached for the first frame. The renderer is only used here until
+// renderedMsg arrives.
+func (m Model) renderFirst() tea.Cmd {
+	m.loading.rendering = true
+	renderer, opts, files, ctx := m.renderer, m.renderOptions(), m.files, m.ctx
+	return func() tea.Msg {
+		renderer.get(opts).RenderFilesContext(ctx, files)
+		return renderedMsg{}
+	}
+}
+
+// renderLoading shows the spinner and the step the load is at, with the
+// time taken once it's noticeable
+func (m Model) renderLoading() string {
+	status := fmt.Sprintf("%s %s…", spinnerFrames[m.loading.frame%len(spinnerFrames)], m.loading.step.get())
+	if elapsed := time.Since(m.loading.started); elapsed >= time.Second {
+		status += fmt.Sprintf(" %ds", int(elapsed.Seconds()))
+	}
+	style := lipgloss.NewStyle().Foreground(themes.GetCurrentTheme().TextMuted)
+	if m.windowWidth > 0 && m.windowHeight > 0 {
+		return lipgloss.Place(m.windowWidth, m.windowHeight, lipgloss.Center, lipgloss.Center, style.Render(status))
+	}
+	return style.Render(status)
+}
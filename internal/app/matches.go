@@ -0,0 +1,30 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/avgvstvs96/differential/internal/config"
+	"github.com/avgvstvs96/differential/internal/diff"
+)
+
+// RunMatches prints which removed line each added line replaced, with how
+// similar they are, as JSON instead of the diff
+func RunMatches(ctx context.Context, input io.Reader, cfg *config.Config, args []string) error {
+	diffText, err := readDiffInput(ctx, input, cfg, args)
+	if err != nil {
+		return err
+	}
+
+	files, err := parseFiles(ctx, diffText, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to parse diff: %w", err)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(diff.MatchReport(files))
+}
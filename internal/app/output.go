@@ -0,0 +1,84 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/avgvstvs96/differential/internal/config"
+	"github.com/avgvstvs96/differential/internal/diff"
+	"github.com/avgvstvs96/differential/internal/themes"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// Formats of files written with --output
+const (
+	OutputFormatANSI = "ansi" // Colored with terminal escape sequences
+	OutputFormatHTML = "html" // A standalone web page
+	OutputFormatText = "text" // Plain text without colors
+)
+
+// OutputFormat infers the format of an output file from its extension.
+// Files with other extensions get plain text.
+func OutputFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".html", ".htm":
+		return OutputFormatHTML
+	case ".ansi":
+		return OutputFormatANSI
+	}
+	return OutputFormatText
+}
+
+// useOutputColors makes lipgloss emit colors for formats that keep them,
+// since the terminal's support doesn't matter for a file
+func useOutputColors(path string) {
+	if OutputFormat(path) != OutputFormatText {
+		lipgloss.SetColorProfile(termenv.TrueColor)
+	}
+}
+
+// outputTheme returns the colors pipe mode renders with: the print theme
+// with --print, otherwise the current theme
+func outputTheme(cfg *config.Config) *themes.ThemeColors {
+	if cfg.Output.Print {
+		return themes.PrintTheme()
+	}
+	return themes.GetCurrentTheme()
+}
+
+// writeOutput writes rendered output to path in the format its extension
+// implies, refusing to replace an existing file unless force is set. HTML
+// pages take their text and background colors from theme.
+func writeOutput(path, output string, theme *themes.ThemeColors, force bool) error {
+	switch OutputFormat(path) {
+	case OutputFormatHTML:
+		output = diff.HTMLDocument(filepath.Base(path), output, theme)
+	case OutputFormatText:
+		output = diff.ParseANSI(output).Plain()
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !force {
+		flags |= os.O_EXCL
+	}
+	f, err := os.OpenFile(path, flags, 0o644)
+	if errors.Is(err, fs.ErrExist) {
+		return fmt.Errorf("%s already exists (use --force to overwrite it)", path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	_, err = f.WriteString(output)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	return nil
+}
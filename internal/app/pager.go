@@ -0,0 +1,160 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/avgvstvs96/differential/internal/commit"
+	"github.com/avgvstvs96/differential/internal/config"
+	"github.com/avgvstvs96/differential/internal/diff"
+	"github.com/avgvstvs96/differential/internal/themes"
+)
+
+// diffLineRegex matches the lines that make text a diff rather than other
+// git output
+var diffLineRegex = regexp.MustCompile(`(?m)^(?:diff --git |@@ -\d)`)
+
+// RunPagerMode renders the output of git as it arrives, for running as
+// git's core.pager. git log -p output is rendered a commit at a time, each
+// parsed afresh; output that isn't a diff, such as git log or git branch,
+// is passed through as it is. The result is paged with $PAGER, less or more
+// when stdout is a terminal, unless noPager is set.
+func RunPagerMode(ctx context.Context, input io.Reader, noPager bool, cfg *config.Config) error {
+	if err := themes.Initialize(); err != nil {
+		return fmt.Errorf("failed to initialize themes: %w", err)
+	}
+	if err := themes.Default().Set(cfg.UI.Theme); err != nil {
+		return fmt.Errorf("failed to set theme: %w", err)
+	}
+	out, closePager := startPager(noPager || cfg.Deterministic)
+	defer closePager()
+	return RenderStream(ctx, input, out, cfg)
+}
+
+// RenderStream renders the output of git read from input to w as it
+// arrives, a commit at a time, as RunPagerMode describes. Themes must be
+// initialized first. It stops without an error when w fails, as it does
+// once the pager is quit.
+func RenderStream(ctx context.Context, input io.Reader, w io.Writer, cfg *config.Config) error {
+	opts, err := pipeRenderOptions(cfg)
+	if err != nil {
+		return err
+	}
+
+	var chunk strings.Builder
+	flush := func() error {
+		if chunk.Len() == 0 {
+			return nil
+		}
+		_, err := io.WriteString(w, renderPagerChunk(ctx, chunk.String(), opts, cfg))
+		chunk.Reset()
+		return err
+	}
+
+	reader := bufio.NewReader(input)
+	for ctx.Err() == nil {
+		line, readErr := reader.ReadString('\n')
+		if commit.IsCommitLine(diff.StripANSI(line)) {
+			if err := flush(); err != nil {
+				return nil
+			}
+		}
+		chunk.WriteString(line)
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return fmt.Errorf("failed to read input: %w", readErr)
+		}
+	}
+	if err := flush(); err != nil {
+		return nil
+	}
+	return ctx.Err()
+}
+
+// renderPagerChunk renders a commit of git log -p output, or the whole
+// output of other commands, returning text unchanged when it isn't a diff
+func renderPagerChunk(ctx context.Context, text string, opts diff.RenderOptions, cfg *config.Config) string {
+	// git colors its output for pagers
+	plain, _ := diff.StripDiffColors(text)
+	if !diffLineRegex.MatchString(plain) {
+		return text
+	}
+
+	var header string
+	var files []*diff.DiffResult
+	series, err := parseSeries(ctx, "", commit.SplitCommits(plain), cfg)
+	if err != nil {
+		return text
+	}
+	if len(series) == 1 {
+		header = renderPatchHeader(series[0], 0, 1, opts.Width)
+		files = series[0].files
+		opts.FileHeaders = true
+	} else if files, err = parseFiles(ctx, plain, cfg); err != nil {
+		return text
+	}
+	rendered, err := diff.NewRenderer(opts).RenderFilesContext(ctx, files)
+	if err != nil {
+		return text
+	}
+	return header + renderCommitLint(plain, cfg, opts.Width) + rendered
+}
+
+// startPager starts the pager to page what is written to the returned
+// writer when stdout is a terminal, returning stdout itself otherwise.
+// close waits for the pager to be quit.
+func startPager(disabled bool) (w io.Writer, close func()) {
+	noop := func() {}
+	if disabled || !shouldUsePager() {
+		return os.Stdout, noop
+	}
+	cmd := pagerCommand()
+	if cmd == nil {
+		return os.Stdout, noop
+	}
+
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return os.Stdout, noop
+	}
+	if err := cmd.Start(); err != nil {
+		return os.Stdout, noop
+	}
+	return stdin, func() {
+		stdin.Close()
+		cmd.Wait()
+	}
+}
+
+// pagerCommand builds the pager command: $PAGER when set, otherwise less -R
+// or, where less is missing, more. It returns nil when none is found.
+func pagerCommand() *exec.Cmd {
+	// $PAGER naming differential itself would only start it again
+	if fields := strings.Fields(os.Getenv("PAGER")); len(fields) > 0 && filepath.Base(fields[0]) != filepath.Base(os.Args[0]) {
+		if _, err := exec.LookPath(fields[0]); err == nil {
+			return exec.Command(fields[0], fields[1:]...)
+		}
+	}
+	if less, err := exec.LookPath("less"); err == nil {
+		cmd := exec.Command(less, "-R")
+		// Quit at once when the output fits the screen, as git runs less
+		if os.Getenv("LESS") == "" {
+			cmd.Env = append(os.Environ(), "LESS=FRX")
+		}
+		return cmd
+	}
+	if more, err := exec.LookPath("more"); err == nil {
+		return exec.Command(more)
+	}
+	return nil
+}
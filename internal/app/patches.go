@@ -0,0 +1,258 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/avgvstvs96/differential/internal/commit"
+	"github.com/avgvstvs96/differential/internal/config"
+	"github.com/avgvstvs96/differential/internal/diff"
+	"github.com/avgvstvs96/differential/internal/themes"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// patchExtensions are the extensions of files read as patches instead of
+// being compared
+var patchExtensions = []string{".patch", ".diff", ".mbox"}
+
+// seriesPatch is a patch of a series shown in the viewer
+type seriesPatch struct {
+	name     string         // File the patch was read from
+	commit   *commit.Commit // Nil for a patch without mail headers
+	diffText string
+	files    []*diff.DiffResult
+}
+
+// PatchFiles returns the patch files args name, reporting whether every
+// arg is a patch file or a directory of them, as git format-patch writes.
+// A directory's *.patch files are taken in name order.
+func PatchFiles(args []string) ([]string, bool) {
+	if len(args) == 0 {
+		return nil, false
+	}
+	var paths []string
+	for _, arg := range args {
+		info, err := os.Stat(arg)
+		if err != nil {
+			return nil, false
+		}
+		if !info.IsDir() {
+			if !slices.Contains(patchExtensions, strings.ToLower(filepath.Ext(arg))) {
+				return nil, false
+			}
+			paths = append(paths, arg)
+			continue
+		}
+		matches, err := filepath.Glob(filepath.Join(arg, "*.patch"))
+		if err != nil || len(matches) == 0 {
+			return nil, false
+		}
+		paths = append(paths, matches...)
+	}
+	return paths, true
+}
+
+// loadSeries reads and parses the patches of the files at paths, in order.
+// A file can hold several patches, as an mbox does.
+func loadSeries(ctx context.Context, paths []string, cfg *config.Config) ([]seriesPatch, error) {
+	var series []seriesPatch
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read patch: %w", err)
+		}
+		patches, err := parseSeries(ctx, path, commit.ParsePatches(string(data)), cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		series = append(series, patches...)
+	}
+	if len(series) == 0 {
+		return nil, fmt.Errorf("no patches in %s", strings.Join(paths, ", "))
+	}
+	return series, nil
+}
+
+// parseSeries parses the diffs of patches read from the file name
+func parseSeries(ctx context.Context, name string, patches []commit.Patch, cfg *config.Config) ([]seriesPatch, error) {
+	var series []seriesPatch
+	for _, patch := range patches {
+		files, err := parseFiles(ctx, patch.Diff, cfg)
+		if err != nil {
+			return nil, err
+		}
+		series = append(series, seriesPatch{name: name, commit: patch.Commit, diffText: patch.Diff, files: files})
+	}
+	return series, nil
+}
+
+// renderSeries renders each patch of series below its header, returning
+// the files of all of them. limits apply to the series as a whole, like
+// to a single diff; the patches they leave out lose their headers too.
+func renderSeries(ctx context.Context, series []seriesPatch, opts diff.RenderOptions, limits diff.Limits) (string, []*diff.DiffResult, error) {
+	opts.FileHeaders = true
+	var files []*diff.DiffResult
+	firsts := make([]int, len(series))
+	for i, patch := range series {
+		firsts[i] = len(files)
+		files = append(files, patch.files...)
+	}
+	rendered, offsets, err := diff.NewRenderer(opts).RenderFilesLimitedWithOffsets(ctx, files, limits)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// Each header goes above the first file of its patch, or where the
+	// files end for empty patches at the end of the series
+	lines := strings.SplitAfter(rendered, "\n")
+	shown := len(offsets) - 1
+	var sb strings.Builder
+	line := 0
+	for i, patch := range series {
+		first := firsts[i]
+		if first > shown || (first == shown && shown < len(files)) {
+			break
+		}
+		for ; line < offsets[first]; line++ {
+			sb.WriteString(lines[line])
+		}
+		sb.WriteString(renderPatchHeader(patch, i, len(series), opts.Width))
+	}
+	for ; line < len(lines); line++ {
+		sb.WriteString(lines[line])
+	}
+	return sb.String(), files, nil
+}
+
+// RunPatches shows the patches of the files at paths, each below its
+// commit message. The viewer shows a patch at a time; pipe mode renders
+// the whole series.
+func RunPatches(ctx context.Context, paths []string, pipeMode bool, cfg *config.Config) error {
+	// Initialize themes
+	if err := themes.Initialize(); err != nil {
+		return fmt.Errorf("failed to initialize themes: %w", err)
+	}
+
+	// Set theme
+	if err := themes.Default().Set(cfg.UI.Theme); err != nil {
+		return fmt.Errorf("failed to set theme: %w", err)
+	}
+
+	if pipeMode && cfg.Output.Path != "" {
+		useOutputColors(cfg.Output.Path)
+	}
+
+	series, err := loadSeries(ctx, paths, cfg)
+	if err != nil {
+		return err
+	}
+
+	if !pipeMode {
+		m, err := newModel(ctx, "", "", "", false, cfg)
+		if err != nil {
+			return err
+		}
+		for _, patch := range series {
+			if m.collapseGenerated(patch.files) && m.foldDuplicates {
+				diff.UnfoldDuplicateHunks(patch.files)
+				diff.FoldDuplicateHunks(patch.files)
+			}
+		}
+		m.series = series
+		m.showPatch(0)
+		return runModel(ctx, m)
+	}
+
+	// The timeout bounds rendering, as in RunPipeMode
+	if cfg.Limits.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Limits.Timeout)
+		defer cancel()
+	}
+	opts, err := pipeRenderOptions(cfg)
+	if err != nil {
+		return err
+	}
+	limits := diff.Limits{Files: cfg.Limits.MaxFiles, Lines: cfg.Limits.MaxLines}
+	output, files, err := renderSeries(ctx, series, opts, limits)
+	if err != nil {
+		return err
+	}
+	if cfg.CI {
+		output += ciSummary(files) + "\n"
+	}
+	return emitOutput(output, opts, cfg)
+}
+
+// showPatch shows the patch at index i of the series, with the cursor on
+// its first row. It does nothing past either end of the series.
+func (m *Model) showPatch(i int) {
+	if i < 0 || i >= len(m.series) {
+		return
+	}
+	patch := m.series[i]
+	m.patchIndex = i
+	m.files, m.diffText = patch.files, patch.diffText
+	m.header = m.seriesHeader()
+	m.renderer = &rendererCache{}
+	m.cursor, m.scrollOffset, m.visual = 0, 0, false
+}
+
+// seriesHeader renders the header of the patch shown, at the window's
+// width once it is known
+func (m Model) seriesHeader() string {
+	width := m.diffWidth()
+	if !m.ready {
+		width = getTerminalWidth()
+	}
+	return renderPatchHeader(m.series[m.patchIndex], m.patchIndex, len(m.series), width)
+}
+
+// renderPatchHeader renders a panel with the hash, author, date and
+// wrapped message of the commit of the patch at index i of a series of n,
+// or its file name when it has no commit. A lone patch has no [1/1] label.
+func renderPatchHeader(patch seriesPatch, i, n, width int) string {
+	theme := themes.GetCurrentTheme()
+	titleStyle := lipgloss.NewStyle().Foreground(theme.Text).Bold(true)
+	mutedStyle := lipgloss.NewStyle().Foreground(theme.TextMuted)
+	textStyle := lipgloss.NewStyle().Foreground(theme.Text)
+	panelStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(theme.Border).
+		Padding(0, 1)
+	if width > 4 {
+		panelStyle = panelStyle.Width(width - 2)
+	}
+
+	label := ""
+	if n > 1 {
+		label = fmt.Sprintf("[%d/%d] ", i+1, n)
+	}
+	c := patch.commit
+	if c == nil {
+		return panelStyle.Render(titleStyle.Render(label+filepath.Base(patch.name))) + "\n"
+	}
+
+	lines := []string{titleStyle.Render(label + c.Subject())}
+	var details []string
+	if c.Hash != "" {
+		details = append(details, c.ShortHash())
+	}
+	if c.Author != "" {
+		details = append(details, fmt.Sprintf("%s <%s>", c.Author, c.AuthorEmail))
+	}
+	if c.Date != "" {
+		details = append(details, c.Date)
+	}
+	if len(details) > 0 {
+		lines = append(lines, mutedStyle.Render(strings.Join(details, " · ")))
+	}
+	if _, body, ok := strings.Cut(c.Message, "\n\n"); ok {
+		lines = append(lines, "", textStyle.Render(body))
+	}
+	return panelStyle.Render(strings.Join(lines, "\n")) + "\n"
+}
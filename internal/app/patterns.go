@@ -0,0 +1,173 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/avgvstvs96/differential/internal/diff"
+	"github.com/avgvstvs96/differential/internal/themes"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// openPatterns lists the distinct changes of the diff for review
+func (m *Model) openPatterns() {
+	m.patterns = diff.ChangePatterns(m.files)
+	m.patternCursor = min(m.patternCursor, max(len(m.patterns)-1, 0))
+	m.mode = ModePatterns
+}
+
+// togglePattern approves the pattern under the cursor, folding every hunk
+// making it, or takes the approval back
+func (m *Model) togglePattern() {
+	if m.patternCursor >= len(m.patterns) {
+		return
+	}
+	key := m.patterns[m.patternCursor].Key
+	if m.approved == nil {
+		m.approved = make(map[string]bool)
+	}
+	if m.approved[key] {
+		delete(m.approved, key)
+	} else {
+		m.approved[key] = true
+	}
+	diff.MarkApproved(m.files, m.approved)
+	m.renderer.get(m.renderOptions()).Reset()
+	m.moveCursor(0)
+}
+
+// showPattern returns to the diff with the cursor on the first file making
+// the pattern under the cursor
+func (m *Model) showPattern() {
+	m.mode = ModeDiff
+	if m.patternCursor >= len(m.patterns) {
+		return
+	}
+	name := m.patterns[m.patternCursor].Files[0]
+	_, offsets := m.renderer.get(m.renderOptions()).RenderFilesWithOffsets(m.files)
+	for i, file := range m.files {
+		if file.DisplayName() == name {
+			m.cursor = offsets[i] + strings.Count(m.header, "\n")
+			m.followCursor()
+			return
+		}
+	}
+}
+
+// approvedHunks counts the hunks folded as approved
+func (m Model) approvedHunks() int {
+	count := 0
+	for _, file := range m.files {
+		for _, hunk := range file.Hunks {
+			if hunk.Approved {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// handlePatternKey handles key presses in the pattern list
+func (m Model) handlePatternKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q", "ctrl+c":
+		return m, tea.Quit
+
+	case "j", "down":
+		m.patternCursor = min(m.patternCursor+1, max(len(m.patterns)-1, 0))
+
+	case "k", "up":
+		m.patternCursor = max(m.patternCursor-1, 0)
+
+	case " ", "a":
+		m.togglePattern()
+
+	case "enter":
+		m.showPattern()
+
+	case "esc", "P":
+		m.mode = ModeDiff
+	}
+	return m, nil
+}
+
+// renderPatterns renders the pattern list, most frequent change first, with
+// approved patterns checked
+func (m Model) renderPatterns() string {
+	theme := themes.GetCurrentTheme()
+	titleStyle := lipgloss.NewStyle().Foreground(theme.Text).Bold(true)
+	cursorStyle := lipgloss.NewStyle().Background(theme.Selection).Foreground(theme.Text)
+	mutedStyle := lipgloss.NewStyle().Foreground(theme.TextMuted)
+	removedStyle := lipgloss.NewStyle().Foreground(theme.DiffRemoved)
+	addedStyle := lipgloss.NewStyle().Foreground(theme.DiffAdded)
+
+	hunks, approved := 0, 0
+	for _, pattern := range m.patterns {
+		hunks += pattern.Hunks
+		if m.approved[pattern.Key] {
+			approved += pattern.Hunks
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(titleStyle.Render(fmt.Sprintf("%d changes in %d hunks", len(m.patterns), hunks)))
+	sb.WriteString(mutedStyle.Render(fmt.Sprintf(" · %d hunks approved", approved)))
+	sb.WriteString("\n\n")
+
+	// Keep the cursor in view below the title
+	visible := max(m.visibleRows()-2, 1)
+	start := max(m.patternCursor-visible+1, 0)
+	end := min(start+visible, len(m.patterns))
+	for i := start; i < end; i++ {
+		pattern := m.patterns[i]
+		mark := "  "
+		if m.approved[pattern.Key] {
+			mark = "✓ "
+		}
+		files := "1 file"
+		if n := len(pattern.Files); n != 1 {
+			files = fmt.Sprintf("%d files", n)
+		}
+		counts := fmt.Sprintf("%4d× %-10s ", pattern.Hunks, files)
+
+		if i == m.patternCursor {
+			line := mark + counts + patternSummary(pattern, nil, nil)
+			sb.WriteString(cursorStyle.Width(m.windowWidth).Render(diff.TruncateString(line, m.windowWidth)))
+		} else {
+			line := mark + mutedStyle.Render(counts) + patternSummary(pattern, &removedStyle, &addedStyle)
+			sb.WriteString(diff.TruncateString(line, m.windowWidth))
+		}
+		sb.WriteString("\n")
+	}
+	for i := end - start; i < visible; i++ {
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString(mutedStyle.Render("space: approve • enter: show in diff • esc: back • q: quit"))
+	return sb.String()
+}
+
+// patternSummary shows the first removed and added line of a pattern, and
+// how many more lines it changes
+func patternSummary(pattern diff.ChangePattern, removedStyle, addedStyle *lipgloss.Style) string {
+	render := func(style *lipgloss.Style, text string) string {
+		if style == nil {
+			return text
+		}
+		return style.Render(text)
+	}
+
+	var parts []string
+	if len(pattern.Removed) > 0 {
+		parts = append(parts, render(removedStyle, "-"+pattern.Removed[0]))
+	}
+	if len(pattern.Added) > 0 {
+		parts = append(parts, render(addedStyle, "+"+pattern.Added[0]))
+	}
+	summary := strings.Join(parts, " → ")
+	if more := len(pattern.Removed) + len(pattern.Added) - len(parts); more > 0 {
+		summary += fmt.Sprintf(" (+%d lines)", more)
+	}
+	return summary
+}
@@ -0,0 +1,129 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/avgvstvs96/differential/internal/themes"
+)
+
+// pickerModel is a small list picker that lets the user mark up to max items
+type pickerModel struct {
+	title     string
+	items     []string
+	max       int
+	cursor    int
+	marked    []int
+	confirmed bool
+}
+
+// runPicker shows a picker over items and returns the indexes the user
+// marked, in the order they were marked. Enter with nothing marked selects
+// the item under the cursor. A nil result means the picker was cancelled.
+func runPicker(title string, items []string, max int) ([]int, error) {
+	m := pickerModel{
+		title: title,
+		items: items,
+		max:   max,
+	}
+
+	final, err := tea.NewProgram(m).Run()
+	if err != nil {
+		return nil, fmt.Errorf("error running picker: %w", err)
+	}
+
+	result := final.(pickerModel)
+	if !result.confirmed {
+		return nil, nil
+	}
+	return result.marked, nil
+}
+
+// Init initializes the picker
+func (m pickerModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles picker key presses
+func (m pickerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	switch key.String() {
+	case "q", "esc", "ctrl+c":
+		return m, tea.Quit
+
+	case "j", "down":
+		if m.cursor < len(m.items)-1 {
+			m.cursor++
+		}
+
+	case "k", "up":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+
+	case " ":
+		m.toggle(m.cursor)
+
+	case "enter":
+		if len(m.marked) == 0 {
+			m.marked = []int{m.cursor}
+		}
+		m.confirmed = true
+		return m, tea.Quit
+	}
+
+	return m, nil
+}
+
+// toggle marks or unmarks an item, dropping the oldest mark when full
+func (m *pickerModel) toggle(idx int) {
+	for i, marked := range m.marked {
+		if marked == idx {
+			m.marked = append(m.marked[:i:i], m.marked[i+1:]...)
+			return
+		}
+	}
+	if len(m.marked) == m.max {
+		m.marked = m.marked[1:]
+	}
+	m.marked = append(m.marked, idx)
+}
+
+// View renders the picker
+func (m pickerModel) View() string {
+	theme := themes.GetCurrentTheme()
+	titleStyle := lipgloss.NewStyle().Foreground(theme.Text).Bold(true)
+	cursorStyle := lipgloss.NewStyle().Background(theme.Selection).Foreground(theme.Text)
+	mutedStyle := lipgloss.NewStyle().Foreground(theme.TextMuted)
+
+	var sb strings.Builder
+	sb.WriteString(titleStyle.Render(m.title))
+	sb.WriteString("\n\n")
+
+	for i, item := range m.items {
+		mark := "  "
+		for n, marked := range m.marked {
+			if marked == i {
+				mark = fmt.Sprintf("%d ", n+1)
+			}
+		}
+
+		line := mark + item
+		if i == m.cursor {
+			line = cursorStyle.Render(line)
+		}
+		sb.WriteString(line)
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("\n")
+	sb.WriteString(mutedStyle.Render(fmt.Sprintf("space: mark (up to %d) • enter: confirm • q: cancel", m.max)))
+	sb.WriteString("\n")
+	return sb.String()
+}
@@ -0,0 +1,60 @@
+package app
+
+import (
+	"strings"
+
+	"github.com/avgvstvs96/differential/internal/diff"
+)
+
+// position is a row of the output by the diff line it shows, so it can be
+// found again after the layout changes the rows
+type position struct {
+	file             *diff.DiffResult // nil when no line is shown at or below the row
+	oldLine, newLine int
+	offset           int // Rows from the line to the row, e.g. -1 for a hunk header
+	row              int // The row, kept when the line can't be found again
+}
+
+// positionOf returns the position of row, by the first diff line shown on
+// it or below
+func (m Model) positionOf(row int) position {
+	headerLines := strings.Count(m.header, "\n")
+	p := position{row: row}
+	file, oldLine, newLine, lineRow, ok := m.renderer.get(m.renderOptions()).LineFrom(m.files, row-headerLines)
+	if ok {
+		p.file, p.oldLine, p.newLine = file, oldLine, newLine
+		p.offset = row - headerLines - lineRow
+	}
+	return p
+}
+
+// rowOf returns the row showing p in the current layout
+func (m Model) rowOf(p position) int {
+	if p.file == nil {
+		return p.row
+	}
+	row, ok := m.renderer.get(m.renderOptions()).RowOf(m.files, p.file, p.oldLine, p.newLine)
+	if !ok {
+		return p.row
+	}
+	return max(row+p.offset, 0) + strings.Count(m.header, "\n")
+}
+
+// keepPosition runs change, which lays the output out anew, and moves the
+// cursor and the start of the selection back to their lines, with the
+// cursor as far down the window as it was
+func (m *Model) keepPosition(change func()) {
+	cursor, screenRow := m.positionOf(m.cursor), m.cursor-m.scrollOffset
+	var selection position
+	if m.visual {
+		selection = m.positionOf(m.anchor)
+	}
+	change()
+
+	m.cursor = m.rowOf(cursor)
+	if m.visual {
+		m.anchor = m.rowOf(selection)
+	}
+	m.scrollOffset = max(m.cursor-screenRow, 0)
+	m.moveCursor(0)
+}
@@ -0,0 +1,76 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/avgvstvs96/differential/internal/config"
+	"github.com/avgvstvs96/differential/internal/forge"
+	"github.com/avgvstvs96/differential/internal/git"
+	"github.com/avgvstvs96/differential/internal/themes"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// RunPR fetches a GitHub pull request or GitLab merge request, given by URL
+// or by number in the repository of the origin remote, and shows its diff
+// below its title and branches
+func RunPR(ctx context.Context, arg string, pipeMode bool, cfg *config.Config) error {
+	// Initialize themes
+	if err := themes.Initialize(); err != nil {
+		return fmt.Errorf("failed to initialize themes: %w", err)
+	}
+
+	// Set theme
+	if err := themes.Default().Set(cfg.UI.Theme); err != nil {
+		return fmt.Errorf("failed to set theme: %w", err)
+	}
+
+	var remote string
+	if !strings.Contains(arg, "://") {
+		output, err := git.RunContext(ctx, "remote", "get-url", "origin")
+		if err != nil {
+			return fmt.Errorf("failed to find the origin remote for %s: %w", arg, err)
+		}
+		remote = strings.TrimSpace(output)
+	}
+	ref, err := forge.ParseRef(arg, remote)
+	if err != nil {
+		return err
+	}
+
+	client := &forge.Client{Token: forge.TokenFromEnv(ref, cfg.Forge.TokenHosts)}
+	pr, err := client.Fetch(ctx, ref)
+	if err != nil {
+		return err
+	}
+
+	if pipeMode {
+		return runPipeMode(ctx, strings.NewReader(pr.Diff), cfg, nil, renderPRHeader(pr, outputWidth(cfg)))
+	}
+	return startTUI(ctx, pr.Diff, "", renderPRHeader(pr, getTerminalWidth()), false, cfg)
+}
+
+// renderPRHeader renders a panel naming a pull request, its author and the
+// branches it merges
+func renderPRHeader(pr *forge.PullRequest, width int) string {
+	theme := themes.GetCurrentTheme()
+	titleStyle := lipgloss.NewStyle().Foreground(theme.Text).Bold(true)
+	mutedStyle := lipgloss.NewStyle().Foreground(theme.TextMuted)
+	panelStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(theme.Border).
+		Padding(0, 1)
+	if width > 4 {
+		panelStyle = panelStyle.Width(width - 2)
+	}
+
+	lines := []string{
+		titleStyle.Render(pr.Ref.String() + " " + pr.Title),
+		mutedStyle.Render(fmt.Sprintf("%s wants to merge %s into %s", pr.Author, pr.Head, pr.Base)),
+	}
+	if pr.URL != "" {
+		lines = append(lines, mutedStyle.Render(pr.URL))
+	}
+	return panelStyle.Render(strings.Join(lines, "\n")) + "\n"
+}
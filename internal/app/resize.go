@@ -0,0 +1,50 @@
+package app
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// resizeDelay is how long the window's size has to hold before the diff is
+// laid out for it, so dragging the window's edge doesn't lay it out for
+// every size it passes through
+const resizeDelay = 50 * time.Millisecond
+
+// resizeMsg lays the diff out for the size of resize number seq, unless
+// another resize came since
+type resizeMsg struct {
+	seq           int
+	width, height int
+}
+
+// handleResize lays the diff out for the window's new size once it holds
+// for resizeDelay. The first size is taken at once.
+func (m Model) handleResize(msg tea.WindowSizeMsg) (tea.Model, tea.Cmd) {
+	if !m.ready {
+		m.windowWidth, m.windowHeight = msg.Width, msg.Height
+		m.ready = true
+		if len(m.series) > 0 {
+			m.header = m.seriesHeader()
+		}
+		m.followCursor()
+		return m, nil
+	}
+	m.resizes++
+	seq := m.resizes
+	return m, tea.Tick(resizeDelay, func(time.Time) tea.Msg {
+		return resizeMsg{seq, msg.Width, msg.Height}
+	})
+}
+
+// resize lays the diff out for a window of width by height, keeping the
+// cursor on its line
+func (m *Model) resize(width, height int) {
+	m.keepPosition(func() {
+		m.windowWidth, m.windowHeight = width, height
+		m.ready = true
+		if len(m.series) > 0 {
+			m.header = m.seriesHeader()
+		}
+	})
+}
@@ -0,0 +1,313 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/avgvstvs96/differential/internal/config"
+	"github.com/avgvstvs96/differential/internal/diff"
+	"github.com/avgvstvs96/differential/internal/themes"
+)
+
+// scriptHeight is the terminal height scripts run at, which page-down and
+// page-up move by
+const scriptHeight = 24
+
+// scriptAction runs one step of a script on the viewer and describes what
+// it did
+type scriptAction func(m *Model, arg string) (string, error)
+
+// scriptActions are the actions a script can take, named after what the
+// viewer's keys do
+var scriptActions = map[string]scriptAction{
+	"next-line":    moveAction(1),
+	"prev-line":    moveAction(-1),
+	"page-down":    pageAction(false),
+	"page-up":      pageAction(true),
+	"top":          topAction,
+	"bottom":       moveAction(math.MaxInt32),
+	"next-hunk":    jumpAction((*Model).jumpHunk, false, "no next hunk"),
+	"prev-hunk":    jumpAction((*Model).jumpHunk, true, "no previous hunk"),
+	"next-file":    jumpAction((*Model).jumpFile, false, "no next file"),
+	"prev-file":    jumpAction((*Model).jumpFile, true, "no previous file"),
+	"select":       selectAction,
+	"stage":        stageAction,
+	"undo":         undoAction,
+	"redo":         redoAction,
+	"collapse":     collapseAction,
+	"unfold":       unfoldAction,
+	"toggle-view":  toggleViewAction,
+	"full-file":    fullFileAction,
+	"more-context": contextAction(contextStep),
+	"less-context": contextAction(-contextStep),
+	"comment":      commentAction,
+	"quit":         nil, // Handled by ExecScript
+}
+
+// scriptStep is an action of a script with its argument
+type scriptStep struct {
+	name, arg string
+}
+
+// parseScript splits a script into steps, separated by semicolons or
+// newlines. An action's argument follows its name after a space.
+func parseScript(script string) ([]scriptStep, error) {
+	var steps []scriptStep
+	for _, field := range strings.FieldsFunc(script, func(r rune) bool { return r == ';' || r == '\n' }) {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		name, arg, _ := strings.Cut(field, " ")
+		if _, ok := scriptActions[name]; !ok {
+			return nil, fmt.Errorf("unknown script action %q (expected %s)", name, strings.Join(ScriptActions(), ", "))
+		}
+		arg = strings.TrimSpace(arg)
+		if name == "comment" && arg == "" {
+			return nil, errors.New("script action comment needs the comment's text, e.g. comment looks good")
+		}
+		steps = append(steps, scriptStep{name: name, arg: arg})
+	}
+	return steps, nil
+}
+
+// ScriptActions returns the names of the script actions, sorted
+func ScriptActions() []string {
+	names := make([]string, 0, len(scriptActions))
+	for name := range scriptActions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RunScript runs the viewer on the diff for args without a terminal,
+// taking the actions of script in order and printing what each did,
+// followed by a summary of the changes left
+func RunScript(ctx context.Context, args []string, script string, cfg *config.Config) error {
+	// Initialize themes
+	if err := themes.Initialize(); err != nil {
+		return fmt.Errorf("failed to initialize themes: %w", err)
+	}
+
+	// Set theme
+	if err := themes.Default().Set(cfg.UI.Theme); err != nil {
+		return fmt.Errorf("failed to set theme: %w", err)
+	}
+
+	// Check the script before running git
+	if _, err := parseScript(script); err != nil {
+		return err
+	}
+
+	diffText, filename, stageable, err := tuiInput(ctx, args, cfg)
+	if err != nil {
+		return err
+	}
+	m, err := newModel(ctx, diffText, filename, "", stageable, cfg)
+	if err != nil {
+		return err
+	}
+	return m.ExecScript(script, os.Stdout)
+}
+
+// ExecScript takes the actions of script on the viewer, writing a line per
+// action to w: its name and what it did, such as where the cursor moved.
+// The last line counts the changes left, as --ci does. It stops at quit or
+// at the first action failing.
+func (m *Model) ExecScript(script string, w io.Writer) error {
+	steps, err := parseScript(script)
+	if err != nil {
+		return err
+	}
+	if !m.ready {
+		m.windowWidth, m.windowHeight, m.ready = outputWidth(m.config), scriptHeight, true
+	}
+
+	for i, step := range steps {
+		if err := m.ctx.Err(); err != nil {
+			return err
+		}
+		if step.name == "quit" {
+			fmt.Fprintln(w, step.name)
+			break
+		}
+		result, err := scriptActions[step.name](m, step.arg)
+		if err != nil {
+			return fmt.Errorf("script step %d (%s): %w", i+1, step.name, err)
+		}
+		fmt.Fprintf(w, "%s: %s\n", step.name, result)
+	}
+	_, err = fmt.Fprintln(w, ciSummary(m.files))
+	return err
+}
+
+// position describes the cursor's line, e.g. main.go:+12
+func (m Model) position() string {
+	if target, ok := m.cursorComment(); ok {
+		return commentLocation(target)
+	}
+	if len(m.files) == 0 {
+		return "no changes"
+	}
+	return fmt.Sprintf("row %d", m.cursor+1)
+}
+
+// moveAction moves the cursor by delta rows
+func moveAction(delta int) scriptAction {
+	return func(m *Model, _ string) (string, error) {
+		m.moveCursor(delta)
+		return m.position(), nil
+	}
+}
+
+// topAction moves the cursor to the first row
+func topAction(m *Model, _ string) (string, error) {
+	m.cursor, m.scrollOffset = 0, 0
+	return m.position(), nil
+}
+
+// pageAction moves the cursor by a page, as ctrl+f and ctrl+b do
+func pageAction(up bool) scriptAction {
+	return func(m *Model, _ string) (string, error) {
+		m.page(up)
+		return m.position(), nil
+	}
+}
+
+// jumpAction moves the cursor with jump, saying none when there's nowhere
+// to go
+func jumpAction(jump func(*Model, bool) bool, backward bool, none string) scriptAction {
+	return func(m *Model, _ string) (string, error) {
+		if !jump(m, backward) {
+			return none, nil
+		}
+		return m.position(), nil
+	}
+}
+
+// selectAction starts selecting lines from the cursor, or cancels the
+// selection
+func selectAction(m *Model, _ string) (string, error) {
+	m.visual, m.anchor = !m.visual, m.cursor
+	if !m.visual {
+		return "selection cancelled", nil
+	}
+	return "selecting from " + m.position(), nil
+}
+
+// stageAction stages the selected lines, or the hunk under the cursor
+func stageAction(m *Model, _ string) (string, error) {
+	if !m.stageable {
+		return "", errors.New("only the unstaged changes of the worktree can be staged")
+	}
+	lines := 0
+	for _, selection := range m.selectLines() {
+		lines += len(selection.removed) + len(selection.added)
+	}
+	if err := m.stageSelection(); err != nil {
+		return "", err
+	}
+	if lines == 1 {
+		return "staged 1 line", nil
+	}
+	return fmt.Sprintf("staged %d lines", lines), nil
+}
+
+// undoAction takes the last staging step back
+func undoAction(m *Model, _ string) (string, error) {
+	if len(m.undo) == 0 {
+		return "nothing to undo", nil
+	}
+	return "undone", m.undoStage()
+}
+
+// redoAction stages the last undone step again
+func redoAction(m *Model, _ string) (string, error) {
+	if len(m.redo) == 0 {
+		return "nothing to redo", nil
+	}
+	return "redone", m.redoStage()
+}
+
+// collapseAction collapses or expands the file under the cursor
+func collapseAction(m *Model, _ string) (string, error) {
+	m.collapseFileAtCursor()
+	_, offsets := m.renderer.get(m.renderOptions()).RenderFilesWithOffsets(m.files)
+	headerLines := strings.Count(m.header, "\n")
+	for i, offset := range offsets {
+		if offset+headerLines != m.cursor {
+			continue
+		}
+		if m.files[i].Collapsed {
+			return "collapsed " + m.files[i].DisplayName(), nil
+		}
+		return "expanded " + m.files[i].DisplayName(), nil
+	}
+	return m.position(), nil
+}
+
+// unfoldAction shows or folds the long context runs of the hunk under the
+// cursor
+func unfoldAction(m *Model, _ string) (string, error) {
+	m.unfoldHunkAtCursor()
+	return m.position(), nil
+}
+
+// toggleViewAction switches between the unified and side-by-side views
+func toggleViewAction(m *Model, _ string) (string, error) {
+	m.toggleViewMode()
+	if m.viewMode == diff.ViewSideBySide {
+		return "side-by-side", nil
+	}
+	return "unified", nil
+}
+
+// fullFileAction shows files whole with a change gutter, or as hunks again
+func fullFileAction(m *Model, _ string) (string, error) {
+	m.toggleFullFile()
+	if m.fullFile {
+		return "full file", nil
+	}
+	return "hunks", nil
+}
+
+// contextAction returns an action regenerating the file under the cursor
+// with delta more lines of context
+func contextAction(delta int) scriptAction {
+	return func(m *Model, _ string) (string, error) {
+		if err := m.changeFileContext(delta); err != nil {
+			return "", err
+		}
+		i, _, ok := m.fileAtCursor()
+		if !ok {
+			return "", errors.New("the cursor isn't on a file")
+		}
+		lines, ok := m.fileContext[m.files[i]]
+		if !ok {
+			lines = m.contextLines
+		}
+		return fmt.Sprintf("%d lines of context in %s", lines, m.files[i].DisplayName()), nil
+	}
+}
+
+// commentAction comments on the cursor's line, replacing its comment
+func commentAction(m *Model, text string) (string, error) {
+	target, ok := m.cursorComment()
+	if !ok {
+		return "", errors.New("the cursor isn't on a line")
+	}
+	m.startComment(target, m.mode)
+	m.input = text
+	m.finishComment()
+	if m.err != nil {
+		return "", m.err
+	}
+	return commentLocation(target), nil
+}
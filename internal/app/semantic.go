@@ -0,0 +1,45 @@
+package app
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/avgvstvs96/differential/internal/config"
+	"github.com/avgvstvs96/differential/internal/semantic"
+	"github.com/avgvstvs96/differential/internal/themes"
+)
+
+// RunSemanticDiff renders a structural diff of two JSON, YAML or TOML files.
+// It reports false when the files can't be compared structurally, in which
+// case the caller should fall back to a text diff.
+func RunSemanticDiff(oldPath, newPath string, cfg *config.Config) (bool, error) {
+	if !semantic.Supported(oldPath) || !semantic.Supported(newPath) {
+		return false, nil
+	}
+
+	// Initialize themes
+	if err := themes.Initialize(); err != nil {
+		return false, fmt.Errorf("failed to initialize themes: %w", err)
+	}
+
+	// Set theme
+	if err := themes.Default().Set(cfg.UI.Theme); err != nil {
+		return false, fmt.Errorf("failed to set theme: %w", err)
+	}
+
+	docs := make([]any, 2)
+	for i, path := range []string{oldPath, newPath} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return false, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		docs[i], err = semantic.Parse(path, data)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "semantic diff unavailable, showing text diff: %v\n", err)
+			return false, nil
+		}
+	}
+
+	changes := semantic.Compare(docs[0], docs[1])
+	return true, displayOutput(semantic.Render(newPath, changes), cfg)
+}
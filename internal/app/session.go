@@ -0,0 +1,137 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/avgvstvs96/differential/internal/config"
+	"github.com/avgvstvs96/differential/internal/diff"
+	"github.com/avgvstvs96/differential/internal/state"
+	"github.com/avgvstvs96/differential/internal/themes"
+)
+
+// RunSession resumes the review saved in the session file at path, showing
+// its diff as it was left rather than running git. The review is saved back
+// to cfg.SessionFile on quit.
+func RunSession(ctx context.Context, path string, cfg *config.Config) error {
+	if err := themes.Initialize(); err != nil {
+		return fmt.Errorf("failed to initialize themes: %w", err)
+	}
+	if err := themes.Default().Set(cfg.UI.Theme); err != nil {
+		return fmt.Errorf("failed to set theme: %w", err)
+	}
+
+	m, err := SessionModel(ctx, path, cfg)
+	if err != nil {
+		return err
+	}
+	return runModel(ctx, m)
+}
+
+// SessionModel returns the interactive viewer on the review saved in the
+// session file at path, for driving it without a terminal as the
+// end-to-end tests do. Themes must be initialized first.
+func SessionModel(ctx context.Context, path string, cfg *config.Config) (Model, error) {
+	session, err := state.LoadSession(path)
+	if err != nil {
+		return Model{}, err
+	}
+	m, err := newModel(ctx, session.Diff, session.Filename, "", false, cfg)
+	if err != nil {
+		return Model{}, err
+	}
+	m.applySession(session)
+	return m, nil
+}
+
+// applySession restores the viewer's state from a saved session. Its
+// comments replace the repository's, which a shared session may not
+// belong to, so they are only saved in the session.
+func (m *Model) applySession(session *state.Session) {
+	m.viewMode = diff.ViewUnified
+	if session.ViewMode == "side-by-side" {
+		m.viewMode = diff.ViewSideBySide
+	}
+	m.showLineNumbers = session.LineNumbers
+	m.dimContext = session.DimContext
+	m.fullFile = session.FullFile
+
+	collapsed := make(map[string]bool, len(session.Collapsed))
+	for _, path := range session.Collapsed {
+		collapsed[path] = true
+	}
+	for _, file := range m.files {
+		file.Collapsed = collapsed[file.DisplayName()]
+	}
+
+	m.approved = make(map[string]bool, len(session.Approved))
+	for _, key := range session.Approved {
+		m.approved[key] = true
+	}
+	diff.MarkApproved(m.files, m.approved)
+
+	m.staged = make(map[state.StagedHunk]bool, len(session.Staged))
+	for _, hunk := range session.Staged {
+		m.staged[hunk] = true
+	}
+	m.markStaged()
+
+	m.comments = session.Comments
+	m.commentsFile = ""
+
+	m.cursor, m.scrollOffset = max(session.Cursor, 0), max(session.Scroll, 0)
+}
+
+// session returns the viewer's review as a session to save
+func (m Model) session() *state.Session {
+	session := &state.Session{
+		Diff:        m.diffText,
+		Filename:    m.filename,
+		ViewMode:    "unified",
+		LineNumbers: m.showLineNumbers,
+		DimContext:  m.dimContext,
+		FullFile:    m.fullFile,
+		Cursor:      m.cursor,
+		Scroll:      m.scrollOffset,
+		Comments:    m.comments,
+	}
+	if m.viewMode == diff.ViewSideBySide {
+		session.ViewMode = "side-by-side"
+	}
+	for _, file := range m.files {
+		if file.Collapsed {
+			session.Collapsed = append(session.Collapsed, file.DisplayName())
+		}
+	}
+	for key, approved := range m.approved {
+		if approved {
+			session.Approved = append(session.Approved, key)
+		}
+	}
+	sort.Strings(session.Approved)
+	for hunk, staged := range m.staged {
+		if staged {
+			session.Staged = append(session.Staged, hunk)
+		}
+	}
+	sort.Slice(session.Staged, func(i, j int) bool {
+		a, b := session.Staged[i], session.Staged[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Header < b.Header
+	})
+	return session
+}
+
+// saveSession writes the review to the session file when one is set
+func (m Model) saveSession() {
+	if m.config.SessionFile == "" {
+		return
+	}
+	if err := m.session().Save(m.config.SessionFile); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to save session: %v\n", err)
+	}
+}
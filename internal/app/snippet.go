@@ -0,0 +1,72 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/avgvstvs96/differential/internal/config"
+	"github.com/avgvstvs96/differential/internal/diff"
+)
+
+// DefaultSnippetDelimiter separates the two blocks of a snippet diff
+const DefaultSnippetDelimiter = "==="
+
+// RunSnippet diffs two text blocks separated by a delimiter line, read from
+// input or, when fromClipboard is set, from the system clipboard
+func RunSnippet(ctx context.Context, input io.Reader, fromClipboard, pipeMode bool, delimiter string, cfg *config.Config) error {
+	var text string
+	if fromClipboard {
+		clip, err := readClipboard()
+		if err != nil {
+			return err
+		}
+		text = clip
+	} else {
+		if isTerminal(os.Stdin) {
+			fmt.Fprintf(os.Stderr, "Paste two blocks separated by a %q line, then press Ctrl-D\n", delimiter)
+		}
+		data, err := io.ReadAll(input)
+		if err != nil {
+			return fmt.Errorf("failed to read snippet: %w", err)
+		}
+		text = string(data)
+	}
+
+	oldText, newText, err := SplitSnippet(text, delimiter)
+	if err != nil {
+		return err
+	}
+
+	diffText := diff.UnifiedDiff("a/snippet", "b/snippet", oldText, newText, cfg.Git.DefaultContext)
+	if diffText == "" {
+		fmt.Println("No differences found")
+		return nil
+	}
+
+	// The TUI needs the terminal, which piped stdin has taken
+	if pipeMode || (!fromClipboard && !isTerminal(os.Stdin)) {
+		return RunPipeMode(ctx, strings.NewReader(diffText), cfg, nil)
+	}
+	return startTUI(ctx, diffText, "snippet", "", false, cfg)
+}
+
+// SplitSnippet splits text into the blocks before and after the first line
+// that consists of the delimiter
+func SplitSnippet(text, delimiter string) (string, string, error) {
+	lines := strings.SplitAfter(text, "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) == delimiter {
+			return strings.Join(lines[:i], ""), strings.Join(lines[i+1:], ""), nil
+		}
+	}
+	return "", "", fmt.Errorf("snippet has no %q line separating the two blocks", delimiter)
+}
+
+// isTerminal reports whether f is an interactive terminal
+func isTerminal(f *os.File) bool {
+	stat, err := f.Stat()
+	return err == nil && stat.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,294 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/avgvstvs96/differential/internal/diff"
+	"github.com/avgvstvs96/differential/internal/git"
+	"github.com/avgvstvs96/differential/internal/state"
+	"github.com/avgvstvs96/differential/internal/themes"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// lineSelection holds the changed lines picked from one file, by old line
+// number for removed lines and new line number for added lines
+type lineSelection struct {
+	removed map[int]bool
+	added   map[int]bool
+}
+
+// contains reports whether line is selected
+func (s lineSelection) contains(line diff.DiffLine) bool {
+	switch line.Kind {
+	case diff.LineRemoved:
+		return s.removed[line.OldLineNo]
+	case diff.LineAdded:
+		return s.added[line.NewLineNo]
+	}
+	return false
+}
+
+// selectedRows returns the first and last output rows of the visual
+// selection, or just the cursor row outside visual mode
+func (m Model) selectedRows() (first, last int) {
+	if !m.visual {
+		return m.cursor, m.cursor
+	}
+	return min(m.anchor, m.cursor), max(m.anchor, m.cursor)
+}
+
+// renderSelectedLine highlights a row of the visual selection
+func (m Model) renderSelectedLine(row string) string {
+	theme := themes.GetCurrentTheme()
+	style := lipgloss.NewStyle().Background(theme.Selection).Foreground(theme.Text)
+	if width := m.diffWidth(); width > 0 {
+		style = style.Width(width)
+	}
+	return style.Render(diff.ParseANSI(row).Plain())
+}
+
+// selectLines collects the changed lines to stage: those in the visual
+// selection, or the hunk under the cursor outside visual mode
+func (m Model) selectLines() map[*diff.DiffResult]lineSelection {
+	renderer := m.renderer.get(m.renderOptions())
+	headerLines := strings.Count(m.header, "\n")
+	selections := make(map[*diff.DiffResult]lineSelection)
+	add := func(file *diff.DiffResult, oldLine, newLine int) {
+		selection, ok := selections[file]
+		if !ok {
+			selection = lineSelection{removed: make(map[int]bool), added: make(map[int]bool)}
+			selections[file] = selection
+		}
+		if oldLine > 0 {
+			selection.removed[oldLine] = true
+		}
+		if newLine > 0 {
+			selection.added[newLine] = true
+		}
+	}
+
+	if m.visual {
+		first, last := m.selectedRows()
+		for row := first; row <= last; row++ {
+			if file, oldLine, newLine, ok := renderer.LineAt(m.files, row-headerLines); ok {
+				add(file, oldLine, newLine)
+			}
+		}
+		return selections
+	}
+
+	file, oldLine, newLine, ok := m.cursorLine()
+	if !ok {
+		return selections
+	}
+	for _, hunk := range file.Hunks {
+		if !hunkContains(hunk, oldLine, newLine) {
+			continue
+		}
+		for _, line := range hunk.Lines {
+			switch line.Kind {
+			case diff.LineRemoved:
+				add(file, line.OldLineNo, 0)
+			case diff.LineAdded:
+				add(file, 0, line.NewLineNo)
+			}
+		}
+		break
+	}
+	return selections
+}
+
+// hunkContains reports whether hunk shows the line with the given old or
+// new line number
+func hunkContains(hunk diff.Hunk, oldLine, newLine int) bool {
+	for _, line := range hunk.Lines {
+		if (oldLine > 0 && line.OldLineNo == oldLine) || (newLine > 0 && line.NewLineNo == newLine) {
+			return true
+		}
+	}
+	return false
+}
+
+// stageOperation is a staging step that can be undone: the patches it
+// applied to the index, in order
+type stageOperation struct {
+	patches []string
+}
+
+// stageSelection stages the selected lines with a patch of just those
+// changes, then reloads the diff so they drop out of it. The step is
+// recorded for undo. Where the diff can't be staged, as in a resumed
+// session, the hunks of the selected lines are marked as staged instead.
+func (m *Model) stageSelection() error {
+	if !m.stageable {
+		m.toggleStaged(m.selectLines())
+		m.visual = false
+		return nil
+	}
+	selections := m.selectLines()
+	m.visual = false
+
+	var op stageOperation
+	var stageErr error
+	for _, file := range m.files {
+		selection, ok := selections[file]
+		if !ok || file.SkipReason != "" {
+			continue
+		}
+		patch, err := diff.PartialPatch(file, selection.contains)
+		if err != nil {
+			stageErr = fmt.Errorf("failed to stage: %w", err)
+			break
+		}
+		if err := git.ApplyCached(patch); err != nil {
+			stageErr = fmt.Errorf("failed to stage %s: %w", file.DisplayName(), err)
+			break
+		}
+		op.patches = append(op.patches, patch)
+	}
+
+	// Record what made it into the index even if a later file failed
+	if len(op.patches) > 0 {
+		m.undo = append(m.undo, op)
+		m.redo = nil
+		if err := m.reloadDiff(); err != nil {
+			return err
+		}
+	}
+	return stageErr
+}
+
+// toggleStaged marks the hunks holding selected lines as staged, or
+// unmarks them when they all are
+func (m *Model) toggleStaged(selections map[*diff.DiffResult]lineSelection) {
+	var hunks []state.StagedHunk
+	for _, file := range m.files {
+		selection, ok := selections[file]
+		if !ok {
+			continue
+		}
+		for _, hunk := range file.Hunks {
+			for _, line := range hunk.Lines {
+				if selection.contains(line) {
+					hunks = append(hunks, state.StagedHunk{File: file.DisplayName(), Header: hunk.Header})
+					break
+				}
+			}
+		}
+	}
+
+	marked := true
+	for _, hunk := range hunks {
+		marked = marked && m.staged[hunk]
+	}
+	if m.staged == nil {
+		m.staged = make(map[state.StagedHunk]bool)
+	}
+	for _, hunk := range hunks {
+		if marked {
+			delete(m.staged, hunk)
+		} else {
+			m.staged[hunk] = true
+		}
+	}
+	m.markStaged()
+}
+
+// markStaged flags the hunks marked as staged, by file and hunk header
+func (m *Model) markStaged() {
+	for _, file := range m.files {
+		for i := range file.Hunks {
+			hunk := &file.Hunks[i]
+			hunk.Staged = m.staged[state.StagedHunk{File: file.DisplayName(), Header: hunk.Header}]
+		}
+	}
+	m.renderer = &rendererCache{}
+}
+
+// stagedHunks counts the hunks shown marked as staged
+func (m Model) stagedHunks() int {
+	count := 0
+	for _, file := range m.files {
+		for _, hunk := range file.Hunks {
+			if hunk.Staged {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// undoStage takes the last staging step back out of the index
+func (m *Model) undoStage() error {
+	if len(m.undo) == 0 {
+		return nil
+	}
+	op := m.undo[len(m.undo)-1]
+	for i := len(op.patches) - 1; i >= 0; i-- {
+		if err := git.ReverseCached(op.patches[i]); err != nil {
+			return fmt.Errorf("failed to undo staging: %w", err)
+		}
+	}
+	m.undo = m.undo[:len(m.undo)-1]
+	m.redo = append(m.redo, op)
+	return m.reloadDiff()
+}
+
+// redoStage stages the last undone step again
+func (m *Model) redoStage() error {
+	if len(m.redo) == 0 {
+		return nil
+	}
+	op := m.redo[len(m.redo)-1]
+	for _, patch := range op.patches {
+		if err := git.ApplyCached(patch); err != nil {
+			return fmt.Errorf("failed to redo staging: %w", err)
+		}
+	}
+	m.redo = m.redo[:len(m.redo)-1]
+	m.undo = append(m.undo, op)
+	return m.reloadDiff()
+}
+
+// reloadDiff runs git diff again, keeping the collapsed files collapsed,
+// the files shown with more or less context so and the cursor within the
+// output
+func (m *Model) reloadDiff() error {
+	text, err := worktreeDiff(m.ctx, m.config, m.untracked)
+	if err != nil {
+		return err
+	}
+	files, err := parseFiles(m.ctx, text, m.config)
+	if err != nil {
+		return fmt.Errorf("failed to parse diff: %w", err)
+	}
+	// Files new to the diff get the collapsing of generated files
+	refold := m.collapseGenerated(files)
+	collapsed := make(map[string]bool)
+	for _, file := range m.files {
+		collapsed[file.DisplayName()] = file.Collapsed
+	}
+	for _, file := range files {
+		if was, ok := collapsed[file.DisplayName()]; ok {
+			file.Collapsed = was
+		}
+	}
+
+	// Rebuilt hunks lose the marks of folded duplicates
+	m.keepFileContext(files)
+	if refold || m.foldDuplicates != m.config.UI.FoldDuplicates || len(m.fileContext) > 0 {
+		diff.UnfoldDuplicateHunks(files)
+		if m.foldDuplicates {
+			diff.FoldDuplicateHunks(files)
+		}
+	}
+	diff.MarkApproved(files, m.approved)
+
+	m.diffText, m.files = text, files
+	m.renderer = &rendererCache{}
+	if len(files) > 0 {
+		m.moveCursor(0)
+	}
+	return nil
+}
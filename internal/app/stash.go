@@ -0,0 +1,272 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/avgvstvs96/differential/internal/config"
+	"github.com/avgvstvs96/differential/internal/diff"
+	"github.com/avgvstvs96/differential/internal/git"
+	"github.com/avgvstvs96/differential/internal/themes"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// RunStashDiff diffs two stash entries, or a stash entry against the
+// worktree. Without args the entries are chosen from a picker.
+func RunStashDiff(ctx context.Context, args []string, pipeMode bool, cfg *config.Config) error {
+	// Initialize themes
+	if err := themes.Initialize(); err != nil {
+		return fmt.Errorf("failed to initialize themes: %w", err)
+	}
+
+	// Set theme
+	if err := themes.Default().Set(cfg.UI.Theme); err != nil {
+		return fmt.Errorf("failed to set theme: %w", err)
+	}
+
+	var from, to string
+	switch len(args) {
+	case 0:
+		selected, err := pickStashes()
+		if err != nil || selected == nil {
+			return err
+		}
+		from = selected[0]
+		if len(selected) > 1 {
+			to = selected[1]
+		}
+	case 1:
+		from = args[0]
+	default:
+		from, to = args[0], args[1]
+	}
+
+	if err := git.ValidateRefs(git.StashRef(from)); err != nil {
+		return err
+	}
+	if to != "" {
+		if err := git.ValidateRefs(git.StashRef(to)); err != nil {
+			return err
+		}
+	}
+
+	diffText, err := git.StashDiff(from, to)
+	if err != nil {
+		return fmt.Errorf("failed to diff stash: %w", err)
+	}
+
+	if pipeMode {
+		return RunPipeMode(ctx, strings.NewReader(diffText), cfg, nil)
+	}
+	return startTUI(ctx, diffText, "", "", false, cfg)
+}
+
+// pickStashes lets the user choose one stash (compared against the worktree)
+// or two stashes (compared against each other)
+func pickStashes() ([]string, error) {
+	stashes, err := git.StashList()
+	if err != nil {
+		return nil, err
+	}
+	if len(stashes) == 0 {
+		return nil, fmt.Errorf("no stash entries found")
+	}
+
+	items := make([]string, len(stashes))
+	for i, stash := range stashes {
+		items[i] = fmt.Sprintf("%-12s %s", stash.Ref, stash.Subject)
+	}
+
+	marked, err := runPicker("Select a stash to compare with the worktree, or mark two to compare", items, 2)
+	if err != nil || marked == nil {
+		return nil, err
+	}
+
+	refs := make([]string, len(marked))
+	for i, idx := range marked {
+		refs[i] = stashes[idx].Ref
+	}
+	return refs, nil
+}
+
+// RunStashes lists the stash entries in the viewer, which shows the changes
+// of the one picked and applies, pops or drops it once confirmed
+func RunStashes(ctx context.Context, cfg *config.Config) error {
+	if err := themes.Initialize(); err != nil {
+		return fmt.Errorf("failed to initialize themes: %w", err)
+	}
+	if err := themes.Default().Set(cfg.UI.Theme); err != nil {
+		return fmt.Errorf("failed to set theme: %w", err)
+	}
+
+	stashes, err := git.StashList()
+	if err != nil {
+		return err
+	}
+	if len(stashes) == 0 {
+		return fmt.Errorf("no stash entries found")
+	}
+
+	m, err := newModel(ctx, "", "", "", false, cfg)
+	if err != nil {
+		return err
+	}
+	m.stashes = stashes
+	m.mode = ModeStashes
+	return runModel(ctx, m)
+}
+
+// showStash shows the changes of the stash entry under the list's cursor
+func (m *Model) showStash() {
+	if m.stashCursor >= len(m.stashes) {
+		return
+	}
+	stash := m.stashes[m.stashCursor]
+	diffText, err := git.StashShow(stash.Ref)
+	if err != nil {
+		m.err = fmt.Errorf("failed to show %s: %w", stash.Ref, err)
+		return
+	}
+	files, err := parseFiles(m.ctx, diffText, m.config)
+	if err != nil {
+		m.err = fmt.Errorf("failed to parse diff: %w", err)
+		return
+	}
+	m.replaceDiff(diffText, "", files)
+}
+
+// runStashAction applies, pops or drops the stash entry under the list's
+// cursor, then lists the entries again
+func (m *Model) runStashAction(action string) {
+	if m.stashCursor >= len(m.stashes) {
+		return
+	}
+	ref := m.stashes[m.stashCursor].Ref
+	var err error
+	switch action {
+	case "apply":
+		err = git.StashApply(ref)
+	case "pop":
+		err = git.StashPop(ref)
+	case "drop":
+		err = git.StashDrop(ref)
+	}
+	if err != nil {
+		m.stashStatus = fmt.Sprintf("failed to %s %s: %v", action, ref, err)
+		return
+	}
+	m.stashStatus = fmt.Sprintf("%s: %s", stashActionDone[action], ref)
+
+	stashes, err := git.StashList()
+	if err != nil {
+		m.err = err
+		return
+	}
+	// Keep the list non-nil so esc still leads back to it
+	m.stashes = append([]git.Stash{}, stashes...)
+	m.stashCursor = min(m.stashCursor, max(len(m.stashes)-1, 0))
+}
+
+// stashActionDone describes each stash action once it has run
+var stashActionDone = map[string]string{
+	"apply": "Applied",
+	"pop":   "Popped",
+	"drop":  "Dropped",
+}
+
+// handleStashKey handles key presses in the stash list. Applying, popping
+// and dropping wait for y to confirm; any other key cancels them.
+func (m Model) handleStashKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.stashConfirm != "" {
+		if msg.String() == "y" {
+			m.runStashAction(m.stashConfirm)
+		}
+		m.stashConfirm = ""
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "q", "ctrl+c":
+		return m, tea.Quit
+
+	case "j", "down":
+		m.stashCursor = min(m.stashCursor+1, max(len(m.stashes)-1, 0))
+
+	case "k", "up":
+		m.stashCursor = max(m.stashCursor-1, 0)
+
+	case "enter":
+		m.showStash()
+
+	case "a":
+		m.askStash("apply")
+
+	case "p":
+		m.askStash("pop")
+
+	case "d":
+		m.askStash("drop")
+	}
+	return m, nil
+}
+
+// askStash asks to confirm action on the stash entry under the cursor
+func (m *Model) askStash(action string) {
+	if m.stashCursor < len(m.stashes) {
+		m.stashConfirm = action
+		m.stashStatus = ""
+	}
+}
+
+// renderStashes renders the stash list, with the confirmation asked or the
+// outcome of the last action on its last line
+func (m Model) renderStashes() string {
+	theme := themes.GetCurrentTheme()
+	titleStyle := lipgloss.NewStyle().Foreground(theme.Text).Bold(true)
+	cursorStyle := lipgloss.NewStyle().Background(theme.Selection).Foreground(theme.Text)
+	mutedStyle := lipgloss.NewStyle().Foreground(theme.TextMuted)
+	refStyle := lipgloss.NewStyle().Foreground(theme.SyntaxFunction)
+	warnStyle := lipgloss.NewStyle().Foreground(theme.DiffRemoved).Bold(true)
+
+	var sb strings.Builder
+	sb.WriteString(titleStyle.Render(fmt.Sprintf("Stashes · %d", len(m.stashes))))
+	sb.WriteString("\n\n")
+
+	// Keep the cursor in view below the title
+	visible := max(m.visibleRows()-2, 1)
+	start := max(m.stashCursor-visible+1, 0)
+	end := min(start+visible, len(m.stashes))
+	if len(m.stashes) == 0 {
+		sb.WriteString(mutedStyle.Render("No stash entries"))
+		sb.WriteString("\n")
+		end = start + 1
+	}
+	for i := start; i < len(m.stashes) && i < end; i++ {
+		stash := m.stashes[i]
+		if i == m.stashCursor {
+			line := fmt.Sprintf("%-12s %s", stash.Ref, stash.Subject)
+			sb.WriteString(cursorStyle.Width(m.windowWidth).Render(diff.TruncateString(line, m.windowWidth)))
+		} else {
+			line := refStyle.Render(fmt.Sprintf("%-12s", stash.Ref)) + " " + stash.Subject
+			sb.WriteString(diff.TruncateString(line, m.windowWidth))
+		}
+		sb.WriteString("\n")
+	}
+	for i := end - start; i < visible; i++ {
+		sb.WriteString("\n")
+	}
+
+	if m.stashConfirm != "" {
+		ref := m.stashes[m.stashCursor].Ref
+		sb.WriteString(warnStyle.Render(fmt.Sprintf("%s %s? y: yes • any other key: no", m.stashConfirm, ref)))
+		return sb.String()
+	}
+	hint := "j/k: move • enter: show • a: apply • p: pop • d: drop • q: quit"
+	if m.stashStatus != "" {
+		hint = m.stashStatus + " • " + hint
+	}
+	sb.WriteString(mutedStyle.Render(hint))
+	return sb.String()
+}
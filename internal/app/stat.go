@@ -0,0 +1,108 @@
+package app
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/avgvstvs96/differential/internal/config"
+	"github.com/avgvstvs96/differential/internal/diff"
+)
+
+// Formats accepted by RunStat
+const (
+	StatFormatText = "text"
+	StatFormatJSON = "json"
+	StatFormatCSV  = "csv"
+)
+
+// RunStat prints per-file addition and deletion counts instead of the diff
+func RunStat(ctx context.Context, input io.Reader, cfg *config.Config, args []string, format string) error {
+	diffText, err := readDiffInput(ctx, input, cfg, args)
+	if err != nil {
+		return err
+	}
+
+	files, err := parseFiles(ctx, diffText, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to parse diff: %w", err)
+	}
+
+	return WriteStats(os.Stdout, diff.Stats(files), format)
+}
+
+// WriteStats writes file stats as an aligned table, JSON or CSV
+func WriteStats(w io.Writer, stats []diff.FileStat, format string) error {
+	switch format {
+	case "", StatFormatText:
+		return writeStatsText(w, stats)
+	case StatFormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(stats)
+	case StatFormatCSV:
+		return writeStatsCSV(w, stats)
+	default:
+		return fmt.Errorf("unknown stat format %q (want text, json or csv)", format)
+	}
+}
+
+// writeStatsText writes a git diff --stat style summary
+func writeStatsText(w io.Writer, stats []diff.FileStat) error {
+	nameWidth := 0
+	for _, stat := range stats {
+		nameWidth = max(nameWidth, utf8.RuneCountInString(statName(stat)))
+	}
+
+	additions, deletions := 0, 0
+	for _, stat := range stats {
+		changes := fmt.Sprintf("+%d -%d", stat.Additions, stat.Deletions)
+		if stat.Binary {
+			changes = "Bin"
+		}
+		name := statName(stat)
+		padding := strings.Repeat(" ", nameWidth-utf8.RuneCountInString(name))
+		if _, err := fmt.Fprintf(w, " %s%s | %s\n", name, padding, changes); err != nil {
+			return err
+		}
+		additions += stat.Additions
+		deletions += stat.Deletions
+	}
+
+	size := diff.ReviewSizeOf(stats)
+	_, err := fmt.Fprintf(w, " %d file(s) changed, %d insertion(s)(+), %d deletion(s)(-), size %s (%s to review)\n", len(stats), additions, deletions, size, size.Estimate())
+	return err
+}
+
+// statName returns the name shown for a file, "old => new" for renames and
+// copies
+func statName(stat diff.FileStat) string {
+	if stat.OldPath != "" {
+		return stat.OldPath + " => " + stat.Path
+	}
+	return stat.Path
+}
+
+// writeStatsCSV writes one row per file below a header row
+func writeStatsCSV(w io.Writer, stats []diff.FileStat) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"path", "old_path", "status", "additions", "deletions", "binary"})
+	for _, stat := range stats {
+		cw.Write([]string{
+			stat.Path,
+			stat.OldPath,
+			stat.Status,
+			strconv.Itoa(stat.Additions),
+			strconv.Itoa(stat.Deletions),
+			strconv.FormatBool(stat.Binary),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
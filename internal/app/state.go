@@ -0,0 +1,87 @@
+package app
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/avgvstvs96/differential/internal/diff"
+	"github.com/avgvstvs96/differential/internal/git"
+	"github.com/avgvstvs96/differential/internal/state"
+)
+
+// repoRoot returns the top-level directory of the current repository, or an
+// empty string outside a repository
+func repoRoot() string {
+	root, err := git.Root()
+	if err != nil {
+		return ""
+	}
+	return root
+}
+
+// restoreCollapsed collapses the files that were collapsed when this
+// repository was last viewed
+func (m *Model) restoreCollapsed() {
+	if m.config.StateFile == "" {
+		return
+	}
+	root := repoRoot()
+	if root == "" {
+		return
+	}
+	saved, err := state.Load(m.config.StateFile)
+	if err != nil {
+		return
+	}
+
+	collapsed := make(map[string]bool)
+	for _, path := range saved.Repo(root).Collapsed {
+		collapsed[path] = true
+	}
+	for _, file := range m.files {
+		if collapsed[file.DisplayName()] {
+			file.Collapsed = true
+		}
+	}
+}
+
+// saveState records the session's UI state for the next launch
+func (m Model) saveState() {
+	if m.config.StateFile == "" {
+		return
+	}
+	saved, err := state.Load(m.config.StateFile)
+	if err != nil {
+		// Replace an unreadable state file rather than failing on exit
+		saved = &state.State{}
+	}
+
+	saved.ViewMode = "unified"
+	if m.viewMode == diff.ViewSideBySide {
+		saved.ViewMode = "side-by-side"
+	}
+	saved.Theme = m.config.UI.Theme
+	lineNumbers, wrapLines := m.showLineNumbers, m.config.UI.WrapLines
+	saved.LineNumbers = &lineNumbers
+	saved.WrapLines = &wrapLines
+	saved.Gutter = m.gutter.Mode.String()
+	dimContext := m.dimContext
+	saved.DimContext = &dimContext
+
+	if root := repoRoot(); root != "" {
+		repo := saved.Repo(root)
+		repo.Collapsed = repo.Collapsed[:0]
+		for _, file := range m.files {
+			if file.Collapsed {
+				repo.Collapsed = append(repo.Collapsed, file.DisplayName())
+			}
+		}
+		if len(repo.Collapsed) == 0 {
+			delete(saved.Repos, root)
+		}
+	}
+
+	if err := saved.Save(m.config.StateFile); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to save state: %v\n", err)
+	}
+}
@@ -0,0 +1,142 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/avgvstvs96/differential/internal/diff"
+	"github.com/avgvstvs96/differential/internal/themes"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// summaryBarWidth is the widest bar of the hunk size histogram
+const summaryBarWidth = 30
+
+// openSummary shows the statistics of the diff
+func (m *Model) openSummary() {
+	m.summary = diff.Summarize(m.files, m.languages)
+	m.summaryScroll = 0
+	m.mode = ModeSummary
+}
+
+// handleSummaryKey handles key presses in the statistics panel
+func (m Model) handleSummaryKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q", "ctrl+c":
+		return m, tea.Quit
+
+	case "j", "down":
+		last := max(len(m.summaryLines())-m.summaryRows(), 0)
+		m.summaryScroll = min(m.summaryScroll+1, last)
+
+	case "k", "up":
+		m.summaryScroll = max(m.summaryScroll-1, 0)
+
+	case "esc", "S":
+		m.mode = ModeDiff
+	}
+	return m, nil
+}
+
+// summaryRows returns the rows of the statistics panel that fit below its
+// title
+func (m Model) summaryRows() int {
+	return max(m.visibleRows()-2, 1)
+}
+
+// summaryLines returns the lines of the statistics panel below its title:
+// changes by language, the largest files and a histogram of hunk sizes
+func (m Model) summaryLines() []string {
+	theme := themes.GetCurrentTheme()
+	titleStyle := lipgloss.NewStyle().Foreground(theme.Text).Bold(true)
+	mutedStyle := lipgloss.NewStyle().Foreground(theme.TextMuted)
+	addedStyle := lipgloss.NewStyle().Foreground(theme.DiffAdded)
+	removedStyle := lipgloss.NewStyle().Foreground(theme.DiffRemoved)
+	barStyle := lipgloss.NewStyle().Foreground(theme.SyntaxFunction)
+
+	s := m.summary
+	counts := func(additions, deletions int) string {
+		return addedStyle.Render(fmt.Sprintf("%6s", fmt.Sprintf("+%d", additions))) + " " +
+			removedStyle.Render(fmt.Sprintf("%6s", fmt.Sprintf("-%d", deletions)))
+	}
+
+	var lines []string
+	nameWidth := 0
+	for _, lang := range s.Languages {
+		nameWidth = max(nameWidth, lipgloss.Width(lang.Name))
+	}
+	lines = append(lines, titleStyle.Render("By language"))
+	for _, lang := range s.Languages {
+		files := "1 file"
+		if lang.Files != 1 {
+			files = fmt.Sprintf("%d files", lang.Files)
+		}
+		lines = append(lines, fmt.Sprintf("  %-*s %s %s", nameWidth, lang.Name, counts(lang.Additions, lang.Deletions), mutedStyle.Render(files)))
+	}
+	lines = append(lines, "")
+
+	lines = append(lines, titleStyle.Render("Largest files"))
+	for _, stat := range s.Largest {
+		lines = append(lines, "  "+counts(stat.Additions, stat.Deletions)+" "+statName(stat))
+	}
+	lines = append(lines, "")
+
+	lines = append(lines, titleStyle.Render(fmt.Sprintf("Hunk sizes · %d hunks", s.Hunks)))
+	most := 0
+	for _, bucket := range s.HunkSizes {
+		most = max(most, bucket.Hunks)
+	}
+	for _, bucket := range s.HunkSizes {
+		label := fmt.Sprintf("%d-%d", bucket.Min, bucket.Max)
+		switch {
+		case bucket.Max == 0:
+			label = fmt.Sprintf("%d+", bucket.Min)
+		case bucket.Min == bucket.Max:
+			label = fmt.Sprint(bucket.Min)
+		}
+		bar := ""
+		if most > 0 {
+			bar = strings.Repeat("█", (bucket.Hunks*summaryBarWidth+most-1)/most)
+		}
+		lines = append(lines, fmt.Sprintf("  %7s lines %s %s", label, barStyle.Render(bar), mutedStyle.Render(fmt.Sprint(bucket.Hunks))))
+	}
+	return lines
+}
+
+// renderSummary renders the statistics panel: the totals, then the lines
+// of summaryLines scrolled into view
+func (m Model) renderSummary() string {
+	theme := themes.GetCurrentTheme()
+	titleStyle := lipgloss.NewStyle().Foreground(theme.Text).Bold(true)
+	mutedStyle := lipgloss.NewStyle().Foreground(theme.TextMuted)
+	addedStyle := lipgloss.NewStyle().Foreground(theme.DiffAdded)
+	removedStyle := lipgloss.NewStyle().Foreground(theme.DiffRemoved)
+
+	s := m.summary
+	files := "1 file"
+	if s.Files != 1 {
+		files = fmt.Sprintf("%d files", s.Files)
+	}
+	var sb strings.Builder
+	sb.WriteString(titleStyle.Render(files + " changed"))
+	sb.WriteString(" " + addedStyle.Render(fmt.Sprintf("+%d", s.Additions)) + " " + removedStyle.Render(fmt.Sprintf("-%d", s.Deletions)))
+	if s.Binary > 0 {
+		sb.WriteString(mutedStyle.Render(fmt.Sprintf(" · %d binary", s.Binary)))
+	}
+	sb.WriteString("\n\n")
+
+	lines := m.summaryLines()
+	visible := m.summaryRows()
+	start := min(m.summaryScroll, max(len(lines)-visible, 0))
+	end := min(start+visible, len(lines))
+	for _, line := range lines[start:end] {
+		sb.WriteString(diff.TruncateString(line, m.windowWidth))
+		sb.WriteString("\n")
+	}
+	for i := end - start; i < visible; i++ {
+		sb.WriteString("\n")
+	}
+	sb.WriteString(mutedStyle.Render("j/k: scroll • esc: back • q: quit"))
+	return sb.String()
+}
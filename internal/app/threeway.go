@@ -0,0 +1,63 @@
+package app
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/avgvstvs96/differential/internal/config"
+	"github.com/avgvstvs96/differential/internal/diff"
+	"github.com/avgvstvs96/differential/internal/themes"
+)
+
+// IsThreeWay reports whether args name a base, ours and theirs file
+func IsThreeWay(args []string) bool {
+	if len(args) != 3 {
+		return false
+	}
+	for _, arg := range args {
+		info, err := os.Stat(arg)
+		if err != nil || info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// RunThreeWay renders base, ours and theirs side by side relative to base
+func RunThreeWay(baseFile, oursFile, theirsFile string, cfg *config.Config) error {
+	// Initialize themes
+	if err := themes.Initialize(); err != nil {
+		return fmt.Errorf("failed to initialize themes: %w", err)
+	}
+
+	// Set theme
+	if err := themes.Default().Set(cfg.UI.Theme); err != nil {
+		return fmt.Errorf("failed to set theme: %w", err)
+	}
+
+	contents := make([]string, 3)
+	for i, path := range []string{baseFile, oursFile, theirsFile} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		contents[i] = string(data)
+	}
+
+	languages, err := languageOptions(cfg)
+	if err != nil {
+		return err
+	}
+
+	result := diff.ComputeThreeWay(baseFile, oursFile, theirsFile, contents[0], contents[1], contents[2])
+
+	opts := diff.RenderOptions{
+		Width:           outputWidth(cfg),
+		ShowLineNumbers: cfg.UI.LineNumbers,
+		ContextLines:    cfg.Git.DefaultContext,
+		TabWidth:        cfg.UI.TabWidth,
+		Languages:       languages,
+	}
+
+	return displayOutput(diff.RenderThreeWayDiff(result, opts), cfg)
+}
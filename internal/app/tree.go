@@ -0,0 +1,308 @@
+package app
+
+import (
+	"path"
+	"strings"
+
+	"github.com/avgvstvs96/differential/internal/diff"
+	"github.com/avgvstvs96/differential/internal/themes"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Bounds of the file tree pane, as a share of the window and in columns
+const (
+	minTreeRatio = 0.1
+	maxTreeRatio = 0.8
+	minTreeWidth = 12
+	minDiffWidth = 20
+)
+
+// treeRow is a row of the file tree: a directory, or a file of the diff
+type treeRow struct {
+	depth int
+	name  string // Directories end in "/"; chains of single directories are joined
+	file  int    // Index of the file in the diff, -1 for directories
+}
+
+// treeNode is a directory or file while the tree is built
+type treeNode struct {
+	name     string
+	file     int
+	children []*treeNode
+}
+
+// buildTree lays out the files of a diff as a directory tree, keeping
+// their order
+func buildTree(files []*diff.DiffResult) []treeRow {
+	root := &treeNode{file: -1}
+	for i, file := range files {
+		parts := strings.Split(file.DisplayName(), "/")
+		node := root
+		for _, dir := range parts[:len(parts)-1] {
+			var child *treeNode
+			for _, c := range node.children {
+				if c.file < 0 && c.name == dir {
+					child = c
+					break
+				}
+			}
+			if child == nil {
+				child = &treeNode{name: dir, file: -1}
+				node.children = append(node.children, child)
+			}
+			node = child
+		}
+		node.children = append(node.children, &treeNode{name: parts[len(parts)-1], file: i})
+	}
+
+	var rows []treeRow
+	var walk func(node *treeNode, depth int)
+	walk = func(node *treeNode, depth int) {
+		for _, child := range node.children {
+			if child.file >= 0 {
+				rows = append(rows, treeRow{depth: depth, name: child.name, file: child.file})
+				continue
+			}
+			// Join directories that only hold another directory
+			name := child.name
+			for len(child.children) == 1 && child.children[0].file < 0 {
+				child = child.children[0]
+				name = path.Join(name, child.name)
+			}
+			rows = append(rows, treeRow{depth: depth, name: name + "/", file: -1})
+			walk(child, depth+1)
+		}
+	}
+	walk(root, 0)
+	return rows
+}
+
+// treeWidth returns the columns of the file tree pane, 0 when it's hidden
+func (m Model) treeWidth() int {
+	if !m.showTree || m.windowWidth < minTreeWidth+minDiffWidth+1 {
+		return 0
+	}
+	width := int(m.treeRatio * float64(m.windowWidth))
+	return min(max(width, minTreeWidth), m.windowWidth-minDiffWidth-1)
+}
+
+// diffWidth returns the columns the diff is rendered at, right of the file
+// tree and its divider when it is shown
+func (m Model) diffWidth() int {
+	if width := m.treeWidth(); width > 0 {
+		return m.windowWidth - width - 1
+	}
+	return m.windowWidth
+}
+
+// toggleTree shows or hides the file tree, taking the mouse for resizing
+// it while it's shown
+func (m *Model) toggleTree() tea.Cmd {
+	m.showTree = !m.showTree
+	m.treeFocus = false
+	if m.showTree {
+		return tea.EnableMouseCellMotion
+	}
+	return tea.DisableMouse
+}
+
+// cursorFile returns the index of the file the diff cursor is in, or -1
+// above the first file
+func (m Model) cursorFile() int {
+	_, offsets := m.renderer.get(m.renderOptions()).RenderFilesWithOffsets(m.files)
+	headerLines := strings.Count(m.header, "\n")
+	current := -1
+	for i, offset := range offsets {
+		if offset+headerLines <= m.cursor {
+			current = i
+		}
+	}
+	return current
+}
+
+// selectTreeRow moves the tree cursor to row and the diff cursor to the
+// start of its file
+func (m *Model) selectTreeRow(row int) {
+	tree := buildTree(m.files)
+	if row < 0 || row >= len(tree) {
+		return
+	}
+	m.treeCursor = row
+	file := tree[row].file
+	if file < 0 {
+		return
+	}
+	_, offsets := m.renderer.get(m.renderOptions()).RenderFilesWithOffsets(m.files)
+	if file < len(offsets) {
+		m.cursor = offsets[file] + strings.Count(m.header, "\n")
+		// Show the file from the top, or the end of the output
+		m.scrollOffset = m.cursor
+		m.clampScroll()
+	}
+}
+
+// moveTreeCursor moves the tree cursor by delta file rows, skipping
+// directories
+func (m *Model) moveTreeCursor(delta int) {
+	step := 1
+	if delta < 0 {
+		step, delta = -1, -delta
+	}
+	tree := buildTree(m.files)
+	row := m.treeCursor
+	for ; delta > 0; delta-- {
+		next := row + step
+		for next >= 0 && next < len(tree) && tree[next].file < 0 {
+			next += step
+		}
+		if next < 0 || next >= len(tree) {
+			break
+		}
+		row = next
+	}
+	m.selectTreeRow(row)
+}
+
+// handleTreeKey handles the key presses of the focused file tree,
+// reporting false for keys left to the diff
+func (m *Model) handleTreeKey(msg tea.KeyMsg) bool {
+	switch msg.String() {
+	case "j", "down":
+		m.moveTreeCursor(1)
+	case "k", "up":
+		m.moveTreeCursor(-1)
+	case "enter", "l", "right":
+		m.selectTreeRow(m.treeCursor)
+		m.treeFocus = false
+	default:
+		return false
+	}
+	return true
+}
+
+// handleMouse resizes the file tree by dragging its divider, selects the
+// files clicked in it and scrolls the diff with the wheel
+func (m Model) handleMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	width := m.treeWidth()
+	switch {
+	case msg.Button == tea.MouseButtonWheelUp:
+		m.moveCursor(-3)
+	case msg.Button == tea.MouseButtonWheelDown:
+		m.moveCursor(3)
+	case msg.Action == tea.MouseActionRelease:
+		m.dragging = false
+	case msg.Action == tea.MouseActionMotion && m.dragging:
+		ratio := float64(msg.X) / float64(max(m.windowWidth, 1))
+		m.treeRatio = min(max(ratio, minTreeRatio), maxTreeRatio)
+		m.followCursor()
+	case msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonLeft && width > 0:
+		switch {
+		case msg.X == width:
+			m.dragging = true
+		case msg.X < width && msg.Y < m.visibleRows():
+			m.selectTreeRow(m.treeScroll() + msg.Y)
+			m.treeFocus = true
+		}
+	}
+	return m, nil
+}
+
+// treeScroll returns the first tree row shown, keeping the highlighted row
+// in view
+func (m Model) treeScroll() int {
+	return max(m.treeHighlight()-m.visibleRows()+1, 0)
+}
+
+// treeHighlight returns the tree row highlighted: the tree cursor when the
+// tree has the focus, otherwise the file the diff cursor is in
+func (m Model) treeHighlight() int {
+	if m.treeFocus {
+		return m.treeCursor
+	}
+	file := m.cursorFile()
+	for i, row := range buildTree(m.files) {
+		if row.file == file {
+			return i
+		}
+	}
+	return 0
+}
+
+// renderTree renders rows of the file tree pane, each padded to width:
+// directories, and files marked with how they changed and named in the
+// color of their change heat, so the biggest changes stand out
+func (m Model) renderTree(rows, width int) []string {
+	theme := themes.GetCurrentTheme()
+	dirStyle := lipgloss.NewStyle().Foreground(theme.TextMuted).Bold(true)
+	highlightStyle := lipgloss.NewStyle().Background(theme.Selection).Foreground(theme.Text)
+	if m.treeFocus {
+		highlightStyle = highlightStyle.Background(theme.Cursor)
+	}
+	statusStyles := map[string]lipgloss.Style{
+		diff.StatusAdded:    lipgloss.NewStyle().Foreground(theme.DiffAdded),
+		diff.StatusDeleted:  lipgloss.NewStyle().Foreground(theme.DiffRemoved),
+		diff.StatusRenamed:  lipgloss.NewStyle().Foreground(theme.SyntaxType),
+		diff.StatusCopied:   lipgloss.NewStyle().Foreground(theme.SyntaxType),
+		diff.StatusModified: lipgloss.NewStyle().Foreground(theme.TextMuted),
+	}
+
+	heat := diff.ChangeHeat(m.files)
+	tree := buildTree(m.files)
+	highlight := m.treeHighlight()
+	start := m.treeScroll()
+	lines := make([]string, rows)
+	for i := range lines {
+		row := start + i
+		if row >= len(tree) {
+			lines[i] = strings.Repeat(" ", width)
+			continue
+		}
+		tr := tree[row]
+		indent := strings.Repeat("  ", tr.depth)
+		var line string
+		if tr.file < 0 {
+			line = indent + "▾ " + tr.name
+			if row != highlight {
+				line = indent + dirStyle.Render("▾ "+tr.name)
+			}
+		} else {
+			stat := m.files[tr.file].Stat()
+			icon := treeStatusIcons[stat.Status]
+			line = indent + icon + " " + tr.name
+			if row != highlight {
+				line = indent + statusStyles[stat.Status].Render(icon) + " " + lipgloss.NewStyle().Foreground(theme.Heat(heat[tr.file])).Render(tr.name)
+			}
+		}
+		line = diff.TruncateString(line, width)
+		if row == highlight {
+			line = highlightStyle.Width(width).Render(line)
+		} else if pad := width - lipgloss.Width(line); pad > 0 {
+			line += strings.Repeat(" ", pad)
+		}
+		lines[i] = line
+	}
+	return lines
+}
+
+// treeStatusIcons mark files in the tree by how they changed
+var treeStatusIcons = map[string]string{
+	diff.StatusAdded:    "A",
+	diff.StatusDeleted:  "D",
+	diff.StatusRenamed:  "R",
+	diff.StatusCopied:   "C",
+	diff.StatusModified: "M",
+}
+
+// joinTree puts the file tree left of the diff rows, with a divider
+func (m Model) joinTree(rows []string) []string {
+	width := m.treeWidth()
+	theme := themes.GetCurrentTheme()
+	divider := lipgloss.NewStyle().Foreground(theme.Border).Render("│")
+	tree := m.renderTree(len(rows), width)
+	joined := make([]string, len(rows))
+	for i, row := range rows {
+		joined[i] = tree[i] + divider + row
+	}
+	return joined
+}
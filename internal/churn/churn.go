@@ -0,0 +1,128 @@
+package churn
+
+import (
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/avgvstvs96/differential/internal/git"
+)
+
+// Options controls how changes are grouped into a Table
+type Options struct {
+	ByDir   bool // Group files by directory
+	Depth   int  // Directory levels kept when grouping by directory
+	Buckets int  // Number of time columns
+	Top     int  // Maximum number of rows, 0 for all
+}
+
+// Row is the churn of one file or directory
+type Row struct {
+	Name      string
+	Commits   int
+	Additions int
+	Deletions int
+	Buckets   []int // Lines changed in each time bucket, oldest first
+}
+
+// Churn returns the total number of lines changed
+func (r Row) Churn() int {
+	return r.Additions + r.Deletions
+}
+
+// Table is the churn of a revision range, hottest rows first
+type Table struct {
+	Rows  []Row
+	Start time.Time // Time of the oldest commit
+	End   time.Time // Time of the newest commit
+	Max   int       // Largest bucket value, for scaling the heatmap
+}
+
+// Aggregate groups per-commit file changes into rows with their churn spread
+// over evenly sized time buckets
+func Aggregate(stats []git.NumStat, opts Options) *Table {
+	if opts.Buckets < 1 {
+		opts.Buckets = 1
+	}
+
+	table := &Table{}
+	for i, stat := range stats {
+		if i == 0 || stat.Time.Before(table.Start) {
+			table.Start = stat.Time
+		}
+		if i == 0 || stat.Time.After(table.End) {
+			table.End = stat.Time
+		}
+	}
+	span := table.End.Sub(table.Start)
+
+	rows := make(map[string]*Row)
+	seen := make(map[string]bool) // name + commit, to count each commit once per row
+	for _, stat := range stats {
+		name := stat.Path
+		if opts.ByDir {
+			name = dirName(stat.Path, opts.Depth)
+		}
+
+		row, ok := rows[name]
+		if !ok {
+			row = &Row{Name: name, Buckets: make([]int, opts.Buckets)}
+			rows[name] = row
+		}
+		if key := name + "\x00" + stat.Commit; !seen[key] {
+			seen[key] = true
+			row.Commits++
+		}
+		row.Additions += stat.Additions
+		row.Deletions += stat.Deletions
+
+		bucket := 0
+		if span > 0 {
+			// In floating point, as the elapsed time times the bucket count
+			// overflows a Duration over a range of years
+			elapsed := stat.Time.Sub(table.Start)
+			bucket = int(float64(elapsed) / float64(span+1) * float64(opts.Buckets))
+			bucket = min(max(bucket, 0), opts.Buckets-1)
+		}
+		row.Buckets[bucket] += stat.Additions + stat.Deletions
+	}
+
+	for _, row := range rows {
+		table.Rows = append(table.Rows, *row)
+	}
+	sort.Slice(table.Rows, func(i, j int) bool {
+		a, b := table.Rows[i], table.Rows[j]
+		if a.Churn() != b.Churn() {
+			return a.Churn() > b.Churn()
+		}
+		if a.Commits != b.Commits {
+			return a.Commits > b.Commits
+		}
+		return a.Name < b.Name
+	})
+	if opts.Top > 0 && len(table.Rows) > opts.Top {
+		table.Rows = table.Rows[:opts.Top]
+	}
+
+	for _, row := range table.Rows {
+		for _, value := range row.Buckets {
+			table.Max = max(table.Max, value)
+		}
+	}
+	return table
+}
+
+// dirName returns the first depth directories of a file path, or "." for
+// files at the top level
+func dirName(file string, depth int) string {
+	dir := path.Dir(file)
+	if dir == "." || depth < 1 {
+		return "."
+	}
+	parts := strings.Split(dir, "/")
+	if len(parts) > depth {
+		parts = parts[:depth]
+	}
+	return strings.Join(parts, "/") + "/"
+}
@@ -0,0 +1,117 @@
+package commit
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	commitLineRegex = regexp.MustCompile(`^commit ([0-9a-f]{7,64})\b`)
+	authorRegex     = regexp.MustCompile(`^Author:\s+(.*?)\s*<([^>]*)>`)
+	dateRegex       = regexp.MustCompile(`^(?:Author)?Date:\s+(.*)`)
+)
+
+// Commit is the metadata git log and git show print above a commit's diff
+type Commit struct {
+	Hash        string
+	Author      string
+	AuthorEmail string
+	Date        string   // Author date, as git printed it
+	Headers     []string // Raw header lines (Author, Date, Merge, ...)
+	Message     string   // Commit message with the indentation removed
+}
+
+// Subject returns the first line of the message
+func (c *Commit) Subject() string {
+	subject, _, _ := strings.Cut(c.Message, "\n")
+	return subject
+}
+
+// ShortHash returns the abbreviated commit hash
+func (c *Commit) ShortHash() string {
+	if len(c.Hash) > 7 {
+		return c.Hash[:7]
+	}
+	return c.Hash
+}
+
+// Parse extracts the commits from git log -p or git show output. Diff text
+// without commit headers yields no commits.
+func Parse(text string) []*Commit {
+	var commits []*Commit
+	var current *Commit
+	var message []string
+	inHeaders, inMessage := false, false
+
+	finish := func() {
+		if current != nil {
+			current.Message = strings.TrimRight(strings.Join(message, "\n"), "\n")
+			commits = append(commits, current)
+		}
+		current, message = nil, nil
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		if matches := commitLineRegex.FindStringSubmatch(line); matches != nil {
+			finish()
+			current = &Commit{Hash: matches[1]}
+			inHeaders, inMessage = true, false
+			continue
+		}
+		if current == nil {
+			continue
+		}
+
+		switch {
+		case inHeaders:
+			if line == "" {
+				inHeaders, inMessage = false, true
+				continue
+			}
+			current.Headers = append(current.Headers, line)
+			if matches := authorRegex.FindStringSubmatch(line); matches != nil {
+				current.Author, current.AuthorEmail = matches[1], matches[2]
+			}
+			if matches := dateRegex.FindStringSubmatch(line); matches != nil && current.Date == "" {
+				current.Date = matches[1]
+			}
+		case inMessage:
+			// The message is indented by four spaces; anything else ends it
+			if rest, ok := strings.CutPrefix(line, "    "); ok {
+				message = append(message, rest)
+			} else if line == "" {
+				message = append(message, "")
+			} else {
+				inMessage = false
+			}
+		}
+	}
+	finish()
+
+	return commits
+}
+
+// IsCommitLine reports whether line starts a commit in git log output
+func IsCommitLine(line string) bool {
+	return commitLineRegex.MatchString(line)
+}
+
+// Split splits git log -p output into one chunk per commit, each starting
+// with its commit line. Text before the first commit is dropped.
+func Split(text string) []string {
+	var chunks []string
+	var current strings.Builder
+	for _, line := range strings.SplitAfter(text, "\n") {
+		if commitLineRegex.MatchString(line) && current.Len() > 0 {
+			chunks = append(chunks, current.String())
+			current.Reset()
+		}
+		if current.Len() > 0 || commitLineRegex.MatchString(line) {
+			current.WriteString(line)
+		}
+	}
+	if current.Len() > 0 {
+		chunks = append(chunks, current.String())
+	}
+	return chunks
+}
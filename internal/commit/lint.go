@@ -0,0 +1,135 @@
+package commit
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// trailerRegex matches a well-formed trailer line such as "Signed-off-by: ..."
+var trailerRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9-]*: \S`)
+
+// knownTrailers are the trailer keys that mark a paragraph as a trailer block
+var knownTrailers = map[string]bool{
+	"signed-off-by":  true,
+	"co-authored-by": true,
+	"reviewed-by":    true,
+	"acked-by":       true,
+	"tested-by":      true,
+	"reported-by":    true,
+	"helped-by":      true,
+	"cc":             true,
+	"fixes":          true,
+	"closes":         true,
+	"change-id":      true,
+}
+
+// LintOptions configures the commit message checks
+type LintOptions struct {
+	SubjectLength  int  // Longest acceptable subject line
+	BodyWidth      int  // Column body lines should be wrapped at
+	RequireSignoff bool // Require a Signed-off-by trailer matching the author (DCO)
+}
+
+// Finding is a single problem found in a commit message
+type Finding struct {
+	Rule    string // Short rule name, e.g. "subject-length"
+	Line    int    // 1-based message line, 0 for the message as a whole
+	Message string
+}
+
+// Lint checks a commit message for common style problems: long or
+// period-terminated subjects, a missing blank line after the subject, body
+// lines over the wrap width and malformed or missing trailers
+func Lint(c *Commit, opts LintOptions) []Finding {
+	var findings []Finding
+	lines := strings.Split(c.Message, "\n")
+	subject := lines[0]
+
+	if strings.TrimSpace(subject) == "" {
+		return []Finding{{Rule: "subject-empty", Line: 1, Message: "subject line is empty"}}
+	}
+	if n := len([]rune(subject)); opts.SubjectLength > 0 && n > opts.SubjectLength {
+		findings = append(findings, Finding{
+			Rule:    "subject-length",
+			Line:    1,
+			Message: fmt.Sprintf("subject is %d characters (limit %d)", n, opts.SubjectLength),
+		})
+	}
+	if strings.HasSuffix(subject, ".") {
+		findings = append(findings, Finding{Rule: "subject-period", Line: 1, Message: "subject ends with a period"})
+	}
+	if len(lines) > 1 && strings.TrimSpace(lines[1]) != "" {
+		findings = append(findings, Finding{Rule: "blank-line", Line: 2, Message: "no blank line between subject and body"})
+	}
+
+	body, trailers := splitTrailers(lines[1:])
+
+	for i, line := range body {
+		n := len([]rune(line))
+		if opts.BodyWidth <= 0 || n <= opts.BodyWidth || unwrappable(line) {
+			continue
+		}
+		findings = append(findings, Finding{
+			Rule:    "body-wrap",
+			Line:    i + 2,
+			Message: fmt.Sprintf("body line is %d characters (wrap at %d)", n, opts.BodyWidth),
+		})
+	}
+
+	signedOff := false
+	for i, line := range trailers {
+		lineNo := len(body) + i + 2
+		if !trailerRegex.MatchString(line) {
+			findings = append(findings, Finding{Rule: "trailer-format", Line: lineNo, Message: fmt.Sprintf("malformed trailer %q", line)})
+			continue
+		}
+		key, value, _ := strings.Cut(line, ": ")
+		if strings.EqualFold(key, "Signed-off-by") {
+			signedOff = signedOff || c.AuthorEmail == "" || strings.Contains(value, "<"+c.AuthorEmail+">")
+		}
+	}
+	if opts.RequireSignoff && !signedOff {
+		findings = append(findings, Finding{Rule: "signoff", Message: "missing Signed-off-by trailer for the author (DCO)"})
+	}
+
+	return findings
+}
+
+// splitTrailers separates the trailer block, the last paragraph of the
+// message when it looks like "Key: value" lines, from the body
+func splitTrailers(lines []string) (body, trailers []string) {
+	end := len(lines)
+	for end > 0 && strings.TrimSpace(lines[end-1]) == "" {
+		end--
+	}
+	start := end
+	for start > 0 && strings.TrimSpace(lines[start-1]) != "" {
+		start--
+	}
+	if start == 0 || start == end {
+		return lines[:end], nil
+	}
+
+	// Like git, treat the paragraph as trailers when every line is one or it
+	// has a well-known trailer, so malformed lines next to it get reported
+	paragraph := lines[start:end]
+	matched, known := 0, false
+	for _, line := range paragraph {
+		if trailerRegex.MatchString(line) {
+			matched++
+			key, _, _ := strings.Cut(line, ":")
+			known = known || knownTrailers[strings.ToLower(key)]
+		}
+	}
+	if matched < len(paragraph) && !known {
+		return lines[:end], nil
+	}
+	return lines[:start], paragraph
+}
+
+// unwrappable reports whether a long line can't reasonably be wrapped, like
+// URLs or indented code
+func unwrappable(line string) bool {
+	return strings.Contains(line, "://") || strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t")
+}
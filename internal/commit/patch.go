@@ -0,0 +1,177 @@
+package commit
+
+import (
+	"mime"
+	"net/mail"
+	"regexp"
+	"strings"
+)
+
+var (
+	// mboxFromRegex matches the line git format-patch starts each patch with
+	mboxFromRegex = regexp.MustCompile(`^From ([0-9a-f]{7,64}) `)
+	// subjectPrefixRegex matches the [PATCH n/m] prefix of a patch subject
+	subjectPrefixRegex = regexp.MustCompile(`^(\[[^\]]*\]\s*)+`)
+)
+
+// Patch is one patch of a mail-style series, as git format-patch writes
+// them: the commit it was made from and its diff
+type Patch struct {
+	Commit *Commit // Nil when the patch has no mail headers
+	Diff   string
+}
+
+// ParsePatches splits text holding one or more mail-style patches into
+// patches. Text without mail headers, such as a plain diff, is a single
+// patch without a commit.
+func ParsePatches(text string) []Patch {
+	var chunks []string
+	var current strings.Builder
+	for _, line := range strings.SplitAfter(text, "\n") {
+		if mboxFromRegex.MatchString(line) && current.Len() > 0 {
+			chunks = append(chunks, current.String())
+			current.Reset()
+		}
+		current.WriteString(line)
+	}
+	if current.Len() > 0 {
+		chunks = append(chunks, current.String())
+	}
+
+	var patches []Patch
+	for _, chunk := range chunks {
+		// Skip what precedes the first patch of an mbox, unless it is all there is
+		if len(chunks) > 1 && len(patches) == 0 && !isMailPatch(chunk) {
+			continue
+		}
+		patches = append(patches, parsePatch(chunk))
+	}
+	return patches
+}
+
+// isMailPatch reports whether text starts with mail headers
+func isMailPatch(text string) bool {
+	first, _, _ := strings.Cut(text, "\n")
+	return mboxFromRegex.MatchString(first) ||
+		strings.HasPrefix(first, "From: ") || strings.HasPrefix(first, "Subject: ")
+}
+
+// parsePatch parses a single patch, splitting its headers and message from
+// its diff
+func parsePatch(text string) Patch {
+	if !isMailPatch(text) {
+		return Patch{Diff: text}
+	}
+
+	lines := strings.Split(text, "\n")
+	c := &Commit{}
+	if matches := mboxFromRegex.FindStringSubmatch(lines[0]); matches != nil {
+		c.Hash = matches[1]
+		lines = lines[1:]
+	}
+
+	// Headers run to the first blank line; indented lines continue the
+	// header above them
+	var headers []string
+	for len(lines) > 0 && lines[0] != "" {
+		if (lines[0][0] == ' ' || lines[0][0] == '\t') && len(headers) > 0 {
+			headers[len(headers)-1] += " " + strings.TrimSpace(lines[0])
+		} else {
+			headers = append(headers, lines[0])
+		}
+		lines = lines[1:]
+	}
+	if len(lines) > 0 {
+		lines = lines[1:]
+	}
+
+	var subject string
+	decoder := new(mime.WordDecoder)
+	for _, header := range headers {
+		name, value, ok := strings.Cut(header, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if decoded, err := decoder.DecodeHeader(value); err == nil {
+			value = decoded
+		}
+		switch strings.ToLower(name) {
+		case "from":
+			c.Author, c.AuthorEmail = value, ""
+			if address, err := mail.ParseAddress(value); err == nil {
+				c.Author, c.AuthorEmail = address.Name, address.Address
+			}
+			c.Headers = append(c.Headers, "Author: "+value)
+		case "date":
+			c.Date = value
+			c.Headers = append(c.Headers, "Date:   "+value)
+		case "subject":
+			subject = subjectPrefixRegex.ReplaceAllString(value, "")
+		}
+	}
+
+	// The message ends at the --- line above the diffstat, or at the diff
+	// when there is none
+	end := len(lines)
+	for i, line := range lines {
+		if line == "---" || strings.HasPrefix(line, "diff --git ") || strings.HasPrefix(line, "Index: ") {
+			end = i
+			break
+		}
+	}
+	c.Message = subject
+	if body := strings.Trim(strings.Join(lines[:end], "\n"), "\n"); body != "" {
+		c.Message += "\n\n" + body
+	}
+
+	return Patch{Commit: c, Diff: patchDiff(lines[end:])}
+}
+
+// patchDiff returns the diff of the lines following a patch's message,
+// without the diffstat before it or the signature git appends after it
+func patchDiff(lines []string) string {
+	start := len(lines)
+	for i, line := range lines {
+		if strings.HasPrefix(line, "diff ") || strings.HasPrefix(line, "Index: ") ||
+			(strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ ")) {
+			start = i
+			break
+		}
+	}
+	lines = lines[start:]
+
+	// git format-patch ends each patch with "-- " and its version
+	for i := len(lines) - 1; i >= 0; i-- {
+		if lines[i] == "-- " {
+			lines = lines[:i]
+			break
+		}
+		if strings.HasPrefix(lines[i], "+") || strings.HasPrefix(lines[i], "-") || strings.HasPrefix(lines[i], " ") {
+			break
+		}
+	}
+	diff := strings.TrimRight(strings.Join(lines, "\n"), "\n")
+	if diff == "" {
+		return ""
+	}
+	return diff + "\n"
+}
+
+// SplitCommits splits git show, git log -p or format-patch output into a
+// patch per commit, with the metadata and message above its diff parsed.
+// Text without commit headers yields no patches.
+func SplitCommits(text string) []Patch {
+	if isMailPatch(text) {
+		return ParsePatches(text)
+	}
+	var patches []Patch
+	for _, chunk := range Split(text) {
+		commits := Parse(chunk)
+		if len(commits) == 0 {
+			continue
+		}
+		patches = append(patches, Patch{Commit: commits[0], Diff: patchDiff(strings.Split(chunk, "\n"))})
+	}
+	return patches
+}
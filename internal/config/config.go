@@ -3,12 +3,89 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"time"
 )
 
 type Config struct {
 	UI          UIConfig          `toml:"ui"`
 	Git         GitConfig         `toml:"git"`
 	Keybindings KeybindingsConfig `toml:"keybindings"`
+	Filters     FiltersConfig     `toml:"filters"`
+	Gutter      GutterConfig      `toml:"gutter"`
+	Lint        LintConfig        `toml:"lint"`
+	Editor      EditorConfig      `toml:"editor"`
+	Forge       ForgeConfig       `toml:"forge"`
+
+	// Languages maps file globs to the lexer highlighting them, e.g.
+	// "*.tpl" = "html", for files whose names don't tell their language.
+	// It is read by ParseLanguages, as viper mangles glob keys.
+	Languages map[string]string `toml:"-"`
+
+	// ThemeOverrides replace colors of the theme by key, e.g.
+	// diffAddedBg = "#123123" under [theme.overrides]. It is read by
+	// ParseThemeOverrides, as viper lowercases keys.
+	ThemeOverrides map[string]string `toml:"-"`
+
+	// StateFile is where the TUI remembers its state between runs; empty
+	// disables saving (--fresh)
+	StateFile string `toml:"-"`
+
+	// SessionFile is where the TUI saves the review on quit, to resume it
+	// later (--save-session, or the --session file resumed)
+	SessionFile string `toml:"-"`
+
+	// Search limits diffs to changes touching a pattern (--search-change)
+	Search SearchConfig `toml:"-"`
+
+	// Output sends pipe mode output to a file (--output) and picks the
+	// colors for it
+	Output OutputConfig `toml:"-"`
+
+	// TokensFile holds an editor's semantic tokens, highlighting the diff
+	// instead of chroma (--tokens)
+	TokensFile string `toml:"-"`
+
+	// Language names the lexer highlighting every file, overriding
+	// Languages and detection from file names (--language)
+	Language string `toml:"-"`
+
+	// Encoding names the encoding of every file, overriding detection
+	// (--encoding)
+	Encoding string `toml:"-"`
+
+	// Limits truncate pipe mode output for callers with strict budgets
+	// (--max-files, --max-lines, --timeout)
+	Limits LimitsConfig `toml:"-"`
+
+	// Deterministic renders the same bytes whatever the terminal and
+	// environment, for snapshot tests (--deterministic)
+	Deterministic bool `toml:"-"`
+
+	// CI renders deterministic output for CI logs, wider and followed by a
+	// summary line (--ci, or CI=true in the environment)
+	CI bool `toml:"-"`
+}
+
+// LimitsConfig holds the resource limits given on the command line; zero
+// values are unlimited
+type LimitsConfig struct {
+	MaxFiles int           // Files rendered
+	MaxLines int           // Diff lines rendered across files
+	Timeout  time.Duration // Time for running git and rendering
+}
+
+// OutputConfig holds the output file given on the command line
+type OutputConfig struct {
+	Path  string // Empty to print to the terminal
+	Force bool   // Whether an existing file may be overwritten
+	Print bool   // Whether to render with the print theme instead of the UI theme (--print)
+	Plain bool   // Whether to print without colors (--ci with NO_COLOR set)
+}
+
+// SearchConfig holds the pickaxe search given on the command line
+type SearchConfig struct {
+	Pattern string // Empty when not searching
+	Regex   bool   // Whether Pattern is a regular expression (git -G) rather than a string (git -S)
 }
 
 type UIConfig struct {
@@ -18,12 +95,103 @@ type UIConfig struct {
 	LineNumbers  bool   `toml:"line_numbers"`
 	SyntaxHighlight bool `toml:"syntax_highlight"`
 	WrapLines    bool   `toml:"wrap_lines"`
+	SemanticDiff bool   `toml:"semantic_diff"`
+	Icons        bool   `toml:"icons"` // Nerd Font icons in file headers
+	HunkContext  string `toml:"hunk_context"` // git, scan or off
+	DimContext   bool   `toml:"dim_context"`  // Dim context lines so changes stand out
+	ShowInvisibles bool `toml:"show_invisibles"` // Mark tabs, spaces and trailing whitespace and show control, zero-width and bidi characters
+	PlainColumns bool   `toml:"plain_columns"` // Separate side-by-side columns with ASCII markers and no background padding
+	Accessibility bool  `toml:"accessibility"` // Mark changes with underlines and bars as well as color
+	Style        string `toml:"style"`        // background, foreground or minimal: how changed lines are colored
+	HunkStats    bool   `toml:"hunk_stats"`   // Count added, removed and modified lines in hunk headers
+	WhitespaceChanges string `toml:"whitespace_changes"` // show, badge or hide changes that only touch whitespace
+	IgnoredChanges string `toml:"ignored_changes"` // dim or hide changes matching filters.ignore_matching_lines
+	IntralineGranularity string `toml:"intraline_granularity"` // character, word or token units of changes within lines
+	FoldDuplicates bool `toml:"fold_duplicates"` // Show a change repeated across files once
+	CollapseGenerated bool `toml:"collapse_generated"` // Collapse generated files in the TUI's multi-file diffs
+	ColorMoved   bool   `toml:"color_moved"`  // Color blocks removed in one place and added in another as moved
+	FoldContext  int    `toml:"fold_context"` // Fold runs of more unchanged lines than this within hunks; 0 never folds
+	FileTree     bool    `toml:"file_tree"`       // Show the changed files as a tree left of the diff
+	FileTreeRatio float64 `toml:"file_tree_ratio"` // Share of the window the file tree takes
+	Hyperlinks   bool    `toml:"hyperlinks"`      // Link file headers and line numbers to GitHub or GitLab when viewing a commit range
+
+	// DiffBackgroundAlpha tints the background with the added and removed
+	// colors for themes that don't set diff backgrounds; 0 picks it from
+	// the terminal's background
+	DiffBackgroundAlpha float64 `toml:"diff_background_alpha"`
+
+	// BinaryDiffBytes is how many differing bytes of a changed binary file
+	// are shown as a hex dump; 0 shows only its sizes and modes
+	BinaryDiffBytes int `toml:"binary_diff_bytes"`
 }
 
 type GitConfig struct {
 	DefaultContext   int  `toml:"default_context"`
 	IgnoreWhitespace bool `toml:"ignore_whitespace"`
 	ShowStats        bool `toml:"show_stats"`
+
+	// IgnoreCRAtEOL passes --ignore-cr-at-eol to git diff, so lines that
+	// only changed between LF and CRLF endings aren't shown as changed
+	IgnoreCRAtEOL bool `toml:"ignore_cr_at_eol"`
+
+	// Untracked shows the untracked files that aren't ignored as added
+	// files with the unstaged changes
+	Untracked bool `toml:"untracked"`
+
+	// Backend is "cli" to run git or "native" to read the repository
+	// directly; empty uses git when it is installed
+	Backend string `toml:"backend"`
+
+	// ExtraArgs are options passed to git diff, e.g. ["--find-copies-harder"]
+	ExtraArgs []string `toml:"extra_args"`
+	// Passthrough holds the command line arguments after "--", appended to
+	// git diff as they are
+	Passthrough []string `toml:"-"`
+}
+
+// FiltersConfig holds glob patterns selecting which files of a multi-file
+// diff are shown, and patterns of lines whose changes are ignored
+type FiltersConfig struct {
+	Include []string `toml:"include"`
+	Exclude []string `toml:"exclude"`
+
+	// IgnoreMatchingLines are regular expressions; changes whose removed
+	// and added lines all match one are dimmed or hidden, like diff -I
+	IgnoreMatchingLines []string `toml:"ignore_matching_lines"`
+
+	// Generated are globs of generated files, such as lockfiles, which are
+	// badged and collapsed as are those marked linguist-generated
+	Generated []string `toml:"generated"`
+}
+
+// GutterConfig controls the line-number gutter layout
+type GutterConfig struct {
+	Mode      string `toml:"mode"`      // both, old, new or none
+	Width     int    `toml:"width"`     // Digits per line number column
+	Separator string `toml:"separator"` // Between the columns and before the content
+}
+
+// LintConfig controls commit message linting when viewing commits
+type LintConfig struct {
+	Commits        bool `toml:"commits"`         // Lint messages of commits in git log/show output
+	SubjectLength  int  `toml:"subject_length"`  // Longest acceptable subject line
+	BodyWidth      int  `toml:"body_width"`      // Column body lines should be wrapped at
+	RequireSignoff bool `toml:"require_signoff"` // Require a Signed-off-by trailer (DCO)
+}
+
+// EditorConfig controls how the TUI opens files for editing
+type EditorConfig struct {
+	// Command opens a file, with {file} and {line} replaced, e.g.
+	// "code -g {file}:{line}"; empty runs $VISUAL or $EDITOR
+	Command string `toml:"command"`
+}
+
+// ForgeConfig controls how pull requests are fetched
+type ForgeConfig struct {
+	// TokenHosts are the self-hosted GitHub and GitLab hosts that may be
+	// sent GITHUB_TOKEN or GITLAB_TOKEN, as only github.com and gitlab.com
+	// are by default
+	TokenHosts []string `toml:"token_hosts"`
 }
 
 type KeybindingsConfig struct {
@@ -51,12 +219,41 @@ func NewConfig() *Config {
 			LineNumbers:     true,
 			SyntaxHighlight: true,
 			WrapLines:       false,
+			HunkContext:     "scan",
+			WhitespaceChanges: "show",
+			IgnoredChanges:  "dim",
+			IntralineGranularity: "character",
+			Style:           "background",
+			FoldDuplicates:  true,
+			CollapseGenerated: true,
+			ColorMoved:      false,
+			FoldContext:     20,
+			FileTreeRatio:   0.25,
+			Hyperlinks:      true,
+			DiffBackgroundAlpha: 0,
+			BinaryDiffBytes: 64,
 		},
 		Git: GitConfig{
 			DefaultContext:   3,
 			IgnoreWhitespace: false,
 			ShowStats:        true,
 		},
+		Filters: FiltersConfig{
+			Generated: []string{
+				"package-lock.json", "yarn.lock", "pnpm-lock.yaml", "Cargo.lock", "go.sum",
+				"poetry.lock", "Pipfile.lock", "Gemfile.lock", "composer.lock",
+				"*.min.js", "*.min.css", "*.pb.go",
+			},
+		},
+		Gutter: GutterConfig{
+			Mode:      "both",
+			Width:     6,
+			Separator: " ",
+		},
+		Lint: LintConfig{
+			SubjectLength: 72,
+			BodyWidth:     72,
+		},
 		Keybindings: KeybindingsConfig{
 			Quit:          "q",
 			Help:          "?",
@@ -0,0 +1,386 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// DefaultFile is the config file written by `differential config init`,
+// holding the default of every setting with a note on what it does
+const DefaultFile = `# differential configuration
+#
+# Change a setting with e.g. ` + "`differential config set ui.theme nord`" + `
+# and list them all with ` + "`differential config list`" + `.
+
+[ui]
+# Run ` + "`differential --list-themes`" + ` for the available themes
+theme = "dracula"
+# "unified" or "side-by-side"
+default_view = "unified"
+tab_width = 4
+line_numbers = true
+syntax_highlight = true
+wrap_lines = false
+# Nerd Font icons in file headers
+icons = false
+# Dim unchanged lines so changes stand out (--dim-context)
+dim_context = false
+# Mark tabs, spaces and trailing whitespace, and show control, zero-width
+# and bidi characters as placeholders (--show-invisibles, i in the viewer)
+show_invisibles = false
+# Separate side-by-side columns with ASCII markers as in diff -y and pad
+# them without a background, for copying as plain text (--plain-columns)
+plain_columns = false
+# Mark changes with more than color: underline removed lines and put a bar
+# before added ones in side-by-side view (--accessibility). The deuteranopia
+# and protanopia themes avoid telling changes apart by red and green.
+accessibility = false
+# How changed lines stand out: "background" fills them with the diff
+# backgrounds, "foreground" only colors their text, and "minimal" only
+# colors their gutter markers, keeping their syntax colors (--style)
+style = "background"
+# Count added, removed and modified lines in hunk headers (--hunk-stats)
+hunk_stats = false
+# Changes that only touch whitespace: "show", "badge" or "hide"
+# (--whitespace-changes)
+whitespace_changes = "show"
+# Changes whose lines all match filters.ignore_matching_lines: "dim" or
+# "hide" (--ignored-changes)
+ignored_changes = "dim"
+# Mark changes within lines by "character", "word" or "token", the syntax
+# tokens of the file's language (--intraline)
+intraline_granularity = "character"
+# Show a change repeated across files once
+fold_duplicates = true
+# Collapse generated files in the TUI's multi-file diffs, see filters.generated
+collapse_generated = true
+# Color blocks removed in one place and added in another in the theme's
+# diffMovedFrom and diffMovedTo colors (--color-moved)
+color_moved = false
+# Fold longer runs of unchanged lines within hunks; 0 never folds
+fold_context = 20
+# Show the changed files as a tree left of the diff in the TUI (t)
+file_tree = false
+# Share of the window the file tree takes; drag its divider to resize it
+file_tree_ratio = 0.25
+# Link file headers and line numbers to the file on GitHub or GitLab, when
+# the origin remote is hosted there and a commit range is viewed
+hyperlinks = true
+# Function shown in hunk headers: "scan", "git" or "off"
+hunk_context = "scan"
+# Diff JSON, YAML and TOML files by structure instead of lines (--semantic)
+semantic_diff = false
+# Tint for themes without diffAddedBg/diffRemovedBg, e.g. 0.15; 0 picks it
+# from the terminal's background, with more on light ones
+diff_background_alpha = 0
+# Differing bytes of changed binary files shown as a hex dump; 0 shows only
+# their sizes and modes
+binary_diff_bytes = 64
+
+[git]
+default_context = 3
+ignore_whitespace = false
+show_stats = true
+# Hide changes that only convert LF to CRLF or back (--ignore-cr-at-eol)
+ignore_cr_at_eol = false
+# Show untracked files as added with the unstaged changes (--untracked)
+untracked = false
+# "cli", "native", or empty to use git when it is installed
+backend = ""
+# Options always passed to git diff
+# extra_args = ["--find-renames=40%"]
+
+[keybindings]
+quit = "q"
+help = "?"
+toggle_view = "tab"
+next_hunk = "}"
+prev_hunk = "{"
+scroll_up = "k"
+scroll_down = "j"
+page_up = "ctrl+b"
+page_down = "ctrl+f"
+search = "/"
+stage_hunk = "s"
+refresh_diff = "r"
+toggle_numbers = "n"
+
+[filters]
+# Only show files matching these globs (--include)
+# include = ["internal/**"]
+# Skip files matching these globs (--exclude)
+# exclude = ["*.lock", "vendor/**", "*.pb.go"]
+# Dim or hide changes whose removed and added lines all match one of these
+# regular expressions, like diff -I (--ignore-matching-lines)
+# ignore_matching_lines = ['^\s*"version": ', 'Generated at ']
+# Globs of generated files, badged and collapsed along with those marked
+# linguist-generated in .gitattributes, minified JavaScript and CSS, and
+# files starting with a "Code generated ... DO NOT EDIT" comment
+generated = [
+  "package-lock.json", "yarn.lock", "pnpm-lock.yaml", "Cargo.lock", "go.sum",
+  "poetry.lock", "Pipfile.lock", "Gemfile.lock", "composer.lock",
+  "*.min.js", "*.min.css", "*.pb.go",
+]
+
+[gutter]
+# "old", "new", "both" or "none"
+mode = "both"
+# Digits per line number column
+width = 6
+# e.g. " │ " for a blame-style gutter
+separator = " "
+
+[lint]
+# Lint messages of commits in git log/show output (--lint-commits)
+commits = false
+subject_length = 72
+body_width = 72
+# Require a Signed-off-by trailer matching the author (DCO)
+require_signoff = false
+
+[editor]
+# e.g. "code -g {file}:{line}"; empty runs $VISUAL or $EDITOR with +{line}
+command = ""
+
+[forge]
+# Self-hosted GitHub and GitLab hosts that differential pr may send
+# GITHUB_TOKEN or GITLAB_TOKEN to; github.com and gitlab.com always get them
+# token_hosts = ["github.example.com"]
+
+[languages]
+# Highlight files matching a glob as a language, for names that don't tell
+# it (--language highlights every file as one language)
+# "*.tpl" = "html"
+# "Jenkinsfile" = "groovy"
+
+[theme.overrides]
+# Replace colors of the theme, by the keys of theme files
+# diffAddedBg = "#123123"
+`
+
+// Keys lists the settings of the config file as section.name, in file order
+func Keys() []string {
+	var keys []string
+	walkSettings(reflect.TypeOf(Config{}), func(key string, _ []int) {
+		keys = append(keys, key)
+	})
+	return keys
+}
+
+// walkSettings calls fn with the key and field index of every setting of t
+func walkSettings(t reflect.Type, fn func(key string, index []int)) {
+	for i := 0; i < t.NumField(); i++ {
+		section := t.Field(i)
+		name := section.Tag.Get("toml")
+		if name == "" || name == "-" || section.Type.Kind() != reflect.Struct {
+			continue
+		}
+		for j := 0; j < section.Type.NumField(); j++ {
+			field := section.Type.Field(j)
+			if key := field.Tag.Get("toml"); key != "" && key != "-" {
+				fn(name+"."+key, []int{i, j})
+			}
+		}
+	}
+}
+
+// setting returns the field holding the setting key of c
+func (c *Config) setting(key string) (reflect.Value, error) {
+	var found []int
+	walkSettings(reflect.TypeOf(*c), func(k string, index []int) {
+		if k == key {
+			found = index
+		}
+	})
+	if found == nil {
+		return reflect.Value{}, fmt.Errorf("unknown setting %q", key)
+	}
+	return reflect.ValueOf(c).Elem().FieldByIndex(found), nil
+}
+
+// Get returns the value of the setting key, formatted as in the config file
+func (c *Config) Get(key string) (string, error) {
+	field, err := c.setting(key)
+	if err != nil {
+		return "", err
+	}
+	return formatValue(field.Interface())
+}
+
+// formatValue formats v as a TOML value
+func formatValue(v any) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return quote(v), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case []string:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = quote(item)
+		}
+		return "[" + strings.Join(items, ", ") + "]", nil
+	}
+	return "", fmt.Errorf("unsupported type %T", v)
+}
+
+// quote returns s as a TOML basic string
+func quote(s string) string {
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			sb.WriteRune('\\')
+			sb.WriteRune(r)
+		case r == '\t':
+			sb.WriteString(`\t`)
+		case r == '\n':
+			sb.WriteString(`\n`)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&sb, `\u%04X`, r)
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
+
+// parseValue converts s to the type of field. Lists are separated by
+// commas, and an empty s is an empty list.
+func parseValue(field reflect.Value, s string) (any, error) {
+	switch field.Kind() {
+	case reflect.String:
+		return s, nil
+	case reflect.Bool:
+		return strconv.ParseBool(s)
+	case reflect.Int:
+		return strconv.Atoi(s)
+	case reflect.Float64:
+		return strconv.ParseFloat(s, 64)
+	case reflect.Slice:
+		list := []string{}
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		return list, nil
+	}
+	return nil, fmt.Errorf("unsupported type %s", field.Type())
+}
+
+var (
+	sectionLine = regexp.MustCompile(`^\s*\[\s*([A-Za-z0-9_-]+)\s*\]`)
+	keyLine     = regexp.MustCompile(`^\s*([A-Za-z0-9_-]+)\s*=`)
+)
+
+// SetInFile sets key to value in the config file at path, creating it if
+// needed. The line holding the setting is replaced, leaving the rest of the
+// file, comments included, as it was.
+func SetInFile(path, key, value string) error {
+	field, err := NewConfig().setting(key)
+	if err != nil {
+		return err
+	}
+	parsed, err := parseValue(field, value)
+	if err != nil {
+		return fmt.Errorf("invalid value for %s: %w", key, err)
+	}
+	formatted, err := formatValue(parsed)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	section, name, _ := strings.Cut(key, ".")
+	updated := setLine(string(data), section, name, name+" = "+formatted)
+
+	// Refuse to leave a file that no longer loads
+	if err := toml.Unmarshal([]byte(updated), NewConfig()); err != nil {
+		return fmt.Errorf("failed to update %s: %w", path, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(updated), 0o644)
+}
+
+// setLine replaces the line setting name in section of a TOML document
+// with line, adding it at the end of the section, or adding the section,
+// when there is none
+func setLine(doc, section, name, line string) string {
+	lines := strings.Split(strings.TrimRight(doc, "\n"), "\n")
+	if doc == "" {
+		lines = nil
+	}
+
+	current, end := "", -1
+	for i, l := range lines {
+		if m := sectionLine.FindStringSubmatch(l); m != nil {
+			current = m[1]
+			continue
+		}
+		if current != section {
+			continue
+		}
+		if m := keyLine.FindStringSubmatch(l); m != nil && m[1] == name {
+			lines[i] = line
+			return strings.Join(lines, "\n") + "\n"
+		}
+		if strings.TrimSpace(l) != "" {
+			end = i
+		}
+	}
+
+	if end < 0 {
+		for i, l := range lines {
+			if m := sectionLine.FindStringSubmatch(l); m != nil && m[1] == section {
+				end = i
+			}
+		}
+	}
+	if end < 0 {
+		var buf bytes.Buffer
+		for _, l := range lines {
+			buf.WriteString(l + "\n")
+		}
+		if len(lines) > 0 {
+			buf.WriteString("\n")
+		}
+		buf.WriteString("[" + section + "]\n" + line + "\n")
+		return buf.String()
+	}
+	lines = append(lines[:end+1], append([]string{line}, lines[end+1:]...)...)
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// WriteDefault writes DefaultFile to path, creating its directory. An
+// existing file is only replaced when force is set.
+func WriteDefault(path string, force bool) error {
+	if !force {
+		if _, err := os.Stat(path); err == nil {
+			return fmt.Errorf("%s already exists; pass --force to overwrite it", path)
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(DefaultFile), 0o644)
+}
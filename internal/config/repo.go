@@ -0,0 +1,109 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// RepoFile is the config file a repository can hold at its root to share
+// how its diffs are presented. Its settings override the user's.
+const RepoFile = ".differential.toml"
+
+// repoSections can be set as a whole by a repository's config file
+var repoSections = map[string]bool{
+	"ui":      true,
+	"filters": true,
+	"gutter":  true,
+	"lint":    true,
+}
+
+// repoGitSettings are the settings of the git section a repository can set.
+// Options passed to git and the editor command are left to the user, as
+// they run programs.
+var repoGitSettings = map[string]bool{
+	"default_context":   true,
+	"ignore_whitespace": true,
+	"ignore_cr_at_eol":  true,
+}
+
+// RepoSettings parses a repository's config file, keeping the settings
+// repositories may override. It returns them by section, and the names of
+// the settings left out, sorted.
+func RepoSettings(data []byte) (map[string]any, []string, error) {
+	var doc map[string]any
+	if err := toml.Unmarshal(data, &doc); err != nil {
+		return nil, nil, err
+	}
+
+	known := make(map[string]bool)
+	for _, key := range Keys() {
+		known[key] = true
+	}
+
+	settings := make(map[string]any)
+	var ignored []string
+	for section, value := range doc {
+		table, ok := value.(map[string]any)
+		if !ok {
+			ignored = append(ignored, section)
+			continue
+		}
+		// Languages are keyed by glob and theme overrides by case-sensitive
+		// keys, read by ParseLanguages and ParseThemeOverrides instead
+		if section == "languages" || section == "theme" {
+			continue
+		}
+		kept := make(map[string]any)
+		for name, v := range table {
+			key := section + "." + name
+			if known[key] && (repoSections[section] || section == "git" && repoGitSettings[name]) {
+				kept[name] = v
+			} else {
+				ignored = append(ignored, key)
+			}
+		}
+		if len(kept) > 0 {
+			settings[section] = kept
+		}
+	}
+
+	// Make sure the kept settings load
+	merged, err := toml.Marshal(settings)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := toml.Unmarshal(merged, NewConfig()); err != nil {
+		return nil, nil, fmt.Errorf("invalid setting: %w", err)
+	}
+
+	sort.Strings(ignored)
+	return settings, ignored, nil
+}
+
+// ParseLanguages returns the languages table of a config file, mapping
+// file globs to lexers
+func ParseLanguages(data []byte) (map[string]string, error) {
+	var doc struct {
+		Languages map[string]string `toml:"languages"`
+	}
+	if err := toml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid languages: %w", err)
+	}
+	return doc.Languages, nil
+}
+
+// ParseThemeOverrides returns the theme overrides of a config file, mapping
+// theme keys to colors
+func ParseThemeOverrides(data []byte) (map[string]string, error) {
+	var doc struct {
+		Theme struct {
+			Overrides map[string]string `toml:"overrides"`
+		} `toml:"theme"`
+	}
+	if err := toml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid theme overrides: %w", err)
+	}
+	return doc.Theme.Overrides, nil
+}
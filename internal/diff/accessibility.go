@@ -0,0 +1,35 @@
+package diff
+
+import "strings"
+
+// Underline on and off, for the content of removed lines with
+// Accessibility, which keeps the colors around it
+const (
+	underlineOn  = "\x1b[4m"
+	underlineOff = "\x1b[24m"
+)
+
+// accessibleChangedGlyph replaces the bar of changed lines in the full-file
+// view's gutter with Accessibility, so they aren't told from added lines by
+// color alone
+const accessibleChangedGlyph = "╎"
+
+// accessibleContent returns the rendered content of a line of kind
+// underlined when it is removed and Accessibility is set. The underline is
+// turned on again after each reset in content, e.g. after intra-line
+// highlights.
+func (r *Renderer) accessibleContent(content string, kind LineType) string {
+	if !r.opts.Accessibility || kind != LineRemoved {
+		return content
+	}
+	content = strings.NewReplacer("\x1b[0m", "\x1b[0m"+underlineOn, "\x1b[m", "\x1b[m"+underlineOn).Replace(content)
+	return underlineOn + content + underlineOff
+}
+
+// changeMarkGlyph returns the full-file gutter character of mark
+func (r *Renderer) changeMarkGlyph(mark changeMark) string {
+	if r.opts.Accessibility && mark == markChanged {
+		return accessibleChangedGlyph
+	}
+	return changeMarkGlyphs[mark]
+}
@@ -0,0 +1,138 @@
+package diff
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// ansiRegex matches ANSI escape sequences (OSC sequences such as
+// hyperlinks, CSI sequences and two-byte escapes)
+var ansiRegex = regexp.MustCompile(`\x1b(?:\][^\x07\x1b]*(?:\x07|\x1b\\)|[@-Z\\-_]|\[[0-9?]*(?:;[0-9?]*)*[@-~])`)
+
+// ANSIString is a string containing ANSI escape sequences, scanned once so
+// it can be measured, stripped and walked without matching it again
+type ANSIString struct {
+	str     string
+	escapes [][]int // Byte ranges of the escape sequences, in order
+}
+
+// ParseANSI scans str for ANSI escape sequences
+func ParseANSI(str string) ANSIString {
+	return ANSIString{str: str, escapes: ansiRegex.FindAllStringIndex(str, -1)}
+}
+
+// String returns the string including its escape sequences
+func (s ANSIString) String() string {
+	return s.str
+}
+
+// Plain returns the string with all escape sequences removed
+func (s ANSIString) Plain() string {
+	if len(s.escapes) == 0 {
+		return s.str
+	}
+	var sb strings.Builder
+	sb.Grow(len(s.str))
+	last := 0
+	for _, esc := range s.escapes {
+		sb.WriteString(s.str[last:esc[0]])
+		last = esc[1]
+	}
+	sb.WriteString(s.str[last:])
+	return sb.String()
+}
+
+// Width returns the number of visible runes
+func (s ANSIString) Width() int {
+	width := utf8.RuneCountInString(s.str)
+	for _, esc := range s.escapes {
+		width -= utf8.RuneCountInString(s.str[esc[0]:esc[1]])
+	}
+	return width
+}
+
+// Truncate cuts the string to width visible runes. Escape sequences after
+// the cut are kept so colors are still reset.
+func (s ANSIString) Truncate(width int) string {
+	if width <= 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	it := s.Iter()
+	for it.Next() {
+		tok := it.Token()
+		if !tok.Escape && tok.Pos >= width {
+			continue
+		}
+		sb.WriteString(tok.Text)
+	}
+	return sb.String()
+}
+
+// StripDiffColors removes the colors from a diff made with
+// git diff --color=always, whose escape sequences would hide the diff
+// markers from the parser. A diff counts as colored when a line starts with
+// an escape sequence; otherwise it is returned unchanged, as escape
+// sequences inside lines may be part of the files' contents.
+func StripDiffColors(text string) (string, bool) {
+	colored := strings.HasPrefix(text, "\x1b[") || strings.Contains(text, "\n\x1b[")
+	if !colored {
+		return text, false
+	}
+	return ParseANSI(text).Plain(), true
+}
+
+// Iter returns an iterator over the escape sequences and visible runes
+func (s ANSIString) Iter() *ANSIIterator {
+	return &ANSIIterator{s: s, pos: -1}
+}
+
+// ANSIToken is either a complete escape sequence or a single visible rune
+type ANSIToken struct {
+	Text   string
+	Escape bool // Whether Text is an escape sequence
+	Pos    int  // Visible index of the rune, or of the next rune for escapes
+}
+
+// ANSIIterator walks an ANSIString token by token:
+//
+//	it := s.Iter()
+//	for it.Next() {
+//		tok := it.Token()
+//	}
+type ANSIIterator struct {
+	s      ANSIString
+	offset int // Byte offset of the next token
+	escape int // Index of the next escape sequence
+	pos    int // Visible index of the current rune
+	tok    ANSIToken
+}
+
+// Next advances to the next token, returning false at the end of the string
+func (it *ANSIIterator) Next() bool {
+	str := it.s.str
+	if it.offset >= len(str) {
+		return false
+	}
+
+	if it.escape < len(it.s.escapes) && it.s.escapes[it.escape][0] == it.offset {
+		end := it.s.escapes[it.escape][1]
+		it.tok = ANSIToken{Text: str[it.offset:end], Escape: true, Pos: it.pos + 1}
+		it.offset = end
+		it.escape++
+		return true
+	}
+
+	_, size := utf8.DecodeRuneInString(str[it.offset:])
+	it.pos++
+	it.tok = ANSIToken{Text: str[it.offset : it.offset+size], Pos: it.pos}
+	it.offset += size
+	return true
+}
+
+// Token returns the current token
+func (it *ANSIIterator) Token() ANSIToken {
+	return it.tok
+}
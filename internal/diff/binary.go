@@ -0,0 +1,305 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/avgvstvs96/differential/internal/preview"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maxImagePreviewWidth caps the width of each image preview in columns
+const maxImagePreviewWidth = 40
+
+// hexRowBytes is how many bytes each row of a binary hex dump shows
+const hexRowBytes = 16
+
+// binaryReadBytes is how much of each version of a binary file is read
+// through RenderOptions.LoadBlobPrefix to find the bytes the dump shows
+const binaryReadBytes = 1 << 20
+
+// binaryVersion is one version of a binary file
+type binaryVersion struct {
+	data        []byte // Its first bytes, nil for the missing side of an added or deleted file
+	size        int64
+	unavailable bool // The diff names it, but it couldn't be read
+}
+
+// renderBinary renders a binary file diff, previewing images when possible
+// and otherwise describing both versions with a hex dump of where they
+// differ. Without a loader it says that the files differ.
+func renderBinary(result *DiffResult, opts RenderOptions) string {
+	summary := fmt.Sprintf("Binary files %s and %s differ\n", result.OldFile, result.NewFile)
+	if opts.LoadBlob == nil {
+		return summary
+	}
+	if preview.IsImage(result.OldFile) || preview.IsImage(result.NewFile) {
+		if rendered, ok := renderImage(result, opts); ok {
+			return rendered
+		}
+	}
+
+	before := loadBinaryVersion(opts, result.OldFile, result.OldIndex)
+	after := loadBinaryVersion(opts, result.NewFile, result.NewIndex)
+	if before.data == nil && after.data == nil && !before.unavailable && !after.unavailable {
+		return summary
+	}
+	return renderBinaryChange(result, before, after, opts)
+}
+
+// loadBinaryVersion loads the start of one version of a binary file, with
+// LoadBlobPrefix when it is set
+func loadBinaryVersion(opts RenderOptions, path, id string) binaryVersion {
+	if opts.LoadBlobPrefix == nil {
+		data, err := loadVersion(opts.LoadBlob, path, id)
+		return binaryVersion{data: data, size: int64(len(data)), unavailable: err != nil}
+	}
+	if path == "" || path == "/dev/null" || (id != "" && strings.Trim(id, "0") == "") {
+		return binaryVersion{}
+	}
+	data, size, err := opts.LoadBlobPrefix(path, id, binaryReadBytes)
+	if err != nil {
+		return binaryVersion{unavailable: true}
+	}
+	if data == nil {
+		data = []byte{}
+	}
+	return binaryVersion{data: data, size: size}
+}
+
+// renderImage renders before and after previews of an image, reporting
+// false when either version can't be loaded or decoded
+func renderImage(result *DiffResult, opts RenderOptions) (string, bool) {
+	before, err := loadImage(opts, result.OldFile, result.OldIndex)
+	if err != nil {
+		return "", false
+	}
+	after, err := loadImage(opts, result.NewFile, result.NewIndex)
+	if err != nil || (before == nil && after == nil) {
+		return "", false
+	}
+
+	theme := opts.theme()
+	titleStyle := lipgloss.NewStyle().Foreground(theme.Text).Bold(true)
+	labelStyle := lipgloss.NewStyle().Foreground(theme.TextMuted)
+
+	var sb strings.Builder
+	sb.WriteString(titleStyle.Render("Image changed: " + result.NewFile))
+	sb.WriteString("\n")
+	sb.WriteString(labelStyle.Render("  before: ") + before.Describe() + "\n")
+	sb.WriteString(labelStyle.Render("  after:  ") + after.Describe())
+	sb.WriteString(labelStyle.Render(fmt.Sprintf(" (%s)", preview.SizeDelta(before, after))))
+	sb.WriteString("\n\n")
+
+	previewWidth := (opts.Width - 3) / 2
+	if previewWidth > maxImagePreviewWidth || previewWidth <= 0 {
+		previewWidth = maxImagePreviewWidth
+	}
+
+	protocol := opts.ImageProtocol
+	if protocol == preview.ProtocolAuto {
+		protocol = preview.DetectProtocol()
+	}
+
+	// Graphics protocols draw one image after the other
+	if protocol != preview.ProtocolHalfBlock {
+		for _, side := range []struct {
+			label string
+			info  *preview.ImageInfo
+		}{{"before", before}, {"after", after}} {
+			if side.info == nil {
+				continue
+			}
+			sb.WriteString(labelStyle.Render(side.label))
+			sb.WriteString("\n")
+			sb.WriteString(preview.Render(side.info.Image, previewWidth, protocol))
+		}
+		return sb.String(), true
+	}
+
+	// Half-block previews are laid out next to each other
+	var left, right []string
+	if before != nil {
+		left = preview.RenderHalfBlock(before.Image, previewWidth)
+	}
+	if after != nil {
+		right = preview.RenderHalfBlock(after.Image, previewWidth)
+	}
+
+	leftWidth := 0
+	for _, line := range left {
+		if w := VisibleLength(line); w > leftWidth {
+			leftWidth = w
+		}
+	}
+	if leftWidth < len("before") {
+		leftWidth = len("before")
+	}
+
+	sb.WriteString(labelStyle.Render("before" + strings.Repeat(" ", leftWidth-len("before")) + "   after"))
+	sb.WriteString("\n")
+	for i := 0; i < len(left) || i < len(right); i++ {
+		line := ""
+		if i < len(left) {
+			line = left[i]
+		}
+		sb.WriteString(line)
+		sb.WriteString(strings.Repeat(" ", leftWidth-VisibleLength(line)+3))
+		if i < len(right) {
+			sb.WriteString(right[i])
+		}
+		sb.WriteString("\n")
+	}
+
+	return sb.String(), true
+}
+
+// loadImage loads and decodes one side of an image diff. A missing side
+// (added or deleted file) yields nil without an error.
+func loadImage(opts RenderOptions, path, id string) (*preview.ImageInfo, error) {
+	data, err := loadVersion(opts.LoadBlob, path, id)
+	if err != nil {
+		return nil, err
+	}
+	return preview.DecodeImage(data)
+}
+
+// loadVersion loads one version of a file with load. A missing version
+// (of an added or deleted file) yields nil without an error.
+func loadVersion(load func(path, id string) ([]byte, error), path, id string) ([]byte, error) {
+	if path == "" || path == "/dev/null" || (id != "" && strings.Trim(id, "0") == "") {
+		return nil, nil
+	}
+	data, err := load(path, id)
+	if err != nil {
+		return nil, err
+	}
+	// An empty file is still a side, unlike a missing one
+	if data == nil {
+		data = []byte{}
+	}
+	return data, nil
+}
+
+// renderBinaryChange renders the sizes and modes of both versions of a
+// binary file, then the hex dump rows holding its first opts.BinaryBytes
+// differing bytes. The dump is left out when either version couldn't be
+// read.
+func renderBinaryChange(result *DiffResult, before, after binaryVersion, opts RenderOptions) string {
+	theme := opts.theme()
+	titleStyle := lipgloss.NewStyle().Foreground(theme.Text).Bold(true)
+	labelStyle := lipgloss.NewStyle().Foreground(theme.TextMuted)
+
+	title := "Binary file changed: "
+	switch {
+	case before.data == nil && !before.unavailable:
+		title = "Binary file added: "
+	case after.data == nil && !after.unavailable:
+		title = "Binary file deleted: "
+	}
+	describe := func(version binaryVersion, mode string) string {
+		switch {
+		case version.unavailable:
+			return labelStyle.Render("unavailable")
+		case version.data == nil:
+			return labelStyle.Render("none")
+		case mode != "":
+			return preview.FormatSize(int(version.size)) + ", mode " + mode
+		}
+		return preview.FormatSize(int(version.size))
+	}
+
+	var sb strings.Builder
+	sb.WriteString(titleStyle.Render(title + result.DisplayName()))
+	sb.WriteString("\n")
+	sb.WriteString(labelStyle.Render("  before: ") + describe(before, result.OldMode) + "\n")
+	sb.WriteString(labelStyle.Render("  after:  ") + describe(after, result.NewMode))
+	if before.data != nil && after.data != nil {
+		delta := int(after.size - before.size)
+		sign := "+"
+		if delta < 0 {
+			sign, delta = "-", -delta
+		}
+		sb.WriteString(labelStyle.Render(fmt.Sprintf(" (%s%s)", sign, preview.FormatSize(delta))))
+	}
+	sb.WriteString("\n")
+
+	if before.unavailable || after.unavailable {
+		return sb.String()
+	}
+	rows := hexDiffRows(before.data, after.data, opts.BinaryBytes)
+	if len(rows) == 0 {
+		return sb.String()
+	}
+	sb.WriteString("\n")
+	previous := -1
+	for _, row := range rows {
+		// Mark the rows without differences left out
+		if previous >= 0 && row != previous+hexRowBytes {
+			sb.WriteString(labelStyle.Render("  …") + "\n")
+		}
+		previous = row
+		if row < len(before.data) {
+			sb.WriteString(renderHexRow(LineRemoved, row, before.data, after.data, opts))
+		}
+		if row < len(after.data) {
+			sb.WriteString(renderHexRow(LineAdded, row, after.data, before.data, opts))
+		}
+	}
+	return sb.String()
+}
+
+// hexDiffRows returns the offsets of the hex dump rows where before and
+// after differ, from the start until the rows hold limit differing bytes
+func hexDiffRows(before, after []byte, limit int) []int {
+	var rows []int
+	size := max(len(before), len(after))
+	shown := 0
+	for row := 0; row < size && shown < limit; row += hexRowBytes {
+		differing := 0
+		for i := row; i < row+hexRowBytes && i < size; i++ {
+			if i >= len(before) || i >= len(after) || before[i] != after[i] {
+				differing++
+			}
+		}
+		if differing > 0 {
+			rows = append(rows, row)
+			shown += differing
+		}
+	}
+	return rows
+}
+
+// renderHexRow renders the hex dump row of data at offset, marked as kind,
+// with the bytes that differ from other emphasized
+func renderHexRow(kind LineType, offset int, data, other []byte, opts RenderOptions) string {
+	theme := opts.theme()
+	mutedStyle := lipgloss.NewStyle().Foreground(theme.TextMuted)
+	sign, changedStyle := "-", lipgloss.NewStyle().Foreground(theme.DiffRemoved).Bold(true)
+	if kind == LineAdded {
+		sign, changedStyle = "+", lipgloss.NewStyle().Foreground(theme.DiffAdded).Bold(true)
+	}
+
+	var hex, text strings.Builder
+	for i := offset; i < offset+hexRowBytes; i++ {
+		if i == offset+hexRowBytes/2 {
+			hex.WriteString(" ")
+		}
+		if i >= len(data) {
+			hex.WriteString("   ")
+			continue
+		}
+		b := data[i]
+		char := "."
+		if b >= 0x20 && b < 0x7f {
+			char = string(rune(b))
+		}
+		style := mutedStyle
+		if i >= len(other) || other[i] != b {
+			style = changedStyle
+		}
+		hex.WriteString(" " + style.Render(fmt.Sprintf("%02x", b)))
+		text.WriteString(style.Render(char))
+	}
+	return changedStyle.Render(sign) + mutedStyle.Render(fmt.Sprintf(" %08x ", offset)) + hex.String() + "  " + mutedStyle.Render("|") + text.String() + mutedStyle.Render("|") + "\n"
+}
@@ -0,0 +1,165 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// ChangeKind classifies how a removed line differs from the added line
+// replacing it
+type ChangeKind string
+
+const (
+	ChangeSubstantive ChangeKind = "substantive" // The text changed
+	ChangeWhitespace  ChangeKind = "whitespace"  // Only whitespace changed, e.g. reindenting
+	ChangeCase        ChangeKind = "case"        // Only letter case changed, and maybe whitespace
+)
+
+// ClassifyChange tells how new differs from old
+func ClassifyChange(old, new string) ChangeKind {
+	old, new = stripSpace(old), stripSpace(new)
+	switch {
+	case old == new:
+		return ChangeWhitespace
+	case strings.EqualFold(old, new):
+		return ChangeCase
+	}
+	return ChangeSubstantive
+}
+
+// stripSpace removes all whitespace from s
+func stripSpace(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// Change classifies the change of a pair of a removed and an added line. It
+// is empty for unpaired and context lines.
+func (p LinePair) Change() ChangeKind {
+	if p.Left == nil || p.Right == nil || p.Left.Kind != LineRemoved {
+		return ""
+	}
+	return ClassifyChange(p.Left.Content, p.Right.Content)
+}
+
+// WhitespaceMode selects how whitespace-only changes are shown
+type WhitespaceMode int
+
+const (
+	WhitespaceShow  WhitespaceMode = iota // Like any other change
+	WhitespaceBadge                       // With a badge on the added lines
+	WhitespaceHide                        // As unchanged lines
+)
+
+var whitespaceModeNames = []string{"show", "badge", "hide"}
+
+// ParseWhitespaceMode parses a whitespace mode name as used in the config
+// file
+func ParseWhitespaceMode(name string) (WhitespaceMode, error) {
+	for i, n := range whitespaceModeNames {
+		if strings.EqualFold(name, n) {
+			return WhitespaceMode(i), nil
+		}
+	}
+	return WhitespaceShow, fmt.Errorf("unknown whitespace mode %q (expected %s)", name, strings.Join(whitespaceModeNames, ", "))
+}
+
+// String returns the config name of the mode
+func (m WhitespaceMode) String() string {
+	if m < 0 || int(m) >= len(whitespaceModeNames) {
+		return "unknown"
+	}
+	return whitespaceModeNames[m]
+}
+
+// whitespaceBadge follows added lines that only changed whitespace
+const whitespaceBadge = " ␣ whitespace only"
+
+// whitespaceLines applies opts.Whitespace to the lines of a hunk. In a run
+// of removed lines followed by as many added lines, as when a block is
+// reindented, each removed line pairs with the added line in the same
+// place. Hiding turns pairs that only change whitespace into context lines
+// with the new content; badging marks their added lines. lines is returned
+// as is when nothing changes.
+func (r *Renderer) whitespaceLines(lines []DiffLine) []DiffLine {
+	if r.opts.Whitespace == WhitespaceShow {
+		return lines
+	}
+
+	var shown []DiffLine
+	for i := 0; i < len(lines); {
+		removed := i
+		for removed < len(lines) && lines[removed].Kind == LineRemoved {
+			removed++
+		}
+		added := removed
+		for added < len(lines) && lines[added].Kind == LineAdded {
+			added++
+		}
+		end := max(added, i+1)
+		n := removed - i
+		if n == 0 || added-removed != n {
+			if shown != nil {
+				shown = append(shown, lines[i:end]...)
+			}
+			i = end
+			continue
+		}
+
+		run := r.whitespaceRun(lines[i:removed], lines[removed:added])
+		if run != nil && shown == nil {
+			shown = append(make([]DiffLine, 0, len(lines)), lines[:i]...)
+		}
+		if run == nil {
+			run = lines[i:end]
+		}
+		if shown != nil {
+			shown = append(shown, run...)
+		}
+		i = end
+	}
+	if shown == nil {
+		return lines
+	}
+	return shown
+}
+
+// whitespaceRun returns the lines shown for removed lines replaced by as
+// many added lines, or nil when no pair only changes whitespace
+func (r *Renderer) whitespaceRun(removed, added []DiffLine) []DiffLine {
+	var run, pendingRemoved, pendingAdded []DiffLine
+	found := false
+	for j := range removed {
+		if ClassifyChange(removed[j].Content, added[j].Content) != ChangeWhitespace {
+			pendingRemoved = append(pendingRemoved, removed[j])
+			pendingAdded = append(pendingAdded, added[j])
+			continue
+		}
+		found = true
+
+		line := added[j]
+		if r.opts.Whitespace == WhitespaceBadge {
+			line.whitespaceOnly = true
+			pendingRemoved = append(pendingRemoved, removed[j])
+			pendingAdded = append(pendingAdded, line)
+			continue
+		}
+
+		// Changes around the hidden pair stay on their side of it
+		run = append(append(run, pendingRemoved...), pendingAdded...)
+		pendingRemoved, pendingAdded = nil, nil
+		line.Kind = LineContext
+		line.OldLineNo = removed[j].OldLineNo
+		line.Segments = nil
+		run = append(run, line)
+	}
+	if !found {
+		return nil
+	}
+	return append(append(run, pendingRemoved...), pendingAdded...)
+}
@@ -0,0 +1,158 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// lineOp is a single line-level edit operation between two texts
+type lineOp struct {
+	Type  diffmatchpatch.Operation
+	Lines []string
+}
+
+// diffLines computes a line-level diff between two texts
+func diffLines(oldText, newText string) []lineOp {
+	dmp := diffmatchpatch.New()
+
+	// Encode each line as a single rune so the diff runs line by line
+	oldChars, newChars, lineArray := dmp.DiffLinesToChars(oldText, newText)
+	diffs := dmp.DiffMain(oldChars, newChars, false)
+	diffs = dmp.DiffCharsToLines(diffs, lineArray)
+
+	ops := make([]lineOp, 0, len(diffs))
+	for _, d := range diffs {
+		ops = append(ops, lineOp{Type: d.Type, Lines: splitLines(d.Text)})
+	}
+	return ops
+}
+
+// splitLines splits text into lines without their trailing newlines
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// editLine is one line of a flattened line diff
+type editLine struct {
+	Type diffmatchpatch.Operation
+	Text string
+}
+
+// UnifiedDiff computes a unified diff between two texts, in the same format
+// as diff -u, so in-memory content can go through the regular parser
+func UnifiedDiff(oldName, newName, oldText, newText string, contextLines int) string {
+	var lines []editLine
+	for _, op := range diffLines(oldText, newText) {
+		for _, text := range op.Lines {
+			lines = append(lines, editLine{Type: op.Type, Text: text})
+		}
+	}
+
+	// The last line of a side without a trailing newline gets the same marker
+	// as in diff -u
+	lastOld, lastNew := -1, -1
+	for j, line := range lines {
+		if line.Type != diffmatchpatch.DiffInsert {
+			lastOld = j
+		}
+		if line.Type != diffmatchpatch.DiffDelete {
+			lastNew = j
+		}
+	}
+	if strings.HasSuffix(oldText, "\n") {
+		lastOld = -1
+	}
+	if strings.HasSuffix(newText, "\n") {
+		lastNew = -1
+	}
+
+	var b strings.Builder
+	oldLine, newLine := 1, 1
+	i := 0
+	for i < len(lines) {
+		// Find the next change
+		start := i
+		for start < len(lines) && lines[start].Type == diffmatchpatch.DiffEqual {
+			start++
+		}
+		if start == len(lines) {
+			break
+		}
+
+		// Extend the hunk while changes are within two contexts of each other
+		end := start
+		for end < len(lines) {
+			if lines[end].Type != diffmatchpatch.DiffEqual {
+				end++
+				continue
+			}
+			next := end
+			for next < len(lines) && lines[next].Type == diffmatchpatch.DiffEqual {
+				next++
+			}
+			if next == len(lines) || next-end > 2*contextLines {
+				break
+			}
+			end = next
+		}
+
+		hunkStart := max(start-contextLines, i)
+		hunkEnd := min(end+contextLines, len(lines))
+
+		// Advance line numbers up to the start of the hunk
+		for ; i < hunkStart; i++ {
+			oldLine++
+			newLine++
+		}
+
+		var body strings.Builder
+		oldCount, newCount := 0, 0
+		for j := hunkStart; j < hunkEnd; j++ {
+			line := lines[j]
+			switch line.Type {
+			case diffmatchpatch.DiffDelete:
+				body.WriteString("-" + line.Text + "\n")
+				oldCount++
+			case diffmatchpatch.DiffInsert:
+				body.WriteString("+" + line.Text + "\n")
+				newCount++
+			default:
+				body.WriteString(" " + line.Text + "\n")
+				oldCount++
+				newCount++
+			}
+			if j == lastOld || j == lastNew {
+				body.WriteString("\\ No newline at end of file\n")
+			}
+		}
+
+		if b.Len() == 0 {
+			fmt.Fprintf(&b, "--- %s\n+++ %s\n", oldName, newName)
+		}
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n", hunkRange(oldLine, oldCount), hunkRange(newLine, newCount))
+		b.WriteString(body.String())
+
+		oldLine += oldCount
+		newLine += newCount
+		i = hunkEnd
+	}
+
+	return b.String()
+}
+
+// hunkRange formats the start,count pair of a hunk header. Empty ranges
+// point at the line before the change, as diff -u does.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start-1)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
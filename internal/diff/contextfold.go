@@ -0,0 +1,83 @@
+package diff
+
+import "fmt"
+
+// foldKeepLines is how many context lines stay visible next to a change
+// when the context around it is folded
+const foldKeepLines = 3
+
+// hunkPart is a stretch of a hunk's lines: either shown, or folded behind
+// a divider
+type hunkPart struct {
+	lines  []DiffLine
+	folded bool
+}
+
+// hunkParts splits a hunk at the runs of context longer than
+// opts.FoldContext, folding all but foldKeepLines lines next to the
+// changes on either side. Hunks with Unfolded set are shown whole.
+// Whitespace-only changes are shown as opts.Whitespace says, and ignored
+// ones as opts.IgnoredChanges does. Changes of line endings show the
+// carriage returns.
+func (r *Renderer) hunkParts(hunk Hunk) []hunkPart {
+	hunk.Lines = r.ignoredLines(r.whitespaceLines(eolLines(hunk.Lines)))
+	limit := r.opts.FoldContext
+	if limit <= 0 || hunk.Unfolded {
+		return []hunkPart{{lines: hunk.Lines}}
+	}
+
+	var parts []hunkPart
+	shown := 0 // Start of the lines not yet in a part
+	for start := 0; start < len(hunk.Lines); {
+		if hunk.Lines[start].Kind != LineContext {
+			start++
+			continue
+		}
+		end := start
+		for end < len(hunk.Lines) && hunk.Lines[end].Kind == LineContext {
+			end++
+		}
+
+		// Keep the lines next to changes, none at the hunk's edges
+		from, to := start+foldKeepLines, end-foldKeepLines
+		if start == 0 {
+			from = 0
+		}
+		if end == len(hunk.Lines) {
+			to = end
+		}
+		if end-start > limit && to > from {
+			if from > shown {
+				parts = append(parts, hunkPart{lines: hunk.Lines[shown:from]})
+			}
+			parts = append(parts, hunkPart{lines: hunk.Lines[from:to], folded: true})
+			shown = to
+		}
+		start = end
+	}
+	if shown < len(hunk.Lines) {
+		parts = append(parts, hunkPart{lines: hunk.Lines[shown:]})
+	}
+	return parts
+}
+
+// renderContextFold renders the divider standing in for folded context
+// lines, naming the declaration they end in when the language is known
+func (r *Renderer) renderContextFold(filename string, hunk Hunk, lines []DiffLine) string {
+	divider := r.skippedStyle.Render(fmt.Sprintf("⋯ %d unchanged lines", len(lines)))
+
+	// Look for the declaration enclosing the line after the fold
+	var source []string
+	for _, line := range hunk.Lines {
+		if line.Kind != LineRemoved {
+			source = append(source, line.Content)
+		}
+		if line.NewLineNo == lines[len(lines)-1].NewLineNo {
+			break
+		}
+	}
+	if name := FunctionContext(filename, source, len(source)+1); name != "" {
+		divider += r.skippedStyle.Render(" · ") + r.hunkContextStyle.Render(name)
+	}
+	return divider
+}
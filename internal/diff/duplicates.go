@@ -0,0 +1,78 @@
+package diff
+
+import "strings"
+
+// SkipDuplicate is the SkipReason for files whose every hunk repeats a hunk
+// shown in an earlier file
+const SkipDuplicate = "duplicate"
+
+// FoldDuplicateHunks finds hunks making the same change in several files,
+// such as license header updates, and folds every instance but the first:
+// the first lists the other files in Duplicates, and the others name the
+// first file in DuplicateOf and render as just their header. Files made up
+// entirely of folded hunks are skipped with SkipDuplicate. Collapsed files,
+// such as generated ones, are left out so that the instance shown is one
+// that can be seen.
+func FoldDuplicateHunks(files []*DiffResult) {
+	type instance struct {
+		hunk *Hunk
+		file *DiffResult
+	}
+	first := make(map[string]instance)
+	for _, file := range files {
+		if file.SkipReason != "" || file.Collapsed || file.IsBinary || file.Submodule != nil || file.LFS != nil || file.EOL != nil {
+			continue
+		}
+
+		folded := 0
+		for i := range file.Hunks {
+			hunk := &file.Hunks[i]
+			key := hunkKey(*hunk)
+			orig, ok := first[key]
+			if !ok {
+				first[key] = instance{hunk, file}
+				continue
+			}
+			if orig.file == file {
+				continue
+			}
+			name := file.DisplayName()
+			if n := len(orig.hunk.Duplicates); n == 0 || orig.hunk.Duplicates[n-1] != name {
+				orig.hunk.Duplicates = append(orig.hunk.Duplicates, name)
+			}
+			hunk.DuplicateOf = orig.file.DisplayName()
+			folded++
+		}
+		if folded > 0 && folded == len(file.Hunks) {
+			file.SkipReason = SkipDuplicate
+		}
+	}
+}
+
+// UnfoldDuplicateHunks undoes FoldDuplicateHunks
+func UnfoldDuplicateHunks(files []*DiffResult) {
+	for _, file := range files {
+		if file.SkipReason == SkipDuplicate {
+			file.SkipReason = ""
+		}
+		for i := range file.Hunks {
+			file.Hunks[i].Duplicates = nil
+			file.Hunks[i].DuplicateOf = ""
+		}
+	}
+}
+
+// hunkKey identifies the change a hunk makes by its lines, ignoring where in
+// the file it is
+func hunkKey(hunk Hunk) string {
+	var sb strings.Builder
+	for _, line := range hunk.Lines {
+		sb.WriteByte(" +-"[line.Kind])
+		sb.WriteString(line.Content)
+		if line.CRLF {
+			sb.WriteByte('\r')
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
@@ -0,0 +1,229 @@
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/avgvstvs96/differential/internal/preview"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// encodingSample is how many bytes encoding detection looks at
+const encodingSample = 8192
+
+// encodings are the encodings files can be transcoded from, by the names
+// --encoding takes and badges show
+var encodings = map[string]encoding.Encoding{
+	"latin-1":      charmap.ISO8859_1,
+	"windows-1252": charmap.Windows1252,
+	"utf-16le":     unicode.UTF16(unicode.LittleEndian, unicode.UseBOM),
+	"utf-16be":     unicode.UTF16(unicode.BigEndian, unicode.UseBOM),
+}
+
+// encodingAliases are other common names of the encodings
+var encodingAliases = map[string]string{
+	"utf8":       "utf-8",
+	"latin1":     "latin-1",
+	"iso-8859-1": "latin-1",
+	"iso8859-1":  "latin-1",
+	"cp1252":     "windows-1252",
+	"utf-16":     "utf-16le",
+	"utf16":      "utf-16le",
+	"utf16le":    "utf-16le",
+	"utf16be":    "utf-16be",
+}
+
+// EncodingName returns the canonical name of an encoding given to
+// --encoding, or an error listing the known ones
+func EncodingName(name string) (string, error) {
+	name = strings.ToLower(name)
+	if alias, ok := encodingAliases[name]; ok {
+		name = alias
+	}
+	if _, ok := encodings[name]; ok || name == "utf-8" {
+		return name, nil
+	}
+	names := []string{"utf-8"}
+	for known := range encodings {
+		names = append(names, known)
+	}
+	sort.Strings(names)
+	return "", fmt.Errorf("unknown encoding %q; use one of %s", name, strings.Join(names, ", "))
+}
+
+// DetectEncoding guesses the encoding of data from its byte order mark,
+// the zero bytes of UTF-16 text, or whether it is UTF-8. Text with any
+// multibyte UTF-8 sequence is taken as UTF-8 with a few stray bytes. Other
+// text is taken as Windows-1252 when it uses the bytes that encoding prints
+// and Latin-1 doesn't, and as Latin-1 otherwise.
+func DetectEncoding(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xff, 0xfe}):
+		return "utf-16le"
+	case bytes.HasPrefix(data, []byte{0xfe, 0xff}):
+		return "utf-16be"
+	case bytes.HasPrefix(data, []byte{0xef, 0xbb, 0xbf}):
+		return "utf-8"
+	}
+
+	sample := data[:min(len(data), encodingSample)]
+	// ASCII text in UTF-16 has a zero byte in every other place
+	evenZeros, oddZeros := 0, 0
+	for i, b := range sample {
+		if b != 0 {
+			continue
+		}
+		if i%2 == 0 {
+			evenZeros++
+		} else {
+			oddZeros++
+		}
+	}
+	pairs := len(sample) / 2
+	switch {
+	case pairs > 0 && oddZeros*2 >= pairs && evenZeros*10 < oddZeros:
+		return "utf-16le"
+	case pairs > 0 && evenZeros*2 >= pairs && oddZeros*10 < evenZeros:
+		return "utf-16be"
+	}
+
+	// A multibyte rune may be cut at the end of the sample
+	if utf8.Valid(sample) || (len(sample) < len(data) && utf8.Valid(sample[:max(len(sample)-utf8.UTFMax, 0)])) {
+		return "utf-8"
+	}
+	if hasMultibyteRune(sample) {
+		return "utf-8"
+	}
+	for _, b := range sample {
+		if b >= 0x80 && b < 0xa0 {
+			return "windows-1252"
+		}
+	}
+	return "latin-1"
+}
+
+// hasMultibyteRune reports whether data holds a valid UTF-8 sequence of more
+// than one byte, which Latin-1 text is unlikely to
+func hasMultibyteRune(data []byte) bool {
+	for len(data) > 0 {
+		r, size := utf8.DecodeRune(data)
+		if r != utf8.RuneError && size > 1 {
+			return true
+		}
+		data = data[size:]
+	}
+	return false
+}
+
+// isUTF16 reports whether an encoding name is one of UTF-16's
+func isUTF16(name string) bool {
+	return strings.HasPrefix(name, "utf-16")
+}
+
+// decodeText transcodes data from the named encoding to UTF-8, leaving it
+// as it is for UTF-8 and unknown encodings
+func decodeText(data []byte, name string) []byte {
+	enc, ok := encodings[name]
+	if !ok {
+		return data
+	}
+	decoded, err := enc.NewDecoder().Bytes(data)
+	if err != nil {
+		return data
+	}
+	return decoded
+}
+
+// DecodeFiles transcodes files in other encodings than UTF-8 for display,
+// setting their Encoding. forced names the encoding of every file; when
+// it's empty each file's is detected. The lines of text files are decoded
+// in place. UTF-16 files, which git takes for binary, are diffed again
+// from both versions as load returns them, with contextLines lines of
+// context; they stay binary when load is nil or fails. Skipped files are
+// left alone, so files should be filtered first.
+func DecodeFiles(files []*DiffResult, forced string, load func(path, id string) ([]byte, error), contextLines int) {
+	if forced == "utf-8" {
+		return
+	}
+	for _, file := range files {
+		if file.SkipReason != "" || file.Submodule != nil || file.LFS != nil {
+			continue
+		}
+		if file.IsBinary {
+			if !preview.IsImage(file.DisplayName()) {
+				decodeBinaryFile(file, forced, load, contextLines)
+			}
+			continue
+		}
+
+		name := forced
+		if name == "" {
+			var sample []byte
+			for _, hunk := range file.Hunks {
+				for _, line := range hunk.Lines {
+					sample = append(sample, line.Content...)
+					sample = append(sample, '\n')
+				}
+				if len(sample) >= encodingSample {
+					break
+				}
+			}
+			name = DetectEncoding(sample)
+		}
+		// Lines of UTF-16 text can't be decoded on their own, as git split
+		// them before the zero byte ending each newline
+		if name == "utf-8" || isUTF16(name) {
+			continue
+		}
+		for i := range file.Hunks {
+			for j := range file.Hunks[i].Lines {
+				line := &file.Hunks[i].Lines[j]
+				line.Content = string(decodeText([]byte(line.Content), name))
+			}
+		}
+		file.Encoding = name
+	}
+}
+
+// decodeBinaryFile turns a binary file whose versions are UTF-16 text into
+// a text diff of them. Unless the encoding is forced, the old version is
+// only loaded once the new one turns out to be UTF-16.
+func decodeBinaryFile(file *DiffResult, forced string, load func(path, id string) ([]byte, error), contextLines int) {
+	if load == nil || (forced != "" && !isUTF16(forced)) {
+		return
+	}
+	after, err := loadVersion(load, file.NewFile, file.NewIndex)
+	if err != nil {
+		return
+	}
+	name := forced
+	if name == "" && after != nil {
+		if name = DetectEncoding(after); !isUTF16(name) {
+			return
+		}
+	}
+	before, err := loadVersion(load, file.OldFile, file.OldIndex)
+	if err != nil || (before == nil && after == nil) || bytes.Equal(before, after) {
+		return
+	}
+	if name == "" {
+		name = DetectEncoding(before)
+	}
+	if !isUTF16(name) {
+		return
+	}
+
+	oldText, newText := string(decodeText(before, name)), string(decodeText(after, name))
+	decoded, err := ParseUnifiedDiff(UnifiedDiff("a/"+file.DisplayName(), "b/"+file.DisplayName(), oldText, newText, contextLines))
+	if err != nil {
+		return
+	}
+	file.Hunks = decoded.Hunks
+	file.IsBinary = false
+	file.Encoding = name
+}
@@ -0,0 +1,126 @@
+package diff
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// EOLChange is a change that only converts line endings
+type EOLChange struct {
+	From  string // "LF", "CRLF" or "mixed"
+	To    string
+	Lines int // Number of lines converted
+}
+
+// parseEOL recognizes a diff whose changed lines only trade "\n" for
+// "\r\n" or back, returning nil when any content changed
+func parseEOL(hunks []Hunk) *EOLChange {
+	var from, to eolCount
+	for _, hunk := range hunks {
+		var removed, added []DiffLine
+		for _, line := range hunk.Lines {
+			switch line.Kind {
+			case LineRemoved:
+				removed = append(removed, line)
+			case LineAdded:
+				added = append(added, line)
+			}
+		}
+		if len(removed) != len(added) {
+			return nil
+		}
+		for i := range removed {
+			if removed[i].Content != added[i].Content || removed[i].CRLF == added[i].CRLF {
+				return nil
+			}
+			from.add(removed[i].CRLF)
+			to.add(added[i].CRLF)
+		}
+	}
+	if from.lines() == 0 {
+		return nil
+	}
+	return &EOLChange{From: from.name(), To: to.name(), Lines: from.lines()}
+}
+
+// eolLines marks the lines of a hunk that only change their line ending.
+// In a run of removed lines followed by as many added lines, each removed
+// line pairs with the added line in the same place, as in whitespaceLines.
+// lines is returned as is when no pair only changes its line ending.
+func eolLines(lines []DiffLine) []DiffLine {
+	var marked []DiffLine
+	for i := 0; i < len(lines); {
+		removed := i
+		for removed < len(lines) && lines[removed].Kind == LineRemoved {
+			removed++
+		}
+		added := removed
+		for added < len(lines) && lines[added].Kind == LineAdded {
+			added++
+		}
+		n := removed - i
+		if n > 0 && added-removed == n {
+			for j := 0; j < n; j++ {
+				old, new := lines[i+j], lines[removed+j]
+				if old.Content != new.Content || old.CRLF == new.CRLF {
+					continue
+				}
+				if marked == nil {
+					marked = append([]DiffLine(nil), lines...)
+				}
+				marked[i+j].eolOnly = true
+				marked[removed+j].eolOnly = true
+			}
+		}
+		i = max(added, i+1)
+	}
+	if marked == nil {
+		return lines
+	}
+	return marked
+}
+
+// eolCount counts the line endings of one side of a diff
+type eolCount struct {
+	lf, crlf int
+}
+
+func (c *eolCount) add(crlf bool) {
+	if crlf {
+		c.crlf++
+	} else {
+		c.lf++
+	}
+}
+
+func (c eolCount) lines() int {
+	return c.lf + c.crlf
+}
+
+// name describes the line endings counted
+func (c eolCount) name() string {
+	switch {
+	case c.lf == 0:
+		return "CRLF"
+	case c.crlf == 0:
+		return "LF"
+	}
+	return "mixed"
+}
+
+// renderEOL renders a line ending conversion as a one-line notice instead of
+// every line of the file
+func renderEOL(result *DiffResult, opts RenderOptions) string {
+	change := result.EOL
+	theme := opts.theme()
+	titleStyle := lipgloss.NewStyle().Foreground(theme.Text).Bold(true)
+	labelStyle := lipgloss.NewStyle().Foreground(theme.TextMuted)
+
+	lines := "1 line"
+	if change.Lines != 1 {
+		lines = fmt.Sprintf("%d lines", change.Lines)
+	}
+	return titleStyle.Render(fmt.Sprintf("EOL changed (%s→%s)", change.From, change.To)) +
+		labelStyle.Render(" in "+lines+" of "+result.NewFile) + "\n"
+}
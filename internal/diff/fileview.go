@@ -0,0 +1,37 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FileView returns the whole of an unchanged file as a diff of context
+// lines, so it renders with syntax highlighting like any other diff. Its
+// hunk is never folded.
+func FileView(path, content string) *DiffResult {
+	result := &DiffResult{OldFile: path, NewFile: path}
+	if strings.IndexByte(content, 0) >= 0 {
+		result.IsBinary = true
+		return result
+	}
+	if content == "" {
+		return result
+	}
+
+	noNewline := !strings.HasSuffix(content, "\n")
+	lines := splitLines(content)
+	hunk := Hunk{
+		Header:   fmt.Sprintf("@@ -1,%d +1,%d @@", len(lines), len(lines)),
+		Unfolded: true,
+	}
+	for i, line := range lines {
+		dl := DiffLine{OldLineNo: i + 1, NewLineNo: i + 1, Kind: LineContext, Content: line}
+		if strings.HasSuffix(line, "\r") {
+			dl.Content, dl.CRLF = strings.TrimSuffix(line, "\r"), true
+		}
+		hunk.Lines = append(hunk.Lines, dl)
+	}
+	hunk.Lines[len(hunk.Lines)-1].NoNewline = noNewline
+	result.Hunks = []Hunk{hunk}
+	return result
+}
@@ -0,0 +1,64 @@
+package diff
+
+import (
+	"path"
+	"strings"
+)
+
+// SkipFiltered is the SkipReason for files excluded by a FileFilter
+const SkipFiltered = "filtered"
+
+// FileFilter decides which files of a multi-file diff are shown, using glob
+// patterns. Patterns without a slash match the base name anywhere in the
+// tree; patterns ending in "/" or "/**" match everything below a directory.
+type FileFilter struct {
+	Include []string // If set, only matching files are shown
+	Exclude []string // Matching files are skipped
+}
+
+// Allows reports whether a file path passes the filter
+func (f FileFilter) Allows(filePath string) bool {
+	for _, pattern := range f.Exclude {
+		if MatchGlob(pattern, filePath) {
+			return false
+		}
+	}
+	if len(f.Include) == 0 {
+		return true
+	}
+	for _, pattern := range f.Include {
+		if MatchGlob(pattern, filePath) {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply marks files that don't pass the filter as skipped
+func (f FileFilter) Apply(files []*DiffResult) {
+	for _, file := range files {
+		if file.SkipReason == "" && !f.Allows(file.DisplayName()) {
+			file.SkipReason = SkipFiltered
+		}
+	}
+}
+
+// MatchGlob matches a file path against a filter pattern
+func MatchGlob(pattern, filePath string) bool {
+	// Directory patterns match everything below the directory
+	if dir, ok := strings.CutSuffix(pattern, "/**"); ok {
+		return filePath == dir || strings.HasPrefix(filePath, dir+"/")
+	}
+	if dir, ok := strings.CutSuffix(pattern, "/"); ok {
+		return strings.HasPrefix(filePath, dir+"/") || strings.Contains(filePath, "/"+dir+"/")
+	}
+
+	if matched, _ := path.Match(pattern, filePath); matched {
+		return true
+	}
+	if !strings.Contains(pattern, "/") {
+		matched, _ := path.Match(pattern, path.Base(filePath))
+		return matched
+	}
+	return false
+}
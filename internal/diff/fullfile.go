@@ -0,0 +1,215 @@
+package diff
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/avgvstvs96/differential/internal/themes"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// changeMark is what the change gutter of the full-file view marks next to
+// a line, as editors mark changed lines
+type changeMark int
+
+const (
+	markNone         changeMark = iota
+	markAdded                   // The line was added
+	markChanged                 // The line replaced removed lines
+	markRemovedAbove            // Lines were removed right above the line
+	markRemovedBelow            // Lines were removed below the last line
+)
+
+// changeMarkGlyphs are the gutter characters of each changeMark
+var changeMarkGlyphs = [...]string{" ", "▎", "▎", "▔", "▁"}
+
+// fullFileLine is a line of the new version of a file in the full-file view
+type fullFileLine struct {
+	line DiffLine // Context, or added for the lines the diff added
+	mark changeMark
+}
+
+// fullFile returns the lines of the new version of result for the
+// full-file view, or nil when it can't be shown whole, e.g. for a deleted
+// file or one whose source can't be loaded
+func (r *Renderer) fullFile(result *DiffResult) []fullFileLine {
+	if !r.opts.FullFile || result.IsBinary || result.Submodule != nil || result.LFS != nil || result.EOL != nil {
+		return nil
+	}
+	lines, ok := r.fullFiles[result]
+	if !ok {
+		if source := r.loadSource(result); source != nil {
+			lines = fullFileLines(result, source)
+		}
+		r.fullFiles[result] = lines
+	}
+	return lines
+}
+
+// fullFileLines lays the hunks of result over source, the lines of its new
+// version, marking the changes they make
+func fullFileLines(result *DiffResult, source []string) []fullFileLine {
+	lines := make([]fullFileLine, len(source))
+	for i, content := range source {
+		lines[i].line = DiffLine{NewLineNo: i + 1, Kind: LineContext, Content: content}
+	}
+
+	// Old line numbers outside hunks are shifted by the hunks above
+	delta, filled := 0, 0
+	fill := func(upTo int) {
+		for ; filled < upTo && filled < len(lines); filled++ {
+			lines[filled].line.OldLineNo = filled + 1 + delta
+		}
+	}
+
+	for _, hunk := range result.Hunks {
+		oldEnd, newEnd, ok := hunkEnds(hunk)
+		if !ok {
+			continue
+		}
+		newNext := 0                    // The new line after the last one read
+		removed, added := 0, []int(nil) // The run of changes being read
+		endRun := func() {
+			for _, n := range added {
+				lines[n-1].mark = markAdded
+				if removed > 0 {
+					lines[n-1].mark = markChanged
+				}
+			}
+			if len(added) == 0 && removed > 0 && len(lines) > 0 {
+				next := newNext
+				if next == 0 {
+					next = newEnd
+				}
+				if next <= len(lines) {
+					lines[next-1].mark = markRemovedAbove
+				} else {
+					lines[len(lines)-1].mark = markRemovedBelow
+				}
+			}
+			removed, added = 0, nil
+		}
+
+		for _, dl := range hunk.Lines {
+			// Ignored changes are left unmarked
+			switch {
+			case dl.Kind == LineRemoved:
+				if !dl.Ignored {
+					removed++
+				}
+				continue
+			case dl.Kind == LineAdded:
+				if !dl.Ignored {
+					added = append(added, dl.NewLineNo)
+				}
+			default:
+				endRun()
+			}
+			if dl.NewLineNo < 1 || dl.NewLineNo > len(lines) {
+				continue
+			}
+			fill(dl.NewLineNo - 1)
+			lines[dl.NewLineNo-1].line = dl
+			filled = dl.NewLineNo
+			newNext = dl.NewLineNo + 1
+		}
+		endRun()
+		delta = oldEnd - newEnd
+	}
+	fill(len(lines))
+	return lines
+}
+
+// hunkEnds returns the first old and new lines after hunk, read from its
+// header
+func hunkEnds(hunk Hunk) (oldEnd, newEnd int, ok bool) {
+	matches := hunkHeaderRegex.FindStringSubmatch(hunk.Header)
+	if matches == nil {
+		return 0, 0, false
+	}
+	end := func(start, count string) int {
+		n, _ := strconv.Atoi(start)
+		if count == "" {
+			return n + 1
+		}
+		c, _ := strconv.Atoi(count)
+		if c == 0 {
+			// An empty range points at the line before the change
+			return n + 1
+		}
+		return n + c
+	}
+	return end(matches[1], matches[2]), end(matches[3], matches[4]), true
+}
+
+// renderFullFile renders the whole new version of result with a change
+// gutter, highlighting the syntax of every line
+func (r *Renderer) renderFullFile(result *DiffResult, lines []fullFileLine) string {
+	r.tokens = r.opts.Tokens.forFile(result.NewFile)
+	r.file = result
+	h := r.highlighter(result.NewFile, result)
+
+	r.buf.Reset()
+	for _, fl := range lines {
+		if r.cancelled() {
+			break
+		}
+		r.buf.WriteString(r.renderFullFileLine(h, fl))
+		r.buf.WriteString("\n")
+	}
+	r.buf.WriteString("\n")
+	return r.buf.String()
+}
+
+// renderFullFileLine renders a line of the full-file view: its new line
+// number, change mark and highlighted content. Unchanged lines are dimmed
+// with DimContext.
+func (r *Renderer) renderFullFileLine(h *themes.Highlighter, fl fullFileLine) string {
+	style := &r.lineStyles[LineContext]
+	opts := r.opts
+	var sb strings.Builder
+
+	if opts.ShowLineNumbers && opts.Gutter.Mode != GutterNone {
+		sb.WriteString(r.link(r.file, style.lineNumber.Render(opts.Gutter.number(fl.line.NewLineNo)), fl.line.NewLineNo, false))
+		sb.WriteString(opts.Gutter.separator())
+	}
+	sb.WriteString(r.changeMarkStyles[fl.mark].Render(r.changeMarkGlyph(fl.mark)))
+
+	line := r.revealControls(fl.line)
+	content := line.Content
+	if !opts.DimContext || fl.mark == markAdded || fl.mark == markChanged {
+		content = r.highlightContext(h, line)
+	}
+	if opts.Highlight != nil && line.Kind == LineAdded {
+		content = highlightMatches(content, line.Content, opts.Highlight, r.matchHighlight)
+	}
+	content = r.revealWhitespace(content, line.Content)
+	content = r.revealCR(content, line)
+	sb.WriteString(style.bg.Render(content))
+	if fl.line.NoNewline {
+		sb.WriteString(style.annotation.Render(noNewlineMarker))
+	}
+
+	if opts.Width > 0 {
+		if width := VisibleLength(sb.String()); width < opts.Width {
+			sb.WriteString(style.bg.Render(strings.Repeat(" ", opts.Width-width)))
+		}
+	}
+	return sb.String()
+}
+
+// newChangeMarkStyles builds the styles of the change gutter's marks:
+// added lines in the added color, changed ones halfway to the removed color
+func newChangeMarkStyles(theme *themes.ThemeColors) [len(changeMarkGlyphs)]lipgloss.Style {
+	changed := theme.DiffAdded
+	if color, err := themes.Mix(theme.DiffAdded, theme.DiffRemoved, 0.5); err == nil {
+		changed = color
+	}
+	colors := [...]lipgloss.Color{theme.TextMuted, theme.DiffAdded, changed, theme.DiffRemoved, theme.DiffRemoved}
+
+	var styles [len(changeMarkGlyphs)]lipgloss.Style
+	for i, color := range colors {
+		styles[i] = lipgloss.NewStyle().Background(theme.DiffContextBg).Foreground(color).Bold(true)
+	}
+	return styles
+}
@@ -0,0 +1,298 @@
+package diff
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// HunkContextMode selects what hunk headers show after the line ranges
+type HunkContextMode int
+
+const (
+	HunkContextGit  HunkContextMode = iota // The function context git printed
+	HunkContextScan                        // The nearest declaration above the change, falling back to git's
+	HunkContextOff                         // Nothing
+)
+
+var hunkContextModeNames = []string{"git", "scan", "off"}
+
+// ParseHunkContextMode parses a hunk context mode name as used in the
+// config file
+func ParseHunkContextMode(name string) (HunkContextMode, error) {
+	for i, n := range hunkContextModeNames {
+		if strings.EqualFold(name, n) {
+			return HunkContextMode(i), nil
+		}
+	}
+	return HunkContextGit, fmt.Errorf("unknown hunk context mode %q (expected %s)", name, strings.Join(hunkContextModeNames, ", "))
+}
+
+// String returns the config name of the mode
+func (m HunkContextMode) String() string {
+	if m < 0 || int(m) >= len(hunkContextModeNames) {
+		return "unknown"
+	}
+	return hunkContextModeNames[m]
+}
+
+// declarationPatterns match lines declaring a function, type or section,
+// by file extension
+var declarationPatterns = map[string]*regexp.Regexp{}
+
+func init() {
+	languages := []struct {
+		extensions []string
+		pattern    string
+	}{
+		{[]string{".go"}, `^func\b|^type\s+\w+\s+(struct|interface)\b`},
+		{[]string{".py", ".pyi"}, `^\s*(async\s+)?(def|class)\s+\w+`},
+		{[]string{".js", ".jsx", ".mjs", ".cjs", ".ts", ".tsx", ".mts", ".cts"},
+			`^\s*(export\s+)?(default\s+)?(async\s+)?(function\b|class\s+\w+)` +
+				`|^\s*(export\s+)?(const|let|var)\s+\w+\s*=\s*(async\s+)?(function\b|\([^)]*\)\s*=>|\w+\s*=>)` +
+				`|^\s+((public|private|protected|static|async|get|set)\s+)*[A-Za-z_$][\w$]*\s*\([^)]*\)\s*(:\s*[^{]+)?\{\s*$`},
+		{[]string{".rs"}, `^\s*(pub(\([^)]*\))?\s+)?(async\s+)?(unsafe\s+)?(fn|impl|trait|struct|enum|mod)\b`},
+		{[]string{".java", ".kt", ".kts", ".cs", ".scala"},
+			`^\s*((public|private|protected|internal|static|final|abstract|override|sealed|open|data|partial)\s+)*(class|interface|enum|record|object|fun|def)\s+\w+` +
+				`|^\s*((public|private|protected|internal|static|final|abstract|override|synchronized|async|virtual)\s+)+[\w<>\[\],.? ]+\s+\w+\s*\(`},
+		{[]string{".c", ".h", ".cc", ".cpp", ".cxx", ".hh", ".hpp", ".hxx", ".m", ".mm"},
+			`^[A-Za-z_][\w\s*&:<>,~]*\b[\w~]+\s*\([^;]*$|^(struct|class|namespace|enum|union)\s+\w+[^;]*$`},
+		{[]string{".rb", ".rake"}, `^\s*(def|class|module)\s+`},
+		{[]string{".php"}, `^\s*((public|private|protected|static|abstract|final)\s+)*(function|class|interface|trait|enum)\s+\w+`},
+		{[]string{".swift"}, `^\s*((public|private|internal|fileprivate|open|static|final|override|@\w+)\s+)*(func|class|struct|enum|protocol|extension|init)\b`},
+		{[]string{".sh", ".bash", ".zsh"}, `^\s*(function\s+[\w-]+|[\w-]+\s*\(\)\s*\{?\s*$)`},
+		{[]string{".lua"}, `^\s*(local\s+)?function\b`},
+		{[]string{".ex", ".exs"}, `^\s*(defp?|defmodule|defmacrop?)\s`},
+		{[]string{".md", ".markdown"}, `^#{1,6}\s`},
+	}
+	for _, language := range languages {
+		re := regexp.MustCompile(language.pattern)
+		for _, ext := range language.extensions {
+			declarationPatterns[ext] = re
+		}
+	}
+}
+
+// controlKeywords start lines that look like C-style function declarations
+// but aren't
+var controlKeywords = map[string]bool{
+	"if": true, "else": true, "for": true, "while": true, "switch": true,
+	"catch": true, "return": true, "do": true, "with": true, "new": true,
+}
+
+// isDeclaration reports whether line declares something worth naming in a
+// hunk header
+func isDeclaration(re *regexp.Regexp, line string) bool {
+	if !re.MatchString(line) {
+		return false
+	}
+	word := strings.TrimSpace(line)
+	if i := strings.IndexFunc(word, func(r rune) bool { return r != '_' && !isWordRune(r) }); i >= 0 {
+		word = word[:i]
+	}
+	return !controlKeywords[word]
+}
+
+// isWordRune reports whether r can be part of an identifier
+func isWordRune(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9'
+}
+
+// indentWidth returns the width of a line's leading whitespace, counting
+// tabs as four columns
+func indentWidth(line string) int {
+	width := 0
+	for _, r := range line {
+		switch r {
+		case ' ':
+			width++
+		case '\t':
+			width += 4
+		default:
+			return width
+		}
+	}
+	return width
+}
+
+// FunctionContext returns the nearest declaration enclosing line (1-based)
+// of lines, or "" when there is none or the language of filename isn't
+// known. A declaration encloses the lines indented deeper than it that
+// follow; at the top level the nearest top-level declaration is used.
+func FunctionContext(filename string, lines []string, line int) string {
+	re := declarationPatterns[strings.ToLower(filepath.Ext(filename))]
+	if re == nil || line < 1 || line > len(lines)+1 {
+		return ""
+	}
+	bound := maxIndent
+	if line <= len(lines) && strings.TrimSpace(lines[line-1]) != "" {
+		bound = indentWidth(lines[line-1])
+	}
+	for i := line - 2; i >= 0; i-- {
+		if strings.TrimSpace(lines[i]) == "" {
+			continue
+		}
+		width := indentWidth(lines[i])
+		if (width < bound || width == 0) && isDeclaration(re, lines[i]) {
+			return strings.TrimSpace(lines[i])
+		}
+		bound = min(bound, width)
+	}
+	return ""
+}
+
+// maxIndent accepts declarations at any indentation
+const maxIndent = int(^uint(0) >> 1)
+
+// hunkContexts returns the function context shown in each hunk header of
+// result. In scan mode, when a hunk's own lines don't reveal its context,
+// the new version of the file is loaded through LoadBlob, if set, to look
+// above the hunk.
+func (r *Renderer) hunkContexts(result *DiffResult) []string {
+	contexts := make([]string, len(result.Hunks))
+	if r.opts.HunkContext == HunkContextOff {
+		return contexts
+	}
+	for i, hunk := range result.Hunks {
+		contexts[i] = gitHunkContext(hunk.Header)
+	}
+	if r.opts.HunkContext != HunkContextScan || declarationPatterns[strings.ToLower(filepath.Ext(result.NewFile))] == nil {
+		return contexts
+	}
+
+	loaded := false
+	var source []string
+	loadSource := func() []string {
+		if !loaded {
+			source, loaded = r.loadSource(result), true
+		}
+		return source
+	}
+	for i, hunk := range result.Hunks {
+		if context := scanHunkContext(result.NewFile, hunk, loadSource); context != "" {
+			contexts[i] = context
+		}
+	}
+	return contexts
+}
+
+// gitHunkContext returns the text git printed after a hunk's line ranges
+func gitHunkContext(header string) string {
+	match := hunkHeaderRegex.FindString(header)
+	return strings.TrimSpace(strings.TrimPrefix(header, match))
+}
+
+// scanHunkContext finds the declaration enclosing a hunk's first change,
+// looking through the hunk's leading context lines and then the new file
+// above the hunk, as returned by loadSource
+func scanHunkContext(filename string, hunk Hunk, loadSource func() []string) string {
+	first := -1
+	for i, line := range hunk.Lines {
+		if line.Kind != LineContext {
+			first = i
+			break
+		}
+	}
+	if first < 0 {
+		return ""
+	}
+
+	// The hunk's own lines cover the declaration when it is close
+	lines := make([]string, 0, first+1)
+	for _, line := range hunk.Lines[:first+1] {
+		lines = append(lines, line.Content)
+	}
+	if context := FunctionContext(filename, lines, len(lines)); context != "" {
+		return context
+	}
+	source := loadSource()
+	if source == nil {
+		return ""
+	}
+
+	// Otherwise look above the hunk, indenting as deep as the first change
+	matches := hunkHeaderRegex.FindStringSubmatch(hunk.Header)
+	if matches == nil {
+		return ""
+	}
+	start, _ := strconv.Atoi(matches[3])
+	above := source[:min(max(start-1, 0), len(source))]
+	return FunctionContext(filename, append(above[:len(above):len(above)], hunk.Lines[first].Content), len(above)+1)
+}
+
+// loadSource returns the lines of the new version of result, or nil when it
+// can't be loaded or doesn't match the diff, e.g. for a patch from another
+// tree
+func (r *Renderer) loadSource(result *DiffResult) []string {
+	if r.opts.LoadBlob == nil || result.NewFile == "" || result.NewFile == "/dev/null" ||
+		(result.NewIndex != "" && strings.Trim(result.NewIndex, "0") == "") {
+		return nil
+	}
+	data, err := r.opts.LoadBlob(result.NewFile, result.NewIndex)
+	if err != nil {
+		return nil
+	}
+	data = decodeText(data, result.Encoding)
+	source := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	for i, line := range source {
+		source[i] = strings.TrimSuffix(line, "\r")
+	}
+
+	for _, hunk := range result.Hunks {
+		for _, line := range hunk.Lines {
+			if line.Kind == LineRemoved {
+				continue
+			}
+			if line.NewLineNo > len(source) || source[line.NewLineNo-1] != line.Content {
+				return nil
+			}
+		}
+	}
+	return source
+}
+
+// renderHunkHeader renders a hunk's line ranges followed by its stats, if
+// enabled, and its function context
+func (r *Renderer) renderHunkHeader(hunk Hunk, context string) string {
+	ranges := hunkHeaderRegex.FindString(hunk.Header)
+	if ranges == "" {
+		return r.hunkHeaderStyle.Render(hunk.Header)
+	}
+	header := r.hunkHeaderStyle.Render(ranges)
+	if r.opts.HunkStats {
+		header += " " + r.renderHunkStats(hunk)
+	}
+	if context != "" {
+		header += " " + r.hunkContextStyle.Render(context)
+	}
+	switch n := len(hunk.Duplicates); {
+	case hunk.Approved:
+		header += r.skippedStyle.Render(" · approved")
+	case hunk.Ignored:
+		header += r.skippedStyle.Render(" · ignored")
+	case hunk.DuplicateOf != "":
+		header += r.skippedStyle.Render(" · same change as " + hunk.DuplicateOf)
+	case n == 1:
+		header += r.skippedStyle.Render(" · same change in 1 other file")
+	case n > 1:
+		header += r.skippedStyle.Render(fmt.Sprintf(" · same change in %d other files", n))
+	}
+	if hunk.Staged {
+		header += r.skippedStyle.Render(" · staged")
+	}
+	return header
+}
+
+// renderHunkStats renders how many lines a hunk adds and removes, and how
+// many of those replace each other, e.g. "+12 -4 (3 modified)"
+func (r *Renderer) renderHunkStats(hunk Hunk) string {
+	stat := hunk.Stat()
+	out := r.hunkAddedStyle.Render(fmt.Sprintf("+%d", stat.Added)) + " " +
+		r.hunkRemovedStyle.Render(fmt.Sprintf("-%d", stat.Removed))
+	if stat.Modified > 0 {
+		out += r.skippedStyle.Render(fmt.Sprintf(" (%d modified)", stat.Modified))
+	}
+	return out
+}
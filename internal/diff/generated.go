@@ -0,0 +1,86 @@
+package diff
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+// generatedMarker matches the comments code generators put at the top of
+// their output, such as Go's "// Code generated by stringer; DO NOT EDIT."
+var generatedMarker = regexp.MustCompile(`Code generated .*DO NOT EDIT|@generated\b`)
+
+const (
+	// generatedHeaderLines is how many lines from the top of a file a
+	// generatedMarker is looked for in
+	generatedHeaderLines = 5
+	// minifiedLineLength is the length past which a line of JavaScript or
+	// CSS is taken to be minified
+	minifiedLineLength = 1000
+)
+
+// GeneratedFiles recognizes generated files: those matching Patterns or
+// marked in Attributes, JavaScript and CSS with minified lines, and files
+// whose diff shows a generated-code comment in their first lines
+type GeneratedFiles struct {
+	Patterns   []string        // Globs of generated files, matched as FileFilter's
+	Attributes map[string]bool // Paths .gitattributes marks linguist-generated
+}
+
+// Matches reports whether file is generated
+func (g GeneratedFiles) Matches(file *DiffResult) bool {
+	name := file.DisplayName()
+	if g.Attributes[name] {
+		return true
+	}
+	for _, pattern := range g.Patterns {
+		if MatchGlob(pattern, name) {
+			return true
+		}
+	}
+
+	minifiable := false
+	switch strings.ToLower(path.Ext(name)) {
+	case ".js", ".mjs", ".cjs", ".css":
+		minifiable = true
+	}
+	for _, hunk := range file.Hunks {
+		for _, line := range hunk.Lines {
+			if minifiable && line.Kind != LineContext && len(line.Content) > minifiedLineLength {
+				return true
+			}
+			lineNo := line.NewLineNo
+			if line.Kind == LineRemoved {
+				lineNo = line.OldLineNo
+			}
+			if lineNo <= generatedHeaderLines && generatedMarker.MatchString(line.Content) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Apply marks the generated files of files as Generated
+func (g GeneratedFiles) Apply(files []*DiffResult) {
+	for _, file := range files {
+		if file.SkipReason == "" && g.Matches(file) {
+			file.Generated = true
+		}
+	}
+}
+
+// CollapseGenerated collapses the files marked Generated when there is more
+// than one file to show, reporting whether it collapsed any
+func CollapseGenerated(files []*DiffResult) bool {
+	if len(files) < 2 {
+		return false
+	}
+	collapsed := false
+	for _, file := range files {
+		if file.Generated && !file.Collapsed {
+			file.Collapsed, collapsed = true, true
+		}
+	}
+	return collapsed
+}
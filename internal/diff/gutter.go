@@ -0,0 +1,102 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GutterMode selects which line numbers the gutter shows
+type GutterMode int
+
+const (
+	GutterBoth GutterMode = iota // Old and new line numbers
+	GutterOld                    // Old line numbers only
+	GutterNew                    // New line numbers only
+	GutterNone                   // No line numbers
+)
+
+var gutterModeNames = []string{"both", "old", "new", "none"}
+
+// ParseGutterMode parses a gutter mode name as used in the config file
+func ParseGutterMode(name string) (GutterMode, error) {
+	for i, n := range gutterModeNames {
+		if strings.EqualFold(name, n) {
+			return GutterMode(i), nil
+		}
+	}
+	return GutterBoth, fmt.Errorf("unknown gutter mode %q (expected %s)", name, strings.Join(gutterModeNames, ", "))
+}
+
+// String returns the config name of the mode
+func (g GutterMode) String() string {
+	if g < 0 || int(g) >= len(gutterModeNames) {
+		return "unknown"
+	}
+	return gutterModeNames[g]
+}
+
+// Next returns the mode that follows g when cycling through modes
+func (g GutterMode) Next() GutterMode {
+	return (g + 1) % GutterMode(len(gutterModeNames))
+}
+
+// Gutter configures the line-number column. The zero value shows both line
+// numbers six digits wide, separated by a space.
+type Gutter struct {
+	Mode      GutterMode
+	Width     int    // Digits per line number column
+	Separator string // Between the number columns and before the content
+}
+
+// width returns the digits per column, defaulting to 6
+func (g Gutter) width() int {
+	if g.Width <= 0 {
+		return 6
+	}
+	return g.Width
+}
+
+// separator returns the separator, defaulting to a single space
+func (g Gutter) separator() string {
+	if g.Separator == "" {
+		return " "
+	}
+	return g.Separator
+}
+
+// number formats a line number column, leaving it blank for 0
+func (g Gutter) number(n int) string {
+	if n == 0 {
+		return strings.Repeat(" ", g.width())
+	}
+	return fmt.Sprintf("%*d", g.width(), n)
+}
+
+// unified formats the gutter of a unified line, without the trailing
+// separator. It returns an empty string when no numbers are shown.
+func (g Gutter) unified(dl DiffLine) string {
+	switch g.Mode {
+	case GutterOld:
+		return g.number(dl.OldLineNo)
+	case GutterNew:
+		return g.number(dl.NewLineNo)
+	case GutterNone:
+		return ""
+	default:
+		return g.number(dl.OldLineNo) + g.separator() + g.number(dl.NewLineNo)
+	}
+}
+
+// showsSide reports whether a side-by-side column shows line numbers
+func (g Gutter) showsSide(isLeft bool) bool {
+	switch g.Mode {
+	case GutterOld:
+		return isLeft
+	case GutterNew:
+		return !isLeft
+	case GutterNone:
+		return false
+	default:
+		return true
+	}
+}
@@ -0,0 +1,131 @@
+package diff
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/avgvstvs96/differential/internal/themes"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// fileIcons maps file extensions to Nerd Font icons
+var fileIcons = map[string]string{
+	".go":   "\ue627",
+	".py":   "\ue606",
+	".js":   "\ue60c",
+	".jsx":  "\ue60c",
+	".ts":   "\ue628",
+	".tsx":  "\ue628",
+	".rs":   "\ue7a8",
+	".rb":   "\ue739",
+	".java": "\ue738",
+	".c":    "\ue61e",
+	".h":    "\ue61e",
+	".cpp":  "\ue61d",
+	".html": "\ue60e",
+	".css":  "\ue614",
+	".md":   "\ue609",
+	".json": "\ue60b",
+	".yaml": "\ue615",
+	".yml":  "\ue615",
+	".toml": "\ue615",
+	".sh":   "\ue795",
+	".lock": "\uf023",
+}
+
+// fileNameIcons maps well-known file names to Nerd Font icons
+var fileNameIcons = map[string]string{
+	"Dockerfile":     "\ue7b0",
+	".gitignore":     "\ue702",
+	".gitattributes": "\ue702",
+	".gitmodules":    "\ue702",
+}
+
+// defaultFileIcon is the Nerd Font icon for unrecognized files
+const defaultFileIcon = "\uf15b"
+
+// fileIcon returns the Nerd Font icon for a file
+func fileIcon(name string) string {
+	base := path.Base(name)
+	if icon, ok := fileNameIcons[base]; ok {
+		return icon
+	}
+	if icon, ok := fileIcons[strings.ToLower(path.Ext(base))]; ok {
+		return icon
+	}
+	return defaultFileIcon
+}
+
+// renderFileHeader renders the band above a file of a multi-file diff: its
+// name, language and change counts, with a badge unless it was modified in
+// place
+func (r *Renderer) renderFileHeader(file *DiffResult) string {
+	theme := r.theme
+	segment := func(fg lipgloss.Color) lipgloss.Style {
+		return lipgloss.NewStyle().Background(theme.BackgroundPanel).Foreground(fg)
+	}
+
+	stat := file.Stat()
+	name := stat.Path
+	if stat.OldPath != "" {
+		name = stat.OldPath + " → " + stat.Path
+	}
+
+	var sb strings.Builder
+	sb.WriteString(segment(theme.Text).Bold(true).Render(" ▾ "))
+	if r.opts.Icons {
+		sb.WriteString(segment(theme.Text).Render(fileIcon(stat.Path) + " "))
+	}
+	// Deleted files only have an old version to link to
+	sb.WriteString(r.link(file, segment(theme.Text).Bold(true).Render(name), 0, stat.Status == StatusDeleted))
+	language := themes.LanguageName(path.Base(stat.Path))
+	if lexer := r.language(stat.Path, file); lexer != "" {
+		language = themes.LexerName(lexer)
+	}
+	if language != "" {
+		sb.WriteString(segment(theme.TextMuted).Render("  " + language))
+	}
+	if file.Encoding != "" {
+		sb.WriteString(segment(theme.TextMuted).Render("  encoding: " + file.Encoding))
+	}
+	sb.WriteString(segment(theme.DiffAdded).Render(fmt.Sprintf("  +%d", stat.Additions)))
+	sb.WriteString(segment(theme.DiffRemoved).Render(fmt.Sprintf(" -%d", stat.Deletions)))
+
+	badges := map[string]struct {
+		label string
+		color lipgloss.Color
+	}{
+		StatusAdded:   {"NEW", theme.DiffAdded},
+		StatusDeleted: {"DELETED", theme.DiffRemoved},
+		StatusRenamed: {"RENAMED", theme.SyntaxKeyword},
+		StatusCopied:  {"COPIED", theme.SyntaxKeyword},
+	}
+	if badge, ok := badges[stat.Status]; ok {
+		sb.WriteString(segment(theme.Text).Render("  "))
+		sb.WriteString(lipgloss.NewStyle().
+			Background(badge.color).
+			Foreground(theme.Background).
+			Bold(true).
+			Render(" " + badge.label + " "))
+	}
+	if file.Generated {
+		sb.WriteString(segment(theme.Text).Render("  "))
+		sb.WriteString(lipgloss.NewStyle().
+			Background(theme.TextMuted).
+			Foreground(theme.Background).
+			Bold(true).
+			Render(" GENERATED "))
+	}
+	// Among several files, a dot in the color of the file tree's change heat
+	if heat, ok := r.heat[file]; ok {
+		sb.WriteString(segment(theme.Heat(heat)).Render("  ●"))
+	}
+
+	// Fill the rest of the line so the band spans the full width
+	band := sb.String()
+	if padding := r.opts.Width - VisibleLength(band); padding > 0 {
+		band += segment(theme.Text).Render(strings.Repeat(" ", padding))
+	}
+	return band
+}
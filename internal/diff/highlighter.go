@@ -2,161 +2,290 @@ package diff
 
 import (
 	"fmt"
-	"regexp"
+	"sort"
 	"strings"
+	"unicode"
 	"unicode/utf8"
 
 	"github.com/sergi/go-diff/diffmatchpatch"
 )
 
+// IntralineGranularity selects the units changes within a line are
+// marked in
+type IntralineGranularity int
+
+const (
+	IntralineCharacter IntralineGranularity = iota // Characters, merged into readable runs
+	IntralineWord                                  // Words, runs of whitespace and single symbols
+	IntralineToken                                 // Syntax tokens of the file's language
+)
+
+var intralineGranularityNames = []string{"character", "word", "token"}
+
+// ParseIntralineGranularity parses an intra-line granularity name as used
+// in the config file
+func ParseIntralineGranularity(name string) (IntralineGranularity, error) {
+	for i, n := range intralineGranularityNames {
+		if strings.EqualFold(name, n) {
+			return IntralineGranularity(i), nil
+		}
+	}
+	return IntralineCharacter, fmt.Errorf("unknown intra-line granularity %q (expected %s)", name, strings.Join(intralineGranularityNames, ", "))
+}
+
+// String returns the config name of the granularity
+func (g IntralineGranularity) String() string {
+	if g < 0 || int(g) >= len(intralineGranularityNames) {
+		return "unknown"
+	}
+	return intralineGranularityNames[g]
+}
+
 // HighlightIntralineChanges computes character-level differences within changed lines
 func HighlightIntralineChanges(h *Hunk) {
-	dmp := diffmatchpatch.New()
+	HighlightIntralineUnits(h, nil)
+}
 
-	for i := 0; i < len(h.Lines); i++ {
-		// Look for adjacent removed/added line pairs
-		if i+1 < len(h.Lines) &&
-			h.Lines[i].Kind == LineRemoved &&
-			h.Lines[i+1].Kind == LineAdded {
-
-			oldLine := &h.Lines[i]
-			newLine := &h.Lines[i+1]
-
-			// Compute character-level differences
-			diffs := dmp.DiffMain(oldLine.Content, newLine.Content, false)
-			diffs = dmp.DiffCleanupSemantic(diffs)
-
-			// Build segments for highlighting
-			oldSegments := []Segment{}
-			newSegments := []Segment{}
-			oldPos, newPos := 0, 0
-
-			for _, diff := range diffs {
-				switch diff.Type {
-				case diffmatchpatch.DiffDelete:
-					oldSegments = append(oldSegments, Segment{
-						Start: oldPos,
-						End:   oldPos + len(diff.Text),
-						Type:  LineRemoved,
-						Text:  diff.Text,
-					})
-					oldPos += len(diff.Text)
-
-				case diffmatchpatch.DiffInsert:
-					newSegments = append(newSegments, Segment{
-						Start: newPos,
-						End:   newPos + len(diff.Text),
-						Type:  LineAdded,
-						Text:  diff.Text,
-					})
-					newPos += len(diff.Text)
-
-				case diffmatchpatch.DiffEqual:
-					oldPos += len(diff.Text)
-					newPos += len(diff.Text)
-				}
+// HighlightIntralineUnits computes the differences within changed lines
+// over the units split cuts each line into, so that changes mark whole
+// units. A nil split compares characters. Lines are paired as
+// intralinePairs pairs them.
+func HighlightIntralineUnits(h *Hunk, split func(line string) []string) {
+	for i := range h.Lines {
+		if h.Lines[i].Kind != LineContext {
+			h.Lines[i].Segments = nil
+		}
+	}
+	for _, pair := range intralinePairs(h.Lines) {
+		oldLine := &h.Lines[pair[0]]
+		newLine := &h.Lines[pair[1]]
+
+		diffs := diffUnits(oldLine.Content, newLine.Content, split)
+
+		// Build segments for highlighting, counted in runes
+		oldSegments := []Segment{}
+		newSegments := []Segment{}
+		oldPos, newPos := 0, 0
+
+		for _, diff := range diffs {
+			length := utf8.RuneCountInString(diff.Text)
+			switch diff.Type {
+			case diffmatchpatch.DiffDelete:
+				oldSegments = append(oldSegments, Segment{
+					Start: oldPos,
+					End:   oldPos + length,
+					Type:  LineRemoved,
+					Text:  diff.Text,
+				})
+				oldPos += length
+
+			case diffmatchpatch.DiffInsert:
+				newSegments = append(newSegments, Segment{
+					Start: newPos,
+					End:   newPos + length,
+					Type:  LineAdded,
+					Text:  diff.Text,
+				})
+				newPos += length
+
+			case diffmatchpatch.DiffEqual:
+				oldPos += length
+				newPos += length
 			}
+		}
+
+		// Apply segments to lines
+		oldLine.Segments = oldSegments
+		newLine.Segments = newSegments
+	}
+}
 
-			// Apply segments to lines
-			oldLine.Segments = oldSegments
-			newLine.Segments = newSegments
+// minPairSimilarity is how similar a removed and an added line must be, as
+// Similarity scores them, to be paired wherever they are in the hunk
+const minPairSimilarity = 0.5
+
+// maxPairCandidates caps how many pairs of a removed and an added line of
+// a hunk are scored; larger hunks only pair adjacent lines
+const maxPairCandidates = 2500
+
+// intralinePairs returns the indexes of the removed and added lines whose
+// changes are highlighted against each other. Each removed line pairs with
+// the most similar added line of the hunk, most similar pairs first, so
+// reordered lines and rewritten blocks still pair up. A removed line left
+// over pairs with an added line left over right after it, as git shows a
+// changed line.
+func intralinePairs(lines []DiffLine) [][2]int {
+	var removed, added []int
+	for i, line := range lines {
+		switch line.Kind {
+		case LineRemoved:
+			removed = append(removed, i)
+		case LineAdded:
+			added = append(added, i)
+		}
+	}
 
-			i++ // Skip the next line since we processed it
+	type candidate struct {
+		removed, added int
+		similarity     float64
+	}
+	var candidates []candidate
+	if len(removed)*len(added) <= maxPairCandidates {
+		for _, r := range removed {
+			for _, a := range added {
+				if similarity := Similarity(lines[r].Content, lines[a].Content); similarity >= minPairSimilarity {
+					candidates = append(candidates, candidate{r, a, similarity})
+				}
+			}
+		}
+	}
+	// Ties go to the earlier lines
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].similarity > candidates[j].similarity
+	})
+
+	paired := make(map[int]bool)
+	var pairs [][2]int
+	for _, c := range candidates {
+		if !paired[c.removed] && !paired[c.added] {
+			paired[c.removed], paired[c.added] = true, true
+			pairs = append(pairs, [2]int{c.removed, c.added})
+		}
+	}
+	for i := 0; i+1 < len(lines); i++ {
+		if lines[i].Kind == LineRemoved && lines[i+1].Kind == LineAdded && !paired[i] && !paired[i+1] {
+			paired[i], paired[i+1] = true, true
+			pairs = append(pairs, [2]int{i, i + 1})
 		}
 	}
+	return pairs
 }
 
-// ApplyHighlighting applies ANSI color codes to highlight segments while preserving existing ANSI sequences
-func ApplyHighlighting(content string, segments []Segment, segmentType LineType, highlightStyle string) string {
-	if len(segments) == 0 {
-		return content
-	}
+// unitRuneBase is the first rune units are encoded as for diffing, in a
+// private use plane so they can't clash with other runes diffmatchpatch
+// treats specially
+const unitRuneBase = 0xf0000
 
-	// Find all ANSI sequences in the content
-	ansiRegex := regexp.MustCompile(`\x1b(?:[@-Z\\-_]|\[[0-9?]*(?:;[0-9?]*)*[@-~])`)
-	ansiMatches := ansiRegex.FindAllStringIndex(content, -1)
+// maxUnits is how many distinct units the private use plane can encode
+const maxUnits = 0xfffe
 
-	// Build mapping of visible position to byte position and ANSI sequences
-	visibleToBytePos := make(map[int]int)
-	ansiSequences := make(map[int]string)
-	lastAnsiSeq := "\x1b[0m" // Default reset
+// diffUnits diffs two lines over the units split cuts them into by
+// encoding each distinct unit as one rune, the way lines are diffed.
+// Lines with more distinct units than can be encoded, and a nil split,
+// are diffed by character.
+func diffUnits(oldText, newText string, split func(line string) []string) []diffmatchpatch.Diff {
+	dmp := diffmatchpatch.New()
+	if split == nil {
+		return dmp.DiffCleanupSemantic(dmp.DiffMain(oldText, newText, false))
+	}
 
-	visibleIdx := 0
-	byteIdx := 0
-
-	for byteIdx < len(content) {
-		isAnsi := false
-
-		// Check if current position is an ANSI sequence
-		for _, match := range ansiMatches {
-			if match[0] == byteIdx {
-				ansiSeq := content[match[0]:match[1]]
-				ansiSequences[visibleIdx] = ansiSeq
-				lastAnsiSeq = ansiSeq
-				byteIdx = match[1]
-				isAnsi = true
-				break
+	var units []string
+	index := make(map[string]rune)
+	encode := func(line string) []rune {
+		var runes []rune
+		for _, unit := range split(line) {
+			r, ok := index[unit]
+			if !ok {
+				r = unitRuneBase + rune(len(units))
+				index[unit] = r
+				units = append(units, unit)
 			}
+			runes = append(runes, r)
+		}
+		return runes
+	}
+	oldRunes, newRunes := encode(oldText), encode(newText)
+	if len(units) > maxUnits {
+		return diffUnits(oldText, newText, nil)
+	}
+
+	diffs := dmp.DiffCleanupSemantic(dmp.DiffMainRunes(oldRunes, newRunes, false))
+	for i := range diffs {
+		var sb strings.Builder
+		for _, r := range diffs[i].Text {
+			sb.WriteString(units[r-unitRuneBase])
 		}
+		diffs[i].Text = sb.String()
+	}
+	return diffs
+}
 
-		if !isAnsi {
-			// Map visible position to byte position
-			visibleToBytePos[visibleIdx] = byteIdx
+// Classes of the runes words are split by
+const (
+	symbolRune = iota
+	wordRune
+	spaceRune
+)
 
-			// Store last ANSI sequence for this position
-			if _, exists := ansiSequences[visibleIdx]; !exists {
-				ansiSequences[visibleIdx] = lastAnsiSeq
-			}
+// runeClass returns the class of r for splitting words
+func runeClass(r rune) int {
+	switch {
+	case r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+		return wordRune
+	case unicode.IsSpace(r):
+		return spaceRune
+	}
+	return symbolRune
+}
 
-			// Advance by one rune
-			_, size := utf8.DecodeRuneInString(content[byteIdx:])
-			byteIdx += size
-			visibleIdx++
+// splitWords splits a line into words of letters, digits and underscores,
+// runs of whitespace, and single other characters
+func splitWords(line string) []string {
+	var units []string
+	start, class := 0, symbolRune
+	for i, r := range line {
+		c := runeClass(r)
+		if i > start && (c != class || c == symbolRune) {
+			units = append(units, line[start:i])
+			start = i
 		}
+		class = c
+	}
+	if start < len(line) {
+		units = append(units, line[start:])
 	}
+	return units
+}
 
-	// Apply highlighting
+// ApplyHighlighting applies ANSI color codes to highlight segments while preserving existing ANSI sequences
+func ApplyHighlighting(content string, segments []Segment, segmentType LineType, highlightStyle string) string {
+	if len(segments) == 0 {
+		return content
+	}
+
+	// Walk the content, keeping existing ANSI sequences and remembering the
+	// last one so it can be restored after a highlighted segment
 	var sb strings.Builder
 	inSelection := false
-	currentPos := 0
-
-	for i := 0; i < len(content); {
-		// Check if this is an ANSI sequence
-		isAnsiSeq := false
-		for _, match := range ansiMatches {
-			if match[0] == i {
-				sb.WriteString(content[match[0]:match[1]])
-				i = match[1]
-				isAnsiSeq = true
-				break
-			}
+	lastAnsiSeq := "\x1b[0m" // Default reset
+
+	it := ParseANSI(content).Iter()
+	for it.Next() {
+		tok := it.Token()
+		if tok.Escape {
+			sb.WriteString(tok.Text)
+			lastAnsiSeq = tok.Text
+			continue
 		}
 
-		if !isAnsiSeq {
-			// Check if we're entering or leaving a highlighted segment
-			for _, seg := range segments {
-				if seg.Type == segmentType {
-					if currentPos == seg.Start && !inSelection {
-						sb.WriteString(highlightStyle)
-						inSelection = true
-					}
-					if currentPos == seg.End && inSelection {
-						sb.WriteString("\x1b[0m") // Reset
-						// Restore previous ANSI state
-						if prevAnsi, ok := ansiSequences[currentPos]; ok {
-							sb.WriteString(prevAnsi)
-						}
-						inSelection = false
-					}
+		// Check if we're entering or leaving a highlighted segment
+		for _, seg := range segments {
+			if seg.Type == segmentType {
+				if tok.Pos == seg.Start && !inSelection {
+					sb.WriteString(highlightStyle)
+					inSelection = true
+				}
+				if tok.Pos == seg.End && inSelection {
+					sb.WriteString("\x1b[0m") // Reset
+					// Restore previous ANSI state
+					sb.WriteString(lastAnsiSeq)
+					inSelection = false
 				}
 			}
-
-			// Write the character
-			r, size := utf8.DecodeRuneInString(content[i:])
-			sb.WriteRune(r)
-			currentPos++
-			i += size
 		}
+
+		// Write the character
+		sb.WriteString(tok.Text)
 	}
 
 	// Make sure we reset if still in selection
@@ -174,55 +303,15 @@ func CreateHighlightStyle(fg, bg string) string {
 
 // StripANSI removes all ANSI escape sequences from a string
 func StripANSI(str string) string {
-	ansiRegex := regexp.MustCompile(`\x1b(?:[@-Z\\-_]|\[[0-9?]*(?:;[0-9?]*)*[@-~])`)
 	return ansiRegex.ReplaceAllString(str, "")
 }
 
 // VisibleLength returns the visible length of a string (excluding ANSI sequences)
 func VisibleLength(str string) int {
-	stripped := StripANSI(str)
-	return utf8.RuneCountInString(stripped)
+	return ParseANSI(str).Width()
 }
 
 // TruncateString truncates a string to a visible width, preserving ANSI sequences
 func TruncateString(str string, width int) string {
-	if width <= 0 {
-		return ""
-	}
-
-	ansiRegex := regexp.MustCompile(`\x1b(?:[@-Z\\-_]|\[[0-9?]*(?:;[0-9?]*)*[@-~])`)
-	ansiMatches := ansiRegex.FindAllStringIndex(str, -1)
-
-	var sb strings.Builder
-	visibleCount := 0
-	i := 0
-
-	for i < len(str) && visibleCount < width {
-		// Check if we're at an ANSI sequence
-		isAnsi := false
-		for _, match := range ansiMatches {
-			if match[0] == i {
-				sb.WriteString(str[match[0]:match[1]])
-				i = match[1]
-				isAnsi = true
-				break
-			}
-		}
-
-		if !isAnsi {
-			r, size := utf8.DecodeRuneInString(str[i:])
-			sb.WriteRune(r)
-			i += size
-			visibleCount++
-		}
-	}
-
-	// Copy any remaining ANSI sequences
-	for _, match := range ansiMatches {
-		if match[0] >= i {
-			sb.WriteString(str[match[0]:match[1]])
-		}
-	}
-
-	return sb.String()
-}
\ No newline at end of file
+	return ParseANSI(str).Truncate(width)
+}
@@ -0,0 +1,214 @@
+package diff
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+
+	"github.com/avgvstvs96/differential/internal/themes"
+)
+
+// sgrState is the text style set by SGR escape sequences
+type sgrState struct {
+	fg, bg    string // CSS colors, empty for the default
+	bold      bool
+	italic    bool
+	underline bool
+	reverse   bool
+}
+
+// css returns the inline style of text in this state
+func (s sgrState) css() string {
+	fg, bg := s.fg, s.bg
+	if s.reverse {
+		fg, bg = bg, fg
+		if fg == "" {
+			fg = "var(--bg)"
+		}
+		if bg == "" {
+			bg = "var(--fg)"
+		}
+	}
+	var parts []string
+	if fg != "" {
+		parts = append(parts, "color:"+fg)
+	}
+	if bg != "" {
+		parts = append(parts, "background:"+bg)
+	}
+	if s.bold {
+		parts = append(parts, "font-weight:bold")
+	}
+	if s.italic {
+		parts = append(parts, "font-style:italic")
+	}
+	if s.underline {
+		parts = append(parts, "text-decoration:underline")
+	}
+	return strings.Join(parts, ";")
+}
+
+// apply updates the state with the parameters of an SGR sequence
+func (s *sgrState) apply(params []int) {
+	if len(params) == 0 {
+		params = []int{0}
+	}
+	for i := 0; i < len(params); i++ {
+		switch p := params[i]; {
+		case p == 0:
+			*s = sgrState{}
+		case p == 1:
+			s.bold = true
+		case p == 3:
+			s.italic = true
+		case p == 4:
+			s.underline = true
+		case p == 7:
+			s.reverse = true
+		case p == 22:
+			s.bold = false
+		case p == 23:
+			s.italic = false
+		case p == 24:
+			s.underline = false
+		case p == 27:
+			s.reverse = false
+		case p >= 30 && p <= 37:
+			s.fg = ansiColor(p - 30)
+		case p >= 90 && p <= 97:
+			s.fg = ansiColor(p - 90 + 8)
+		case p >= 40 && p <= 47:
+			s.bg = ansiColor(p - 40)
+		case p >= 100 && p <= 107:
+			s.bg = ansiColor(p - 100 + 8)
+		case p == 39:
+			s.fg = ""
+		case p == 49:
+			s.bg = ""
+		case p == 38 || p == 48:
+			color, n := extendedColor(params[i+1:])
+			i += n
+			if p == 38 {
+				s.fg = color
+			} else {
+				s.bg = color
+			}
+		}
+	}
+}
+
+// extendedColor parses the arguments of a 38 or 48 SGR parameter, either
+// "5;n" or "2;r;g;b", returning the color and how many parameters it used
+func extendedColor(params []int) (string, int) {
+	switch {
+	case len(params) >= 2 && params[0] == 5:
+		return ansiColor(params[1]), 2
+	case len(params) >= 4 && params[0] == 2:
+		return fmt.Sprintf("#%02x%02x%02x", params[1]&0xff, params[2]&0xff, params[3]&0xff), 4
+	}
+	return "", len(params)
+}
+
+// basicColors are the xterm defaults for the 16 standard colors
+var basicColors = [16]string{
+	"#000000", "#cd0000", "#00cd00", "#cdcd00", "#0000ee", "#cd00cd", "#00cdcd", "#e5e5e5",
+	"#7f7f7f", "#ff0000", "#00ff00", "#ffff00", "#5c5cff", "#ff00ff", "#00ffff", "#ffffff",
+}
+
+// ansiColor returns the CSS color of an entry of the 256-color palette
+func ansiColor(n int) string {
+	switch {
+	case n < 0 || n > 255:
+		return ""
+	case n < 16:
+		return basicColors[n]
+	case n < 232:
+		n -= 16
+		level := func(v int) int {
+			if v == 0 {
+				return 0
+			}
+			return 55 + v*40
+		}
+		return fmt.Sprintf("#%02x%02x%02x", level(n/36), level(n/6%6), level(n%6))
+	}
+	gray := 8 + (n-232)*10
+	return fmt.Sprintf("#%02x%02x%02x", gray, gray, gray)
+}
+
+// ANSIToHTML converts text colored with ANSI escape sequences to HTML,
+// styling runs of text with inline CSS. Escape sequences other than colors
+// and text attributes are dropped.
+func ANSIToHTML(text string) string {
+	var sb strings.Builder
+	var state sgrState
+	open := "" // Style of the open span, if any
+	it := ParseANSI(text).Iter()
+	for it.Next() {
+		tok := it.Token()
+		if tok.Escape {
+			if params, ok := strings.CutPrefix(tok.Text, "\x1b["); ok && strings.HasSuffix(params, "m") {
+				state.apply(sgrParams(strings.TrimSuffix(params, "m")))
+			}
+			continue
+		}
+
+		if style := state.css(); style != open {
+			if open != "" {
+				sb.WriteString("</span>")
+			}
+			if style != "" {
+				sb.WriteString(`<span style="` + style + `">`)
+			}
+			open = style
+		}
+		sb.WriteString(html.EscapeString(tok.Text))
+	}
+	if open != "" {
+		sb.WriteString("</span>")
+	}
+	return sb.String()
+}
+
+// sgrParams parses the semicolon-separated parameters of an SGR sequence;
+// empty parameters count as 0
+func sgrParams(s string) []int {
+	if s == "" {
+		return nil
+	}
+	fields := strings.Split(s, ";")
+	params := make([]int, len(fields))
+	for i, field := range fields {
+		params[i], _ = strconv.Atoi(field)
+	}
+	return params
+}
+
+// HTMLDocument wraps rendered output in a standalone HTML page using the
+// theme's text and background colors, or black on white where it has none
+func HTMLDocument(title, output string, theme *themes.ThemeColors) string {
+	text, background := theme.Text, theme.Background
+	if text == "" {
+		text = "#000000"
+	}
+	if background == "" {
+		background = "#ffffff"
+	}
+	return fmt.Sprintf(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>%s</title>
+<style>
+:root { --fg: %s; --bg: %s; }
+body { margin: 0; color: var(--fg); background: var(--bg); }
+pre { margin: 0; padding: 1em; font-family: ui-monospace, SFMono-Regular, Menlo, Consolas, monospace; line-height: 1.3; }
+</style>
+</head>
+<body>
+<pre>%s</pre>
+</body>
+</html>
+`, html.EscapeString(title), text, background, ANSIToHTML(output))
+}
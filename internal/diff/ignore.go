@@ -0,0 +1,129 @@
+package diff
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// SkipIgnored is the SkipReason for files whose every change is ignored
+// and hidden
+const SkipIgnored = "ignored"
+
+// IgnoredMode selects how changes ignored by IgnoreMatchingLines are shown
+type IgnoredMode int
+
+const (
+	IgnoredDim  IgnoredMode = iota // Dimmed, keeping their markers
+	IgnoredHide                    // Left out
+)
+
+var ignoredModeNames = []string{"dim", "hide"}
+
+// ParseIgnoredMode parses an ignored changes mode name as used in the
+// config file
+func ParseIgnoredMode(name string) (IgnoredMode, error) {
+	for i, n := range ignoredModeNames {
+		if strings.EqualFold(name, n) {
+			return IgnoredMode(i), nil
+		}
+	}
+	return IgnoredDim, fmt.Errorf("unknown ignored changes mode %q (expected %s)", name, strings.Join(ignoredModeNames, ", "))
+}
+
+// String returns the config name of the mode
+func (m IgnoredMode) String() string {
+	if m < 0 || int(m) >= len(ignoredModeNames) {
+		return "unknown"
+	}
+	return ignoredModeNames[m]
+}
+
+// IgnoreMatchingLines marks the lines of the changes whose removed and
+// added lines all match one of patterns as Ignored, like diff -I. A change
+// is a run of removed and added lines between context lines. With hide,
+// hunks making only ignored changes fold to their header and files making
+// only ignored changes are skipped as SkipIgnored.
+func IgnoreMatchingLines(files []*DiffResult, patterns []*regexp.Regexp, hide bool) {
+	if len(patterns) == 0 {
+		return
+	}
+	matches := func(content string) bool {
+		for _, re := range patterns {
+			if re.MatchString(content) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, file := range files {
+		if file.SkipReason != "" || file.IsBinary || file.Submodule != nil || file.LFS != nil || file.EOL != nil {
+			continue
+		}
+		ignoredHunks := 0
+		for h := range file.Hunks {
+			hunk := &file.Hunks[h]
+			kept := false // Whether the hunk makes a change that isn't ignored
+			for start := 0; start < len(hunk.Lines); {
+				if hunk.Lines[start].Kind == LineContext {
+					start++
+					continue
+				}
+				end, ignored := start, true
+				for ; end < len(hunk.Lines) && hunk.Lines[end].Kind != LineContext; end++ {
+					ignored = ignored && matches(hunk.Lines[end].Content)
+				}
+				for i := start; i < end; i++ {
+					hunk.Lines[i].Ignored = ignored
+				}
+				kept = kept || !ignored
+				start = end
+			}
+			if hide && !kept {
+				hunk.Ignored = true
+				ignoredHunks++
+			}
+		}
+		if ignoredHunks > 0 && ignoredHunks == len(file.Hunks) {
+			file.SkipReason = SkipIgnored
+		}
+	}
+}
+
+// IgnoredChanges counts the changes of files that IgnoreMatchingLines
+// marked as ignored
+func IgnoredChanges(files []*DiffResult) int {
+	count := 0
+	for _, file := range files {
+		for _, hunk := range file.Hunks {
+			for i, line := range hunk.Lines {
+				if line.Ignored && (i == 0 || !hunk.Lines[i-1].Ignored) {
+					count++
+				}
+			}
+		}
+	}
+	return count
+}
+
+// ignoredLines leaves the ignored changes out of the lines of a hunk when
+// opts.IgnoredChanges hides them. lines is returned as is otherwise.
+func (r *Renderer) ignoredLines(lines []DiffLine) []DiffLine {
+	if r.opts.IgnoredChanges != IgnoredHide {
+		return lines
+	}
+	for i, line := range lines {
+		if !line.Ignored {
+			continue
+		}
+		shown := append(make([]DiffLine, 0, len(lines)), lines[:i]...)
+		for _, line := range lines[i+1:] {
+			if !line.Ignored {
+				shown = append(shown, line)
+			}
+		}
+		return shown
+	}
+	return lines
+}
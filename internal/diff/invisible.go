@@ -0,0 +1,118 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Faint on and off, for the whitespace markers, which keep the colors
+// around them
+const (
+	faintOn  = "\x1b[2m"
+	faintOff = "\x1b[22m"
+)
+
+// invisiblePlaceholders stand in for characters that show as nothing or
+// move the text around them. Each is one rune, like the character it
+// replaces, so intra-line segments still line up.
+var invisiblePlaceholders = map[rune]rune{
+	'\u00a0': '⍽', // No-break space
+	'\u200b': '␣', // Zero-width space
+	'\u200c': '␣', // Zero-width non-joiner
+	'\u200d': '␣', // Zero-width joiner
+	'\u2060': '␣', // Word joiner
+	'\ufeff': '␣', // Zero-width no-break space (byte order mark)
+	'\u200e': '�', // Left-to-right mark
+	'\u200f': '�', // Right-to-left mark
+	'\u061c': '�', // Arabic letter mark
+	'\u202a': '�', // Bidi embeddings, overrides and isolates
+	'\u202b': '�',
+	'\u202c': '�',
+	'\u202d': '�',
+	'\u202e': '�',
+	'\u2066': '�',
+	'\u2067': '�',
+	'\u2068': '�',
+	'\u2069': '�',
+}
+
+// revealControls returns dl with its control, zero-width and bidi
+// characters replaced by visible placeholders, when invisibles are shown.
+// Control characters become their Unicode control pictures, e.g. ␛ for
+// escape. Tabs and spaces are marked after highlighting by
+// revealWhitespace.
+func (r *Renderer) revealControls(dl DiffLine) DiffLine {
+	if !r.opts.ShowInvisibles {
+		return dl
+	}
+	dl.Content = strings.Map(func(c rune) rune {
+		switch {
+		case c == '\t':
+			return c
+		case c < 0x20:
+			return 0x2400 + c
+		case c == 0x7f:
+			return '␡'
+		}
+		if placeholder, ok := invisiblePlaceholders[c]; ok {
+			return placeholder
+		}
+		return c
+	}, dl.Content)
+	return dl
+}
+
+// revealWhitespace marks the tabs and spaces of rendered content, whose
+// text without styles is plain, with → and ·, and puts trailing whitespace
+// on the trailing whitespace color, when invisibles are shown
+func (r *Renderer) revealWhitespace(content, plain string) string {
+	if !r.opts.ShowInvisibles {
+		return content
+	}
+	if trimmed := strings.TrimRight(plain, " \t"); len(trimmed) < len(plain) {
+		trailing := Segment{Start: utf8.RuneCountInString(trimmed), End: utf8.RuneCountInString(plain), Type: LineContext}
+		content = ApplyHighlighting(content, []Segment{trailing}, LineContext, r.trailingSpace)
+	}
+
+	var sb strings.Builder
+	it := ParseANSI(content).Iter()
+	for it.Next() {
+		tok := it.Token()
+		switch {
+		case tok.Escape:
+			sb.WriteString(tok.Text)
+		case tok.Text == "\t":
+			sb.WriteString(faintOn + "→" + faintOff)
+		case tok.Text == " ":
+			sb.WriteString(faintOn + "·" + faintOff)
+		default:
+			sb.WriteString(tok.Text)
+		}
+	}
+	return sb.String()
+}
+
+// crMarker stands for the carriage return of a line ending in "\r\n"
+const crMarker = "␍"
+
+// revealCR marks the carriage return ending dl after its rendered content,
+// when it is all that sets dl apart from the line it replaces or replaced,
+// or when invisibles are shown
+func (r *Renderer) revealCR(content string, dl DiffLine) string {
+	if !dl.CRLF || (!dl.eolOnly && !r.opts.ShowInvisibles) {
+		return content
+	}
+	return content + faintOn + crMarker + faintOff
+}
+
+// trailingWhitespaceStyle returns the ANSI sequence putting trailing
+// whitespace on the removed color, or on red when the theme's isn't a hex
+// color
+func trailingWhitespaceStyle(removed string) string {
+	if !strings.HasPrefix(removed, "#") {
+		return "\x1b[41m"
+	}
+	red, green, blue := hexToRGB(removed)
+	return fmt.Sprintf("\x1b[48;2;%d;%d;%dm", red, green, blue)
+}
@@ -0,0 +1,80 @@
+package diff
+
+import (
+	"path"
+	"sort"
+
+	"github.com/avgvstvs96/differential/internal/themes"
+)
+
+// Languages picks the syntax highlighting of files whose names don't tell
+// their language, such as templates and extensionless scripts
+type Languages struct {
+	Override string            // Lexer of every file; empty detects it per file
+	ByGlob   map[string]string // Lexers by file glob, matched as MatchGlob does
+}
+
+// For returns the lexer name for the file at filePath, or an empty string
+// to detect it from the name. The longest matching glob wins.
+func (l Languages) For(filePath string) string {
+	if l.Override != "" || filePath == "" {
+		return l.Override
+	}
+	patterns := make([]string, 0, len(l.ByGlob))
+	for pattern := range l.ByGlob {
+		patterns = append(patterns, pattern)
+	}
+	sort.Slice(patterns, func(i, j int) bool {
+		if len(patterns[i]) != len(patterns[j]) {
+			return len(patterns[i]) > len(patterns[j])
+		}
+		return patterns[i] < patterns[j]
+	})
+	for _, pattern := range patterns {
+		if MatchGlob(pattern, filePath) {
+			return l.ByGlob[pattern]
+		}
+	}
+	return ""
+}
+
+// headLineCount is how many lines at the top of a file are searched for a
+// shebang or modeline
+const headLineCount = 5
+
+// language returns the lexer name for the file at filePath: the configured
+// one, or for files whose name doesn't tell their language, the one a
+// shebang or modeline at the head of file asks for
+func (r *Renderer) language(filePath string, file *DiffResult) string {
+	if lexer := r.opts.Languages.For(filePath); lexer != "" {
+		return lexer
+	}
+	if file == nil || themes.LanguageName(path.Base(filePath)) != "" {
+		return ""
+	}
+	return themes.DetectLanguage(headLines(file))
+}
+
+// headLines returns the first lines of the new side of file as far as its
+// hunks show them, or of the old side when the file was deleted
+func headLines(file *DiffResult) []string {
+	lines := make([]string, headLineCount)
+	found := false
+	for _, hunk := range file.Hunks {
+		for _, dl := range hunk.Lines {
+			if dl.NewLineNo >= 1 && dl.NewLineNo <= headLineCount && dl.Kind != LineRemoved {
+				lines[dl.NewLineNo-1], found = dl.Content, true
+			}
+		}
+	}
+	if !found {
+		for _, hunk := range file.Hunks {
+			for _, dl := range hunk.Lines {
+				if dl.OldLineNo >= 1 && dl.OldLineNo <= headLineCount && dl.Kind != LineAdded {
+					lines[dl.OldLineNo-1] = dl.Content
+				}
+			}
+		}
+	}
+	return lines
+}
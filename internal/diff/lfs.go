@@ -0,0 +1,133 @@
+package diff
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/avgvstvs96/differential/internal/preview"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// lfsVersionPrefix starts the first line of every Git LFS pointer file
+const lfsVersionPrefix = "version https://git-lfs.github.com/spec/"
+
+// LFSPointer is the object a Git LFS pointer file refers to
+type LFSPointer struct {
+	OID  string // e.g. sha256:4d7a...
+	Size int64  // Size of the object in bytes
+}
+
+// LFSChange is a change to a Git LFS pointer file. Old or New is nil when
+// the file was added or deleted.
+type LFSChange struct {
+	Old *LFSPointer
+	New *LFSPointer
+}
+
+// parseLFS recognizes a diff of Git LFS pointer files, returning nil when
+// either side is something else
+func parseLFS(hunks []Hunk) *LFSChange {
+	var oldLines, newLines []string
+	for _, hunk := range hunks {
+		for _, line := range hunk.Lines {
+			if line.Kind != LineAdded {
+				oldLines = append(oldLines, line.Content)
+			}
+			if line.Kind != LineRemoved {
+				newLines = append(newLines, line.Content)
+			}
+		}
+	}
+
+	oldPointer, ok := parseLFSPointer(oldLines)
+	if !ok {
+		return nil
+	}
+	newPointer, ok := parseLFSPointer(newLines)
+	if !ok || (oldPointer == nil && newPointer == nil) {
+		return nil
+	}
+	return &LFSChange{Old: oldPointer, New: newPointer}
+}
+
+// parseLFSPointer parses the lines of a pointer file. No lines is a missing
+// file, which yields nil and true.
+func parseLFSPointer(lines []string) (*LFSPointer, bool) {
+	if len(lines) == 0 {
+		return nil, true
+	}
+	if !strings.HasPrefix(lines[0], lfsVersionPrefix) {
+		return nil, false
+	}
+
+	pointer := &LFSPointer{}
+	for _, line := range lines[1:] {
+		key, value, ok := strings.Cut(line, " ")
+		switch {
+		case !ok:
+			return nil, false
+		case key == "oid":
+			pointer.OID = value
+		case key == "size":
+			size, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return nil, false
+			}
+			pointer.Size = size
+		case !strings.HasPrefix(key, "ext-"):
+			return nil, false
+		}
+	}
+	if pointer.OID == "" {
+		return nil, false
+	}
+	return pointer, true
+}
+
+// renderLFS renders an LFS pointer change as the object IDs and sizes
+// before and after
+func renderLFS(result *DiffResult, opts RenderOptions) string {
+	change := result.LFS
+	theme := opts.theme()
+	titleStyle := lipgloss.NewStyle().Foreground(theme.Text).Bold(true)
+	labelStyle := lipgloss.NewStyle().Foreground(theme.TextMuted)
+
+	describe := func(pointer *LFSPointer) string {
+		if pointer == nil {
+			return labelStyle.Render("none")
+		}
+		return fmt.Sprintf("%s  %s", shortOID(pointer.OID), preview.FormatSize(int(pointer.Size)))
+	}
+
+	var sb strings.Builder
+	sb.WriteString(titleStyle.Render("LFS object " + result.NewFile))
+	sb.WriteString("\n")
+	sb.WriteString(labelStyle.Render("  before: ") + describe(change.Old) + "\n")
+	sb.WriteString(labelStyle.Render("  after:  ") + describe(change.New))
+	if change.Old != nil && change.New != nil {
+		delta := change.New.Size - change.Old.Size
+		sign := "+"
+		if delta < 0 {
+			sign, delta = "-", -delta
+		}
+		sb.WriteString(labelStyle.Render(fmt.Sprintf(" (%s%s)", sign, preview.FormatSize(int(delta)))))
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// shortOID abbreviates the hash of an LFS object ID, keeping its algorithm
+func shortOID(oid string) string {
+	algorithm, hash, ok := strings.Cut(oid, ":")
+	if !ok {
+		algorithm, hash = "", oid
+	}
+	if len(hash) > 12 {
+		hash = hash[:12] + "…"
+	}
+	if algorithm == "" {
+		return hash
+	}
+	return algorithm + ":" + hash
+}
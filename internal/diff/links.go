@@ -0,0 +1,59 @@
+package diff
+
+import "strings"
+
+// LinkFunc returns the web URL of line of the file at path, on the old side
+// of the diff or the new one; line 0 stands for the whole file. It returns
+// an empty string for versions that can't be linked.
+type LinkFunc func(path string, line int, old bool) string
+
+// hyperlink makes text an OSC 8 hyperlink to url, which terminals without
+// support show as plain text
+func hyperlink(url, text string) string {
+	if url == "" {
+		return text
+	}
+	return "\x1b]8;;" + url + "\x1b\\" + text + "\x1b]8;;\x1b\\"
+}
+
+// link makes rendered a hyperlink to line of a side of file, when
+// RenderOptions.Links is set
+func (r *Renderer) link(file *DiffResult, rendered string, line int, old bool) string {
+	if r.opts.Links == nil || file == nil {
+		return rendered
+	}
+	path := file.NewFile
+	if old {
+		path = file.OldFile
+	}
+	if path == "/dev/null" {
+		return rendered
+	}
+	return hyperlink(r.opts.Links(path, line, old), rendered)
+}
+
+// linkedGutter renders the gutter of a unified line with each number
+// linking to its line, without the trailing separator
+func (r *Renderer) linkedGutter(style *lineStyle, dl DiffLine) string {
+	number := func(n int, old bool) string {
+		rendered := style.lineNumber.Render(r.opts.Gutter.number(n))
+		if n == 0 {
+			return rendered
+		}
+		return r.link(r.file, rendered, n, old)
+	}
+	switch r.opts.Gutter.Mode {
+	case GutterOld:
+		return number(dl.OldLineNo, true)
+	case GutterNew:
+		return number(dl.NewLineNo, false)
+	case GutterNone:
+		return ""
+	default:
+		var sb strings.Builder
+		sb.WriteString(number(dl.OldLineNo, true))
+		sb.WriteString(style.lineNumber.Render(r.opts.Gutter.separator()))
+		sb.WriteString(number(dl.NewLineNo, false))
+		return sb.String()
+	}
+}
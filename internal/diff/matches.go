@@ -0,0 +1,86 @@
+package diff
+
+import (
+	"math"
+	"unicode/utf8"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// LineMatch is a removed line paired with the added line replacing it, as
+// the side-by-side view pairs them
+type LineMatch struct {
+	OldLine    int        `json:"old_line"`
+	NewLine    int        `json:"new_line"`
+	Old        string     `json:"old"`
+	New        string     `json:"new"`
+	Similarity float64    `json:"similarity"` // Share of characters the lines have in common, from 0 to 1
+	Change     ChangeKind `json:"change"`     // Whether only whitespace or letter case changed
+}
+
+// FileMatches holds the line matches of one file
+type FileMatches struct {
+	Path    string      `json:"path"`
+	Matches []LineMatch `json:"matches"`
+}
+
+// Matches returns the removed lines of the hunk that were paired with an
+// added line, in order
+func (h Hunk) Matches() []LineMatch {
+	var matches []LineMatch
+	for _, pair := range PairLines(h.Lines) {
+		if pair.Left == nil || pair.Right == nil || pair.Left.Kind != LineRemoved {
+			continue
+		}
+		matches = append(matches, LineMatch{
+			OldLine:    pair.Left.OldLineNo,
+			NewLine:    pair.Right.NewLineNo,
+			Old:        pair.Left.Content,
+			New:        pair.Right.Content,
+			Similarity: Similarity(pair.Left.Content, pair.Right.Content),
+			Change:     pair.Change(),
+		})
+	}
+	return matches
+}
+
+// Matches returns the line matches of all hunks of the file
+func (d *DiffResult) Matches() []LineMatch {
+	var matches []LineMatch
+	for _, hunk := range d.Hunks {
+		matches = append(matches, hunk.Matches()...)
+	}
+	return matches
+}
+
+// MatchReport returns the line matches of each file, leaving out files
+// hidden by a FileFilter and files without matches
+func MatchReport(files []*DiffResult) []FileMatches {
+	report := []FileMatches{}
+	for _, file := range files {
+		if file.SkipReason == SkipFiltered {
+			continue
+		}
+		if matches := file.Matches(); len(matches) > 0 {
+			report = append(report, FileMatches{Path: file.DisplayName(), Matches: matches})
+		}
+	}
+	return report
+}
+
+// Similarity returns the share of characters a and b have in common, twice
+// the characters left alone by a character diff over the length of both,
+// rounded to three decimals. Two empty strings are identical.
+func Similarity(a, b string) float64 {
+	total := utf8.RuneCountInString(a) + utf8.RuneCountInString(b)
+	if total == 0 {
+		return 1
+	}
+	equal := 0
+	for _, d := range diffmatchpatch.New().DiffMain(a, b, false) {
+		if d.Type == diffmatchpatch.DiffEqual {
+			equal += utf8.RuneCountInString(d.Text)
+		}
+	}
+	return math.Round(2*float64(equal)/float64(total)*1000) / 1000
+}
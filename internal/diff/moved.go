@@ -0,0 +1,171 @@
+package diff
+
+import (
+	"hash/maphash"
+	"strings"
+	"unicode"
+)
+
+// movedMinAlnum is how many letters and digits a block must hold to be
+// shown as moved, as in git, so that lone braces and blank lines aren't
+const movedMinAlnum = 20
+
+// movedMaxCandidates bounds the removed lines a line can be matched with,
+// so that diffs repeating a line thousands of times stay fast
+const movedMaxCandidates = 64
+
+// movedHunk holds the lines of a hunk with what matching them needs,
+// computed once per line
+type movedHunk struct {
+	lines  []DiffLine
+	keys   []string // Content with whitespace collapsed, see movedKey
+	change []int    // Index of the run of changed lines each line is in
+}
+
+// movedLine locates a removed line within its hunk
+type movedLine struct {
+	hunk  *movedHunk
+	index int
+}
+
+// DetectMovedLines marks blocks of lines removed in one place and added
+// elsewhere in files as Moved, like git diff --color-moved. Lines match
+// when they only differ in whitespace, so reindented code counts as moved.
+// Each removed line moves at most once, and a block replaced in place,
+// such as a reindented one, stays a change.
+func DetectMovedLines(files []*DiffResult) {
+	var hunks []*movedHunk
+	for _, file := range movedFiles(files) {
+		for i := range file.Hunks {
+			hunks = append(hunks, newMovedHunk(file.Hunks[i].Lines))
+		}
+	}
+
+	// Removed lines by the hash of their key, as git indexes them
+	seed := maphash.MakeSeed()
+	removed := make(map[uint64][]movedLine)
+	for _, h := range hunks {
+		for j, line := range h.lines {
+			// Blocks don't start with blank lines, but may hold them
+			if line.Kind != LineRemoved || h.keys[j] == "" {
+				continue
+			}
+			hash := maphash.String(seed, h.keys[j])
+			if len(removed[hash]) < movedMaxCandidates {
+				removed[hash] = append(removed[hash], movedLine{h, j})
+			}
+		}
+	}
+
+	for _, h := range hunks {
+		// The removed lines the block of added lines so far repeats, at
+		// the line matching its last line
+		var active []movedLine
+		start := 0
+		for j := 0; j <= len(h.lines); j++ {
+			if len(active) > 0 {
+				next := active[:0]
+				if j < len(h.lines) && h.lines[j].Kind == LineAdded {
+					for _, c := range active {
+						if c.index++; c.matches(h, j) {
+							next = append(next, c)
+						}
+					}
+				}
+				if len(next) > 0 {
+					active = next
+					continue
+				}
+				// Every candidate ended, the one surviving longest with them
+				h.markMoved(start, j, active[0])
+				active = nil
+			}
+			if j == len(h.lines) || h.lines[j].Kind != LineAdded || h.keys[j] == "" {
+				continue
+			}
+			for _, c := range removed[maphash.String(seed, h.keys[j])] {
+				if c.matches(h, j) && !c.sameChange(h, j) {
+					active = append(active, c)
+				}
+			}
+			start = j
+		}
+	}
+}
+
+// newMovedHunk computes the keys and runs of changes of lines, clearing
+// the Moved marks of an earlier detection
+func newMovedHunk(lines []DiffLine) *movedHunk {
+	h := &movedHunk{lines: lines, keys: make([]string, len(lines)), change: make([]int, len(lines))}
+	change := 0
+	for j := range lines {
+		lines[j].Moved = false
+		if lines[j].Kind == LineContext {
+			change++
+			continue
+		}
+		h.keys[j] = movedKey(lines[j].Content)
+		h.change[j] = change
+	}
+	return h
+}
+
+// matches reports whether c is a removed line, not moved yet, repeated by
+// the added line at index j of h
+func (c movedLine) matches(h *movedHunk, j int) bool {
+	if c.index >= len(c.hunk.lines) {
+		return false
+	}
+	old := c.hunk.lines[c.index]
+	return old.Kind == LineRemoved && !old.Moved && c.hunk.keys[c.index] == h.keys[j]
+}
+
+// sameChange reports whether c belongs to the change that the added line
+// at index j of h is part of, with no context line between them
+func (c movedLine) sameChange(h *movedHunk, j int) bool {
+	return c.hunk == h && c.index < len(h.lines) && h.change[c.index] == h.change[j]
+}
+
+// markMoved marks the added lines from start to end, and the removed lines
+// they repeat up to last, the removed line matching the last of them, as
+// moved when they hold enough letters and digits to count as a block
+func (h *movedHunk) markMoved(start, end int, last movedLine) {
+	if alnumCount(h.lines[start:end]) < movedMinAlnum {
+		return
+	}
+	from := last.index - (end - 1 - start)
+	for k := 0; k < end-start; k++ {
+		h.lines[start+k].Moved = true
+		last.hunk.lines[from+k].Moved = true
+	}
+}
+
+// movedFiles returns the files of files whose lines can move
+func movedFiles(files []*DiffResult) []*DiffResult {
+	var text []*DiffResult
+	for _, file := range files {
+		if file.SkipReason == "" && !file.IsBinary && file.Submodule == nil && file.LFS == nil && file.EOL == nil {
+			text = append(text, file)
+		}
+	}
+	return text
+}
+
+// movedKey returns the content of a line with its whitespace collapsed,
+// for matching moved lines
+func movedKey(content string) string {
+	return strings.Join(strings.Fields(content), " ")
+}
+
+// alnumCount counts the letters and digits of lines
+func alnumCount(lines []DiffLine) int {
+	count := 0
+	for _, line := range lines {
+		for _, r := range line.Content {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				count++
+			}
+		}
+	}
+	return count
+}
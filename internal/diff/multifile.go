@@ -0,0 +1,135 @@
+package diff
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// commitHeaderRegex matches the line git log -p and git show print before
+// each commit's metadata
+var commitHeaderRegex = regexp.MustCompile(`^commit [0-9a-f]{7,64}\b`)
+
+// ParseMultiFileDiff parses a diff that may cover several files (git diff,
+// diff -ru, concatenated patches) into one DiffResult per file
+func ParseMultiFileDiff(diffText string) ([]*DiffResult, error) {
+	return ParseMultiFileDiffContext(context.Background(), diffText)
+}
+
+// ParseMultiFileDiffContext is ParseMultiFileDiff, stopping between files
+// with ctx's error when ctx is done
+func ParseMultiFileDiffContext(ctx context.Context, diffText string) ([]*DiffResult, error) {
+	return ParseMultiFileDiffHook(ctx, diffText, nil)
+}
+
+// FileHook receives each file of a multi-file diff as soon as it is parsed,
+// along with its stats, so embedders can compute their own metrics (e.g. the
+// test-to-code ratio of a change) without going over the files again. Hooks
+// run before path filters are applied and must not modify file.
+type FileHook func(file *DiffResult, stat FileStat)
+
+// ParseMultiFileDiffHook is ParseMultiFileDiffContext, passing each file to
+// hook, if not nil, as it is parsed
+func ParseMultiFileDiffHook(ctx context.Context, diffText string, hook FileHook) ([]*DiffResult, error) {
+	var results []*DiffResult
+	for _, chunk := range SplitFileDiffs(diffText) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		result, err := ParseUnifiedDiff(chunk)
+		if err != nil {
+			return nil, err
+		}
+		// Skip preambles such as commit headers that carry no file
+		if result.OldFile == "" && result.NewFile == "" && len(result.Hunks) == 0 && !result.IsBinary {
+			continue
+		}
+		if hook != nil {
+			hook(result, result.Stat())
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// SplitFileDiffs splits a multi-file diff into per-file chunks. Hunk line
+// counts are tracked so that removed lines starting with "--" are never
+// mistaken for the header of the next file.
+func SplitFileDiffs(diffText string) []string {
+	if strings.TrimSpace(diffText) == "" {
+		return nil
+	}
+
+	var chunks []string
+	var current strings.Builder
+	seenNewHeader := false
+	remainingOld, remainingNew := 0, 0
+
+	flush := func() {
+		if strings.TrimSpace(current.String()) != "" {
+			chunks = append(chunks, current.String())
+		}
+		current.Reset()
+		seenNewHeader = false
+	}
+
+	for _, line := range strings.SplitAfter(diffText, "\n") {
+		content := strings.TrimRight(line, "\r\n")
+
+		// Inside a hunk body every line belongs to the current file
+		if remainingOld > 0 || remainingNew > 0 {
+			switch {
+			case strings.HasPrefix(content, "+"):
+				remainingNew--
+			case strings.HasPrefix(content, "-"):
+				remainingOld--
+			case strings.HasPrefix(content, "\\"):
+				// "\ No newline at end of file" doesn't count
+			default:
+				remainingOld--
+				remainingNew--
+			}
+			current.WriteString(line)
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(content, "diff "), commitHeaderRegex.MatchString(content):
+			// git log -p and git show start each commit with a "commit" line
+			flush()
+		case strings.HasPrefix(content, "--- ") && seenNewHeader:
+			// A new file header without a preceding "diff" line
+			flush()
+		case strings.HasPrefix(content, "+++ "):
+			seenNewHeader = true
+		case strings.HasPrefix(content, "@@ "):
+			if matches := hunkHeaderRegex.FindStringSubmatch(content); matches != nil {
+				remainingOld = hunkCount(matches[2])
+				remainingNew = hunkCount(matches[4])
+			}
+		}
+		current.WriteString(line)
+	}
+	flush()
+
+	return chunks
+}
+
+// hunkCount parses a hunk header line count, which defaults to 1 when omitted
+func hunkCount(s string) int {
+	if s == "" {
+		return 1
+	}
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// DisplayName returns the path that best identifies the file, preferring the
+// new name unless the file was deleted
+func (d *DiffResult) DisplayName() string {
+	if d.NewFile == "" || d.NewFile == "/dev/null" {
+		return d.OldFile
+	}
+	return d.NewFile
+}
@@ -2,6 +2,7 @@ package diff
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
 	"regexp"
 	"strconv"
@@ -10,11 +11,17 @@ import (
 
 var (
 	// Regular expressions for parsing diff format
-	fileHeaderRegex = regexp.MustCompile(`^diff --git a/(.+) b/(.+)$`)
-	oldFileRegex    = regexp.MustCompile(`^--- (?:a/)?(.+?)(?:\s+\d{4}-\d{2}-\d{2}.*)?$`)
-	newFileRegex    = regexp.MustCompile(`^\+\+\+ (?:b/)?(.+?)(?:\s+\d{4}-\d{2}-\d{2}.*)?$`)
-	hunkHeaderRegex = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
-	binaryFileRegex = regexp.MustCompile(`^Binary files? .* differ$`)
+	fileHeaderRegex  = regexp.MustCompile(`^diff --git a/(.+) b/(.+)$`)
+	oldFileRegex     = regexp.MustCompile(`^--- (?:a/)?(.+?)(?:\s+\d{4}-\d{2}-\d{2}.*)?$`)
+	newFileRegex     = regexp.MustCompile(`^\+\+\+ (?:b/)?(.+?)(?:\s+\d{4}-\d{2}-\d{2}.*)?$`)
+	hunkHeaderRegex  = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+	binaryFileRegex  = regexp.MustCompile(`^Binary files? .* differ$`)
+	binaryNamesRegex = regexp.MustCompile(`^Binary files (?:a/)?(.+) and (?:b/)?(.+) differ$`)
+	indexRegex       = regexp.MustCompile(`^index ([0-9a-f]+)\.\.([0-9a-f]+)(?: ([0-7]+))?`)
+
+	// Git quotes names containing special characters (and non-ASCII
+	// characters unless core.quotepath is off) as C strings
+	quotedHeaderRegex = regexp.MustCompile(`^diff --git ("(?:[^"\\]|\\.)*"|\S+) ("(?:[^"\\]|\\.)*"|\S+)$`)
 )
 
 // ParseUnifiedDiff parses a unified diff format string into a DiffResult
@@ -28,31 +35,107 @@ func ParseUnifiedDiff(diffText string) (*DiffResult, error) {
 	}
 
 	scanner := bufio.NewScanner(strings.NewReader(diffText))
+	scanner.Split(scanDiffLines)
 	var currentHunk *Hunk
 	var oldLine, newLine int
 	inFileHeader := true
 
 	for scanner.Scan() {
 		line := scanner.Text()
+		if inFileHeader || line == "" || !strings.ContainsRune("+- ", rune(line[0])) {
+			// Only content lines keep their carriage returns, for parseDiffLine
+			line = strings.TrimSuffix(line, "\r")
+		}
 
 		// Check for binary file
 		if binaryFileRegex.MatchString(line) {
 			result.IsBinary = true
+			// Git binary diffs have no ---/+++ headers, so take names from here
+			if matches := binaryNamesRegex.FindStringSubmatch(line); matches != nil {
+				if result.OldFile == "" {
+					result.OldFile = unquotePath(matches[1], "a/")
+				}
+				if result.NewFile == "" {
+					result.NewFile = unquotePath(matches[2], "b/")
+				}
+			}
 			return result, nil
 		}
 
 		// File headers
 		if inFileHeader {
-			if matches := oldFileRegex.FindStringSubmatch(line); matches != nil {
+			// Git names both sides up front, which covers diffs without ---/+++ lines
+			if strings.Contains(line, `"`) {
+				if matches := quotedHeaderRegex.FindStringSubmatch(line); matches != nil {
+					result.OldFile = unquotePath(matches[1], "a/")
+					result.NewFile = unquotePath(matches[2], "b/")
+					continue
+				}
+			}
+			if matches := fileHeaderRegex.FindStringSubmatch(line); matches != nil {
 				result.OldFile = matches[1]
+				result.NewFile = matches[2]
+				continue
+			}
+			if matches := oldFileRegex.FindStringSubmatch(line); matches != nil {
+				result.OldFile = unquotePath(matches[1], "a/")
 				continue
 			}
 			if matches := newFileRegex.FindStringSubmatch(line); matches != nil {
-				result.NewFile = matches[1]
+				result.NewFile = unquotePath(matches[1], "b/")
 				inFileHeader = false
 				continue
 			}
-			// Skip other header lines (index, mode, etc.)
+			if matches := indexRegex.FindStringSubmatch(line); matches != nil {
+				result.OldIndex = matches[1]
+				result.NewIndex = matches[2]
+				// The mode is only given here when it didn't change
+				if matches[3] != "" {
+					result.OldMode, result.NewMode = matches[3], matches[3]
+				}
+				continue
+			}
+			if name, ok := strings.CutPrefix(line, "rename from "); ok {
+				result.OldFile = unquotePath(name, "")
+				result.Renamed = true
+				continue
+			}
+			if name, ok := strings.CutPrefix(line, "rename to "); ok {
+				result.NewFile = unquotePath(name, "")
+				result.Renamed = true
+				continue
+			}
+			if name, ok := strings.CutPrefix(line, "copy from "); ok {
+				result.OldFile = unquotePath(name, "")
+				result.Copied = true
+				continue
+			}
+			if name, ok := strings.CutPrefix(line, "copy to "); ok {
+				result.NewFile = unquotePath(name, "")
+				result.Copied = true
+				continue
+			}
+			// Empty and binary files have no ---/+++ lines to tell they
+			// were added or deleted
+			if mode, ok := strings.CutPrefix(line, "new file mode "); ok {
+				result.OldFile = "/dev/null"
+				result.NewMode = mode
+				continue
+			}
+			if mode, ok := strings.CutPrefix(line, "deleted file mode "); ok {
+				result.NewFile = "/dev/null"
+				result.OldMode = mode
+				continue
+			}
+			if mode, ok := strings.CutPrefix(line, "old mode "); ok {
+				result.OldMode = mode
+				continue
+			}
+			if mode, ok := strings.CutPrefix(line, "new mode "); ok {
+				result.NewMode = mode
+				continue
+			}
+			// Skip other header lines (mode, etc.)
 			continue
 		}
 
@@ -74,8 +157,11 @@ func ParseUnifiedDiff(diffText string) (*DiffResult, error) {
 			continue
 		}
 
-		// Skip "\ No newline at end of file"
+		// "\ No newline at end of file" applies to the line before it
 		if strings.HasPrefix(line, "\\") {
+			if currentHunk != nil && len(currentHunk.Lines) > 0 {
+				currentHunk.Lines[len(currentHunk.Lines)-1].NoNewline = true
+			}
 			continue
 		}
 
@@ -91,9 +177,33 @@ func ParseUnifiedDiff(diffText string) (*DiffResult, error) {
 		result.Hunks = append(result.Hunks, *currentHunk)
 	}
 
+	// Submodule bumps and LFS pointers read better summarized
+	result.Submodule = parseSubmodule(result.Hunks)
+	if result.Submodule == nil {
+		result.LFS = parseLFS(result.Hunks)
+	}
+	if result.Submodule == nil && result.LFS == nil {
+		result.EOL = parseEOL(result.Hunks)
+	}
+
 	return result, scanner.Err()
 }
 
+// scanDiffLines splits diff text into lines like bufio.ScanLines, but keeps
+// carriage returns so CRLF line endings can be told apart
+func scanDiffLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
 // parseDiffLine parses a single line from a diff
 func parseDiffLine(line string, oldLine, newLine *int) DiffLine {
 	if len(line) == 0 {
@@ -138,6 +248,9 @@ func parseDiffLine(line string, oldLine, newLine *int) DiffLine {
 		(*newLine)++
 	}
 
+	if content, ok := strings.CutSuffix(dl.Content, "\r"); ok {
+		dl.Content, dl.CRLF = content, true
+	}
 	return dl
 }
 
@@ -211,6 +324,20 @@ func GetFileExtension(filename string) string {
 	return ""
 }
 
+// unquotePath decodes a path git quoted as a C string, such as
+// "a/caf\303\251.txt", and removes its a/ or b/ prefix. Unquoted paths are
+// returned as they are.
+func unquotePath(name, prefix string) string {
+	if len(name) < 2 || name[0] != '"' || name[len(name)-1] != '"' {
+		return name
+	}
+	unquoted, err := strconv.Unquote(name)
+	if err != nil {
+		return name
+	}
+	return strings.TrimPrefix(unquoted, prefix)
+}
+
 // CountChanges returns the number of additions and deletions in a diff
 func (d *DiffResult) CountChanges() (additions, deletions int) {
 	for _, hunk := range d.Hunks {
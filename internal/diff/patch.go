@@ -0,0 +1,97 @@
+package diff
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PartialPatch returns a patch that makes only the changes of result that
+// selected accepts, like editing a hunk in git add --patch: unselected
+// removed lines become context and unselected added lines are left out.
+// Hunks without selected changes are dropped. The patch applies to the old
+// version of the file, e.g. the index for unstaged changes.
+func PartialPatch(result *DiffResult, selected func(DiffLine) bool) (string, error) {
+	if result.IsBinary || result.Submodule != nil {
+		return "", fmt.Errorf("%s has no lines to select", result.DisplayName())
+	}
+	// The lines no longer hold the bytes of the file
+	if result.Encoding != "" {
+		return "", fmt.Errorf("%s is shown transcoded from %s and can't be staged by line", result.DisplayName(), result.Encoding)
+	}
+
+	var body strings.Builder
+	keepsLines := false // Whether old lines survive, so a deletion becomes an edit
+	offset := 0         // How far the selected hunks so far moved later lines
+	for _, hunk := range result.Hunks {
+		matches := hunkHeaderRegex.FindStringSubmatch(hunk.Header)
+		if matches == nil {
+			return "", fmt.Errorf("malformed hunk header %q", hunk.Header)
+		}
+		oldStart, _ := strconv.Atoi(matches[1])
+		oldCount := 1
+		if matches[2] != "" {
+			oldCount, _ = strconv.Atoi(matches[2])
+		}
+
+		var lines strings.Builder
+		changes, newCount := 0, 0
+		for _, line := range hunk.Lines {
+			// How the line ends in the patch, including git's note for a
+			// last line without a newline
+			eol := "\n"
+			if line.CRLF {
+				eol = "\r\n"
+			}
+			if line.NoNewline {
+				eol += "\\ No newline at end of file\n"
+			}
+			switch {
+			case line.Kind == LineContext:
+				lines.WriteString(" " + line.Content + eol)
+				newCount++
+			case !selected(line) && line.Kind == LineAdded:
+			case !selected(line):
+				lines.WriteString(" " + line.Content + eol)
+				newCount++
+				keepsLines = true
+			case line.Kind == LineAdded:
+				lines.WriteString("+" + line.Content + eol)
+				newCount++
+				changes++
+			default:
+				lines.WriteString("-" + line.Content + eol)
+				changes++
+			}
+		}
+		if changes == 0 {
+			continue
+		}
+
+		// Like git, an empty side starts at the line before it
+		newStart := oldStart + offset
+		if oldCount == 0 && newCount > 0 {
+			newStart++
+		} else if oldCount > 0 && newCount == 0 {
+			newStart--
+		}
+		fmt.Fprintf(&body, "@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount)
+		body.WriteString(lines.String())
+		offset += newCount - oldCount
+	}
+	if body.Len() == 0 {
+		return "", fmt.Errorf("no changes of %s are selected", result.DisplayName())
+	}
+
+	oldName, newName := "a/"+result.OldFile, "b/"+result.NewFile
+	if result.OldFile == "/dev/null" {
+		oldName = result.OldFile
+	}
+	if result.NewFile == "/dev/null" {
+		newName = "/dev/null"
+		if keepsLines {
+			newName = "b/" + result.OldFile
+		}
+	}
+	return fmt.Sprintf("--- %s\n+++ %s\n%s", oldName, newName, body.String()), nil
+}
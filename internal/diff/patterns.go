@@ -0,0 +1,98 @@
+package diff
+
+import (
+	"sort"
+	"strings"
+)
+
+// SkipApproved is the SkipReason for files whose every hunk makes an
+// approved change pattern
+const SkipApproved = "approved"
+
+// ChangePattern is a change made by one or more hunks, such as one rewrite
+// of a codemod or regex sweep
+type ChangePattern struct {
+	Key     string   // Identifies the change, see PatternKey
+	Removed []string // The removed lines, without indentation
+	Added   []string // The added lines, without indentation
+	Hunks   int      // How many hunks make the change
+	Files   []string // Display names of the files making it
+}
+
+// ChangePatterns groups the hunks of files by the change they make, most
+// frequent first. Unlike duplicate folding, context lines and indentation
+// don't matter, so the same edit in different surroundings is one pattern.
+func ChangePatterns(files []*DiffResult) []ChangePattern {
+	var patterns []ChangePattern
+	index := make(map[string]int)
+	for _, file := range files {
+		if file.SkipReason == SkipFiltered || file.IsBinary || file.Submodule != nil || file.LFS != nil || file.EOL != nil {
+			continue
+		}
+		name := file.DisplayName()
+		for _, hunk := range file.Hunks {
+			key := PatternKey(hunk)
+			i, ok := index[key]
+			if !ok {
+				i = len(patterns)
+				index[key] = i
+				pattern := ChangePattern{Key: key}
+				for _, line := range hunk.Lines {
+					switch line.Kind {
+					case LineRemoved:
+						pattern.Removed = append(pattern.Removed, strings.TrimSpace(line.Content))
+					case LineAdded:
+						pattern.Added = append(pattern.Added, strings.TrimSpace(line.Content))
+					}
+				}
+				patterns = append(patterns, pattern)
+			}
+			pattern := &patterns[i]
+			pattern.Hunks++
+			if n := len(pattern.Files); n == 0 || pattern.Files[n-1] != name {
+				pattern.Files = append(pattern.Files, name)
+			}
+		}
+	}
+	sort.SliceStable(patterns, func(i, j int) bool {
+		return patterns[i].Hunks > patterns[j].Hunks
+	})
+	return patterns
+}
+
+// PatternKey identifies the change a hunk makes by its removed and added
+// lines, ignoring context and indentation
+func PatternKey(hunk Hunk) string {
+	var sb strings.Builder
+	for _, line := range hunk.Lines {
+		if line.Kind == LineContext {
+			continue
+		}
+		sb.WriteByte(" +-"[line.Kind])
+		sb.WriteString(strings.TrimSpace(line.Content))
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// MarkApproved flags the hunks making the approved patterns, by key, which
+// then render as just their header, and skips files with nothing else with
+// SkipApproved. It can be called again as approvals change.
+func MarkApproved(files []*DiffResult, approved map[string]bool) {
+	for _, file := range files {
+		if file.SkipReason == SkipApproved {
+			file.SkipReason = ""
+		}
+		count := 0
+		for i := range file.Hunks {
+			hunk := &file.Hunks[i]
+			hunk.Approved = approved[PatternKey(*hunk)]
+			if hunk.Approved {
+				count++
+			}
+		}
+		if file.SkipReason == "" && count > 0 && count == len(file.Hunks) {
+			file.SkipReason = SkipApproved
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package diff
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// RegenerateContext rebuilds the hunks of result with contextLines lines of
+// context around its changes, as git diff -U would show them. The changes
+// keep the lines git paired them with; the lines around them are read from
+// the new version of the file, which load returns as RenderOptions.LoadBlob
+// does. Hunks closer than twice the context merge, and those further apart
+// split. Files without lines, and deleted files, which are a single hunk
+// already, are left as they are.
+//
+// It fails when the new version can't be read or no longer matches the
+// diff, or when changes between the hunks were left out, e.g. by a search
+// filter, as far as the line numbers and the old version tell.
+func RegenerateContext(result *DiffResult, contextLines int, load func(path, id string) ([]byte, error)) error {
+	if result.IsBinary || result.Submodule != nil || result.LFS != nil || result.EOL != nil || len(result.Hunks) == 0 {
+		return nil
+	}
+	if load == nil {
+		return fmt.Errorf("the new version of %s can't be read", result.DisplayName())
+	}
+	data, err := loadVersion(load, result.NewFile, result.NewIndex)
+	if err != nil {
+		return fmt.Errorf("failed to read the new version of %s: %w", result.DisplayName(), err)
+	}
+	if data == nil {
+		return nil
+	}
+	lines, err := wholeFileLines(result, versionLines(result.NewFile, data, result.Encoding))
+	if err != nil {
+		return err
+	}
+
+	if err := checkOldVersion(result, lines, load); err != nil {
+		return err
+	}
+	result.Hunks = hunksWithContext(lines, result.Hunks, max(contextLines, 0))
+	return nil
+}
+
+// checkOldVersion checks that the lines between the hunks are the same in
+// the old version of result, as they are unless hunks were left out. It
+// only reads the old version from the blob the diff names, since load falls
+// back to the new version on disk.
+func checkOldVersion(result *DiffResult, lines []fileLine, load func(path, id string) ([]byte, error)) error {
+	if result.OldIndex == "" {
+		return nil
+	}
+	data, err := loadVersion(load, result.OldFile, result.OldIndex)
+	if err != nil || data == nil {
+		return nil
+	}
+	old := versionLines(result.OldFile, data, result.Encoding)
+	for _, line := range lines {
+		if line.hunk < 0 && (line.OldLineNo > len(old) || old[line.OldLineNo-1].Content != line.Content) {
+			return fmt.Errorf("%s has changes outside its hunks", result.DisplayName())
+		}
+	}
+	return nil
+}
+
+// versionLines returns the lines of a version of a file as context lines
+func versionLines(path string, data []byte, encoding string) []DiffLine {
+	version := FileView(path, string(decodeText(data, encoding)))
+	if len(version.Hunks) == 0 {
+		return nil
+	}
+	return version.Hunks[0].Lines
+}
+
+// fileLine is a line of the whole diff of a file, with the hunk of the diff
+// it came from, or -1 for context read from the file
+type fileLine struct {
+	DiffLine
+	hunk int
+}
+
+// wholeFileLines lays the hunks of result over source, the lines of its new
+// version as context lines, numbering the old side of the lines between
+// them
+func wholeFileLines(result *DiffResult, source []DiffLine) ([]fileLine, error) {
+	var lines []fileLine
+	oldNext, newNext := 1, 1 // Lines of either side not yet in lines
+	fill := func(upTo int) {
+		for ; newNext < upTo && newNext <= len(source); newNext++ {
+			line := source[newNext-1]
+			line.OldLineNo = oldNext
+			lines = append(lines, fileLine{DiffLine: line, hunk: -1})
+			oldNext++
+		}
+	}
+
+	for i, hunk := range result.Hunks {
+		oldStart, newStart, ok := hunkStarts(hunk.Header)
+		if !ok {
+			return nil, fmt.Errorf("malformed hunk header %q", hunk.Header)
+		}
+		if oldStart-newStart != oldNext-newNext || newStart < newNext {
+			return nil, fmt.Errorf("%s has changes outside its hunks", result.DisplayName())
+		}
+		fill(newStart)
+		for _, line := range hunk.Lines {
+			if line.Kind != LineRemoved {
+				if line.NewLineNo > len(source) || source[line.NewLineNo-1].Content != line.Content {
+					return nil, fmt.Errorf("the new version of %s no longer matches the diff", result.DisplayName())
+				}
+				newNext = line.NewLineNo + 1
+			}
+			if line.Kind != LineAdded {
+				oldNext = line.OldLineNo + 1
+			}
+			lines = append(lines, fileLine{DiffLine: line, hunk: i})
+		}
+	}
+	fill(len(source) + 1)
+	return lines, nil
+}
+
+// hunkStarts returns the first line of either side of the hunk with header,
+// where an empty side starts after the line git names
+func hunkStarts(header string) (oldStart, newStart int, ok bool) {
+	matches := hunkHeaderRegex.FindStringSubmatch(header)
+	if matches == nil {
+		return 0, 0, false
+	}
+	oldStart, _ = strconv.Atoi(matches[1])
+	newStart, _ = strconv.Atoi(matches[3])
+	if matches[2] == "0" {
+		oldStart++
+	}
+	if matches[4] == "0" {
+		newStart++
+	}
+	return oldStart, newStart, true
+}
+
+// hunksWithContext cuts lines into hunks holding their changes and up to
+// contextLines lines around them. Each hunk keeps the function context
+// git printed for the hunk its first change came from.
+func hunksWithContext(lines []fileLine, original []Hunk, contextLines int) []Hunk {
+	shown := make([]bool, len(lines))
+	for i, line := range lines {
+		if line.Kind == LineContext {
+			continue
+		}
+		for j := max(i-contextLines, 0); j <= min(i+contextLines, len(lines)-1); j++ {
+			shown[j] = true
+		}
+	}
+
+	var hunks []Hunk
+	oldSeen, newSeen := 0, 0 // Lines of either side before lines[i]
+	for i := 0; i < len(lines); {
+		if !shown[i] {
+			if lines[i].Kind != LineAdded {
+				oldSeen++
+			}
+			if lines[i].Kind != LineRemoved {
+				newSeen++
+			}
+			i++
+			continue
+		}
+
+		var hunk Hunk
+		from, oldCount, newCount := -1, 0, 0
+		for ; i < len(lines) && shown[i]; i++ {
+			if lines[i].Kind != LineAdded {
+				oldCount++
+			}
+			if lines[i].Kind != LineRemoved {
+				newCount++
+			}
+			if from < 0 && lines[i].Kind != LineContext {
+				from = lines[i].hunk
+			}
+			hunk.Lines = append(hunk.Lines, lines[i].DiffLine)
+		}
+
+		hunk.Header = fmt.Sprintf("@@ -%s +%s @@", hunkRange(oldSeen+1, oldCount), hunkRange(newSeen+1, newCount))
+		if from >= 0 {
+			header := original[from].Header
+			hunk.Header += strings.TrimPrefix(header, hunkHeaderRegex.FindString(header))
+		}
+		hunks = append(hunks, hunk)
+		oldSeen += oldCount
+		newSeen += newCount
+	}
+	return hunks
+}
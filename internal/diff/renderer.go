@@ -1,161 +1,474 @@
 package diff
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"regexp"
+	"runtime"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"unicode/utf8"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/avgvstvs96/differential/internal/themes"
 )
 
-// RenderUnifiedDiff renders a diff in unified format with syntax highlighting
-func RenderUnifiedDiff(result *DiffResult, opts RenderOptions) string {
-	if result.IsBinary {
-		return fmt.Sprintf("Binary files %s and %s differ\n", result.OldFile, result.NewFile)
-	}
+// Renderer renders diffs with one set of options. Styles derived from the
+// theme and syntax highlighters are built once and reused across renders,
+// and each file's output is cached, so keep a Renderer around when the same
+// diff is re-rendered (e.g. on every TUI frame). Call Reset after modifying
+// a rendered DiffResult. A Renderer is not safe for concurrent use.
+type Renderer struct {
+	opts  RenderOptions
+	theme *themes.ThemeColors
 
-	// Initialize themes if not already done
-	themes.Initialize()
-	theme := themes.GetCurrentTheme()
+	// Styles indexed by LineType, and of moved and ignored lines
+	lineStyles    [3]lineStyle
+	movedStyles   [3]lineStyle
+	ignoredStyles [3]lineStyle
 
-	var sb strings.Builder
+	hunkHeaderStyle  lipgloss.Style
+	hunkContextStyle lipgloss.Style
+	hunkAddedStyle   lipgloss.Style
+	hunkRemovedStyle lipgloss.Style
+	fileHeaderStyle  lipgloss.Style
+	skippedStyle     lipgloss.Style
+	emptyStyle       lipgloss.Style
+	matchHighlight   string // ANSI sequence for highlight pattern matches
+	trailingSpace    string // ANSI sequence for trailing whitespace, with ShowInvisibles
+	changeMarkStyles [len(changeMarkGlyphs)]lipgloss.Style
 
-	// Apply intra-line highlighting to all hunks
-	for i := range result.Hunks {
-		HighlightIntralineChanges(&result.Hunks[i])
+	highlighters map[string]*themes.Highlighter
+	tokens       map[int][]SemanticToken // Semantic tokens of the file being rendered, by line
+	file         *DiffResult             // The file being rendered, nil for three-way diffs
+	rendered     map[*DiffResult]string
+	rowCounts    map[*DiffResult]int            // Lines of the rendered output, counted once
+	fullFiles    map[*DiffResult][]fullFileLine // Nil for files shown as hunks
+	heat         map[*DiffResult]float64        // Change heat of the files rendered together, see ChangeHeat
+	hunkRows     *hunkRowsCache                 // Result of HunkRows, nil until called
+	buf          bytes.Buffer
+	ctx          context.Context // Stops the render in progress when done; nil while idle
+}
+
+// lineStyle holds the precomputed styles for one kind of diff line
+type lineStyle struct {
+	marker     string
+	bg         lipgloss.Style
+	lineNumber lipgloss.Style
+	markerBold lipgloss.Style
+	annotation lipgloss.Style // Notes after the content, like noNewlineMarker
+	bar        lipgloss.Style // Bar marking the line in side-by-side view, see gutterBar
+	highlight  string         // ANSI sequence for intraline changes, empty for context
+	textColor  string         // ANSI sequence for the color of changed lines without a background
+}
+
+// noNewlineMarker follows a line that ends its side without a newline
+const noNewlineMarker = " ⏎ missing"
+
+// NewRenderer creates a renderer for opts.Theme, or the current theme when
+// it isn't set
+func NewRenderer(opts RenderOptions) *Renderer {
+	theme := opts.theme()
+	opts.Theme = theme
+	theme = opts.Style.theme(theme)
+
+	r := &Renderer{
+		opts:         opts,
+		theme:        theme,
+		highlighters: make(map[string]*themes.Highlighter),
+		rendered:     make(map[*DiffResult]string),
+		rowCounts:    make(map[*DiffResult]int),
+		fullFiles:    make(map[*DiffResult][]fullFileLine),
 	}
 
-	// Render each hunk
-	for _, hunk := range result.Hunks {
-		sb.WriteString(renderUnifiedHunk(result.NewFile, hunk, theme, opts))
-		sb.WriteString("\n")
+	r.lineStyles[LineRemoved] = newLineStyle("-", theme.DiffRemovedBg, theme.DiffRemovedLineNumberBg, theme.DiffRemoved, theme.DiffHighlightRemoved)
+	r.lineStyles[LineAdded] = newLineStyle("+", theme.DiffAddedBg, theme.DiffAddedLineNumberBg, theme.DiffAdded, theme.DiffHighlightAdded)
+	r.lineStyles[LineContext] = newLineStyle(" ", theme.DiffContextBg, theme.DiffLineNumber, theme.TextMuted, "")
+	if opts.DimContext {
+		r.lineStyles[LineContext].bg = r.lineStyles[LineContext].bg.Foreground(dimColor(theme))
+	}
+	r.movedStyles[LineRemoved] = newLineStyle("-", theme.DiffMovedFromBg, theme.DiffMovedFromBg, theme.DiffMovedFrom, theme.DiffHighlightRemoved)
+	r.movedStyles[LineAdded] = newLineStyle("+", theme.DiffMovedToBg, theme.DiffMovedToBg, theme.DiffMovedTo, theme.DiffHighlightAdded)
+	r.movedStyles[LineContext] = r.lineStyles[LineContext]
+	for _, styles := range []*[3]lineStyle{&r.lineStyles, &r.movedStyles} {
+		for i := range styles {
+			styles[i].annotation = lipgloss.NewStyle().
+				Background(styles[i].bg.GetBackground()).
+				Foreground(theme.TextMuted)
+		}
+		opts.Style.restyle(styles)
+	}
+	// Ignored changes look like dimmed context, keeping their markers
+	for kind, marker := range [...]string{LineContext: " ", LineAdded: "+", LineRemoved: "-"} {
+		style := newLineStyle(marker, theme.DiffContextBg, theme.DiffLineNumber, theme.TextMuted, "")
+		style.bg = style.bg.Foreground(dimColor(theme))
+		style.annotation = r.lineStyles[LineContext].annotation
+		r.ignoredStyles[kind] = style
 	}
 
-	return sb.String()
+	r.hunkHeaderStyle = lipgloss.NewStyle().
+		Foreground(theme.TextMuted).
+		Bold(true)
+	r.hunkContextStyle = lipgloss.NewStyle().
+		Foreground(theme.SyntaxFunction).
+		Bold(true)
+	r.hunkAddedStyle = lipgloss.NewStyle().Foreground(theme.DiffAdded)
+	r.hunkRemovedStyle = lipgloss.NewStyle().Foreground(theme.DiffRemoved)
+	r.fileHeaderStyle = lipgloss.NewStyle().
+		Foreground(theme.Text).
+		Bold(true)
+	r.skippedStyle = lipgloss.NewStyle().
+		Foreground(theme.TextMuted)
+	r.emptyStyle = lipgloss.NewStyle().Background(theme.Background)
+	r.changeMarkStyles = newChangeMarkStyles(theme)
+
+	// Matches are drawn on the selection color, or in reverse video when
+	// the theme's isn't a hex color
+	r.matchHighlight = reverseHighlight
+	if strings.HasPrefix(string(theme.Selection), "#") {
+		red, green, blue := hexToRGB(string(theme.Selection))
+		r.matchHighlight = fmt.Sprintf("\x1b[1;48;2;%d;%d;%dm", red, green, blue)
+	}
+	r.trailingSpace = trailingWhitespaceStyle(string(theme.DiffRemoved))
+
+	return r
 }
 
-// renderUnifiedHunk renders a single hunk in unified format
-func renderUnifiedHunk(filename string, hunk Hunk, theme *themes.ThemeColors, opts RenderOptions) string {
-	var sb strings.Builder
+// lineStyle returns the styles of a diff line
+func (r *Renderer) lineStyle(dl DiffLine) *lineStyle {
+	if dl.Ignored && r.opts.IgnoredChanges == IgnoredDim {
+		return &r.ignoredStyles[dl.Kind]
+	}
+	if dl.Moved {
+		return &r.movedStyles[dl.Kind]
+	}
+	return &r.lineStyles[dl.Kind]
+}
 
-	// Render hunk header
-	headerStyle := lipgloss.NewStyle().
-		Foreground(theme.TextMuted).
+// newLineStyle builds the styles for one kind of diff line
+func newLineStyle(marker string, bg, lineNumberBg, lineNumberFg, highlight lipgloss.Color) lineStyle {
+	s := lineStyle{
+		marker: marker,
+		bg:     lipgloss.NewStyle().Background(bg),
+		lineNumber: lipgloss.NewStyle().
+			Background(lineNumberBg).
+			Foreground(lineNumberFg),
+	}
+	// Without a background, changed lines are told apart by their color
+	if bg == "" && marker != " " {
+		s.bg = s.bg.Foreground(lineNumberFg)
+		if strings.HasPrefix(string(lineNumberFg), "#") {
+			red, green, blue := hexToRGB(string(lineNumberFg))
+			s.textColor = fmt.Sprintf("\x1b[38;2;%d;%d;%dm", red, green, blue)
+		}
+	}
+	s.markerBold = lipgloss.NewStyle().
+		Background(s.bg.GetBackground()).
+		Foreground(s.bg.GetForeground()).
 		Bold(true)
-	sb.WriteString(headerStyle.Render(hunk.Header))
-	sb.WriteString("\n")
+	s.bar = lipgloss.NewStyle().
+		Background(s.bg.GetBackground()).
+		Foreground(lineNumberFg)
+	if highlight != "" {
+		red, green, blue := hexToRGB(string(highlight))
+		s.highlight = fmt.Sprintf("\x1b[48;2;%d;%d;%dm", red, green, blue)
+	}
+	return s
+}
 
-	// Render lines in parallel for performance
-	lines := make([]string, len(hunk.Lines))
-	var wg sync.WaitGroup
-	wg.Add(len(hunk.Lines))
+// dimColor returns the color of dimmed context lines: the text color blended
+// halfway into the context background, or the muted text color for themes
+// without hex colors
+func dimColor(theme *themes.ThemeColors) lipgloss.Color {
+	for _, bg := range []lipgloss.Color{theme.DiffContextBg, theme.Background} {
+		if color, err := themes.Mix(theme.Text, bg, 0.45); err == nil {
+			return color
+		}
+	}
+	return theme.TextMuted
+}
 
-	for i, line := range hunk.Lines {
-		go func(idx int, dl DiffLine) {
-			defer wg.Done()
-			lines[idx] = renderUnifiedLine(filename, dl, theme, opts)
-		}(i, line)
+// Options returns the options the renderer was created with
+func (r *Renderer) Options() RenderOptions {
+	return r.opts
+}
+
+// highlightContext colors the syntax of a context line with the semantic
+// tokens of the file, when it has any, or chroma
+func (r *Renderer) highlightContext(h *themes.Highlighter, dl DiffLine) string {
+	if r.tokens != nil {
+		return r.highlightTokens(dl.Content, r.tokens[dl.NewLineNo])
+	}
+	if h == nil {
+		return dl.Content
 	}
+	return h.HighlightLine(dl.Content)
+}
 
-	wg.Wait()
+// highlighter returns the cached syntax highlighter for a file, detecting
+// the language of extensionless files from the head of file when it's set
+func (r *Renderer) highlighter(filename string, file *DiffResult) *themes.Highlighter {
+	if filename == "" {
+		return nil
+	}
+	h, ok := r.highlighters[filename]
+	if !ok {
+		h = themes.NewHighlighter(filename, r.language(filename, file), r.theme)
+		r.highlighters[filename] = h
+	}
+	return h
+}
 
-	// Join lines
-	for _, line := range lines {
-		sb.WriteString(line)
-		sb.WriteString("\n")
+// highlightIntraline computes the changes within the changed lines of
+// result at the configured granularity. Token granularity splits lines of
+// languages chroma doesn't know into words.
+func (r *Renderer) highlightIntraline(result *DiffResult) {
+	var split func(line string) []string
+	switch r.opts.Intraline {
+	case IntralineWord:
+		split = splitWords
+	case IntralineToken:
+		h := r.highlighter(result.NewFile, result)
+		split = func(line string) []string {
+			if tokens := h.Tokens(line); tokens != nil {
+				return tokens
+			}
+			return splitWords(line)
+		}
 	}
+	for i := range result.Hunks {
+		HighlightIntralineUnits(&result.Hunks[i], split)
+	}
+}
 
-	return sb.String()
+// Reset drops the cached output of previously rendered files
+func (r *Renderer) Reset() {
+	clear(r.rendered)
+	clear(r.rowCounts)
+	clear(r.fullFiles)
+	r.hunkRows = nil
 }
 
-// renderUnifiedLine renders a single line in unified format
-func renderUnifiedLine(filename string, dl DiffLine, theme *themes.ThemeColors, opts RenderOptions) string {
-	var marker string
-	var bgStyle lipgloss.Style
-	var lineNumberStyle lipgloss.Style
-	var highlightColor lipgloss.Color
-	var lineNum string
+// Render renders a single file in the renderer's view mode, reusing the
+// output of an earlier render of the same file
+func (r *Renderer) Render(result *DiffResult) string {
+	if output, ok := r.rendered[result]; ok {
+		return output
+	}
 
-	switch dl.Kind {
-	case LineRemoved:
-		marker = "-"
-		bgStyle = lipgloss.NewStyle().Background(theme.DiffRemovedBg)
-		lineNumberStyle = lipgloss.NewStyle().
-			Background(theme.DiffRemovedLineNumberBg).
-			Foreground(theme.DiffRemoved)
-		highlightColor = theme.DiffHighlightRemoved
-		if opts.ShowLineNumbers {
-			lineNum = fmt.Sprintf("%6d       ", dl.OldLineNo)
+	var output string
+	if lines := r.fullFile(result); lines != nil {
+		output = r.renderFullFile(result, lines)
+	} else if r.opts.ViewMode == ViewSideBySide {
+		output = r.RenderSideBySide(result)
+	} else {
+		output = r.RenderUnified(result)
+	}
+	// A cancelled render is incomplete, so it isn't reused
+	if !r.cancelled() {
+		r.rendered[result] = output
+	}
+	return output
+}
+
+// cancelled reports whether the render in progress has been cancelled
+func (r *Renderer) cancelled() bool {
+	return r.ctx != nil && r.ctx.Err() != nil
+}
+
+// reverseHighlight marks highlight pattern matches in bold reverse video
+const reverseHighlight = "\x1b[1;7m"
+
+// highlightMatches marks the matches of re in plain, the unstyled text of
+// content, with the style sequence
+func highlightMatches(content, plain string, re *regexp.Regexp, style string) string {
+	matches := re.FindAllStringIndex(plain, -1)
+	if len(matches) == 0 {
+		return content
+	}
+
+	// Segments are counted in runes rather than bytes
+	segments := make([]Segment, 0, len(matches))
+	for _, match := range matches {
+		if match[0] == match[1] {
+			continue
 		}
+		segments = append(segments, Segment{
+			Start: utf8.RuneCountInString(plain[:match[0]]),
+			End:   utf8.RuneCountInString(plain[:match[1]]),
+			Type:  LineContext,
+			Text:  plain[match[0]:match[1]],
+		})
+	}
+	return ApplyHighlighting(content, segments, LineContext, style)
+}
+
+// RenderUnifiedDiff renders a diff in unified format with syntax highlighting
+func RenderUnifiedDiff(result *DiffResult, opts RenderOptions) string {
+	return NewRenderer(opts).RenderUnified(result)
+}
+
+// RenderUnified renders a diff in unified format with syntax highlighting
+func (r *Renderer) RenderUnified(result *DiffResult) string {
+	switch {
+	case result.IsBinary:
+		return renderBinary(result, r.opts)
+	case result.Submodule != nil:
+		return renderSubmodule(result, r.opts)
+	case result.LFS != nil:
+		return renderLFS(result, r.opts)
+	case result.EOL != nil:
+		return renderEOL(result, r.opts)
+	}
+
+	r.highlightIntraline(result)
+	r.tokens = r.opts.Tokens.forFile(result.NewFile)
+	r.file = result
+
+	// Render each hunk
+	r.buf.Reset()
+	h := r.highlighter(result.NewFile, result)
+	contexts := r.hunkContexts(result)
+	for i, hunk := range result.Hunks {
+		if r.cancelled() {
+			break
+		}
+		r.renderUnifiedHunk(h, result.NewFile, hunk, contexts[i])
+		r.buf.WriteString("\n")
+	}
+
+	return r.buf.String()
+}
+
+// renderUnifiedHunk renders a single hunk in unified format into the buffer
+func (r *Renderer) renderUnifiedHunk(h *themes.Highlighter, filename string, hunk Hunk, context string) {
+	// Render hunk header
+	r.buf.WriteString(r.renderHunkHeader(hunk, context))
+	r.buf.WriteString("\n")
+	if hunk.Folded() {
+		return
+	}
 
-	case LineAdded:
-		marker = "+"
-		bgStyle = lipgloss.NewStyle().Background(theme.DiffAddedBg)
-		lineNumberStyle = lipgloss.NewStyle().
-			Background(theme.DiffAddedLineNumberBg).
-			Foreground(theme.DiffAdded)
-		highlightColor = theme.DiffHighlightAdded
-		if opts.ShowLineNumbers {
-			lineNum = fmt.Sprintf("       %6d", dl.NewLineNo)
+	for _, part := range r.hunkParts(hunk) {
+		if part.folded {
+			r.buf.WriteString(r.renderContextFold(filename, hunk, part.lines))
+			r.buf.WriteString("\n")
+			continue
+		}
+		for _, line := range r.renderLines(h, part.lines) {
+			r.buf.WriteString(line)
+			r.buf.WriteString("\n")
 		}
+	}
+}
 
-	case LineContext:
-		marker = " "
-		bgStyle = lipgloss.NewStyle().Background(theme.DiffContextBg)
-		lineNumberStyle = lipgloss.NewStyle().
-			Background(theme.DiffLineNumber).
-			Foreground(theme.TextMuted)
-		if opts.ShowLineNumbers {
-			lineNum = fmt.Sprintf("%6d %6d", dl.OldLineNo, dl.NewLineNo)
+// parallelLineThreshold is the hunk size below which lines are rendered
+// sequentially, as starting workers costs more than it saves
+const parallelLineThreshold = 256
+
+// renderLines renders the lines of a hunk in unified format, spreading big
+// hunks over a bounded pool of workers
+func (r *Renderer) renderLines(h *themes.Highlighter, lines []DiffLine) []string {
+	rendered := make([]string, len(lines))
+	workers := min(runtime.GOMAXPROCS(0), len(lines)/parallelLineThreshold)
+	if workers <= 1 {
+		for i, dl := range lines {
+			if r.cancelled() {
+				break
+			}
+			rendered[i] = r.renderUnifiedLine(h, dl)
 		}
+		return rendered
 	}
 
+	// Workers claim lines through a shared counter, writing to disjoint slots
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(next.Add(1)) - 1
+				if i >= len(lines) || r.cancelled() {
+					return
+				}
+				rendered[i] = r.renderUnifiedLine(h, lines[i])
+			}
+		}()
+	}
+	wg.Wait()
+
+	return rendered
+}
+
+// renderUnifiedLine renders a single line in unified format
+func (r *Renderer) renderUnifiedLine(h *themes.Highlighter, dl DiffLine) string {
+	style := r.lineStyle(dl)
+	opts := r.opts
+	dl = r.revealControls(dl)
+
 	// Build the line
 	var result strings.Builder
 
 	// Line numbers
+	var lineNum string
 	if opts.ShowLineNumbers {
-		result.WriteString(lineNumberStyle.Render(lineNum))
-		result.WriteString(" ")
+		lineNum = opts.Gutter.unified(dl)
+	}
+	if lineNum != "" {
+		if opts.Links != nil {
+			result.WriteString(r.linkedGutter(style, dl))
+		} else {
+			result.WriteString(style.lineNumber.Render(lineNum))
+		}
+		result.WriteString(opts.Gutter.separator())
 	}
 
 	// Marker
-	markerStyle := lipgloss.NewStyle().
-		Background(bgStyle.GetBackground()).
-		Foreground(bgStyle.GetForeground()).
-		Bold(true)
-	result.WriteString(markerStyle.Render(marker))
+	result.WriteString(style.markerBold.Render(style.marker))
 
 	// Content with syntax highlighting
 	content := dl.Content
 
-	// Apply syntax highlighting
-	if filename != "" && dl.Kind == LineContext {
-		// Only apply syntax highlighting to context lines
-		// (added/removed lines will have diff colors)
-		highlighted := themes.SyntaxHighlightLine(content, filename)
-		content = highlighted
+	// Apply syntax highlighting, usually only to context lines, as
+	// added and removed lines have the diff colors
+	if r.syntaxHighlighted(dl) {
+		content = r.highlightContext(h, dl)
 	}
 
-	// Apply intra-line highlighting for added/removed lines
-	if len(dl.Segments) > 0 && highlightColor != "" {
-		// Create highlight style
-		r, g, b := hexToRGB(string(highlightColor))
-		highlightStyle := fmt.Sprintf("\x1b[48;2;%d;%d;%dm", r, g, b)
-		content = ApplyHighlighting(content, dl.Segments, dl.Kind, highlightStyle)
+	// Apply intra-line highlighting for added/removed lines, starting with
+	// the line's color so it is restored after them
+	if len(dl.Segments) > 0 && style.highlight != "" {
+		content = ApplyHighlighting(style.textColor+content, dl.Segments, dl.Kind, style.highlight)
 	}
 
+	// Mark matches of the highlight pattern
+	if opts.Highlight != nil && dl.Kind != LineContext {
+		content = highlightMatches(content, dl.Content, opts.Highlight, r.matchHighlight)
+	}
+	content = r.revealWhitespace(content, dl.Content)
+	content = r.revealCR(content, dl)
+	content = r.accessibleContent(content, dl.Kind)
+
 	// Apply background color to the entire line
-	styledContent := bgStyle.Render(content)
-	result.WriteString(styledContent)
+	result.WriteString(style.bg.Render(content))
+	if dl.NoNewline {
+		result.WriteString(style.annotation.Render(noNewlineMarker))
+	}
+	if dl.whitespaceOnly {
+		result.WriteString(style.annotation.Render(whitespaceBadge))
+	}
 
 	// Pad to width if needed
 	if opts.Width > 0 {
 		currentWidth := VisibleLength(result.String())
 		if currentWidth < opts.Width {
 			padding := strings.Repeat(" ", opts.Width-currentWidth)
-			result.WriteString(bgStyle.Render(padding))
+			result.WriteString(style.bg.Render(padding))
 		}
 	}
 
@@ -164,157 +477,286 @@ func renderUnifiedLine(filename string, dl DiffLine, theme *themes.ThemeColors,
 
 // RenderSideBySideDiff renders a diff in side-by-side format
 func RenderSideBySideDiff(result *DiffResult, opts RenderOptions) string {
-	if result.IsBinary {
-		return fmt.Sprintf("Binary files %s and %s differ\n", result.OldFile, result.NewFile)
-	}
-
-	// Initialize themes
-	themes.Initialize()
-	theme := themes.GetCurrentTheme()
-
-	var sb strings.Builder
+	return NewRenderer(opts).RenderSideBySide(result)
+}
 
-	// Apply intra-line highlighting
-	for i := range result.Hunks {
-		HighlightIntralineChanges(&result.Hunks[i])
+// RenderSideBySide renders a diff in side-by-side format
+func (r *Renderer) RenderSideBySide(result *DiffResult) string {
+	switch {
+	case result.IsBinary:
+		return renderBinary(result, r.opts)
+	case result.Submodule != nil:
+		return renderSubmodule(result, r.opts)
+	case result.LFS != nil:
+		return renderLFS(result, r.opts)
+	case result.EOL != nil:
+		return renderEOL(result, r.opts)
 	}
 
+	r.highlightIntraline(result)
+	r.tokens = r.opts.Tokens.forFile(result.NewFile)
+	r.file = result
+
 	// Calculate column widths
-	halfWidth := opts.Width / 2
+	halfWidth := r.opts.Width / 2
 	if halfWidth < 40 {
 		halfWidth = 40
 	}
 
 	// Render each hunk
-	for _, hunk := range result.Hunks {
-		sb.WriteString(renderSideBySideHunk(result.OldFile, result.NewFile, hunk, theme, opts, halfWidth))
-		sb.WriteString("\n")
+	r.buf.Reset()
+	oldHighlighter, newHighlighter := r.highlighter(result.OldFile, result), r.highlighter(result.NewFile, result)
+	contexts := r.hunkContexts(result)
+	for i, hunk := range result.Hunks {
+		if r.cancelled() {
+			break
+		}
+		r.renderSideBySideHunk(oldHighlighter, newHighlighter, result.NewFile, hunk, contexts[i], halfWidth)
+		r.buf.WriteString("\n")
 	}
 
-	return sb.String()
+	return r.buf.String()
 }
 
-// renderSideBySideHunk renders a single hunk in side-by-side format
-func renderSideBySideHunk(oldFile, newFile string, hunk Hunk, theme *themes.ThemeColors, opts RenderOptions, halfWidth int) string {
-	var sb strings.Builder
-
+// renderSideBySideHunk renders a single hunk in side-by-side format into the buffer
+func (r *Renderer) renderSideBySideHunk(oldHighlighter, newHighlighter *themes.Highlighter, filename string, hunk Hunk, context string, halfWidth int) {
 	// Render hunk header
-	headerStyle := lipgloss.NewStyle().
-		Foreground(theme.TextMuted).
-		Bold(true)
-	sb.WriteString(headerStyle.Render(hunk.Header))
-	sb.WriteString("\n")
+	r.buf.WriteString(r.renderHunkHeader(hunk, context))
+	r.buf.WriteString("\n")
+	if hunk.Folded() {
+		return
+	}
 
-	// Pair lines for side-by-side rendering
-	pairs := PairLines(hunk.Lines)
+	for _, part := range r.hunkParts(hunk) {
+		if part.folded {
+			r.buf.WriteString(r.renderContextFold(filename, hunk, part.lines))
+			r.buf.WriteString("\n")
+			continue
+		}
 
-	// Render each pair
-	for _, pair := range pairs {
-		leftLine := renderSideBySideLine(oldFile, pair.Left, theme, opts, halfWidth, true)
-		rightLine := renderSideBySideLine(newFile, pair.Right, theme, opts, halfWidth, false)
+		// Pair lines for side-by-side rendering; pairs never span the
+		// context lines parts are split at
+		for _, pair := range PairLines(part.lines) {
+			if r.cancelled() {
+				return
+			}
+			leftLine := r.renderSideBySideLine(oldHighlighter, pair.Left, halfWidth, true)
+			rightLine := r.renderSideBySideLine(newHighlighter, pair.Right, halfWidth, false)
 
-		sb.WriteString(leftLine)
-		sb.WriteString(" ┃ ")
-		sb.WriteString(rightLine)
-		sb.WriteString("\n")
+			r.buf.WriteString(leftLine)
+			if r.opts.PlainColumns {
+				r.buf.WriteString(strings.TrimRight(plainSeparator(pair)+rightLine, " "))
+			} else {
+				r.buf.WriteString(" ┃ ")
+				r.buf.WriteString(rightLine)
+			}
+			r.buf.WriteString("\n")
+		}
 	}
+}
 
-	return sb.String()
+// plainSeparator returns the ASCII separator between the columns of pair,
+// marking lines only on the left with <, only on the right with > and
+// changed on both sides with |, as diff -y does
+func plainSeparator(pair LinePair) string {
+	switch {
+	case pair.Right == nil:
+		return " < "
+	case pair.Left == nil:
+		return " > "
+	case pair.Left.Kind != LineContext || pair.Right.Kind != LineContext:
+		return " | "
+	}
+	return "   "
 }
 
 // renderSideBySideLine renders a single line for side-by-side view
-func renderSideBySideLine(filename string, dl *DiffLine, theme *themes.ThemeColors, opts RenderOptions, width int, isLeft bool) string {
+func (r *Renderer) renderSideBySideLine(h *themes.Highlighter, dl *DiffLine, width int, isLeft bool) string {
 	if dl == nil {
 		// Empty side
-		emptyStyle := lipgloss.NewStyle().Background(theme.Background)
-		return emptyStyle.Render(strings.Repeat(" ", width))
+		if r.opts.PlainColumns {
+			return strings.Repeat(" ", width)
+		}
+		return r.emptyStyle.Render(strings.Repeat(" ", width))
 	}
 
 	// Similar to renderUnifiedLine but adapted for side-by-side
-	var bgStyle lipgloss.Style
-	var lineNumberStyle lipgloss.Style
-	var highlightColor lipgloss.Color
-	var lineNum string
-
-	switch dl.Kind {
-	case LineRemoved:
-		bgStyle = lipgloss.NewStyle().Background(theme.DiffRemovedBg)
-		lineNumberStyle = lipgloss.NewStyle().
-			Background(theme.DiffRemovedLineNumberBg).
-			Foreground(theme.DiffRemoved)
-		highlightColor = theme.DiffHighlightRemoved
-		if opts.ShowLineNumbers {
-			lineNum = fmt.Sprintf("%6d", dl.OldLineNo)
-		}
-
-	case LineAdded:
-		bgStyle = lipgloss.NewStyle().Background(theme.DiffAddedBg)
-		lineNumberStyle = lipgloss.NewStyle().
-			Background(theme.DiffAddedLineNumberBg).
-			Foreground(theme.DiffAdded)
-		highlightColor = theme.DiffHighlightAdded
-		if opts.ShowLineNumbers {
-			lineNum = fmt.Sprintf("%6d", dl.NewLineNo)
-		}
-
-	case LineContext:
-		bgStyle = lipgloss.NewStyle().Background(theme.DiffContextBg)
-		lineNumberStyle = lipgloss.NewStyle().
-			Background(theme.DiffLineNumber).
-			Foreground(theme.TextMuted)
-		if opts.ShowLineNumbers {
-			if isLeft {
-				lineNum = fmt.Sprintf("%6d", dl.OldLineNo)
-			} else {
-				lineNum = fmt.Sprintf("%6d", dl.NewLineNo)
-			}
-		}
+	style := r.lineStyle(*dl)
+	opts := r.opts
+	if opts.ShowInvisibles {
+		revealed := r.revealControls(*dl)
+		dl = &revealed
 	}
 
 	var result strings.Builder
 
 	// Line numbers
-	if opts.ShowLineNumbers {
-		result.WriteString(lineNumberStyle.Render(lineNum))
-		result.WriteString(" ")
+	var lineNum string
+	showNumbers := opts.ShowLineNumbers && opts.Gutter.showsSide(isLeft)
+	if showNumbers {
+		n := dl.NewLineNo
+		if isLeft {
+			n = dl.OldLineNo
+		}
+		lineNum = opts.Gutter.number(n)
+		rendered := style.lineNumber.Render(lineNum)
+		if n != 0 {
+			rendered = r.link(r.file, rendered, n, isLeft)
+		}
+		result.WriteString(rendered)
+		result.WriteString(opts.Gutter.separator())
 	}
 
 	// Content
 	content := dl.Content
 
-	// Apply syntax highlighting for context lines
-	if filename != "" && dl.Kind == LineContext {
-		content = themes.SyntaxHighlightLine(content, filename)
+	// Apply syntax highlighting, usually only for context lines
+	if r.syntaxHighlighted(*dl) {
+		content = r.highlightContext(h, *dl)
 	}
 
 	// Apply intra-line highlighting
-	if len(dl.Segments) > 0 && highlightColor != "" {
-		r, g, b := hexToRGB(string(highlightColor))
-		highlightStyle := fmt.Sprintf("\x1b[48;2;%d;%d;%dm", r, g, b)
-		content = ApplyHighlighting(content, dl.Segments, dl.Kind, highlightStyle)
+	if len(dl.Segments) > 0 && style.highlight != "" {
+		content = ApplyHighlighting(style.textColor+content, dl.Segments, dl.Kind, style.highlight)
+	}
+
+	// Mark matches of the highlight pattern
+	if opts.Highlight != nil && dl.Kind != LineContext {
+		content = highlightMatches(content, dl.Content, opts.Highlight, r.matchHighlight)
 	}
+	content = r.revealWhitespace(content, dl.Content)
+	content = r.revealCR(content, *dl)
 
 	// Truncate if needed
 	contentWidth := width
-	if opts.ShowLineNumbers {
-		contentWidth -= 7 // Line number width
+	if showNumbers {
+		contentWidth -= VisibleLength(lineNum + opts.Gutter.separator())
+	}
+	if bar := r.gutterBar(style, dl.Kind); bar != "" && contentWidth > 1 {
+		result.WriteString(bar)
+		contentWidth--
+	}
+	marker := ""
+	if dl.NoNewline && contentWidth > VisibleLength(noNewlineMarker) {
+		marker = style.annotation.Render(noNewlineMarker)
+		contentWidth -= VisibleLength(noNewlineMarker)
 	}
-	content = TruncateString(content, contentWidth)
+	if dl.whitespaceOnly && contentWidth > VisibleLength(whitespaceBadge) {
+		marker += style.annotation.Render(whitespaceBadge)
+		contentWidth -= VisibleLength(whitespaceBadge)
+	}
+	content = r.accessibleContent(TruncateString(content, contentWidth), dl.Kind)
 
 	// Apply background and add to result
-	styledContent := bgStyle.Render(content)
-	result.WriteString(styledContent)
+	result.WriteString(style.bg.Render(content))
+	result.WriteString(marker)
 
 	// Pad to width
 	currentWidth := VisibleLength(result.String())
 	if currentWidth < width {
 		padding := strings.Repeat(" ", width-currentWidth)
-		result.WriteString(bgStyle.Render(padding))
+		if !opts.PlainColumns {
+			padding = style.bg.Render(padding)
+		}
+		result.WriteString(padding)
 	}
 
 	return result.String()
 }
 
+// RenderThreeWayDiff renders a three-way diff as base, ours and theirs columns
+func RenderThreeWayDiff(result *ThreeWayResult, opts RenderOptions) string {
+	return NewRenderer(opts).RenderThreeWay(result)
+}
+
+// RenderThreeWay renders a three-way diff as base, ours and theirs columns
+func (r *Renderer) RenderThreeWay(result *ThreeWayResult) string {
+	theme := r.theme
+	opts := r.opts
+	r.tokens, r.file = nil, nil
+
+	var sb strings.Builder
+
+	// Calculate column widths, leaving room for two separators
+	columnWidth := (opts.Width - 6) / 3
+	if columnWidth < 30 {
+		columnWidth = 30
+	}
+
+	separator := lipgloss.NewStyle().Foreground(theme.Border).Render(" ┃ ")
+	conflictSeparator := lipgloss.NewStyle().Foreground(theme.Error).Bold(true).Render(" ┃ ")
+
+	// Column headers
+	headerStyle := lipgloss.NewStyle().
+		Foreground(theme.TextMuted).
+		Bold(true).
+		Width(columnWidth)
+	sb.WriteString(headerStyle.Render(TruncateString("base: "+result.BaseFile, columnWidth)))
+	sb.WriteString(separator)
+	sb.WriteString(headerStyle.Render(TruncateString("ours: "+result.OursFile, columnWidth)))
+	sb.WriteString(separator)
+	sb.WriteString(headerStyle.Render(TruncateString("theirs: "+result.TheirsFile, columnWidth)))
+	sb.WriteString("\n")
+
+	// Only show rows within the context window of a change
+	visible := make([]bool, len(result.Rows))
+	for i, row := range result.Rows {
+		if !row.Changed() {
+			continue
+		}
+		for j := i - opts.ContextLines; j <= i+opts.ContextLines; j++ {
+			if j >= 0 && j < len(visible) {
+				visible[j] = true
+			}
+		}
+	}
+
+	baseHighlighter := r.highlighter(result.BaseFile, nil)
+	oursHighlighter := r.highlighter(result.OursFile, nil)
+	theirsHighlighter := r.highlighter(result.TheirsFile, nil)
+
+	gapStyle := lipgloss.NewStyle().Foreground(theme.TextMuted)
+	hidden := 0
+	for i, row := range result.Rows {
+		if !visible[i] {
+			hidden++
+			continue
+		}
+		if hidden > 0 {
+			sb.WriteString(gapStyle.Render(fmt.Sprintf("⋯ %d unchanged line(s)", hidden)))
+			sb.WriteString("\n")
+			hidden = 0
+		}
+
+		sep := separator
+		if row.Conflicting() {
+			sep = conflictSeparator
+		}
+
+		sb.WriteString(r.renderSideBySideLine(baseHighlighter, row.Base, columnWidth, true))
+		sb.WriteString(sep)
+		sb.WriteString(r.renderThreeWayCell(oursHighlighter, row.Base, row.Ours, columnWidth))
+		sb.WriteString(sep)
+		sb.WriteString(r.renderThreeWayCell(theirsHighlighter, row.Base, row.Theirs, columnWidth))
+		sb.WriteString("\n")
+	}
+	if hidden > 0 {
+		sb.WriteString(gapStyle.Render(fmt.Sprintf("⋯ %d unchanged line(s)", hidden)))
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
+// renderThreeWayCell renders one side of a three-way row, marking base lines
+// that the side deleted
+func (r *Renderer) renderThreeWayCell(h *themes.Highlighter, base, side *DiffLine, width int) string {
+	if side == nil && base != nil {
+		return r.lineStyles[LineRemoved].bg.Render(strings.Repeat(" ", width))
+	}
+	return r.renderSideBySideLine(h, side, width, false)
+}
+
 // hexToRGB converts a hex color to RGB values
 func hexToRGB(hex string) (r, g, b int) {
 	hex = strings.TrimPrefix(hex, "#")
@@ -326,32 +768,329 @@ func hexToRGB(hex string) (r, g, b int) {
 	return
 }
 
+// RenderFiles renders a multi-file diff in the view mode from opts, with a
+// header above each file when there is more than one
+func RenderFiles(files []*DiffResult, opts RenderOptions) string {
+	output, _ := NewRenderer(opts).RenderFilesWithOffsets(files)
+	return output
+}
+
+// RenderFilesContext renders like RenderFiles, stopping early with ctx's
+// error when ctx is done
+func RenderFilesContext(ctx context.Context, files []*DiffResult, opts RenderOptions) (string, error) {
+	return NewRenderer(opts).RenderFilesContext(ctx, files)
+}
+
+// RenderFilesWithOffsets renders like RenderFiles and also returns the output
+// line at which each file starts. A cancelled render ends with the last file
+// rendered in full.
+func RenderFilesWithOffsets(files []*DiffResult, opts RenderOptions) (string, []int) {
+	return NewRenderer(opts).RenderFilesWithOffsets(files)
+}
+
+// RenderFiles renders a multi-file diff, with a header above each file when
+// there is more than one
+func (r *Renderer) RenderFiles(files []*DiffResult) string {
+	output, _ := r.RenderFilesWithOffsets(files)
+	return output
+}
+
+// RenderFilesContext renders like RenderFiles, stopping early with ctx's
+// error when ctx is done, e.g. on ctrl+c in the middle of a huge diff
+func (r *Renderer) RenderFilesContext(ctx context.Context, files []*DiffResult) (string, error) {
+	r.ctx = ctx
+	defer func() { r.ctx = nil }()
+	output, _ := r.RenderFilesWithOffsets(files)
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return output, nil
+}
+
+// RenderFilesWithOffsets renders like RenderFiles and also returns the output
+// line at which each file starts. A cancelled render ends with the last file
+// rendered in full.
+func (r *Renderer) RenderFilesWithOffsets(files []*DiffResult) (string, []int) {
+	var sb strings.Builder
+	offsets := make([]int, 0, len(files))
+	line := 0
+	r.heat = nil
+	if len(files) > 1 {
+		r.heat = make(map[*DiffResult]float64, len(files))
+		for i, heat := range ChangeHeat(files) {
+			r.heat[files[i]] = heat
+		}
+	}
+	for _, file := range files {
+		if r.cancelled() {
+			break
+		}
+
+		var output string
+		switch {
+		case file.SkipReason != "":
+			output = r.skippedStyle.Render(fmt.Sprintf("▸ %s — skipped (%s)", file.DisplayName(), file.SkipReason)) + "\n"
+		case file.Collapsed:
+			additions, deletions := file.CountChanges()
+			summary := fmt.Sprintf("▸ %s (+%d -%d)", file.DisplayName(), additions, deletions)
+			if file.Generated {
+				summary += " · generated"
+			}
+			output = r.fileHeaderStyle.Render(summary) + "\n"
+		default:
+			if len(files) > 1 || r.opts.FileHeaders {
+				output = r.renderFileHeader(file) + "\n"
+			}
+			output += r.Render(file)
+			// A file cut short by a cancellation is left out, so the output
+			// ends with the last file rendered in full
+			if r.cancelled() {
+				return sb.String(), offsets
+			}
+		}
+
+		offsets = append(offsets, line)
+		sb.WriteString(output)
+		line += strings.Count(output, "\n")
+	}
+
+	return sb.String(), offsets
+}
+
+// RowCount returns how many lines RenderFiles outputs for files. It counts
+// the lines of each file's output once, so it's cheap to call again.
+func (r *Renderer) RowCount(files []*DiffResult) int {
+	count := 0
+	for _, file := range files {
+		if file.SkipReason != "" || file.Collapsed {
+			count++
+			continue
+		}
+		if len(files) > 1 || r.opts.FileHeaders {
+			count++
+		}
+		rows, ok := r.rowCounts[file]
+		if !ok {
+			rows = strings.Count(r.Render(file), "\n")
+			if _, cached := r.rendered[file]; cached {
+				r.rowCounts[file] = rows
+			}
+		}
+		count += rows
+	}
+	return count
+}
+
+// LineAt returns the file and the old and new line numbers shown at row of
+// the output of RenderFiles. ok is false for rows without a diff line, such
+// as headers. A side-by-side row has both numbers when it pairs a removed
+// line with an added one.
+func (r *Renderer) LineAt(files []*DiffResult, row int) (file *DiffResult, oldLine, newLine int, ok bool) {
+	r.walkRows(files, func(current int, f *DiffResult, old, new int) bool {
+		if current < row {
+			return true
+		}
+		if current == row {
+			file, oldLine, newLine, ok = f, old, new, true
+		}
+		return false
+	})
+	return file, oldLine, newLine, ok
+}
+
+// LineFrom returns the first diff line shown at row of the output of
+// RenderFiles or below it, and the row showing it
+func (r *Renderer) LineFrom(files []*DiffResult, row int) (file *DiffResult, oldLine, newLine, lineRow int, ok bool) {
+	r.walkRows(files, func(current int, f *DiffResult, old, new int) bool {
+		if current < row {
+			return true
+		}
+		file, oldLine, newLine, lineRow, ok = f, old, new, current, true
+		return false
+	})
+	return file, oldLine, newLine, lineRow, ok
+}
+
+// NewLineAt returns the file and new-file line number shown at row of the
+// output of RenderFiles, or on the first row below it that shows a line of
+// a new file
+func (r *Renderer) NewLineAt(files []*DiffResult, row int) (file *DiffResult, newLine int, ok bool) {
+	r.walkRows(files, func(current int, f *DiffResult, _, new int) bool {
+		if current < row || new == 0 || f.NewFile == "" || f.NewFile == "/dev/null" {
+			return true
+		}
+		file, newLine, ok = f, new, true
+		return false
+	})
+	return file, newLine, ok
+}
+
+// RowOf returns the row of the output of RenderFiles showing a line of
+// file: the new line newLine, or the old line oldLine when newLine is 0. ok
+// is false when the line isn't shown, e.g. in a collapsed file.
+func (r *Renderer) RowOf(files []*DiffResult, file *DiffResult, oldLine, newLine int) (row int, ok bool) {
+	r.walkRows(files, func(current int, f *DiffResult, old, new int) bool {
+		if f != file || (newLine > 0 && new != newLine) || (newLine == 0 && old != oldLine) {
+			return true
+		}
+		row, ok = current, true
+		return false
+	})
+	return row, ok
+}
+
+// hunkRowsCache holds the rows HunkRows found along with the files they
+// were found in and which of them were shown, which Reset doesn't cover
+type hunkRowsCache struct {
+	files []*DiffResult
+	shown []bool
+	rows  [][]int
+}
+
+// HunkRows returns the rows of the first line of each hunk shown in the
+// output of RenderFiles, per file, as RowOf finds them but in one pass over
+// the rows. Skipped and collapsed files and folded hunks have none. The
+// rows are kept until Reset or until files change or are collapsed.
+func (r *Renderer) HunkRows(files []*DiffResult) [][]int {
+	shown := make([]bool, len(files))
+	for i, file := range files {
+		shown[i] = file.SkipReason == "" && !file.Collapsed
+	}
+	if cache := r.hunkRows; cache != nil && slices.Equal(cache.files, files) && slices.Equal(cache.shown, shown) {
+		return cache.rows
+	}
+
+	// The first lines awaited, by their new line number, or by their old
+	// one when they were removed
+	type lineKey struct {
+		file *DiffResult
+		line int
+	}
+	index := make(map[*DiffResult]int, len(files))
+	byOld, byNew := make(map[lineKey]bool), make(map[lineKey]bool)
+	for i, file := range files {
+		index[file] = i
+		for _, hunk := range file.Hunks {
+			if hunk.Folded() || len(hunk.Lines) == 0 {
+				continue
+			}
+			if first := hunk.Lines[0]; first.Kind == LineRemoved {
+				byOld[lineKey{file, first.OldLineNo}] = true
+			} else {
+				byNew[lineKey{file, first.NewLineNo}] = true
+			}
+		}
+	}
+
+	rows := make([][]int, len(files))
+	r.walkRows(files, func(row int, file *DiffResult, oldLine, newLine int) bool {
+		if key := (lineKey{file, newLine}); newLine > 0 && byNew[key] {
+			delete(byNew, key)
+			rows[index[file]] = append(rows[index[file]], row)
+		}
+		if key := (lineKey{file, oldLine}); oldLine > 0 && byOld[key] {
+			delete(byOld, key)
+			rows[index[file]] = append(rows[index[file]], row)
+		}
+		return len(byOld)+len(byNew) > 0
+	})
+	r.hunkRows = &hunkRowsCache{files: slices.Clone(files), shown: shown, rows: rows}
+	return rows
+}
+
+// walkRows calls fn with the row of each diff line in the output of
+// RenderFiles, in order, until fn returns false. It mirrors the layout
+// RenderFiles produces.
+func (r *Renderer) walkRows(files []*DiffResult, fn func(row int, file *DiffResult, oldLine, newLine int) bool) {
+	current := 0
+	for _, file := range files {
+		if file.SkipReason != "" || file.Collapsed {
+			current++
+			continue
+		}
+		if len(files) > 1 || r.opts.FileHeaders {
+			current++
+		}
+		if file.IsBinary || file.Submodule != nil || file.LFS != nil || file.EOL != nil {
+			current += strings.Count(r.Render(file), "\n")
+			continue
+		}
+		if lines := r.fullFile(file); lines != nil {
+			for _, fl := range lines {
+				if !fn(current, file, fl.line.OldLineNo, fl.line.NewLineNo) {
+					return
+				}
+				current++
+			}
+			// The blank line after the file
+			current++
+			continue
+		}
+
+		for _, hunk := range file.Hunks {
+			// The hunk header, which is all a folded hunk shows
+			current++
+			if hunk.Folded() {
+				current++
+				continue
+			}
+
+			for _, part := range r.hunkParts(hunk) {
+				switch {
+				case part.folded:
+					// The divider stands for the first folded line
+					if !fn(current, file, part.lines[0].OldLineNo, part.lines[0].NewLineNo) {
+						return
+					}
+					current++
+				case r.opts.ViewMode == ViewSideBySide:
+					for _, pair := range PairLines(part.lines) {
+						old, new := 0, 0
+						if pair.Left != nil {
+							old = pair.Left.OldLineNo
+						}
+						if pair.Right != nil {
+							new = pair.Right.NewLineNo
+						}
+						if !fn(current, file, old, new) {
+							return
+						}
+						current++
+					}
+				default:
+					for _, line := range part.lines {
+						if !fn(current, file, line.OldLineNo, line.NewLineNo) {
+							return
+						}
+						current++
+					}
+				}
+			}
+
+			// The blank line after the hunk
+			current++
+		}
+	}
+}
+
 // FormatUnifiedDiff formats an entire diff in unified view
 func FormatUnifiedDiff(filename, diffText string, opts RenderOptions) (string, error) {
-	result, err := ParseUnifiedDiff(diffText)
+	files, err := ParseMultiFileDiff(diffText)
 	if err != nil {
 		return "", err
 	}
 
-	// Use filename from diff if not provided
-	if filename == "" && result.NewFile != "" {
-		filename = result.NewFile
-	}
-
-	return RenderUnifiedDiff(result, opts), nil
+	opts.ViewMode = ViewUnified
+	return RenderFiles(files, opts), nil
 }
 
 // FormatSideBySideDiff formats an entire diff in side-by-side view
 func FormatSideBySideDiff(filename, diffText string, opts RenderOptions) (string, error) {
-	result, err := ParseUnifiedDiff(diffText)
+	files, err := ParseMultiFileDiff(diffText)
 	if err != nil {
 		return "", err
 	}
 
-	// Use filename from diff if not provided
-	if filename == "" && result.NewFile != "" {
-		filename = result.NewFile
-	}
-
-	return RenderSideBySideDiff(result, opts), nil
-}
\ No newline at end of file
+	opts.ViewMode = ViewSideBySide
+	return RenderFiles(files, opts), nil
+}
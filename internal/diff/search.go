@@ -0,0 +1,32 @@
+package diff
+
+import "regexp"
+
+// FilterChanges keeps the hunks with an added or removed line matching re,
+// like git's -G pickaxe, and drops files left without any
+func FilterChanges(files []*DiffResult, re *regexp.Regexp) []*DiffResult {
+	kept := files[:0]
+	for _, file := range files {
+		hunks := file.Hunks[:0]
+		for _, hunk := range file.Hunks {
+			if hunkMatches(hunk, re) {
+				hunks = append(hunks, hunk)
+			}
+		}
+		file.Hunks = hunks
+		if len(hunks) > 0 {
+			kept = append(kept, file)
+		}
+	}
+	return kept
+}
+
+// hunkMatches reports whether an added or removed line of hunk matches re
+func hunkMatches(hunk Hunk, re *regexp.Regexp) bool {
+	for _, line := range hunk.Lines {
+		if line.Kind != LineContext && re.MatchString(line.Content) {
+			return true
+		}
+	}
+	return false
+}
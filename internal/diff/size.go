@@ -0,0 +1,61 @@
+package diff
+
+// ReviewSize is a rough measure of how long a change takes to review, as
+// shown by the size labels many projects put on pull requests
+type ReviewSize int
+
+const (
+	SizeXS ReviewSize = iota
+	SizeS
+	SizeM
+	SizeL
+	SizeXL
+)
+
+var reviewSizeNames = []string{"XS", "S", "M", "L", "XL"}
+
+// Upper bounds of each size but XL, in changed lines and in changed files.
+// The line bounds are the usual ones of pull request size labelers.
+var (
+	sizeMaxLines = []int{9, 29, 99, 499}
+	sizeMaxFiles = []int{1, 3, 10, 25}
+)
+
+// reviewEstimates are the rough review times of each size
+var reviewEstimates = []string{"< 5 min", "~10 min", "~30 min", "~1 h", "> 2 h"}
+
+// ReviewSizeOf returns the size of a change from its file stats: the larger
+// of the sizes given by its changed lines and by its number of files
+func ReviewSizeOf(stats []FileStat) ReviewSize {
+	lines := 0
+	for _, stat := range stats {
+		lines += stat.Additions + stat.Deletions
+	}
+	return max(sizeFor(lines, sizeMaxLines), sizeFor(len(stats), sizeMaxFiles))
+}
+
+// sizeFor returns the first size whose bound n is within
+func sizeFor(n int, bounds []int) ReviewSize {
+	for i, bound := range bounds {
+		if n <= bound {
+			return ReviewSize(i)
+		}
+	}
+	return SizeXL
+}
+
+// String returns the size's label, e.g. M
+func (s ReviewSize) String() string {
+	if s < 0 || int(s) >= len(reviewSizeNames) {
+		return "unknown"
+	}
+	return reviewSizeNames[s]
+}
+
+// Estimate returns a rough review time for the size, e.g. ~30 min
+func (s ReviewSize) Estimate() string {
+	if s < 0 || int(s) >= len(reviewEstimates) {
+		return ""
+	}
+	return reviewEstimates[s]
+}
@@ -0,0 +1,118 @@
+package diff
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// RenderHunk renders only the hunk of result at index
+func (r *Renderer) RenderHunk(result *DiffResult, index int) (string, error) {
+	if index < 0 || index >= len(result.Hunks) {
+		return "", fmt.Errorf("hunk %d out of range (%s has %d)", index, result.DisplayName(), len(result.Hunks))
+	}
+	part := *result
+	part.Hunks = []Hunk{result.Hunks[index]}
+	return r.renderPart(&part), nil
+}
+
+// RenderLines renders only lines start to end of the new file, inclusive,
+// along with the lines removed between them. Hunks overlapping the range
+// are cut down to it.
+func (r *Renderer) RenderLines(result *DiffResult, start, end int) (string, error) {
+	if start < 1 || end < start {
+		return "", fmt.Errorf("invalid line range %d-%d", start, end)
+	}
+	part := *result
+	part.Hunks = nil
+	for _, hunk := range result.Hunks {
+		if cut, ok := cutHunk(hunk, start, end); ok {
+			part.Hunks = append(part.Hunks, cut)
+		}
+	}
+	if len(part.Hunks) == 0 {
+		return "", fmt.Errorf("lines %d-%d of %s are not in the diff", start, end, result.DisplayName())
+	}
+	return r.renderPart(&part), nil
+}
+
+// renderPart renders a partial copy of a file without caching it
+func (r *Renderer) renderPart(part *DiffResult) string {
+	if r.opts.ViewMode == ViewSideBySide {
+		return r.RenderSideBySide(part)
+	}
+	return r.RenderUnified(part)
+}
+
+// cutHunk keeps the lines of hunk within new-file lines start to end and
+// rewrites its header to match. Removed lines count as being at the new
+// line they precede.
+func cutHunk(hunk Hunk, start, end int) (Hunk, bool) {
+	matches := hunkHeaderRegex.FindStringSubmatch(hunk.Header)
+	if matches == nil {
+		return Hunk{}, false
+	}
+	oldFirst, _ := strconv.Atoi(matches[1])
+	newFirst, _ := strconv.Atoi(matches[3])
+	newCount := 1
+	if matches[4] != "" {
+		newCount, _ = strconv.Atoi(matches[4])
+	}
+
+	// Find the new line each removed line precedes, scanning backwards from
+	// the line after the hunk
+	positions := make([]int, len(hunk.Lines))
+	next := newFirst + newCount
+	if newCount == 0 {
+		next = newFirst + 1
+	}
+	for i := len(hunk.Lines) - 1; i >= 0; i-- {
+		if line := hunk.Lines[i]; line.Kind != LineRemoved {
+			next = line.NewLineNo
+		}
+		positions[i] = next
+	}
+
+	cut := Hunk{}
+	oldStart, newStart, oldCount, newCount := 0, 0, 0, 0
+	lastOld, lastNew := oldFirst-1, newFirst-1
+	if oldFirst == 0 {
+		lastOld = 0
+	}
+	for i, line := range hunk.Lines {
+		if positions[i] >= start && positions[i] <= end {
+			if len(cut.Lines) == 0 {
+				oldStart, newStart = lastOld+1, lastNew+1
+			}
+			cut.Lines = append(cut.Lines, line)
+			if line.Kind != LineAdded {
+				oldCount++
+			}
+			if line.Kind != LineRemoved {
+				newCount++
+			}
+		}
+		if line.OldLineNo > 0 {
+			lastOld = line.OldLineNo
+		}
+		if line.NewLineNo > 0 {
+			lastNew = line.NewLineNo
+		}
+	}
+	if len(cut.Lines) == 0 {
+		return Hunk{}, false
+	}
+
+	// Like git, an empty side starts at the line before it
+	if oldCount == 0 {
+		oldStart--
+	}
+	if newCount == 0 {
+		newStart--
+	}
+	cut.Header = fmt.Sprintf("@@ -%d,%d +%d,%d @@", oldStart, oldCount, newStart, newCount)
+	if section := strings.TrimPrefix(hunk.Header, matches[0]); section != "" {
+		cut.Header += section
+	}
+	return cut, true
+}
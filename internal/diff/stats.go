@@ -0,0 +1,106 @@
+package diff
+
+// File statuses reported in FileStat.Status
+const (
+	StatusAdded    = "added"
+	StatusDeleted  = "deleted"
+	StatusRenamed  = "renamed"
+	StatusCopied   = "copied"
+	StatusModified = "modified"
+)
+
+// FileStat summarizes the changes to one file
+type FileStat struct {
+	Path      string `json:"path"`
+	OldPath   string `json:"old_path,omitempty"` // Set for renames and copies
+	Status    string `json:"status"`
+	Additions int    `json:"additions"`
+	Deletions int    `json:"deletions"`
+	Binary    bool   `json:"binary"`
+}
+
+// Stat returns the change summary of a file
+func (d *DiffResult) Stat() FileStat {
+	additions, deletions := d.CountChanges()
+	stat := FileStat{
+		Path:      d.DisplayName(),
+		Status:    StatusModified,
+		Additions: additions,
+		Deletions: deletions,
+		Binary:    d.IsBinary,
+	}
+
+	switch {
+	case d.OldFile == "/dev/null":
+		stat.Status = StatusAdded
+	case d.NewFile == "/dev/null":
+		stat.Status = StatusDeleted
+	case d.Renamed:
+		stat.Status = StatusRenamed
+		stat.OldPath = d.OldFile
+	case d.Copied:
+		stat.Status = StatusCopied
+		stat.OldPath = d.OldFile
+	}
+	return stat
+}
+
+// Stats returns the change summary of each file, leaving out files hidden by
+// a FileFilter
+func Stats(files []*DiffResult) []FileStat {
+	stats := make([]FileStat, 0, len(files))
+	for _, file := range files {
+		if file.SkipReason == SkipFiltered {
+			continue
+		}
+		stats = append(stats, file.Stat())
+	}
+	return stats
+}
+
+// ChangeHeat rates how much each file changed relative to the file that
+// changed most, from 0 to 1, for coloring file names by it
+func ChangeHeat(files []*DiffResult) []float64 {
+	changed := make([]int, len(files))
+	most := 0
+	for i, file := range files {
+		additions, deletions := file.CountChanges()
+		changed[i] = additions + deletions
+		most = max(most, changed[i])
+	}
+	heat := make([]float64, len(files))
+	for i, n := range changed {
+		if most > 0 {
+			heat[i] = float64(n) / float64(most)
+		}
+	}
+	return heat
+}
+
+// HunkStat counts the changed lines of a hunk. Modified lines are removed
+// lines paired with an added line replacing them, as in the side-by-side
+// view; they are also counted in Added and Removed.
+type HunkStat struct {
+	Added    int
+	Removed  int
+	Modified int
+}
+
+// Stat returns the changed line counts of the hunk
+func (h Hunk) Stat() HunkStat {
+	var stat HunkStat
+	for _, pair := range PairLines(h.Lines) {
+		left := pair.Left != nil && pair.Left.Kind == LineRemoved
+		right := pair.Right != nil && pair.Right.Kind == LineAdded
+		if left {
+			stat.Removed++
+		}
+		if right {
+			stat.Added++
+		}
+		if left && right {
+			stat.Modified++
+		}
+	}
+	return stat
+}
@@ -0,0 +1,108 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/avgvstvs96/differential/internal/themes"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// RenderStyle selects how changed lines are told apart from unchanged ones
+type RenderStyle int
+
+const (
+	StyleBackground RenderStyle = iota // On the theme's diff backgrounds
+	StyleForeground                    // In the diff colors, on the terminal's background
+	StyleMinimal                       // By colored gutter markers only, with their syntax colors
+)
+
+var renderStyleNames = []string{"background", "foreground", "minimal"}
+
+// ParseRenderStyle parses a render style name as used in the config file
+func ParseRenderStyle(name string) (RenderStyle, error) {
+	for i, n := range renderStyleNames {
+		if strings.EqualFold(name, n) {
+			return RenderStyle(i), nil
+		}
+	}
+	return StyleBackground, fmt.Errorf("unknown style %q (expected %s)", name, strings.Join(renderStyleNames, ", "))
+}
+
+// String returns the config name of the style
+func (s RenderStyle) String() string {
+	if s < 0 || int(s) >= len(renderStyleNames) {
+		return "unknown"
+	}
+	return renderStyleNames[s]
+}
+
+// changedWords marks the changes within lines in styles without
+// backgrounds: bold and underlined, in the color of the line
+const changedWords = "\x1b[1;4m"
+
+// theme returns t without the backgrounds the style leaves to the
+// terminal; t itself is shared, so it is copied
+func (s RenderStyle) theme(t *themes.ThemeColors) *themes.ThemeColors {
+	if s == StyleBackground {
+		return t
+	}
+	plain := *t
+	for _, bg := range []*lipgloss.Color{
+		&plain.Background, &plain.DiffContextBg,
+		&plain.DiffAddedBg, &plain.DiffRemovedBg,
+		&plain.DiffAddedLineNumberBg, &plain.DiffRemovedLineNumberBg,
+		&plain.DiffMovedFromBg, &plain.DiffMovedToBg,
+	} {
+		*bg = ""
+	}
+	return &plain
+}
+
+// restyle adapts the styles of lines to the style: without backgrounds,
+// context line numbers are drawn in the color they are otherwise on,
+// changed words are bold and underlined instead of highlighted, and with
+// StyleMinimal only the marker and line number keep the line's color
+func (s RenderStyle) restyle(styles *[3]lineStyle) {
+	if s == StyleBackground {
+		return
+	}
+	context := &styles[LineContext]
+	context.lineNumber = lipgloss.NewStyle().Foreground(context.lineNumber.GetBackground())
+	for _, kind := range []LineType{LineAdded, LineRemoved} {
+		style := &styles[kind]
+		style.highlight = changedWords
+		if s == StyleMinimal {
+			style.bg = lipgloss.NewStyle()
+			style.textColor = ""
+		}
+	}
+}
+
+// syntaxHighlighted reports whether the content of dl gets its syntax
+// colors: context lines unless they are dimmed, and with StyleMinimal
+// changed lines too, unless they are dimmed for being ignored
+func (r *Renderer) syntaxHighlighted(dl DiffLine) bool {
+	if dl.Ignored && r.opts.IgnoredChanges == IgnoredDim {
+		return false
+	}
+	if dl.Kind == LineContext {
+		return !r.opts.DimContext
+	}
+	return r.opts.Style == StyleMinimal
+}
+
+// gutterBar returns the bar starting the content of a side-by-side line
+// of kind: ▎ in the line's color for added lines with Accessibility, and
+// for changed lines with StyleMinimal, which has no other marks there, and
+// a blank for others, so both sides stay aligned. It returns "" when
+// neither is set.
+func (r *Renderer) gutterBar(style *lineStyle, kind LineType) string {
+	if !r.opts.Accessibility && r.opts.Style != StyleMinimal {
+		return ""
+	}
+	if kind == LineAdded || kind == LineRemoved && r.opts.Style == StyleMinimal {
+		return style.bar.Render("▎")
+	}
+	return style.bg.Render(" ")
+}
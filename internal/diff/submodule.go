@@ -0,0 +1,102 @@
+package diff
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maxSubmoduleCommits caps the commit subjects listed for a submodule bump
+const maxSubmoduleCommits = 10
+
+// subprojectRegex matches the lines git diffs a submodule as
+var subprojectRegex = regexp.MustCompile(`^Subproject commit ([0-9a-f]{7,64})(-dirty)?$`)
+
+// SubmoduleChange is a submodule moving from one commit to another. One of
+// the commits is empty when the submodule was added or removed.
+type SubmoduleChange struct {
+	OldCommit string
+	NewCommit string
+	Dirty     bool // Whether the submodule's worktree has local changes
+}
+
+// parseSubmodule recognizes a diff whose only changes are "Subproject
+// commit" lines, returning nil for anything else
+func parseSubmodule(hunks []Hunk) *SubmoduleChange {
+	var change SubmoduleChange
+	for _, hunk := range hunks {
+		for _, line := range hunk.Lines {
+			matches := subprojectRegex.FindStringSubmatch(line.Content)
+			if matches == nil {
+				return nil
+			}
+			switch line.Kind {
+			case LineRemoved:
+				change.OldCommit = matches[1]
+			case LineAdded:
+				change.NewCommit = matches[1]
+				change.Dirty = matches[2] != ""
+			default:
+				return nil
+			}
+		}
+	}
+	if change.OldCommit == "" && change.NewCommit == "" {
+		return nil
+	}
+	return &change
+}
+
+// renderSubmodule renders a submodule bump as its old and new commits,
+// followed by the subjects of the commits in between when they can be
+// looked up
+func renderSubmodule(result *DiffResult, opts RenderOptions) string {
+	change := result.Submodule
+	theme := opts.theme()
+	titleStyle := lipgloss.NewStyle().Foreground(theme.Text).Bold(true)
+	labelStyle := lipgloss.NewStyle().Foreground(theme.TextMuted)
+	oldStyle := lipgloss.NewStyle().Foreground(theme.DiffRemoved)
+	newStyle := lipgloss.NewStyle().Foreground(theme.DiffAdded)
+
+	var sb strings.Builder
+	sb.WriteString(titleStyle.Render("Submodule " + result.NewFile))
+	sb.WriteString("\n  ")
+	switch {
+	case change.OldCommit == "":
+		sb.WriteString(labelStyle.Render("added at ") + newStyle.Render(shortCommit(change.NewCommit)))
+	case change.NewCommit == "":
+		sb.WriteString(labelStyle.Render("removed, was at ") + oldStyle.Render(shortCommit(change.OldCommit)))
+	default:
+		sb.WriteString(oldStyle.Render(shortCommit(change.OldCommit)) + labelStyle.Render(" → ") + newStyle.Render(shortCommit(change.NewCommit)))
+	}
+	if change.Dirty {
+		sb.WriteString(labelStyle.Render(" (modified content)"))
+	}
+	sb.WriteString("\n")
+
+	if opts.SubmoduleLog == nil || change.OldCommit == "" || change.NewCommit == "" {
+		return sb.String()
+	}
+	subjects, err := opts.SubmoduleLog(result.NewFile, change.OldCommit, change.NewCommit)
+	if err != nil {
+		return sb.String()
+	}
+	for i, subject := range subjects {
+		if i == maxSubmoduleCommits {
+			sb.WriteString(labelStyle.Render(fmt.Sprintf("  … %d more\n", len(subjects)-i)))
+			break
+		}
+		sb.WriteString(labelStyle.Render("  • ") + subject + "\n")
+	}
+	return sb.String()
+}
+
+// shortCommit abbreviates a commit ID
+func shortCommit(id string) string {
+	if len(id) > 7 {
+		return id[:7]
+	}
+	return id
+}
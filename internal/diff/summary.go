@@ -0,0 +1,146 @@
+package diff
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/avgvstvs96/differential/internal/themes"
+)
+
+// summaryLargestFiles is how many of the files with the most changed lines
+// a Summary lists
+const summaryLargestFiles = 5
+
+// hunkSizeBuckets are the upper bounds of the hunk size histogram's buckets,
+// in changed lines; the last bucket has no bound
+var hunkSizeBuckets = []int{1, 5, 10, 25, 50, 100}
+
+// Summary is an overview of a diff: its totals, the languages it touches,
+// its largest files and how big its hunks are
+type Summary struct {
+	Files     int
+	Additions int
+	Deletions int
+	Binary    int              // Binary files among Files
+	Languages []LanguageStat   // Most changed lines first
+	Largest   []FileStat       // Files with the most changed lines, most first
+	HunkSizes []HunkSizeBucket // Smallest hunks first
+	Hunks     int
+}
+
+// LanguageStat counts the changes to the files of one language, or of one
+// extension when the language isn't recognized
+type LanguageStat struct {
+	Name      string
+	Files     int
+	Additions int
+	Deletions int
+}
+
+// HunkSizeBucket counts the hunks changing from Min to Max lines; Max is 0
+// for the last, unbounded bucket
+type HunkSizeBucket struct {
+	Min   int
+	Max   int
+	Hunks int
+}
+
+// Summarize computes the Summary of files, leaving out files hidden by a
+// FileFilter. languages picks the language of files as highlighting does.
+func Summarize(files []*DiffResult, languages Languages) Summary {
+	var s Summary
+	byName := make(map[string]*LanguageStat)
+	for i, bound := range hunkSizeBuckets {
+		bucket := HunkSizeBucket{Min: 1, Max: bound}
+		if i > 0 {
+			bucket.Min = hunkSizeBuckets[i-1] + 1
+		}
+		s.HunkSizes = append(s.HunkSizes, bucket)
+	}
+	s.HunkSizes = append(s.HunkSizes, HunkSizeBucket{Min: hunkSizeBuckets[len(hunkSizeBuckets)-1] + 1})
+
+	for _, file := range files {
+		if file.SkipReason == SkipFiltered {
+			continue
+		}
+		stat := file.Stat()
+		s.Files++
+		s.Additions += stat.Additions
+		s.Deletions += stat.Deletions
+		if stat.Binary {
+			s.Binary++
+		}
+		s.Largest = append(s.Largest, stat)
+
+		name := fileLanguage(file, languages)
+		lang, ok := byName[name]
+		if !ok {
+			lang = &LanguageStat{Name: name}
+			byName[name] = lang
+		}
+		lang.Files++
+		lang.Additions += stat.Additions
+		lang.Deletions += stat.Deletions
+
+		for _, hunk := range file.Hunks {
+			changed := 0
+			for _, dl := range hunk.Lines {
+				if dl.Kind == LineAdded || dl.Kind == LineRemoved {
+					changed++
+				}
+			}
+			if changed == 0 {
+				continue
+			}
+			s.Hunks++
+			for i := range s.HunkSizes {
+				if bucket := &s.HunkSizes[i]; bucket.Max == 0 || changed <= bucket.Max {
+					bucket.Hunks++
+					break
+				}
+			}
+		}
+	}
+
+	for _, lang := range byName {
+		s.Languages = append(s.Languages, *lang)
+	}
+	sort.Slice(s.Languages, func(i, j int) bool {
+		a, b := s.Languages[i], s.Languages[j]
+		if a.Additions+a.Deletions != b.Additions+b.Deletions {
+			return a.Additions+a.Deletions > b.Additions+b.Deletions
+		}
+		return a.Name < b.Name
+	})
+
+	sort.SliceStable(s.Largest, func(i, j int) bool {
+		return s.Largest[i].Additions+s.Largest[i].Deletions > s.Largest[j].Additions+s.Largest[j].Deletions
+	})
+	s.Largest = s.Largest[:min(len(s.Largest), summaryLargestFiles)]
+	return s
+}
+
+// fileLanguage names the language of file: the configured lexer, the one
+// its name tells, or the one a shebang or modeline asks for. Files of no
+// known language go by their extension, and "Other" without one.
+func fileLanguage(file *DiffResult, languages Languages) string {
+	name := file.DisplayName()
+	if lexer := languages.For(name); lexer != "" {
+		if language := themes.LexerName(lexer); language != "" {
+			return language
+		}
+	}
+	if language := themes.LanguageName(path.Base(name)); language != "" {
+		return language
+	}
+	if lexer := themes.DetectLanguage(headLines(file)); lexer != "" {
+		if language := themes.LexerName(lexer); language != "" {
+			return language
+		}
+	}
+	if ext := path.Ext(name); ext != "" {
+		return strings.ToLower(ext)
+	}
+	return "Other"
+}
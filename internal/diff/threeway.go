@@ -0,0 +1,168 @@
+package diff
+
+import (
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// ThreeWayRow is one aligned row of a three-way diff. Base uses OldLineNo for
+// its line number, the sides use NewLineNo. A nil side next to a non-nil Base
+// means that side deleted the base line.
+type ThreeWayRow struct {
+	Base   *DiffLine
+	Ours   *DiffLine
+	Theirs *DiffLine
+}
+
+// Changed reports whether either side differs from base on this row
+func (r ThreeWayRow) Changed() bool {
+	return r.Base == nil || r.Base.Kind != LineContext
+}
+
+// Conflicting reports whether both sides changed this row
+func (r ThreeWayRow) Conflicting() bool {
+	return sideChanged(r.Base, r.Ours) && sideChanged(r.Base, r.Theirs)
+}
+
+// ThreeWayResult contains a base file aligned against two modified versions
+type ThreeWayResult struct {
+	BaseFile   string
+	OursFile   string
+	TheirsFile string
+	Rows       []ThreeWayRow
+}
+
+// threeWaySide holds one side's lines aligned to base line positions
+type threeWaySide struct {
+	lines []*DiffLine  // Line shown next to each base line (nil if deleted)
+	extra [][]DiffLine // Lines inserted before each base line (len(base)+1 slots)
+}
+
+// ComputeThreeWay aligns ours and theirs against base line by line
+func ComputeThreeWay(baseFile, oursFile, theirsFile, base, ours, theirs string) *ThreeWayResult {
+	baseLines := splitLines(base)
+
+	oursSide := alignSide(baseLines, diffLines(base, ours))
+	theirsSide := alignSide(baseLines, diffLines(base, theirs))
+
+	result := &ThreeWayResult{
+		BaseFile:   baseFile,
+		OursFile:   oursFile,
+		TheirsFile: theirsFile,
+	}
+
+	for i := 0; i <= len(baseLines); i++ {
+		// Lines inserted before this base line, paired up across sides
+		oursExtra, theirsExtra := oursSide.extra[i], theirsSide.extra[i]
+		for j := 0; j < len(oursExtra) || j < len(theirsExtra); j++ {
+			var row ThreeWayRow
+			if j < len(oursExtra) {
+				row.Ours = &oursExtra[j]
+			}
+			if j < len(theirsExtra) {
+				row.Theirs = &theirsExtra[j]
+			}
+			result.Rows = append(result.Rows, row)
+		}
+
+		if i == len(baseLines) {
+			break
+		}
+
+		row := ThreeWayRow{
+			Base: &DiffLine{
+				Kind:      LineContext,
+				OldLineNo: i + 1,
+				Content:   baseLines[i],
+			},
+			Ours:   oursSide.lines[i],
+			Theirs: theirsSide.lines[i],
+		}
+		if sideChanged(row.Base, row.Ours) || sideChanged(row.Base, row.Theirs) {
+			row.Base.Kind = LineRemoved
+		}
+		highlightAgainstBase(row.Base, row.Ours)
+		highlightAgainstBase(row.Base, row.Theirs)
+
+		result.Rows = append(result.Rows, row)
+	}
+
+	return result
+}
+
+// alignSide distributes one side's lines over the base line positions,
+// placing replacement lines next to the base lines they replace
+func alignSide(baseLines []string, ops []lineOp) threeWaySide {
+	side := threeWaySide{
+		lines: make([]*DiffLine, len(baseLines)),
+		extra: make([][]DiffLine, len(baseLines)+1),
+	}
+
+	baseIdx, sideNo := 0, 1
+	for i := 0; i < len(ops); i++ {
+		op := ops[i]
+		switch op.Type {
+		case diffmatchpatch.DiffEqual:
+			for _, line := range op.Lines {
+				side.lines[baseIdx] = &DiffLine{
+					Kind:      LineContext,
+					OldLineNo: baseIdx + 1,
+					NewLineNo: sideNo,
+					Content:   line,
+				}
+				baseIdx++
+				sideNo++
+			}
+
+		case diffmatchpatch.DiffDelete:
+			var inserted []string
+			if i+1 < len(ops) && ops[i+1].Type == diffmatchpatch.DiffInsert {
+				inserted = ops[i+1].Lines
+				i++
+			}
+			// Replacement lines sit next to the deleted base lines
+			for j := range op.Lines {
+				if j < len(inserted) {
+					side.lines[baseIdx] = &DiffLine{Kind: LineAdded, NewLineNo: sideNo, Content: inserted[j]}
+					sideNo++
+				}
+				baseIdx++
+			}
+			// Any remaining lines are inserted after the replaced block
+			for j := len(op.Lines); j < len(inserted); j++ {
+				side.extra[baseIdx] = append(side.extra[baseIdx], DiffLine{Kind: LineAdded, NewLineNo: sideNo, Content: inserted[j]})
+				sideNo++
+			}
+
+		case diffmatchpatch.DiffInsert:
+			for _, line := range op.Lines {
+				side.extra[baseIdx] = append(side.extra[baseIdx], DiffLine{Kind: LineAdded, NewLineNo: sideNo, Content: line})
+				sideNo++
+			}
+		}
+	}
+
+	return side
+}
+
+// sideChanged reports whether a side's line differs from the base line
+func sideChanged(base, side *DiffLine) bool {
+	if base == nil {
+		return side != nil
+	}
+	return side == nil || side.Kind != LineContext
+}
+
+// highlightAgainstBase computes intra-line segments for a side line that
+// replaced a base line
+func highlightAgainstBase(base, side *DiffLine) {
+	if side == nil || side.Kind != LineAdded {
+		return
+	}
+
+	pair := Hunk{Lines: []DiffLine{
+		{Kind: LineRemoved, Content: base.Content},
+		{Kind: LineAdded, Content: side.Content},
+	}}
+	HighlightIntralineChanges(&pair)
+	side.Segments = pair.Lines[1].Segments
+}
@@ -0,0 +1,147 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/avgvstvs96/differential/internal/themes"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// SemanticToken colors a range of a line with a scope, as reported by an
+// editor's language server or tree-sitter
+type SemanticToken struct {
+	Line  int    `json:"line"`  // 1-based line number in the new version of the file
+	Start int    `json:"start"` // 0-based character offset where the token starts
+	End   int    `json:"end"`   // Character offset just past the token
+	Scope string `json:"scope"` // e.g. "keyword", "string.quoted" or "function"
+}
+
+// SemanticTokens holds the tokens of each file by path, highlighting its
+// lines in place of chroma
+type SemanticTokens map[string][]SemanticToken
+
+// ParseSemanticTokens parses tokens in the JSON form
+//
+//	{"files": {"main.go": [{"line": 3, "start": 0, "end": 4, "scope": "keyword"}]}}
+func ParseSemanticTokens(data []byte) (SemanticTokens, error) {
+	var doc struct {
+		Files SemanticTokens `json:"files"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid semantic tokens: %w", err)
+	}
+	for path, tokens := range doc.Files {
+		for _, token := range tokens {
+			if token.Line < 1 || token.Start < 0 || token.End < token.Start {
+				return nil, fmt.Errorf("invalid semantic token in %s: line %d, %d-%d", path, token.Line, token.Start, token.End)
+			}
+		}
+	}
+	return doc.Files, nil
+}
+
+// forFile returns the tokens of a file by line number, or nil when there
+// are none. Paths match exactly or, for absolute paths from an editor, by
+// their ending.
+func (t SemanticTokens) forFile(filename string) map[int][]SemanticToken {
+	if len(t) == 0 || filename == "" {
+		return nil
+	}
+	tokens, ok := t[filename]
+	if !ok {
+		// The shortest matching path wins, whatever the map order
+		match := ""
+		for path, candidate := range t {
+			if strings.HasSuffix(path, "/"+filename) && (match == "" || len(path) < len(match) || len(path) == len(match) && path < match) {
+				tokens, ok, match = candidate, true, path
+			}
+		}
+	}
+	if !ok {
+		return nil
+	}
+
+	lines := make(map[int][]SemanticToken)
+	for _, token := range tokens {
+		lines[token.Line] = append(lines[token.Line], token)
+	}
+	for _, line := range lines {
+		sort.SliceStable(line, func(i, j int) bool { return line[i].Start < line[j].Start })
+	}
+	return lines
+}
+
+// scopeColor returns the theme color of a scope, from TextMate-style
+// names to LSP token types
+func scopeColor(t *themes.ThemeColors, scope string) (lipgloss.Color, bool) {
+	switch scope {
+	case "keyword", "modifier", "storage":
+		return t.SyntaxKeyword, true
+	case "operator", "keyword.operator":
+		return t.SyntaxOperator, true
+	case "function", "method", "macro", "entity.name.function", "support.function":
+		return t.SyntaxFunction, true
+	case "type", "class", "struct", "interface", "enum", "typeParameter", "namespace",
+		"storage.type", "entity.name.type", "support.type":
+		return t.SyntaxType, true
+	case "variable", "parameter", "property", "enumMember":
+		return t.SyntaxVariable, true
+	case "string", "regexp":
+		return t.SyntaxString, true
+	case "number", "constant.numeric":
+		return t.SyntaxNumber, true
+	case "comment":
+		return t.SyntaxComment, true
+	case "punctuation":
+		return t.SyntaxPunctuation, true
+	}
+	return "", false
+}
+
+// scopeSequence returns the ANSI sequence coloring a scope, or "" for
+// scopes without a color. The most specific known scope wins, so
+// "keyword.operator.assignment" is an operator and "keyword.control" a
+// keyword.
+func (r *Renderer) scopeSequence(scope string) string {
+	for scope != "" {
+		if color, ok := scopeColor(r.theme, scope); ok {
+			if !strings.HasPrefix(string(color), "#") {
+				return ""
+			}
+			red, green, blue := hexToRGB(string(color))
+			return fmt.Sprintf("\x1b[38;2;%d;%d;%dm", red, green, blue)
+		}
+		i := strings.LastIndexByte(scope, '.')
+		if i < 0 {
+			break
+		}
+		scope = scope[:i]
+	}
+	return ""
+}
+
+// highlightTokens colors a line with its semantic tokens. Tokens
+// overlapping an earlier one and ranges past the line are left out.
+func (r *Renderer) highlightTokens(line string, tokens []SemanticToken) string {
+	runes := []rune(line)
+	var sb strings.Builder
+	pos := 0
+	for _, token := range tokens {
+		start, end := token.Start, min(token.End, len(runes))
+		if start < pos || start >= end {
+			continue
+		}
+		sequence := r.scopeSequence(token.Scope)
+		if sequence == "" {
+			continue
+		}
+		sb.WriteString(string(runes[pos:start]))
+		sb.WriteString(sequence + string(runes[start:end]) + "\x1b[39m")
+		pos = end
+	}
+	sb.WriteString(string(runes[pos:]))
+	return sb.String()
+}
@@ -0,0 +1,152 @@
+package diff
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Reasons a diff was truncated
+const (
+	TruncatedFiles   = "max files"
+	TruncatedLines   = "max lines"
+	TruncatedTimeout = "timeout"
+)
+
+// Limits bound how much of a diff is rendered; zero fields are unlimited
+type Limits struct {
+	Files int // Files shown, including skipped ones
+	Lines int // Hunk lines shown across all files, not counting headers
+}
+
+// Truncation tells what a limit left out of a diff
+type Truncation struct {
+	Reason string // The limit that was reached, e.g. TruncatedLines
+	Files  int    // Files not shown in full
+	Lines  int    // Hunk lines not shown
+}
+
+// Truncate returns the files that fit within limits, cutting the last one
+// short when the line limit falls inside it, and what was left out. The
+// truncation is nil when everything fits. files are not modified.
+func Truncate(files []*DiffResult, limits Limits) ([]*DiffResult, *Truncation) {
+	var truncation *Truncation
+	if limits.Files > 0 && len(files) > limits.Files {
+		truncation = &Truncation{
+			Reason: TruncatedFiles,
+			Files:  len(files) - limits.Files,
+			Lines:  countShownLines(files[limits.Files:]),
+		}
+		files = files[:limits.Files]
+	}
+	if limits.Lines <= 0 {
+		return files, truncation
+	}
+
+	budget := limits.Lines
+	for i, file := range files {
+		lines := countShownLines(files[i : i+1])
+		if lines <= budget {
+			budget -= lines
+			continue
+		}
+
+		// Keep the hunks that fit and the start of the one that doesn't
+		shown := files[:i:i]
+		if budget > 0 {
+			cut := *file
+			cut.Hunks = nil
+			for _, hunk := range file.Hunks {
+				if budget == 0 {
+					break
+				}
+				if !hunk.Folded() {
+					hunk.Lines = hunk.Lines[:min(len(hunk.Lines), budget)]
+					budget -= len(hunk.Lines)
+				}
+				cut.Hunks = append(cut.Hunks, hunk)
+			}
+			shown = append(shown, &cut)
+		}
+
+		if truncation == nil {
+			truncation = &Truncation{}
+		}
+		truncation.Reason = TruncatedLines
+		truncation.Files += len(files) - i
+		truncation.Lines += countShownLines(files[i:]) - countShownLines(shown[i:])
+		return shown, truncation
+	}
+	return files, truncation
+}
+
+// countShownLines counts the hunk lines rendered for files. Skipped,
+// collapsed and summarized files and folded hunks show none.
+func countShownLines(files []*DiffResult) int {
+	count := 0
+	for _, file := range files {
+		if file.SkipReason != "" || file.Collapsed || file.IsBinary || file.Submodule != nil || file.LFS != nil || file.EOL != nil {
+			continue
+		}
+		for _, hunk := range file.Hunks {
+			if !hunk.Folded() {
+				count += len(hunk.Lines)
+			}
+		}
+	}
+	return count
+}
+
+// RenderFilesLimited renders the files within limits like RenderFiles,
+// followed by a footer telling what was left out. When ctx's deadline
+// passes, the files rendered in full so far are kept and the rest is
+// truncated; other cancellations return ctx's error.
+func (r *Renderer) RenderFilesLimited(ctx context.Context, files []*DiffResult, limits Limits) (string, error) {
+	output, _, err := r.RenderFilesLimitedWithOffsets(ctx, files, limits)
+	return output, err
+}
+
+// RenderFilesLimitedWithOffsets renders like RenderFilesLimited and also
+// returns the output line at which each file shown starts, followed by the
+// line the files end at, above the footer
+func (r *Renderer) RenderFilesLimitedWithOffsets(ctx context.Context, files []*DiffResult, limits Limits) (string, []int, error) {
+	shown, truncation := Truncate(files, limits)
+
+	r.ctx = ctx
+	output, offsets := r.RenderFilesWithOffsets(shown)
+	r.ctx = nil
+	if err := ctx.Err(); err != nil {
+		if !errors.Is(err, context.DeadlineExceeded) {
+			return "", nil, err
+		}
+
+		// The render stopped after the last file it finished
+		kept := len(offsets)
+		if truncation == nil {
+			truncation = &Truncation{}
+		}
+		truncation.Reason = TruncatedTimeout
+		truncation.Files += len(shown) - kept
+		truncation.Lines += countShownLines(shown[kept:])
+	}
+	offsets = append(offsets, strings.Count(output, "\n"))
+
+	// A diff of several files truncated to one still names it
+	if len(files) > 1 && len(shown) == 1 && output != "" && !r.opts.FileHeaders {
+		output = r.renderFileHeader(shown[0]) + "\n" + output
+	}
+	if truncation != nil {
+		output += r.renderTruncation(truncation) + "\n"
+	}
+	return output, offsets, nil
+}
+
+// renderTruncation renders the footer below a truncated diff
+func (r *Renderer) renderTruncation(t *Truncation) string {
+	footer := fmt.Sprintf("✂ output truncated (%s): %d file(s)", t.Reason, t.Files)
+	if t.Lines > 0 {
+		footer += fmt.Sprintf(" and %d line(s)", t.Lines)
+	}
+	return r.skippedStyle.Render(footer + " not shown")
+}
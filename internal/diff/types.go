@@ -1,5 +1,12 @@
 package diff
 
+import (
+	"regexp"
+
+	"github.com/avgvstvs96/differential/internal/preview"
+	"github.com/avgvstvs96/differential/internal/themes"
+)
+
 // LineType represents the type of change for a line in a diff
 type LineType int
 
@@ -24,20 +31,89 @@ type DiffLine struct {
 	Kind      LineType  // Type of line (added, removed, context)
 	Content   string    // Content of the line (without diff markers)
 	Segments  []Segment // Segments for intraline highlighting
+	CRLF      bool      // Whether the line ended in "\r\n"; Content never holds the "\r"
+
+	// NoNewline marks the last line of a side that doesn't end in a newline:
+	// the old side for removed lines, the new side for added lines and both
+	// for context lines
+	NoNewline bool
+
+	// Moved marks the lines of a block removed in one place and added in
+	// another, as DetectMovedLines finds them
+	Moved bool
+
+	// Ignored marks the lines of a change whose lines all match the
+	// patterns IgnoreMatchingLines was given
+	Ignored bool
+
+	// whitespaceOnly marks added lines rendered with a badge for only
+	// changing whitespace
+	whitespaceOnly bool
+
+	// eolOnly marks changed lines paired with a line of the same content
+	// and the other line ending, whose carriage returns are shown
+	eolOnly bool
 }
 
 // Hunk represents a contiguous block of changes in a diff
 type Hunk struct {
 	Header string     // The @@ header line
 	Lines  []DiffLine // All lines in this hunk
+
+	// Set by FoldDuplicateHunks: the other files making the same change, on
+	// the instance that is shown, and the file showing it, on folded ones
+	Duplicates  []string
+	DuplicateOf string
+
+	// Approved is set by MarkApproved when the hunk's change was approved
+	Approved bool
+	// Staged marks a hunk the reviewer marked as staged in a diff that
+	// can't be staged, such as a resumed session
+	Staged bool
+	// Unfolded shows the long context runs that RenderOptions.FoldContext
+	// would fold
+	Unfolded bool
+	// Ignored is set by IgnoreMatchingLines when every change of the hunk
+	// is ignored and hidden
+	Ignored bool
+}
+
+// Folded reports whether the hunk renders as just its header, being a
+// duplicate, approved or ignored
+func (h Hunk) Folded() bool {
+	return h.DuplicateOf != "" || h.Approved || h.Ignored
 }
 
 // DiffResult contains the complete parsed diff
 type DiffResult struct {
-	OldFile string // Old file path
-	NewFile string // New file path
-	Hunks   []Hunk // All hunks in the diff
-	IsBinary bool  // Whether this is a binary file diff
+	OldFile  string // Old file path
+	NewFile  string // New file path
+	OldIndex string // Old blob ID from the git "index" header, if any
+	NewIndex string // New blob ID from the git "index" header, if any
+	OldMode  string // Old file mode from the git headers, e.g. 100644, if any
+	NewMode  string // New file mode from the git headers, if any
+	Encoding string // Encoding the lines were transcoded from, empty for UTF-8
+	Hunks    []Hunk // All hunks in the diff
+	IsBinary bool   // Whether this is a binary file diff
+	Renamed  bool   // Whether git reported the file as renamed
+	Copied   bool   // Whether git reported the file as a copy of OldFile
+
+	// Submodule is set when the diff only moves a submodule to another commit
+	Submodule *SubmoduleChange
+	// LFS is set when both sides are Git LFS pointer files
+	LFS *LFSChange
+	// EOL is set when the diff only converts line endings
+	EOL *EOLChange
+
+	// SkipReason explains why the file's hunks are hidden (e.g. "filtered");
+	// skipped files render as a single collapsed line
+	SkipReason string
+
+	// Collapsed hides the hunks behind a one-line summary at the user's
+	// request, or for Generated files in a multi-file view
+	Collapsed bool
+	// Generated is set on files GeneratedFiles recognizes as generated
+	Generated bool
 }
 
 // LinePair is used for side-by-side rendering
@@ -61,4 +137,95 @@ type RenderOptions struct {
 	ShowLineNumbers bool     // Whether to show line numbers
 	ContextLines    int      // Number of context lines
 	TabWidth        int      // Tab character width
-}
\ No newline at end of file
+	Gutter          Gutter   // Line number layout when ShowLineNumbers is set
+	Icons           bool     // Whether file headers show Nerd Font icons
+	DimContext      bool     // Whether context lines are dimmed so changes stand out
+
+	// FullFile shows the whole new version of each file, loaded through
+	// LoadBlob, with a gutter marking the changed lines instead of hunks
+	FullFile bool
+
+	// FileHeaders shows the header of a file even when it is the only one,
+	// e.g. below a commit message
+	FileHeaders bool
+
+	// PlainColumns separates side-by-side columns with ASCII markers as in
+	// diff -y and pads them without a background, so copied panels stay
+	// readable as plain text
+	PlainColumns bool
+
+	// Style selects whether changed lines are filled with the diff
+	// backgrounds, or only colored, keeping the terminal's background
+	Style RenderStyle
+
+	// Accessibility marks changes with more than color, for color blind
+	// readers: removed lines are underlined, added lines have a bar in
+	// side-by-side view, and changed lines a dashed one in full-file view
+	Accessibility bool
+
+	// HunkContext selects the function context shown in hunk headers
+	HunkContext HunkContextMode
+	// HunkStats adds the number of added, removed and modified lines to
+	// hunk headers
+	HunkStats bool
+	// Intraline selects the units changes within a changed line are
+	// marked in
+	Intraline IntralineGranularity
+	// ShowInvisibles marks tabs, spaces and trailing whitespace and shows
+	// control, zero-width and bidi characters as placeholders
+	ShowInvisibles bool
+
+	// FoldContext folds runs of more context lines than this within hunks
+	// behind a divider; 0 shows them all
+	FoldContext int
+	// Whitespace selects how changes that only touch whitespace are shown
+	Whitespace WhitespaceMode
+	// IgnoredChanges selects how the changes IgnoreMatchingLines marked
+	// are shown
+	IgnoredChanges IgnoredMode
+
+	// Tokens highlights the files they cover with an editor's semantic
+	// tokens instead of chroma
+	Tokens SemanticTokens
+
+	// Languages overrides the syntax highlighting detected from file names
+	Languages Languages
+
+	// Highlight marks matches in added and removed lines, e.g. of a search
+	Highlight *regexp.Regexp
+
+	// Links makes file headers and line numbers OSC 8 hyperlinks, e.g. to
+	// the files on the forge hosting the repository
+	Links LinkFunc
+
+	// Theme colors the output. When nil the current theme is used; set it
+	// to render with different themes concurrently.
+	Theme *themes.ThemeColors
+
+	// LoadBlob returns the contents of a file version for binary previews.
+	// id is the blob ID from the diff header and may be empty.
+	LoadBlob      func(path, id string) ([]byte, error)
+	ImageProtocol preview.Protocol // How image previews are drawn
+
+	// LoadBlobPrefix returns the first n bytes of a file version and its
+	// size, for the hex dumps of binary files. LoadBlob reads them whole
+	// when it is nil.
+	LoadBlobPrefix func(path, id string, n int) ([]byte, int64, error)
+
+	// BinaryBytes is how many differing bytes of other binary files are
+	// shown as a hex dump below their sizes and modes
+	BinaryBytes int
+
+	// SubmoduleLog returns "<id> <subject>" lines for the commits a submodule
+	// moved through from one commit to another, newest first. Submodule
+	// bumps are shown without their commits when it is nil.
+	SubmoduleLog func(path, from, to string) ([]string, error)
+}
+
+// theme returns the theme to render with
+func (o RenderOptions) theme() *themes.ThemeColors {
+	if o.Theme != nil {
+		return o.Theme
+	}
+	return themes.GetCurrentTheme()
+}
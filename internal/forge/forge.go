@@ -0,0 +1,395 @@
+package forge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Kinds of forge
+const (
+	GitHub = "github"
+	GitLab = "gitlab"
+)
+
+// Ref names a pull request (merge request on GitLab)
+type Ref struct {
+	Kind    string // GitHub or GitLab
+	Host    string // e.g. github.com
+	Project string // owner/repo, or group/subgroup/project on GitLab
+	Number  int
+
+	// API is the base URL of the host's REST API; ParseRef derives it from
+	// Host
+	API string
+}
+
+// PullRequest is a pull request with its diff
+type PullRequest struct {
+	Ref    Ref
+	Title  string
+	Author string
+	Base   string // The branch merged into
+	Head   string // The branch merged
+	URL    string
+	Diff   string // In git diff format
+}
+
+var (
+	githubURL = regexp.MustCompile(`^https?://([^/]+)/([^/]+/[^/]+)/pull/(\d+)`)
+	gitlabURL = regexp.MustCompile(`^https?://([^/]+)/(.+?)/(?:-/)?merge_requests/(\d+)`)
+	remoteURL = regexp.MustCompile(`^(?:[a-z+]+://)?(?:[^@/]+@)?([^/:]+)(?::\d+)?[:/](.+?)(?:\.git)?/?$`)
+)
+
+// ParseRef parses a pull request URL, or a number of a pull request of the
+// repository at remote, such as git@github.com:owner/repo.git
+func ParseRef(s, remote string) (Ref, error) {
+	var ref Ref
+	if m := githubURL.FindStringSubmatch(s); m != nil {
+		ref = Ref{Kind: GitHub, Host: m[1], Project: m[2]}
+		ref.Number, _ = strconv.Atoi(m[3])
+	} else if m := gitlabURL.FindStringSubmatch(s); m != nil {
+		ref = Ref{Kind: GitLab, Host: m[1], Project: m[2]}
+		ref.Number, _ = strconv.Atoi(m[3])
+	} else if n, err := strconv.Atoi(strings.TrimPrefix(strings.TrimPrefix(s, "#"), "!")); err == nil && n > 0 {
+		repo, err := ParseRemote(remote)
+		if err != nil {
+			return Ref{}, fmt.Errorf("%w; pass the pull request URL", err)
+		}
+		ref = Ref{Kind: repo.Kind, Host: repo.Host, Project: repo.Project, Number: n}
+	} else {
+		return Ref{}, fmt.Errorf("invalid pull request %q (want a URL or a number)", s)
+	}
+
+	switch {
+	case ref.Kind == GitLab:
+		ref.API = "https://" + ref.Host + "/api/v4"
+	case ref.Host == "github.com":
+		ref.API = "https://api.github.com"
+	default:
+		// GitHub Enterprise Server
+		ref.API = "https://" + ref.Host + "/api/v3"
+	}
+	return ref, nil
+}
+
+// String returns the usual short name of the pull request, e.g.
+// owner/repo#12 or group/project!34
+func (r Ref) String() string {
+	if r.Kind == GitLab {
+		return fmt.Sprintf("%s!%d", r.Project, r.Number)
+	}
+	return fmt.Sprintf("%s#%d", r.Project, r.Number)
+}
+
+// Repo is a repository hosted on a forge
+type Repo struct {
+	Kind    string // GitHub or GitLab
+	Host    string // e.g. github.com
+	Project string // owner/repo, or group/subgroup/project on GitLab
+}
+
+// ParseRemote parses the URL of a git remote hosted on GitHub or GitLab,
+// such as git@github.com:owner/repo.git or https://gitlab.com/group/project
+func ParseRemote(remote string) (Repo, error) {
+	m := remoteURL.FindStringSubmatch(strings.TrimSpace(remote))
+	if m == nil {
+		return Repo{}, fmt.Errorf("can't tell the repository from remote %q", remote)
+	}
+	repo := Repo{Host: m[1], Project: m[2]}
+	switch {
+	case repo.Host == "github.com" || strings.Contains(repo.Host, "github"):
+		repo.Kind = GitHub
+	case repo.Host == "gitlab.com" || strings.Contains(repo.Host, "gitlab"):
+		repo.Kind = GitLab
+	default:
+		return Repo{}, fmt.Errorf("can't tell whether %s is GitHub or GitLab", repo.Host)
+	}
+	return repo, nil
+}
+
+// BlobURL returns the web page of the file at path at revision rev,
+// scrolled to line unless it is 0
+func (r Repo) BlobURL(rev, path string, line int) string {
+	blob := "/blob/"
+	if r.Kind == GitLab {
+		blob = "/-/blob/"
+	}
+	u := "https://" + r.Host + "/" + r.Project + blob + rev + "/" + (&url.URL{Path: path}).EscapedPath()
+	if line > 0 {
+		u += "#L" + strconv.Itoa(line)
+	}
+	return u
+}
+
+// Client fetches pull requests
+type Client struct {
+	HTTP  *http.Client // http.DefaultClient when nil
+	Token string       // Sent when set, for private repositories and higher rate limits
+}
+
+// publicHosts are the hosts the tokens of the environment are sent to
+// without being trusted explicitly
+var publicHosts = map[string]bool{"github.com": true, "gitlab.com": true}
+
+// TokenFromEnv returns the API token for ref from the environment:
+// GITHUB_TOKEN or GH_TOKEN for GitHub and GITLAB_TOKEN for GitLab. Only
+// github.com, gitlab.com and the self-hosted hosts in trusted get one, so
+// that a crafted URL or remote can't have the token sent to its server.
+func TokenFromEnv(ref Ref, trusted []string) string {
+	if !trustedHost(ref.Host, trusted) {
+		return ""
+	}
+	if ref.Kind == GitLab {
+		return os.Getenv("GITLAB_TOKEN")
+	}
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		return token
+	}
+	return os.Getenv("GH_TOKEN")
+}
+
+// trustedHost reports whether the tokens of the environment may be sent to
+// host
+func trustedHost(host string, trusted []string) bool {
+	host = strings.ToLower(host)
+	if publicHosts[host] {
+		return true
+	}
+	for _, t := range trusted {
+		if strings.ToLower(t) == host {
+			return true
+		}
+	}
+	return false
+}
+
+// Fetch fetches a pull request and its diff, following pagination for
+// pull requests with many files
+func (c *Client) Fetch(ctx context.Context, ref Ref) (*PullRequest, error) {
+	if ref.Kind == GitLab {
+		return c.fetchGitLab(ctx, ref)
+	}
+	return c.fetchGitHub(ctx, ref)
+}
+
+// perPage is the page size asked for when listing files, the most both
+// services allow
+const perPage = 100
+
+// get fetches an API URL into v, returning the response headers for
+// pagination
+func (c *Client) get(ctx context.Context, ref Ref, u string, v any) (http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	// Pages are fetched from the URLs the API returns, which must not take
+	// the token elsewhere
+	token := c.Token
+	if !strings.HasPrefix(u, ref.API+"/") {
+		token = ""
+	}
+	if ref.Kind == GitLab {
+		if token != "" {
+			req.Header.Set("PRIVATE-TOKEN", token)
+		}
+	} else {
+		req.Header.Set("Accept", "application/vnd.github+json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+
+	client := c.HTTP
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", ref, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Message any `json:"message"`
+		}
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+		json.Unmarshal(body, &apiErr)
+		msg := resp.Status
+		if apiErr.Message != nil {
+			msg += ": " + fmt.Sprint(apiErr.Message)
+		}
+		if (resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusUnauthorized) && c.Token == "" {
+			msg += fmt.Sprintf(" (set %s for private repositories, and list self-hosted hosts in forge.token_hosts)", tokenVariable(ref.Kind))
+		}
+		return nil, fmt.Errorf("failed to fetch %s: %s", ref, msg)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", ref, err)
+	}
+	return resp.Header, nil
+}
+
+// tokenVariable names the environment variable holding the token for kind
+func tokenVariable(kind string) string {
+	if kind == GitLab {
+		return "GITLAB_TOKEN"
+	}
+	return "GITHUB_TOKEN"
+}
+
+// nextLink matches the next page in a GitHub Link header
+var nextLink = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// fetchGitHub fetches a GitHub pull request. Its diff is put together from
+// the paginated file list, as the diff media type fails for big pull
+// requests.
+func (c *Client) fetchGitHub(ctx context.Context, ref Ref) (*PullRequest, error) {
+	base := fmt.Sprintf("%s/repos/%s/pulls/%d", ref.API, ref.Project, ref.Number)
+
+	var pr struct {
+		Title   string `json:"title"`
+		HTMLURL string `json:"html_url"`
+		User    struct {
+			Login string `json:"login"`
+		} `json:"user"`
+		Base struct {
+			Ref string `json:"ref"`
+		} `json:"base"`
+		Head struct {
+			Label string `json:"label"`
+			Ref   string `json:"ref"`
+		} `json:"head"`
+	}
+	if _, err := c.get(ctx, ref, base, &pr); err != nil {
+		return nil, err
+	}
+
+	var sb strings.Builder
+	next := fmt.Sprintf("%s/files?per_page=%d", base, perPage)
+	for next != "" {
+		var files []struct {
+			Filename         string `json:"filename"`
+			PreviousFilename string `json:"previous_filename"`
+			Status           string `json:"status"`
+			Patch            string `json:"patch"`
+		}
+		header, err := c.get(ctx, ref, next, &files)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			oldPath := f.Filename
+			if f.PreviousFilename != "" {
+				oldPath = f.PreviousFilename
+			}
+			writeFileDiff(&sb, oldPath, f.Filename, f.Status == "added", f.Status == "removed", f.Patch)
+		}
+
+		next = ""
+		if m := nextLink.FindStringSubmatch(header.Get("Link")); m != nil {
+			next = m[1]
+		}
+	}
+
+	head := pr.Head.Ref
+	if pr.Head.Label != "" {
+		head = pr.Head.Label
+	}
+	return &PullRequest{
+		Ref:    ref,
+		Title:  pr.Title,
+		Author: pr.User.Login,
+		Base:   pr.Base.Ref,
+		Head:   head,
+		URL:    pr.HTMLURL,
+		Diff:   sb.String(),
+	}, nil
+}
+
+// fetchGitLab fetches a GitLab merge request and the pages of its diffs
+func (c *Client) fetchGitLab(ctx context.Context, ref Ref) (*PullRequest, error) {
+	base := fmt.Sprintf("%s/projects/%s/merge_requests/%d", ref.API, url.PathEscape(ref.Project), ref.Number)
+
+	var mr struct {
+		Title        string `json:"title"`
+		WebURL       string `json:"web_url"`
+		SourceBranch string `json:"source_branch"`
+		TargetBranch string `json:"target_branch"`
+		Author       struct {
+			Username string `json:"username"`
+		} `json:"author"`
+	}
+	if _, err := c.get(ctx, ref, base, &mr); err != nil {
+		return nil, err
+	}
+
+	var sb strings.Builder
+	for page := "1"; page != ""; {
+		var diffs []struct {
+			OldPath     string `json:"old_path"`
+			NewPath     string `json:"new_path"`
+			NewFile     bool   `json:"new_file"`
+			DeletedFile bool   `json:"deleted_file"`
+			Diff        string `json:"diff"`
+		}
+		header, err := c.get(ctx, ref, fmt.Sprintf("%s/diffs?per_page=%d&page=%s", base, perPage, page), &diffs)
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range diffs {
+			writeFileDiff(&sb, d.OldPath, d.NewPath, d.NewFile, d.DeletedFile, d.Diff)
+		}
+		page = header.Get("X-Next-Page")
+	}
+
+	return &PullRequest{
+		Ref:    ref,
+		Title:  mr.Title,
+		Author: mr.Author.Username,
+		Base:   mr.TargetBranch,
+		Head:   mr.SourceBranch,
+		URL:    mr.WebURL,
+		Diff:   sb.String(),
+	}, nil
+}
+
+// writeFileDiff writes the git diff of one file from the hunks an API
+// returned for it. Files without hunks, such as binary files and ones too
+// big for the API, get just their header.
+func writeFileDiff(sb *strings.Builder, oldPath, newPath string, added, deleted bool, patch string) {
+	fmt.Fprintf(sb, "diff --git a/%s b/%s\n", oldPath, newPath)
+	switch {
+	case added:
+		sb.WriteString("new file mode 100644\n")
+	case deleted:
+		sb.WriteString("deleted file mode 100644\n")
+	case oldPath != newPath:
+		fmt.Fprintf(sb, "rename from %s\nrename to %s\n", oldPath, newPath)
+	}
+	if patch == "" {
+		return
+	}
+
+	from, to := "a/"+oldPath, "b/"+newPath
+	if added {
+		from = "/dev/null"
+	}
+	if deleted {
+		to = "/dev/null"
+	}
+	fmt.Fprintf(sb, "--- %s\n+++ %s\n", from, to)
+	sb.WriteString(patch)
+	if !strings.HasSuffix(patch, "\n") {
+		sb.WriteString("\n")
+	}
+}
@@ -0,0 +1,128 @@
+// Package fuzzy ranks strings, typically file paths, by how well a typed
+// pattern matches them as a subsequence
+package fuzzy
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Score weights of a match
+const (
+	scoreChar        = 16 // Every matched character
+	bonusConsecutive = 8  // A character right after the previous match
+	bonusBoundary    = 10 // A character starting a word or path segment
+	bonusBaseName    = 4  // A character in the last path segment
+	penaltyGapStart  = 3  // Skipping characters between matches
+	penaltyGap       = 1  // Every skipped character
+)
+
+// Match is a candidate the pattern matched
+type Match struct {
+	Str       string // The candidate
+	Index     int    // Its index among the candidates
+	Score     int    // Higher is better
+	Positions []int  // Rune offsets of the matched characters
+}
+
+// Find returns the candidates pattern matches, best first. Characters of
+// pattern must appear in order in a candidate; the match ignores case
+// unless pattern has an uppercase letter. An empty pattern matches every
+// candidate, in their order.
+func Find(pattern string, candidates []string) []Match {
+	var matches []Match
+	for i, candidate := range candidates {
+		if positions, score, ok := match(pattern, candidate); ok {
+			matches = append(matches, Match{Str: candidate, Index: i, Score: score, Positions: positions})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return len(matches[i].Str) < len(matches[j].Str)
+	})
+	if pattern == "" {
+		sort.SliceStable(matches, func(i, j int) bool { return matches[i].Index < matches[j].Index })
+	}
+	return matches
+}
+
+// match finds pattern in candidate, preferring the shortest window ending
+// at the first complete match
+func match(pattern, candidate string) ([]int, int, bool) {
+	if pattern == "" {
+		return nil, 0, true
+	}
+	text := []rune(candidate)
+	if !hasUpper(pattern) {
+		for i, r := range text {
+			text[i] = unicode.ToLower(r)
+		}
+	}
+	p := []rune(pattern)
+
+	// Forward to the end of the first match, then back to its latest start
+	end, pi := -1, 0
+	for i, r := range text {
+		if r == p[pi] {
+			pi++
+			if pi == len(p) {
+				end = i
+				break
+			}
+		}
+	}
+	if end < 0 {
+		return nil, 0, false
+	}
+	positions := make([]int, len(p))
+	pi = len(p) - 1
+	for i := end; i >= 0 && pi >= 0; i-- {
+		if text[i] == p[pi] {
+			positions[pi] = i
+			pi--
+		}
+	}
+	return positions, score(text, positions), true
+}
+
+// score rates the matched positions of text
+func score(text []rune, positions []int) int {
+	base := 0
+	for i, r := range text {
+		if r == '/' {
+			base = i + 1
+		}
+	}
+	total := 0
+	for i, pos := range positions {
+		total += scoreChar
+		if pos == 0 || strings.ContainsRune("/_-. ", text[pos-1]) {
+			total += bonusBoundary
+		}
+		if pos >= base {
+			total += bonusBaseName
+		}
+		if i > 0 {
+			gap := pos - positions[i-1] - 1
+			if gap == 0 {
+				total += bonusConsecutive
+			} else {
+				total -= penaltyGapStart + gap*penaltyGap
+			}
+		}
+	}
+	return total
+}
+
+// hasUpper reports whether s has an uppercase letter
+func hasUpper(s string) bool {
+	for _, r := range s {
+		if unicode.IsUpper(r) {
+			return true
+		}
+	}
+	return false
+}
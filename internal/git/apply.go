@@ -0,0 +1,41 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// ApplyCached applies a patch to the index with git apply --cached, leaving
+// the worktree alone. Paths in the patch are relative to the repository
+// root.
+func ApplyCached(patch string) error {
+	return applyCached(patch)
+}
+
+// ReverseCached takes a patch applied with ApplyCached back out of the index
+func ReverseCached(patch string) error {
+	return applyCached(patch, "--reverse")
+}
+
+// applyCached runs git apply --cached with args on patch from the
+// repository root
+func applyCached(patch string, args ...string) error {
+	root, err := Root()
+	if err != nil {
+		return fmt.Errorf("failed to find the repository root: %w", err)
+	}
+
+	cmd := Command(append(append([]string{"apply", "--cached"}, args...), "-")...)
+	cmd.Dir = root
+	cmd.Stdin = strings.NewReader(patch)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("git apply: %s (%w)", msg, err)
+		}
+		return err
+	}
+	return nil
+}
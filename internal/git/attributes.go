@@ -0,0 +1,44 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+)
+
+// LinguistGenerated returns the paths, relative to the repository root, that
+// .gitattributes marks as linguist-generated, as GitHub does to collapse
+// them in its diffs
+func LinguistGenerated(ctx context.Context, paths []string) (map[string]bool, error) {
+	if len(paths) == 0 {
+		return nil, nil
+	}
+	root, err := Root()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the repository root: %w", err)
+	}
+
+	cmd := CommandContext(ctx, "check-attr", "-z", "--stdin", "linguist-generated")
+	cmd.Dir = root
+	cmd.Stdin = strings.NewReader(strings.Join(paths, "\x00") + "\x00")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git check-attr: %s (%w)", msg, err)
+		}
+		return nil, err
+	}
+
+	// The output is the path, the attribute and its value for each path
+	generated := make(map[string]bool)
+	fields := strings.Split(string(output), "\x00")
+	for i := 0; i+2 < len(fields); i += 3 {
+		if value := fields[i+2]; value == "set" || value == "true" {
+			generated[fields[i]] = true
+		}
+	}
+	return generated, nil
+}
@@ -0,0 +1,125 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// Backend names accepted by the git.backend config option
+const (
+	BackendCLI    = "cli"    // Run the git binary
+	BackendNative = "native" // Read the repository directly, without git installed
+)
+
+// Backend reads diffs and objects from the repository in the working
+// directory. Revisions use git's syntax, e.g. main, HEAD~2 or a commit ID.
+type Backend interface {
+	// Diff returns the output of git diff with args, empty when nothing changed
+	Diff(args ...string) (string, error)
+	// ResolveRevision returns the commit ID a revision points to
+	ResolveRevision(rev string) (string, error)
+	// MergeBase returns the ID of the best common ancestor of two commits
+	MergeBase(a, b string) (string, error)
+	// ObjectType returns blob, tree or commit for rev:path specs and object IDs
+	ObjectType(spec string) (string, error)
+	// ReadBlob returns the contents of a rev:path spec or a blob ID
+	ReadBlob(spec string) ([]byte, error)
+	// ReadBlobPrefix returns the first n bytes of a rev:path spec or a blob
+	// ID, and its size
+	ReadBlobPrefix(spec string, n int) ([]byte, int64, error)
+	// Blame returns the commit that last changed each line of path at rev
+	Blame(rev, path string) ([]BlameLine, error)
+	// Refs returns the short names of all branches, remote branches and tags
+	Refs() ([]string, error)
+	// Root returns the top-level directory of the worktree
+	Root() (string, error)
+}
+
+// BlameLine is one line of a blamed file
+type BlameLine struct {
+	Commit string
+	Author string // Author email
+	Time   time.Time
+	Text   string
+}
+
+var (
+	backendMu sync.RWMutex
+	backend   Backend = CLI{}
+)
+
+// NewBackend returns the named backend. An empty name selects the git
+// binary when it is installed and the native backend otherwise.
+func NewBackend(name string) (Backend, error) {
+	switch name {
+	case "":
+		if _, err := exec.LookPath("git"); err != nil {
+			return NewNative("."), nil
+		}
+		return CLI{}, nil
+	case BackendCLI:
+		return CLI{}, nil
+	case BackendNative:
+		return NewNative("."), nil
+	}
+	return nil, fmt.Errorf("unknown git backend %q (want %s or %s)", name, BackendCLI, BackendNative)
+}
+
+// SetBackend selects the backend used by the package functions
+func SetBackend(name string) error {
+	b, err := NewBackend(name)
+	if err != nil {
+		return err
+	}
+	backendMu.Lock()
+	backend = b
+	backendMu.Unlock()
+	return nil
+}
+
+// CurrentBackend returns the backend used by the package functions
+func CurrentBackend() Backend {
+	backendMu.RLock()
+	defer backendMu.RUnlock()
+	return backend
+}
+
+// Diff runs git diff with args on the current backend
+func Diff(args ...string) (string, error) {
+	return DiffContext(context.Background(), args...)
+}
+
+// contextDiffer is implemented by backends that can stop a diff midway
+type contextDiffer interface {
+	DiffContext(ctx context.Context, args ...string) (string, error)
+}
+
+// DiffContext is Diff stopping when ctx is done. Backends that can't be
+// interrupted finish the diff, which is then thrown away.
+func DiffContext(ctx context.Context, args ...string) (string, error) {
+	b := CurrentBackend()
+	if d, ok := b.(contextDiffer); ok {
+		return d.DiffContext(ctx, args...)
+	}
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	output, err := b.Diff(args...)
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+	return output, err
+}
+
+// Blame returns the origin of each line of path at rev
+func Blame(rev, path string) ([]BlameLine, error) {
+	return CurrentBackend().Blame(rev, path)
+}
+
+// Root returns the top-level directory of the current repository
+func Root() (string, error) {
+	return CurrentBackend().Root()
+}
@@ -0,0 +1,82 @@
+package git
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"os"
+	"strings"
+)
+
+// IsBlobSpec reports whether arg names a file at a revision, such as
+// main:internal/app/app.go or HEAD~2:README.md. Existing worktree paths
+// are never treated as blob specs.
+func IsBlobSpec(arg string) bool {
+	i := strings.Index(arg, ":")
+	if i <= 0 || i == len(arg)-1 {
+		return false
+	}
+	if _, err := os.Stat(arg); err == nil {
+		return false
+	}
+	kind, err := CurrentBackend().ObjectType(arg)
+	return err == nil && kind == "blob"
+}
+
+// ReadBlob returns the contents of a file at a revision, or of a blob by ID
+func ReadBlob(spec string) ([]byte, error) {
+	data, err := CurrentBackend().ReadBlob(spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", spec, err)
+	}
+	return data, nil
+}
+
+// ReadBlobPrefix returns the first n bytes of a file at a revision, or of a
+// blob by ID, and its size, without reading the rest
+func ReadBlobPrefix(spec string, n int) ([]byte, int64, error) {
+	data, size, err := CurrentBackend().ReadBlobPrefix(spec, n)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read %s: %w", spec, err)
+	}
+	return data, size, nil
+}
+
+// HasBlobID reports whether data is the blob a possibly abbreviated object
+// ID names, in SHA-1 or SHA-256 repositories
+func HasBlobID(data []byte, id string) bool {
+	if len(id) < 4 {
+		return false
+	}
+	for _, h := range []hash.Hash{sha1.New(), sha256.New()} {
+		fmt.Fprintf(h, "blob %d\x00", len(data))
+		h.Write(data)
+		if strings.HasPrefix(hex.EncodeToString(h.Sum(nil)), strings.ToLower(id)) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckBlobSpec returns an error when arg has the form rev:path with a valid
+// revision but doesn't name a file at that revision
+func CheckBlobSpec(arg string) error {
+	rev, path, ok := strings.Cut(arg, ":")
+	if !ok || rev == "" || path == "" || !IsRef(rev) {
+		return nil
+	}
+	if _, err := os.Stat(arg); err == nil {
+		return nil
+	}
+
+	kind, err := CurrentBackend().ObjectType(arg)
+	switch {
+	case err != nil:
+		return fmt.Errorf("path %q does not exist in %s", path, rev)
+	case kind != "blob":
+		return fmt.Errorf("%q is a %s in %s, not a file", path, kind, rev)
+	}
+	return nil
+}
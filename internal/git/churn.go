@@ -0,0 +1,57 @@
+package git
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NumStat is the change count of one file in one commit
+type NumStat struct {
+	Commit    string
+	Time      time.Time // Commit time
+	Path      string
+	Additions int
+	Deletions int
+	Binary    bool // Binary files have no line counts
+}
+
+// LogNumStat returns the per-file line counts of every commit in a revision
+// range, most recent commit first. Renames are reported as a deletion and an
+// addition so every entry has a single path.
+func LogNumStat(revRange string) ([]NumStat, error) {
+	output, err := Run("log", "--numstat", "--no-renames", "--format=%x00%H %ct", revRange, "--")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read log: %w", err)
+	}
+
+	var stats []NumStat
+	var commit string
+	var when time.Time
+	for _, line := range strings.Split(output, "\n") {
+		if header, ok := strings.CutPrefix(line, "\x00"); ok {
+			hash, timestamp, _ := strings.Cut(header, " ")
+			seconds, err := strconv.ParseInt(timestamp, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse commit time %q: %w", timestamp, err)
+			}
+			commit, when = hash, time.Unix(seconds, 0)
+			continue
+		}
+
+		parts := strings.SplitN(line, "\t", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		stat := NumStat{Commit: commit, Time: when, Path: parts[2]}
+		if parts[0] == "-" && parts[1] == "-" {
+			stat.Binary = true
+		} else {
+			stat.Additions, _ = strconv.Atoi(parts[0])
+			stat.Deletions, _ = strconv.Atoi(parts[1])
+		}
+		stats = append(stats, stat)
+	}
+	return stats, nil
+}
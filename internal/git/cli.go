@@ -0,0 +1,167 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CLI is the backend that runs the git binary
+type CLI struct{}
+
+// Diff runs git diff without colors or external diff drivers
+func (c CLI) Diff(args ...string) (string, error) {
+	return c.DiffContext(context.Background(), args...)
+}
+
+// DiffContext is Diff with git killed when ctx is done
+func (CLI) DiffContext(ctx context.Context, args ...string) (string, error) {
+	output, err := RunContext(ctx, append([]string{"diff", "--no-color", "--no-ext-diff"}, args...)...)
+	// Exit code 1 means the inputs differ with --no-index or --exit-code
+	if err != nil && exitCode(err) != 1 {
+		return "", err
+	}
+	return output, nil
+}
+
+// ResolveRevision resolves rev to a commit with git rev-parse
+func (CLI) ResolveRevision(rev string) (string, error) {
+	output, err := Run("rev-parse", "--verify", "--quiet", "--end-of-options", rev+"^{commit}")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(output), nil
+}
+
+// MergeBase finds the best common ancestor with git merge-base
+func (CLI) MergeBase(a, b string) (string, error) {
+	output, err := Run("merge-base", a, b)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(output), nil
+}
+
+// ObjectType returns the type of an object with git cat-file -t
+func (CLI) ObjectType(spec string) (string, error) {
+	output, err := Run("cat-file", "-t", spec)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(output), nil
+}
+
+// ReadBlob reads a blob with git cat-file
+func (CLI) ReadBlob(spec string) ([]byte, error) {
+	output, err := Run("cat-file", "blob", spec)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(output), nil
+}
+
+// ReadBlobPrefix reads the size of a blob with git cat-file -s, then stops
+// git cat-file blob after its first n bytes
+func (CLI) ReadBlobPrefix(spec string, n int) ([]byte, int64, error) {
+	output, err := Run("cat-file", "-s", spec)
+	if err != nil {
+		return nil, 0, err
+	}
+	size, err := strconv.ParseInt(strings.TrimSpace(output), 10, 64)
+	if err != nil {
+		return nil, 0, fmt.Errorf("unexpected size %q of %s", strings.TrimSpace(output), spec)
+	}
+
+	cmd := Command("cat-file", "blob", spec)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, 0, err
+	}
+	data, err := io.ReadAll(io.LimitReader(stdout, int64(n)))
+	// git is killed rather than left to write the rest
+	cmd.Process.Kill()
+	cmd.Wait()
+	if err != nil {
+		return nil, 0, err
+	}
+	return data, size, nil
+}
+
+// Blame runs git blame --porcelain
+func (CLI) Blame(rev, path string) ([]BlameLine, error) {
+	output, err := Run("blame", "--porcelain", rev, "--", path)
+	if err != nil {
+		return nil, err
+	}
+	return parseBlamePorcelain(output)
+}
+
+// Refs lists refs with git for-each-ref
+func (CLI) Refs() ([]string, error) {
+	output, err := Run("for-each-ref", "--format=%(refname:short)")
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(output), nil
+}
+
+// Root returns the worktree root with git rev-parse
+func (CLI) Root() (string, error) {
+	output, err := Run("rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(output), nil
+}
+
+// parseBlamePorcelain parses git blame --porcelain output. Commit details
+// are only printed the first time a commit appears, so they are remembered
+// by commit ID.
+func parseBlamePorcelain(output string) ([]BlameLine, error) {
+	type commitInfo struct {
+		author string
+		time   time.Time
+	}
+	commits := make(map[string]*commitInfo)
+
+	var lines []BlameLine
+	var current *commitInfo
+	var commit string
+	for _, line := range strings.Split(output, "\n") {
+		if text, ok := strings.CutPrefix(line, "\t"); ok {
+			if current == nil {
+				return nil, fmt.Errorf("malformed blame output: line without a header")
+			}
+			lines = append(lines, BlameLine{Commit: commit, Author: current.author, Time: current.time, Text: text})
+			current = nil
+			continue
+		}
+
+		key, value, _ := strings.Cut(line, " ")
+		switch {
+		case current == nil && (len(key) == 40 || len(key) == 64):
+			commit = key
+			if commits[commit] == nil {
+				commits[commit] = &commitInfo{}
+			}
+			current = commits[commit]
+		case current == nil:
+			continue
+		case key == "author-mail":
+			current.author = strings.Trim(value, "<>")
+		case key == "author-time":
+			seconds, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("malformed blame output: %w", err)
+			}
+			current.time = time.Unix(seconds, 0)
+		}
+	}
+	return lines, nil
+}
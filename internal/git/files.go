@@ -0,0 +1,23 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// TrackedFiles returns the paths of the files git tracks below the working
+// directory, relative to it
+func TrackedFiles(ctx context.Context) ([]string, error) {
+	output, err := RunContext(ctx, "ls-files", "-z")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files: %w", err)
+	}
+	var paths []string
+	for _, path := range strings.Split(output, "\x00") {
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
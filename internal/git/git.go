@@ -0,0 +1,58 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// defaultConfig makes git print non-ASCII paths and commit messages as UTF-8
+// rather than as octal escapes or in the commit's own encoding
+var defaultConfig = []string{"-c", "core.quotepath=off", "-c", "i18n.logOutputEncoding=UTF-8"}
+
+// Command returns a git command with the default config applied
+func Command(args ...string) *exec.Cmd {
+	return CommandContext(context.Background(), args...)
+}
+
+// CommandContext is Command with git killed when ctx is done
+func CommandContext(ctx context.Context, args ...string) *exec.Cmd {
+	return exec.CommandContext(ctx, "git", append(append([]string{}, defaultConfig...), args...)...)
+}
+
+// Run executes git with the given arguments and returns its standard output
+func Run(args ...string) (string, error) {
+	return RunContext(context.Background(), args...)
+}
+
+// RunContext is Run with git killed when ctx is done, returning ctx's error
+func RunContext(ctx context.Context, args ...string) (string, error) {
+	cmd := CommandContext(ctx, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		if ctx.Err() != nil {
+			return string(output), ctx.Err()
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return string(output), fmt.Errorf("git %s: %s (%w)", args[0], msg, err)
+		}
+		return string(output), err
+	}
+	return string(output), nil
+}
+
+// exitCode returns the exit code of a failed git invocation, or -1 if the
+// error did not come from the process exiting
+func exitCode(err error) int {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
@@ -0,0 +1,11 @@
+package git
+
+// LineLog returns the git log -L output tracing each line range through
+// revs, e.g. ":parseConfig:config.go" for a function or "10,20:README.md"
+func LineLog(ranges []string, revs ...string) (string, error) {
+	args := []string{"log", "--no-color", "--no-ext-diff"}
+	for _, r := range ranges {
+		args = append(args, "-L"+r)
+	}
+	return Run(append(args, revs...)...)
+}
@@ -0,0 +1,62 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MergePreview describes the outcome of an in-memory merge of two revisions
+type MergePreview struct {
+	Ours      string   // Revision being merged into
+	Theirs    string   // Revision being merged
+	Tree      string   // Object ID of the resulting tree (contains conflict markers)
+	Conflicts []string // Paths that would conflict
+}
+
+// HasConflicts reports whether the merge would produce conflicts
+func (p *MergePreview) HasConflicts() bool {
+	return len(p.Conflicts) > 0
+}
+
+// MergeTree performs an in-memory merge of theirs into ours using
+// `git merge-tree --write-tree` without touching the index or worktree
+func MergeTree(ours, theirs string) (*MergePreview, error) {
+	output, err := Run("merge-tree", "--write-tree", "--name-only", "--no-messages", ours, theirs)
+	// Exit code 1 means the merge has conflicts, which is what we're after
+	if err != nil && exitCode(err) != 1 {
+		return nil, fmt.Errorf("failed to merge %s and %s: %w", ours, theirs, err)
+	}
+
+	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+	if lines[0] == "" {
+		if err != nil {
+			return nil, fmt.Errorf("failed to merge %s and %s: %w", ours, theirs, err)
+		}
+		return nil, fmt.Errorf("unexpected merge-tree output")
+	}
+
+	preview := &MergePreview{
+		Ours:   ours,
+		Theirs: theirs,
+		Tree:   lines[0],
+	}
+	for _, path := range lines[1:] {
+		if path != "" {
+			preview.Conflicts = append(preview.Conflicts, path)
+		}
+	}
+
+	return preview, nil
+}
+
+// ConflictDiff returns the unified diff of a conflicted path between ours and
+// the merged tree, so the conflict markers show up as added lines
+func (p *MergePreview) ConflictDiff(path string, contextLines int) (string, error) {
+	return Run("diff", "--no-color", "--no-ext-diff", fmt.Sprintf("-U%d", contextLines), p.Ours, p.Tree, "--", path)
+}
+
+// ConflictedContent returns a conflicted file as the merge would leave it,
+// conflict markers included
+func (p *MergePreview) ConflictedContent(path string) ([]byte, error) {
+	return ReadBlob(p.Tree + ":" + path)
+}
@@ -0,0 +1,224 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// Native is the backend that reads the repository with go-git, for
+// environments without the git binary. It doesn't detect renames and
+// supports only the common git diff options.
+type Native struct {
+	dir string
+
+	once sync.Once
+	repo *gogit.Repository
+	err  error
+}
+
+// NewNative returns a native backend for the repository containing dir.
+// The repository is opened on first use.
+func NewNative(dir string) *Native {
+	return &Native{dir: dir}
+}
+
+// open returns the repository, opening it the first time
+func (n *Native) open() (*gogit.Repository, error) {
+	n.once.Do(func() {
+		n.repo, n.err = gogit.PlainOpenWithOptions(n.dir, &gogit.PlainOpenOptions{DetectDotGit: true})
+		if n.err != nil {
+			n.err = fmt.Errorf("failed to open repository: %w", n.err)
+		}
+	})
+	return n.repo, n.err
+}
+
+// ResolveRevision resolves rev to a commit
+func (n *Native) ResolveRevision(rev string) (string, error) {
+	commit, err := n.commit(rev)
+	if err != nil {
+		return "", err
+	}
+	return commit.Hash.String(), nil
+}
+
+// commit resolves rev and reads the commit it points to
+func (n *Native) commit(rev string) (*object.Commit, error) {
+	repo, err := n.open()
+	if err != nil {
+		return nil, err
+	}
+	hash, err := repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", rev, err)
+	}
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a commit: %w", rev, err)
+	}
+	return commit, nil
+}
+
+// ObjectType returns the type of a rev:path spec or an object ID
+func (n *Native) ObjectType(spec string) (string, error) {
+	repo, err := n.open()
+	if err != nil {
+		return "", err
+	}
+
+	rev, path, ok := strings.Cut(spec, ":")
+	if !ok {
+		obj, err := repo.Storer.EncodedObject(plumbing.AnyObject, plumbing.NewHash(spec))
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", spec, err)
+		}
+		return obj.Type().String(), nil
+	}
+
+	commit, err := n.commit(rev)
+	if err != nil {
+		return "", err
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return "", err
+	}
+	entry, err := tree.FindEntry(path)
+	if err != nil {
+		return "", fmt.Errorf("path %q does not exist in %s", path, rev)
+	}
+	switch entry.Mode {
+	case filemode.Dir:
+		return plumbing.TreeObject.String(), nil
+	case filemode.Submodule:
+		return plumbing.CommitObject.String(), nil
+	}
+	return plumbing.BlobObject.String(), nil
+}
+
+// ReadBlob reads a rev:path spec or a blob ID
+func (n *Native) ReadBlob(spec string) ([]byte, error) {
+	blob, err := n.blob(spec)
+	if err != nil {
+		return nil, err
+	}
+	return readBlob(blob)
+}
+
+// ReadBlobPrefix reads the first n bytes of a rev:path spec or a blob ID
+func (n *Native) ReadBlobPrefix(spec string, limit int) ([]byte, int64, error) {
+	blob, err := n.blob(spec)
+	if err != nil {
+		return nil, 0, err
+	}
+	reader, err := blob.Reader()
+	if err != nil {
+		return nil, 0, err
+	}
+	defer reader.Close()
+	data, err := io.ReadAll(io.LimitReader(reader, int64(limit)))
+	if err != nil {
+		return nil, 0, err
+	}
+	return data, blob.Size, nil
+}
+
+// blob looks up the blob a rev:path spec or a blob ID names
+func (n *Native) blob(spec string) (*object.Blob, error) {
+	repo, err := n.open()
+	if err != nil {
+		return nil, err
+	}
+
+	rev, path, ok := strings.Cut(spec, ":")
+	if !ok {
+		blob, err := repo.BlobObject(plumbing.NewHash(spec))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", spec, err)
+		}
+		return blob, nil
+	}
+
+	commit, err := n.commit(rev)
+	if err != nil {
+		return nil, err
+	}
+	file, err := commit.File(path)
+	if err != nil {
+		return nil, fmt.Errorf("path %q does not exist in %s", path, rev)
+	}
+	return &file.Blob, nil
+}
+
+// readBlob returns the contents of a blob
+func readBlob(blob *object.Blob) ([]byte, error) {
+	reader, err := blob.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+// Blame blames path at rev
+func (n *Native) Blame(rev, path string) ([]BlameLine, error) {
+	commit, err := n.commit(rev)
+	if err != nil {
+		return nil, err
+	}
+	result, err := gogit.Blame(commit, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to blame %s: %w", path, err)
+	}
+
+	lines := make([]BlameLine, len(result.Lines))
+	for i, line := range result.Lines {
+		lines[i] = BlameLine{Commit: line.Hash.String(), Author: line.Author, Time: line.Date.Local(), Text: line.Text}
+	}
+	return lines, nil
+}
+
+// Refs returns the short names of branches, remote branches and tags
+func (n *Native) Refs() ([]string, error) {
+	repo, err := n.open()
+	if err != nil {
+		return nil, err
+	}
+	iter, err := repo.References()
+	if err != nil {
+		return nil, err
+	}
+
+	var refs []string
+	err = iter.ForEach(func(ref *plumbing.Reference) error {
+		name := ref.Name()
+		if name.IsBranch() || name.IsRemote() || name.IsTag() {
+			refs = append(refs, name.Short())
+		}
+		return nil
+	})
+	return refs, err
+}
+
+// Root returns the top-level directory of the worktree
+func (n *Native) Root() (string, error) {
+	repo, err := n.open()
+	if err != nil {
+		return "", err
+	}
+	worktree, err := repo.Worktree()
+	if errors.Is(err, gogit.ErrIsBareRepository) {
+		return "", fmt.Errorf("repository has no worktree")
+	} else if err != nil {
+		return "", err
+	}
+	return worktree.Filesystem.Root(), nil
+}
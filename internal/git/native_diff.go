@@ -0,0 +1,506 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	fdiff "github.com/go-git/go-git/v5/plumbing/format/diff"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/utils/diff"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// diffRequest is a git diff command line as understood by the native backend
+type diffRequest struct {
+	cached    bool           // --cached/--staged: compare against the index
+	mergeBase bool           // A...B: compare from the merge base of A and B
+	context   int            // Lines of context around changes
+	revs      []string       // Up to two revisions
+	paths     []string       // Pathspecs, relative to the working directory
+	pickaxe   string         // -S: changes in the number of occurrences
+	pickaxeRe *regexp.Regexp // -G: added or removed lines matching
+}
+
+// parseDiffArgs parses the git diff options and arguments supported by the
+// native backend. Arguments that don't resolve to a revision are paths.
+func (n *Native) parseDiffArgs(args []string) (*diffRequest, error) {
+	req := &diffRequest{context: fdiff.DefaultContextLines}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			req.paths = append(req.paths, args[i+1:]...)
+			i = len(args)
+		case arg == "--cached" || arg == "--staged":
+			req.cached = true
+		case arg == "--no-color" || arg == "--no-ext-diff" || arg == "--color=never":
+			// The native backend never colors or runs diff drivers
+		case strings.HasPrefix(arg, "-U") || strings.HasPrefix(arg, "--unified="):
+			value := strings.TrimPrefix(strings.TrimPrefix(arg, "-U"), "--unified=")
+			context, err := strconv.Atoi(value)
+			if err != nil || context < 0 {
+				return nil, fmt.Errorf("invalid context lines %q", arg)
+			}
+			req.context = context
+		case strings.HasPrefix(arg, "-S") && len(arg) > 2:
+			req.pickaxe = arg[2:]
+		case strings.HasPrefix(arg, "-G") && len(arg) > 2:
+			re, err := regexp.Compile(arg[2:])
+			if err != nil {
+				return nil, fmt.Errorf("invalid -G pattern: %w", err)
+			}
+			req.pickaxeRe = re
+		case strings.HasPrefix(arg, "-"):
+			return nil, fmt.Errorf("the native git backend doesn't support %s (set git.backend = \"cli\")", arg)
+		case strings.Contains(arg, ".."):
+			from, to, _ := strings.Cut(arg, "..")
+			if rest, ok := strings.CutPrefix(to, "."); ok {
+				to = rest
+				req.mergeBase = true
+			}
+			req.revs = append(req.revs, orHead(from), orHead(to))
+		default:
+			if _, err := n.commit(arg); err != nil {
+				req.paths = append(req.paths, arg)
+				continue
+			}
+			req.revs = append(req.revs, arg)
+		}
+	}
+
+	if len(req.revs) > 2 || (req.cached && len(req.revs) > 1) {
+		return nil, fmt.Errorf("the native git backend compares at most two revisions")
+	}
+	return req, nil
+}
+
+// orHead returns rev, or HEAD for the empty side of a range
+func orHead(rev string) string {
+	if rev == "" {
+		return "HEAD"
+	}
+	return rev
+}
+
+// source is one version of a file: a blob in the object database, or a
+// file in the worktree when file is set
+type source struct {
+	hash plumbing.Hash
+	mode filemode.FileMode
+	file string
+	data []byte // Contents, once read
+}
+
+// snapshot maps slash-separated repository paths to file versions
+type snapshot map[string]*source
+
+// Diff generates a unified diff between commits, the index and the worktree
+func (n *Native) Diff(args ...string) (string, error) {
+	repo, err := n.open()
+	if err != nil {
+		return "", err
+	}
+	req, err := n.parseDiffArgs(args)
+	if err != nil {
+		return "", err
+	}
+
+	var from, to snapshot
+	switch {
+	case req.cached:
+		rev := "HEAD"
+		if len(req.revs) == 1 {
+			rev = req.revs[0]
+		}
+		if from, err = n.treeSnapshot(rev, true); err != nil {
+			return "", err
+		}
+		to, err = n.indexSnapshot()
+	case len(req.revs) == 0:
+		if from, err = n.indexSnapshot(); err != nil {
+			return "", err
+		}
+		to, err = n.worktreeSnapshot()
+	case len(req.revs) == 1:
+		if from, err = n.treeSnapshot(req.revs[0], false); err != nil {
+			return "", err
+		}
+		to, err = n.worktreeSnapshot()
+	default:
+		base := req.revs[0]
+		if req.mergeBase {
+			if base, err = n.MergeBase(req.revs[0], req.revs[1]); err != nil {
+				return "", err
+			}
+		}
+		if from, err = n.treeSnapshot(base, false); err != nil {
+			return "", err
+		}
+		to, err = n.treeSnapshot(req.revs[1], false)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	pathspecs, err := n.pathspecs(req.paths)
+	if err != nil {
+		return "", err
+	}
+
+	var patches filePatches
+	for _, name := range changedPaths(from, to, pathspecs) {
+		patch, err := n.filePatch(repo, name, from[name], to[name], req)
+		if err != nil {
+			return "", err
+		}
+		if patch != nil {
+			patches = append(patches, patch)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := fdiff.NewUnifiedEncoder(&buf, req.context).Encode(patches); err != nil {
+		return "", fmt.Errorf("failed to encode diff: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// MergeBase returns the best common ancestor of two revisions
+func (n *Native) MergeBase(a, b string) (string, error) {
+	commitA, err := n.commit(a)
+	if err != nil {
+		return "", err
+	}
+	commitB, err := n.commit(b)
+	if err != nil {
+		return "", err
+	}
+	bases, err := commitA.MergeBase(commitB)
+	if err != nil {
+		return "", err
+	}
+	if len(bases) == 0 {
+		return "", fmt.Errorf("%s and %s have no merge base", a, b)
+	}
+	return bases[0].Hash.String(), nil
+}
+
+// treeSnapshot lists the files of a commit. With allowUnborn, HEAD of a
+// repository without commits is an empty tree.
+func (n *Native) treeSnapshot(rev string, allowUnborn bool) (snapshot, error) {
+	commit, err := n.commit(rev)
+	if err != nil {
+		if allowUnborn && rev == "HEAD" {
+			return snapshot{}, nil
+		}
+		return nil, err
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+
+	files := snapshot{}
+	err = tree.Files().ForEach(func(f *object.File) error {
+		files[f.Name] = &source{hash: f.Hash, mode: f.Mode}
+		return nil
+	})
+	return files, err
+}
+
+// indexSnapshot lists the staged files. Unmerged paths are left out.
+func (n *Native) indexSnapshot() (snapshot, error) {
+	idx, err := n.index()
+	if err != nil {
+		return nil, err
+	}
+	files := snapshot{}
+	for _, entry := range idx.Entries {
+		if tracked(entry) {
+			files[entry.Name] = &source{hash: entry.Hash, mode: entry.Mode}
+		}
+	}
+	return files, nil
+}
+
+// worktreeSnapshot lists the tracked files in the worktree. Files whose
+// size and modification time match the index are assumed unchanged, the
+// rest are read and hashed.
+func (n *Native) worktreeSnapshot() (snapshot, error) {
+	root, err := n.Root()
+	if err != nil {
+		return nil, err
+	}
+	idx, err := n.index()
+	if err != nil {
+		return nil, err
+	}
+
+	files := snapshot{}
+	for _, entry := range idx.Entries {
+		if !tracked(entry) {
+			continue
+		}
+		file := filepath.Join(root, filepath.FromSlash(entry.Name))
+		info, err := os.Lstat(file)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		src := &source{hash: entry.Hash, mode: worktreeMode(info), file: file}
+		if info.Size() != int64(entry.Size) || !info.ModTime().Equal(entry.ModifiedAt) {
+			if src.data, err = src.read(); err != nil {
+				return nil, err
+			}
+			src.hash = plumbing.ComputeHash(plumbing.BlobObject, src.data)
+		}
+		files[entry.Name] = src
+	}
+	return files, nil
+}
+
+// index reads the repository's index
+func (n *Native) index() (*index.Index, error) {
+	repo, err := n.open()
+	if err != nil {
+		return nil, err
+	}
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index: %w", err)
+	}
+	return idx, nil
+}
+
+// tracked reports whether an index entry is a merged file rather than a
+// conflict stage or a submodule. Merged entries have stage 0, not
+// index.Merged.
+func tracked(entry *index.Entry) bool {
+	return entry.Stage == 0 && entry.Mode != filemode.Submodule
+}
+
+// worktreeMode returns the git file mode of a worktree file
+func worktreeMode(info os.FileInfo) filemode.FileMode {
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		return filemode.Symlink
+	case info.Mode()&0o111 != 0:
+		return filemode.Executable
+	}
+	return filemode.Regular
+}
+
+// pathspecs converts paths relative to the working directory into
+// repository paths
+func (n *Native) pathspecs(paths []string) ([]string, error) {
+	if len(paths) == 0 {
+		return nil, nil
+	}
+	root, err := n.Root()
+	if err != nil {
+		return nil, err
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+
+	specs := make([]string, len(paths))
+	for i, p := range paths {
+		abs := p
+		if !filepath.IsAbs(p) {
+			abs = filepath.Join(wd, p)
+		}
+		rel, err := filepath.Rel(root, abs)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("%s is outside the repository", p)
+		}
+		specs[i] = filepath.ToSlash(rel)
+	}
+	return specs, nil
+}
+
+// changedPaths returns the sorted paths that differ between two snapshots
+// and match one of the pathspecs
+func changedPaths(from, to snapshot, pathspecs []string) []string {
+	var names []string
+	for name, a := range from {
+		if b, ok := to[name]; !ok || a.hash != b.hash || a.mode != b.mode {
+			names = append(names, name)
+		}
+	}
+	for name := range to {
+		if _, ok := from[name]; !ok {
+			names = append(names, name)
+		}
+	}
+
+	kept := names[:0]
+	for _, name := range names {
+		if matchesPathspec(name, pathspecs) {
+			kept = append(kept, name)
+		}
+	}
+	sort.Strings(kept)
+	return kept
+}
+
+// matchesPathspec reports whether name is one of the pathspecs or inside
+// one of them
+func matchesPathspec(name string, pathspecs []string) bool {
+	if len(pathspecs) == 0 {
+		return true
+	}
+	for _, spec := range pathspecs {
+		spec = path.Clean(spec)
+		if spec == "." || name == spec || strings.HasPrefix(name, spec+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// read returns the contents of a file version
+func (s *source) read() ([]byte, error) {
+	if s.data != nil || s.file == "" {
+		return s.data, nil
+	}
+	if s.mode == filemode.Symlink {
+		target, err := os.Readlink(s.file)
+		return []byte(target), err
+	}
+	return os.ReadFile(s.file)
+}
+
+// load reads the contents of a file version from the worktree or the
+// object database
+func (s *source) load(repo *gogit.Repository) ([]byte, error) {
+	if s.file != "" {
+		return s.read()
+	}
+	blob, err := repo.BlobObject(s.hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blob %s: %w", s.hash, err)
+	}
+	return readBlob(blob)
+}
+
+// filePatch diffs two versions of a file, either of which may be nil. It
+// returns nil when the pickaxe options exclude the file.
+func (n *Native) filePatch(repo *gogit.Repository, name string, from, to *source, req *diffRequest) (*filePatch, error) {
+	patch := &filePatch{}
+	var oldData, newData []byte
+	var err error
+	if from != nil {
+		patch.from = &patchFile{hash: from.hash, mode: from.mode, path: name}
+		if oldData, err = from.load(repo); err != nil {
+			return nil, err
+		}
+	}
+	if to != nil {
+		patch.to = &patchFile{hash: to.hash, mode: to.mode, path: name}
+		if newData, err = to.load(repo); err != nil {
+			return nil, err
+		}
+	}
+
+	if isBinary(oldData) || isBinary(newData) {
+		patch.binary = true
+		if req.pickaxe != "" || req.pickaxeRe != nil {
+			return nil, nil
+		}
+		return patch, nil
+	}
+
+	oldText, newText := string(oldData), string(newData)
+	if req.pickaxe != "" && strings.Count(oldText, req.pickaxe) == strings.Count(newText, req.pickaxe) {
+		return nil, nil
+	}
+
+	matched := req.pickaxeRe == nil
+	for _, d := range diff.Do(oldText, newText) {
+		chunk := &chunk{content: d.Text}
+		switch d.Type {
+		case diffmatchpatch.DiffEqual:
+			chunk.op = fdiff.Equal
+		case diffmatchpatch.DiffDelete:
+			chunk.op = fdiff.Delete
+		case diffmatchpatch.DiffInsert:
+			chunk.op = fdiff.Add
+		}
+		if !matched && chunk.op != fdiff.Equal && req.pickaxeRe.MatchString(d.Text) {
+			matched = true
+		}
+		patch.chunks = append(patch.chunks, chunk)
+	}
+	if !matched {
+		return nil, nil
+	}
+	return patch, nil
+}
+
+// isBinary reports whether data looks binary, like git, by the presence of
+// a NUL byte in its first 8000 bytes
+func isBinary(data []byte) bool {
+	return bytes.IndexByte(data[:min(len(data), 8000)], 0) >= 0
+}
+
+// filePatches implements diff.Patch for the unified encoder
+type filePatches []fdiff.FilePatch
+
+func (p filePatches) FilePatches() []fdiff.FilePatch { return p }
+func (p filePatches) Message() string                { return "" }
+
+// filePatch implements diff.FilePatch
+type filePatch struct {
+	from, to *patchFile
+	binary   bool
+	chunks   []fdiff.Chunk
+}
+
+func (p *filePatch) IsBinary() bool        { return p.binary }
+func (p *filePatch) Chunks() []fdiff.Chunk { return p.chunks }
+func (p *filePatch) Files() (fdiff.File, fdiff.File) {
+	// Return untyped nils for missing sides, as the encoder checks for nil
+	var from, to fdiff.File
+	if p.from != nil {
+		from = p.from
+	}
+	if p.to != nil {
+		to = p.to
+	}
+	return from, to
+}
+
+// patchFile implements diff.File
+type patchFile struct {
+	hash plumbing.Hash
+	mode filemode.FileMode
+	path string
+}
+
+func (f *patchFile) Hash() plumbing.Hash     { return f.hash }
+func (f *patchFile) Mode() filemode.FileMode { return f.mode }
+func (f *patchFile) Path() string            { return f.path }
+
+// chunk implements diff.Chunk
+type chunk struct {
+	content string
+	op      fdiff.Operation
+}
+
+func (c *chunk) Content() string       { return c.content }
+func (c *chunk) Type() fdiff.Operation { return c.op }
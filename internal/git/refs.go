@@ -0,0 +1,236 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// rangeRegex splits revision ranges such as A..B and A...B
+var rangeRegex = regexp.MustCompile(`\.\.\.?`)
+
+// RefError reports a revision that doesn't resolve, with the closest known
+// ref as a suggestion when one is near enough
+type RefError struct {
+	Ref        string
+	Suggestion string
+	Reason     string
+}
+
+func (e *RefError) Error() string {
+	if e.Reason != "" {
+		return fmt.Sprintf("unknown revision %q: %s", e.Ref, e.Reason)
+	}
+	if e.Suggestion != "" {
+		return fmt.Sprintf("unknown revision %q (did you mean %q?)", e.Ref, e.Suggestion)
+	}
+	return fmt.Sprintf("unknown revision %q", e.Ref)
+}
+
+// ResolveRef resolves a revision to a commit ID. Upstream and push
+// shorthands such as @{upstream}, @{u}, @{push} and main@{u} are resolved
+// by git itself.
+func ResolveRef(ref string) (string, error) {
+	id, err := CurrentBackend().ResolveRevision(ref)
+	if err != nil {
+		return "", refError(ref)
+	}
+	return id, nil
+}
+
+// IsRef reports whether a revision resolves to a commit
+func IsRef(ref string) bool {
+	_, err := ResolveRef(ref)
+	return err == nil
+}
+
+// ResolveRange returns the commit IDs of the two sides of a diff between
+// commits given as git diff arguments: A..B, A...B (from the merge base of
+// A and B) or A B. ok is false for other diffs, e.g. against the worktree.
+func ResolveRange(args []string) (from, to string, ok bool) {
+	var a, b string
+	mergeBase := false
+	switch {
+	case len(args) == 1 && rangeRegex.MatchString(args[0]):
+		sides := rangeRegex.Split(args[0], 2)
+		a, b = orHead(sides[0]), orHead(sides[1])
+		mergeBase = strings.Contains(args[0], "...")
+	case len(args) == 2 && !strings.HasPrefix(args[0], "-") && !strings.HasPrefix(args[1], "-"):
+		a, b = args[0], args[1]
+	default:
+		return "", "", false
+	}
+
+	from, err := ResolveRef(a)
+	if err != nil {
+		return "", "", false
+	}
+	to, err = ResolveRef(b)
+	if err != nil {
+		return "", "", false
+	}
+	if mergeBase {
+		base, err := CurrentBackend().MergeBase(from, to)
+		if err != nil {
+			return "", "", false
+		}
+		from = base
+	}
+	return from, to, true
+}
+
+// ValidateRefs checks that every revision resolves, including both ends of
+// A..B and A...B ranges
+func ValidateRefs(refs ...string) error {
+	for _, ref := range refs {
+		for _, part := range rangeRegex.Split(ref, -1) {
+			// An empty side of a range means HEAD
+			if part == "" {
+				continue
+			}
+			if _, err := ResolveRef(part); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateRevisionArgs validates the revisions in a git diff argument list.
+// Flags, existing paths and everything after "--" are left to git.
+func ValidateRevisionArgs(args []string) error {
+	var refs []string
+	for _, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if strings.HasPrefix(arg, "-") {
+			continue
+		}
+		if _, err := os.Stat(arg); err == nil {
+			continue
+		}
+		// rev:path names a file at a revision rather than a revision
+		if strings.Contains(arg, ":") {
+			if err := CheckBlobSpec(arg); err != nil {
+				return err
+			}
+		}
+		refs = append(refs, arg)
+	}
+
+	// A typo'd file name next to a real file reads better as a path error
+	if len(args) == 2 && len(refs) == 1 && !strings.Contains(refs[0], "..") {
+		if err := ValidateRefs(refs[0]); err != nil {
+			if _, statErr := os.Stat(otherArg(args, refs[0])); statErr == nil {
+				return fmt.Errorf("%s is neither a file nor a revision: %w", refs[0], err)
+			}
+			return err
+		}
+		return nil
+	}
+
+	return ValidateRefs(refs...)
+}
+
+// otherArg returns the element of a two-element args list that isn't arg
+func otherArg(args []string, arg string) string {
+	if args[0] == arg {
+		return args[1]
+	}
+	return args[0]
+}
+
+// refError builds a RefError with a suggestion from the repository's refs
+func refError(ref string) error {
+	err := &RefError{Ref: ref}
+
+	// Tracking shorthands fail when no upstream or push branch is set
+	for _, shorthand := range []string{"@{u}", "@{upstream}", "@{push}"} {
+		if strings.Contains(strings.ToLower(ref), shorthand) {
+			err.Reason = "no upstream or push branch is configured"
+			return err
+		}
+	}
+
+	refs, listErr := CurrentBackend().Refs()
+	if listErr != nil {
+		return err
+	}
+	candidates := append([]string{"HEAD"}, refs...)
+	err.Suggestion = SuggestRef(ref, candidates)
+	return err
+}
+
+// SuggestRef returns the candidate closest to ref by edit distance, or an
+// empty string when none is close enough to be a plausible typo
+func SuggestRef(ref string, candidates []string) string {
+	// Ignore revision suffixes like ~2 or ^ when comparing names
+	name := ref
+	if i := strings.IndexAny(name, "~^@:"); i > 0 {
+		name = name[:i]
+	}
+
+	best, bestDistance := "", -1
+	for _, candidate := range candidates {
+		d := levenshtein(name, candidate)
+		if bestDistance < 0 || d < bestDistance {
+			best, bestDistance = candidate, d
+		}
+	}
+
+	maxDistance := len(name) / 3
+	if maxDistance < 2 {
+		maxDistance = 2
+	}
+	if bestDistance < 0 || bestDistance == 0 || bestDistance > maxDistance {
+		return ""
+	}
+	return best + ref[len(name):]
+}
+
+// CompleteRevision returns the candidates that complete a partly typed
+// revision. The end of a range is completed, so "main..fea" may become
+// "main..feature/login".
+func CompleteRevision(partial string, candidates []string) []string {
+	prefix, name := "", partial
+	if i := strings.LastIndex(partial, ".."); i >= 0 {
+		prefix, name = partial[:i+2], partial[i+2:]
+		// The symmetric difference "a...b" has a third dot
+		if strings.HasPrefix(name, ".") {
+			prefix, name = prefix+".", name[1:]
+		}
+	}
+
+	var matches []string
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, name) {
+			matches = append(matches, prefix+candidate)
+		}
+	}
+	return matches
+}
+
+// levenshtein computes the edit distance between two strings
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,115 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Resolution is a conflict resolution recorded by git rerere
+type Resolution struct {
+	ID        string // Conflict ID, the rr-cache directory name
+	Postimage []byte // The resolved file contents
+}
+
+// RerereID computes the conflict ID git rerere would give a conflicted file.
+// The ID hashes both sides of every conflict, sorted so it doesn't depend on
+// which side was ours; labels and diff3 base sections are ignored. It reports
+// false when the content has no conflict markers.
+func RerereID(content []byte) (string, bool) {
+	const (
+		outside = iota
+		sideOne
+		base
+		sideTwo
+	)
+
+	h := sha1.New()
+	var one, two bytes.Buffer
+	state := outside
+	found := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(content)+1)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case isConflictMarker(line, '<', true):
+			state = sideOne
+			one.Reset()
+			two.Reset()
+		case state != outside && isConflictMarker(line, '|', false):
+			state = base
+		case state != outside && isConflictMarker(line, '=', false):
+			state = sideTwo
+		case state == sideTwo && isConflictMarker(line, '>', true):
+			first, second := one.Bytes(), two.Bytes()
+			if bytes.Compare(first, second) > 0 {
+				first, second = second, first
+			}
+			h.Write(first)
+			h.Write([]byte{0})
+			h.Write(second)
+			h.Write([]byte{0})
+			state = outside
+			found = true
+		case state == sideOne:
+			one.WriteString(line + "\n")
+		case state == sideTwo:
+			two.WriteString(line + "\n")
+		}
+	}
+
+	if !found {
+		return "", false
+	}
+	return hex.EncodeToString(h.Sum(nil)), true
+}
+
+// isConflictMarker reports whether line is a seven-character conflict marker.
+// Start and end markers must carry a label; the others may.
+func isConflictMarker(line string, c byte, labelled bool) bool {
+	marker := strings.Repeat(string(c), 7)
+	if !strings.HasPrefix(line, marker) {
+		return false
+	}
+	rest := line[len(marker):]
+	if rest == "" {
+		return !labelled
+	}
+	return rest[0] == ' ' || (!labelled && rest[0] == '\t')
+}
+
+// RecordedResolution returns the resolution git rerere recorded for a
+// conflicted file, or nil when there is none
+func RecordedResolution(content []byte) (*Resolution, error) {
+	id, ok := RerereID(content)
+	if !ok {
+		return nil, nil
+	}
+
+	output, err := Run("rev-parse", "--git-path", "rr-cache/"+id+"/postimage")
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate rr-cache: %w", err)
+	}
+	postimage, err := os.ReadFile(strings.TrimSpace(output))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recorded resolution: %w", err)
+	}
+
+	return &Resolution{ID: id, Postimage: postimage}, nil
+}
+
+// MergeInProgress reports whether the worktree is in the middle of a merge
+func MergeInProgress() bool {
+	_, err := Run("rev-parse", "--quiet", "--verify", "MERGE_HEAD")
+	return err == nil
+}
@@ -0,0 +1,78 @@
+package git
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Stash is a single entry of the stash list
+type Stash struct {
+	Ref     string // Reflog selector, e.g. stash@{0}
+	Subject string // Stash message
+}
+
+// StashList returns all stash entries, most recent first
+func StashList() ([]Stash, error) {
+	output, err := Run("stash", "list", "--format=%gd%x00%s")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stashes: %w", err)
+	}
+
+	var stashes []Stash
+	for _, line := range strings.Split(strings.TrimRight(output, "\n"), "\n") {
+		if line == "" {
+			continue
+		}
+		parts := strings.SplitN(line, "\x00", 2)
+		stash := Stash{Ref: parts[0]}
+		if len(parts) == 2 {
+			stash.Subject = parts[1]
+		}
+		stashes = append(stashes, stash)
+	}
+	return stashes, nil
+}
+
+// StashRef normalizes a stash argument, accepting a bare index ("2") as
+// shorthand for stash@{2}
+func StashRef(arg string) string {
+	if _, err := strconv.Atoi(arg); err == nil {
+		return fmt.Sprintf("stash@{%s}", arg)
+	}
+	return arg
+}
+
+// StashDiff returns the diff between two stash entries, or between a stash
+// entry and the worktree when to is empty
+func StashDiff(from, to string) (string, error) {
+	args := []string{"diff", "--no-color", "--no-ext-diff", StashRef(from)}
+	if to != "" {
+		args = append(args, StashRef(to))
+	}
+	return Run(args...)
+}
+
+// StashShow returns the changes a stash entry records, as git stash show -p
+// prints them
+func StashShow(ref string) (string, error) {
+	return Run("stash", "show", "-p", "--no-color", "--no-ext-diff", StashRef(ref))
+}
+
+// StashApply applies a stash entry to the worktree, keeping it in the list
+func StashApply(ref string) error {
+	_, err := Run("stash", "apply", StashRef(ref))
+	return err
+}
+
+// StashPop applies a stash entry to the worktree and drops it
+func StashPop(ref string) error {
+	_, err := Run("stash", "pop", StashRef(ref))
+	return err
+}
+
+// StashDrop removes a stash entry from the list
+func StashDrop(ref string) error {
+	_, err := Run("stash", "drop", StashRef(ref))
+	return err
+}
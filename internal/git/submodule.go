@@ -0,0 +1,24 @@
+package git
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// SubmoduleLog returns "<short id> <subject>" for each commit of the
+// submodule at path (relative to the repository root) between from and to,
+// newest first
+func SubmoduleLog(path, from, to string) ([]string, error) {
+	root, err := Root()
+	if err != nil {
+		return nil, err
+	}
+	cmd := Command("log", "--format=%h %s", from+".."+to)
+	cmd.Dir = filepath.Join(root, filepath.FromSlash(path))
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the log of submodule %s: %w", path, err)
+	}
+	return strings.FieldsFunc(string(output), func(r rune) bool { return r == '\n' }), nil
+}
@@ -0,0 +1,104 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// UntrackedFiles returns the repository paths of the files git doesn't
+// track and doesn't ignore
+func UntrackedFiles(ctx context.Context) ([]string, error) {
+	output, err := RunContext(ctx, "ls-files", "-z", "--others", "--exclude-standard", "--full-name", "--", ":/")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list untracked files: %w", err)
+	}
+	var paths []string
+	for _, path := range strings.Split(output, "\x00") {
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
+
+// UntrackedDiff returns the untracked files as a diff adding each of them
+// whole, like git diff shows new files once they are staged
+func UntrackedDiff(ctx context.Context) (string, error) {
+	paths, err := UntrackedFiles(ctx)
+	if err != nil || len(paths) == 0 {
+		return "", err
+	}
+	root, err := Root()
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	for _, path := range paths {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		full := filepath.Join(root, filepath.FromSlash(path))
+		info, err := os.Lstat(full)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		mode := "100644"
+		var data []byte
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			// git records the target of a symlink as its content
+			mode = "120000"
+			target, err := os.Readlink(full)
+			if err != nil {
+				return "", fmt.Errorf("failed to read %s: %w", path, err)
+			}
+			data = []byte(target)
+		case info.IsDir():
+			// A nested repository, which git lists but can't diff
+			continue
+		default:
+			if info.Mode()&0o111 != 0 {
+				mode = "100755"
+			}
+			if data, err = os.ReadFile(full); err != nil {
+				return "", fmt.Errorf("failed to read %s: %w", path, err)
+			}
+		}
+		writeAddedFile(&sb, path, mode, data)
+	}
+	return sb.String(), nil
+}
+
+// writeAddedFile writes the diff adding the file at path with data
+func writeAddedFile(sb *strings.Builder, path, mode string, data []byte) {
+	fmt.Fprintf(sb, "diff --git a/%s b/%s\nnew file mode %s\n", path, path, mode)
+	if len(data) == 0 {
+		return
+	}
+	if bytes.IndexByte(data, 0) >= 0 {
+		fmt.Fprintf(sb, "Binary files /dev/null and b/%s differ\n", path)
+		return
+	}
+
+	text := string(data)
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	fmt.Fprintf(sb, "--- /dev/null\n+++ b/%s\n@@ -0,0 +1", path)
+	if len(lines) != 1 {
+		fmt.Fprintf(sb, ",%d", len(lines))
+	}
+	sb.WriteString(" @@\n")
+	for _, line := range lines {
+		sb.WriteString("+" + line)
+	}
+	if !strings.HasSuffix(text, "\n") {
+		sb.WriteString("\n\\ No newline at end of file\n")
+	}
+}
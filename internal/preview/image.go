@@ -0,0 +1,263 @@
+package preview
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Protocol selects how images are drawn in the terminal
+type Protocol int
+
+const (
+	ProtocolAuto      Protocol = iota // Detect from the environment
+	ProtocolHalfBlock                 // Unicode half-block characters with truecolor
+	ProtocolKitty                     // Kitty graphics protocol
+	ProtocolSixel                     // DEC sixel graphics
+)
+
+// maxPreviewRows caps the height of half-block previews
+const maxPreviewRows = 20
+
+// IsImage reports whether a path looks like an image we can decode
+func IsImage(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".png", ".jpg", ".jpeg", ".gif":
+		return true
+	}
+	return false
+}
+
+// DetectProtocol picks the best image protocol the terminal likely supports
+func DetectProtocol() Protocol {
+	term := os.Getenv("TERM")
+	program := os.Getenv("TERM_PROGRAM")
+
+	if os.Getenv("KITTY_WINDOW_ID") != "" || strings.Contains(term, "kitty") ||
+		program == "WezTerm" || program == "ghostty" {
+		return ProtocolKitty
+	}
+	if strings.Contains(term, "sixel") || program == "mlterm" || strings.HasPrefix(term, "foot") {
+		return ProtocolSixel
+	}
+	return ProtocolHalfBlock
+}
+
+// ImageInfo describes one side of an image diff
+type ImageInfo struct {
+	Image  image.Image
+	Format string
+	Size   int
+}
+
+// DecodeImage decodes image data, returning nil for empty data (a missing side)
+func DecodeImage(data []byte) (*ImageInfo, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	return &ImageInfo{Image: img, Format: format, Size: len(data)}, nil
+}
+
+// Describe returns a one-line summary of dimensions and size
+func (i *ImageInfo) Describe() string {
+	if i == nil {
+		return "(none)"
+	}
+	bounds := i.Image.Bounds()
+	return fmt.Sprintf("%d×%d %s, %s", bounds.Dx(), bounds.Dy(), i.Format, FormatSize(i.Size))
+}
+
+// SizeDelta describes the change in byte size between two images
+func SizeDelta(before, after *ImageInfo) string {
+	var oldSize, newSize int
+	if before != nil {
+		oldSize = before.Size
+	}
+	if after != nil {
+		newSize = after.Size
+	}
+
+	delta := newSize - oldSize
+	sign := "+"
+	if delta < 0 {
+		sign = "-"
+		delta = -delta
+	}
+	return sign + FormatSize(delta)
+}
+
+// FormatSize formats a byte count for humans
+func FormatSize(n int) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
+
+// Render draws an image in at most width columns using the given protocol
+func Render(img image.Image, width int, protocol Protocol) string {
+	if protocol == ProtocolAuto {
+		protocol = DetectProtocol()
+	}
+
+	switch protocol {
+	case ProtocolKitty:
+		return renderKitty(img, width)
+	case ProtocolSixel:
+		return renderSixel(img, width)
+	}
+	return strings.Join(RenderHalfBlock(img, width), "\n") + "\n"
+}
+
+// RenderHalfBlock draws an image using "▀" cells, where the foreground colors
+// the top pixel and the background the bottom one. It returns one string per
+// terminal row so callers can lay previews out next to each other.
+func RenderHalfBlock(img image.Image, width int) []string {
+	bounds := img.Bounds()
+	if bounds.Dx() == 0 || bounds.Dy() == 0 || width <= 0 {
+		return nil
+	}
+
+	cols := width
+	if bounds.Dx() < cols {
+		cols = bounds.Dx()
+	}
+	// Each cell is roughly twice as tall as it is wide and holds two pixels
+	rows := (bounds.Dy()*cols/bounds.Dx() + 1) / 2
+	if rows > maxPreviewRows {
+		rows = maxPreviewRows
+		cols = bounds.Dx() * rows * 2 / bounds.Dy()
+	}
+	if rows < 1 {
+		rows = 1
+	}
+	if cols < 1 {
+		cols = 1
+	}
+
+	sample := func(x, y int) color.RGBA {
+		px := bounds.Min.X + x*bounds.Dx()/cols
+		py := bounds.Min.Y + y*bounds.Dy()/(rows*2)
+		return color.RGBAModel.Convert(img.At(px, py)).(color.RGBA)
+	}
+
+	lines := make([]string, rows)
+	for y := 0; y < rows; y++ {
+		var sb strings.Builder
+		for x := 0; x < cols; x++ {
+			top, bottom := sample(x, y*2), sample(x, y*2+1)
+			fmt.Fprintf(&sb, "\x1b[38;2;%d;%d;%dm\x1b[48;2;%d;%d;%dm▀",
+				top.R, top.G, top.B, bottom.R, bottom.G, bottom.B)
+		}
+		sb.WriteString("\x1b[0m")
+		lines[y] = sb.String()
+	}
+	return lines
+}
+
+// renderKitty transmits the image as PNG using the kitty graphics protocol
+func renderKitty(img image.Image, width int) string {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return strings.Join(RenderHalfBlock(img, width), "\n") + "\n"
+	}
+	payload := base64.StdEncoding.EncodeToString(buf.Bytes())
+
+	cols := width
+	if img.Bounds().Dx() < cols {
+		cols = img.Bounds().Dx()
+	}
+
+	// The payload must be sent in chunks of at most 4096 bytes
+	var sb strings.Builder
+	const chunkSize = 4096
+	for i := 0; i < len(payload); i += chunkSize {
+		end := i + chunkSize
+		more := 1
+		if end >= len(payload) {
+			end = len(payload)
+			more = 0
+		}
+		if i == 0 {
+			fmt.Fprintf(&sb, "\x1b_Gf=100,a=T,c=%d,m=%d;%s\x1b\\", cols, more, payload[i:end])
+		} else {
+			fmt.Fprintf(&sb, "\x1b_Gm=%d;%s\x1b\\", more, payload[i:end])
+		}
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// renderSixel encodes the image as sixels using a 6×6×6 color cube palette
+func renderSixel(img image.Image, width int) string {
+	bounds := img.Bounds()
+	if bounds.Dx() == 0 || bounds.Dy() == 0 {
+		return ""
+	}
+
+	// Assume roughly 8 pixels per terminal column
+	w := bounds.Dx()
+	if w > width*8 {
+		w = width * 8
+	}
+	h := bounds.Dy() * w / bounds.Dx()
+	if h < 1 {
+		h = 1
+	}
+
+	paletteIndex := func(x, y int) int {
+		c := color.RGBAModel.Convert(img.At(bounds.Min.X+x*bounds.Dx()/w, bounds.Min.Y+y*bounds.Dy()/h)).(color.RGBA)
+		return int(c.R)*6/256*36 + int(c.G)*6/256*6 + int(c.B)*6/256
+	}
+
+	var sb strings.Builder
+	sb.WriteString("\x1bPq")
+	fmt.Fprintf(&sb, "\"1;1;%d;%d", w, h)
+	for i := 0; i < 216; i++ {
+		r, g, b := i/36, i/6%6, i%6
+		fmt.Fprintf(&sb, "#%d;2;%d;%d;%d", i, r*100/5, g*100/5, b*100/5)
+	}
+
+	for band := 0; band < h; band += 6 {
+		// Collect the sixel bits of every color used in this band
+		var bits [216][]byte
+		for x := 0; x < w; x++ {
+			for dy := 0; dy < 6 && band+dy < h; dy++ {
+				idx := paletteIndex(x, band+dy)
+				if bits[idx] == nil {
+					bits[idx] = make([]byte, w)
+				}
+				bits[idx][x] |= 1 << dy
+			}
+		}
+		for idx, row := range bits {
+			if row == nil {
+				continue
+			}
+			fmt.Fprintf(&sb, "#%d", idx)
+			for _, b := range row {
+				sb.WriteByte(63 + b)
+			}
+			sb.WriteByte('$')
+		}
+		sb.WriteByte('-')
+	}
+
+	sb.WriteString("\x1b\\\n")
+	return sb.String()
+}
@@ -0,0 +1,183 @@
+package review
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Sides of the diff a comment is on
+const (
+	SideNew = "new" // A line of the new file, an added or context line
+	SideOld = "old" // A removed line, numbered in the old file
+)
+
+// Comment is a review note on a line of a diff
+type Comment struct {
+	Path string `json:"path"`
+	Line int    `json:"line"`
+	Side string `json:"side"`
+	Text string `json:"text,omitempty"` // The commented line, for context when exported
+	Body string `json:"body"`
+}
+
+// Store holds the comments of each repository, by commit reviewed
+type Store struct {
+	Repos map[string]map[string][]Comment `json:"repos,omitempty"`
+}
+
+// DefaultPath returns the comment store location, next to the UI state in
+// $XDG_STATE_HOME/differential (falling back to ~/.local/state)
+func DefaultPath() string {
+	dir := os.Getenv("XDG_STATE_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(dir, "differential", "comments.json")
+}
+
+// Load reads the comment store. A missing file yields an empty store.
+func Load(path string) (*Store, error) {
+	s := &Store{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return s, fmt.Errorf("failed to read comments: %w", err)
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return &Store{}, fmt.Errorf("failed to parse comments %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Save writes the comment store, creating its directory if needed
+func (s *Store) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create comments directory: %w", err)
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode comments: %w", err)
+	}
+
+	// Write through a temp file so a crash never loses the comments
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write comments: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write comments: %w", err)
+	}
+	return nil
+}
+
+// Comments returns the comments on commit in the repository at root
+func (s *Store) Comments(root, commit string) []Comment {
+	return s.Repos[root][commit]
+}
+
+// SetComments replaces the comments on commit in the repository at root,
+// dropping the entry when there are none left
+func (s *Store) SetComments(root, commit string, comments []Comment) {
+	if len(comments) == 0 {
+		delete(s.Repos[root], commit)
+		if len(s.Repos[root]) == 0 {
+			delete(s.Repos, root)
+		}
+		return
+	}
+	if s.Repos == nil {
+		s.Repos = make(map[string]map[string][]Comment)
+	}
+	if s.Repos[root] == nil {
+		s.Repos[root] = make(map[string][]Comment)
+	}
+	s.Repos[root][commit] = comments
+}
+
+// Find returns the index of the comment on a line, or -1
+func Find(comments []Comment, path, side string, line int) int {
+	for i, c := range comments {
+		if c.Path == path && c.Side == side && c.Line == line {
+			return i
+		}
+	}
+	return -1
+}
+
+// Sort orders comments by file, then line, old lines first
+func Sort(comments []Comment) {
+	sort.SliceStable(comments, func(i, j int) bool {
+		a, b := comments[i], comments[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Side == SideOld && b.Side != SideOld
+	})
+}
+
+// Markdown formats comments as a markdown review, a section per file with
+// each commented line quoted above its comment
+func Markdown(title string, comments []Comment) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# %s\n", title)
+	for i, c := range comments {
+		if i == 0 || c.Path != comments[i-1].Path {
+			fmt.Fprintf(&sb, "\n## `%s`\n", c.Path)
+		}
+		location := fmt.Sprintf("Line %d", c.Line)
+		if c.Side == SideOld {
+			location = fmt.Sprintf("Removed line %d", c.Line)
+		}
+		fmt.Fprintf(&sb, "\n**%s**", location)
+		if c.Text != "" {
+			fmt.Fprintf(&sb, ": `%s`", strings.TrimSpace(strings.ReplaceAll(c.Text, "`", "'")))
+		}
+		fmt.Fprintf(&sb, "\n\n%s\n", c.Body)
+	}
+	return sb.String()
+}
+
+// GitHubReview is the request body of GitHub's create review endpoint,
+// POST /repos/{owner}/{repo}/pulls/{number}/reviews
+type GitHubReview struct {
+	CommitID string          `json:"commit_id,omitempty"`
+	Body     string          `json:"body,omitempty"`
+	Event    string          `json:"event"`
+	Comments []GitHubComment `json:"comments"`
+}
+
+// GitHubComment is a line comment of a GitHubReview
+type GitHubComment struct {
+	Path string `json:"path"`
+	Line int    `json:"line"`
+	Side string `json:"side"` // LEFT for removed lines, RIGHT otherwise
+	Body string `json:"body"`
+}
+
+// NewGitHubReview builds a review commenting on commitID, the head commit
+// of the pull request, without approving or requesting changes
+func NewGitHubReview(commitID string, comments []Comment) GitHubReview {
+	review := GitHubReview{CommitID: commitID, Event: "COMMENT", Comments: []GitHubComment{}}
+	for _, c := range comments {
+		side := "RIGHT"
+		if c.Side == SideOld {
+			side = "LEFT"
+		}
+		review.Comments = append(review.Comments, GitHubComment{Path: c.Path, Line: c.Line, Side: side, Body: c.Body})
+	}
+	return review
+}
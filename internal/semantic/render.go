@@ -0,0 +1,76 @@
+package semantic
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/avgvstvs96/differential/internal/themes"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maxValueWidth caps how much of a value is shown inline
+const maxValueWidth = 60
+
+// Render renders changes as an indented tree under a file heading
+func Render(filename string, changes []Change) string {
+	theme := themes.GetCurrentTheme()
+	titleStyle := lipgloss.NewStyle().Foreground(theme.Text).Bold(true)
+	keyStyle := lipgloss.NewStyle().Foreground(theme.SyntaxType)
+	addedStyle := lipgloss.NewStyle().Foreground(theme.DiffAdded)
+	removedStyle := lipgloss.NewStyle().Foreground(theme.DiffRemoved)
+	mutedStyle := lipgloss.NewStyle().Foreground(theme.TextMuted)
+
+	var sb strings.Builder
+	sb.WriteString(titleStyle.Render(fmt.Sprintf("%s (%d structural change(s))", filename, len(changes))))
+	sb.WriteString("\n")
+
+	if len(changes) == 0 {
+		sb.WriteString(mutedStyle.Render("  no structural changes"))
+		sb.WriteString("\n")
+		return sb.String()
+	}
+
+	var prev []string
+	for _, change := range changes {
+		if len(change.Path) == 0 {
+			// The document root itself changed type or value
+			sb.WriteString(removedStyle.Render("  - " + FormatValue(change.Old, maxValueWidth)))
+			sb.WriteString("\n")
+			sb.WriteString(addedStyle.Render("  + " + FormatValue(change.New, maxValueWidth)))
+			sb.WriteString("\n")
+			continue
+		}
+
+		parents := change.Path[:len(change.Path)-1]
+
+		// Print the parent keys that differ from the previous change
+		common := 0
+		for common < len(parents) && common < len(prev) && parents[common] == prev[common] {
+			common++
+		}
+		for depth := common; depth < len(parents); depth++ {
+			sb.WriteString(strings.Repeat("  ", depth+1))
+			sb.WriteString(keyStyle.Render(parents[depth]))
+			sb.WriteString("\n")
+		}
+		prev = parents
+
+		indent := strings.Repeat("  ", len(parents)+1)
+		key := change.Path[len(change.Path)-1]
+
+		switch change.Kind {
+		case ChangeAdded:
+			sb.WriteString(indent + addedStyle.Render(fmt.Sprintf("+ %s: %s", key, FormatValue(change.New, maxValueWidth))))
+		case ChangeRemoved:
+			sb.WriteString(indent + removedStyle.Render(fmt.Sprintf("- %s: %s", key, FormatValue(change.Old, maxValueWidth))))
+		case ChangeUpdated:
+			sb.WriteString(indent + keyStyle.Render("~ "+key+": "))
+			sb.WriteString(removedStyle.Render(FormatValue(change.Old, maxValueWidth)))
+			sb.WriteString(mutedStyle.Render(" → "))
+			sb.WriteString(addedStyle.Render(FormatValue(change.New, maxValueWidth)))
+		}
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
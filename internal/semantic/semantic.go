@@ -0,0 +1,183 @@
+package semantic
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// ChangeKind describes how a value changed between two documents
+type ChangeKind int
+
+const (
+	ChangeAdded   ChangeKind = iota // Key or element only exists in the new document
+	ChangeRemoved                   // Key or element only exists in the old document
+	ChangeUpdated                   // Value differs between the documents
+)
+
+// Change is a single structural difference
+type Change struct {
+	Path []string   // Keys and indexes from the document root
+	Kind ChangeKind // Type of change
+	Old  any        // Old value (nil for added)
+	New  any        // New value (nil for removed)
+}
+
+// Supported reports whether a file can be diffed structurally
+func Supported(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json", ".yaml", ".yml", ".toml":
+		return true
+	}
+	return false
+}
+
+// Parse decodes a JSON, YAML or TOML document based on the file extension
+func Parse(path string, data []byte) (any, error) {
+	var doc any
+	var err error
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(data, &doc)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &doc)
+	case ".toml":
+		err = toml.Unmarshal(data, &doc)
+	default:
+		return nil, fmt.Errorf("unsupported file type: %s", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	return normalize(doc), nil
+}
+
+// Compare returns the structural changes between two decoded documents,
+// ordered by path
+func Compare(oldDoc, newDoc any) []Change {
+	var changes []Change
+	compare(nil, oldDoc, newDoc, &changes)
+	return changes
+}
+
+// compare walks both values and records changes under path
+func compare(path []string, oldVal, newVal any, changes *[]Change) {
+	oldMap, oldIsMap := oldVal.(map[string]any)
+	newMap, newIsMap := newVal.(map[string]any)
+	if oldIsMap && newIsMap {
+		for _, key := range unionKeys(oldMap, newMap) {
+			child := appendPath(path, key)
+			oldChild, inOld := oldMap[key]
+			newChild, inNew := newMap[key]
+			switch {
+			case !inOld:
+				*changes = append(*changes, Change{Path: child, Kind: ChangeAdded, New: newChild})
+			case !inNew:
+				*changes = append(*changes, Change{Path: child, Kind: ChangeRemoved, Old: oldChild})
+			default:
+				compare(child, oldChild, newChild, changes)
+			}
+		}
+		return
+	}
+
+	oldList, oldIsList := oldVal.([]any)
+	newList, newIsList := newVal.([]any)
+	if oldIsList && newIsList {
+		for i := 0; i < len(oldList) || i < len(newList); i++ {
+			child := appendPath(path, fmt.Sprintf("[%d]", i))
+			switch {
+			case i >= len(oldList):
+				*changes = append(*changes, Change{Path: child, Kind: ChangeAdded, New: newList[i]})
+			case i >= len(newList):
+				*changes = append(*changes, Change{Path: child, Kind: ChangeRemoved, Old: oldList[i]})
+			default:
+				compare(child, oldList[i], newList[i], changes)
+			}
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(oldVal, newVal) {
+		*changes = append(*changes, Change{Path: path, Kind: ChangeUpdated, Old: oldVal, New: newVal})
+	}
+}
+
+// normalize converts YAML's map[any]any into map[string]any recursively
+func normalize(v any) any {
+	switch val := v.(type) {
+	case map[any]any:
+		m := make(map[string]any, len(val))
+		for k, item := range val {
+			m[fmt.Sprint(k)] = normalize(item)
+		}
+		return m
+	case map[string]any:
+		for k, item := range val {
+			val[k] = normalize(item)
+		}
+		return val
+	case []any:
+		for i, item := range val {
+			val[i] = normalize(item)
+		}
+		return val
+	}
+	return v
+}
+
+// unionKeys returns the sorted keys present in either map
+func unionKeys(a, b map[string]any) []string {
+	seen := make(map[string]bool, len(a)+len(b))
+	var keys []string
+	for _, m := range []map[string]any{a, b} {
+		for k := range m {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// appendPath returns a copy of path with key appended
+func appendPath(path []string, key string) []string {
+	child := make([]string, len(path), len(path)+1)
+	copy(child, path)
+	return append(child, key)
+}
+
+// FormatValue renders a value compactly on one line
+func FormatValue(v any, maxLen int) string {
+	var s string
+	switch v.(type) {
+	case map[string]any, []any:
+		data, err := json.Marshal(v)
+		if err != nil {
+			s = fmt.Sprint(v)
+		} else {
+			s = string(data)
+		}
+	case string:
+		s = fmt.Sprintf("%q", v)
+	case nil:
+		s = "null"
+	default:
+		s = fmt.Sprint(v)
+	}
+
+	if maxLen > 0 && len([]rune(s)) > maxLen {
+		s = string([]rune(s)[:maxLen-1]) + "…"
+	}
+	return s
+}
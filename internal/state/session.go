@@ -0,0 +1,80 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/avgvstvs96/differential/internal/review"
+)
+
+// SessionVersion is the format version of session files written
+const SessionVersion = 1
+
+// Session is a review saved to resume later or share: the raw diff and the
+// viewer's state over it
+type Session struct {
+	Version  int    `json:"version"`
+	Diff     string `json:"diff"`
+	Filename string `json:"filename,omitempty"` // File compared, for diffs of two files
+
+	ViewMode    string `json:"view_mode,omitempty"`
+	LineNumbers bool   `json:"line_numbers"`
+	DimContext  bool   `json:"dim_context,omitempty"`
+	FullFile    bool   `json:"full_file,omitempty"`
+	Cursor      int    `json:"cursor,omitempty"`
+	Scroll      int    `json:"scroll,omitempty"`
+
+	Collapsed []string         `json:"collapsed,omitempty"` // Paths of collapsed files
+	Approved  []string         `json:"approved,omitempty"`  // Keys of the approved change patterns
+	Staged    []StagedHunk     `json:"staged,omitempty"`    // Hunks marked as staged
+	Comments  []review.Comment `json:"comments,omitempty"`
+}
+
+// StagedHunk names a hunk marked as staged by its file's path and its @@
+// header line
+type StagedHunk struct {
+	File   string `json:"file"`
+	Header string `json:"header"`
+}
+
+// LoadSession reads a session file
+func LoadSession(path string) (*Session, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session: %w", err)
+	}
+	s := &Session{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("failed to parse session %s: %w", path, err)
+	}
+	if s.Version > SessionVersion {
+		return nil, fmt.Errorf("session %s has version %d; this differential reads up to version %d", path, s.Version, SessionVersion)
+	}
+	return s, nil
+}
+
+// Save writes the session file, creating its directory if needed
+func (s *Session) Save(path string) error {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create session directory: %w", err)
+		}
+	}
+	s.Version = SessionVersion
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode session: %w", err)
+	}
+
+	// Write through a temp file so a crash never leaves a truncated session
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write session: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write session: %w", err)
+	}
+	return nil
+}
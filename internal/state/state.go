@@ -0,0 +1,89 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// State is the UI state remembered between runs
+type State struct {
+	ViewMode    string                `json:"view_mode,omitempty"`
+	Theme       string                `json:"theme,omitempty"`
+	LineNumbers *bool                 `json:"line_numbers,omitempty"`
+	WrapLines   *bool                 `json:"wrap_lines,omitempty"`
+	Gutter      string                `json:"gutter,omitempty"`
+	DimContext  *bool                 `json:"dim_context,omitempty"`
+	Repos       map[string]*RepoState `json:"repos,omitempty"`
+}
+
+// RepoState is the state remembered per repository
+type RepoState struct {
+	Collapsed []string `json:"collapsed,omitempty"` // Paths of collapsed files
+}
+
+// DefaultPath returns the state file location, following the XDG base
+// directory spec ($XDG_STATE_HOME, falling back to ~/.local/state)
+func DefaultPath() string {
+	dir := os.Getenv("XDG_STATE_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(dir, "differential", "state.json")
+}
+
+// Load reads the state file. A missing file yields an empty state.
+func Load(path string) (*State, error) {
+	s := &State{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return s, fmt.Errorf("failed to read state: %w", err)
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return &State{}, fmt.Errorf("failed to parse state %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Save writes the state file, creating its directory if needed
+func (s *State) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode state: %w", err)
+	}
+
+	// Write through a temp file so a crash never leaves a truncated state
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+	return nil
+}
+
+// Repo returns the state of a repository, creating it if needed
+func (s *State) Repo(root string) *RepoState {
+	if s.Repos == nil {
+		s.Repos = make(map[string]*RepoState)
+	}
+	repo, ok := s.Repos[root]
+	if !ok {
+		repo = &RepoState{}
+		s.Repos[root] = repo
+	}
+	return repo
+}
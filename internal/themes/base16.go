@@ -0,0 +1,167 @@
+package themes
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/lipgloss"
+	"gopkg.in/yaml.v3"
+)
+
+// base16Slots are the names of the sixteen colors of a base16 scheme, in
+// order: eight shades from the background to the foreground, then eight
+// accents
+var base16Slots = []string{
+	"base00", "base01", "base02", "base03", "base04", "base05", "base06", "base07",
+	"base08", "base09", "base0A", "base0B", "base0C", "base0D", "base0E", "base0F",
+}
+
+// base16Keys are the values imported themes give their colors, after the
+// base16 styling guidelines. Diff backgrounds are left to be derived.
+var base16Keys = map[string]string{
+	"text":                    "base05",
+	"textMuted":               "base04",
+	"error":                   "base08",
+	"diffAdded":               "base0B",
+	"diffRemoved":             "base08",
+	"diffContext":             "base05",
+	"diffContextBg":           "base00",
+	"diffHighlightAdded":      "mix(diffAdded, background, 0.3)",
+	"diffHighlightRemoved":    "mix(diffRemoved, background, 0.3)",
+	"diffLineNumber":          "base03",
+	"diffAddedLineNumberBg":   "mix(diffAdded, background, 0.25)",
+	"diffRemovedLineNumberBg": "mix(diffRemoved, background, 0.25)",
+	"diffMovedFrom":           "base0E",
+	"diffMovedTo":             "base0C",
+	"syntaxKeyword":           "base0E",
+	"syntaxFunction":          "base0D",
+	"syntaxType":              "base0A",
+	"syntaxVariable":          "base08",
+	"syntaxString":            "base0B",
+	"syntaxNumber":            "base09",
+	"syntaxComment":           "base03",
+	"syntaxOperator":          "base05",
+	"syntaxPunctuation":       "base05",
+	"background":              "base00",
+	"backgroundPanel":         "base01",
+	"border":                  "base03",
+	"selection":               "base02",
+	"cursor":                  "base01",
+}
+
+// ParseBase16 converts a base16 scheme in YAML to a theme with the same
+// colors for dark and light terminals. It reads both the current format,
+// with the colors under palette, and the older one with them at the top
+// level. The theme is named after the scheme, lowercased with dashes for
+// spaces, or has no name when the scheme has none.
+func ParseBase16(data []byte) (*Theme, error) {
+	var scheme map[string]interface{}
+	if err := yaml.Unmarshal(data, &scheme); err != nil {
+		return nil, fmt.Errorf("failed to parse base16 scheme: %w", err)
+	}
+	if system, ok := scheme["system"].(string); ok && system != "base16" && system != "base24" {
+		return nil, fmt.Errorf("%s schemes aren't base16 schemes", system)
+	}
+
+	colors := scheme
+	if palette, ok := scheme["palette"].(map[string]interface{}); ok {
+		colors = palette
+	}
+	theme := &Theme{Defs: make(map[string]string), Theme: make(map[string]map[string]string)}
+	for key, value := range colors {
+		slot := base16Slot(key)
+		if slot == "" {
+			continue
+		}
+		color, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s is not a color", key)
+		}
+		color = "#" + strings.ToLower(strings.TrimPrefix(strings.TrimSpace(color), "#"))
+		if _, _, _, err := rgb(lipgloss.Color(color)); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", key, err)
+		}
+		theme.Defs[slot] = color
+	}
+	for _, slot := range base16Slots {
+		if _, ok := theme.Defs[slot]; !ok {
+			return nil, fmt.Errorf("base16 scheme has no %s", slot)
+		}
+	}
+
+	for key, value := range base16Keys {
+		theme.Theme[key] = map[string]string{"dark": value, "light": value}
+	}
+	name, _ := scheme["name"].(string)
+	if name == "" {
+		name, _ = scheme["scheme"].(string)
+	}
+	theme.Name = themeSlug(name)
+	return theme, nil
+}
+
+// base16Slot returns the name in base16Slots of a scheme key, whose hex
+// digit may be in either case, or "" when it isn't one
+func base16Slot(key string) string {
+	for _, slot := range base16Slots {
+		if strings.EqualFold(key, slot) {
+			return slot
+		}
+	}
+	return ""
+}
+
+// themeSlug lowercases name and joins its words with dashes, e.g.
+// "Gruvbox dark, hard" becomes "gruvbox-dark-hard"
+func themeSlug(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(words, "-")
+}
+
+// Base16YAML returns a theme as a base16 scheme named name, in the current
+// YAML format. The shades between the background and the text that
+// differential has no colors for are mixed from them. All the colors used
+// must be hex colors.
+func Base16YAML(t *ThemeColors, name string) (string, error) {
+	variant, extreme := "dark", lipgloss.Color("#ffffff")
+	if !isDarkColor(t.Background) {
+		variant, extreme = "light", "#000000"
+	}
+	base06, err := Mix(extreme, t.Text, 0.33)
+	if err != nil {
+		return "", fmt.Errorf("text: %w", err)
+	}
+	base07, err := Mix(extreme, t.Text, 0.66)
+	if err != nil {
+		return "", fmt.Errorf("text: %w", err)
+	}
+	colors := []lipgloss.Color{
+		t.Background, t.BackgroundPanel, t.Selection, t.SyntaxComment,
+		t.TextMuted, t.Text, base06, base07,
+		t.DiffRemoved, t.SyntaxNumber, t.SyntaxType, t.DiffAdded,
+		t.DiffMovedTo, t.SyntaxFunction, t.SyntaxKeyword, t.Error,
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "system: \"base16\"\nname: %q\nauthor: \"differential\"\nvariant: %q\npalette:\n", name, variant)
+	for i, color := range colors {
+		if _, _, _, err := rgb(color); err != nil {
+			return "", fmt.Errorf("%s: %w", base16Slots[i], err)
+		}
+		fmt.Fprintf(&sb, "  %s: %q\n", base16Slots[i], strings.ToLower(string(color)))
+	}
+	return sb.String(), nil
+}
+
+// isDarkColor reports whether a hex color is dark, taking colors that
+// aren't hex as dark
+func isDarkColor(c lipgloss.Color) bool {
+	r, g, b, err := rgb(c)
+	if err != nil {
+		return true
+	}
+	return 0.299*r+0.587*g+0.114*b < 128
+}
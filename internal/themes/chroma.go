@@ -3,8 +3,11 @@ package themes
 import (
 	"bytes"
 	"fmt"
+	"html"
 	"io"
+	"path/filepath"
 	"strings"
+	"unicode"
 
 	"github.com/alecthomas/chroma/v2"
 	"github.com/alecthomas/chroma/v2/formatters"
@@ -15,23 +18,38 @@ import (
 
 // GenerateChromaStyle creates a Chroma style from the current theme
 func GenerateChromaStyle() (*chroma.Style, error) {
-	t := GetCurrentTheme()
+	return ChromaStyle(GetCurrentTheme())
+}
+
+// ChromaStyle creates a Chroma style from a theme
+func ChromaStyle(t *ThemeColors) (*chroma.Style, error) {
+	style, err := chroma.NewXMLStyle(strings.NewReader(ChromaXML(t, "pretty-diff")))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Chroma style: %w", err)
+	}
 	
-	// Convert lipgloss colors to Chroma format
+	return style, nil
+}
+
+// ChromaXML returns a theme as a Chroma style named name, in the XML format
+// of Chroma's style files
+func ChromaXML(t *ThemeColors, name string) string {
+	// Convert lipgloss colors to Chroma format, which needs the # prefix
 	toChroma := func(c lipgloss.Color) string {
-		s := string(c)
-		// Remove # prefix if present
-		if strings.HasPrefix(s, "#") {
-			s = s[1:]
+		return "#" + strings.TrimPrefix(string(c), "#")
+	}
+	// Backgrounds are left out when the theme has none, e.g. for printing
+	toChromaBg := func(c lipgloss.Color) string {
+		if c == "" {
+			return ""
 		}
-		return s
+		return "bg:" + toChroma(c)
 	}
 	
 	// Generate Chroma style XML
-	styleXML := fmt.Sprintf(`
-<style name="pretty-diff">
+	return fmt.Sprintf(`<style name="%s">
     <!-- Base -->
-    <entry type="Background" style="bg:%s"/>
+    <entry type="Background" style="%s"/>
     <entry type="Text" style="%s"/>
     <entry type="Error" style="%s bold"/>
     
@@ -105,14 +123,16 @@ func GenerateChromaStyle() (*chroma.Style, error) {
     <entry type="Punctuation" style="%s"/>
     
     <!-- Generic (for diffs, etc) -->
-    <entry type="GenericDeleted" style="%s bg:%s"/>
-    <entry type="GenericInserted" style="%s bg:%s"/>
+    <entry type="GenericDeleted" style="%s %s"/>
+    <entry type="GenericInserted" style="%s %s"/>
     <entry type="GenericHeading" style="%s bold"/>
     <entry type="GenericSubheading" style="%s bold"/>
     <entry type="GenericStrong" style="bold"/>
     <entry type="GenericEmph" style="italic"/>
-</style>`,
-		toChroma(t.Background),
+</style>
+`,
+		html.EscapeString(name),
+		toChromaBg(t.Background),
 		toChroma(t.Text),
 		toChroma(t.Error),
 		// Keywords
@@ -180,19 +200,11 @@ func GenerateChromaStyle() (*chroma.Style, error) {
 		toChroma(t.SyntaxOperator),
 		toChroma(t.SyntaxPunctuation),
 		// Generic (diff)
-		toChroma(t.DiffRemoved), toChroma(t.DiffRemovedBg),
-		toChroma(t.DiffAdded), toChroma(t.DiffAddedBg),
+		toChroma(t.DiffRemoved), toChromaBg(t.DiffRemovedBg),
+		toChroma(t.DiffAdded), toChromaBg(t.DiffAddedBg),
 		toChroma(t.Text),
 		toChroma(t.TextMuted),
 	)
-	
-	// Create style from XML
-	style, err := chroma.NewXMLStyle(strings.NewReader(styleXML))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create Chroma style: %w", err)
-	}
-	
-	return style, nil
 }
 
 // SyntaxHighlight applies syntax highlighting to source code
@@ -290,4 +302,139 @@ func ApplySyntaxHighlighting(w io.Writer, source, filename string) error {
 	}
 	
 	return formatter.Format(w, style, tokens)
-}
\ No newline at end of file
+}
+
+// Highlighter highlights lines of one file with the lexer, style and
+// formatter resolved once up front. Use it instead of SyntaxHighlightLine
+// when highlighting many lines; it is safe for concurrent use.
+type Highlighter struct {
+	lexer     chroma.Lexer // nil when the filename doesn't identify a language
+	style     *chroma.Style
+	formatter chroma.Formatter
+}
+
+// languageNames names files chroma would attribute to the wrong language
+var languageNames = map[string]string{
+	"go.mod":  "Go Module",
+	"go.sum":  "Go Checksums",
+	"go.work": "Go Workspace",
+}
+
+// LanguageName returns the name of the language detected from filename, or
+// an empty string when it isn't recognized
+func LanguageName(filename string) string {
+	if name, ok := languageNames[filepath.Base(filename)]; ok {
+		return name
+	}
+	return lexerName(lexers.Match(filename))
+}
+
+// LexerName returns the name of the chroma lexer called language, by name,
+// alias or file extension (go, html, py), or an empty string when there is
+// none
+func LexerName(language string) string {
+	return lexerName(lexers.Get(language))
+}
+
+// Languages returns the names of the lexers LexerName knows, sorted
+func Languages() []string {
+	return lexers.Names(false)
+}
+
+// lexerName returns the capitalized name of lexer, or an empty string
+func lexerName(lexer chroma.Lexer) string {
+	if lexer == nil || lexer.Config().Name == "" {
+		return ""
+	}
+	// Some lexers have lowercase names, e.g. markdown
+	name := []rune(lexer.Config().Name)
+	name[0] = unicode.ToUpper(name[0])
+	return string(name)
+}
+
+// NewHighlighter creates a highlighter for filename using theme, or the
+// current theme when theme is nil. language names the lexer to use, as
+// LexerName takes it, instead of detecting it from filename.
+func NewHighlighter(filename, language string, theme *ThemeColors) *Highlighter {
+	if theme == nil {
+		theme = GetCurrentTheme()
+	}
+
+	h := &Highlighter{}
+	var lexer chroma.Lexer
+	if language != "" {
+		lexer = lexers.Get(language)
+	} else if filename != "" {
+		lexer = lexers.Match(filename)
+	}
+	if lexer != nil {
+		h.lexer = chroma.Coalesce(lexer)
+	}
+
+	style, err := ChromaStyle(theme)
+	if err != nil {
+		style = styles.Get("monokai")
+	}
+	h.style = style
+
+	h.formatter = formatters.Get("terminal16m")
+	if h.formatter == nil {
+		h.formatter = formatters.Fallback
+	}
+	return h
+}
+
+// HighlightLine highlights a single line, returning it unchanged on error
+func (h *Highlighter) HighlightLine(line string) string {
+	// Don't highlight empty lines
+	if strings.TrimSpace(line) == "" {
+		return line
+	}
+
+	lexer := h.lexer
+	if lexer == nil {
+		lexer = lexers.Analyse(line)
+		if lexer == nil {
+			lexer = lexers.Fallback
+		}
+		lexer = chroma.Coalesce(lexer)
+	}
+
+	tokens, err := lexer.Tokenise(nil, line)
+	if err != nil {
+		return line
+	}
+	var buf bytes.Buffer
+	if err := h.formatter.Format(&buf, h.style, tokens); err != nil {
+		return line
+	}
+
+	// Remove trailing newline that Chroma adds
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// Tokens splits a line into the texts of its syntax tokens, or returns nil
+// when the filename and language didn't identify a lexer
+func (h *Highlighter) Tokens(line string) []string {
+	if h == nil || h.lexer == nil || line == "" {
+		return nil
+	}
+	iter, err := h.lexer.Tokenise(nil, line)
+	if err != nil {
+		return nil
+	}
+	var texts []string
+	length := 0
+	for _, tok := range iter.Tokens() {
+		// Lexers may end the line with a newline it doesn't have
+		text := tok.Value[:min(len(tok.Value), len(line)-length)]
+		if text != "" {
+			texts = append(texts, text)
+			length += len(text)
+		}
+	}
+	if strings.Join(texts, "") != line {
+		return nil
+	}
+	return texts
+}
@@ -0,0 +1,316 @@
+package themes
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// rgb returns the channels of a "#rrggbb" or "#rgb" color
+func rgb(c lipgloss.Color) (r, g, b float64, err error) {
+	hex := strings.TrimPrefix(string(c), "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if !strings.HasPrefix(string(c), "#") || len(hex) != 6 {
+		return 0, 0, 0, fmt.Errorf("%q is not a hex color", string(c))
+	}
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("%q is not a hex color", string(c))
+	}
+	return float64(value >> 16), float64(value >> 8 & 0xff), float64(value & 0xff), nil
+}
+
+// Mix blends two hex colors, weighting a by weight and b by 1-weight, so
+// Mix(a, b, 0.15) is b tinted with a
+func Mix(a, b lipgloss.Color, weight float64) (lipgloss.Color, error) {
+	ar, ag, ab, err := rgb(a)
+	if err != nil {
+		return "", err
+	}
+	br, bg, bb, err := rgb(b)
+	if err != nil {
+		return "", err
+	}
+	weight = math.Max(0, math.Min(1, weight))
+	mix := func(x, y float64) int {
+		return int(math.Round(x*weight + y*(1-weight)))
+	}
+	return lipgloss.Color(fmt.Sprintf("#%02x%02x%02x", mix(ar, br), mix(ag, bg), mix(ab, bb))), nil
+}
+
+// Lighten mixes amount of white into a hex color
+func Lighten(c lipgloss.Color, amount float64) (lipgloss.Color, error) {
+	return Mix("#ffffff", c, amount)
+}
+
+// Darken mixes amount of black into a hex color
+func Darken(c lipgloss.Color, amount float64) (lipgloss.Color, error) {
+	return Mix("#000000", c, amount)
+}
+
+// Heat returns the color of a heatmap at ratio, from 0 for the least to 1
+// for the most, on a gradient from TextMuted to DiffRemoved
+func (t *ThemeColors) Heat(ratio float64) lipgloss.Color {
+	color, err := Mix(t.DiffRemoved, t.TextMuted, ratio)
+	if err != nil {
+		// Colors that aren't hex, e.g. ANSI numbers, can't be blended
+		if ratio >= 0.5 {
+			return t.DiffRemoved
+		}
+		return t.TextMuted
+	}
+	return color
+}
+
+// colorFuncs are the functions theme values can call, e.g.
+// "mix(diffAdded, background, 0.15)". Each takes its color arguments
+// first and a weight last.
+var colorFuncs = map[string]struct {
+	colors int
+	apply  func(colors []lipgloss.Color, weight float64) (lipgloss.Color, error)
+}{
+	"mix":     {2, func(c []lipgloss.Color, w float64) (lipgloss.Color, error) { return Mix(c[0], c[1], w) }},
+	"lighten": {1, func(c []lipgloss.Color, w float64) (lipgloss.Color, error) { return Lighten(c[0], w) }},
+	"darken":  {1, func(c []lipgloss.Color, w float64) (lipgloss.Color, error) { return Darken(c[0], w) }},
+}
+
+// DefaultDiffBackgroundAlpha is how much of the added and removed colors
+// tints the background of themes without diff backgrounds
+const DefaultDiffBackgroundAlpha = 0.15
+
+// derivedColors are the keys a theme may leave out, with the values used in
+// their place. {alpha} is replaced with the diff background alpha.
+var derivedColors = map[string]string{
+	"diffAddedBg":   "mix(diffAdded, background, {alpha})",
+	"diffRemovedBg": "mix(diffRemoved, background, {alpha})",
+	// Magenta and cyan, like git's moved lines, in the theme's shades
+	"diffMovedFrom":   "syntaxNumber",
+	"diffMovedTo":     "syntaxType",
+	"diffMovedFromBg": "mix(diffMovedFrom, background, {alpha})",
+	"diffMovedToBg":   "mix(diffMovedTo, background, {alpha})",
+	"selection":       "mix(text, background, 0.2)",
+	"cursor":          "selection",
+}
+
+// resolver evaluates the color values of one variant of a theme. Values
+// name a color in defs, another key of the theme, a color literal, or call
+// one of colorFuncs on such values. Keys the theme leaves out are taken
+// from the theme it extends, or derived from its other colors, or taken
+// from BaseTheme.
+type resolver struct {
+	theme   *Theme
+	themes  map[string]*Theme // Themes to inherit from by name; nil inherits nothing
+	variant string            // "dark" or "light"
+	alpha   float64           // Weight of the foreground in derived colors
+
+	// active holds the names being resolved, to report reference cycles
+	active map[string]bool
+	// extending holds the names of the theme and those it extends, to
+	// report cycles
+	extending map[string]bool
+}
+
+// newResolver creates a resolver for the dark or light variant of theme,
+// deriving missing diff backgrounds with alpha, or the default when it is 0,
+// and taking other missing colors from the themes it inherits from, which
+// may be nil
+func newResolver(theme *Theme, themes map[string]*Theme, dark bool, alpha float64) *resolver {
+	variant := "dark"
+	if !dark {
+		variant = "light"
+	}
+	if alpha <= 0 {
+		alpha = DefaultDiffBackgroundAlpha
+	}
+	return &resolver{
+		theme:     theme,
+		themes:    themes,
+		variant:   variant,
+		alpha:     alpha,
+		active:    make(map[string]bool),
+		extending: map[string]bool{theme.Name: true},
+	}
+}
+
+// base returns a resolver for the theme the resolved one inherits from: the
+// one it extends, or BaseTheme. It returns nil when there is none.
+func (r *resolver) base() (*resolver, error) {
+	if r.themes == nil {
+		return nil, nil
+	}
+	name := r.theme.Extends
+	if name == "" {
+		name = BaseTheme
+	}
+	base, ok := r.themes[name]
+	switch {
+	case !ok && r.theme.Extends != "":
+		return nil, fmt.Errorf("%s extends unknown theme %s", r.theme.Name, name)
+	case !ok || base == r.theme && r.theme.Extends == "":
+		return nil, nil
+	case r.extending[name]:
+		return nil, fmt.Errorf("%s extends itself through %s", r.theme.Name, name)
+	}
+
+	b := newResolver(base, r.themes, r.variant == "dark", r.alpha)
+	for name := range r.extending {
+		b.extending[name] = true
+	}
+	return b, nil
+}
+
+// key resolves a theme key, e.g. "diffAddedBg"
+func (r *resolver) key(key string) (lipgloss.Color, error) {
+	value, ok := r.theme.Theme[key][r.variant]
+	if !ok && r.theme.Extends != "" {
+		// Extended themes provide all they have, derived colors included
+		base, err := r.base()
+		if err != nil {
+			return "", err
+		}
+		return base.key(key)
+	}
+	if !ok {
+		derived, ok := derivedColors[key]
+		if !ok {
+			base, err := r.base()
+			if err != nil {
+				return "", err
+			}
+			if base != nil {
+				return base.key(key)
+			}
+			return "", fmt.Errorf("%s has no %s color", key, r.variant)
+		}
+		value = strings.ReplaceAll(derived, "{alpha}", strconv.FormatFloat(r.alpha, 'g', -1, 64))
+	}
+	return r.named("theme:"+key, value)
+}
+
+// named evaluates value on behalf of name, failing if it refers back to
+// name
+func (r *resolver) named(name, value string) (lipgloss.Color, error) {
+	if r.active[name] {
+		return "", fmt.Errorf("%s refers to itself", strings.TrimPrefix(strings.TrimPrefix(name, "theme:"), "def:"))
+	}
+	r.active[name] = true
+	defer delete(r.active, name)
+	return r.eval(value)
+}
+
+// eval evaluates a color value
+func (r *resolver) eval(value string) (lipgloss.Color, error) {
+	value = strings.TrimSpace(value)
+	name, args, ok := strings.Cut(value, "(")
+	if !ok {
+		if def, ok := r.theme.Defs[value]; ok {
+			return r.named("def:"+value, def)
+		}
+		if _, ok := r.theme.Theme[value]; ok || derivedColors[value] != "" || isThemeKey(value) {
+			return r.key(value)
+		}
+		return lipgloss.Color(value), nil
+	}
+
+	name = strings.TrimSpace(name)
+	fn, ok := colorFuncs[name]
+	if !ok {
+		return "", fmt.Errorf("unknown color function %s", name)
+	}
+	args, ok = strings.CutSuffix(args, ")")
+	if !ok {
+		return "", fmt.Errorf("missing ) in %q", value)
+	}
+	parts := splitArgs(args)
+	if len(parts) != fn.colors+1 {
+		return "", fmt.Errorf("%s takes %d arguments, got %d", name, fn.colors+1, len(parts))
+	}
+
+	colors := make([]lipgloss.Color, fn.colors)
+	for i := range colors {
+		color, err := r.eval(parts[i])
+		if err != nil {
+			return "", err
+		}
+		colors[i] = color
+	}
+	weight, err := parseWeight(parts[fn.colors])
+	if err != nil {
+		return "", fmt.Errorf("invalid weight in %q: %w", value, err)
+	}
+	color, err := fn.apply(colors, weight)
+	if err != nil {
+		return "", fmt.Errorf("failed to evaluate %q: %w", value, err)
+	}
+	return color, nil
+}
+
+// splitArgs splits function arguments at the commas outside nested calls
+func splitArgs(args string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i, c := range args {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, args[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, args[start:])
+}
+
+// parseWeight parses a weight between 0 and 1, written as a fraction
+// ("0.15") or a percentage ("15%")
+func parseWeight(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	scale := 1.0
+	if trimmed, ok := strings.CutSuffix(s, "%"); ok {
+		s, scale = trimmed, 100
+	}
+	weight, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, err
+	}
+	weight /= scale
+	if weight < 0 || weight > 1 {
+		return 0, fmt.Errorf("%s is not between 0 and 1", s)
+	}
+	return weight, nil
+}
+
+// Validate checks that every color of both variants of the theme resolves
+func (t *Theme) Validate() error {
+	return t.validate(nil)
+}
+
+// validate checks that every color of both variants of the theme resolves,
+// taking the colors it leaves out from the themes it inherits from, which
+// may be nil
+func (t *Theme) validate(themes map[string]*Theme) error {
+	for _, dark := range []bool{true, false} {
+		r := newResolver(t, themes, dark, 0)
+		if _, err := r.base(); err != nil {
+			return err
+		}
+		for key, variants := range t.Theme {
+			if _, ok := variants[r.variant]; !ok {
+				continue
+			}
+			if _, err := r.key(key); err != nil {
+				return fmt.Errorf("invalid %s color %s: %w", r.variant, key, err)
+			}
+		}
+	}
+	return nil
+}
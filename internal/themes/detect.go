@@ -0,0 +1,116 @@
+package themes
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2/lexers"
+)
+
+var (
+	// vimModeline matches "vim: set ft=python:" and "vi: syntax=sh"
+	vimModeline = regexp.MustCompile(`(?:^|\s)(?:vi|vim|ex):.*?\b(?:ft|filetype|syn|syntax)=([\w+#-]+)`)
+	// emacsModeline matches "-*- mode: python -*-" and "-*- python -*-"
+	emacsModeline = regexp.MustCompile(`-\*-(.*?)-\*-`)
+	emacsMode     = regexp.MustCompile(`(?:^|;)\s*mode:\s*([\w+#-]+)`)
+)
+
+// modeLexers names the lexers of interpreters and editor modes chroma
+// doesn't know by that name
+var modeLexers = map[string]string{
+	"node":         "javascript",
+	"nodejs":       "javascript",
+	"bun":          "javascript",
+	"deno":         "typescript",
+	"dash":         "bash",
+	"ash":          "bash",
+	"pwsh":         "powershell",
+	"runghc":       "haskell",
+	"escript":      "erlang",
+	"shell-script": "bash",
+}
+
+// DetectLanguage returns the name of the lexer a shebang or a vim or emacs
+// modeline in the first lines of a file asks for, or an empty string when
+// they name none
+func DetectLanguage(lines []string) string {
+	if len(lines) > 0 && strings.HasPrefix(lines[0], "#!") {
+		if lexer := shebangLexer(lines[0]); lexer != "" {
+			return lexer
+		}
+	}
+	for _, line := range lines {
+		if match := vimModeline.FindStringSubmatch(line); match != nil {
+			if lexer := modeLexer(match[1]); lexer != "" {
+				return lexer
+			}
+		}
+		if match := emacsModeline.FindStringSubmatch(line); match != nil {
+			mode := strings.TrimSpace(match[1])
+			if strings.Contains(mode, ":") {
+				m := emacsMode.FindStringSubmatch(mode)
+				if m == nil {
+					continue
+				}
+				mode = m[1]
+			}
+			if lexer := modeLexer(strings.TrimSuffix(mode, "-mode")); lexer != "" {
+				return lexer
+			}
+		}
+	}
+	return ""
+}
+
+// shebangLexer returns the lexer of the interpreter a "#!" line runs, looking
+// past env and its options
+func shebangLexer(line string) string {
+	fields := strings.Fields(strings.TrimPrefix(line, "#!"))
+	if len(fields) == 0 {
+		return ""
+	}
+	interpreter := path.Base(fields[0])
+	if interpreter == "env" {
+		interpreter = ""
+		for _, field := range fields[1:] {
+			if !strings.HasPrefix(field, "-") && !strings.Contains(field, "=") {
+				interpreter = path.Base(field)
+				break
+			}
+		}
+	}
+	if interpreter == "" {
+		return ""
+	}
+	if lexer := modeLexer(interpreter); lexer != "" {
+		return lexer
+	}
+	// Versioned binaries, e.g. python3.12 or ruby2.7
+	return modeLexer(strings.TrimRight(interpreter, "0123456789.-"))
+}
+
+// modeLexer returns the lexer called name, as a vim filetype, emacs mode or
+// interpreter names it
+func modeLexer(name string) string {
+	name = strings.ToLower(name)
+	if lexer, ok := modeLexers[name]; ok {
+		name = lexer
+	}
+	lexer := lexers.Get(name)
+	if lexer == nil {
+		return ""
+	}
+	// Get falls back to matching name as a file extension, which would take
+	// e.g. ruby2.7 for a man page
+	config := lexer.Config()
+	if strings.EqualFold(config.Name, name) {
+		return config.Name
+	}
+	for _, alias := range config.Aliases {
+		if strings.EqualFold(alias, name) {
+			return config.Name
+		}
+	}
+	return ""
+}
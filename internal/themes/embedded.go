@@ -31,10 +31,16 @@ var (
 
 	//go:embed themes/solarized.json
 	solarizedTheme string
+
+	//go:embed themes/deuteranopia.json
+	deuteranopiaTheme string
+
+	//go:embed themes/protanopia.json
+	protanopiaTheme string
 )
 
-// loadEmbeddedThemes loads all embedded theme files
-func loadEmbeddedThemes() error {
+// embeddedThemes parses all embedded theme files
+func embeddedThemes() (map[string]*Theme, error) {
 	themeData := map[string]string{
 		"dracula":    draculaTheme,
 		"monokai":    monokaiTheme,
@@ -44,16 +50,23 @@ func loadEmbeddedThemes() error {
 		"catppuccin": catppuccinTheme,
 		"tokyonight": tokyonightTheme,
 		"solarized":  solarizedTheme,
+		// Blue for added lines instead of green, for red-green color blindness
+		"deuteranopia": deuteranopiaTheme,
+		"protanopia":   protanopiaTheme,
 	}
 
+	themes := make(map[string]*Theme, len(themeData))
 	for name, data := range themeData {
 		var theme Theme
 		if err := json.Unmarshal([]byte(data), &theme); err != nil {
-			return fmt.Errorf("failed to parse %s theme: %w", name, err)
+			return nil, fmt.Errorf("failed to parse %s theme: %w", name, err)
 		}
 		theme.Name = name
-		availableThemes[name] = &theme
+		if err := theme.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid %s theme: %w", name, err)
+		}
+		themes[name] = &theme
 	}
 
-	return nil
+	return themes, nil
 }
\ No newline at end of file
@@ -0,0 +1,60 @@
+package themes
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ExportFormats are the formats Export writes themes in
+var ExportFormats = []string{"chroma", "base16", "delta"}
+
+// Export returns a theme's colors in a format other tools read, named
+// name: "chroma" for a Chroma style, "base16" for a base16 scheme or
+// "delta" for a delta feature in git config syntax
+func Export(t *ThemeColors, name, format string) (string, error) {
+	switch format {
+	case "chroma":
+		return ChromaXML(t, name), nil
+	case "base16":
+		return Base16YAML(t, name)
+	case "delta":
+		return DeltaConfig(t, name), nil
+	}
+	return "", fmt.Errorf("unknown export format %q, expected one of %s", format, strings.Join(ExportFormats, ", "))
+}
+
+// DeltaConfig returns a theme as a delta feature named after it, for a git
+// config file. Delta takes syntax colors from its own themes, so only the
+// diff colors are set; moved lines are mapped from git's colors for them.
+func DeltaConfig(t *ThemeColors, name string) string {
+	feature := "differential-" + themeSlug(name)
+	quote := func(c lipgloss.Color) string {
+		return fmt.Sprintf("%q", string(c))
+	}
+	syntax := func(c lipgloss.Color) string {
+		if c == "" {
+			return "syntax"
+		}
+		return "syntax " + quote(c)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Enable with features = %s under [delta]\n", feature)
+	fmt.Fprintf(&sb, "[delta %q]\n", feature)
+	fmt.Fprintf(&sb, "\tdark = %t\n", isDarkColor(t.Background))
+	fmt.Fprintf(&sb, "\tminus-style = %s\n", syntax(t.DiffRemovedBg))
+	fmt.Fprintf(&sb, "\tminus-emph-style = %s\n", syntax(t.DiffHighlightRemoved))
+	fmt.Fprintf(&sb, "\tplus-style = %s\n", syntax(t.DiffAddedBg))
+	fmt.Fprintf(&sb, "\tplus-emph-style = %s\n", syntax(t.DiffHighlightAdded))
+	fmt.Fprintf(&sb, "\tline-numbers-minus-style = %s\n", quote(t.DiffRemoved))
+	fmt.Fprintf(&sb, "\tline-numbers-plus-style = %s\n", quote(t.DiffAdded))
+	fmt.Fprintf(&sb, "\tline-numbers-zero-style = %s\n", quote(t.DiffLineNumber))
+	fmt.Fprintf(&sb, "\tfile-style = %s bold\n", quote(t.Text))
+	fmt.Fprintf(&sb, "\tfile-decoration-style = %s ul\n", quote(t.Border))
+	fmt.Fprintf(&sb, "\thunk-header-decoration-style = %s box\n", quote(t.Border))
+	fmt.Fprintf(&sb, "\tmap-styles = bold purple => %s, bold blue => %s, bold cyan => %s, bold yellow => %s\n",
+		syntax(t.DiffMovedFromBg), syntax(t.DiffMovedFromBg), syntax(t.DiffMovedToBg), syntax(t.DiffMovedToBg))
+	return sb.String()
+}
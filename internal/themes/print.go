@@ -0,0 +1,34 @@
+package themes
+
+import "github.com/charmbracelet/lipgloss"
+
+// PrintTheme returns the colors for printing or exporting a diff to paper:
+// dark text on no background, with changed lines told apart by their text
+// color and changed words by a pale highlight. It doesn't depend on the
+// interactive theme or the terminal.
+func PrintTheme() *ThemeColors {
+	return &ThemeColors{
+		Text:                 lipgloss.Color("#000000"),
+		TextMuted:            lipgloss.Color("#57606a"),
+		Error:                lipgloss.Color("#a40e26"),
+		DiffAdded:            lipgloss.Color("#116329"),
+		DiffRemoved:          lipgloss.Color("#a40e26"),
+		DiffContext:          lipgloss.Color("#000000"),
+		DiffHighlightAdded:   lipgloss.Color("#abf2bc"),
+		DiffHighlightRemoved: lipgloss.Color("#ffcecb"),
+		DiffMovedFrom:        lipgloss.Color("#8250df"),
+		DiffMovedTo:          lipgloss.Color("#0550ae"),
+		SyntaxKeyword:        lipgloss.Color("#8250df"),
+		SyntaxFunction:       lipgloss.Color("#0550ae"),
+		SyntaxType:           lipgloss.Color("#953800"),
+		SyntaxVariable:       lipgloss.Color("#000000"),
+		SyntaxString:         lipgloss.Color("#0a3069"),
+		SyntaxNumber:         lipgloss.Color("#0550ae"),
+		SyntaxComment:        lipgloss.Color("#57606a"),
+		SyntaxOperator:       lipgloss.Color("#000000"),
+		SyntaxPunctuation:    lipgloss.Color("#000000"),
+		Border:               lipgloss.Color("#57606a"),
+		Selection:            lipgloss.Color("#fff8c5"),
+		Cursor:               lipgloss.Color("#fff8c5"),
+	}
+}
@@ -0,0 +1,275 @@
+package themes
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Registry holds the available themes and the active one. It is safe for
+// concurrent use; the resolved ThemeColors it hands out are never modified,
+// so they can be shared between goroutines while another theme is activated.
+type Registry struct {
+	mu      sync.RWMutex
+	themes  map[string]*Theme
+	current *ThemeColors
+	name    string  // Name of the active theme
+	dark    bool    // Whether the terminal has a dark background
+	darkSet bool    // Whether dark was set with SetDark rather than detected
+	alpha   float64 // Tint of derived diff backgrounds; 0 uses the default
+
+	// overrides replace colors of whichever theme is resolved, by key
+	overrides map[string]string
+}
+
+// NewRegistry creates a registry with the embedded themes, using dracula as
+// the active theme
+func NewRegistry() (*Registry, error) {
+	r := &Registry{}
+	if err := r.reset(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+var (
+	// defaultRegistry backs the package-level functions
+	defaultRegistry = &Registry{}
+	defaultOnce     sync.Once
+	defaultErr      error
+)
+
+// Default returns the registry used by the package-level functions and by
+// renderers that aren't given a theme. The embedded themes are loaded on
+// first use.
+func Default() *Registry {
+	defaultOnce.Do(func() {
+		defaultErr = defaultRegistry.reset()
+	})
+	return defaultRegistry
+}
+
+// SetDark sets whether the default registry resolves themes for a dark or
+// a light background. Called before the registry is first used, it keeps
+// the terminal from being queried for its colors at all.
+func SetDark(dark bool) {
+	defaultRegistry.SetDark(dark)
+}
+
+// reset reloads the embedded themes and activates the default theme
+func (r *Registry) reset() error {
+	themes, err := embeddedThemes()
+	if err != nil {
+		return fmt.Errorf("failed to load themes: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.themes = themes
+	if !r.darkSet {
+		r.dark = detectTerminalBackground()
+	}
+	return r.setLocked("dracula")
+}
+
+// Register adds a theme, replacing any theme with the same name
+func (r *Registry) Register(theme *Theme) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.themes == nil {
+		r.themes = make(map[string]*Theme)
+	}
+	r.themes[theme.Name] = theme
+}
+
+// LoadJSON registers a theme from a JSON file, named after the file unless
+// the theme sets a name
+func (r *Registry) LoadJSON(path string) error {
+	_, err := r.loadJSON(path)
+	return err
+}
+
+// loadJSON registers a theme from a JSON file and returns its name
+func (r *Registry) loadJSON(path string) (string, error) {
+	theme, err := ReadJSON(path)
+	if err != nil {
+		return "", err
+	}
+	r.mu.RLock()
+	err = theme.validate(r.themes)
+	r.mu.RUnlock()
+	if err != nil {
+		return "", fmt.Errorf("invalid theme %s: %w", theme.Name, err)
+	}
+
+	r.Register(theme)
+	return theme.Name, nil
+}
+
+// ReadJSON parses a theme file, naming the theme after the file unless it
+// sets a name
+func ReadJSON(path string) (*Theme, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read theme file: %w", err)
+	}
+
+	var theme Theme
+	if err := json.Unmarshal(data, &theme); err != nil {
+		return nil, fmt.Errorf("failed to parse theme JSON: %w", err)
+	}
+
+	if theme.Name == "" {
+		theme.Name = strings.TrimSuffix(filepath.Base(path), ".json")
+	}
+	return &theme, nil
+}
+
+// Set activates a theme by name, or loads and activates the theme file
+// when name is the path of a JSON file
+func (r *Registry) Set(name string) error {
+	if strings.HasSuffix(name, ".json") {
+		loaded, err := r.loadJSON(name)
+		if err != nil {
+			return err
+		}
+		name = loaded
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.setLocked(name)
+}
+
+// setLocked activates a theme; the caller must hold the write lock
+func (r *Registry) setLocked(name string) error {
+	theme, ok := r.themes[name]
+	if !ok {
+		return fmt.Errorf("theme %s not found", name)
+	}
+	colors, err := r.resolveLocked(theme)
+	if err != nil {
+		return err
+	}
+	r.current, r.name = colors, name
+	return nil
+}
+
+// resolveLocked resolves a theme with the overrides on top; the caller must
+// hold the lock
+func (r *Registry) resolveLocked(theme *Theme) (*ThemeColors, error) {
+	if len(r.overrides) > 0 {
+		overlay := *theme
+		overlay.Theme = make(map[string]map[string]string, len(theme.Theme)+len(r.overrides))
+		for key, variants := range theme.Theme {
+			overlay.Theme[key] = variants
+		}
+		for key, value := range r.overrides {
+			overlay.Theme[key] = map[string]string{"dark": value, "light": value}
+		}
+		for _, dark := range []bool{true, false} {
+			resolver := newResolver(&overlay, r.themes, dark, r.alpha)
+			for key := range r.overrides {
+				if _, err := resolver.key(key); err != nil {
+					return nil, fmt.Errorf("invalid theme override %s: %w", key, err)
+				}
+			}
+		}
+		theme = &overlay
+	}
+	return resolveTheme(theme, r.themes, r.dark, r.alpha), nil
+}
+
+// Current returns the active theme, or a basic default when none is set
+func (r *Registry) Current() *ThemeColors {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.current == nil {
+		return getDefaultTheme()
+	}
+	return r.current
+}
+
+// Resolve returns the colors of a theme without activating it
+func (r *Registry) Resolve(name string) (*ThemeColors, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	theme, ok := r.themes[name]
+	if !ok {
+		return nil, fmt.Errorf("theme %s not found", name)
+	}
+	return r.resolveLocked(theme)
+}
+
+// SetDiffBackgroundAlpha sets how much of the added and removed colors
+// tints the background for themes that don't define diffAddedBg or
+// diffRemovedBg. It applies to themes activated or resolved afterwards.
+func (r *Registry) SetDiffBackgroundAlpha(alpha float64) error {
+	if alpha <= 0 || alpha > 1 {
+		return fmt.Errorf("diff background alpha %g is not between 0 and 1", alpha)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.alpha = alpha
+	return nil
+}
+
+// UseTerminalColors queries the terminal for its colors with DetectTerminal,
+// for a viewer about to take it over, and resolves the active theme again
+// for them: for its dark or light background unless SetDark set it, and,
+// with autoAlpha, with the diff background tint AutoDiffBackgroundAlpha
+// picks for it
+func (r *Registry) UseTerminalColors(autoAlpha bool) error {
+	colors := DetectTerminal()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.darkSet {
+		r.dark = detectTerminalBackground()
+	}
+	if autoAlpha {
+		r.alpha = AutoDiffBackgroundAlpha(colors.Background)
+	}
+	if r.name == "" {
+		return nil
+	}
+	return r.setLocked(r.name)
+}
+
+// SetOverrides sets colors, by theme key, that replace those of whichever
+// theme is activated or resolved afterwards. Values are written as in theme
+// files and may refer to the theme's defs and other colors.
+func (r *Registry) SetOverrides(overrides map[string]string) error {
+	for key := range overrides {
+		if !isThemeKey(key) {
+			return fmt.Errorf("unknown theme key %s", key)
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.overrides = overrides
+	return nil
+}
+
+// SetDark sets whether themes are resolved for a dark or a light
+// background instead of detecting it from the terminal. It applies to
+// themes activated or resolved afterwards.
+func (r *Registry) SetDark(dark bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.dark, r.darkSet = dark, true
+}
+
+// List returns the names of all registered themes in alphabetical order
+func (r *Registry) List() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.themes))
+	for name := range r.themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
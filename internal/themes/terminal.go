@@ -0,0 +1,141 @@
+package themes
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/term"
+)
+
+// TerminalQueryTimeout is how long Terminal waits for the terminal to
+// report its colors before falling back to the environment
+const TerminalQueryTimeout = 200 * time.Millisecond
+
+// TerminalColors are the default foreground and background colors of the
+// terminal as hex colors. Either is empty when the terminal didn't report
+// it.
+type TerminalColors struct {
+	Foreground lipgloss.Color
+	Background lipgloss.Color
+}
+
+var (
+	terminalMu      sync.Mutex
+	terminalQueried bool
+	terminalColors  TerminalColors
+)
+
+// Terminal returns the colors the terminal reported to DetectTerminal. They
+// are empty before, and when the terminal didn't answer.
+func Terminal() TerminalColors {
+	terminalMu.Lock()
+	defer terminalMu.Unlock()
+	return terminalColors
+}
+
+// DetectTerminal queries the terminal for its colors once, when the output
+// goes to it, and returns them. Only a viewer taking over the terminal
+// should call it: the answers of a terminal shared with a pager, as in
+// git diff | differential | less, would end up in the pager's input.
+func DetectTerminal() TerminalColors {
+	terminalMu.Lock()
+	defer terminalMu.Unlock()
+	if !terminalQueried {
+		terminalQueried = true
+		if term.IsTerminal(os.Stdout.Fd()) {
+			terminalColors, _ = QueryTerminal(TerminalQueryTimeout)
+		}
+	}
+	return terminalColors
+}
+
+// QueryTerminal asks the controlling terminal for its colors with OSC 10
+// and 11, waiting at most timeout for the answers. A device attributes
+// query follows them, which every terminal answers, so that terminals
+// ignoring the color queries don't make it wait out the timeout.
+func QueryTerminal(timeout time.Duration) (TerminalColors, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return TerminalColors{}, err
+	}
+	defer tty.Close()
+	// Terminals answer the process in the foreground; another would be
+	// stopped for reading the terminal
+	if !isForeground(tty) {
+		return TerminalColors{}, fmt.Errorf("not in the foreground of the terminal")
+	}
+
+	state, err := term.MakeRaw(tty.Fd())
+	if err != nil {
+		return TerminalColors{}, err
+	}
+	defer term.Restore(tty.Fd(), state) //nolint:errcheck
+	if err := tty.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return TerminalColors{}, err
+	}
+
+	if _, err := tty.WriteString("\x1b]10;?\x1b\\\x1b]11;?\x1b\\\x1b[c"); err != nil {
+		return TerminalColors{}, err
+	}
+	var replies []byte
+	buf := make([]byte, 256)
+	for !deviceAttributes.Match(replies) {
+		n, err := tty.Read(buf)
+		replies = append(replies, buf[:n]...)
+		if err != nil {
+			return ParseTerminalColors(replies), fmt.Errorf("terminal didn't answer: %w", err)
+		}
+	}
+	return ParseTerminalColors(replies), nil
+}
+
+var (
+	// deviceAttributes matches the answer to the device attributes query
+	deviceAttributes = regexp.MustCompile(`\x1b\[\?[0-9;]*c`)
+	// colorReply matches the answer to an OSC 10 or 11 query, with one to
+	// four hex digits per channel, ended by BEL or ST
+	colorReply = regexp.MustCompile(`\x1b\](1[01]);rgba?:([0-9a-fA-F]{1,4})/([0-9a-fA-F]{1,4})/([0-9a-fA-F]{1,4})(?:/[0-9a-fA-F]{1,4})?(?:\x07|\x1b\\)`)
+)
+
+// ParseTerminalColors reads the terminal's answers to OSC 10 and 11
+// queries in data, e.g. "\x1b]11;rgb:2828/2a2a/3636\x1b\\"
+func ParseTerminalColors(data []byte) TerminalColors {
+	var colors TerminalColors
+	for _, match := range colorReply.FindAllSubmatch(data, -1) {
+		var hex bytes.Buffer
+		hex.WriteByte('#')
+		for _, channel := range match[2:5] {
+			// Channels are scaled to their number of digits, so "f" and
+			// "ffff" are both full
+			value, _ := strconv.ParseUint(string(channel), 16, 16)
+			full := uint64(1)<<(4*len(channel)) - 1
+			fmt.Fprintf(&hex, "%02x", (value*255+full/2)/full)
+		}
+		if string(match[1]) == "10" {
+			colors.Foreground = lipgloss.Color(hex.String())
+		} else {
+			colors.Background = lipgloss.Color(hex.String())
+		}
+	}
+	return colors
+}
+
+// AutoDiffBackgroundAlpha returns the tint of derived diff backgrounds
+// that keeps them readable on a terminal background: light backgrounds
+// wash tints out, so they take more of the diff colors than dark ones. It
+// returns DefaultDiffBackgroundAlpha for a background that isn't a hex
+// color, such as an unknown one.
+func AutoDiffBackgroundAlpha(background lipgloss.Color) float64 {
+	r, g, b, err := rgb(background)
+	if err != nil {
+		return DefaultDiffBackgroundAlpha
+	}
+	luminance := (0.299*r + 0.587*g + 0.114*b) / 255
+	return DefaultDiffBackgroundAlpha + 0.1*luminance
+}
@@ -0,0 +1,11 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris
+
+package themes
+
+import "os"
+
+// isForeground reports whether the process is in the foreground of the
+// terminal tty, which is only known on Unix
+func isForeground(tty *os.File) bool {
+	return false
+}
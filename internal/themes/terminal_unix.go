@@ -0,0 +1,16 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+
+package themes
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// isForeground reports whether the process is in the foreground of the
+// terminal tty
+func isForeground(tty *os.File) bool {
+	pgrp, err := unix.IoctlGetInt(int(tty.Fd()), unix.TIOCGPGRP)
+	return err == nil && pgrp == unix.Getpgrp()
+}
@@ -1,8 +1,6 @@
 package themes
 
 import (
-	"encoding/json"
-	"fmt"
 	"os"
 	"strings"
 
@@ -14,6 +12,10 @@ type Theme struct {
 	Name  string                       `json:"name"`
 	Defs  map[string]string           `json:"defs"`
 	Theme map[string]map[string]string `json:"theme"`
+
+	// Extends names the theme this one only changes some colors of; the
+	// colors it leaves out are taken from that theme as it resolves them
+	Extends string `json:"extends,omitempty"`
 }
 
 // ThemeColors contains resolved color values for rendering
@@ -35,6 +37,10 @@ type ThemeColors struct {
 	DiffLineNumber      lipgloss.Color
 	DiffAddedLineNumberBg   lipgloss.Color
 	DiffRemovedLineNumberBg lipgloss.Color
+	DiffMovedFrom           lipgloss.Color // Removed lines of a block added elsewhere
+	DiffMovedTo             lipgloss.Color // Added lines of a block removed elsewhere
+	DiffMovedFromBg         lipgloss.Color
+	DiffMovedToBg           lipgloss.Color
 
 	// Syntax colors
 	SyntaxKeyword     lipgloss.Color
@@ -51,87 +57,58 @@ type ThemeColors struct {
 	Background      lipgloss.Color
 	BackgroundPanel lipgloss.Color
 	Border          lipgloss.Color
-	Selection       lipgloss.Color
+	Selection       lipgloss.Color // Background of search matches and selected lines
+	Cursor          lipgloss.Color // Background of the TUI's cursor line
 }
 
-var (
-	currentTheme     *ThemeColors
-	availableThemes  map[string]*Theme
-	terminalIsDark   = true
-)
-
-// Initialize sets up the theme system
+// Initialize loads the embedded themes into the default registry and
+// activates the default theme
 func Initialize() error {
-	availableThemes = make(map[string]*Theme)
-	
-	// Detect terminal background
-	detectTerminalBackground()
-	
-	// Load embedded themes
-	if err := loadEmbeddedThemes(); err != nil {
-		return fmt.Errorf("failed to load themes: %w", err)
-	}
-	
-	// Set default theme
-	if err := SetTheme("dracula"); err != nil {
-		return err
+	loaded := false
+	defaultOnce.Do(func() {
+		defaultErr = defaultRegistry.reset()
+		loaded = true
+	})
+	if loaded {
+		return defaultErr
 	}
-	
-	return nil
+	return defaultRegistry.reset()
 }
 
-// SetTheme activates a theme by name
+// SetTheme activates a theme by name in the default registry
+//
+// Deprecated: the active theme is shared by everything rendering in the
+// process. Use a Registry, or Registry.Resolve to get a theme's colors
+// without activating it.
 func SetTheme(name string) error {
-	theme, ok := availableThemes[name]
-	if !ok {
-		return fmt.Errorf("theme %s not found", name)
-	}
-	
-	currentTheme = resolveTheme(theme)
-	return nil
+	return Default().Set(name)
 }
 
 // GetCurrentTheme returns the current active theme
 func GetCurrentTheme() *ThemeColors {
-	if currentTheme == nil {
-		// Return a default theme if not initialized
-		return getDefaultTheme()
-	}
-	return currentTheme
+	return Default().Current()
 }
 
 // ListThemes returns all available theme names
 func ListThemes() []string {
-	themes := make([]string, 0, len(availableThemes))
-	for name := range availableThemes {
-		themes = append(themes, name)
-	}
-	return themes
+	return Default().List()
 }
 
-// resolveTheme converts a Theme definition to resolved ThemeColors
-func resolveTheme(theme *Theme) *ThemeColors {
+// resolveTheme converts a Theme definition to resolved ThemeColors for a
+// dark or light terminal, deriving missing diff backgrounds with alpha and
+// taking other missing colors from the themes it inherits from
+func resolveTheme(theme *Theme, themes map[string]*Theme, dark bool, alpha float64) *ThemeColors {
 	tc := &ThemeColors{}
 	
-	// Helper to resolve color references
+	// Helper to resolve color references and expressions
+	r := newResolver(theme, themes, dark, alpha)
 	resolveColor := func(key string) lipgloss.Color {
-		variant := "dark"
-		if !terminalIsDark {
-			variant = "light"
+		color, err := r.key(key)
+		if err != nil {
+			// Default color
+			return lipgloss.Color("#ffffff")
 		}
-		
-		if colorMap, ok := theme.Theme[key]; ok {
-			if color, ok := colorMap[variant]; ok {
-				// Check if it's a reference to a defined color
-				if definedColor, ok := theme.Defs[color]; ok {
-					return lipgloss.Color(definedColor)
-				}
-				return lipgloss.Color(color)
-			}
-		}
-		
-		// Default color
-		return lipgloss.Color("#ffffff")
+		return color
 	}
 	
 	// Resolve all colors
@@ -150,6 +127,10 @@ func resolveTheme(theme *Theme) *ThemeColors {
 	tc.DiffLineNumber = resolveColor("diffLineNumber")
 	tc.DiffAddedLineNumberBg = resolveColor("diffAddedLineNumberBg")
 	tc.DiffRemovedLineNumberBg = resolveColor("diffRemovedLineNumberBg")
+	tc.DiffMovedFrom = resolveColor("diffMovedFrom")
+	tc.DiffMovedTo = resolveColor("diffMovedTo")
+	tc.DiffMovedFromBg = resolveColor("diffMovedFromBg")
+	tc.DiffMovedToBg = resolveColor("diffMovedToBg")
 	
 	tc.SyntaxKeyword = resolveColor("syntaxKeyword")
 	tc.SyntaxFunction = resolveColor("syntaxFunction")
@@ -165,6 +146,7 @@ func resolveTheme(theme *Theme) *ThemeColors {
 	tc.BackgroundPanel = resolveColor("backgroundPanel")
 	tc.Border = resolveColor("border")
 	tc.Selection = resolveColor("selection")
+	tc.Cursor = resolveColor("cursor")
 	
 	return tc
 }
@@ -186,6 +168,10 @@ func getDefaultTheme() *ThemeColors {
 		DiffLineNumber:          lipgloss.Color("#666666"),
 		DiffAddedLineNumberBg:   lipgloss.Color("#002200"),
 		DiffRemovedLineNumberBg: lipgloss.Color("#220000"),
+		DiffMovedFrom:           lipgloss.Color("#ff00ff"),
+		DiffMovedTo:             lipgloss.Color("#00ffff"),
+		DiffMovedFromBg:         lipgloss.Color("#110011"),
+		DiffMovedToBg:           lipgloss.Color("#001111"),
 		SyntaxKeyword:           lipgloss.Color("#ff79c6"),
 		SyntaxFunction:          lipgloss.Color("#50fa7b"),
 		SyntaxType:              lipgloss.Color("#8be9fd"),
@@ -199,11 +185,20 @@ func getDefaultTheme() *ThemeColors {
 		BackgroundPanel:         lipgloss.Color("#44475a"),
 		Border:                  lipgloss.Color("#6272a4"),
 		Selection:               lipgloss.Color("#44475a"),
+		Cursor:                  lipgloss.Color("#44475a"),
 	}
 }
 
-// detectTerminalBackground attempts to detect if the terminal has a dark background
-func detectTerminalBackground() {
+// detectTerminalBackground reports whether the terminal likely has a dark
+// background, from the colors it reported to DetectTerminal or else from
+// the environment
+func detectTerminalBackground() bool {
+	if colors := Terminal(); colors.Background != "" {
+		return isDarkColor(colors.Background)
+	} else if colors.Foreground != "" {
+		return !isDarkColor(colors.Foreground)
+	}
+
 	// Check environment variables
 	colorScheme := os.Getenv("COLORFGBG")
 	if colorScheme != "" {
@@ -211,8 +206,7 @@ func detectTerminalBackground() {
 		if len(parts) >= 2 {
 			// If background is greater than 7, it's likely light
 			if parts[1] > "7" {
-				terminalIsDark = false
-				return
+				return false
 			}
 		}
 	}
@@ -220,33 +214,16 @@ func detectTerminalBackground() {
 	// Check terminal name
 	term := os.Getenv("TERM")
 	if strings.Contains(term, "light") {
-		terminalIsDark = false
-		return
+		return false
 	}
 	
 	// Default to dark
-	terminalIsDark = true
+	return true
 }
 
-// LoadThemeFromJSON loads a theme from a JSON file
+// LoadThemeFromJSON loads a theme from a JSON file into the default registry
+//
+// Deprecated: use Registry.LoadJSON.
 func LoadThemeFromJSON(path string) error {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return fmt.Errorf("failed to read theme file: %w", err)
-	}
-	
-	var theme Theme
-	if err := json.Unmarshal(data, &theme); err != nil {
-		return fmt.Errorf("failed to parse theme JSON: %w", err)
-	}
-	
-	if theme.Name == "" {
-		// Extract name from filename
-		parts := strings.Split(path, "/")
-		filename := parts[len(parts)-1]
-		theme.Name = strings.TrimSuffix(filename, ".json")
-	}
-	
-	availableThemes[theme.Name] = &theme
-	return nil
-}
\ No newline at end of file
+	return Default().LoadJSON(path)
+}
@@ -0,0 +1,109 @@
+package themes
+
+import (
+	"fmt"
+	"sort"
+)
+
+// BaseTheme is the theme other themes take the colors they leave out from
+const BaseTheme = "dracula"
+
+// themeKeys are the keys of the colors of a theme, in the order
+// ThemeColors lists them
+var themeKeys = []string{
+	"text", "textMuted", "error",
+	"diffAdded", "diffRemoved", "diffContext", "diffAddedBg", "diffRemovedBg", "diffContextBg",
+	"diffHighlightAdded", "diffHighlightRemoved", "diffLineNumber",
+	"diffAddedLineNumberBg", "diffRemovedLineNumberBg",
+	"diffMovedFrom", "diffMovedTo", "diffMovedFromBg", "diffMovedToBg",
+	"syntaxKeyword", "syntaxFunction", "syntaxType", "syntaxVariable", "syntaxString",
+	"syntaxNumber", "syntaxComment", "syntaxOperator", "syntaxPunctuation",
+	"background", "backgroundPanel", "border", "selection", "cursor",
+}
+
+// isThemeKey reports whether key is the key of a color of a theme
+func isThemeKey(key string) bool {
+	for _, k := range themeKeys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// ThemeReport lists what a theme file leaves out or gets wrong
+type ThemeReport struct {
+	// Base names the theme the colors left out are taken from, if any
+	Base string
+	// Missing lists the colors the theme leaves to its base, by key,
+	// followed by the variant when it only leaves out one. Colors derived
+	// from others, like diffAddedBg, aren't missing unless the theme
+	// extends another.
+	Missing []string
+	// Unknown lists the keys and variants that aren't colors of a theme,
+	// such as misspelled ones, which are ignored
+	Unknown []string
+	// Errors lists the colors that don't resolve
+	Errors []string
+}
+
+// OK reports whether the theme has no unknown keys and no errors; missing
+// colors are inherited
+func (r ThemeReport) OK() bool {
+	return len(r.Unknown) == 0 && len(r.Errors) == 0
+}
+
+// Check reports the colors a theme leaves out, its unknown keys, and the
+// colors that don't resolve when the missing ones come from the theme it
+// extends or BaseTheme, as registered
+func (r *Registry) Check(theme *Theme) ThemeReport {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	report := ThemeReport{Base: theme.Extends}
+	if report.Base == "" && r.themes[BaseTheme] != nil && r.themes[BaseTheme] != theme {
+		report.Base = BaseTheme
+	}
+	for _, key := range themeKeys {
+		if derivedColors[key] != "" && theme.Extends == "" {
+			continue
+		}
+		_, dark := theme.Theme[key]["dark"]
+		_, light := theme.Theme[key]["light"]
+		switch {
+		case !dark && !light:
+			report.Missing = append(report.Missing, key)
+		case !dark:
+			report.Missing = append(report.Missing, key+" (dark)")
+		case !light:
+			report.Missing = append(report.Missing, key+" (light)")
+		}
+	}
+
+	for key, variants := range theme.Theme {
+		if !isThemeKey(key) {
+			report.Unknown = append(report.Unknown, key)
+			continue
+		}
+		for variant := range variants {
+			if variant != "dark" && variant != "light" {
+				report.Unknown = append(report.Unknown, key+"."+variant)
+			}
+		}
+	}
+	sort.Strings(report.Unknown)
+
+	for _, dark := range []bool{true, false} {
+		resolver := newResolver(theme, r.themes, dark, 0)
+		if _, err := resolver.base(); err != nil {
+			report.Errors = append(report.Errors, err.Error())
+			break
+		}
+		for _, key := range themeKeys {
+			if _, err := resolver.key(key); err != nil {
+				report.Errors = append(report.Errors, fmt.Sprintf("%s (%s): %v", key, resolver.variant, err))
+			}
+		}
+	}
+	return report
+}
@@ -0,0 +1,61 @@
+package app_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// initRepo creates a repository with one commit of notes.txt and makes it
+// the working directory for the rest of the test
+func initRepo(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("first note\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "."},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "init"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v\n%s", args[0], err, output)
+		}
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+func TestBrowseReturnsToDiff(t *testing.T) {
+	initRepo(t)
+	update, view := driveViewer(t, tuiDiff, 100, 20)
+	press(update, "jj")
+	before := view()
+
+	update(tea.KeyMsg{Type: tea.KeyCtrlO})
+	press(update, "notes")
+	update(tea.KeyMsg{Type: tea.KeyEnter})
+	if out := view(); !strings.Contains(out, "first note") || strings.Contains(out, "alpha") {
+		t.Fatalf("expected the browsed file, got:\n%s", out)
+	}
+
+	update(tea.KeyMsg{Type: tea.KeyEsc})
+	if out := view(); out != before {
+		t.Errorf("expected esc to return to the diff where it was left:\n%s\ngot:\n%s", before, out)
+	}
+}
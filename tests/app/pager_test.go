@@ -0,0 +1,94 @@
+package app_test
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/avgvstvs96/differential/internal/app"
+	"github.com/avgvstvs96/differential/internal/config"
+	"github.com/avgvstvs96/differential/internal/diff"
+	"github.com/avgvstvs96/differential/internal/themes"
+)
+
+const logCommit1 = "\x1b[33mcommit 1111111111111111111111111111111111111111\x1b[m\n" +
+	"Author: A U Thor <author@example.com>\n" +
+	"Date:   Mon Jan 1 00:00:00 2024 +0000\n\n" +
+	"    Bump alpha\n\n" +
+	"diff --git a/a.go b/a.go\n" +
+	"index 1111111..2222222 100644\n" +
+	"--- a/a.go\n" +
+	"+++ b/a.go\n" +
+	"@@ -1,2 +1,2 @@\n" +
+	" package a\n" +
+	"\x1b[31m-var alpha = 1\x1b[m\n" +
+	"\x1b[32m+var alpha = 2\x1b[m\n"
+
+const logCommit2 = "commit 2222222222222222222222222222222222222222\n" +
+	"Merge: 1111111 3333333\n" +
+	"Author: A U Thor <author@example.com>\n" +
+	"Date:   Tue Jan 2 00:00:00 2024 +0000\n\n" +
+	"    Merge branch 'topic'\n"
+
+// syncWriter records what is written to it, signalling each write
+type syncWriter struct {
+	writes chan string
+}
+
+func (w syncWriter) Write(p []byte) (int, error) {
+	w.writes <- string(p)
+	return len(p), nil
+}
+
+func TestRenderStream(t *testing.T) {
+	if err := themes.Initialize(); err != nil {
+		t.Fatalf("failed to initialize themes: %v", err)
+	}
+	cfg := config.NewConfig()
+	cfg.Deterministic = true
+
+	input, feed := io.Pipe()
+	out := syncWriter{writes: make(chan string, 4)}
+	done := make(chan error)
+	go func() { done <- app.RenderStream(context.Background(), input, out, cfg) }()
+
+	// A commit is rendered as soon as the next one starts, before the
+	// input ends
+	commitLine := strings.Index(logCommit2, "\n") + 1
+	io.WriteString(feed, logCommit1+logCommit2[:commitLine])
+	select {
+	case first := <-out.writes:
+		plain := diff.StripANSI(first)
+		if !strings.Contains(plain, "Bump alpha") || !strings.Contains(plain, "var alpha = 2") || strings.Contains(plain, "+++ b/a.go") {
+			t.Errorf("expected the first commit rendered, got:\n%s", plain)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("expected the first commit before the input ended")
+	}
+
+	// A commit without a diff passes through as git wrote it
+	io.WriteString(feed, logCommit2[commitLine:])
+	feed.Close()
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if second := <-out.writes; second != logCommit2 {
+		t.Errorf("expected the merge commit unchanged, got:\n%q", second)
+	}
+}
+
+func TestRenderStreamPassthrough(t *testing.T) {
+	if err := themes.Initialize(); err != nil {
+		t.Fatalf("failed to initialize themes: %v", err)
+	}
+	branches := "* \x1b[32mmain\x1b[m\n  topic\n"
+	var sb strings.Builder
+	if err := app.RenderStream(context.Background(), strings.NewReader(branches), &sb, config.NewConfig()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sb.String() != branches {
+		t.Errorf("expected git branch output unchanged, got %q", sb.String())
+	}
+}
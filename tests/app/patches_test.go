@@ -0,0 +1,80 @@
+package app_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/avgvstvs96/differential/internal/app"
+	"github.com/avgvstvs96/differential/internal/config"
+	"github.com/avgvstvs96/differential/internal/diff"
+)
+
+func TestPatchFiles(t *testing.T) {
+	dir := t.TempDir()
+	series := filepath.Join(dir, "series")
+	if err := os.Mkdir(series, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"series/0002-b.patch", "series/0001-a.patch", "series/notes.txt", "fix.diff", "a.go"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	paths, ok := app.PatchFiles([]string{series, filepath.Join(dir, "fix.diff")})
+	want := []string{
+		filepath.Join(series, "0001-a.patch"),
+		filepath.Join(series, "0002-b.patch"),
+		filepath.Join(dir, "fix.diff"),
+	}
+	if !ok || !reflect.DeepEqual(paths, want) {
+		t.Errorf("expected %v, got %v (ok %v)", want, paths, ok)
+	}
+
+	// Any other argument makes them files to compare or git diff arguments
+	for _, args := range [][]string{nil, {filepath.Join(dir, "fix.diff"), filepath.Join(dir, "a.go")}, {dir}, {"missing.patch"}} {
+		if _, ok := app.PatchFiles(args); ok {
+			t.Errorf("expected %v not to be patch files", args)
+		}
+	}
+}
+
+func TestSeriesLimits(t *testing.T) {
+	logCommit3 := "commit 3333333333333333333333333333333333333333\n" +
+		"Author: A U Thor <author@example.com>\n" +
+		"Date:   Wed Jan 3 00:00:00 2024 +0000\n\n" +
+		"    Bump beta\n\n" +
+		"diff --git a/b.go b/b.go\n" +
+		"index 1111111..2222222 100644\n" +
+		"--- a/b.go\n" +
+		"+++ b/b.go\n" +
+		"@@ -1,2 +1,2 @@\n" +
+		" package b\n" +
+		"-var beta = 1\n" +
+		"+var beta = 2\n"
+
+	// --max-files counts the files of the whole series, and the commits
+	// past it go with their files
+	cfg := config.NewConfig()
+	cfg.Deterministic = true
+	cfg.Limits.MaxFiles = 1
+	cfg.Output.Path = filepath.Join(t.TempDir(), "out.txt")
+	if err := app.RunPipeMode(context.Background(), strings.NewReader(logCommit1+logCommit3), cfg, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(cfg.Output.Path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	output := diff.StripANSI(string(data))
+	if !strings.Contains(output, "Bump alpha") || !strings.Contains(output, "output truncated (max files): 1 file(s)") {
+		t.Errorf("expected the first commit and a footer, got:\n%s", output)
+	}
+	if strings.Contains(output, "Bump beta") || strings.Contains(output, "var beta") {
+		t.Errorf("expected the second commit left out, got:\n%s", output)
+	}
+}
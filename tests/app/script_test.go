@@ -0,0 +1,128 @@
+package app_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/avgvstvs96/differential/internal/app"
+	"github.com/avgvstvs96/differential/internal/config"
+	"github.com/avgvstvs96/differential/internal/diff"
+	"github.com/avgvstvs96/differential/internal/themes"
+)
+
+// runScript runs script on a viewer of diffText and returns its report
+func runScript(t *testing.T, diffText, script string) (string, error) {
+	t.Helper()
+	if err := themes.Initialize(); err != nil {
+		t.Fatalf("failed to initialize themes: %v", err)
+	}
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	cfg := config.NewConfig()
+	cfg.Deterministic = true
+	m, err := app.NewModel(context.Background(), diffText, cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var sb strings.Builder
+	err = m.ExecScript(script, &sb)
+	return sb.String(), err
+}
+
+func TestExecScript(t *testing.T) {
+	report, err := runScript(t, tuiDiff, "next-hunk; next-line; next-hunk\nnext-hunk;prev-file;next-file;next-file;comment looks fine;collapse;toggle-view;quit;next-line")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `next-hunk: a.go:+1
+next-line: a.go:-2
+next-hunk: b.go:+1
+next-hunk: no next hunk
+prev-file: a.go:+1
+next-file: b.go:+1
+next-file: no next file
+comment: b.go:+1
+collapse: collapsed b.go
+toggle-view: side-by-side
+quit
+differential: files=2 additions=2 deletions=1 binary=0
+`
+	if report != want {
+		t.Errorf("unexpected report:\n%s\nwant:\n%s", report, want)
+	}
+}
+
+func TestExecScriptErrors(t *testing.T) {
+	if _, err := runScript(t, tuiDiff, "next-hunk;jump"); err == nil || !strings.Contains(err.Error(), `unknown script action "jump"`) {
+		t.Errorf("expected an unknown action error, got %v", err)
+	}
+	if _, err := runScript(t, tuiDiff, "comment"); err == nil {
+		t.Error("expected an error for a comment without text")
+	}
+
+	// A diff given as text isn't the worktree's to stage
+	report, err := runScript(t, tuiDiff, "next-hunk;stage;next-line")
+	if err == nil || !strings.Contains(err.Error(), "script step 2 (stage)") {
+		t.Errorf("expected staging to fail, got %v", err)
+	}
+	if report != "next-hunk: a.go:+1\n" {
+		t.Errorf("expected the steps before the failure reported, got %q", report)
+	}
+}
+
+func TestExecScriptContext(t *testing.T) {
+	var oldText, newText strings.Builder
+	for i := 1; i <= 20; i++ {
+		fmt.Fprintf(&oldText, "line %d\n", i)
+		if i == 10 {
+			newText.WriteString("ten\n")
+		} else {
+			fmt.Fprintf(&newText, "line %d\n", i)
+		}
+	}
+	// The lines around the change are read from the new version on disk
+	path := filepath.Join(t.TempDir(), "f.txt")
+	if err := os.WriteFile(path, []byte(newText.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	diffText := diff.UnifiedDiff("a/"+path, "b/"+path, oldText.String(), newText.String(), 3)
+
+	report, err := runScript(t, diffText, "more-context;more-context;less-context;bottom;prev-line")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := fmt.Sprintf(`more-context: 6 lines of context in %[1]s
+more-context: 9 lines of context in %[1]s
+less-context: 6 lines of context in %[1]s
+bottom: row 16
+prev-line: %[1]s:+16
+`, path)
+	if !strings.HasPrefix(report, want) {
+		t.Errorf("unexpected report:\n%s\nwant:\n%s", report, want)
+	}
+}
+
+func TestExecScriptKeepsPosition(t *testing.T) {
+	// The cursor stays on a.go's added line as the layout changes, so the
+	// next line is the one after it in either view
+	report, err := runScript(t, tuiDiff, "next-hunk;next-line;next-line;toggle-view;next-line;prev-line;full-file;next-line")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `next-hunk: a.go:+1
+next-line: a.go:-2
+next-line: a.go:+2
+toggle-view: side-by-side
+next-line: a.go:+3
+prev-line: a.go:+2
+full-file: full file
+next-line: a.go:+3
+`
+	if !strings.HasPrefix(report, want) {
+		t.Errorf("unexpected report:\n%s\nwant:\n%s", report, want)
+	}
+}
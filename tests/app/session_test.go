@@ -0,0 +1,58 @@
+package app_test
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/avgvstvs96/differential/internal/app"
+	"github.com/avgvstvs96/differential/internal/config"
+	"github.com/avgvstvs96/differential/internal/state"
+	"github.com/avgvstvs96/differential/internal/themes"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestSessionStagedMarks(t *testing.T) {
+	if err := themes.Initialize(); err != nil {
+		t.Fatalf("failed to initialize themes: %v", err)
+	}
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	path := filepath.Join(t.TempDir(), "review.dsp")
+	session := &state.Session{
+		Diff:   tuiDiff,
+		Staged: []state.StagedHunk{{File: "b.go", Header: "@@ -1,2 +1,3 @@"}},
+	}
+	if err := session.Save(path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	m, err := app.SessionModel(context.Background(), path, config.NewConfig())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var model tea.Model = m
+	update := func(msg tea.Msg) tea.Cmd {
+		var cmd tea.Cmd
+		model, cmd = model.Update(msg)
+		return cmd
+	}
+	view := func() string { return escapes.ReplaceAllString(model.View(), "") }
+	update(tea.WindowSizeMsg{Width: 100, Height: 30})
+
+	// The restored mark shows on b.go's hunk only
+	if got := strings.Count(view(), "· staged"); got != 1 || !strings.Contains(view(), "1 staged") {
+		t.Fatalf("expected b.go's hunk marked as staged, got:\n%s", view())
+	}
+
+	// s marks the hunk under the cursor in a diff that can't be staged,
+	// and unmarks it again
+	press(update, "jjs")
+	if !strings.Contains(view(), "2 staged") {
+		t.Fatalf("expected a.go's hunk marked too, got:\n%s", view())
+	}
+	press(update, "s")
+	if !strings.Contains(view(), "1 staged") {
+		t.Errorf("expected a.go's hunk unmarked, got:\n%s", view())
+	}
+}
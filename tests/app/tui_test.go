@@ -0,0 +1,389 @@
+package app_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/avgvstvs96/differential/internal/app"
+	"github.com/avgvstvs96/differential/internal/config"
+	"github.com/avgvstvs96/differential/internal/themes"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/exp/teatest"
+)
+
+const tuiDiff = `diff --git a/a.go b/a.go
+index 1111111..2222222 100644
+--- a/a.go
++++ b/a.go
+@@ -1,3 +1,3 @@
+ package a
+-var alpha = 1
++var alpha = 2
+ var bravo = 3
+diff --git a/b.go b/b.go
+index 3333333..4444444 100644
+--- a/b.go
++++ b/b.go
+@@ -1,2 +1,3 @@
+ package b
++var charlie = 4
+ var delta = 5
+`
+
+// escapes matches the control sequences of rendered frames
+var escapes = regexp.MustCompile(`\x1b\[[0-9;?]*[a-zA-Z]|\x1b\][^\x07]*\x07`)
+
+// startViewer runs the viewer on diffText in a 100x20 terminal
+func startViewer(t *testing.T, diffText string) *teatest.TestModel {
+	t.Helper()
+	if err := themes.Initialize(); err != nil {
+		t.Fatalf("failed to initialize themes: %v", err)
+	}
+	if err := themes.Default().Set("dracula"); err != nil {
+		t.Fatalf("failed to set theme: %v", err)
+	}
+	// Keep comments written by the tests out of the user's store
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	cfg := config.NewConfig()
+	m, err := app.NewModel(context.Background(), diffText, cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return teatest.NewTestModel(t, m, teatest.WithInitialTermSize(100, 20))
+}
+
+// waitFor waits until a frame shows all of texts
+func waitFor(t *testing.T, tm *teatest.TestModel, texts ...string) {
+	t.Helper()
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		frame := escapes.ReplaceAllString(string(bts), "")
+		for _, text := range texts {
+			if !strings.Contains(frame, text) {
+				return false
+			}
+		}
+		return true
+	}, teatest.WithDuration(3*time.Second))
+}
+
+// keys sends key presses, one per rune or named key
+func keys(tm *teatest.TestModel, names ...string) {
+	for _, name := range names {
+		switch name {
+		case "enter":
+			tm.Send(tea.KeyMsg{Type: tea.KeyEnter})
+		case "esc":
+			tm.Send(tea.KeyMsg{Type: tea.KeyEsc})
+		case "tab":
+			tm.Send(tea.KeyMsg{Type: tea.KeyTab})
+		default:
+			tm.Type(name)
+		}
+	}
+}
+
+// finalScreen quits the viewer and returns its last screen without colors
+func finalScreen(t *testing.T, tm *teatest.TestModel) string {
+	t.Helper()
+	keys(tm, "q")
+	final := tm.FinalModel(t, teatest.WithFinalTimeout(3*time.Second))
+	return escapes.ReplaceAllString(final.View(), "")
+}
+
+func TestTUIStatusBar(t *testing.T) {
+	tm := startViewer(t, tuiDiff)
+	waitFor(t, tm, "2 files", "+2 -1", "Size S", "Unified")
+
+	keys(tm, "j", "j")
+	waitFor(t, tm, "Line -1 +1")
+
+	keys(tm, "tab")
+	waitFor(t, tm, "Side-by-Side")
+
+	if screen := finalScreen(t, tm); !strings.Contains(screen, "var charlie = 4") {
+		t.Errorf("expected both files on screen, got:\n%s", screen)
+	}
+}
+
+func TestTUICollapseFile(t *testing.T) {
+	tm := startViewer(t, tuiDiff)
+	waitFor(t, tm, "var alpha = 2")
+
+	keys(tm, "j", "j", "z")
+	screen := finalScreen(t, tm)
+	if !strings.Contains(screen, "▸ a.go (+1 -1)") {
+		t.Errorf("expected a.go collapsed, got:\n%s", screen)
+	}
+	if strings.Contains(screen, "var alpha") || !strings.Contains(screen, "var charlie") {
+		t.Errorf("expected only b.go's lines, got:\n%s", screen)
+	}
+}
+
+func TestTUIComments(t *testing.T) {
+	tm := startViewer(t, tuiDiff)
+	waitFor(t, tm, "var alpha = 2")
+
+	// The added line of a.go
+	keys(tm, "j", "j", "j", "j", "c")
+	waitFor(t, tm, "Comment on a.go:+2")
+	keys(tm, "why two?")
+	waitFor(t, tm, "why two?█")
+	keys(tm, "enter")
+	waitFor(t, tm, "💬 why two?")
+
+	keys(tm, "C")
+	waitFor(t, tm, "1 comment", "a.go:+2  why two?")
+
+	// Deleting it empties the list
+	keys(tm, "d")
+	screen := finalScreen(t, tm)
+	if !strings.Contains(screen, "0 comments") {
+		t.Errorf("expected the comment deleted, got:\n%s", screen)
+	}
+}
+
+func TestTUIPatterns(t *testing.T) {
+	diffText := tuiDiff + `diff --git a/c.go b/c.go
+index 5555555..6666666 100644
+--- a/c.go
++++ b/c.go
+@@ -1,3 +1,3 @@
+ package c
+-var alpha = 1
++var alpha = 2
+ var echo = 6
+`
+	tm := startViewer(t, diffText)
+	waitFor(t, tm, "3 files")
+
+	keys(tm, "P")
+	waitFor(t, tm, "2 changes in 3 hunks", "2× 2 files")
+
+	// Approving the repeated change folds both of its hunks
+	keys(tm, " ", "esc")
+	waitFor(t, tm, "2 approved")
+	finalScreen(t, tm)
+}
+
+func TestTUIJumps(t *testing.T) {
+	tm := startViewer(t, tuiDiff)
+	waitFor(t, tm, "var alpha = 2")
+
+	// b.go's second line is the added one, a.go's the removed one
+	keys(tm, "]", "j")
+	waitFor(t, tm, "Line +2")
+	keys(tm, "{", "{", "j")
+	screen := finalScreen(t, tm)
+	if !strings.Contains(screen, "Line -2") {
+		t.Errorf("expected the cursor back in a.go, got:\n%s", screen)
+	}
+}
+
+func TestTUIFileTree(t *testing.T) {
+	tm := startViewer(t, tuiDiff)
+	waitFor(t, tm, "var alpha = 2")
+
+	keys(tm, "t")
+	waitFor(t, tm, "M a.go", "M b.go", "│")
+
+	// Selecting b.go in the tree moves the diff there
+	keys(tm, "tab", "j", "enter", "j", "j", "j", "j")
+	screen := finalScreen(t, tm)
+	if !strings.Contains(screen, "Line -2 +3") {
+		t.Errorf("expected the cursor in b.go, got:\n%s", screen)
+	}
+}
+
+func TestTUISummary(t *testing.T) {
+	tm := startViewer(t, tuiDiff)
+	waitFor(t, tm, "var alpha = 2")
+
+	keys(tm, "S")
+	waitFor(t, tm, "2 files changed +2 -1", "By language", "Largest files", "Hunk sizes · 2 hunks")
+
+	keys(tm, "esc")
+	waitFor(t, tm, "var charlie = 4")
+	keys(tm, "q")
+	tm.WaitFinished(t, teatest.WithFinalTimeout(3*time.Second))
+}
+
+// longDiff adds a file of 40 numbered lines
+func longDiff() string {
+	var sb strings.Builder
+	sb.WriteString("diff --git a/long.txt b/long.txt\n--- a/long.txt\n+++ b/long.txt\n@@ -0,0 +1,40 @@\n")
+	for i := 1; i <= 40; i++ {
+		fmt.Fprintf(&sb, "+line %d\n", i)
+	}
+	return sb.String()
+}
+
+// driveViewer returns the viewer on diffText in a width by height window,
+// for tests that run its commands themselves: update passes it a message
+// and returns the command it asked for, and view renders it without colors
+func driveViewer(t *testing.T, diffText string, width, height int) (update func(tea.Msg) tea.Cmd, view func() string) {
+	t.Helper()
+	if err := themes.Initialize(); err != nil {
+		t.Fatalf("failed to initialize themes: %v", err)
+	}
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	m, err := app.NewModel(context.Background(), diffText, config.NewConfig())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var model tea.Model = m
+	update = func(msg tea.Msg) tea.Cmd {
+		var cmd tea.Cmd
+		model, cmd = model.Update(msg)
+		return cmd
+	}
+	view = func() string { return escapes.ReplaceAllString(model.View(), "") }
+	update(tea.WindowSizeMsg{Width: width, Height: height})
+	return update, view
+}
+
+// press passes key presses to update, one per rune
+func press(update func(tea.Msg) tea.Cmd, runes string) {
+	for _, r := range runes {
+		update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+}
+
+func TestTUIResize(t *testing.T) {
+	update, view := driveViewer(t, longDiff(), 100, 20)
+	press(update, strings.Repeat("j", 30))
+
+	// Only the last of a burst of sizes is laid out, with the cursor kept
+	// on its line and in view. The view leaves the window's last row free.
+	first := update(tea.WindowSizeMsg{Width: 60, Height: 30})
+	last := update(tea.WindowSizeMsg{Width: 80, Height: 10})
+	update(first())
+	if rows := strings.Count(view(), "\n") + 1; rows != 20-1 {
+		t.Errorf("expected the layout to wait for the last size, got %d rows", rows)
+	}
+	update(last())
+
+	screen := view()
+	rows := strings.Split(screen, "\n")
+	if len(rows) != 10-1 {
+		t.Errorf("expected 9 rows, got %d:\n%s", len(rows), screen)
+	}
+	for _, row := range rows {
+		if width := lipgloss.Width(row); width > 80 {
+			t.Errorf("expected rows of at most 80 columns, got %d: %q", width, row)
+		}
+	}
+	if !strings.Contains(screen, "line 30") || !strings.Contains(screen, "Line +30") {
+		t.Errorf("expected the cursor on line 30, got:\n%s", screen)
+	}
+}
+
+func TestGeneratedFilesCollapse(t *testing.T) {
+	text := tuiDiff + `diff --git a/go.sum b/go.sum
+index 5555555..6666666 100644
+--- a/go.sum
++++ b/go.sum
+@@ -1 +1 @@
+-example.com/mod v1.0.0 h1:old=
++example.com/mod v1.0.1 h1:new=
+`
+	// The viewer collapses generated files
+	_, view := driveViewer(t, text, 100, 30)
+	if screen := view(); !strings.Contains(screen, "▸ go.sum (+1 -1) · generated") || strings.Contains(screen, "h1:new=") {
+		t.Errorf("expected go.sum collapsed in the viewer, got:\n%s", screen)
+	}
+
+	// Output elsewhere shows them whole
+	cfg := config.NewConfig()
+	cfg.Deterministic = true
+	cfg.Output.Path = filepath.Join(t.TempDir(), "out.txt")
+	if err := app.RunPipeMode(context.Background(), strings.NewReader(text), cfg, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	output, err := os.ReadFile(cfg.Output.Path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(string(output), "h1:new=") {
+		t.Errorf("expected go.sum shown whole in pipe output, got:\n%s", output)
+	}
+}
+
+func TestTUIActionError(t *testing.T) {
+	// More context needs the file's blobs, which this diff's index line
+	// doesn't name. The failure shows in the status bar over the diff
+	// until the next key.
+	update, view := driveViewer(t, tuiDiff, 100, 20)
+	press(update, "+")
+	screen := view()
+	if !strings.Contains(screen, "Error: ") || !strings.Contains(screen, "var charlie = 4") {
+		t.Errorf("expected the error in the status bar below the diff, got:\n%s", screen)
+	}
+	press(update, "j")
+	if screen := view(); strings.Contains(screen, "Error: ") {
+		t.Errorf("expected the next key to clear the error, got:\n%s", screen)
+	}
+}
+
+func TestTUIBottom(t *testing.T) {
+	// G shows the end of the output filling the window, as does going
+	// down past it
+	update, view := driveViewer(t, longDiff(), 100, 20)
+	press(update, "G")
+	screen := view()
+	if !strings.Contains(screen, "line 24") || !strings.Contains(screen, "line 40") || strings.Contains(screen, "line 23") {
+		t.Errorf("expected lines 24 to 40, got:\n%s", screen)
+	}
+	press(update, "g"+strings.Repeat("j", 60))
+	if after := view(); after != screen {
+		t.Errorf("expected going down past the end to stop there, got:\n%s", after)
+	}
+
+	// Selecting the last file in the tree doesn't scroll past the end
+	update, view = driveViewer(t, tuiDiff, 100, 10)
+	press(update, "t")
+	update(tea.KeyMsg{Type: tea.KeyTab})
+	press(update, "j")
+	update(tea.KeyMsg{Type: tea.KeyEnter})
+	if screen := view(); !strings.Contains(screen, "var bravo = 3") || !strings.Contains(screen, "var delta = 5") {
+		t.Errorf("expected the window filled up to b.go's end, got:\n%s", screen)
+	}
+}
+
+func TestTUILoading(t *testing.T) {
+	if err := themes.Initialize(); err != nil {
+		t.Fatalf("failed to initialize themes: %v", err)
+	}
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	// The spinner shows until the diff is made, then the diff does
+	release := make(chan struct{})
+	m := app.LoadModel(context.Background(), func() (string, error) {
+		<-release
+		return tuiDiff, nil
+	}, config.NewConfig())
+	tm := teatest.NewTestModel(t, m, teatest.WithInitialTermSize(100, 20))
+	waitFor(t, tm, "Running git diff…")
+	close(release)
+	waitFor(t, tm, "var alpha = 2", "2 files")
+
+	if screen := finalScreen(t, tm); strings.Contains(screen, "…") {
+		t.Errorf("expected the spinner gone, got:\n%s", screen)
+	}
+
+	// A failed load quits the viewer
+	m = app.LoadModel(context.Background(), func() (string, error) {
+		return "", errors.New("not a git repository")
+	}, config.NewConfig())
+	tm = teatest.NewTestModel(t, m, teatest.WithInitialTermSize(100, 20))
+	tm.FinalModel(t, teatest.WithFinalTimeout(3*time.Second))
+}
@@ -0,0 +1,75 @@
+package churn_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/avgvstvs96/differential/internal/churn"
+	"github.com/avgvstvs96/differential/internal/git"
+)
+
+func day(n int) time.Time {
+	return time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, n)
+}
+
+var numStats = []git.NumStat{
+	{Commit: "c3", Time: day(9), Path: "internal/app/app.go", Additions: 10, Deletions: 2},
+	{Commit: "c3", Time: day(9), Path: "internal/app/stat.go", Additions: 5},
+	{Commit: "c2", Time: day(5), Path: "internal/app/app.go", Additions: 1, Deletions: 1},
+	{Commit: "c1", Time: day(0), Path: "README.md", Additions: 3},
+	{Commit: "c1", Time: day(0), Path: "logo.png", Binary: true},
+}
+
+func TestAggregateByFile(t *testing.T) {
+	table := churn.Aggregate(numStats, churn.Options{Buckets: 2})
+
+	if !table.Start.Equal(day(0)) || !table.End.Equal(day(9)) {
+		t.Errorf("unexpected span %v - %v", table.Start, table.End)
+	}
+	if len(table.Rows) != 4 {
+		t.Fatalf("expected 4 rows, got %d", len(table.Rows))
+	}
+
+	top := table.Rows[0]
+	if top.Name != "internal/app/app.go" || top.Commits != 2 || top.Churn() != 14 {
+		t.Errorf("unexpected top row %+v", top)
+	}
+	// Day 5 falls in the second half of the range along with day 9
+	if top.Buckets[0] != 0 || top.Buckets[1] != 14 {
+		t.Errorf("unexpected buckets %v", top.Buckets)
+	}
+	if table.Max != 14 {
+		t.Errorf("expected max 14, got %d", table.Max)
+	}
+	if last := table.Rows[3]; last.Name != "logo.png" || last.Churn() != 0 {
+		t.Errorf("expected binary file last, got %+v", last)
+	}
+}
+
+func TestAggregateByDir(t *testing.T) {
+	table := churn.Aggregate(numStats, churn.Options{ByDir: true, Depth: 1, Buckets: 1, Top: 1})
+
+	if len(table.Rows) != 1 {
+		t.Fatalf("expected 1 row, got %d", len(table.Rows))
+	}
+	row := table.Rows[0]
+	// Both files of c3 count as a single commit for the directory
+	if row.Name != "internal/" || row.Commits != 2 || row.Churn() != 19 {
+		t.Errorf("unexpected row %+v", row)
+	}
+}
+
+func TestAggregateLongRange(t *testing.T) {
+	stats := []git.NumStat{
+		{Commit: "c1", Time: day(0), Path: "main.go", Additions: 1},
+		{Commit: "c2", Time: day(0).AddDate(4, 0, 0), Path: "main.go", Additions: 2},
+		{Commit: "c3", Time: day(0).AddDate(10, 0, 0), Path: "main.go", Additions: 4},
+	}
+	table := churn.Aggregate(stats, churn.Options{Buckets: 40})
+
+	row := table.Rows[0]
+	// Ten years times 40 buckets overflows a Duration
+	if row.Buckets[0] != 1 || row.Buckets[16] != 2 || row.Buckets[39] != 4 {
+		t.Errorf("unexpected buckets %v", row.Buckets)
+	}
+}
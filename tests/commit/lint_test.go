@@ -0,0 +1,76 @@
+package commit_test
+
+import (
+	"testing"
+
+	"github.com/avgvstvs96/differential/internal/commit"
+)
+
+const showOutput = `commit 2fdb70d46c21fb3ca81d23d369ecbdbcc94a8574
+Author: Jane Doe <jane@example.com>
+Date:   Thu Oct 15 15:06:18 2026 +0000
+
+    Add a very long subject line that goes on and on well past the limit.
+    Body starts without a blank line
+
+    Signed-off-by: Jane Doe <jane@example.com>
+    Reviewed-by Someone
+
+diff --git a/f.txt b/f.txt
+`
+
+func TestParse(t *testing.T) {
+	commits := commit.Parse(showOutput)
+	if len(commits) != 1 {
+		t.Fatalf("expected 1 commit, got %d", len(commits))
+	}
+	c := commits[0]
+	if c.ShortHash() != "2fdb70d" || c.AuthorEmail != "jane@example.com" {
+		t.Errorf("unexpected commit %+v", c)
+	}
+	if c.Subject() != "Add a very long subject line that goes on and on well past the limit." {
+		t.Errorf("unexpected subject %q", c.Subject())
+	}
+}
+
+func TestSplit(t *testing.T) {
+	logOutput := "warning: ignored\n" + showOutput + "@@ -1 +1 @@\n-a\n+b\n" +
+		"commit 0123456789abcdef0123456789abcdef01234567\nAuthor: A <a@example.com>\n\n    Second\n"
+
+	chunks := commit.Split(logOutput)
+	if len(chunks) != 2 {
+		t.Fatalf("expected 2 chunks, got %d: %q", len(chunks), chunks)
+	}
+	if chunks[0] != showOutput+"@@ -1 +1 @@\n-a\n+b\n" {
+		t.Errorf("unexpected first chunk %q", chunks[0])
+	}
+	if commits := commit.Parse(chunks[1]); len(commits) != 1 || commits[0].Subject() != "Second" {
+		t.Errorf("unexpected second chunk %q", chunks[1])
+	}
+}
+
+func TestLint(t *testing.T) {
+	c := commit.Parse(showOutput)[0]
+	findings := commit.Lint(c, commit.LintOptions{SubjectLength: 50, BodyWidth: 72, RequireSignoff: true})
+
+	rules := make(map[string]bool)
+	for _, f := range findings {
+		rules[f.Rule] = true
+	}
+	for _, rule := range []string{"subject-length", "subject-period", "blank-line", "trailer-format"} {
+		if !rules[rule] {
+			t.Errorf("expected a %s finding, got %+v", rule, findings)
+		}
+	}
+	if rules["signoff"] {
+		t.Errorf("sign-off matches the author and should pass, got %+v", findings)
+	}
+}
+
+func TestLint_MissingSignoff(t *testing.T) {
+	c := &commit.Commit{AuthorEmail: "jane@example.com", Message: "Fix parser\n\nSigned-off-by: Bob <bob@example.com>"}
+	findings := commit.Lint(c, commit.LintOptions{RequireSignoff: true})
+	if len(findings) != 1 || findings[0].Rule != "signoff" {
+		t.Errorf("expected a single signoff finding, got %+v", findings)
+	}
+}
@@ -0,0 +1,113 @@
+package commit_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/avgvstvs96/differential/internal/commit"
+)
+
+// formatPatch is git format-patch output. Its signature separators, "-- ",
+// get their trailing space back below, as editors strip it.
+var formatPatch = strings.ReplaceAll(`From 03d1ccfcaea3ea72e0647da6364db0cdd0bdf382 Mon Sep 17 00:00:00 2001
+From: =?UTF-8?q?Jos=C3=A9=20Doe?= <jose@example.com>
+Date: Thu, 15 Oct 2026 18:18:28 +0000
+Subject: [PATCH 1/2] Bump x to a rounder number so the
+ tests pass
+
+Ten is rounder than one.
+---
+ a.go | 2 +-
+ 1 file changed, 1 insertion(+), 1 deletion(-)
+
+diff --git a/a.go b/a.go
+index 3ee3116..5a4d6d2 100644
+--- a/a.go
++++ b/a.go
+@@ -1,2 +1,2 @@
+ package a
+-var x = 1
++var x = 10
+--
+2.39.5
+
+From d49aa0f0000000000000000000000000000000ff Mon Sep 17 00:00:00 2001
+From: Jane Doe <jane@example.com>
+Date: Thu, 15 Oct 2026 18:20:00 +0000
+Subject: [PATCH 2/2] Add b
+
+diff --git a/b.go b/b.go
+new file mode 100644
+--- /dev/null
++++ b/b.go
+@@ -0,0 +1 @@
++package b
+--
+2.39.5
+`, "\n--\n", "\n-- \n")
+
+func TestParsePatches(t *testing.T) {
+	patches := commit.ParsePatches(formatPatch)
+	if len(patches) != 2 {
+		t.Fatalf("expected 2 patches, got %d", len(patches))
+	}
+
+	first := patches[0].Commit
+	if first == nil {
+		t.Fatal("expected the first patch's commit")
+	}
+	if first.ShortHash() != "03d1ccf" || first.Author != "José Doe" || first.AuthorEmail != "jose@example.com" {
+		t.Errorf("unexpected commit %+v", first)
+	}
+	if first.Date != "Thu, 15 Oct 2026 18:18:28 +0000" {
+		t.Errorf("unexpected date %q", first.Date)
+	}
+	if want := "Bump x to a rounder number so the tests pass\n\nTen is rounder than one."; first.Message != want {
+		t.Errorf("expected message %q, got %q", want, first.Message)
+	}
+	if !strings.HasPrefix(patches[0].Diff, "diff --git a/a.go b/a.go\n") || !strings.HasSuffix(patches[0].Diff, "+var x = 10\n") {
+		t.Errorf("expected the diff without diffstat or signature, got:\n%s", patches[0].Diff)
+	}
+
+	if second := patches[1].Commit; second == nil || second.Message != "Add b" {
+		t.Errorf("unexpected second commit %+v", second)
+	}
+	if !strings.HasSuffix(patches[1].Diff, "+package b\n") {
+		t.Errorf("expected the signature dropped, got:\n%s", patches[1].Diff)
+	}
+}
+
+func TestParsePatchesPlainDiff(t *testing.T) {
+	plain := "--- a/a.go\n+++ b/a.go\n@@ -1 +1 @@\n-a\n+b\n"
+	patches := commit.ParsePatches(plain)
+	if len(patches) != 1 || patches[0].Commit != nil || patches[0].Diff != plain {
+		t.Errorf("expected the diff as a patch without commit, got %+v", patches)
+	}
+}
+
+func TestSplitCommits(t *testing.T) {
+	logOutput := showOutput + "@@ -1 +1 @@\n-a\n+b\n" +
+		"commit 0123456789abcdef0123456789abcdef01234567\nAuthor: A <a@example.com>\nDate:   Wed Oct 14 09:00:00 2026 +0000\n\n    Second\n\n" +
+		"diff --git a/g.txt b/g.txt\n--- a/g.txt\n+++ b/g.txt\n@@ -1 +1 @@\n-c\n+d\n"
+
+	patches := commit.SplitCommits(logOutput)
+	if len(patches) != 2 {
+		t.Fatalf("expected 2 patches, got %d", len(patches))
+	}
+	if patches[0].Commit.ShortHash() != "2fdb70d" || patches[0].Commit.Date != "Thu Oct 15 15:06:18 2026 +0000" {
+		t.Errorf("unexpected first commit %+v", patches[0].Commit)
+	}
+	if !strings.HasPrefix(patches[0].Diff, "diff --git a/f.txt b/f.txt\n") {
+		t.Errorf("expected the diff without the message, got:\n%s", patches[0].Diff)
+	}
+	if patches[1].Commit.Subject() != "Second" || !strings.HasSuffix(patches[1].Diff, "+d\n") {
+		t.Errorf("unexpected second patch %+v", patches[1])
+	}
+
+	if patches := commit.SplitCommits(formatPatch); len(patches) != 2 {
+		t.Errorf("expected format-patch output split too, got %d patches", len(patches))
+	}
+	if patches := commit.SplitCommits("--- a/a\n+++ b/a\n"); len(patches) != 0 {
+		t.Errorf("expected no patches for a plain diff, got %d", len(patches))
+	}
+}
@@ -0,0 +1,97 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/avgvstvs96/differential/internal/config"
+	"github.com/pelletier/go-toml/v2"
+)
+
+func TestDefaultFile(t *testing.T) {
+	cfg := &config.Config{}
+	if err := toml.Unmarshal([]byte(config.DefaultFile), cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := config.NewConfig(); !reflect.DeepEqual(cfg, want) {
+		t.Errorf("the default file doesn't hold the defaults:\ngot  %+v\nwant %+v", cfg, want)
+	}
+}
+
+func TestGet(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Filters.Exclude = []string{"*.lock", `say "hi"`}
+	for key, want := range map[string]string{
+		"ui.theme":                 `"dracula"`,
+		"ui.diff_background_alpha": "0",
+		"git.default_context":      "3",
+		"lint.commits":             "false",
+		"filters.include":          "[]",
+		"filters.exclude":          `["*.lock", "say \"hi\""]`,
+	} {
+		if got, err := cfg.Get(key); err != nil || got != want {
+			t.Errorf("Get(%q) = %s, %v, want %s", key, got, err, want)
+		}
+	}
+	if _, err := cfg.Get("ui.nope"); err == nil {
+		t.Error("expected an error for an unknown setting")
+	}
+}
+
+func TestSetInFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "differential", "config.toml")
+	if err := config.WriteDefault(path, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := config.WriteDefault(path, false); err == nil {
+		t.Error("expected an error overwriting the file without force")
+	}
+
+	for _, set := range [][2]string{
+		{"ui.theme", "nord"},
+		{"git.extra_args", "--find-copies-harder, -M"},
+		{"editor.command", "code -g {file}:{line}"},
+	} {
+		if err := config.SetInFile(path, set[0], set[1]); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := config.SetInFile(path, "git.default_context", "many"); err == nil {
+		t.Error("expected an error for a value of the wrong type")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := string(data)
+	for _, want := range []string{
+		"# Run `differential --list-themes` for the available themes\ntheme = \"nord\"\n",
+		"# extra_args = [\"--find-renames=40%\"]\nextra_args = [\"--find-copies-harder\", \"-M\"]\n\n[keybindings]",
+		"[editor]\n# e.g. \"code -g {file}:{line}\"; empty runs $VISUAL or $EDITOR with +{line}\ncommand = \"code -g {file}:{line}\"\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected the file to contain %q, got:\n%s", want, got)
+		}
+	}
+	if strings.Count(got, "\n") != strings.Count(config.DefaultFile, "\n")+1 {
+		t.Errorf("expected one line added, got:\n%s", got)
+	}
+}
+
+func TestSetInFile_NewSection(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte("[ui]\ntheme = \"nord\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.SetInFile(path, "gutter.mode", "old"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if want := "[ui]\ntheme = \"nord\"\n\n[gutter]\nmode = \"old\"\n"; string(data) != want {
+		t.Errorf("got %q, want %q", data, want)
+	}
+}
@@ -0,0 +1,103 @@
+package config_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/avgvstvs96/differential/internal/config"
+)
+
+func TestRepoSettings(t *testing.T) {
+	settings, ignored, err := config.RepoSettings([]byte(`
+[ui]
+theme = "nord"
+tab_width = 2
+bogus = 1
+
+[git]
+default_context = 5
+extra_args = ["--ext-diff"]
+
+[filters]
+exclude = ["*.pb.go"]
+
+[editor]
+command = "rm -rf {file}"
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]any{
+		"ui":      map[string]any{"theme": "nord", "tab_width": int64(2)},
+		"git":     map[string]any{"default_context": int64(5)},
+		"filters": map[string]any{"exclude": []any{"*.pb.go"}},
+	}
+	if !reflect.DeepEqual(settings, want) {
+		t.Errorf("got %#v, want %#v", settings, want)
+	}
+	if want := []string{"editor.command", "git.extra_args", "ui.bogus"}; !reflect.DeepEqual(ignored, want) {
+		t.Errorf("ignored %v, want %v", ignored, want)
+	}
+}
+
+func TestRepoSettings_Invalid(t *testing.T) {
+	if _, _, err := config.RepoSettings([]byte("[ui]\ntab_width = \"wide\"\n")); err == nil {
+		t.Error("expected an error for a setting of the wrong type")
+	}
+}
+
+func TestParseLanguages(t *testing.T) {
+	data := []byte(`
+[ui]
+theme = "nord"
+
+[languages]
+"*.tpl" = "html"
+"Jenkinsfile" = "groovy"
+`)
+	languages, err := config.ParseLanguages(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := map[string]string{"*.tpl": "html", "Jenkinsfile": "groovy"}; !reflect.DeepEqual(languages, want) {
+		t.Errorf("got %v, want %v", languages, want)
+	}
+
+	// Repositories can set languages too, without a warning
+	settings, ignored, err := config.RepoSettings(data)
+	if err != nil || len(ignored) != 0 || settings["ui"] == nil {
+		t.Errorf("unexpected settings %v, ignored %v, error %v", settings, ignored, err)
+	}
+
+	if _, err := config.ParseLanguages([]byte("[languages]\n\"*.tpl\" = 1\n")); err == nil {
+		t.Error("expected an error for a language that isn't a string")
+	}
+}
+
+func TestParseThemeOverrides(t *testing.T) {
+	data := []byte(`
+[ui]
+theme = "nord"
+
+[theme.overrides]
+diffAddedBg = "#123123"
+cursor = "mix(diffAdded, background, 0.3)"
+`)
+	overrides, err := config.ParseThemeOverrides(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := map[string]string{"diffAddedBg": "#123123", "cursor": "mix(diffAdded, background, 0.3)"}; !reflect.DeepEqual(overrides, want) {
+		t.Errorf("got %v, want %v", overrides, want)
+	}
+
+	// Repositories can set overrides too, without a warning
+	if _, ignored, err := config.RepoSettings(data); err != nil || len(ignored) != 0 {
+		t.Errorf("unexpected ignored %v, error %v", ignored, err)
+	}
+
+	if _, err := config.ParseThemeOverrides([]byte("[theme.overrides]\ncursor = 1\n")); err == nil {
+		t.Error("expected an error for a color that isn't a string")
+	}
+}
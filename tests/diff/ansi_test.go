@@ -0,0 +1,90 @@
+package diff_test
+
+import (
+	"testing"
+
+	"github.com/avgvstvs96/differential/internal/diff"
+)
+
+const coloredText = "\x1b[31mhé\x1b[0m llo"
+
+func TestANSIString(t *testing.T) {
+	s := diff.ParseANSI(coloredText)
+
+	if s.String() != coloredText {
+		t.Errorf("String() = %q, want the input", s.String())
+	}
+	if s.Plain() != "hé llo" {
+		t.Errorf("Plain() = %q, want %q", s.Plain(), "hé llo")
+	}
+	if s.Width() != 6 {
+		t.Errorf("Width() = %d, want 6", s.Width())
+	}
+	if got := s.Truncate(2); got != "\x1b[31mhé\x1b[0m" {
+		t.Errorf("Truncate(2) = %q", got)
+	}
+	if got := s.Truncate(0); got != "" {
+		t.Errorf("Truncate(0) = %q, want empty", got)
+	}
+}
+
+func TestANSIIterator(t *testing.T) {
+	var tokens []diff.ANSIToken
+	it := diff.ParseANSI(coloredText).Iter()
+	for it.Next() {
+		tokens = append(tokens, it.Token())
+	}
+
+	expected := []diff.ANSIToken{
+		{Text: "\x1b[31m", Escape: true, Pos: 0},
+		{Text: "h", Pos: 0},
+		{Text: "é", Pos: 1},
+		{Text: "\x1b[0m", Escape: true, Pos: 2},
+		{Text: " ", Pos: 2},
+		{Text: "l", Pos: 3},
+		{Text: "l", Pos: 4},
+		{Text: "o", Pos: 5},
+	}
+	if len(tokens) != len(expected) {
+		t.Fatalf("expected %d tokens, got %d: %+v", len(expected), len(tokens), tokens)
+	}
+	for i := range expected {
+		if tokens[i] != expected[i] {
+			t.Errorf("token %d: expected %+v, got %+v", i, expected[i], tokens[i])
+		}
+	}
+}
+
+func TestApplyHighlightingRestoresColor(t *testing.T) {
+	segments := []diff.Segment{{Start: 1, End: 2, Type: diff.LineAdded}}
+	got := diff.ApplyHighlighting("\x1b[32mabc", segments, diff.LineAdded, "<hl>")
+	want := "\x1b[32ma<hl>b\x1b[0m\x1b[32mc"
+	if got != want {
+		t.Errorf("ApplyHighlighting = %q, want %q", got, want)
+	}
+}
+
+func TestStripDiffColors(t *testing.T) {
+	colored := "\x1b[1mdiff --git a/a.go b/a.go\x1b[m\n\x1b[1m--- a/a.go\x1b[m\n\x1b[1m+++ b/a.go\x1b[m\n" +
+		"\x1b[36m@@ -1 +1 @@\x1b[m\n\x1b[31m-old\x1b[m\n\x1b[32m+new\x1b[m\n"
+	text, ok := diff.StripDiffColors(colored)
+	if !ok {
+		t.Fatal("expected the colored diff to be detected")
+	}
+	files, err := diff.ParseMultiFileDiff(text)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(files) != 1 || len(files[0].Hunks) != 1 || len(files[0].Hunks[0].Lines) != 2 {
+		t.Fatalf("unexpected parse of stripped diff: %+v", files)
+	}
+	if line := files[0].Hunks[0].Lines[1]; line.Kind != diff.LineAdded || line.Content != "new" {
+		t.Errorf("added line = %+v", line)
+	}
+
+	// Escape sequences inside lines belong to the file
+	plain := "--- a/log.txt\n+++ b/log.txt\n@@ -1 +1 @@\n-\x1b[31mred\n+\x1b[32mgreen\n"
+	if text, ok := diff.StripDiffColors(plain); ok || text != plain {
+		t.Errorf("StripDiffColors changed an uncolored diff: %q, %v", text, ok)
+	}
+}
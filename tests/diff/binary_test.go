@@ -0,0 +1,112 @@
+package diff_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/avgvstvs96/differential/internal/diff"
+)
+
+func TestRenderBinaryChange(t *testing.T) {
+	input := `diff --git a/data.bin b/data.bin
+old mode 100644
+new mode 100755
+index 1111111..2222222
+Binary files a/data.bin and b/data.bin differ`
+	result, err := diff.ParseUnifiedDiff(input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.OldMode != "100644" || result.NewMode != "100755" {
+		t.Errorf("expected modes from the mode lines, got %q and %q", result.OldMode, result.NewMode)
+	}
+
+	before := []byte("0123456789abcdef" + "unchanged row..." + "ABCDEFGHIJKLMNOP" + "\x00\x01")
+	after := []byte("0123456789abcdeF" + "unchanged row..." + "ABCDEFGHIJKLMNOP" + "\x00\x02\x03")
+	blobs := map[string][]byte{"1111111": before, "2222222": after}
+	load := func(path, id string) ([]byte, error) { return blobs[id], nil }
+
+	output := diff.StripANSI(diff.NewRenderer(diff.RenderOptions{Width: 100, LoadBlob: load, BinaryBytes: 64}).RenderUnified(result))
+	for _, want := range []string{
+		"Binary file changed: data.bin",
+		"before: 50 B, mode 100644",
+		"after:  51 B, mode 100755 (+1 B)",
+		"- 00000000  30 31 32 33 34 35 36 37  38 39 61 62 63 64 65 66  |0123456789abcdef|",
+		"+ 00000000  30 31 32 33 34 35 36 37  38 39 61 62 63 64 65 46  |0123456789abcdeF|",
+		"  …",
+		"+ 00000030  00 02 03",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q in:\n%s", want, output)
+		}
+	}
+	// Rows without differences are left out
+	if strings.Contains(output, "unchanged row") || strings.Contains(output, "ABCDEF") {
+		t.Errorf("expected only differing rows, got:\n%s", output)
+	}
+
+	// The limit counts differing bytes, so the first row is enough for one
+	output = diff.StripANSI(diff.NewRenderer(diff.RenderOptions{Width: 100, LoadBlob: load, BinaryBytes: 1}).RenderUnified(result))
+	if strings.Contains(output, "00000030") || !strings.Contains(output, "00000000") {
+		t.Errorf("expected only the first differing row, got:\n%s", output)
+	}
+	output = diff.StripANSI(diff.NewRenderer(diff.RenderOptions{Width: 100, LoadBlob: load}).RenderUnified(result))
+	if strings.Contains(output, "00000000") || !strings.Contains(output, "mode 100755") {
+		t.Errorf("expected sizes and modes without a hex dump, got:\n%s", output)
+	}
+
+	// Only the start of each version is read when a prefix loader is set
+	var limits []int
+	prefix := func(path, id string, n int) ([]byte, int64, error) {
+		limits = append(limits, n)
+		return blobs[id][:min(len(blobs[id]), 20)], int64(len(blobs[id])), nil
+	}
+	output = diff.StripANSI(diff.NewRenderer(diff.RenderOptions{Width: 100, LoadBlobPrefix: prefix, LoadBlob: load, BinaryBytes: 64}).RenderUnified(result))
+	if len(limits) != 2 || !strings.Contains(output, "after:  51 B, mode 100755 (+1 B)") || strings.Contains(output, "00000030") {
+		t.Errorf("expected the full sizes and a dump of the prefixes, read %v:\n%s", limits, output)
+	}
+
+	// Without a loader the files are only said to differ
+	output = diff.StripANSI(diff.NewRenderer(diff.RenderOptions{Width: 100}).RenderUnified(result))
+	if !strings.Contains(output, "Binary files data.bin and data.bin differ") {
+		t.Errorf("expected the plain summary, got:\n%s", output)
+	}
+
+	// Versions the loader can't read are unavailable, not dumped
+	failing := func(path, id string) ([]byte, error) {
+		if id == "2222222" {
+			return nil, errors.New("missing")
+		}
+		return blobs[id], nil
+	}
+	output = diff.StripANSI(diff.NewRenderer(diff.RenderOptions{Width: 100, LoadBlob: failing, BinaryBytes: 64}).RenderUnified(result))
+	if !strings.Contains(output, "before: 50 B") || !strings.Contains(output, "after:  unavailable") || strings.Contains(output, "00000000") {
+		t.Errorf("expected the new version to be unavailable, got:\n%s", output)
+	}
+}
+
+func TestRenderBinaryAdded(t *testing.T) {
+	input := `diff --git a/data.bin b/data.bin
+new file mode 100644
+index 0000000..2222222
+Binary files /dev/null and b/data.bin differ`
+	result, err := diff.ParseUnifiedDiff(input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.NewMode != "100644" || result.Stat().Status != diff.StatusAdded {
+		t.Errorf("expected an added file with mode 100644, got %q %q", result.Stat().Status, result.NewMode)
+	}
+	load := func(path, id string) ([]byte, error) { return []byte("\x7fELF"), nil }
+
+	output := diff.StripANSI(diff.NewRenderer(diff.RenderOptions{Width: 100, LoadBlob: load, BinaryBytes: 64}).RenderUnified(result))
+	for _, want := range []string{"Binary file added: data.bin", "before: none", "after:  4 B, mode 100644", "+ 00000000  7f 45 4c 46"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q in:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "- 00000000") {
+		t.Errorf("expected no removed rows for an added file, got:\n%s", output)
+	}
+}
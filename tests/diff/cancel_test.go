@@ -0,0 +1,37 @@
+package diff_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/avgvstvs96/differential/internal/diff"
+)
+
+func TestCancelledParse(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := diff.ParseMultiFileDiffContext(ctx, largeDiff(100)); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestCancelledRender(t *testing.T) {
+	files, err := diff.ParseMultiFileDiff(largeHunk(2000))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	renderer := diff.NewRenderer(diff.RenderOptions{})
+	if out, err := renderer.RenderFilesContext(ctx, files); !errors.Is(err, context.Canceled) || out != "" {
+		t.Errorf("expected no output and context.Canceled, got %d bytes and %v", len(out), err)
+	}
+
+	// The interrupted render isn't cached in place of the full one
+	want := diff.NewRenderer(diff.RenderOptions{}).RenderFiles(files)
+	if got := renderer.RenderFiles(files); got != want {
+		t.Errorf("expected a full render after cancelling, got %d bytes, want %d", len(got), len(want))
+	}
+}
@@ -0,0 +1,68 @@
+package diff_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/avgvstvs96/differential/internal/diff"
+)
+
+func TestClassifyChange(t *testing.T) {
+	for _, tt := range []struct {
+		old, new string
+		want     diff.ChangeKind
+	}{
+		{"if x {", "\tif x {", diff.ChangeWhitespace},
+		{"a+b", "a + b  ", diff.ChangeWhitespace},
+		{"SELECT 1", "select  1", diff.ChangeCase},
+		{"x := 1", "x := 2", diff.ChangeSubstantive},
+	} {
+		if got := diff.ClassifyChange(tt.old, tt.new); got != tt.want {
+			t.Errorf("ClassifyChange(%q, %q) = %s, want %s", tt.old, tt.new, got, tt.want)
+		}
+	}
+}
+
+const reindentDiff = `--- a/a.go
++++ b/a.go
+@@ -1,4 +1,4 @@
+ func a() {
+-y()
+-Z := 1
++  y()
++  z := 1
+ }
+`
+
+func TestRenderWhitespaceChanges(t *testing.T) {
+	result, err := diff.ParseUnifiedDiff(reindentDiff)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	render := func(mode diff.WhitespaceMode) []string {
+		out := diff.NewRenderer(diff.RenderOptions{Whitespace: mode, TabWidth: 4}).Render(result)
+		var lines []string
+		for _, line := range strings.Split(diff.StripANSI(out), "\n") {
+			lines = append(lines, strings.Join(strings.Fields(line), " "))
+		}
+		return lines
+	}
+
+	// The reindented call becomes context, leaving the renamed variable
+	hidden := render(diff.WhitespaceHide)
+	want := []string{"@@ -1,4 +1,4 @@", "func a() {", "y()", "-Z := 1", "+ z := 1", "}"}
+	if strings.Join(hidden[:len(want)], "\n") != strings.Join(want, "\n") {
+		t.Errorf("got:\n%s\nwant:\n%s", strings.Join(hidden, "\n"), strings.Join(want, "\n"))
+	}
+
+	badged := strings.Join(render(diff.WhitespaceBadge), "\n")
+	if !strings.Contains(badged, "+ y() ␣ whitespace only") || strings.Count(badged, "whitespace only") != 1 {
+		t.Errorf("expected a badge on the reindented call only, got:\n%s", badged)
+	}
+
+	// The parsed hunk, which staging uses, is left alone
+	if result.Hunks[0].Lines[1].Kind != diff.LineRemoved {
+		t.Error("expected the hunk's lines to be left alone")
+	}
+}
@@ -0,0 +1,72 @@
+package diff_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/avgvstvs96/differential/internal/diff"
+)
+
+// longContextDiff changes the first and last line of a Go file with 30
+// unchanged lines between them, including a function declaration
+func longContextDiff() string {
+	var sb strings.Builder
+	sb.WriteString("--- a/main.go\n+++ b/main.go\n@@ -1,32 +1,32 @@\n-package old\n+package main\n")
+	for i := 2; i <= 31; i++ {
+		if i == 10 {
+			sb.WriteString(" func run() {\n")
+			continue
+		}
+		fmt.Fprintf(&sb, " \tx%d := %d\n", i, i)
+	}
+	sb.WriteString("-\treturn\n+\treturn nil\n")
+	return sb.String()
+}
+
+func TestFoldContext(t *testing.T) {
+	result, err := diff.ParseUnifiedDiff(longContextDiff())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, viewMode := range []diff.ViewMode{diff.ViewUnified, diff.ViewSideBySide} {
+		opts := diff.RenderOptions{FoldContext: 20, ViewMode: viewMode, Width: 120}
+		out := diff.NewRenderer(opts).RenderFiles([]*diff.DiffResult{result})
+		if !strings.Contains(out, "⋯ 24 unchanged lines · func run() {") {
+			t.Errorf("expected a divider naming the function, got:\n%s", out)
+		}
+		if strings.Contains(out, "x15") || !strings.Contains(out, "x4") || !strings.Contains(out, "x29") {
+			t.Errorf("expected three lines kept around the changes, got:\n%s", out)
+		}
+
+		// The divider row maps to the first folded line; side by side, the
+		// changed first line takes one row
+		row := 6
+		if viewMode == diff.ViewSideBySide {
+			row = 5
+		}
+		renderer := diff.NewRenderer(opts)
+		_, oldLine, newLine, ok := renderer.LineAt([]*diff.DiffResult{result}, row)
+		if !ok || oldLine != 5 || newLine != 5 {
+			t.Errorf("LineAt(divider) = %d, %d, %v; want 5, 5, true", oldLine, newLine, ok)
+		}
+	}
+
+	result.Hunks[0].Unfolded = true
+	out := diff.NewRenderer(diff.RenderOptions{FoldContext: 20}).RenderFiles([]*diff.DiffResult{result})
+	if strings.Contains(out, "unchanged lines") || !strings.Contains(out, "x15") {
+		t.Errorf("expected an unfolded hunk to show every line, got:\n%s", out)
+	}
+}
+
+func TestFoldContextShortRuns(t *testing.T) {
+	result, err := diff.ParseUnifiedDiff(longContextDiff())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := diff.NewRenderer(diff.RenderOptions{FoldContext: 30}).RenderFiles([]*diff.DiffResult{result})
+	if strings.Contains(out, "unchanged lines") {
+		t.Errorf("expected a run of 30 lines not to fold at 30, got:\n%s", out)
+	}
+}
@@ -0,0 +1,84 @@
+package diff_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/avgvstvs96/differential/internal/diff"
+)
+
+// licenseDiff changes the same header line in three files; c.go also has a
+// change of its own
+const licenseDiff = `diff --git a/a.go b/a.go
+--- a/a.go
++++ b/a.go
+@@ -1,2 +1,2 @@
+-// Copyright 2023
++// Copyright 2024
+ package x
+diff --git a/b.go b/b.go
+--- a/b.go
++++ b/b.go
+@@ -1,2 +1,2 @@
+-// Copyright 2023
++// Copyright 2024
+ package x
+diff --git a/c.go b/c.go
+--- a/c.go
++++ b/c.go
+@@ -1,2 +1,2 @@
+-// Copyright 2023
++// Copyright 2024
+ package x
+@@ -10,2 +10,3 @@
+ func c() {
++	return
+ }
+`
+
+func TestFoldDuplicateHunks(t *testing.T) {
+	files, err := diff.ParseMultiFileDiff(licenseDiff)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	diff.FoldDuplicateHunks(files)
+
+	a, b, c := files[0], files[1], files[2]
+	if got := strings.Join(a.Hunks[0].Duplicates, ","); got != "b.go,c.go" {
+		t.Errorf("a.go duplicates = %q, want b.go,c.go", got)
+	}
+	if b.SkipReason != diff.SkipDuplicate {
+		t.Errorf("b.go SkipReason = %q, want %q", b.SkipReason, diff.SkipDuplicate)
+	}
+	if c.SkipReason != "" || c.Hunks[0].DuplicateOf != "a.go" || c.Hunks[1].DuplicateOf != "" {
+		t.Errorf("c.go should only fold its first hunk: %q, %+v", c.SkipReason, c.Hunks)
+	}
+
+	r := diff.NewRenderer(diff.RenderOptions{HunkContext: diff.HunkContextOff})
+	output := diff.ParseANSI(r.RenderFiles(files)).Plain()
+	for _, want := range []string{"same change in 2 other files", "▸ b.go — skipped (duplicate)", "@@ -1,2 +1,2 @@ · same change as a.go\n\n"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+
+	// Rows after a folded hunk still map to their lines
+	found := false
+	for i, row := range strings.Split(output, "\n") {
+		if strings.Contains(row, "return") {
+			found = true
+			file, _, newLine, ok := r.LineAt(files, i)
+			if !ok || file != c || newLine != 11 {
+				t.Errorf("LineAt(%d) = %v, %d, %v; want c.go line 11", i, file, newLine, ok)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("output missing the added line:\n%s", output)
+	}
+
+	diff.UnfoldDuplicateHunks(files)
+	if b.SkipReason != "" || len(a.Hunks[0].Duplicates) != 0 || c.Hunks[0].DuplicateOf != "" {
+		t.Error("UnfoldDuplicateHunks left hunks folded")
+	}
+}
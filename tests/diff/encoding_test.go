@@ -0,0 +1,133 @@
+package diff_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/avgvstvs96/differential/internal/diff"
+)
+
+func TestDetectEncoding(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"ascii", "plain text\n", "utf-8"},
+		{"utf-8", "café\n", "utf-8"},
+		{"utf-8 bom", "\xef\xbb\xbfcaf\xc3\xa9", "utf-8"},
+		{"latin-1", "caf\xe9 na\xefve\n", "latin-1"},
+		{"utf-8 with a stray byte", "café \xff\n", "utf-8"},
+		{"windows-1252 quotes", "\x93quoted\x94\n", "windows-1252"},
+		{"utf-16le bom", "\xff\xfeh\x00i\x00", "utf-16le"},
+		{"utf-16be bom", "\xfe\xff\x00h\x00i", "utf-16be"},
+		{"utf-16le without bom", "h\x00e\x00l\x00l\x00o\x00", "utf-16le"},
+		{"utf-16be without bom", "\x00h\x00e\x00l\x00l\x00o", "utf-16be"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diff.DetectEncoding([]byte(tt.data)); got != tt.want {
+				t.Errorf("DetectEncoding(%q) = %q, want %q", tt.data, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodingName(t *testing.T) {
+	for name, want := range map[string]string{"Latin1": "latin-1", "ISO-8859-1": "latin-1", "cp1252": "windows-1252", "utf-16": "utf-16le", "UTF-8": "utf-8"} {
+		if got, err := diff.EncodingName(name); err != nil || got != want {
+			t.Errorf("EncodingName(%q) = %q, %v, want %q", name, got, err, want)
+		}
+	}
+	if _, err := diff.EncodingName("ebcdic"); err == nil || !strings.Contains(err.Error(), "latin-1") {
+		t.Errorf("expected an error listing the encodings, got %v", err)
+	}
+}
+
+func TestDecodeFilesLatin1(t *testing.T) {
+	input := "diff --git a/notes.txt b/notes.txt\nindex 1111111..2222222 100644\n--- a/notes.txt\n+++ b/notes.txt\n@@ -1,2 +1,2 @@\n-caf\xe9\n+caf\xe9!\n na\xefve\n"
+	files, err := diff.ParseMultiFileDiff(input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	diff.DecodeFiles(files, "", nil, 3)
+	file := files[0]
+	if file.Encoding != "latin-1" {
+		t.Errorf("expected latin-1, got %q", file.Encoding)
+	}
+	var contents []string
+	for _, line := range file.Hunks[0].Lines {
+		contents = append(contents, line.Content)
+	}
+	if got := strings.Join(contents, "|"); got != "café|café!|naïve" {
+		t.Errorf("expected transcoded lines, got %q", got)
+	}
+
+	output := diff.StripANSI(diff.NewRenderer(diff.RenderOptions{Width: 100, FileHeaders: true}).RenderFiles(files))
+	if !strings.Contains(output, "encoding: latin-1") {
+		t.Errorf("expected an encoding badge, got:\n%s", output)
+	}
+	if _, err := diff.PartialPatch(file, func(diff.DiffLine) bool { return true }); err == nil {
+		t.Error("expected transcoded lines not to be staged")
+	}
+
+	// A forced encoding overrides detection, and UTF-8 leaves lines alone
+	files, _ = diff.ParseMultiFileDiff(input)
+	diff.DecodeFiles(files, "utf-8", nil, 3)
+	if files[0].Encoding != "" || files[0].Hunks[0].Lines[0].Content != "caf\xe9" {
+		t.Errorf("expected forced UTF-8 to keep the bytes, got %q", files[0].Hunks[0].Lines[0].Content)
+	}
+}
+
+func TestDecodeFilesUTF16(t *testing.T) {
+	input := "diff --git a/names.txt b/names.txt\nindex 1111111..2222222 100644\nBinary files a/names.txt and b/names.txt differ\n"
+	utf16 := func(s string) []byte {
+		data := []byte{0xff, 0xfe}
+		for _, r := range s {
+			data = append(data, byte(r), 0)
+		}
+		return data
+	}
+	blobs := map[string][]byte{"1111111": utf16("one\ntwo\n"), "2222222": utf16("one\nthree\n")}
+	load := func(path, id string) ([]byte, error) { return blobs[id], nil }
+
+	files, err := diff.ParseMultiFileDiff(input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	diff.DecodeFiles(files, "", load, 3)
+	file := files[0]
+	if file.IsBinary || file.Encoding != "utf-16le" {
+		t.Fatalf("expected a UTF-16 text diff, got binary=%v encoding=%q", file.IsBinary, file.Encoding)
+	}
+	if additions, deletions := file.CountChanges(); additions != 1 || deletions != 1 {
+		t.Errorf("expected +1 -1, got +%d -%d", additions, deletions)
+	}
+	output := diff.StripANSI(diff.NewRenderer(diff.RenderOptions{Width: 100}).RenderFiles(files))
+	if !strings.Contains(output, "-two") || !strings.Contains(output, "+three") {
+		t.Errorf("expected the decoded lines, got:\n%s", output)
+	}
+
+	// Without the versions the file stays binary
+	files, _ = diff.ParseMultiFileDiff(input)
+	diff.DecodeFiles(files, "", nil, 3)
+	if !files[0].IsBinary {
+		t.Error("expected the file to stay binary without a loader")
+	}
+
+	// Filtered files aren't read, nor the old version of other binaries
+	var loaded []string
+	record := func(path, id string) ([]byte, error) {
+		loaded = append(loaded, id)
+		return blobs[id], nil
+	}
+	blobs["2222222"] = []byte("\x89PNG\x00\x01")
+	files, _ = diff.ParseMultiFileDiff(input)
+	files[0].SkipReason = diff.SkipFiltered
+	diff.DecodeFiles(files, "", record, 3)
+	files[0].SkipReason = ""
+	diff.DecodeFiles(files, "", record, 3)
+	if strings.Join(loaded, ",") != "2222222" || !files[0].IsBinary {
+		t.Errorf("expected only the new version to be read, read %v", loaded)
+	}
+}
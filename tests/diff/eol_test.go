@@ -0,0 +1,89 @@
+package diff_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/avgvstvs96/differential/internal/diff"
+)
+
+const eolDiff = "diff --git a/notes.txt b/notes.txt\r\n" +
+	"index 3b18e51..8c3f1a2 100644\r\n" +
+	"--- a/notes.txt\r\n" +
+	"+++ b/notes.txt\r\n" +
+	"@@ -1,3 +1,3 @@\r\n" +
+	"-first\n" +
+	"-second\n" +
+	"+first\r\n" +
+	"+second\r\n" +
+	" third\n"
+
+func TestParseEOLChange(t *testing.T) {
+	result, err := diff.ParseUnifiedDiff(eolDiff)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.NewFile != "notes.txt" || len(result.Hunks) != 1 {
+		t.Fatalf("got file %q with %d hunks", result.NewFile, len(result.Hunks))
+	}
+	want := diff.EOLChange{From: "LF", To: "CRLF", Lines: 2}
+	if result.EOL == nil || *result.EOL != want {
+		t.Fatalf("EOL = %+v, want %+v", result.EOL, want)
+	}
+	for _, line := range result.Hunks[0].Lines {
+		if strings.Contains(line.Content, "\r") {
+			t.Errorf("content %q keeps its carriage return", line.Content)
+		}
+		if line.CRLF != (line.Kind == diff.LineAdded) {
+			t.Errorf("line %q: CRLF = %v", line.Content, line.CRLF)
+		}
+	}
+
+	out := diff.RenderUnifiedDiff(result, diff.RenderOptions{})
+	if !strings.Contains(out, "EOL changed (LF→CRLF)") || strings.Contains(out, "second") {
+		t.Errorf("expected an EOL notice instead of the lines, got:\n%s", out)
+	}
+}
+
+func TestContentChangeIsNotEOLChange(t *testing.T) {
+	text := strings.Replace(eolDiff, "+second\r\n", "+2nd\r\n", 1)
+	result, err := diff.ParseUnifiedDiff(text)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.EOL != nil {
+		t.Errorf("EOL = %+v, want nil", result.EOL)
+	}
+
+	// Only the line whose ending is all that changed shows its carriage
+	// return, unless invisibles are shown
+	for _, out := range []string{
+		diff.StripANSI(diff.RenderUnifiedDiff(result, diff.RenderOptions{})),
+		diff.StripANSI(diff.RenderSideBySideDiff(result, diff.RenderOptions{Width: 100})),
+	} {
+		if !strings.Contains(out, "first␍") || strings.Contains(out, "2nd␍") {
+			t.Errorf("expected a CR marker on the first line only, got:\n%s", out)
+		}
+	}
+	out := diff.StripANSI(diff.RenderUnifiedDiff(result, diff.RenderOptions{ShowInvisibles: true}))
+	if !strings.Contains(out, "first␍") || !strings.Contains(out, "2nd␍") || strings.Contains(out, "third␍") {
+		t.Errorf("expected CR markers on the CRLF lines, got:\n%s", out)
+	}
+}
+
+func TestPartialPatchKeepsCRLF(t *testing.T) {
+	result, err := diff.ParseUnifiedDiff(eolDiff)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	patch, err := diff.PartialPatch(result, func(line diff.DiffLine) bool {
+		return line.Content == "first"
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "--- a/notes.txt\n+++ b/notes.txt\n@@ -1,3 +1,3 @@\n-first\n second\n+first\r\n third\n"
+	if patch != want {
+		t.Errorf("patch = %q, want %q", patch, want)
+	}
+}
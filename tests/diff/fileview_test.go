@@ -0,0 +1,34 @@
+package diff_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/avgvstvs96/differential/internal/diff"
+)
+
+func TestFileView(t *testing.T) {
+	file := diff.FileView("main.go", "package main\n\nfunc main() {}")
+	if len(file.Hunks) != 1 || len(file.Hunks[0].Lines) != 3 {
+		t.Fatalf("expected one hunk of three lines, got %+v", file.Hunks)
+	}
+	last := file.Hunks[0].Lines[2]
+	if last.Kind != diff.LineContext || last.OldLineNo != 3 || last.NewLineNo != 3 || !last.NoNewline {
+		t.Errorf("unexpected last line %+v", last)
+	}
+	if added, removed := file.CountChanges(); added != 0 || removed != 0 {
+		t.Errorf("expected no changes, got +%d -%d", added, removed)
+	}
+
+	output := diff.StripANSI(diff.NewRenderer(diff.RenderOptions{Width: 80, FoldContext: 1}).Render(file))
+	if !strings.Contains(output, "func main() {}") {
+		t.Errorf("expected the whole file unfolded, got:\n%s", output)
+	}
+
+	if !diff.FileView("logo.png", "\x89PNG\x00").IsBinary {
+		t.Error("expected a file with NUL bytes to be binary")
+	}
+	if file := diff.FileView("empty", ""); len(file.Hunks) != 0 {
+		t.Errorf("expected an empty file to have no hunks, got %+v", file.Hunks)
+	}
+}
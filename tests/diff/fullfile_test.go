@@ -0,0 +1,53 @@
+package diff_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/avgvstvs96/differential/internal/diff"
+)
+
+func TestRenderFullFile(t *testing.T) {
+	oldText := "a\nb\nc\nd\ne\n"
+	newText := "a\nB\nc\ne\nf\n"
+	files, err := diff.ParseMultiFileDiff(diff.UnifiedDiff("a/f.txt", "b/f.txt", oldText, newText, 3))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	opts := diff.RenderOptions{
+		Width:    40,
+		FullFile: true,
+		LoadBlob: func(path, id string) ([]byte, error) { return []byte(newText), nil },
+	}
+	renderer := diff.NewRenderer(opts)
+	output := diff.StripANSI(renderer.RenderFiles(files))
+	rows := strings.Split(strings.TrimRight(output, "\n "), "\n")
+	want := []string{" a", "▎B", " c", "▔e", "▎f"}
+	if len(rows) != len(want) {
+		t.Fatalf("expected %d rows, got:\n%s", len(want), output)
+	}
+	for i, row := range rows {
+		if strings.TrimRight(row, " ") != want[i] {
+			t.Errorf("row %d = %q, want %q", i, row, want[i])
+		}
+	}
+
+	for row, lines := range map[int][2]int{0: {1, 1}, 3: {5, 4}, 4: {0, 5}} {
+		_, oldLine, newLine, ok := renderer.LineAt(files, row)
+		if !ok || oldLine != lines[0] || newLine != lines[1] {
+			t.Errorf("LineAt(%d) = -%d +%d %v, want -%d +%d", row, oldLine, newLine, ok, lines[0], lines[1])
+		}
+	}
+}
+
+func TestRenderFullFileFallback(t *testing.T) {
+	files, err := diff.ParseMultiFileDiff(diff.UnifiedDiff("a/f.txt", "b/f.txt", "a\n", "b\n", 3))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Without the new version the file is shown as hunks
+	output := diff.StripANSI(diff.RenderFiles(files, diff.RenderOptions{Width: 40, FullFile: true}))
+	if !strings.Contains(output, "@@") {
+		t.Errorf("expected hunks, got:\n%s", output)
+	}
+}
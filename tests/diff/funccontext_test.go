@@ -0,0 +1,134 @@
+package diff_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/avgvstvs96/differential/internal/diff"
+)
+
+func TestParseHunkContextMode(t *testing.T) {
+	for _, name := range []string{"git", "scan", "off"} {
+		mode, err := diff.ParseHunkContextMode(name)
+		if err != nil {
+			t.Fatalf("unexpected error for %q: %v", name, err)
+		}
+		if mode.String() != name {
+			t.Errorf("round trip of %q gave %q", name, mode.String())
+		}
+	}
+
+	if _, err := diff.ParseHunkContextMode("always"); err == nil {
+		t.Error("expected error for unknown mode")
+	}
+}
+
+func TestFunctionContext(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		lines    []string
+		line     int
+		want     string
+	}{
+		{
+			name:     "go function",
+			filename: "main.go",
+			lines:    []string{"package main", "", "func run() error {", "\tx := 1", "\treturn nil", "}"},
+			line:     5,
+			want:     "func run() error {",
+		},
+		{
+			name:     "python method skips deeper nested functions",
+			filename: "app.py",
+			lines:    []string{"class App:", "    def start(self):", "        def helper():", "            pass", "        return 1"},
+			line:     5,
+			want:     "def start(self):",
+		},
+		{
+			name:     "javascript ignores control flow",
+			filename: "app.js",
+			lines:    []string{"class Store {", "  load(id) {", "    if (id) {", "      return 1", "    }"},
+			line:     4,
+			want:     "load(id) {",
+		},
+		{
+			name:     "markdown heading",
+			filename: "README.md",
+			lines:    []string{"# Title", "", "## Usage", "", "Run it."},
+			line:     5,
+			want:     "## Usage",
+		},
+		{
+			name:     "unknown language",
+			filename: "notes.txt",
+			lines:    []string{"func run() {", "x"},
+			line:     2,
+			want:     "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diff.FunctionContext(tt.filename, tt.lines, tt.line); got != tt.want {
+				t.Errorf("FunctionContext() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+const contextDiff = `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -6,3 +6,3 @@ import "fmt"
+ 	a := 1
+-	b := 2
++	b := 3
+ 	c := 3
+`
+
+const contextSource = `package main
+
+import "fmt"
+
+func compute() int {
+	a := 1
+	b := 3
+	c := 3
+	return a + b + c
+}
+`
+
+func TestRenderHunkContext(t *testing.T) {
+	files, err := diff.ParseMultiFileDiff(contextDiff)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	render := func(opts diff.RenderOptions) string {
+		return diff.ParseANSI(diff.NewRenderer(opts).Render(files[0])).Plain()
+	}
+	loadSource := func(path, id string) ([]byte, error) {
+		return []byte(contextSource), nil
+	}
+
+	if output := render(diff.RenderOptions{HunkContext: diff.HunkContextScan, LoadBlob: loadSource}); !strings.Contains(output, "@@ -6,3 +6,3 @@ func compute() int {") {
+		t.Errorf("expected the scanned function in the header, got:\n%s", output)
+	}
+	if output := render(diff.RenderOptions{HunkContext: diff.HunkContextGit, LoadBlob: loadSource}); !strings.Contains(output, `@@ -6,3 +6,3 @@ import "fmt"`) {
+		t.Errorf("expected git's context in the header, got:\n%s", output)
+	}
+	if output := render(diff.RenderOptions{HunkContext: diff.HunkContextOff}); strings.Contains(output, "import") {
+		t.Errorf("expected no context in the header, got:\n%s", output)
+	}
+
+	// Without a matching source git's context is kept
+	failing := func(path, id string) ([]byte, error) { return nil, errors.New("missing") }
+	mismatched := func(path, id string) ([]byte, error) { return []byte("func other() {\n}\n"), nil }
+	for _, load := range []func(string, string) ([]byte, error){nil, failing, mismatched} {
+		if output := render(diff.RenderOptions{HunkContext: diff.HunkContextScan, LoadBlob: load}); !strings.Contains(output, `@@ -6,3 +6,3 @@ import "fmt"`) {
+			t.Errorf("expected git's context as a fallback, got:\n%s", output)
+		}
+	}
+}
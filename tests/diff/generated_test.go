@@ -0,0 +1,65 @@
+package diff_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/avgvstvs96/differential/internal/diff"
+)
+
+// addedFile returns the diff adding a file of lines
+func addedFile(name string, lines ...string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "diff --git a/%s b/%s\nnew file mode 100644\n--- /dev/null\n+++ b/%s\n", name, name, name)
+	fmt.Fprintf(&sb, "@@ -0,0 +1,%d @@\n", len(lines))
+	for _, line := range lines {
+		sb.WriteString("+" + line + "\n")
+	}
+	return sb.String()
+}
+
+func TestGeneratedFiles(t *testing.T) {
+	text := addedFile("main.go", "package main") +
+		addedFile("yarn.lock", "# yarn lockfile v1") +
+		addedFile("api/client.go", "package api") +
+		addedFile("gen.go", "// Code generated by stringer; DO NOT EDIT.", "", "package main") +
+		addedFile("app.js", strings.Repeat("var a=1;", 200)) +
+		addedFile("late.go", "package main", "", "", "", "", "// @generated")
+	files, err := diff.ParseMultiFileDiff(text)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	generated := diff.GeneratedFiles{
+		Patterns:   []string{"*.lock"},
+		Attributes: map[string]bool{"api/client.go": true},
+	}
+	generated.Apply(files)
+	diff.CollapseGenerated(files)
+	for _, file := range files {
+		want := map[string]bool{"yarn.lock": true, "api/client.go": true, "gen.go": true, "app.js": true}[file.DisplayName()]
+		if file.Generated != want || file.Collapsed != want {
+			t.Errorf("%s: generated %v and collapsed %v, want %v", file.DisplayName(), file.Generated, file.Collapsed, want)
+		}
+	}
+
+	output := diff.StripANSI(diff.RenderFiles(files, diff.RenderOptions{Width: 80}))
+	if !strings.Contains(output, "▸ yarn.lock (+1 -0) · generated") {
+		t.Errorf("expected a collapsed, badged yarn.lock, got:\n%s", output)
+	}
+
+	// A generated file alone is shown, with its badge
+	files, err = diff.ParseMultiFileDiff(addedFile("yarn.lock", "# yarn lockfile v1"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	generated.Apply(files)
+	if diff.CollapseGenerated(files) || !files[0].Generated || files[0].Collapsed {
+		t.Errorf("expected a lone generated file to be expanded, got collapsed %v", files[0].Collapsed)
+	}
+	output = diff.StripANSI(diff.RenderFiles(files, diff.RenderOptions{Width: 80, FileHeaders: true}))
+	if !strings.Contains(output, "GENERATED") || !strings.Contains(output, "# yarn lockfile v1") {
+		t.Errorf("expected the badged file, got:\n%s", output)
+	}
+}
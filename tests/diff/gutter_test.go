@@ -0,0 +1,49 @@
+package diff_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/avgvstvs96/differential/internal/diff"
+)
+
+func TestParseGutterMode(t *testing.T) {
+	for _, name := range []string{"both", "old", "new", "none"} {
+		mode, err := diff.ParseGutterMode(name)
+		if err != nil {
+			t.Fatalf("unexpected error for %q: %v", name, err)
+		}
+		if mode.String() != name {
+			t.Errorf("round trip of %q gave %q", name, mode.String())
+		}
+	}
+
+	if _, err := diff.ParseGutterMode("left"); err == nil {
+		t.Error("expected error for unknown mode")
+	}
+}
+
+func TestGutterMode_NextCycles(t *testing.T) {
+	mode := diff.GutterBoth
+	for i := 0; i < 4; i++ {
+		mode = mode.Next()
+	}
+	if mode != diff.GutterBoth {
+		t.Errorf("expected to cycle back to both, got %s", mode)
+	}
+}
+
+func TestRenderUnifiedDiff_GutterSeparator(t *testing.T) {
+	result, err := diff.ParseUnifiedDiff("--- a/x.txt\n+++ b/x.txt\n@@ -1 +1 @@\n-old\n+new\n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	output := diff.RenderUnifiedDiff(result, diff.RenderOptions{
+		ShowLineNumbers: true,
+		Gutter:          diff.Gutter{Mode: diff.GutterNew, Width: 3, Separator: " │ "},
+	})
+	if !strings.Contains(output, "  1 │ ") {
+		t.Errorf("expected new-only gutter with separator, got:\n%s", output)
+	}
+}
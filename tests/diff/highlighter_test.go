@@ -1,6 +1,7 @@
 package diff_test
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/avgvstvs96/differential/internal/diff"
@@ -118,4 +119,93 @@ func TestHighlightIntralineChanges(t *testing.T) {
 	if !foundAdded {
 		t.Error("expected 'Differential' to be highlighted in added line")
 	}
-}
\ No newline at end of file
+}
+
+func TestIntralineGranularity(t *testing.T) {
+	segments := func(name, removed, added string, granularity diff.IntralineGranularity) ([]string, []string) {
+		result, err := diff.ParseUnifiedDiff("--- a/" + name + "\n+++ b/" + name + "\n@@ -1 +1 @@\n-" + removed + "\n+" + added + "\n")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		diff.NewRenderer(diff.RenderOptions{Width: 120, Intraline: granularity}).RenderUnified(result)
+		var texts [2][]string
+		for i, line := range result.Hunks[0].Lines {
+			for _, seg := range line.Segments {
+				texts[i] = append(texts[i], seg.Text)
+			}
+		}
+		return texts[0], texts[1]
+	}
+
+	tests := []struct {
+		name             string
+		file             string
+		removed, added   string
+		granularity      diff.IntralineGranularity
+		wantOld, wantNew []string
+	}{
+		{"character", "notes.txt", "the brown fox", "the brawn fox", diff.IntralineCharacter, []string{"o"}, []string{"a"}},
+		{"word", "notes.txt", "the brown fox", "the brawn fox", diff.IntralineWord, []string{"brown"}, []string{"brawn"}},
+		{"word counts runes", "notes.txt", "café brown", "café brawn", diff.IntralineWord, []string{"brown"}, []string{"brawn"}},
+		{"token", "main.go", `s := "hello world"`, `s := "hello there"`, diff.IntralineToken, []string{`"hello world"`}, []string{`"hello there"`}},
+		{"token without a lexer", "notes.unknown", "a brown fox", "a brawn fox", diff.IntralineToken, []string{"brown"}, []string{"brawn"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotOld, gotNew := segments(tt.file, tt.removed, tt.added, tt.granularity)
+			if strings.Join(gotOld, "|") != strings.Join(tt.wantOld, "|") || strings.Join(gotNew, "|") != strings.Join(tt.wantNew, "|") {
+				t.Errorf("got %q and %q, want %q and %q", gotOld, gotNew, tt.wantOld, tt.wantNew)
+			}
+		})
+	}
+
+	if _, err := diff.ParseIntralineGranularity("line"); err == nil {
+		t.Error("expected an error for an unknown granularity")
+	}
+	if granularity, err := diff.ParseIntralineGranularity("Word"); err != nil || granularity != diff.IntralineWord {
+		t.Errorf("ParseIntralineGranularity(Word) = %v, %v", granularity, err)
+	}
+}
+
+func TestHighlightIntralineChangesPairsSimilarLines(t *testing.T) {
+	hunk := &diff.Hunk{
+		Lines: []diff.DiffLine{
+			{Kind: diff.LineRemoved, Content: "alpha := compute(1)"},
+			{Kind: diff.LineRemoved, Content: "beta := compute(2)"},
+			{Kind: diff.LineRemoved, Content: "gamma := compute(3)"},
+			// Reordered and edited
+			{Kind: diff.LineAdded, Content: "gamma := compute(30)"},
+			{Kind: diff.LineAdded, Content: "alpha := compute(10)"},
+			{Kind: diff.LineAdded, Content: "beta := compute(20)"},
+			// Unlike any removed line
+			{Kind: diff.LineAdded, Content: "return nil"},
+		},
+	}
+
+	diff.HighlightIntralineChanges(hunk)
+
+	for i, want := range []string{"", "", "", "0", "0", "0", ""} {
+		var texts []string
+		for _, seg := range hunk.Lines[i].Segments {
+			texts = append(texts, seg.Text)
+		}
+		if got := strings.Join(texts, "|"); got != want {
+			t.Errorf("line %d %q has segments %q, want %q", i, hunk.Lines[i].Content, got, want)
+		}
+	}
+}
+
+func TestHighlightIntralineChangesFallsBackToAdjacentLines(t *testing.T) {
+	hunk := &diff.Hunk{
+		Lines: []diff.DiffLine{
+			{Kind: diff.LineRemoved, Content: "one"},
+			{Kind: diff.LineAdded, Content: "something else"},
+		},
+	}
+
+	diff.HighlightIntralineChanges(hunk)
+
+	if len(hunk.Lines[0].Segments) == 0 || len(hunk.Lines[1].Segments) == 0 {
+		t.Error("expected adjacent dissimilar lines to still be paired")
+	}
+}
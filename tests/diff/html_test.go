@@ -0,0 +1,45 @@
+package diff_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/avgvstvs96/differential/internal/diff"
+	"github.com/avgvstvs96/differential/internal/themes"
+)
+
+func TestANSIToHTML(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain", "a < b", "a &lt; b"},
+		{"truecolor", "\x1b[38;2;255;0;128mx\x1b[0my", `<span style="color:#ff0080">x</span>y`},
+		{"palette", "\x1b[48;5;22mx\x1b[49m", `<span style="background:#005f00">x</span>`},
+		{"basic and bold", "\x1b[1;31mx\x1b[22my\x1b[0m", `<span style="color:#cd0000;font-weight:bold">x</span><span style="color:#cd0000">y</span>`},
+		{"reverse", "\x1b[7mx\x1b[27m", `<span style="color:var(--bg);background:var(--fg)">x</span>`},
+		{"other escapes dropped", "\x1b[2Kx", "x"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diff.ANSIToHTML(tt.in); got != tt.want {
+				t.Errorf("ANSIToHTML(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTMLDocument(t *testing.T) {
+	theme := &themes.ThemeColors{Text: "#eeeeee", Background: "#111111"}
+	doc := diff.HTMLDocument("a<b.html", "\x1b[32m+x\x1b[0m", theme)
+	for _, want := range []string{
+		"<title>a&lt;b.html</title>",
+		"--fg: #eeeeee; --bg: #111111;",
+		`<pre><span style="color:#00cd00">+x</span></pre>`,
+	} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("document missing %q:\n%s", want, doc)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package diff_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/avgvstvs96/differential/internal/diff"
+)
+
+const stampDiff = `diff --git a/a.go b/a.go
+--- a/a.go
++++ b/a.go
+@@ -1,7 +1,7 @@
+-// Generated at 2024-01-01
++// Generated at 2024-06-01
+ package a
+ 
+ func a() {
+-	return nil
++	return err
+ }
+ 
+diff --git a/b.go b/b.go
+--- a/b.go
++++ b/b.go
+@@ -1,2 +1,2 @@
+-// Generated at 2024-01-01
++// Generated at 2024-06-01
+ package b
+`
+
+func TestIgnoreMatchingLines(t *testing.T) {
+	patterns := []*regexp.Regexp{regexp.MustCompile(`^// Generated at `)}
+
+	files, err := diff.ParseMultiFileDiff(stampDiff)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	diff.IgnoreMatchingLines(files, patterns, false)
+	if n := diff.IgnoredChanges(files); n != 2 {
+		t.Errorf("expected 2 ignored changes, got %d", n)
+	}
+	lines := files[0].Hunks[0].Lines
+	if !lines[0].Ignored || !lines[1].Ignored || lines[5].Ignored || lines[6].Ignored {
+		t.Errorf("expected only the stamp to be ignored, got %+v", lines)
+	}
+	if files[1].SkipReason != "" || files[1].Hunks[0].Folded() {
+		t.Error("expected dimmed changes to keep their hunks")
+	}
+
+	// Dimmed changes keep their markers, in the context's style
+	opts := diff.RenderOptions{Width: 60, IgnoredChanges: diff.IgnoredDim}
+	output := diff.StripANSI(diff.RenderFiles(files, opts))
+	if !strings.Contains(output, "-// Generated at 2024-01-01") || !strings.Contains(output, "+    return err") {
+		t.Errorf("expected all changes, got:\n%s", output)
+	}
+
+	// Hidden changes are left out, along with the hunks and files of
+	// nothing else
+	files, err = diff.ParseMultiFileDiff(stampDiff)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	diff.IgnoreMatchingLines(files, patterns, true)
+	if files[0].SkipReason != "" || files[1].SkipReason != diff.SkipIgnored {
+		t.Errorf("expected only b.go to be skipped, got %q and %q", files[0].SkipReason, files[1].SkipReason)
+	}
+	opts.IgnoredChanges = diff.IgnoredHide
+	output = diff.StripANSI(diff.RenderFiles(files, opts))
+	if strings.Contains(output, "Generated") || !strings.Contains(output, "+    return err") {
+		t.Errorf("expected only the other change, got:\n%s", output)
+	}
+	if !strings.Contains(output, "b.go — skipped (ignored)") {
+		t.Errorf("expected b.go to be skipped, got:\n%s", output)
+	}
+
+	// A change with a line not matching isn't ignored
+	files, err = diff.ParseMultiFileDiff(strings.Replace(stampDiff, "+// Generated at 2024-06-01\n package b", "+// Generated at 2024-06-01\n+// by hand\n package b", 1))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	diff.IgnoreMatchingLines(files, patterns, true)
+	if n := diff.IgnoredChanges(files); n != 1 || files[1].SkipReason != "" {
+		t.Errorf("expected b.go's change to be kept, got %d ignored and %q", n, files[1].SkipReason)
+	}
+}
+
+func TestParseIgnoredMode(t *testing.T) {
+	for _, mode := range []diff.IgnoredMode{diff.IgnoredDim, diff.IgnoredHide} {
+		parsed, err := diff.ParseIgnoredMode(mode.String())
+		if err != nil || parsed != mode {
+			t.Errorf("ParseIgnoredMode(%q) = %v, %v", mode, parsed, err)
+		}
+	}
+	if _, err := diff.ParseIgnoredMode("fold"); err == nil {
+		t.Error("expected an error for an unknown mode")
+	}
+}
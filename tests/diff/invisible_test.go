@@ -0,0 +1,43 @@
+package diff_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/avgvstvs96/differential/internal/diff"
+)
+
+func TestShowInvisibles(t *testing.T) {
+	input := "--- a/x.txt\n+++ b/x.txt\n@@ -1,2 +1,2 @@\n-a\tb\n+a    b  \n-plain\n+pl\u200bain\x1b\u202e\n"
+	render := func(opts diff.RenderOptions) string {
+		result, err := diff.ParseUnifiedDiff(input)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if opts.ViewMode == diff.ViewSideBySide {
+			return diff.NewRenderer(opts).RenderSideBySide(result)
+		}
+		return diff.NewRenderer(opts).RenderUnified(result)
+	}
+
+	for _, view := range []diff.ViewMode{diff.ViewUnified, diff.ViewSideBySide} {
+		output := diff.StripANSI(render(diff.RenderOptions{Width: 120, ViewMode: view, ShowInvisibles: true}))
+		for _, want := range []string{"a→b", "a····b··", "pl␣ain␛�"} {
+			if !strings.Contains(output, want) {
+				t.Errorf("view %v: expected %q in:\n%s", view, want, output)
+			}
+		}
+	}
+
+	// Trailing whitespace is put on the removed color
+	output := render(diff.RenderOptions{Width: 120, ShowInvisibles: true})
+	if !strings.Contains(output, "\x1b[48;2;") || !strings.Contains(diff.StripANSI(output), "b··") {
+		t.Errorf("expected trailing whitespace highlighted, got %q", output)
+	}
+
+	// Without the option the text is left as it is
+	output = diff.StripANSI(render(diff.RenderOptions{Width: 120}))
+	if strings.ContainsAny(output, "→·␣␛�") || !strings.Contains(output, "a    b") {
+		t.Errorf("expected plain whitespace, got:\n%s", output)
+	}
+}
@@ -0,0 +1,54 @@
+package diff_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/avgvstvs96/differential/internal/diff"
+)
+
+func TestLanguagesFor(t *testing.T) {
+	languages := diff.Languages{ByGlob: map[string]string{
+		"*.tpl":      "html",
+		"*.html.tpl": "django",
+		"bin/**":     "bash",
+	}}
+	for path, want := range map[string]string{
+		"web/page.tpl":      "html",
+		"web/page.html.tpl": "django",
+		"bin/deploy":        "bash",
+		"main.go":           "",
+		"":                  "",
+	} {
+		if got := languages.For(path); got != want {
+			t.Errorf("For(%q) = %q, want %q", path, got, want)
+		}
+	}
+
+	languages.Override = "go"
+	if got := languages.For("web/page.tpl"); got != "go" {
+		t.Errorf("expected the override to win, got %q", got)
+	}
+}
+
+func TestRenderFileHeaderLanguage(t *testing.T) {
+	files, err := diff.ParseMultiFileDiff("diff --git a/page.tpl b/page.tpl\n--- a/page.tpl\n+++ b/page.tpl\n@@ -1 +1 @@\n-<p>a</p>\n+<p>b</p>\n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	opts := diff.RenderOptions{Width: 80, FileHeaders: true, Languages: diff.Languages{ByGlob: map[string]string{"*.tpl": "html"}}}
+	if output := diff.StripANSI(diff.RenderFiles(files, opts)); !strings.Contains(output, "▾ page.tpl  HTML") {
+		t.Errorf("expected the header to name the language, got:\n%s", output)
+	}
+}
+
+func TestRenderFileHeaderShebangLanguage(t *testing.T) {
+	files, err := diff.ParseMultiFileDiff("diff --git a/bin/deploy b/bin/deploy\n--- a/bin/deploy\n+++ b/bin/deploy\n@@ -1,2 +1,2 @@\n #!/usr/bin/env python3\n-print(1)\n+print(2)\n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	opts := diff.RenderOptions{Width: 80, FileHeaders: true}
+	if output := diff.StripANSI(diff.RenderFiles(files, opts)); !strings.Contains(output, "▾ bin/deploy  Python") {
+		t.Errorf("expected the header to name the shebang's language, got:\n%s", output)
+	}
+}
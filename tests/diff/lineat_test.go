@@ -0,0 +1,167 @@
+package diff_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/avgvstvs96/differential/internal/diff"
+)
+
+const lineAtDiff = `diff --git a/a.go b/a.go
+index 1111111..2222222 100644
+--- a/a.go
++++ b/a.go
+@@ -1,3 +1,3 @@
+ alpha
+-bravo
++charlie
+ delta
+diff --git a/b.go b/b.go
+index 3333333..4444444 100644
+--- a/b.go
++++ b/b.go
+@@ -10,2 +10,3 @@
+ echo
++foxtrot
+ golf
+`
+
+// lineAtCase is a row of rendered output and the line it should map to
+type lineAtCase struct {
+	row  int
+	file string
+	line int
+}
+
+func TestNewLineAt(t *testing.T) {
+	for _, mode := range []diff.ViewMode{diff.ViewUnified, diff.ViewSideBySide} {
+		files, err := diff.ParseMultiFileDiff(lineAtDiff)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		renderer := diff.NewRenderer(diff.RenderOptions{Width: 120, ViewMode: mode})
+		rows := strings.Split(diff.ParseANSI(renderer.RenderFiles(files)).Plain(), "\n")
+		rowOf := func(text string) int {
+			for i, row := range rows {
+				if strings.Contains(row, text) {
+					return i
+				}
+			}
+			t.Fatalf("%q not in output:\n%s", text, strings.Join(rows, "\n"))
+			return 0
+		}
+
+		tests := []lineAtCase{
+			{rowOf("delta"), "a.go", 3},
+			{rowOf("foxtrot"), "b.go", 11},
+			{rowOf("@@ -10,2"), "b.go", 10},
+			{0, "a.go", 1},
+		}
+		if mode == diff.ViewUnified {
+			// Removed lines give the line after them
+			tests = append(tests, lineAtCase{rowOf("bravo"), "a.go", 2})
+		}
+		for _, tt := range tests {
+			file, line, ok := renderer.NewLineAt(files, tt.row)
+			if !ok || file.NewFile != tt.file || line != tt.line {
+				t.Errorf("mode %d row %d: got %v %d %v, want %s:%d", mode, tt.row, file, line, ok, tt.file, tt.line)
+			}
+		}
+		if _, _, ok := renderer.NewLineAt(files, len(rows)); ok {
+			t.Errorf("mode %d: expected no line past the end", mode)
+		}
+	}
+}
+
+func TestRowOf(t *testing.T) {
+	for _, mode := range []diff.ViewMode{diff.ViewUnified, diff.ViewSideBySide} {
+		files, err := diff.ParseMultiFileDiff(lineAtDiff)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		renderer := diff.NewRenderer(diff.RenderOptions{Width: 120, ViewMode: mode})
+		rows := strings.Split(diff.ParseANSI(renderer.RenderFiles(files)).Plain(), "\n")
+
+		for _, tt := range []struct {
+			file     int
+			old, new int
+			text     string
+		}{
+			{0, 0, 2, "charlie"},
+			{0, 2, 0, "bravo"},
+			{1, 0, 11, "foxtrot"},
+		} {
+			row, ok := renderer.RowOf(files, files[tt.file], tt.old, tt.new)
+			if !ok || !strings.Contains(rows[row], tt.text) {
+				t.Errorf("mode %d line -%d +%d: got row %d %v, want the row of %q", mode, tt.old, tt.new, row, ok, tt.text)
+			}
+		}
+
+		files[1].Collapsed = true
+		if _, ok := renderer.RowOf(files, files[1], 0, 11); ok {
+			t.Errorf("mode %d: expected no row in a collapsed file", mode)
+		}
+	}
+}
+
+func TestHunkRows(t *testing.T) {
+	for _, mode := range []diff.ViewMode{diff.ViewUnified, diff.ViewSideBySide} {
+		files, err := diff.ParseMultiFileDiff(lineAtDiff)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		renderer := diff.NewRenderer(diff.RenderOptions{Width: 120, ViewMode: mode})
+		rows := strings.Split(diff.ParseANSI(renderer.RenderFiles(files)).Plain(), "\n")
+
+		hunkRows := renderer.HunkRows(files)
+		if len(hunkRows) != 2 || len(hunkRows[0]) != 1 || len(hunkRows[1]) != 1 {
+			t.Fatalf("mode %d: expected a row per hunk, got %v", mode, hunkRows)
+		}
+		for i, text := range []string{"alpha", "echo"} {
+			if !strings.Contains(rows[hunkRows[i][0]], text) {
+				t.Errorf("mode %d: expected the row of %q, got %q", mode, text, rows[hunkRows[i][0]])
+			}
+		}
+
+		// Collapsing a file invalidates the rows kept
+		files[0].Collapsed = true
+		if hunkRows := renderer.HunkRows(files); len(hunkRows[0]) != 0 || len(hunkRows[1]) != 1 {
+			t.Errorf("mode %d: expected no rows in a collapsed file, got %v", mode, hunkRows)
+		}
+	}
+}
+
+func TestRowCountAndLineFrom(t *testing.T) {
+	for _, mode := range []diff.ViewMode{diff.ViewUnified, diff.ViewSideBySide} {
+		files, err := diff.ParseMultiFileDiff(lineAtDiff)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		renderer := diff.NewRenderer(diff.RenderOptions{Width: 120, ViewMode: mode})
+		output := renderer.RenderFiles(files)
+		if count := renderer.RowCount(files); count != strings.Count(output, "\n") {
+			t.Errorf("mode %d: got %d rows, want %d", mode, count, strings.Count(output, "\n"))
+		}
+
+		// A hunk header gives the hunk's first line, on the row below
+		rows := strings.Split(diff.ParseANSI(output).Plain(), "\n")
+		header := 0
+		for i, row := range rows {
+			if strings.Contains(row, "@@ -10,2") {
+				header = i
+			}
+		}
+		file, oldLine, newLine, row, ok := renderer.LineFrom(files, header)
+		if !ok || file != files[1] || oldLine != 10 || newLine != 10 || row != header+1 {
+			t.Errorf("mode %d: got %v -%d +%d at row %d %v, want b.go's first line below row %d", mode, file, oldLine, newLine, row, ok, header)
+		}
+		if _, _, _, _, ok := renderer.LineFrom(files, len(rows)); ok {
+			t.Errorf("mode %d: expected no line past the end", mode)
+		}
+
+		files[0].Collapsed = true
+		if count := renderer.RowCount(files); count != strings.Count(renderer.RenderFiles(files), "\n") {
+			t.Errorf("mode %d: got %d rows with a.go collapsed", mode, count)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package diff_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/avgvstvs96/differential/internal/diff"
+	"github.com/avgvstvs96/differential/internal/themes"
+)
+
+func TestRenderLinks(t *testing.T) {
+	files, err := diff.ParseMultiFileDiff(linksDiff)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	theme, err := themes.Default().Resolve("dracula")
+	if err != nil {
+		t.Fatalf("failed to resolve theme: %v", err)
+	}
+	links := func(path string, line int, old bool) string {
+		return fmt.Sprintf("https://example.com/%s/%d/%v", path, line, old)
+	}
+
+	for _, mode := range []diff.ViewMode{diff.ViewUnified, diff.ViewSideBySide} {
+		opts := diff.RenderOptions{Theme: theme, Width: 100, ViewMode: mode, ShowLineNumbers: true}
+		plain := diff.NewRenderer(opts).RenderFiles(files)
+		opts.Links = links
+		linked := diff.NewRenderer(opts).RenderFiles(files)
+
+		for _, url := range []string{
+			"https://example.com/a.go/0/false", // The file header
+			"https://example.com/a.go/2/true",  // The removed line
+			"https://example.com/a.go/2/false", // The added line
+		} {
+			if !strings.Contains(linked, "\x1b]8;;"+url+"\x1b\\") {
+				t.Errorf("mode %v: expected a link to %s in:\n%q", mode, url, linked)
+			}
+		}
+		// Links don't take up room on screen
+		if diff.StripANSI(linked) != diff.StripANSI(plain) {
+			t.Errorf("mode %v: links changed the visible text:\n%s\nwant:\n%s", mode, diff.StripANSI(linked), diff.StripANSI(plain))
+		}
+	}
+}
+
+func TestANSIStringHyperlink(t *testing.T) {
+	s := diff.ParseANSI("\x1b]8;;https://example.com\x1b\\12\x1b]8;;\x1b\\ x")
+	if s.Plain() != "12 x" || s.Width() != 4 {
+		t.Errorf("expected the link's text only, got %q (width %d)", s.Plain(), s.Width())
+	}
+}
+
+const linksDiff = `diff --git a/a.go b/a.go
+index 1111111..2222222 100644
+--- a/a.go
++++ b/a.go
+@@ -1,3 +1,3 @@
+ package a
+-var alpha = 1
++var alpha = 2
+ var bravo = 3
+diff --git a/b.go b/b.go
+index 3333333..4444444 100644
+--- a/b.go
++++ b/b.go
+@@ -1,2 +1,3 @@
+ package b
++var charlie = 4
+ var delta = 5
+`
@@ -0,0 +1,56 @@
+package diff_test
+
+import (
+	"testing"
+
+	"github.com/avgvstvs96/differential/internal/diff"
+)
+
+func TestMatchReport(t *testing.T) {
+	files, err := diff.ParseMultiFileDiff(`diff --git a/a.go b/a.go
+--- a/a.go
++++ b/a.go
+@@ -1,4 +1,4 @@
+ func a() {
+-	x := 1
++	x := 10
+ 	return
+-	y := 2
+ }
+diff --git a/b.go b/b.go
+--- a/b.go
++++ b/b.go
+@@ -1,1 +1,2 @@
+ package b
++var c = 3
+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	report := diff.MatchReport(files)
+	if len(report) != 1 || report[0].Path != "a.go" || len(report[0].Matches) != 1 {
+		t.Fatalf("expected a single match in a.go, got %+v", report)
+	}
+	want := diff.LineMatch{OldLine: 2, NewLine: 2, Old: "\tx := 1", New: "\tx := 10", Similarity: 0.933, Change: diff.ChangeSubstantive}
+	if got := report[0].Matches[0]; got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	for _, tt := range []struct {
+		a, b string
+		want float64
+	}{
+		{"", "", 1},
+		{"abc", "abc", 1},
+		{"abc", "xyz", 0},
+		{"abcd", "abXY", 0.5},
+		{"héllo", "hello", 0.8},
+	} {
+		if got := diff.Similarity(tt.a, tt.b); got != tt.want {
+			t.Errorf("Similarity(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
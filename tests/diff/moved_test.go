@@ -0,0 +1,105 @@
+package diff_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/avgvstvs96/differential/internal/diff"
+	"github.com/avgvstvs96/differential/internal/themes"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// movedDiff moves parse from a.go into b.go, reindented, reindents a line
+// of c.go in place, and adds a lone brace to b.go
+const movedDiff = `diff --git a/a.go b/a.go
+--- a/a.go
++++ b/a.go
+@@ -1,6 +1,2 @@
+ package x
+-func parse(input string) error {
+-	return decode(input)
+-}
+-
+ }
+diff --git a/b.go b/b.go
+--- a/b.go
++++ b/b.go
+@@ -1,2 +1,7 @@
+ package x
++type parser struct{}
++  func parse(input string) error {
++      return decode(input)
++  }
++}
+ var y = 1
+diff --git a/c.go b/c.go
+--- a/c.go
++++ b/c.go
+@@ -1,3 +1,3 @@
+ package x
+-func reindented(value string) string { return value }
++	func reindented(value string) string { return value }
+ var z = 2
+`
+
+func TestDetectMovedLines(t *testing.T) {
+	files, err := diff.ParseMultiFileDiff(movedDiff)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	diff.DetectMovedLines(files)
+
+	moved := func(file *diff.DiffResult) string {
+		var marks []string
+		for _, line := range file.Hunks[0].Lines {
+			if line.Kind != diff.LineContext {
+				marks = append(marks, fmt.Sprint(line.Moved))
+			}
+		}
+		return strings.Join(marks, " ")
+	}
+	for i, want := range []string{
+		// The blank line after the block stays, as b.go adds a brace there
+		"true true true false",
+		"false true true true false",
+		"false false",
+	} {
+		if got := moved(files[i]); got != want {
+			t.Errorf("%s: moved lines %s, want %s", files[i].DisplayName(), got, want)
+		}
+	}
+
+	// Detecting again starts over
+	files[1].Hunks[0].Lines[2].Content = "something else entirely"
+	diff.DetectMovedLines(files)
+	if got := moved(files[0]); got != "false false false false" {
+		t.Errorf("a.go: moved lines %s after the move was broken", got)
+	}
+}
+
+func TestRenderMovedLines(t *testing.T) {
+	files, err := diff.ParseMultiFileDiff(movedDiff)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	diff.DetectMovedLines(files)
+
+	profile := lipgloss.ColorProfile()
+	lipgloss.SetColorProfile(termenv.TrueColor)
+	defer lipgloss.SetColorProfile(profile)
+
+	theme := *themes.GetCurrentTheme()
+	theme.DiffMovedToBg = lipgloss.Color("#123456")
+	want := "48;2;18;52;86"
+	for _, view := range []diff.ViewMode{diff.ViewUnified, diff.ViewSideBySide} {
+		renderer := diff.NewRenderer(diff.RenderOptions{Width: 120, ViewMode: view, Theme: &theme})
+		if output := renderer.Render(files[1]); !strings.Contains(output, want) {
+			t.Errorf("view %v: moved lines of b.go aren't on the diffMovedToBg color", view)
+		}
+		if output := renderer.Render(files[2]); strings.Contains(output, want) {
+			t.Errorf("view %v: c.go has no moved lines but uses the diffMovedToBg color", view)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package diff_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/avgvstvs96/differential/internal/diff"
+)
+
+const multiFileDiff = `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -1,2 +1,2 @@
+ package main
+--- removed comment
++// added comment
+diff --git a/go.sum b/go.sum
+index 3333333..4444444 100644
+--- a/go.sum
++++ b/go.sum
+@@ -1 +1 @@
+-old
++new
+`
+
+func TestParseMultiFileDiff(t *testing.T) {
+	files, err := diff.ParseMultiFileDiff(multiFileDiff)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("expected 2 files, got %d", len(files))
+	}
+	if files[0].DisplayName() != "main.go" || files[1].DisplayName() != "go.sum" {
+		t.Errorf("unexpected file names %q, %q", files[0].DisplayName(), files[1].DisplayName())
+	}
+
+	// The removed "-- removed comment" line must stay inside the first file
+	additions, deletions := files[0].CountChanges()
+	if additions != 1 || deletions != 1 {
+		t.Errorf("expected +1 -1 in main.go, got +%d -%d", additions, deletions)
+	}
+}
+
+func TestFileFilter_Apply(t *testing.T) {
+	files, err := diff.ParseMultiFileDiff(multiFileDiff)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	diff.FileFilter{Exclude: []string{"*.sum"}}.Apply(files)
+	if files[0].SkipReason != "" {
+		t.Errorf("main.go should not be skipped, got %q", files[0].SkipReason)
+	}
+	if files[1].SkipReason != diff.SkipFiltered {
+		t.Errorf("go.sum should be filtered, got %q", files[1].SkipReason)
+	}
+}
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern, path string
+		want          bool
+	}{
+		{"*.go", "internal/diff/parser.go", true},
+		{"*.go", "README.md", false},
+		{"vendor/**", "vendor/a/b.go", true},
+		{"vendor/**", "src/vendor/a.go", false},
+		{"vendor/", "src/vendor/a.go", true},
+		{"internal/*.go", "internal/app.go", true},
+		{"internal/*.go", "internal/app/app.go", false},
+	}
+
+	for _, tt := range tests {
+		if got := diff.MatchGlob(tt.pattern, tt.path); got != tt.want {
+			t.Errorf("MatchGlob(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestRenderFilesWithOffsets_Collapsed(t *testing.T) {
+	files, err := diff.ParseMultiFileDiff(multiFileDiff)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	files[0].Collapsed = true
+
+	output, offsets := diff.RenderFilesWithOffsets(files, diff.RenderOptions{})
+	if len(offsets) != 2 || offsets[0] != 0 || offsets[1] != 1 {
+		t.Fatalf("expected offsets [0 1], got %v", offsets)
+	}
+	if !strings.Contains(output, "▸ main.go (+1 -1)") {
+		t.Errorf("expected collapsed summary, got:\n%s", output)
+	}
+}
+
+func TestRenderFileHeaders(t *testing.T) {
+	files, err := diff.ParseMultiFileDiff(multiFileDiff + `diff --git a/old.py b/new.py
+similarity index 100%
+rename from old.py
+rename to new.py
+diff --git a/README.md b/README.md
+new file mode 100644
+index 0000000..5555555
+--- /dev/null
++++ b/README.md
+@@ -0,0 +1 @@
++# Hello
+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	output := diff.StripANSI(diff.RenderFiles(files, diff.RenderOptions{Width: 80}))
+	for _, want := range []string{
+		"▾ main.go  Go  +1 -1",
+		"▾ old.py → new.py  Python  +0 -0   RENAMED",
+		"▾ README.md  Markdown  +1 -0   NEW",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected header %q in:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "\ue627") {
+		t.Error("expected no icons unless enabled")
+	}
+
+	output = diff.RenderFiles(files, diff.RenderOptions{Width: 80, Icons: true})
+	if !strings.Contains(output, "\ue627 ") {
+		t.Error("expected the Go icon with icons enabled")
+	}
+}
+
+func TestRenderFileHeadersHeat(t *testing.T) {
+	files, err := diff.ParseMultiFileDiff(multiFileDiff)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if heat := diff.ChangeHeat(files); len(heat) != len(files) || heat[0] != 1 {
+		t.Errorf("expected the most changed file to rate 1, got %v", heat)
+	}
+	output := diff.StripANSI(diff.RenderFiles(files, diff.RenderOptions{Width: 80}))
+	if strings.Count(output, "●") != len(files) {
+		t.Errorf("expected a heat dot in each of the %d headers, got:\n%s", len(files), output)
+	}
+	opts := diff.RenderOptions{Width: 80, FileHeaders: true}
+	if output := diff.StripANSI(diff.RenderFiles(files[:1], opts)); strings.Contains(output, "●") {
+		t.Errorf("expected no heat dot for a lone file, got:\n%s", output)
+	}
+}
+
+func TestRenderFileHeadersSingleFile(t *testing.T) {
+	files, err := diff.ParseMultiFileDiff(multiFileDiff)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	files = files[:1]
+
+	if output := diff.StripANSI(diff.RenderFiles(files, diff.RenderOptions{Width: 80})); strings.Contains(output, "▾ main.go") {
+		t.Errorf("expected no header for a lone file, got:\n%s", output)
+	}
+	opts := diff.RenderOptions{Width: 80, FileHeaders: true}
+	if output := diff.StripANSI(diff.RenderFiles(files, opts)); !strings.HasPrefix(output, " ▾ main.go") {
+		t.Errorf("expected the header with FileHeaders, got:\n%s", output)
+	}
+
+	// Rows count the header
+	if _, _, newLine, ok := diff.NewRenderer(opts).LineAt(files, 2); !ok || newLine != 1 {
+		t.Errorf("expected new line 1 below the header and hunk header, got %d (ok %v)", newLine, ok)
+	}
+}
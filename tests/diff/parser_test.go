@@ -1,6 +1,7 @@
 package diff_test
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/avgvstvs96/differential/internal/diff"
@@ -97,4 +98,142 @@ Binary files a/image.png and b/image.png differ`
 	if len(result.Hunks) != 0 {
 		t.Errorf("expected 0 hunks for binary file, got %d", len(result.Hunks))
 	}
-}
\ No newline at end of file
+}
+
+func TestParseUnifiedDiff_GitBinaryNames(t *testing.T) {
+	input := `diff --git a/img.png b/img.png
+index 9b74a97..a995769 100644
+Binary files a/img.png and b/img.png differ`
+
+	result, err := diff.ParseUnifiedDiff(input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !result.IsBinary {
+		t.Errorf("expected IsBinary to be true")
+	}
+	if result.OldFile != "img.png" || result.NewFile != "img.png" {
+		t.Errorf("expected file names from binary line, got %q and %q", result.OldFile, result.NewFile)
+	}
+	if result.OldIndex != "9b74a97" || result.NewIndex != "a995769" {
+		t.Errorf("expected blob IDs from index line, got %q and %q", result.OldIndex, result.NewIndex)
+	}
+}
+
+func TestUnifiedDiff_RoundTrip(t *testing.T) {
+	oldText := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\n"
+	newText := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\n"
+
+	diffText := diff.UnifiedDiff("a/x", "b/x", oldText, newText, 1)
+	result, err := diff.ParseUnifiedDiff(diffText)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Hunks) != 2 {
+		t.Fatalf("expected 2 hunks, got %d:\n%s", len(result.Hunks), diffText)
+	}
+	if result.Hunks[0].Header != "@@ -1,3 +1,3 @@" {
+		t.Errorf("unexpected first hunk header %q", result.Hunks[0].Header)
+	}
+	if result.Hunks[1].Header != "@@ -10 +10,2 @@" {
+		t.Errorf("unexpected second hunk header %q", result.Hunks[1].Header)
+	}
+	additions, deletions := result.CountChanges()
+	if additions != 2 || deletions != 1 {
+		t.Errorf("expected +2 -1, got +%d -%d", additions, deletions)
+	}
+}
+
+func TestUnifiedDiff_Identical(t *testing.T) {
+	if got := diff.UnifiedDiff("a", "b", "same\n", "same\n", 3); got != "" {
+		t.Errorf("expected empty diff, got %q", got)
+	}
+}
+
+func TestUnifiedDiff_NoNewlineAtEOF(t *testing.T) {
+	tests := []struct {
+		name, oldText, newText, want string
+	}{
+		{"added newline", "a\nb", "a\nb\n", "@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n"},
+		{"removed newline", "a\nb\n", "a\nB", "@@ -1,2 +1,2 @@\n a\n-b\n+B\n\\ No newline at end of file\n"},
+		{"neither side", "a\nb", "A\nb", "@@ -1,2 +1,2 @@\n-a\n+A\n b\n\\ No newline at end of file\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := diff.UnifiedDiff("a/x", "b/x", tt.oldText, tt.newText, 3)
+			if want := "--- a/x\n+++ b/x\n" + tt.want; got != want {
+				t.Errorf("expected:\n%s\ngot:\n%s", want, got)
+			}
+			if _, err := diff.ParseUnifiedDiff(got); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestParseQuotedPaths(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		oldFile string
+		newFile string
+	}{
+		{
+			name:    "octal escapes",
+			input:   "diff --git \"a/caf\\303\\251.txt\" \"b/caf\\303\\251.txt\"\n--- \"a/caf\\303\\251.txt\"\n+++ \"b/caf\\303\\251.txt\"\n@@ -1 +1 @@\n-a\n+b\n",
+			oldFile: "café.txt",
+			newFile: "café.txt",
+		},
+		{
+			name:    "quoted rename",
+			input:   "diff --git \"a/caf\\303\\251.txt\" b/cafe.txt\nsimilarity index 100%\nrename from \"caf\\303\\251.txt\"\nrename to cafe.txt\n",
+			oldFile: "café.txt",
+			newFile: "cafe.txt",
+		},
+		{
+			name:    "tab in binary name",
+			input:   "diff --git \"a/x\\ty.png\" \"b/x\\ty.png\"\nBinary files \"a/x\\ty.png\" and \"b/x\\ty.png\" differ\n",
+			oldFile: "x\ty.png",
+			newFile: "x\ty.png",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := diff.ParseUnifiedDiff(tt.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.OldFile != tt.oldFile || result.NewFile != tt.newFile {
+				t.Errorf("got %q -> %q, want %q -> %q", result.OldFile, result.NewFile, tt.oldFile, tt.newFile)
+			}
+		})
+	}
+}
+
+func TestParseNoNewline(t *testing.T) {
+	input := "--- a/f.txt\n+++ b/f.txt\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n"
+	result, err := diff.ParseUnifiedDiff(input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lines := result.Hunks[0].Lines
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got %d", len(lines))
+	}
+	for i, want := range []bool{false, true, false} {
+		if lines[i].NoNewline != want {
+			t.Errorf("line %d (%q): NoNewline = %v, want %v", i, lines[i].Content, lines[i].NoNewline, want)
+		}
+	}
+
+	out := diff.RenderUnifiedDiff(result, diff.RenderOptions{})
+	if strings.Count(out, "⏎ missing") != 1 || !strings.Contains(out, "-b ⏎ missing") {
+		t.Errorf("expected the removed line to be marked, got:\n%s", out)
+	}
+	out = diff.RenderSideBySideDiff(result, diff.RenderOptions{Width: 80})
+	if strings.Count(out, "⏎ missing") != 1 {
+		t.Errorf("expected one marker side by side, got:\n%s", out)
+	}
+}
@@ -0,0 +1,102 @@
+package diff_test
+
+import (
+	"testing"
+
+	"github.com/avgvstvs96/differential/internal/diff"
+)
+
+func TestPartialPatch(t *testing.T) {
+	files, err := diff.ParseMultiFileDiff(sparseDiff)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	file := files[0]
+
+	tests := []struct {
+		name     string
+		selected func(diff.DiffLine) bool
+		want     string
+	}{
+		{
+			name: "one replaced line",
+			selected: func(line diff.DiffLine) bool {
+				return line.OldLineNo == 5 || line.NewLineNo == 5
+			},
+			want: "--- a/a.go\n+++ b/a.go\n" +
+				"@@ -1,6 +1,6 @@\n line1\n old2\n line3\n line4\n-old5\n+new5\n line6\n",
+		},
+		{
+			name: "removal only",
+			selected: func(line diff.DiffLine) bool {
+				return line.Kind == diff.LineRemoved && line.OldLineNo == 2
+			},
+			want: "--- a/a.go\n+++ b/a.go\n" +
+				"@@ -1,6 +1,5 @@\n line1\n-old2\n line3\n line4\n old5\n line6\n",
+		},
+		{
+			name: "later hunk shifted by earlier selection",
+			selected: func(line diff.DiffLine) bool {
+				return line.Kind == diff.LineRemoved && line.OldLineNo == 2 || line.NewLineNo == 21
+			},
+			want: "--- a/a.go\n+++ b/a.go\n" +
+				"@@ -1,6 +1,5 @@\n line1\n-old2\n line3\n line4\n old5\n line6\n" +
+				"@@ -20,2 +19,3 @@\n line20\n+new21\n line22\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patch, err := diff.PartialPatch(file, tt.selected)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if patch != tt.want {
+				t.Errorf("patch =\n%s\nwant\n%s", patch, tt.want)
+			}
+		})
+	}
+
+	if _, err := diff.PartialPatch(file, func(diff.DiffLine) bool { return false }); err == nil {
+		t.Error("expected an error when nothing is selected")
+	}
+}
+
+func TestPartialPatchDeletedFile(t *testing.T) {
+	files, err := diff.ParseMultiFileDiff("diff --git a/gone.txt b/gone.txt\ndeleted file mode 100644\n" +
+		"--- a/gone.txt\n+++ /dev/null\n@@ -1,2 +0,0 @@\n-one\n-two\n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	all, err := diff.PartialPatch(files[0], func(diff.DiffLine) bool { return true })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "--- a/gone.txt\n+++ /dev/null\n@@ -1,2 +0,0 @@\n-one\n-two\n"; all != want {
+		t.Errorf("patch =\n%s\nwant\n%s", all, want)
+	}
+
+	// Keeping a line turns the deletion into an edit
+	first, err := diff.PartialPatch(files[0], func(line diff.DiffLine) bool { return line.OldLineNo == 1 })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "--- a/gone.txt\n+++ b/gone.txt\n@@ -1,2 +1,1 @@\n-one\n two\n"; first != want {
+		t.Errorf("patch =\n%s\nwant\n%s", first, want)
+	}
+}
+
+func TestPartialPatchNoNewline(t *testing.T) {
+	input := "--- a/f.txt\n+++ b/f.txt\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n"
+	result, err := diff.ParseUnifiedDiff(input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	patch, err := diff.PartialPatch(result, func(diff.DiffLine) bool { return true })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if patch != input {
+		t.Errorf("patch = %q, want %q", patch, input)
+	}
+}
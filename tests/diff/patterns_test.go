@@ -0,0 +1,79 @@
+package diff_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/avgvstvs96/differential/internal/diff"
+)
+
+const sweepDiff = `diff --git a/a.go b/a.go
+--- a/a.go
++++ b/a.go
+@@ -1,3 +1,3 @@
+ func a() {
+-	log.Printf("a")
++	slog.Info("a")
+ }
+@@ -10,3 +10,3 @@
+ func b() {
+-	return nil
++	return err
+ }
+diff --git a/b.go b/b.go
+--- a/b.go
++++ b/b.go
+@@ -5,3 +5,3 @@
+ 	if x {
+-		log.Printf("a")
++		slog.Info("a")
+ 	}
+`
+
+func TestChangePatterns(t *testing.T) {
+	files, err := diff.ParseMultiFileDiff(sweepDiff)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	patterns := diff.ChangePatterns(files)
+	if len(patterns) != 2 {
+		t.Fatalf("expected 2 patterns, got %d", len(patterns))
+	}
+
+	// Context and indentation don't split the sweep
+	sweep := patterns[0]
+	if sweep.Hunks != 2 || strings.Join(sweep.Files, ",") != "a.go,b.go" {
+		t.Errorf("got %d hunks in %v, want 2 in a.go and b.go", sweep.Hunks, sweep.Files)
+	}
+	if len(sweep.Removed) != 1 || sweep.Removed[0] != `log.Printf("a")` || sweep.Added[0] != `slog.Info("a")` {
+		t.Errorf("got lines %q -> %q", sweep.Removed, sweep.Added)
+	}
+	if patterns[1].Hunks != 1 {
+		t.Errorf("expected the other change once, got %d", patterns[1].Hunks)
+	}
+}
+
+func TestMarkApproved(t *testing.T) {
+	files, err := diff.ParseMultiFileDiff(sweepDiff)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sweep := diff.ChangePatterns(files)[0]
+
+	diff.MarkApproved(files, map[string]bool{sweep.Key: true})
+	if !files[0].Hunks[0].Approved || files[0].Hunks[1].Approved {
+		t.Errorf("expected only the first hunk of a.go to be approved")
+	}
+	if files[0].SkipReason != "" || files[1].SkipReason != diff.SkipApproved {
+		t.Errorf("got skip reasons %q and %q", files[0].SkipReason, files[1].SkipReason)
+	}
+	out := diff.RenderUnifiedDiff(files[0], diff.RenderOptions{})
+	if !strings.Contains(out, "· approved") || strings.Contains(out, "slog") {
+		t.Errorf("expected the approved hunk to be folded, got:\n%s", out)
+	}
+
+	diff.MarkApproved(files, nil)
+	if files[0].Hunks[0].Approved || files[1].SkipReason != "" {
+		t.Errorf("expected approvals to be taken back")
+	}
+}
@@ -0,0 +1,86 @@
+package diff_test
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/avgvstvs96/differential/internal/diff"
+)
+
+// numberedLines returns lines 1 to n of a file, with the lines in changed
+// replaced
+func numberedLines(n int, changed map[int]string) string {
+	var sb strings.Builder
+	for i := 1; i <= n; i++ {
+		if line, ok := changed[i]; ok {
+			sb.WriteString(line)
+		} else {
+			fmt.Fprintf(&sb, "line %d\n", i)
+		}
+	}
+	return sb.String()
+}
+
+func TestRegenerateContext(t *testing.T) {
+	oldText := numberedLines(30, nil)
+	newText := numberedLines(30, map[int]string{5: "five\n", 15: "", 16: "line 16\nsixteen\n", 28: "twenty-eight\n"})
+	load := func(path, id string) ([]byte, error) { return []byte(newText), nil }
+
+	// Regenerated hunks are those of a diff made with the context asked for
+	for _, contextLines := range []int{0, 1, 3, 5, 20} {
+		files, err := diff.ParseMultiFileDiff(diff.UnifiedDiff("a/f.txt", "b/f.txt", oldText, newText, 3))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := diff.RegenerateContext(files[0], contextLines, load); err != nil {
+			t.Fatalf("context %d: unexpected error: %v", contextLines, err)
+		}
+		want, err := diff.ParseUnifiedDiff(diff.UnifiedDiff("a/f.txt", "b/f.txt", oldText, newText, contextLines))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(files[0].Hunks, want.Hunks) {
+			t.Errorf("context %d: got hunks\n%+v\nwant\n%+v", contextLines, files[0].Hunks, want.Hunks)
+		}
+	}
+}
+
+func TestRegenerateContextErrors(t *testing.T) {
+	oldText := numberedLines(30, nil)
+	newText := numberedLines(30, map[int]string{5: "five\n", 25: "twenty-five\n"})
+	parse := func() *diff.DiffResult {
+		files, err := diff.ParseMultiFileDiff(diff.UnifiedDiff("a/f.txt", "b/f.txt", oldText, newText, 3))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return files[0]
+	}
+
+	// The file changed since the diff was made
+	edited := numberedLines(30, map[int]string{5: "five\n", 25: "twenty-five\n", 26: "edited\n"})
+	file := parse()
+	hunks := file.Hunks
+	err := diff.RegenerateContext(file, 5, func(path, id string) ([]byte, error) { return []byte(edited), nil })
+	if err == nil || !strings.Contains(err.Error(), "no longer matches") {
+		t.Errorf("expected a mismatch error, got %v", err)
+	}
+	if !reflect.DeepEqual(file.Hunks, hunks) {
+		t.Error("expected the hunks to be left alone")
+	}
+
+	// A hunk was left out, which the old version tells
+	file = parse()
+	file.OldFile, file.OldIndex = "old.txt", "1234567"
+	file.Hunks = file.Hunks[1:]
+	err = diff.RegenerateContext(file, 5, func(path, id string) ([]byte, error) {
+		if path == "old.txt" {
+			return []byte(oldText), nil
+		}
+		return []byte(newText), nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "outside its hunks") {
+		t.Errorf("expected an error for a diff missing a hunk, got %v", err)
+	}
+}
@@ -0,0 +1,167 @@
+package diff_test
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/avgvstvs96/differential/internal/diff"
+)
+
+// largeDiff builds a Go diff with n lines spread over hunks of mixed
+// context, removed and added lines
+func largeDiff(n int) string {
+	var sb strings.Builder
+	sb.WriteString("--- a/large.go\n+++ b/large.go\n")
+	line := 1
+	for written := 0; written < n; written += 10 {
+		sb.WriteString(fmt.Sprintf("@@ -%d,8 +%d,8 @@\n", line, line))
+		for i := 0; i < 3; i++ {
+			sb.WriteString(fmt.Sprintf(" \tx%d := compute(%d, \"value\")\n", line+i, i))
+		}
+		sb.WriteString(fmt.Sprintf("-\treturn fmt.Errorf(\"failed %d: %%w\", err)\n", line))
+		sb.WriteString(fmt.Sprintf("-\tlog.Printf(\"old %d\")\n", line))
+		sb.WriteString(fmt.Sprintf("+\treturn fmt.Errorf(\"failed to run %d: %%w\", err)\n", line))
+		sb.WriteString(fmt.Sprintf("+\tlog.Printf(\"new %d\")\n", line))
+		for i := 0; i < 3; i++ {
+			sb.WriteString(fmt.Sprintf(" \ty%d := compute(%d, \"value\")\n", line+i, i))
+		}
+		line += 20
+	}
+	return sb.String()
+}
+
+// largeHunk builds a Go diff with a single hunk of n lines, alternating
+// context, removed and added lines
+func largeHunk(n int) string {
+	var sb strings.Builder
+	sb.WriteString("--- a/large.go\n+++ b/large.go\n")
+	sb.WriteString(fmt.Sprintf("@@ -1,%d +1,%d @@\n", n/3*2, n/3*2))
+	for i := 0; i < n/3; i++ {
+		sb.WriteString(fmt.Sprintf(" \tx%d := compute(%d, \"value\")\n", i, i))
+		sb.WriteString(fmt.Sprintf("-\tlog.Printf(\"old %d\")\n", i))
+		sb.WriteString(fmt.Sprintf("+\tlog.Printf(\"new %d\")\n", i))
+	}
+	return sb.String()
+}
+
+var benchOptions = diff.RenderOptions{
+	Width:           160,
+	ShowLineNumbers: true,
+	TabWidth:        4,
+}
+
+// benchmarkRender measures rendering a diff with a fresh renderer, as on the
+// first frame or in pipe mode
+func benchmarkRender(b *testing.B, lines int, viewMode diff.ViewMode) {
+	result, err := diff.ParseUnifiedDiff(largeDiff(lines))
+	if err != nil {
+		b.Fatalf("unexpected error: %v", err)
+	}
+	opts := benchOptions
+	opts.ViewMode = viewMode
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		diff.NewRenderer(opts).Render(result)
+	}
+}
+
+// benchmarkRerender measures rendering the same diff again with the same
+// renderer, as on every TUI frame
+func benchmarkRerender(b *testing.B, lines int, viewMode diff.ViewMode) {
+	result, err := diff.ParseUnifiedDiff(largeDiff(lines))
+	if err != nil {
+		b.Fatalf("unexpected error: %v", err)
+	}
+	opts := benchOptions
+	opts.ViewMode = viewMode
+	r := diff.NewRenderer(opts)
+	r.Render(result)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r.RenderFiles([]*diff.DiffResult{result})
+	}
+}
+
+func BenchmarkRenderUnified1k(b *testing.B)     { benchmarkRender(b, 1000, diff.ViewUnified) }
+func BenchmarkRenderUnified10k(b *testing.B)    { benchmarkRender(b, 10000, diff.ViewUnified) }
+func BenchmarkRenderSideBySide1k(b *testing.B)  { benchmarkRender(b, 1000, diff.ViewSideBySide) }
+func BenchmarkRenderSideBySide10k(b *testing.B) { benchmarkRender(b, 10000, diff.ViewSideBySide) }
+func BenchmarkRenderUnifiedHunk10k(b *testing.B) {
+	result, err := diff.ParseUnifiedDiff(largeHunk(10000))
+	if err != nil {
+		b.Fatalf("unexpected error: %v", err)
+	}
+	opts := benchOptions
+	opts.ViewMode = diff.ViewUnified
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		diff.NewRenderer(opts).Render(result)
+	}
+}
+
+func BenchmarkRerenderUnified10k(b *testing.B)    { benchmarkRerender(b, 10000, diff.ViewUnified) }
+func BenchmarkRerenderSideBySide10k(b *testing.B) { benchmarkRerender(b, 10000, diff.ViewSideBySide) }
+
+// TestRendererRerenderBudget checks that re-rendering a 10k line diff stays
+// within the 100ms frame budget
+func TestRendererRerenderBudget(t *testing.T) {
+	result, err := diff.ParseUnifiedDiff(largeDiff(10000))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r := diff.NewRenderer(benchOptions)
+	first := r.Render(result)
+
+	start := time.Now()
+	second := r.Render(result)
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("re-render took %v, want under 100ms", elapsed)
+	}
+	if first != second {
+		t.Error("re-render produced different output")
+	}
+
+	r.Reset()
+	if third := r.Render(result); third != first {
+		t.Error("render after Reset produced different output")
+	}
+}
+
+func BenchmarkParseUnifiedDiff10k(b *testing.B) {
+	text := largeDiff(10000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := diff.ParseUnifiedDiff(text); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// TestRendererParallelHunk checks that big hunks rendered by the worker pool
+// match the sequential output line for line
+func TestRendererParallelHunk(t *testing.T) {
+	result, err := diff.ParseUnifiedDiff(largeHunk(3000))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	prev := runtime.GOMAXPROCS(1)
+	sequential := diff.NewRenderer(benchOptions).Render(result)
+	runtime.GOMAXPROCS(4)
+	parallel := diff.NewRenderer(benchOptions).Render(result)
+	runtime.GOMAXPROCS(prev)
+
+	if parallel != sequential {
+		t.Error("parallel render differs from sequential render")
+	}
+}
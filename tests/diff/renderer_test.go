@@ -0,0 +1,187 @@
+package diff_test
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/avgvstvs96/differential/internal/diff"
+	"github.com/avgvstvs96/differential/internal/themes"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+func TestRenderWithExplicitTheme(t *testing.T) {
+	result, err := diff.ParseUnifiedDiff(largeDiff(200))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	names := []string{"dracula", "nord", "github"}
+	expected := make(map[string]string)
+	for _, name := range names {
+		theme, err := themes.Default().Resolve(name)
+		if err != nil {
+			t.Fatalf("failed to resolve %s: %v", name, err)
+		}
+		opts := benchOptions
+		opts.Theme = theme
+		expected[name] = diff.NewRenderer(opts).Render(result)
+	}
+	if expected["dracula"] == expected["nord"] {
+		t.Fatal("expected different output for different themes")
+	}
+
+	// Render in all themes at once while the current theme changes
+	var wg sync.WaitGroup
+	for _, name := range names {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			theme, _ := themes.Default().Resolve(name)
+			opts := benchOptions
+			opts.Theme = theme
+			if got := diff.NewRenderer(opts).Render(result); got != expected[name] {
+				t.Errorf("concurrent %s render differs", name)
+			}
+		}(name)
+	}
+	for _, name := range names {
+		if err := themes.Default().Set(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+}
+
+func TestRenderDimContext(t *testing.T) {
+	result, err := diff.ParseUnifiedDiff("--- a/main.go\n+++ b/main.go\n@@ -1,2 +1,2 @@\n func main() {}\n-var a = 1\n+var a = 2\n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	theme, err := themes.Default().Resolve("dracula")
+	if err != nil {
+		t.Fatalf("failed to resolve theme: %v", err)
+	}
+	normal := diff.NewRenderer(diff.RenderOptions{Theme: theme}).Render(result)
+	dimmed := diff.NewRenderer(diff.RenderOptions{Theme: theme, DimContext: true}).Render(result)
+
+	if diff.StripANSI(normal) != diff.StripANSI(dimmed) {
+		t.Errorf("dimming changed the visible text:\n%s\nwant:\n%s", diff.StripANSI(dimmed), diff.StripANSI(normal))
+	}
+	// Dimmed context lines drop their syntax colors
+	if !strings.Contains(normal, "\x1b[38;2;") || strings.Contains(dimmed, "\x1b[38;2;") {
+		t.Errorf("expected syntax colors only without dimming:\n%q\n%q", normal, dimmed)
+	}
+}
+
+func TestRenderPlainColumns(t *testing.T) {
+	result, err := diff.ParseUnifiedDiff("--- a/main.go\n+++ b/main.go\n@@ -1,3 +1,3 @@\n func main() {}\n-var a = 1\n+var a = 2\n-var b = 1\n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	out := diff.NewRenderer(diff.RenderOptions{Width: 40, ViewMode: diff.ViewSideBySide, PlainColumns: true}).Render(result)
+	want := []string{
+		"func main() {} func main() {}",
+		"var a = 1 | var a = 2",
+		"var b = 1 <",
+	}
+	lines := strings.Split(diff.StripANSI(out), "\n")
+	if len(lines) < 4 {
+		t.Fatalf("expected a hunk header and 3 rows, got:\n%s", out)
+	}
+	for i, w := range want {
+		line := lines[i+1]
+		if strings.HasSuffix(line, " ") {
+			t.Errorf("row %d has trailing spaces: %q", i, line)
+		}
+		if got := strings.Join(strings.Fields(line), " "); got != w {
+			t.Errorf("row %d = %q, want %q", i, got, w)
+		}
+	}
+	if strings.Contains(out, "┃") {
+		t.Errorf("expected ASCII separators only, got:\n%s", out)
+	}
+}
+
+func TestRenderAccessibility(t *testing.T) {
+	result, err := diff.ParseUnifiedDiff("--- a/main.go\n+++ b/main.go\n@@ -1,2 +1,2 @@\n func main() {}\n-var a = 1\n+var a = 2\n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	theme, err := themes.Default().Resolve("deuteranopia")
+	if err != nil {
+		t.Fatalf("failed to resolve theme: %v", err)
+	}
+
+	for _, view := range []diff.ViewMode{diff.ViewUnified, diff.ViewSideBySide} {
+		opts := diff.RenderOptions{Width: 60, ViewMode: view, Theme: theme}
+		normal := diff.NewRenderer(opts).Render(result)
+		opts.Accessibility = true
+		accessible := diff.NewRenderer(opts).Render(result)
+
+		if strings.Contains(normal, "\x1b[4m") || !strings.Contains(accessible, "\x1b[4m") {
+			t.Errorf("view %v: expected removed lines underlined only with accessibility", view)
+		}
+		lines := strings.Split(diff.StripANSI(accessible), "\n")
+		for i, line := range lines {
+			if w := diff.VisibleLength(line); line != "" && w != diff.VisibleLength(strings.Split(diff.StripANSI(normal), "\n")[i]) {
+				t.Errorf("view %v: row %d is %d wide with accessibility", view, i, w)
+			}
+		}
+		if view == diff.ViewSideBySide && !strings.Contains(diff.StripANSI(accessible), "▎var a = 2") {
+			t.Errorf("expected a bar before the added line:\n%s", diff.StripANSI(accessible))
+		}
+	}
+}
+
+func TestRenderStyle(t *testing.T) {
+	result, err := diff.ParseUnifiedDiff("--- a/main.go\n+++ b/main.go\n@@ -1,2 +1,2 @@\n func main() {}\n-var a = 1\n+var a = 2\n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	diff.HighlightIntralineChanges(&result.Hunks[0])
+
+	profile := lipgloss.ColorProfile()
+	lipgloss.SetColorProfile(termenv.TrueColor)
+	defer lipgloss.SetColorProfile(profile)
+
+	theme, err := themes.Default().Resolve("dracula")
+	if err != nil {
+		t.Fatalf("failed to resolve theme: %v", err)
+	}
+	removed := "38;2;255;85;85m"
+	for _, test := range []struct {
+		name        string
+		backgrounds bool // Whether lines are filled
+		colored     bool // Whether removed text is in the removed color
+	}{
+		{"background", true, false},
+		{"foreground", false, true},
+		{"minimal", false, false},
+	} {
+		style, err := diff.ParseRenderStyle(test.name)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for _, view := range []diff.ViewMode{diff.ViewUnified, diff.ViewSideBySide} {
+			out := diff.NewRenderer(diff.RenderOptions{Width: 60, ViewMode: view, ShowLineNumbers: true, Theme: theme, Style: style}).Render(result)
+			if got := strings.Contains(out, "48;2;"); got != test.backgrounds {
+				t.Errorf("%s, view %v: backgrounds %t, want %t", test.name, view, got, test.backgrounds)
+			}
+			// The text of the removed line, after its marker or bar
+			if got := strings.Contains(out, removed+"var a = "); got != test.colored {
+				t.Errorf("%s, view %v: colored text %t, want %t:\n%q", test.name, view, got, test.colored, out)
+			}
+			if !test.backgrounds && !strings.Contains(out, "\x1b[1;4m1") {
+				t.Errorf("%s, view %v: expected the changed word bold and underlined", test.name, view)
+			}
+		}
+	}
+
+	if _, err := diff.ParseRenderStyle("outline"); err == nil {
+		t.Error("expected an error for an unknown style")
+	}
+}
@@ -0,0 +1,92 @@
+package diff_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/avgvstvs96/differential/internal/diff"
+	"github.com/avgvstvs96/differential/internal/themes"
+)
+
+const searchDiff = `diff --git a/a.go b/a.go
+index 1111111..2222222 100644
+--- a/a.go
++++ b/a.go
+@@ -1,3 +1,3 @@
+ package a
+-func Old() {}
++func New() {}
+@@ -10,3 +10,3 @@
+ // TODO: keep
+-x := 1
++x := 2
+diff --git a/b.go b/b.go
+index 3333333..4444444 100644
+--- a/b.go
++++ b/b.go
+@@ -1,2 +1,2 @@
+ package b
+-y := 1
++y := 2
+`
+
+func TestFilterChanges(t *testing.T) {
+	files, err := diff.ParseMultiFileDiff(searchDiff)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	files = diff.FilterChanges(files, regexp.MustCompile(`func New`))
+	if len(files) != 1 || files[0].NewFile != "a.go" {
+		t.Fatalf("expected only a.go to be kept, got %d file(s)", len(files))
+	}
+	if len(files[0].Hunks) != 1 || !strings.HasPrefix(files[0].Hunks[0].Header, "@@ -1,3") {
+		t.Errorf("expected only the first hunk to be kept, got %+v", files[0].Hunks)
+	}
+}
+
+func TestFilterChangesIgnoresContext(t *testing.T) {
+	files, err := diff.ParseMultiFileDiff(searchDiff)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if files = diff.FilterChanges(files, regexp.MustCompile(`TODO`)); len(files) != 0 {
+		t.Errorf("expected matches in context lines to be ignored, got %d file(s)", len(files))
+	}
+}
+
+func TestRenderHighlightsMatches(t *testing.T) {
+	files, err := diff.ParseMultiFileDiff(searchDiff)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	opts := benchOptions
+	plain := diff.NewRenderer(opts).Render(files[0])
+	opts.Highlight = regexp.MustCompile(`New`)
+	highlighted := diff.NewRenderer(opts).Render(files[0])
+
+	if plain == highlighted {
+		t.Fatal("expected highlighting to change the output")
+	}
+	if got := diff.StripANSI(highlighted); got != diff.StripANSI(plain) {
+		t.Errorf("highlighting changed the visible text:\n%s\nwant:\n%s", got, diff.StripANSI(plain))
+	}
+	selection := opts.Theme
+	if selection == nil {
+		selection = themes.GetCurrentTheme()
+	}
+	if !strings.Contains(highlighted, "\x1b[1;48;2;") {
+		t.Errorf("expected the match to be marked with the selection color %s", selection.Selection)
+	}
+
+	// Themes without a hex selection color fall back to reverse video
+	ansi := *selection
+	ansi.Selection = "8"
+	opts.Theme = &ansi
+	if !strings.Contains(diff.NewRenderer(opts).Render(files[0]), "\x1b[1;7m") {
+		t.Error("expected the match to be marked in reverse video")
+	}
+}
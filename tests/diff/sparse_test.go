@@ -0,0 +1,95 @@
+package diff_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/avgvstvs96/differential/internal/diff"
+)
+
+const sparseDiff = `diff --git a/a.go b/a.go
+index 1111111..2222222 100644
+--- a/a.go
++++ b/a.go
+@@ -1,6 +1,6 @@ package a
+ line1
+-old2
++new2
+ line3
+ line4
+-old5
++new5
+ line6
+@@ -20,2 +20,3 @@ func tail() {
+ line20
++new21
+ line22
+`
+
+// renderPlain renders with fn and strips the ANSI codes
+func renderPlain(t *testing.T, fn func(*diff.Renderer, *diff.DiffResult) (string, error)) string {
+	t.Helper()
+	files, err := diff.ParseMultiFileDiff(sparseDiff)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	output, err := fn(diff.NewRenderer(diff.RenderOptions{}), files[0])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return diff.ParseANSI(output).Plain()
+}
+
+func TestRenderHunk(t *testing.T) {
+	output := renderPlain(t, func(r *diff.Renderer, file *diff.DiffResult) (string, error) {
+		return r.RenderHunk(file, 1)
+	})
+	if !strings.Contains(output, "@@ -20,2 +20,3 @@ func tail() {") || !strings.Contains(output, "new21") {
+		t.Errorf("expected the second hunk, got:\n%s", output)
+	}
+	if strings.Contains(output, "new2\n") || strings.Contains(output, "line1") {
+		t.Errorf("expected only the second hunk, got:\n%s", output)
+	}
+
+	files, _ := diff.ParseMultiFileDiff(sparseDiff)
+	if _, err := diff.NewRenderer(diff.RenderOptions{}).RenderHunk(files[0], 2); err == nil {
+		t.Error("expected an error for a missing hunk")
+	}
+}
+
+func TestRenderLines(t *testing.T) {
+	// Line 5 takes the line removed before it
+	output := renderPlain(t, func(r *diff.Renderer, file *diff.DiffResult) (string, error) {
+		return r.RenderLines(file, 4, 5)
+	})
+	if !strings.Contains(output, "@@ -4,2 +4,2 @@ package a") {
+		t.Errorf("expected a rewritten header, got:\n%s", output)
+	}
+	for _, want := range []string{"line4", "old5", "new5"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q in:\n%s", want, output)
+		}
+	}
+	for _, unwanted := range []string{"line3", "line6", "new21"} {
+		if strings.Contains(output, unwanted) {
+			t.Errorf("unexpected %q in:\n%s", unwanted, output)
+		}
+	}
+
+	// A range spanning both hunks keeps a part of each
+	output = renderPlain(t, func(r *diff.Renderer, file *diff.DiffResult) (string, error) {
+		return r.RenderLines(file, 6, 21)
+	})
+	if !strings.Contains(output, "@@ -6,1 +6,1 @@") || !strings.Contains(output, "@@ -20,1 +20,2 @@") {
+		t.Errorf("expected both hunks cut to the range, got:\n%s", output)
+	}
+
+	files, _ := diff.ParseMultiFileDiff(sparseDiff)
+	renderer := diff.NewRenderer(diff.RenderOptions{})
+	if _, err := renderer.RenderLines(files[0], 10, 15); err == nil {
+		t.Error("expected an error for lines outside the diff")
+	}
+	if _, err := renderer.RenderLines(files[0], 5, 4); err == nil {
+		t.Error("expected an error for an inverted range")
+	}
+}
@@ -0,0 +1,145 @@
+package diff_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/avgvstvs96/differential/internal/diff"
+)
+
+const statsDiff = `diff --git a/add.txt b/add.txt
+new file mode 100644
+index 0000000..3f9e1a8
+--- /dev/null
++++ b/add.txt
+@@ -0,0 +1 @@
++n
+diff --git a/del.txt b/del.txt
+deleted file mode 100644
+index 587be6b..0000000
+--- a/del.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-x
+diff --git a/old.txt b/new.txt
+similarity index 85%
+rename from old.txt
+rename to new.txt
+index 0e7a3b1..5c1d2ee 100644
+--- a/old.txt
++++ b/new.txt
+@@ -5,2 +5,3 @@ d
+ e
+ f
++g
+diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -1,2 +1,2 @@
+ package main
+-// old
++// new
+`
+
+func TestStats(t *testing.T) {
+	files, err := diff.ParseMultiFileDiff(statsDiff)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	files[3].SkipReason = diff.SkipFiltered
+
+	stats := diff.Stats(files)
+	expected := []diff.FileStat{
+		{Path: "add.txt", Status: diff.StatusAdded, Additions: 1},
+		{Path: "del.txt", Status: diff.StatusDeleted, Deletions: 1},
+		{Path: "new.txt", OldPath: "old.txt", Status: diff.StatusRenamed, Additions: 1},
+	}
+	if len(stats) != len(expected) {
+		t.Fatalf("expected %d stats, got %d: %+v", len(expected), len(stats), stats)
+	}
+	for i := range expected {
+		if stats[i] != expected[i] {
+			t.Errorf("stat %d: expected %+v, got %+v", i, expected[i], stats[i])
+		}
+	}
+}
+
+func TestStatPureRename(t *testing.T) {
+	result, err := diff.ParseUnifiedDiff("diff --git a/a.go b/b.go\nsimilarity index 100%\nrename from a.go\nrename to b.go\n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	stat := result.Stat()
+	if stat.Status != diff.StatusRenamed || stat.OldPath != "a.go" || stat.Path != "b.go" {
+		t.Errorf("unexpected stat %+v", stat)
+	}
+}
+
+func TestStatCopy(t *testing.T) {
+	result, err := diff.ParseUnifiedDiff("diff --git a/a.go b/b.go\nsimilarity index 90%\ncopy from a.go\ncopy to b.go\n--- a/a.go\n+++ b/b.go\n@@ -1 +1,2 @@\n x\n+y\n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	stat := result.Stat()
+	if stat.Status != diff.StatusCopied || stat.OldPath != "a.go" || stat.Additions != 1 {
+		t.Errorf("unexpected stat %+v", stat)
+	}
+}
+
+func TestHunkStat(t *testing.T) {
+	files, err := diff.ParseMultiFileDiff(sparseDiff)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	hunks := files[0].Hunks
+	if got, want := hunks[0].Stat(), (diff.HunkStat{Added: 2, Removed: 2, Modified: 2}); got != want {
+		t.Errorf("first hunk = %+v, want %+v", got, want)
+	}
+	if got, want := hunks[1].Stat(), (diff.HunkStat{Added: 1}); got != want {
+		t.Errorf("second hunk = %+v, want %+v", got, want)
+	}
+
+	output := diff.ParseANSI(diff.NewRenderer(diff.RenderOptions{HunkStats: true}).RenderUnified(files[0])).Plain()
+	for _, want := range []string{"@@ -1,6 +1,6 @@ +2 -2 (2 modified) package a", "@@ -20,2 +20,3 @@ +1 -0 func tail() {"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+}
+
+func TestReviewSizeOf(t *testing.T) {
+	file := func(lines int) diff.FileStat {
+		return diff.FileStat{Path: "f", Additions: lines / 2, Deletions: lines - lines/2}
+	}
+	repeat := func(n, lines int) []diff.FileStat {
+		var stats []diff.FileStat
+		for i := 0; i < n; i++ {
+			stats = append(stats, file(lines))
+		}
+		return stats
+	}
+
+	for _, tt := range []struct {
+		name  string
+		stats []diff.FileStat
+		want  diff.ReviewSize
+	}{
+		{"empty", nil, diff.SizeXS},
+		{"one small file", repeat(1, 9), diff.SizeXS},
+		{"ten lines", repeat(1, 10), diff.SizeS},
+		{"a hundred lines", repeat(1, 100), diff.SizeL},
+		{"five hundred lines", repeat(1, 500), diff.SizeXL},
+		{"many small files", repeat(4, 1), diff.SizeM},
+		{"lines outweigh files", repeat(2, 60), diff.SizeL},
+		{"files outweigh lines", repeat(26, 1), diff.SizeXL},
+	} {
+		if got := diff.ReviewSizeOf(tt.stats); got != tt.want {
+			t.Errorf("%s: expected %s, got %s", tt.name, tt.want, got)
+		}
+	}
+
+	if diff.SizeM.String() != "M" || diff.SizeM.Estimate() != "~30 min" {
+		t.Errorf("unexpected label %q or estimate %q", diff.SizeM, diff.SizeM.Estimate())
+	}
+}
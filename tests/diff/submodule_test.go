@@ -0,0 +1,117 @@
+package diff_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/avgvstvs96/differential/internal/diff"
+)
+
+const submoduleDiff = `diff --git a/lib b/lib
+index a2db8a8..75dd2a4 160000
+--- a/lib
++++ b/lib
+@@ -1 +1 @@
+-Subproject commit a2db8a85cd88071d11d6b4739fa64b93196f8f09
++Subproject commit 75dd2a4c9a0653d447751e75315952f8e5ada01a-dirty
+`
+
+const lfsDiff = `diff --git a/big.bin b/big.bin
+index 60c8d8a..29123b1 100644
+--- a/big.bin
++++ b/big.bin
+@@ -1,3 +1,3 @@
+ version https://git-lfs.github.com/spec/v1
+-oid sha256:4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393
+-size 12345
++oid sha256:1111114614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393
++size 20000
+`
+
+func TestParseSubmodule(t *testing.T) {
+	result, err := diff.ParseUnifiedDiff(submoduleDiff)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := diff.SubmoduleChange{
+		OldCommit: "a2db8a85cd88071d11d6b4739fa64b93196f8f09",
+		NewCommit: "75dd2a4c9a0653d447751e75315952f8e5ada01a",
+		Dirty:     true,
+	}
+	if result.Submodule == nil || *result.Submodule != want {
+		t.Fatalf("Submodule = %+v, want %+v", result.Submodule, want)
+	}
+
+	var gotRange string
+	opts := benchOptions
+	opts.SubmoduleLog = func(path, from, to string) ([]string, error) {
+		gotRange = path + " " + from + ".." + to
+		return []string{"75dd2a4 Fix the frobnicator"}, nil
+	}
+	output := diff.StripANSI(diff.NewRenderer(opts).Render(result))
+	for _, want := range []string{"Submodule lib", "a2db8a8 → 75dd2a4", "Fix the frobnicator"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q in output:\n%s", want, output)
+		}
+	}
+	if gotRange != "lib "+want.OldCommit+".."+want.NewCommit {
+		t.Errorf("SubmoduleLog called with %q", gotRange)
+	}
+}
+
+func TestParseAddedSubmodule(t *testing.T) {
+	result, err := diff.ParseUnifiedDiff(`diff --git a/lib b/lib
+new file mode 160000
+index 0000000..75dd2a4
+--- /dev/null
++++ b/lib
+@@ -0,0 +1 @@
++Subproject commit 75dd2a4c9a0653d447751e75315952f8e5ada01a
+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Submodule == nil || result.Submodule.OldCommit != "" || result.Submodule.NewCommit == "" {
+		t.Fatalf("unexpected submodule change %+v", result.Submodule)
+	}
+	if output := diff.StripANSI(diff.NewRenderer(benchOptions).Render(result)); !strings.Contains(output, "added at 75dd2a4") {
+		t.Errorf("unexpected output:\n%s", output)
+	}
+}
+
+func TestParseLFS(t *testing.T) {
+	result, err := diff.ParseUnifiedDiff(lfsDiff)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.LFS == nil || result.LFS.Old == nil || result.LFS.New == nil {
+		t.Fatalf("expected an LFS change, got %+v", result.LFS)
+	}
+	if result.LFS.Old.Size != 12345 || result.LFS.New.Size != 20000 ||
+		!strings.HasPrefix(result.LFS.New.OID, "sha256:111111") {
+		t.Errorf("unexpected pointers %+v %+v", result.LFS.Old, result.LFS.New)
+	}
+
+	output := diff.StripANSI(diff.NewRenderer(benchOptions).Render(result))
+	for _, want := range []string{"LFS object big.bin", "sha256:4d7a214614ab…", "19.5 KB (+7.5 KB)"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q in output:\n%s", want, output)
+		}
+	}
+}
+
+func TestOrdinaryDiffIsNotSpecial(t *testing.T) {
+	result, err := diff.ParseUnifiedDiff(`--- a/notes.txt
++++ b/notes.txt
+@@ -1,2 +1,2 @@
+ version https://git-lfs.github.com/spec/v1
+-Subproject commit a2db8a85cd88071d11d6b4739fa64b93196f8f09
++something else
+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Submodule != nil || result.LFS != nil {
+		t.Errorf("expected a plain diff, got submodule %+v, LFS %+v", result.Submodule, result.LFS)
+	}
+}
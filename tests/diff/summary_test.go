@@ -0,0 +1,94 @@
+package diff_test
+
+import (
+	"testing"
+
+	"github.com/avgvstvs96/differential/internal/diff"
+)
+
+const summaryDiff = `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -1,2 +1,3 @@
+ package main
+-var a = 1
++var a = 2
++var b = 3
+diff --git a/util.go b/util.go
+index 3333333..4444444 100644
+--- a/util.go
++++ b/util.go
+@@ -1 +1 @@
+-package old
++package util
+diff --git a/run b/run
+new file mode 100755
+index 0000000..5555555
+--- /dev/null
++++ b/run
+@@ -0,0 +1,2 @@
++#!/bin/sh
++echo hi
+diff --git a/data.xyz b/data.xyz
+index 6666666..7777777 100644
+--- a/data.xyz
++++ b/data.xyz
+@@ -1,6 +1,0 @@
+-1
+-2
+-3
+-4
+-5
+-6
+`
+
+func TestSummarize(t *testing.T) {
+	files, err := diff.ParseMultiFileDiff(summaryDiff)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s := diff.Summarize(files, diff.Languages{})
+
+	if s.Files != 4 || s.Additions != 5 || s.Deletions != 8 || s.Hunks != 4 {
+		t.Errorf("unexpected totals %+v", s)
+	}
+
+	want := []diff.LanguageStat{
+		{Name: ".xyz", Files: 1, Deletions: 6},
+		{Name: "Go", Files: 2, Additions: 3, Deletions: 2},
+		{Name: "Bash", Files: 1, Additions: 2},
+	}
+	if len(s.Languages) != len(want) {
+		t.Fatalf("expected %d languages, got %+v", len(want), s.Languages)
+	}
+	for i, lang := range want {
+		if s.Languages[i] != lang {
+			t.Errorf("language %d: expected %+v, got %+v", i, lang, s.Languages[i])
+		}
+	}
+
+	if len(s.Largest) != 4 || s.Largest[0].Path != "data.xyz" || s.Largest[1].Path != "main.go" {
+		t.Errorf("unexpected largest files %+v", s.Largest)
+	}
+
+	// Hunks of 3 and 2 changed lines share the 2-5 bucket
+	counts := map[int]int{}
+	for _, bucket := range s.HunkSizes {
+		counts[bucket.Min] = bucket.Hunks
+	}
+	if counts[2] != 3 || counts[6] != 1 || counts[1] != 0 {
+		t.Errorf("unexpected hunk sizes %+v", s.HunkSizes)
+	}
+}
+
+func TestSummarize_Filtered(t *testing.T) {
+	files, err := diff.ParseMultiFileDiff(summaryDiff)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	files[0].SkipReason = diff.SkipFiltered
+	if s := diff.Summarize(files, diff.Languages{}); s.Files != 3 || s.Additions != 3 {
+		t.Errorf("expected filtered files left out, got %+v", s)
+	}
+}
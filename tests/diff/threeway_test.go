@@ -0,0 +1,55 @@
+package diff_test
+
+import (
+	"testing"
+
+	"github.com/avgvstvs96/differential/internal/diff"
+)
+
+func TestComputeThreeWay(t *testing.T) {
+	base := "a\nb\nc\nd\n"
+	ours := "a\nB ours\nc\nd\nnew\n"
+	theirs := "a\nB theirs\nc\n"
+
+	result := diff.ComputeThreeWay("base", "ours", "theirs", base, ours, theirs)
+
+	if len(result.Rows) != 5 {
+		t.Fatalf("expected 5 rows, got %d", len(result.Rows))
+	}
+
+	// Row for "b" was replaced on both sides
+	row := result.Rows[1]
+	if row.Base == nil || row.Base.Content != "b" || row.Base.Kind != diff.LineRemoved {
+		t.Fatalf("expected base row 'b' marked as changed, got %+v", row.Base)
+	}
+	if row.Ours == nil || row.Ours.Content != "B ours" || row.Ours.Kind != diff.LineAdded {
+		t.Errorf("expected ours 'B ours', got %+v", row.Ours)
+	}
+	if row.Theirs == nil || row.Theirs.Content != "B theirs" || row.Theirs.Kind != diff.LineAdded {
+		t.Errorf("expected theirs 'B theirs', got %+v", row.Theirs)
+	}
+	if !row.Conflicting() {
+		t.Error("expected row changed on both sides to be conflicting")
+	}
+
+	// Row for "d" was deleted by theirs only
+	row = result.Rows[3]
+	if row.Ours == nil || row.Ours.Kind != diff.LineContext {
+		t.Errorf("expected ours to keep 'd', got %+v", row.Ours)
+	}
+	if row.Theirs != nil {
+		t.Errorf("expected theirs to delete 'd', got %+v", row.Theirs)
+	}
+	if row.Conflicting() {
+		t.Error("expected row changed on one side not to be conflicting")
+	}
+
+	// Trailing insertion only exists in ours
+	row = result.Rows[4]
+	if row.Base != nil || row.Ours == nil || row.Ours.Content != "new" || row.Theirs != nil {
+		t.Errorf("expected trailing insertion in ours only, got %+v", row)
+	}
+	if row.Ours.NewLineNo != 5 {
+		t.Errorf("expected inserted line number 5, got %d", row.Ours.NewLineNo)
+	}
+}
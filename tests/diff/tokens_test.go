@@ -0,0 +1,60 @@
+package diff_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/avgvstvs96/differential/internal/diff"
+	"github.com/avgvstvs96/differential/internal/themes"
+)
+
+const tokensDiff = `--- a/main.go
++++ b/main.go
+@@ -1,3 +1,3 @@
+ func main() {
+-	old()
++	new()
+ }
+`
+
+func TestSemanticTokens(t *testing.T) {
+	tokens, err := diff.ParseSemanticTokens([]byte(`{"files": {"/home/me/src/main.go": [
+		{"line": 1, "start": 0, "end": 4, "scope": "keyword.declaration"},
+		{"line": 1, "start": 5, "end": 9, "scope": "unknown"}
+	]}}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	result, err := diff.ParseUnifiedDiff(tokensDiff)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	theme := themes.GetCurrentTheme()
+	out := diff.RenderUnifiedDiff(result, diff.RenderOptions{Tokens: tokens, Theme: theme})
+	lines := strings.Split(out, "\n")
+	if len(lines) < 5 {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+
+	// The keyword gets the theme's color; unknown scopes and lines without
+	// tokens stay plain rather than falling back to chroma
+	if !strings.Contains(lines[1], "func\x1b[39m main() {") || !strings.Contains(lines[1], "\x1b[38;2;") {
+		t.Errorf("expected only the keyword to be colored, got %q", lines[1])
+	}
+	if strings.Contains(lines[4], "\x1b[38;2;") {
+		t.Errorf("expected the closing brace to be plain, got %q", lines[4])
+	}
+}
+
+func TestParseSemanticTokensErrors(t *testing.T) {
+	for _, input := range []string{
+		`{"files": [`,
+		`{"files": {"a.go": [{"line": 0, "start": 0, "end": 1, "scope": "keyword"}]}}`,
+		`{"files": {"a.go": [{"line": 1, "start": 4, "end": 2, "scope": "keyword"}]}}`,
+	} {
+		if _, err := diff.ParseSemanticTokens([]byte(input)); err == nil {
+			t.Errorf("expected an error for %s", input)
+		}
+	}
+}
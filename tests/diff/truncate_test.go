@@ -0,0 +1,107 @@
+package diff_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/avgvstvs96/differential/internal/diff"
+)
+
+func TestTruncate(t *testing.T) {
+	files, err := diff.ParseMultiFileDiff(sweepDiff)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	shown, truncation := diff.Truncate(files, diff.Limits{Files: 1})
+	if len(shown) != 1 || truncation == nil || truncation.Reason != diff.TruncatedFiles || truncation.Files != 1 || truncation.Lines != 4 {
+		t.Errorf("got %d files and %+v, want 1 file and 1 file and 4 lines left out", len(shown), truncation)
+	}
+
+	// The line limit cuts the second hunk of a.go short
+	shown, truncation = diff.Truncate(files, diff.Limits{Lines: 6})
+	if len(shown) != 1 || len(shown[0].Hunks) != 2 || len(shown[0].Hunks[1].Lines) != 2 {
+		t.Fatalf("expected a.go cut after 2 lines of its second hunk, got %d files", len(shown))
+	}
+	if truncation.Reason != diff.TruncatedLines || truncation.Files != 2 || truncation.Lines != 6 {
+		t.Errorf("got %+v, want 2 files and 6 lines left out", truncation)
+	}
+	if len(files[0].Hunks[1].Lines) != 4 {
+		t.Error("expected the input files to be left alone")
+	}
+
+	if shown, truncation = diff.Truncate(files, diff.Limits{Files: 2, Lines: 12}); len(shown) != 2 || truncation != nil {
+		t.Errorf("expected everything to fit, got %d files and %+v", len(shown), truncation)
+	}
+}
+
+func TestRenderFilesLimited(t *testing.T) {
+	files, err := diff.ParseMultiFileDiff(sweepDiff)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	renderer := diff.NewRenderer(diff.RenderOptions{})
+	out, err := renderer.RenderFilesLimited(context.Background(), files, diff.Limits{Lines: 6})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out, "a.go") || strings.Contains(out, "b.go") || strings.Contains(out, "return err") {
+		t.Errorf("expected a.go alone, cut inside its second hunk, got:\n%s", out)
+	}
+	if !strings.HasSuffix(out, "✂ output truncated (max lines): 2 file(s) and 6 line(s) not shown\n") {
+		t.Errorf("expected a footer, got:\n%s", out)
+	}
+
+	// Past the deadline nothing is rendered, which the footer says
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now())
+	defer cancel()
+	out, err = renderer.RenderFilesLimited(ctx, files, diff.Limits{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out != "✂ output truncated (timeout): 2 file(s) and 12 line(s) not shown\n" {
+		t.Errorf("got %q", out)
+	}
+}
+
+// expiringContext passes its deadline once Err has been asked n times
+type expiringContext struct {
+	context.Context
+	n int
+}
+
+func (c *expiringContext) Err() error {
+	if c.n <= 0 {
+		return context.DeadlineExceeded
+	}
+	c.n--
+	return nil
+}
+
+func TestRenderFilesLimitedKeepsFinishedFiles(t *testing.T) {
+	files, err := diff.ParseMultiFileDiff(sweepDiff)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Count the checks rendering the first file takes, less the one after
+	// the render, to pass the deadline right after it was finished
+	counter := &expiringContext{Context: context.Background(), n: 1 << 30}
+	diff.NewRenderer(diff.RenderOptions{Width: 80}).RenderFilesLimited(counter, files[:1], diff.Limits{})
+	ctx := &expiringContext{Context: context.Background(), n: 1<<30 - counter.n - 1}
+
+	out, offsets, err := diff.NewRenderer(diff.RenderOptions{Width: 80}).RenderFilesLimitedWithOffsets(ctx, files, diff.Limits{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	plain := diff.StripANSI(out)
+	if !strings.Contains(plain, "a.go") || strings.Contains(plain, "b.go") {
+		t.Errorf("expected a.go in full and b.go left out, got:\n%s", plain)
+	}
+	if len(offsets) != 2 || !strings.HasSuffix(plain, "✂ output truncated (timeout): 1 file(s) and 4 line(s) not shown\n") {
+		t.Errorf("expected a.go's offsets and a footer for b.go, got %v and:\n%s", offsets, plain)
+	}
+}
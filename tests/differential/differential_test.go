@@ -0,0 +1,126 @@
+package differential_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/avgvstvs96/differential"
+)
+
+const snippetDiff = `diff --git a/a.go b/a.go
+index 1111111..2222222 100644
+--- a/a.go
++++ b/a.go
+@@ -1,3 +1,3 @@
+ package a
+-func Old() {}
++func New() {}
+`
+
+func TestRenderWithTheme(t *testing.T) {
+	files, err := differential.Parse(snippetDiff)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(differential.Themes()) == 0 {
+		t.Fatal("expected built-in themes")
+	}
+	theme, err := differential.Theme(differential.Themes()[0])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	opts := differential.Options{Width: 80, ViewMode: differential.SideBySide, Theme: theme}
+	output, err := differential.RenderHunk(files[0], 0, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(output, "New") || !strings.Contains(output, "\x1b[") {
+		t.Errorf("expected a styled hunk, got:\n%s", output)
+	}
+
+	if _, err := differential.RenderLines(files[0], 2, 2, opts); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := differential.Theme("no-such-theme"); err == nil {
+		t.Error("expected an error for an unknown theme")
+	}
+}
+
+func TestMatches(t *testing.T) {
+	files, err := differential.Parse(snippetDiff)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	matches := differential.Matches(files)
+	if len(matches) != 1 || len(matches[0].Matches) != 1 {
+		t.Fatalf("expected one match, got %+v", matches)
+	}
+	if m := matches[0].Matches[0]; m.Old != "func Old() {}" || m.New != "func New() {}" || m.Similarity <= 0.5 || m.Similarity >= 1 {
+		t.Errorf("unexpected match %+v", m)
+	}
+}
+
+func TestParseOnFile(t *testing.T) {
+	text := snippetDiff + `diff --git a/a_test.go b/a_test.go
+index 3333333..4444444 100644
+--- a/a_test.go
++++ b/a_test.go
+@@ -1,1 +1,3 @@
+ package a
++
++func TestNew(t *testing.T) {}
+`
+	// Test-to-code ratio of a change, computed as it is parsed
+	var calls []string
+	var testLines, codeLines int
+	opts := differential.ParseOptions{OnFile: func(file *differential.File, stat differential.FileStat) {
+		calls = append(calls, stat.Path)
+		if strings.HasSuffix(stat.Path, "_test.go") {
+			testLines += stat.Additions
+		} else {
+			codeLines += stat.Additions
+		}
+	}}
+	files, err := differential.ParseWith(text, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(files) != 2 || strings.Join(calls, " ") != "a.go a_test.go" {
+		t.Errorf("expected each file once, in order, got %v", calls)
+	}
+	if codeLines != 1 || testLines != 2 {
+		t.Errorf("expected 1 code and 2 test lines, got %d and %d", codeLines, testLines)
+	}
+}
+
+func TestRenderer(t *testing.T) {
+	files, err := differential.Parse(snippetDiff)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	opts := differential.Options{Width: 80}
+	renderer := differential.NewRenderer(opts)
+
+	// The same output as the one-off functions, render after render
+	expected := differential.RenderFile(files[0], opts)
+	for i := 0; i < 2; i++ {
+		if output := renderer.RenderFile(files[0]); output != expected {
+			t.Errorf("render %d: expected %q, got %q", i, expected, output)
+		}
+	}
+	hunk, err := renderer.RenderHunk(files[0], 0)
+	if err != nil || !strings.Contains(hunk, "New") {
+		t.Errorf("expected the hunk, got %q (%v)", hunk, err)
+	}
+	if _, err := renderer.RenderHunk(files[0], 1); err == nil {
+		t.Error("expected an error for a hunk out of range")
+	}
+
+	// Modified files render anew after Reset
+	files[0].Hunks[0].Lines[2].Content = "func Newer() {}"
+	renderer.Reset()
+	if output := renderer.RenderFiles(files); !strings.Contains(output, "Newer") {
+		t.Errorf("expected the modified line after Reset, got:\n%s", output)
+	}
+}
@@ -0,0 +1,211 @@
+package forge_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/avgvstvs96/differential/internal/diff"
+	"github.com/avgvstvs96/differential/internal/forge"
+)
+
+func TestParseRef(t *testing.T) {
+	for _, tt := range []struct {
+		arg, remote string
+		want        forge.Ref
+	}{
+		{"https://github.com/owner/repo/pull/12/files", "", forge.Ref{Kind: forge.GitHub, Host: "github.com", Project: "owner/repo", Number: 12, API: "https://api.github.com"}},
+		{"https://gitlab.com/group/sub/project/-/merge_requests/34", "", forge.Ref{Kind: forge.GitLab, Host: "gitlab.com", Project: "group/sub/project", Number: 34, API: "https://gitlab.com/api/v4"}},
+		{"56", "git@github.com:owner/repo.git", forge.Ref{Kind: forge.GitHub, Host: "github.com", Project: "owner/repo", Number: 56, API: "https://api.github.com"}},
+		{"#7", "https://github.example.com/owner/repo", forge.Ref{Kind: forge.GitHub, Host: "github.example.com", Project: "owner/repo", Number: 7, API: "https://github.example.com/api/v3"}},
+		{"!8", "ssh://git@gitlab.example.com:2222/group/project.git", forge.Ref{Kind: forge.GitLab, Host: "gitlab.example.com", Project: "group/project", Number: 8, API: "https://gitlab.example.com/api/v4"}},
+	} {
+		got, err := forge.ParseRef(tt.arg, tt.remote)
+		if err != nil {
+			t.Errorf("ParseRef(%q, %q): unexpected error: %v", tt.arg, tt.remote, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseRef(%q, %q) = %+v, want %+v", tt.arg, tt.remote, got, tt.want)
+		}
+	}
+
+	for _, arg := range []string{"main", "0"} {
+		if _, err := forge.ParseRef(arg, "git@github.com:owner/repo.git"); err == nil {
+			t.Errorf("ParseRef(%q): expected an error", arg)
+		}
+	}
+	if _, err := forge.ParseRef("3", "git@code.example.com:owner/repo.git"); err == nil {
+		t.Error("expected an error for a host of unknown kind")
+	}
+}
+
+func TestFetchGitHub(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("expected the token to be sent, got %q", r.Header.Get("Authorization"))
+		}
+		switch {
+		case r.URL.Path == "/repos/owner/repo/pulls/12":
+			fmt.Fprint(w, `{"title": "Add greeting", "html_url": "https://github.com/owner/repo/pull/12",
+				"user": {"login": "alice"}, "base": {"ref": "main"}, "head": {"ref": "greet", "label": "alice:greet"}}`)
+		case r.URL.Path == "/repos/owner/repo/pulls/12/files" && r.URL.Query().Get("page") == "":
+			w.Header().Set("Link", fmt.Sprintf(`<%s/repos/owner/repo/pulls/12/files?per_page=100&page=2>; rel="next", <%s/x>; rel="last"`, server.URL, server.URL))
+			fmt.Fprint(w, `[{"filename": "hello.go", "status": "modified", "patch": "@@ -1 +1 @@\n-hi\n+hello"}]`)
+		case r.URL.Path == "/repos/owner/repo/pulls/12/files" && r.URL.Query().Get("page") == "2":
+			fmt.Fprint(w, `[{"filename": "new.go", "status": "added", "patch": "@@ -0,0 +1 @@\n+package new"},
+				{"filename": "b.go", "previous_filename": "a.go", "status": "renamed"}]`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := &forge.Client{HTTP: server.Client(), Token: "secret"}
+	ref := forge.Ref{Kind: forge.GitHub, Project: "owner/repo", Number: 12, API: server.URL}
+	pr, err := client.Fetch(context.Background(), ref)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pr.Title != "Add greeting" || pr.Author != "alice" || pr.Base != "main" || pr.Head != "alice:greet" {
+		t.Errorf("unexpected pull request %+v", pr)
+	}
+
+	files, err := diff.ParseMultiFileDiff(pr.Diff)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(files) != 3 {
+		t.Fatalf("expected the files of both pages, got %d in:\n%s", len(files), pr.Diff)
+	}
+	if stat := files[1].Stat(); stat.Path != "new.go" || stat.Status != diff.StatusAdded || stat.Additions != 1 {
+		t.Errorf("unexpected added file %+v", stat)
+	}
+	if stat := files[2].Stat(); stat.Status != diff.StatusRenamed || stat.OldPath != "a.go" {
+		t.Errorf("unexpected renamed file %+v", stat)
+	}
+}
+
+func TestFetchGitLab(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.RawPath == "/projects/group%2Fproject/merge_requests/34":
+			fmt.Fprint(w, `{"title": "Fix typo", "web_url": "https://gitlab.com/group/project/-/merge_requests/34",
+				"author": {"username": "bob"}, "source_branch": "typo", "target_branch": "main"}`)
+		case r.URL.RawPath == "/projects/group%2Fproject/merge_requests/34/diffs" && r.URL.Query().Get("page") == "1":
+			w.Header().Set("X-Next-Page", "2")
+			fmt.Fprint(w, `[{"old_path": "README.md", "new_path": "README.md", "diff": "@@ -1 +1 @@\n-teh\n+the\n"}]`)
+		case r.URL.RawPath == "/projects/group%2Fproject/merge_requests/34/diffs" && r.URL.Query().Get("page") == "2":
+			fmt.Fprint(w, `[{"old_path": "old.txt", "new_path": "old.txt", "deleted_file": true, "diff": "@@ -1 +0,0 @@\n-bye\n"}]`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := &forge.Client{HTTP: server.Client()}
+	ref := forge.Ref{Kind: forge.GitLab, Project: "group/project", Number: 34, API: server.URL}
+	pr, err := client.Fetch(context.Background(), ref)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pr.Title != "Fix typo" || pr.Author != "bob" || pr.Base != "main" || pr.Head != "typo" {
+		t.Errorf("unexpected merge request %+v", pr)
+	}
+	files, err := diff.ParseMultiFileDiff(pr.Diff)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(files) != 2 || files[1].Stat().Status != diff.StatusDeleted {
+		t.Errorf("expected a modified and a deleted file, got:\n%s", pr.Diff)
+	}
+}
+
+func TestFetchNotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"message": "Not Found"}`)
+	}))
+	defer server.Close()
+
+	client := &forge.Client{HTTP: server.Client()}
+	_, err := client.Fetch(context.Background(), forge.Ref{Kind: forge.GitHub, Project: "owner/private", Number: 1, API: server.URL})
+	if err == nil || !strings.Contains(err.Error(), "Not Found") || !strings.Contains(err.Error(), "GITHUB_TOKEN") {
+		t.Errorf("expected a not found error mentioning the token, got %v", err)
+	}
+}
+
+func TestBlobURL(t *testing.T) {
+	for _, tt := range []struct {
+		remote string
+		line   int
+		want   string
+	}{
+		{"git@github.com:owner/repo.git", 12, "https://github.com/owner/repo/blob/abc123/dir/my%20file.go#L12"},
+		{"https://gitlab.com/group/sub/project.git", 0, "https://gitlab.com/group/sub/project/-/blob/abc123/dir/my%20file.go"},
+	} {
+		repo, err := forge.ParseRemote(tt.remote)
+		if err != nil {
+			t.Errorf("ParseRemote(%q): unexpected error: %v", tt.remote, err)
+			continue
+		}
+		if got := repo.BlobURL("abc123", "dir/my file.go", tt.line); got != tt.want {
+			t.Errorf("BlobURL for %q = %s, want %s", tt.remote, got, tt.want)
+		}
+	}
+
+	if _, err := forge.ParseRemote("git@code.example.com:owner/repo.git"); err == nil {
+		t.Error("expected an error for a host of unknown kind")
+	}
+}
+
+func TestTokenFromEnv(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "github-secret")
+	t.Setenv("GITLAB_TOKEN", "gitlab-secret")
+
+	for _, tt := range []struct {
+		ref     forge.Ref
+		trusted []string
+		want    string
+	}{
+		{forge.Ref{Kind: forge.GitHub, Host: "github.com"}, nil, "github-secret"},
+		{forge.Ref{Kind: forge.GitLab, Host: "gitlab.com"}, nil, "gitlab-secret"},
+		// A URL naming another server doesn't get the token
+		{forge.Ref{Kind: forge.GitHub, Host: "github.attacker.example"}, nil, ""},
+		{forge.Ref{Kind: forge.GitLab, Host: "gitlab.example.com"}, []string{"github.example.com"}, ""},
+		{forge.Ref{Kind: forge.GitLab, Host: "gitlab.example.com"}, []string{"GitLab.example.com"}, "gitlab-secret"},
+	} {
+		if got := forge.TokenFromEnv(tt.ref, tt.trusted); got != tt.want {
+			t.Errorf("TokenFromEnv(%s, %v) = %q, want %q", tt.ref.Host, tt.trusted, got, tt.want)
+		}
+	}
+}
+
+func TestFetchPageElsewhere(t *testing.T) {
+	// A next page on another server is fetched without the token
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); auth != "" {
+			t.Errorf("expected no token sent to another server, got %q", auth)
+		}
+		fmt.Fprint(w, `[]`)
+	}))
+	defer other.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/repos/owner/repo/pulls/1/files" {
+			w.Header().Set("Link", fmt.Sprintf(`<%s/files?page=2>; rel="next"`, other.URL))
+			fmt.Fprint(w, `[]`)
+			return
+		}
+		fmt.Fprint(w, `{"title": "Change"}`)
+	}))
+	defer server.Close()
+
+	client := &forge.Client{HTTP: server.Client(), Token: "secret"}
+	if _, err := client.Fetch(context.Background(), forge.Ref{Kind: forge.GitHub, Project: "owner/repo", Number: 1, API: server.URL}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
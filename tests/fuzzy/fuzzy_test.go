@@ -0,0 +1,53 @@
+package fuzzy_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/avgvstvs96/differential/internal/fuzzy"
+)
+
+func TestFind(t *testing.T) {
+	candidates := []string{
+		"internal/app/app.go",
+		"internal/diff/renderer.go",
+		"README.md",
+		"cmd/differential/main.go",
+	}
+
+	matches := fuzzy.Find("rend", candidates)
+	if len(matches) != 1 || matches[0].Str != "internal/diff/renderer.go" {
+		t.Fatalf("expected only the renderer to match, got %+v", matches)
+	}
+	if want := []int{14, 15, 16, 17}; !reflect.DeepEqual(matches[0].Positions, want) {
+		t.Errorf("expected positions %v, got %v", want, matches[0].Positions)
+	}
+
+	// A match in the file name beats one spread over the path
+	matches = fuzzy.Find("main", candidates)
+	if len(matches) == 0 || matches[0].Str != "cmd/differential/main.go" {
+		t.Errorf("expected main.go first, got %+v", matches)
+	}
+
+	if matches := fuzzy.Find("xyz", candidates); len(matches) != 0 {
+		t.Errorf("expected no matches, got %+v", matches)
+	}
+}
+
+func TestFindCase(t *testing.T) {
+	candidates := []string{"readme.txt", "README.md"}
+	if matches := fuzzy.Find("readme", candidates); len(matches) != 2 {
+		t.Errorf("expected a lowercase pattern to ignore case, got %+v", matches)
+	}
+	if matches := fuzzy.Find("README", candidates); len(matches) != 1 || matches[0].Str != "README.md" {
+		t.Errorf("expected an uppercase pattern to match case, got %+v", matches)
+	}
+}
+
+func TestFindEmpty(t *testing.T) {
+	candidates := []string{"b.go", "a.go"}
+	matches := fuzzy.Find("", candidates)
+	if len(matches) != 2 || matches[0].Str != "b.go" || matches[1].Str != "a.go" {
+		t.Errorf("expected every candidate in order, got %+v", matches)
+	}
+}
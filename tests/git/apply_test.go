@@ -0,0 +1,77 @@
+package git_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/avgvstvs96/differential/internal/diff"
+	"github.com/avgvstvs96/differential/internal/git"
+)
+
+func TestApplyCachedPartialPatch(t *testing.T) {
+	initRepo(t)
+	if err := os.WriteFile("src/main.go", []byte("package main\n\nfunc a() {}\n\nfunc b() {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir("src"); err != nil {
+		t.Fatal(err)
+	}
+
+	text, err := git.Diff()
+	if err != nil {
+		t.Fatalf("Diff: %v", err)
+	}
+	files, err := diff.ParseMultiFileDiff(text)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	patch, err := diff.PartialPatch(files[0], func(line diff.DiffLine) bool { return line.NewLineNo <= 3 })
+	if err != nil {
+		t.Fatalf("PartialPatch: %v", err)
+	}
+	if err := git.ApplyCached(patch); err != nil {
+		t.Fatalf("ApplyCached: %v", err)
+	}
+
+	staged, err := git.ReadBlob(":src/main.go")
+	if err != nil {
+		t.Fatalf("ReadBlob: %v", err)
+	}
+	if want := "package main\n\nfunc a() {}\n"; string(staged) != want {
+		t.Errorf("index has %q, want %q", staged, want)
+	}
+	unstaged, err := git.Diff()
+	if err != nil {
+		t.Fatalf("Diff: %v", err)
+	}
+	if !strings.Contains(unstaged, "+func b() {}") || strings.Contains(unstaged, "+func a() {}") {
+		t.Errorf("unexpected unstaged changes:\n%s", unstaged)
+	}
+
+	if err := git.ApplyCached("--- a/src/main.go\n+++ b/src/main.go\n@@ -1,1 +1,1 @@\n-nope\n+nope\n"); err == nil {
+		t.Error("expected a patch that doesn't apply to fail")
+	}
+}
+
+func TestReverseCached(t *testing.T) {
+	initRepo(t)
+	patch := "--- a/src/main.go\n+++ b/src/main.go\n@@ -1,1 +1,2 @@\n package main\n+// staged\n"
+	if err := git.ApplyCached(patch); err != nil {
+		t.Fatalf("ApplyCached: %v", err)
+	}
+	if err := git.ReverseCached(patch); err != nil {
+		t.Fatalf("ReverseCached: %v", err)
+	}
+
+	staged, err := git.ReadBlob(":src/main.go")
+	if err != nil {
+		t.Fatalf("ReadBlob: %v", err)
+	}
+	if string(staged) != "package main\n" {
+		t.Errorf("index has %q after reversing, want the original", staged)
+	}
+	if err := git.ReverseCached(patch); err == nil {
+		t.Error("expected reversing a patch twice to fail")
+	}
+}
@@ -0,0 +1,30 @@
+package git_test
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/avgvstvs96/differential/internal/git"
+)
+
+func TestLinguistGenerated(t *testing.T) {
+	initRepo(t)
+	attributes := "api/*.go linguist-generated\nschema.sql linguist-generated=true\napi/handwritten.go -linguist-generated\n"
+	if err := os.WriteFile(".gitattributes", []byte(attributes), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir("src"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Paths are relative to the root, whatever the working directory
+	generated, err := git.LinguistGenerated(context.Background(), []string{"api/client.go", "api/handwritten.go", "schema.sql", "src/main.go"})
+	if err != nil {
+		t.Fatalf("LinguistGenerated: %v", err)
+	}
+	if want := map[string]bool{"api/client.go": true, "schema.sql": true}; !reflect.DeepEqual(generated, want) {
+		t.Errorf("got %v, want %v", generated, want)
+	}
+}
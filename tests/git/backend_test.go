@@ -0,0 +1,153 @@
+package git_test
+
+import (
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/avgvstvs96/differential/internal/diff"
+	"github.com/avgvstvs96/differential/internal/git"
+)
+
+// runGit runs a git command in the working directory
+func runGit(t *testing.T, args ...string) {
+	t.Helper()
+	args = append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)
+	if output, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, output)
+	}
+}
+
+// writeFile writes a file in the working directory
+func writeFile(t *testing.T, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// diffLines parses a diff into its file names and changed lines, which the
+// backends must agree on
+func diffLines(t *testing.T, text string) []string {
+	t.Helper()
+	files, err := diff.ParseMultiFileDiff(text)
+	if err != nil {
+		t.Fatalf("failed to parse diff: %v\n%s", err, text)
+	}
+	var lines []string
+	for _, file := range files {
+		lines = append(lines, "file "+file.OldFile+" "+file.NewFile)
+		for _, hunk := range file.Hunks {
+			for _, line := range hunk.Lines {
+				lines = append(lines, line.Content)
+			}
+		}
+	}
+	return lines
+}
+
+func TestNativeDiffMatchesCLI(t *testing.T) {
+	initRepo(t)
+	writeFile(t, "README.md", "# test\n\nsome text\n")
+	runGit(t, "add", ".")
+	runGit(t, "commit", "-q", "-m", "readme")
+	writeFile(t, "src/main.go", "package main\n\nfunc main() {}\n")
+	runGit(t, "commit", "-q", "-am", "main")
+
+	// Leave a staged and an unstaged change
+	writeFile(t, "src/util.go", "package main\n")
+	runGit(t, "add", "src/util.go")
+	writeFile(t, "README.md", "# test\n\nother text\n")
+
+	cli, native := git.CLI{}, git.NewNative(".")
+	for _, args := range [][]string{
+		nil,
+		{"--cached"},
+		{"HEAD~2"},
+		{"HEAD~2", "HEAD"},
+		{"HEAD~2..HEAD", "--", "src"},
+		{"-U1", "HEAD~1"},
+		{"-Sfunc", "HEAD~2"},
+	} {
+		t.Run(strings.Join(args, " "), func(t *testing.T) {
+			want, err := cli.Diff(append([]string{"--no-renames"}, args...)...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := native.Diff(args...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if w, g := diffLines(t, want), diffLines(t, got); !reflect.DeepEqual(w, g) {
+				t.Errorf("native diff differs\nwant:\n%s\ngot:\n%s", want, got)
+			}
+		})
+	}
+}
+
+func TestNativeObjects(t *testing.T) {
+	initRepo(t)
+	writeFile(t, "src/main.go", "package main\n\nfunc main() {}\n")
+	runGit(t, "commit", "-q", "-am", "main")
+
+	cli, native := git.CLI{}, git.NewNative(".")
+
+	want, _ := cli.ResolveRevision("HEAD~1")
+	if got, err := native.ResolveRevision("HEAD~1"); err != nil || got != want {
+		t.Errorf("ResolveRevision = %q, %v, want %q", got, err, want)
+	}
+	if got, err := native.MergeBase("HEAD", "HEAD~1"); err != nil || got != want {
+		t.Errorf("MergeBase = %q, %v, want %q", got, err, want)
+	}
+	if got, err := cli.MergeBase("HEAD", "HEAD~1"); err != nil || got != want {
+		t.Errorf("CLI MergeBase = %q, %v, want %q", got, err, want)
+	}
+
+	for _, spec := range []string{"HEAD:src/main.go", "HEAD~1:src/main.go", "HEAD:src"} {
+		want, _ := cli.ObjectType(spec)
+		if got, err := native.ObjectType(spec); err != nil || got != want {
+			t.Errorf("ObjectType(%s) = %q, %v, want %q", spec, got, err, want)
+		}
+	}
+	if data, err := native.ReadBlob("HEAD~1:src/main.go"); err != nil || string(data) != "package main\n" {
+		t.Errorf("ReadBlob = %q, %v", data, err)
+	}
+	if data, size, err := native.ReadBlobPrefix("HEAD~1:src/main.go", 4); err != nil || string(data) != "pack" || size != 13 {
+		t.Errorf("ReadBlobPrefix = %q, %d, %v", data, size, err)
+	}
+
+	wantBlame, err := cli.Blame("HEAD", "src/main.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotBlame, err := native.Blame("HEAD", "src/main.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gotBlame) != 3 || !reflect.DeepEqual(wantBlame, gotBlame) {
+		t.Errorf("Blame = %+v, want %+v", gotBlame, wantBlame)
+	}
+}
+
+func TestNativeUnsupportedOption(t *testing.T) {
+	initRepo(t)
+	if _, err := git.NewNative(".").Diff("--word-diff"); err == nil || !strings.Contains(err.Error(), "--word-diff") {
+		t.Errorf("expected an error naming the option, got %v", err)
+	}
+}
+
+func TestSetBackend(t *testing.T) {
+	defer git.SetBackend(git.BackendCLI)
+
+	if err := git.SetBackend("svn"); err == nil {
+		t.Error("expected an error for an unknown backend")
+	}
+	if err := git.SetBackend(git.BackendNative); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := git.CurrentBackend().(*git.Native); !ok {
+		t.Errorf("expected the native backend, got %T", git.CurrentBackend())
+	}
+}
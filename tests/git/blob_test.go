@@ -0,0 +1,98 @@
+package git_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/avgvstvs96/differential/internal/git"
+)
+
+// initRepo creates a repository with one commit of src/main.go and makes
+// it the working directory for the rest of the test
+func initRepo(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "src"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "src", "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "."},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "init"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v\n%s", args[0], err, output)
+		}
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+func TestBlobSpecs(t *testing.T) {
+	initRepo(t)
+
+	if !git.IsBlobSpec("HEAD:src/main.go") {
+		t.Error("expected HEAD:src/main.go to be a blob spec")
+	}
+	data, err := git.ReadBlob("HEAD:src/main.go")
+	if err != nil || string(data) != "package main\n" {
+		t.Errorf("ReadBlob = %q, %v", data, err)
+	}
+	data, size, err := git.ReadBlobPrefix("HEAD:src/main.go", 4)
+	if err != nil || string(data) != "pack" || size != 13 {
+		t.Errorf("ReadBlobPrefix = %q, %d, %v", data, size, err)
+	}
+
+	tests := []struct {
+		arg     string
+		wantErr string
+	}{
+		{arg: "HEAD:src/main.go"},
+		{arg: "HEAD:src/missing.go", wantErr: "does not exist in HEAD"},
+		{arg: "HEAD:src", wantErr: "is a tree in HEAD"},
+		{arg: "nope:src/main.go"}, // Not a revision, left to ref validation
+	}
+	for _, tt := range tests {
+		t.Run(tt.arg, func(t *testing.T) {
+			err := git.CheckBlobSpec(tt.arg)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestHasBlobID(t *testing.T) {
+	data := []byte("package main\n")
+	for id, want := range map[string]bool{
+		"06ab7d0f9a35a7d1070711496d6ca1cb892a258f": true,
+		"06ab7d0": true,
+		"06AB7D0": true,
+		"1234567": false,
+		"06a":     false,
+		"0000000": false,
+	} {
+		if got := git.HasBlobID(data, id); got != want {
+			t.Errorf("HasBlobID(%q) = %v, want %v", id, got, want)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package git_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/avgvstvs96/differential/internal/git"
+)
+
+func TestSuggestRef(t *testing.T) {
+	candidates := []string{"HEAD", "main", "origin/main", "feature/login"}
+
+	tests := []struct {
+		name     string
+		ref      string
+		expected string
+	}{
+		{
+			name:     "transposed letters",
+			ref:      "orgin/main",
+			expected: "origin/main",
+		},
+		{
+			name:     "keeps revision suffix",
+			ref:      "mian~2",
+			expected: "main~2",
+		},
+		{
+			name:     "nothing close",
+			ref:      "release-2024",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := git.SuggestRef(tt.ref, candidates)
+			if result != tt.expected {
+				t.Errorf("SuggestRef(%q) = %q, want %q", tt.ref, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRefError(t *testing.T) {
+	err := &git.RefError{Ref: "orgin/main", Suggestion: "origin/main"}
+	expected := `unknown revision "orgin/main" (did you mean "origin/main"?)`
+	if err.Error() != expected {
+		t.Errorf("Error() = %q, want %q", err.Error(), expected)
+	}
+}
+
+func TestCompleteRevision(t *testing.T) {
+	candidates := []string{"HEAD", "main", "feature/login", "feature/search"}
+
+	tests := []struct {
+		partial  string
+		expected []string
+	}{
+		{"fea", []string{"feature/login", "feature/search"}},
+		{"main..feature/l", []string{"main..feature/login"}},
+		{"main...ma", []string{"main...main"}},
+		{"v1", nil},
+	}
+
+	for _, tt := range tests {
+		result := git.CompleteRevision(tt.partial, candidates)
+		if strings.Join(result, ",") != strings.Join(tt.expected, ",") {
+			t.Errorf("CompleteRevision(%q) = %q, want %q", tt.partial, result, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package git_test
+
+import (
+	"testing"
+
+	"github.com/avgvstvs96/differential/internal/git"
+)
+
+func TestRerereID(t *testing.T) {
+	// ID recorded by git rerere for this conflict
+	const want = "142a8244b2c84e2e94dcea72e68c7d8229b90418"
+
+	content := "1\n<<<<<<< HEAD\nmain\n=======\nfeat\n>>>>>>> feat\n3\n"
+	id, ok := git.RerereID([]byte(content))
+	if !ok {
+		t.Fatal("expected a conflict")
+	}
+	if id != want {
+		t.Errorf("RerereID = %s, want %s", id, want)
+	}
+
+	// Labels, side order and diff3 base sections don't change the ID
+	swapped := "1\n<<<<<<< main\nfeat\n||||||| base\n2\n=======\nmain\n>>>>>>> other\n3\n"
+	if id, _ := git.RerereID([]byte(swapped)); id != want {
+		t.Errorf("RerereID of swapped sides = %s, want %s", id, want)
+	}
+}
+
+func TestRerereID_NoConflict(t *testing.T) {
+	if _, ok := git.RerereID([]byte("=======\nplain text\n")); ok {
+		t.Error("expected no conflict")
+	}
+}
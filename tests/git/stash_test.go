@@ -0,0 +1,62 @@
+package git_test
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/avgvstvs96/differential/internal/git"
+)
+
+// stash changes src/main.go to content and stashes it with message
+func stash(t *testing.T, content, message string) {
+	t.Helper()
+	if err := os.WriteFile("src/main.go", []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command("git", "-c", "user.name=test", "-c", "user.email=test@example.com", "stash", "push", "-q", "-m", message)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git stash: %v\n%s", err, output)
+	}
+}
+
+func TestStashShowApplyPopDrop(t *testing.T) {
+	initRepo(t)
+	stash(t, "package main\n\n// first\n", "first")
+	stash(t, "package main\n\n// second\n", "second")
+
+	shown, err := git.StashShow("1")
+	if err != nil {
+		t.Fatalf("StashShow: %v", err)
+	}
+	if !strings.Contains(shown, "+// first") || strings.Contains(shown, "second") {
+		t.Errorf("unexpected stash@{1} changes:\n%s", shown)
+	}
+
+	if err := git.StashApply("0"); err != nil {
+		t.Fatalf("StashApply: %v", err)
+	}
+	if data, _ := os.ReadFile("src/main.go"); !strings.Contains(string(data), "// second") {
+		t.Errorf("worktree has %q after applying stash@{0}", data)
+	}
+	if stashes, _ := git.StashList(); len(stashes) != 2 {
+		t.Errorf("apply left %d stashes, want 2", len(stashes))
+	}
+
+	if err := git.StashDrop("stash@{0}"); err != nil {
+		t.Fatalf("StashDrop: %v", err)
+	}
+	if _, err := git.Run("checkout", "--", "src/main.go"); err != nil {
+		t.Fatal(err)
+	}
+	if err := git.StashPop("0"); err != nil {
+		t.Fatalf("StashPop: %v", err)
+	}
+	if data, _ := os.ReadFile("src/main.go"); !strings.Contains(string(data), "// first") {
+		t.Errorf("worktree has %q after popping stash@{0}", data)
+	}
+	if stashes, _ := git.StashList(); len(stashes) != 0 {
+		t.Errorf("pop left %d stashes, want none", len(stashes))
+	}
+}
@@ -0,0 +1,82 @@
+package git_test
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/avgvstvs96/differential/internal/diff"
+	"github.com/avgvstvs96/differential/internal/git"
+)
+
+func TestUntrackedDiff(t *testing.T) {
+	initRepo(t)
+	for path, content := range map[string]string{
+		".gitignore":  "*.log\n",
+		"debug.log":   "ignored\n",
+		"src/new.go":  "package main\n\nfunc a() {}\n",
+		"notes.txt":   "no newline",
+		"data.bin":    "\x00\x01",
+		"empty.txt":   "",
+		"src/main.go": "package main\n", // Tracked and unchanged
+	} {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Chdir("src"); err != nil {
+		t.Fatal(err)
+	}
+
+	text, err := git.UntrackedDiff(context.Background())
+	if err != nil {
+		t.Fatalf("UntrackedDiff: %v", err)
+	}
+	if strings.Contains(text, "debug.log") || strings.Contains(text, "src/main.go") {
+		t.Errorf("diff has ignored or tracked files:\n%s", text)
+	}
+	files, err := diff.ParseMultiFileDiff(text)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	byName := make(map[string]*diff.DiffResult)
+	for _, file := range files {
+		if stat := file.Stat(); stat.Status != diff.StatusAdded {
+			t.Errorf("%s has status %q, want added", file.DisplayName(), stat.Status)
+		}
+		byName[file.DisplayName()] = file
+	}
+	for _, name := range []string{".gitignore", "src/new.go", "notes.txt", "data.bin", "empty.txt"} {
+		if byName[name] == nil {
+			t.Errorf("missing %s in:\n%s", name, text)
+		}
+	}
+	if file := byName["src/new.go"]; file != nil {
+		if additions, deletions := file.CountChanges(); additions != 3 || deletions != 0 {
+			t.Errorf("src/new.go has +%d -%d, want +3 -0", additions, deletions)
+		}
+	}
+	if !strings.Contains(text, "+no newline\n\\ No newline at end of file\n") {
+		t.Errorf("missing no-newline marker:\n%s", text)
+	}
+	if file := byName["data.bin"]; file != nil && !file.Stat().Binary {
+		t.Error("data.bin should be binary")
+	}
+
+	// The diff applies to the index like one git wrote
+	patch, err := diff.PartialPatch(byName["src/new.go"], func(line diff.DiffLine) bool { return line.NewLineNo == 1 })
+	if err != nil {
+		t.Fatalf("PartialPatch: %v", err)
+	}
+	if err := git.ApplyCached(patch); err != nil {
+		t.Fatalf("ApplyCached: %v", err)
+	}
+	staged, err := git.ReadBlob(":src/new.go")
+	if err != nil {
+		t.Fatalf("ReadBlob: %v", err)
+	}
+	if string(staged) != "package main\n" {
+		t.Errorf("index has %q, want the first line", staged)
+	}
+}
@@ -0,0 +1,101 @@
+package review_test
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/avgvstvs96/differential/internal/review"
+)
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "differential", "comments.json")
+	store, err := review.Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error loading a missing store: %v", err)
+	}
+
+	comments := []review.Comment{{Path: "main.go", Line: 3, Side: review.SideNew, Body: "why?"}}
+	store.SetComments("/repo", "abc", comments)
+	store.SetComments("/repo", "def", []review.Comment{{Path: "a.go", Line: 1, Side: review.SideOld, Body: "keep"}})
+	if err := store.Save(path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	loaded, err := review.Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := loaded.Comments("/repo", "abc"); len(got) != 1 || got[0] != comments[0] {
+		t.Errorf("expected the saved comments back, got %+v", got)
+	}
+	if got := loaded.Comments("/other", "abc"); got != nil {
+		t.Errorf("expected no comments for another repository, got %+v", got)
+	}
+
+	loaded.SetComments("/repo", "abc", nil)
+	loaded.SetComments("/repo", "def", nil)
+	if len(loaded.Repos) != 0 {
+		t.Errorf("expected repositories without comments to be dropped, got %+v", loaded.Repos)
+	}
+}
+
+func TestSortAndFind(t *testing.T) {
+	comments := []review.Comment{
+		{Path: "b.go", Line: 1, Side: review.SideNew},
+		{Path: "a.go", Line: 7, Side: review.SideNew},
+		{Path: "a.go", Line: 7, Side: review.SideOld},
+		{Path: "a.go", Line: 2, Side: review.SideNew},
+	}
+	review.Sort(comments)
+
+	var got []string
+	for _, c := range comments {
+		got = append(got, c.Path+":"+c.Side)
+	}
+	want := "a.go:new a.go:old a.go:new b.go:new"
+	if strings.Join(got, " ") != want {
+		t.Errorf("expected order %s, got %s", want, strings.Join(got, " "))
+	}
+	if i := review.Find(comments, "a.go", review.SideOld, 7); i != 1 {
+		t.Errorf("expected the old line 7 at 1, got %d", i)
+	}
+	if i := review.Find(comments, "a.go", review.SideOld, 2); i != -1 {
+		t.Errorf("expected no comment on old line 2, got %d", i)
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	comments := []review.Comment{
+		{Path: "a.go", Line: 2, Side: review.SideOld, Text: "\treturn `x`", Body: "Still needed?"},
+		{Path: "a.go", Line: 9, Side: review.SideNew, Body: "Nice"},
+		{Path: "b.go", Line: 1, Side: review.SideNew, Text: "package b", Body: "Rename"},
+	}
+	want := "# Review of abc1234\n" +
+		"\n## `a.go`\n" +
+		"\n**Removed line 2**: `return 'x'`\n\nStill needed?\n" +
+		"\n**Line 9**\n\nNice\n" +
+		"\n## `b.go`\n" +
+		"\n**Line 1**: `package b`\n\nRename\n"
+	if got := review.Markdown("Review of abc1234", comments); got != want {
+		t.Errorf("unexpected markdown:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestGitHubReview(t *testing.T) {
+	comments := []review.Comment{
+		{Path: "a.go", Line: 2, Side: review.SideOld, Text: "x", Body: "Still needed?"},
+		{Path: "a.go", Line: 9, Side: review.SideNew, Body: "Nice"},
+	}
+	data, err := json.Marshal(review.NewGitHubReview("abc", comments))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `{"commit_id":"abc","event":"COMMENT","comments":[` +
+		`{"path":"a.go","line":2,"side":"LEFT","body":"Still needed?"},` +
+		`{"path":"a.go","line":9,"side":"RIGHT","body":"Nice"}]}`
+	if string(data) != want {
+		t.Errorf("unexpected payload:\n%s\nwant:\n%s", data, want)
+	}
+}
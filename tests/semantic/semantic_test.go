@@ -0,0 +1,49 @@
+package semantic_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/avgvstvs96/differential/internal/semantic"
+)
+
+func TestCompare(t *testing.T) {
+	oldDoc, err := semantic.Parse("old.json", []byte(`{"server": {"port": 8080}, "legacy": true}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	newDoc, err := semantic.Parse("new.yaml", []byte("server:\n  port: 9090\n  tls: true\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	changes := semantic.Compare(oldDoc, newDoc)
+	if len(changes) != 3 {
+		t.Fatalf("expected 3 changes, got %d: %+v", len(changes), changes)
+	}
+
+	expected := []struct {
+		path string
+		kind semantic.ChangeKind
+	}{
+		{"legacy", semantic.ChangeRemoved},
+		{"server.port", semantic.ChangeUpdated},
+		{"server.tls", semantic.ChangeAdded},
+	}
+
+	for i, want := range expected {
+		path := strings.Join(changes[i].Path, ".")
+		if path != want.path || changes[i].Kind != want.kind {
+			t.Errorf("change %d: expected %s (%v), got %s (%v)", i, want.path, want.kind, path, changes[i].Kind)
+		}
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	if _, err := semantic.Parse("broken.toml", []byte("key = ")); err == nil {
+		t.Error("expected parse error for invalid TOML")
+	}
+	if _, err := semantic.Parse("notes.txt", []byte("hello")); err == nil {
+		t.Error("expected error for unsupported file type")
+	}
+}
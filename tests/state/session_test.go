@@ -0,0 +1,62 @@
+package state_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/avgvstvs96/differential/internal/review"
+	"github.com/avgvstvs96/differential/internal/state"
+)
+
+func TestSession_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reviews", "fix.dsp")
+
+	s := &state.Session{
+		Diff:        "--- a/a.go\n+++ b/a.go\n@@ -1 +1 @@\n-a\n+b\n",
+		ViewMode:    "side-by-side",
+		LineNumbers: true,
+		Cursor:      4,
+		Collapsed:   []string{"go.sum"},
+		Approved:    []string{"-a\n+b\n"},
+		Staged:      []state.StagedHunk{{File: "a.go", Header: "@@ -1 +1 @@"}},
+		Comments:    []review.Comment{{Path: "a.go", Line: 1, Side: review.SideNew, Body: "why?"}},
+	}
+	if err := s.Save(path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	loaded, err := state.LoadSession(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if loaded.Version != state.SessionVersion {
+		t.Errorf("expected version %d, got %d", state.SessionVersion, loaded.Version)
+	}
+	if loaded.Diff != s.Diff || loaded.ViewMode != "side-by-side" || !loaded.LineNumbers || loaded.Cursor != 4 {
+		t.Errorf("unexpected session %+v", loaded)
+	}
+	if len(loaded.Collapsed) != 1 || len(loaded.Approved) != 1 || len(loaded.Staged) != 1 || loaded.Staged[0] != s.Staged[0] {
+		t.Errorf("unexpected marks: collapsed %v, approved %v, staged %v", loaded.Collapsed, loaded.Approved, loaded.Staged)
+	}
+	if len(loaded.Comments) != 1 || loaded.Comments[0].Body != "why?" {
+		t.Errorf("unexpected comments %v", loaded.Comments)
+	}
+}
+
+func TestLoadSession_NewerVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fix.dsp")
+	if err := os.WriteFile(path, []byte(`{"version": 99, "diff": ""}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := state.LoadSession(path); err == nil || !strings.Contains(err.Error(), "version 99") {
+		t.Errorf("expected a version error, got %v", err)
+	}
+}
+
+func TestLoadSession_Missing(t *testing.T) {
+	if _, err := state.LoadSession(filepath.Join(t.TempDir(), "none.dsp")); err == nil {
+		t.Error("expected an error for a missing session")
+	}
+}
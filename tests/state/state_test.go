@@ -0,0 +1,43 @@
+package state_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/avgvstvs96/differential/internal/state"
+)
+
+func TestLoad_Missing(t *testing.T) {
+	s, err := state.Load(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s.ViewMode != "" || s.LineNumbers != nil {
+		t.Errorf("expected empty state, got %+v", s)
+	}
+}
+
+func TestSave_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "differential", "state.json")
+
+	lineNumbers := false
+	s := &state.State{ViewMode: "side-by-side", Theme: "nord", LineNumbers: &lineNumbers}
+	s.Repo("/src/project").Collapsed = []string{"go.sum"}
+	if err := s.Save(path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	loaded, err := state.Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if loaded.ViewMode != "side-by-side" || loaded.Theme != "nord" {
+		t.Errorf("unexpected state %+v", loaded)
+	}
+	if loaded.LineNumbers == nil || *loaded.LineNumbers {
+		t.Errorf("expected line numbers off, got %v", loaded.LineNumbers)
+	}
+	if got := loaded.Repo("/src/project").Collapsed; len(got) != 1 || got[0] != "go.sum" {
+		t.Errorf("unexpected collapsed files %v", got)
+	}
+}
@@ -0,0 +1,270 @@
+package themes_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/avgvstvs96/differential/internal/themes"
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestMix(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func() (lipgloss.Color, error)
+		want lipgloss.Color
+	}{
+		{"mix halfway", func() (lipgloss.Color, error) { return themes.Mix("#ffffff", "#000000", 0.5) }, "#808080"},
+		{"mix weights the first color", func() (lipgloss.Color, error) { return themes.Mix("#00ff00", "#000000", 0.25) }, "#004000"},
+		{"short hex", func() (lipgloss.Color, error) { return themes.Mix("#fff", "#000", 1) }, "#ffffff"},
+		{"lighten", func() (lipgloss.Color, error) { return themes.Lighten("#000000", 0.2) }, "#333333"},
+		{"darken", func() (lipgloss.Color, error) { return themes.Darken("#ffffff", 0.2) }, "#cccccc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.fn()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := themes.Mix("21", "#000000", 0.5); err == nil {
+		t.Error("expected an error mixing an ANSI color")
+	}
+}
+
+func TestHeat(t *testing.T) {
+	theme := &themes.ThemeColors{TextMuted: "#000000", DiffRemoved: "#ff0000"}
+	for ratio, want := range map[float64]lipgloss.Color{0: "#000000", 0.5: "#800000", 1: "#ff0000", 2: "#ff0000"} {
+		if got := theme.Heat(ratio); got != want {
+			t.Errorf("Heat(%v) = %s, want %s", ratio, got, want)
+		}
+	}
+
+	// ANSI colors fall back to the nearer end of the gradient
+	ansi := &themes.ThemeColors{TextMuted: "8", DiffRemoved: "1"}
+	if got := ansi.Heat(0.2); got != "8" {
+		t.Errorf("expected the muted color, got %s", got)
+	}
+	if got := ansi.Heat(0.9); got != "1" {
+		t.Errorf("expected the removed color, got %s", got)
+	}
+}
+
+func TestThemeExpressions(t *testing.T) {
+	r, err := themes.NewRegistry()
+	if err != nil {
+		t.Fatalf("failed to create registry: %v", err)
+	}
+
+	r.Register(&themes.Theme{
+		Name: "derived",
+		Defs: map[string]string{"green": "#00ff00", "bg": "#000000"},
+		Theme: map[string]map[string]string{
+			"background":         {"dark": "bg", "light": "#ffffff"},
+			"diffAdded":          {"dark": "green", "light": "darken(green, 50%)"},
+			"diffAddedBg":        {"dark": "mix(diffAdded, background, 0.25)", "light": "mix(diffAdded, background, 0.25)"},
+			"diffHighlightAdded": {"dark": "lighten(mix(diffAdded, background, 0.25), 0.5)", "light": "diffAddedBg"},
+		},
+	})
+	colors, err := r.Resolve("derived")
+	if err != nil {
+		t.Fatalf("failed to resolve theme: %v", err)
+	}
+	if colors.DiffAddedBg != "#004000" {
+		t.Errorf("expected diffAddedBg #004000, got %s", colors.DiffAddedBg)
+	}
+	if colors.DiffHighlightAdded != "#80a080" {
+		t.Errorf("expected diffHighlightAdded #80a080, got %s", colors.DiffHighlightAdded)
+	}
+}
+
+func TestThemeValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"unknown function", "blend(bg, bg, 0.5)"},
+		{"wrong argument count", "mix(bg, 0.5)"},
+		{"weight out of range", "lighten(bg, 1.5)"},
+		{"bad weight", "darken(bg, lots)"},
+		{"non-hex color", "mix(21, bg, 0.5)"},
+		{"cycle", "mix(text, bg, 0.5)"},
+		{"missing paren", "mix(bg, bg, 0.5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			theme := &themes.Theme{
+				Defs:  map[string]string{"bg": "#000000"},
+				Theme: map[string]map[string]string{"text": {"dark": tt.value}},
+			}
+			if err := theme.Validate(); err == nil {
+				t.Errorf("expected an error for %q", tt.value)
+			}
+		})
+	}
+}
+
+func TestLoadJSONValidates(t *testing.T) {
+	r, err := themes.NewRegistry()
+	if err != nil {
+		t.Fatalf("failed to create registry: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "broken.json")
+	data := `{"theme": {"text": {"dark": "mix(#ffffff, 0.5)"}}}`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.LoadJSON(path); err == nil {
+		t.Error("expected an error for an invalid color expression")
+	}
+}
+
+func TestDerivedDiffBackgrounds(t *testing.T) {
+	r, err := themes.NewRegistry()
+	if err != nil {
+		t.Fatalf("failed to create registry: %v", err)
+	}
+
+	r.Register(&themes.Theme{
+		Name: "minimal",
+		Theme: map[string]map[string]string{
+			"text":               {"dark": "#ffffff", "light": "#ffffff"},
+			"background":         {"dark": "#000000", "light": "#000000"},
+			"diffAdded":          {"dark": "#00ff00", "light": "#00ff00"},
+			"diffRemoved":        {"dark": "#ff0000", "light": "#ff0000"},
+			"diffHighlightAdded": {"dark": "lighten(diffAddedBg, 0.5)", "light": "lighten(diffAddedBg, 0.5)"},
+		},
+	})
+	resolve := func() *themes.ThemeColors {
+		t.Helper()
+		colors, err := r.Resolve("minimal")
+		if err != nil {
+			t.Fatalf("failed to resolve theme: %v", err)
+		}
+		return colors
+	}
+
+	colors := resolve()
+	if colors.DiffAddedBg != "#002600" || colors.DiffRemovedBg != "#260000" {
+		t.Errorf("expected backgrounds tinted 15%%, got %s and %s", colors.DiffAddedBg, colors.DiffRemovedBg)
+	}
+	if colors.DiffHighlightAdded != "#809380" {
+		t.Errorf("expected expressions to use the derived background, got %s", colors.DiffHighlightAdded)
+	}
+
+	if err := r.SetDiffBackgroundAlpha(0.5); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if colors := resolve(); colors.DiffAddedBg != "#008000" {
+		t.Errorf("expected a background tinted 50%%, got %s", colors.DiffAddedBg)
+	}
+
+	// Selection and cursor colors fall back to a tint of the text color
+	if colors.Selection != "#333333" || colors.Cursor != "#333333" {
+		t.Errorf("expected derived selection and cursor colors, got %s and %s", colors.Selection, colors.Cursor)
+	}
+
+	for _, alpha := range []float64{0, -0.1, 1.5} {
+		if err := r.SetDiffBackgroundAlpha(alpha); err == nil {
+			t.Errorf("expected an error for alpha %g", alpha)
+		}
+	}
+}
+
+func TestThemeCheck(t *testing.T) {
+	r, err := themes.NewRegistry()
+	if err != nil {
+		t.Fatalf("failed to create registry: %v", err)
+	}
+
+	theme := &themes.Theme{
+		Theme: map[string]map[string]string{
+			"text":        {"dark": "#ffffff", "light": "#000000"},
+			"diffAdded":   {"dark": "#00ff00"},
+			"diffRemoved": {"dark": "mix(#ff0000, 0.5)", "light": "#ff0000", "dim": "#800000"},
+			"diffAdd":     {"dark": "#00ff00", "light": "#00ff00"},
+		},
+	}
+	report := r.Check(theme)
+	if report.OK() {
+		t.Error("expected the report to fail")
+	}
+	if got := strings.Join(report.Unknown, ","); got != "diffAdd,diffRemoved.dim" {
+		t.Errorf("unknown keys %q", got)
+	}
+	missing := strings.Join(report.Missing, ",")
+	if !strings.Contains(missing, "diffAdded (light)") || !strings.Contains(missing, "textMuted") || strings.Contains(missing, "diffAddedBg") {
+		t.Errorf("missing keys %q", missing)
+	}
+	if len(report.Errors) == 0 || !strings.HasPrefix(report.Errors[0], "diffRemoved (dark)") {
+		t.Errorf("errors %q", report.Errors)
+	}
+}
+
+func TestMissingColorsInheritBase(t *testing.T) {
+	r, err := themes.NewRegistry()
+	if err != nil {
+		t.Fatalf("failed to create registry: %v", err)
+	}
+	base, err := r.Resolve(themes.BaseTheme)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "partial.json")
+	data := `{"theme": {"text": {"dark": "#123456", "light": "#123456"}, "cursor": {"dark": "syntaxKeyword", "light": "syntaxKeyword"}}}`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// Set loads theme files by path
+	if err := r.Set(path); err != nil {
+		t.Fatalf("failed to set theme file: %v", err)
+	}
+	colors := r.Current()
+	if colors.Text != "#123456" {
+		t.Errorf("expected the theme's text color, got %s", colors.Text)
+	}
+	if colors.DiffRemoved != base.DiffRemoved || colors.SyntaxType != base.SyntaxType {
+		t.Errorf("expected colors left out to come from %s, got %s and %s", themes.BaseTheme, colors.DiffRemoved, colors.SyntaxType)
+	}
+	if colors.Cursor != base.SyntaxKeyword {
+		t.Errorf("expected references to colors left out to resolve from %s, got %s", themes.BaseTheme, colors.Cursor)
+	}
+	if _, err := r.Resolve("partial"); err != nil {
+		t.Errorf("expected the theme registered as partial: %v", err)
+	}
+}
+
+func TestParseTerminalColors(t *testing.T) {
+	// Answers end with ST or BEL, with channels of four or two digits,
+	// followed by the device attributes
+	data := []byte("\x1b]10;rgb:f8f8/f8f8/f2f2\x1b\\\x1b]11;rgb:28/2a/36\x07\x1b[?62;22c")
+	colors := themes.ParseTerminalColors(data)
+	if colors.Foreground != "#f8f8f2" || colors.Background != "#282a36" {
+		t.Errorf("unexpected colors %+v", colors)
+	}
+
+	// Terminals that don't support the queries only answer the last one
+	if colors := themes.ParseTerminalColors([]byte("\x1b[?1;2c")); colors != (themes.TerminalColors{}) {
+		t.Errorf("expected no colors, got %+v", colors)
+	}
+}
+
+func TestAutoDiffBackgroundAlpha(t *testing.T) {
+	dark := themes.AutoDiffBackgroundAlpha("#000000")
+	light := themes.AutoDiffBackgroundAlpha("#ffffff")
+	if dark != themes.DefaultDiffBackgroundAlpha || light <= dark {
+		t.Errorf("expected light backgrounds to take more tint, got %g on black and %g on white", dark, light)
+	}
+	if alpha := themes.AutoDiffBackgroundAlpha(""); alpha != themes.DefaultDiffBackgroundAlpha {
+		t.Errorf("expected the default for an unknown background, got %g", alpha)
+	}
+}
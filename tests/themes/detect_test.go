@@ -0,0 +1,30 @@
+package themes_test
+
+import (
+	"testing"
+
+	"github.com/avgvstvs96/differential/internal/themes"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	for _, tc := range []struct {
+		lines []string
+		want  string
+	}{
+		{[]string{"#!/usr/bin/env python3"}, "Python"},
+		{[]string{"#!/usr/bin/env -S node --no-warnings"}, "JavaScript"},
+		{[]string{"#!/bin/sh", "set -e"}, "Bash"},
+		{[]string{"#!/usr/bin/ruby2.7"}, "Ruby"},
+		{[]string{"", "# vim: set ft=python:"}, "Python"},
+		{[]string{"# -*- mode: ruby; coding: utf-8 -*-"}, "Ruby"},
+		{[]string{";; -*- emacs-lisp -*-"}, "EmacsLisp"},
+		{[]string{"# -*- coding: utf-8 -*-"}, ""},
+		{[]string{"#!/opt/unknown-interpreter"}, ""},
+		{[]string{"plain text"}, ""},
+		{nil, ""},
+	} {
+		if got := themes.DetectLanguage(tc.lines); got != tc.want {
+			t.Errorf("DetectLanguage(%q) = %q, want %q", tc.lines, got, tc.want)
+		}
+	}
+}
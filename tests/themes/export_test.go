@@ -0,0 +1,120 @@
+package themes_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/avgvstvs96/differential/internal/themes"
+)
+
+// gruvboxScheme is a base16 scheme in the older format, without a palette
+// section or # before the colors
+const gruvboxScheme = `scheme: "Gruvbox dark, hard"
+author: "Dawid Kurek"
+base00: "1d2021"
+base01: "3c3836"
+base02: "504945"
+base03: "665c54"
+base04: "bdae93"
+base05: "d5c4a1"
+base06: "ebdbb2"
+base07: "fbf1c7"
+base08: "fb4934"
+base09: "fe8019"
+base0A: "fabd2f"
+base0B: "b8bb26"
+base0C: "8ec07c"
+base0D: "83a598"
+base0E: "d3869b"
+base0F: "d65d0e"
+`
+
+func TestParseBase16(t *testing.T) {
+	theme, err := themes.ParseBase16([]byte(gruvboxScheme))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if theme.Name != "gruvbox-dark-hard" {
+		t.Errorf("expected the theme to be named gruvbox-dark-hard, got %q", theme.Name)
+	}
+
+	r, err := themes.NewRegistry()
+	if err != nil {
+		t.Fatalf("failed to create registry: %v", err)
+	}
+	if report := r.Check(theme); !report.OK() || len(report.Missing) > 0 {
+		t.Errorf("expected a complete theme, got %+v", report)
+	}
+	r.Register(theme)
+	colors, err := r.Resolve(theme.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if colors.Background != "#1d2021" || colors.DiffAdded != "#b8bb26" || colors.SyntaxKeyword != "#d3869b" {
+		t.Errorf("unexpected colors %s, %s and %s", colors.Background, colors.DiffAdded, colors.SyntaxKeyword)
+	}
+
+	for name, scheme := range map[string]string{
+		"missing color": strings.Replace(gruvboxScheme, "base0F", "base0G", 1),
+		"invalid color": strings.Replace(gruvboxScheme, "d65d0e", "d65d0", 1),
+		"other system":  "system: \"base32\"\n" + gruvboxScheme,
+	} {
+		if _, err := themes.ParseBase16([]byte(scheme)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestExport(t *testing.T) {
+	r, err := themes.NewRegistry()
+	if err != nil {
+		t.Fatalf("failed to create registry: %v", err)
+	}
+	nord, err := r.Resolve("nord")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A base16 scheme imports back to the same main colors
+	scheme, err := themes.Export(nord, "nord", "base16")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	theme, err := themes.ParseBase16([]byte(scheme))
+	if err != nil {
+		t.Fatalf("failed to import the exported scheme: %v\n%s", err, scheme)
+	}
+	r.Register(theme)
+	imported, err := r.Resolve(theme.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imported.Background != nord.Background || imported.Text != nord.Text || imported.DiffRemoved != nord.DiffRemoved {
+		t.Errorf("expected nord's colors, got %+v", imported)
+	}
+
+	style, err := themes.Export(nord, "nord", "chroma")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := chroma.NewXMLStyle(strings.NewReader(style))
+	if err != nil {
+		t.Fatalf("exported Chroma style doesn't parse: %v", err)
+	}
+	if parsed.Name != "nord" {
+		t.Errorf("expected the style to be named nord, got %q", parsed.Name)
+	}
+
+	delta, err := themes.Export(nord, "nord", "delta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(delta, `[delta "differential-nord"]`) || !strings.Contains(delta, `plus-style = syntax "`+string(nord.DiffAddedBg)+`"`) {
+		t.Errorf("unexpected delta config:\n%s", delta)
+	}
+
+	if _, err := themes.Export(nord, "nord", "vim"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,178 @@
+package themes_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/avgvstvs96/differential/internal/themes"
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestRegistryResolve(t *testing.T) {
+	r, err := themes.NewRegistry()
+	if err != nil {
+		t.Fatalf("failed to create registry: %v", err)
+	}
+
+	current := r.Current()
+	nord, err := r.Resolve("nord")
+	if err != nil {
+		t.Fatalf("failed to resolve nord: %v", err)
+	}
+	if nord.Background == current.Background {
+		t.Error("expected nord to differ from the default theme")
+	}
+	if r.Current() != current {
+		t.Error("Resolve changed the active theme")
+	}
+	if _, err := r.Resolve("nonexistent"); err == nil {
+		t.Error("expected an error for an unknown theme")
+	}
+}
+
+func TestRegistryRegister(t *testing.T) {
+	r, err := themes.NewRegistry()
+	if err != nil {
+		t.Fatalf("failed to create registry: %v", err)
+	}
+
+	r.Register(&themes.Theme{
+		Name:  "plain",
+		Defs:  map[string]string{"ink": "#123456"},
+		Theme: map[string]map[string]string{"text": {"dark": "ink", "light": "ink"}},
+	})
+	if err := r.Set("plain"); err != nil {
+		t.Fatalf("failed to set registered theme: %v", err)
+	}
+	if got := r.Current().Text; got != "#123456" {
+		t.Errorf("expected text color #123456, got %s", got)
+	}
+}
+
+func TestRegistrySetDark(t *testing.T) {
+	r, err := themes.NewRegistry()
+	if err != nil {
+		t.Fatalf("failed to create registry: %v", err)
+	}
+
+	r.Register(&themes.Theme{
+		Name:  "shades",
+		Theme: map[string]map[string]string{"text": {"dark": "#eeeeee", "light": "#111111"}},
+	})
+	for _, tt := range []struct {
+		dark bool
+		want string
+	}{{true, "#eeeeee"}, {false, "#111111"}} {
+		r.SetDark(tt.dark)
+		colors, err := r.Resolve("shades")
+		if err != nil {
+			t.Fatalf("failed to resolve theme: %v", err)
+		}
+		if colors.Text != lipgloss.Color(tt.want) {
+			t.Errorf("SetDark(%v): expected text color %s, got %s", tt.dark, tt.want, colors.Text)
+		}
+	}
+}
+
+func TestRegistryConcurrentUse(t *testing.T) {
+	r, err := themes.NewRegistry()
+	if err != nil {
+		t.Fatalf("failed to create registry: %v", err)
+	}
+	names := r.List()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if err := r.Set(names[(i+j)%len(names)]); err != nil {
+					t.Error(err)
+					return
+				}
+				if r.Current() == nil {
+					t.Error("expected a current theme")
+					return
+				}
+				r.List()
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestRegistryExtends(t *testing.T) {
+	r, err := themes.NewRegistry()
+	if err != nil {
+		t.Fatalf("failed to create registry: %v", err)
+	}
+	nord, err := r.Resolve("nord")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r.Register(&themes.Theme{
+		Name:    "arctic",
+		Extends: "nord",
+		Theme:   map[string]map[string]string{"diffAdded": {"dark": "#00ff00", "light": "#00ff00"}},
+	})
+	r.Register(&themes.Theme{
+		Name:    "glacier",
+		Extends: "arctic",
+		Theme:   map[string]map[string]string{"text": {"dark": "#eeeeee", "light": "#111111"}},
+	})
+	glacier, err := r.Resolve("glacier")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if glacier.Text != "#eeeeee" || glacier.DiffAdded != "#00ff00" {
+		t.Errorf("expected the colors of glacier and arctic, got %s and %s", glacier.Text, glacier.DiffAdded)
+	}
+	// Colors derived in nord stay nord's rather than being derived again
+	if glacier.Background != nord.Background || glacier.DiffAddedBg != nord.DiffAddedBg {
+		t.Errorf("expected nord's other colors, got %s and %s", glacier.Background, glacier.DiffAddedBg)
+	}
+	if report := r.Check(&themes.Theme{Name: "x", Extends: "arctic"}); report.Base != "arctic" || !report.OK() {
+		t.Errorf("unexpected report %+v", report)
+	}
+
+	for name, theme := range map[string]*themes.Theme{
+		"unknown": {Name: "lost", Extends: "missing"},
+		"cycle":   {Name: "arctic", Extends: "glacier"},
+	} {
+		if report := r.Check(theme); report.OK() {
+			t.Errorf("%s: expected the check to fail", name)
+		}
+	}
+}
+
+func TestRegistryOverrides(t *testing.T) {
+	r, err := themes.NewRegistry()
+	if err != nil {
+		t.Fatalf("failed to create registry: %v", err)
+	}
+
+	if err := r.SetOverrides(map[string]string{"diffAddedBg": "#123123", "cursor": "diffAdded"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, name := range []string{"dracula", "nord"} {
+		if err := r.Set(name); err != nil {
+			t.Fatalf("failed to set %s: %v", name, err)
+		}
+		colors := r.Current()
+		if colors.DiffAddedBg != "#123123" || colors.Cursor != colors.DiffAdded {
+			t.Errorf("%s: expected the overrides, got %s and %s", name, colors.DiffAddedBg, colors.Cursor)
+		}
+	}
+
+	if err := r.SetOverrides(map[string]string{"diffAddBg": "#123123"}); err == nil {
+		t.Error("expected an error for an unknown key")
+	}
+	if err := r.SetOverrides(map[string]string{"cursor": "mix(diffAdded, 0.5)"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Set("nord"); err == nil {
+		t.Error("expected an error for an override that doesn't resolve")
+	}
+}
@@ -3,6 +3,7 @@ package themes_test
 import (
 	"testing"
 
+	"github.com/alecthomas/chroma/v2"
 	"github.com/avgvstvs96/differential/internal/themes"
 )
 
@@ -104,4 +105,20 @@ func TestGetCurrentTheme(t *testing.T) {
 	if theme.DiffRemoved == "" {
 		t.Error("expected DiffRemoved color to be set")
 	}
-}
\ No newline at end of file
+}
+
+func TestPrintTheme(t *testing.T) {
+	theme := themes.PrintTheme()
+	if theme.Background != "" || theme.DiffAddedBg != "" || theme.DiffRemovedBg != "" {
+		t.Errorf("print theme should have no backgrounds: %+v", theme)
+	}
+
+	// Syntax highlighting must not fall back to a dark style
+	style, err := themes.ChromaStyle(theme)
+	if err != nil {
+		t.Fatalf("ChromaStyle: %v", err)
+	}
+	if text := style.Get(chroma.Text); text.Colour.String() != "#000000" || text.Background.IsSet() {
+		t.Errorf("text style = %v, want black on no background", text)
+	}
+}